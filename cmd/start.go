@@ -128,6 +128,10 @@ func Start(bootstrapConfigPath string) {
 		log.Warnf("init trace provider failed: %v", err)
 	}
 
+	if err := discovery.InitAudit(context.Background()); err != nil {
+		log.Warnf("init auditor failed: %v", err)
+	}
+
 	if err := discovery.InitSupervisor(context.Background()); err != nil {
 		log.Warnf("init supervisor failed: %v", err)
 	}
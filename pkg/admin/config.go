@@ -32,14 +32,15 @@ type GroupDTO struct {
 }
 
 type TableDTO struct {
-	Name           string            `json:"name,omitempty"`
-	Sequence       *config.Sequence  `json:"sequence,omitempty"`
-	AllowFullScan  bool              `json:"allow_full_scan,omitempty"`
-	DbRules        []*config.Rule    `json:"db_rules,omitempty"`
-	TblRules       []*config.Rule    `json:"tbl_rules,omitempty"`
-	Topology       *config.Topology  `json:"topology,omitempty"`
-	ShadowTopology *config.Topology  `json:"shadow_topology,omitempty"`
-	Attributes     map[string]string `json:"attributes,omitempty"`
+	Name             string                    `json:"name,omitempty"`
+	Sequence         *config.Sequence          `json:"sequence,omitempty"`
+	AllowFullScan    bool                      `json:"allow_full_scan,omitempty"`
+	DbRules          []*config.Rule            `json:"db_rules,omitempty"`
+	TblRules         []*config.Rule            `json:"tbl_rules,omitempty"`
+	GeneratedColumns []*config.GeneratedColumn `json:"generated_columns,omitempty"`
+	Topology         *config.Topology          `json:"topology,omitempty"`
+	ShadowTopology   *config.Topology          `json:"shadow_topology,omitempty"`
+	Attributes       map[string]string         `json:"attributes,omitempty"`
 }
 
 type TenantDTO struct {
@@ -247,13 +247,14 @@ func (cs *MyConfigService) ListTables(ctx context.Context, tenant, cluster strin
 		}
 
 		ret = append(ret, &TableDTO{
-			Name:           tbl,
-			Sequence:       next.Sequence,
-			DbRules:        next.DbRules,
-			TblRules:       next.TblRules,
-			Topology:       next.Topology,
-			ShadowTopology: next.ShadowTopology,
-			Attributes:     next.Attributes,
+			Name:             tbl,
+			Sequence:         next.Sequence,
+			DbRules:          next.DbRules,
+			TblRules:         next.TblRules,
+			GeneratedColumns: next.GeneratedColumns,
+			Topology:         next.Topology,
+			ShadowTopology:   next.ShadowTopology,
+			Attributes:       next.Attributes,
 		})
 	}
 
@@ -631,6 +632,7 @@ func (cs *MyConfigService) UpsertTable(ctx context.Context, tenant, cluster, tab
 			tableCfg.Sequence = body.Sequence
 			tableCfg.DbRules = body.DbRules
 			tableCfg.TblRules = body.TblRules
+			tableCfg.GeneratedColumns = body.GeneratedColumns
 			tableCfg.Topology = body.Topology
 			tableCfg.ShadowTopology = body.ShadowTopology
 			tableCfg.Attributes = body.Attributes
@@ -640,13 +642,14 @@ func (cs *MyConfigService) UpsertTable(ctx context.Context, tenant, cluster, tab
 	}
 	if !exist {
 		newTable := &config.Table{
-			Name:           cluster + "." + table,
-			Sequence:       body.Sequence,
-			DbRules:        body.DbRules,
-			TblRules:       body.TblRules,
-			Topology:       body.Topology,
-			ShadowTopology: body.ShadowTopology,
-			Attributes:     body.Attributes,
+			Name:             cluster + "." + table,
+			Sequence:         body.Sequence,
+			DbRules:          body.DbRules,
+			TblRules:         body.TblRules,
+			GeneratedColumns: body.GeneratedColumns,
+			Topology:         body.Topology,
+			ShadowTopology:   body.ShadowTopology,
+			Attributes:       body.Attributes,
 		}
 		newTables = append(newTables, newTable)
 	}
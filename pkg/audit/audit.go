@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/config"
+	"github.com/arana-db/arana/pkg/metrics"
+	"github.com/arana-db/arana/pkg/util/log"
+)
+
+// Record represents the audit record of a statement routed by arana.
+type Record struct {
+	Time     time.Time     `json:"time"`
+	ConnID   uint32        `json:"conn_id"`
+	Tenant   string        `json:"tenant"`
+	Username string        `json:"username"`
+	Schema   string        `json:"schema"`
+	SQL      string        `json:"sql"`
+	Write    bool          `json:"write"`
+	TxID     string        `json:"tx_id,omitempty"`
+	Shards   []Shard       `json:"shards"`
+	Elapsed  time.Duration `json:"elapsed"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Shard represents a physical statement sent to the database.
+type Shard struct {
+	DB  string `json:"db"`
+	SQL string `json:"sql"`
+}
+
+// Auditor receives the audit records, it is called in a dedicated goroutine one record at a time.
+type Auditor interface {
+	Audit(r *Record)
+}
+
+// _dropWarnInterval limits the frequency of warnings about the dropped records.
+const _dropWarnInterval = 10 * time.Second
+
+// Factory creates an Auditor with the options.
+type Factory func(options map[string]interface{}) (Auditor, error)
+
+var (
+	factories = make(map[string]Factory, 4)
+	current   atomic.Pointer[dispatcher]
+	once      sync.Once
+)
+
+// Register registers the factory of Auditor with the type name.
+func Register(typ string, factory Factory) {
+	factories[typ] = factory
+}
+
+// Initialize creates the configured Auditor, and starts dispatching the audit records to it.
+func Initialize(cfg *config.Audit) error {
+	var err error
+	once.Do(func() {
+		factory, ok := factories[cfg.Type]
+		if !ok {
+			err = errors.Errorf("not supported %s auditor", cfg.Type)
+			return
+		}
+		var auditor Auditor
+		if auditor, err = factory(cfg.Options); err != nil {
+			err = errors.Wrapf(err, "failed to create %s auditor", cfg.Type)
+			return
+		}
+		Use(auditor, cfg.BufferSize)
+	})
+	return err
+}
+
+// Use dispatches the audit records to the auditor, the previous one will be stopped after its pending records are sent.
+func Use(auditor Auditor, bufferSize int) {
+	next := newDispatcher(auditor, bufferSize)
+	if prev := current.Swap(next); prev != nil {
+		prev.close()
+	}
+}
+
+// Enabled returns true if the audit records should be submitted.
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Submit sends the audit record to the auditor asynchronously, the record will be dropped if the buffer is full.
+func Submit(r *Record) {
+	if d := current.Load(); d != nil {
+		d.submit(r)
+	}
+}
+
+// Dropped returns the number of audit records dropped by current auditor since the buffer is full.
+func Dropped() uint64 {
+	if d := current.Load(); d != nil {
+		return d.dropped.Load()
+	}
+	return 0
+}
+
+type dispatcher struct {
+	auditor  Auditor
+	records  chan *Record
+	done     chan struct{}
+	mu       sync.RWMutex
+	closed   bool
+	dropped  atomic.Uint64
+	lastWarn atomic.Int64 // the unix nano of last warning about the dropped records
+}
+
+func newDispatcher(auditor Auditor, bufferSize int) *dispatcher {
+	d := &dispatcher{
+		auditor: auditor,
+		records: make(chan *Record, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go d.loop()
+	return d
+}
+
+func (d *dispatcher) submit(r *Record) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	select {
+	case d.records <- r:
+	default:
+		metrics.AuditDropped.Inc()
+		d.warnDropped(d.dropped.Add(1))
+	}
+}
+
+func (d *dispatcher) warnDropped(dropped uint64) {
+	now, last := time.Now().UnixNano(), d.lastWarn.Load()
+	if now-last < int64(_dropWarnInterval) || !d.lastWarn.CompareAndSwap(last, now) {
+		return
+	}
+	log.Warnf("audit buffer is full, %d records are dropped totally, please increase the buffer size", dropped)
+}
+
+func (d *dispatcher) close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.records)
+	}
+	d.mu.Unlock()
+	<-d.done
+}
+
+func (d *dispatcher) loop() {
+	defer close(d.done)
+	for r := range d.records {
+		d.audit(r)
+	}
+}
+
+func (d *dispatcher) audit(r *Record) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("failed to audit statement: sql=%s, err=%v", r.SQL, err)
+		}
+	}()
+	d.auditor.Audit(r)
+}
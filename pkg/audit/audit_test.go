@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/config"
+)
+
+type fakeAuditor struct {
+	block   chan struct{}
+	records chan *Record
+}
+
+func (fa *fakeAuditor) Audit(r *Record) {
+	if fa.block != nil {
+		<-fa.block
+	}
+	fa.records <- r
+}
+
+func TestSubmit(t *testing.T) {
+	defer current.Store(nil)
+
+	Submit(&Record{SQL: "SELECT 1"})
+	assert.False(t, Enabled())
+	assert.Zero(t, Dropped())
+
+	fa := &fakeAuditor{records: make(chan *Record, 8)}
+	Use(fa, 8)
+	assert.True(t, Enabled())
+
+	Submit(&Record{SQL: "SELECT 1", TxID: "tx-1"})
+	Submit(&Record{SQL: "UPDATE student SET age = 18", Write: true})
+
+	select {
+	case r := <-fa.records:
+		assert.Equal(t, "SELECT 1", r.SQL)
+		assert.Equal(t, "tx-1", r.TxID)
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "audit record is not received")
+	}
+	select {
+	case r := <-fa.records:
+		assert.True(t, r.Write)
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "audit record is not received")
+	}
+}
+
+func TestSubmit_BufferFull(t *testing.T) {
+	defer current.Store(nil)
+
+	fa := &fakeAuditor{
+		block:   make(chan struct{}),
+		records: make(chan *Record, 8),
+	}
+	Use(fa, 1)
+
+	// never blocked even if the auditor is slow
+	for i := 0; i < 4; i++ {
+		Submit(&Record{SQL: "SELECT 1"})
+	}
+	// the records are dropped except the pending and the auditing one
+	assert.GreaterOrEqual(t, Dropped(), uint64(2))
+	assert.LessOrEqual(t, Dropped(), uint64(3))
+	close(fa.block)
+
+	// the pending records are sent before the dispatcher is stopped
+	current.Load().close()
+	assert.GreaterOrEqual(t, len(fa.records), 1)
+	assert.LessOrEqual(t, len(fa.records), 2)
+}
+
+func TestInitialize(t *testing.T) {
+	err := Initialize(&config.Audit{Type: "not-exists"})
+	assert.Error(t, err)
+}
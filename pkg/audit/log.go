@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"go.uber.org/zap"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/util/log"
+)
+
+// Log is the type of Auditor which writes the audit records into the file 'audit.log' of the option 'path'.
+const Log = "log"
+
+func init() {
+	Register(Log, newLogAuditor)
+}
+
+type logAuditor struct {
+	logger *zap.Logger
+}
+
+func newLogAuditor(options map[string]interface{}) (Auditor, error) {
+	cfg := log.DefaultConfig()
+	if path, ok := options["path"].(string); ok && len(path) > 0 {
+		cfg.Path = path
+	}
+	return &logAuditor{
+		logger: log.NewAuditLogger(cfg.Path, cfg),
+	}, nil
+}
+
+func (la *logAuditor) Audit(r *Record) {
+	la.logger.Info("audit",
+		zap.Time("time", r.Time),
+		zap.Uint32("conn_id", r.ConnID),
+		zap.String("tenant", r.Tenant),
+		zap.String("username", r.Username),
+		zap.String("schema", r.Schema),
+		zap.String("sql", r.SQL),
+		zap.Bool("write", r.Write),
+		zap.String("tx_id", r.TxID),
+		zap.Any("shards", r.Shards),
+		zap.Duration("elapsed", r.Elapsed),
+		zap.String("error", r.Error),
+	)
+}
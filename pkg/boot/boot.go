@@ -221,6 +221,9 @@ func buildNamespace(ctx context.Context, tenant string, provider Discovery, clus
 		namespace.UpdateSlowLogger(provider.GetOptions().SlowLogPath, provider.GetOptions().Logging),
 		namespace.UpdateParameters(cluster.Parameters),
 		namespace.UpdateSlowThreshold(),
+		namespace.UpdateSlowSampleRate(),
+		namespace.UpdateReadRetries(),
+		namespace.UpdateQueryMemoryLimit(),
 	}
 
 	for _, group := range groups {
@@ -260,6 +263,24 @@ func buildNamespace(ctx context.Context, tenant string, provider Discovery, clus
 		log.Infof("add logical table '%s' success", table)
 	}
 
+	var policies []*rule.StatementPolicy
+	if policies, err = provider.ListPolicies(ctx, tenant, clusterName); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ru.SetPolicies(policies)
+
+	var views []*rule.View
+	if views, err = provider.ListViews(ctx, tenant, clusterName); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ru.SetViews(views)
+
+	var routes []*rule.SchemaRoute
+	if routes, err = provider.ListSchemaRoutes(ctx, tenant, clusterName); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ru.SetSchemaRoutes(routes)
+
 	initCmds = append(initCmds, namespace.UpdateRule(&ru))
 
 	return namespace.New(clusterName, initCmds...)
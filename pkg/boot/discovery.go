@@ -30,6 +30,7 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/audit"
 	"github.com/arana-db/arana/pkg/config"
 	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/security"
@@ -189,6 +190,16 @@ func (fp *discovery) InitTrace(ctx context.Context) error {
 	return trace.Initialize(ctx, fp.options.Trace)
 }
 
+func (fp *discovery) InitAudit(ctx context.Context) error {
+	if fp.options.Audit == nil || len(fp.options.Audit.Type) < 1 {
+		return nil
+	}
+	if err := defaults.Set(fp.options.Audit); err != nil {
+		return err
+	}
+	return audit.Initialize(fp.options.Audit)
+}
+
 func (fp *discovery) InitSupervisor(ctx context.Context) error {
 	if fp.options.Supervisor != nil {
 		security.DefaultTenantManager().SetSupervisor(fp.options.Supervisor)
@@ -340,6 +351,84 @@ func (fp *discovery) GetTable(ctx context.Context, tenant, cluster, tableName st
 	return config.MakeVTable(tableName, table)
 }
 
+func (fp *discovery) ListPolicies(ctx context.Context, tenant, cluster string) ([]*rule.StatementPolicy, error) {
+	op, ok := fp.centers[tenant]
+	if !ok {
+		return nil, ErrorNoTenant
+	}
+
+	cfg, err := op.LoadAll(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ShardingRule == nil {
+		return nil, nil
+	}
+
+	var policies []*rule.StatementPolicy
+	for _, it := range cfg.ShardingRule.Policies {
+		policy, err := config.MakeStatementPolicy(cluster, it)
+		if err != nil {
+			return nil, err
+		}
+		if policy == nil {
+			continue
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+func (fp *discovery) ListViews(ctx context.Context, tenant, cluster string) ([]*rule.View, error) {
+	op, ok := fp.centers[tenant]
+	if !ok {
+		return nil, ErrorNoTenant
+	}
+
+	cfg, err := op.LoadAll(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ShardingRule == nil {
+		return nil, nil
+	}
+
+	var views []*rule.View
+	for _, it := range cfg.ShardingRule.Views {
+		view, err := config.MakeView(cluster, it)
+		if err != nil {
+			return nil, err
+		}
+		if view == nil {
+			continue
+		}
+		views = append(views, view)
+	}
+
+	return views, nil
+}
+
+func (fp *discovery) ListSchemaRoutes(ctx context.Context, tenant, cluster string) ([]*rule.SchemaRoute, error) {
+	op, ok := fp.centers[tenant]
+	if !ok {
+		return nil, ErrorNoTenant
+	}
+
+	cfg, err := op.LoadAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ShardingRule == nil {
+		return nil, nil
+	}
+
+	return config.MakeSchemaRoutes(cluster, cfg.ShardingRule.Schemas)
+}
+
 func (fp *discovery) loadCluster(tenant, cluster string) (*config.DataSourceCluster, error) {
 	op, ok := fp.centers[tenant]
 	if !ok {
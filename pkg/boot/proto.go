@@ -70,6 +70,15 @@ type ConfigProvider interface {
 	// GetTable returns the table info.
 	GetTable(ctx context.Context, tenant, cluster, table string) (*rule.VTable, error)
 
+	// ListPolicies lists the statement policies.
+	ListPolicies(ctx context.Context, tenant, cluster string) ([]*rule.StatementPolicy, error)
+
+	// ListViews lists the views.
+	ListViews(ctx context.Context, tenant, cluster string) ([]*rule.View, error)
+
+	// ListSchemaRoutes lists the routes of physical schemas.
+	ListSchemaRoutes(ctx context.Context, tenant, cluster string) ([]*rule.SchemaRoute, error)
+
 	// GetSysDB return the arana sys db
 	GetSysDB(ctx context.Context, tenant string) (*config.Node, error)
 
@@ -120,6 +129,9 @@ type Discovery interface {
 	// InitTrace distributed tracing
 	InitTrace(ctx context.Context) error
 
+	// InitAudit initializes the auditor of statements
+	InitAudit(ctx context.Context) error
+
 	// InitTenant initializes tenant (just a workaround, TBD)
 	InitTenant(tenant string) error
 
@@ -31,6 +31,7 @@ import (
 
 import (
 	"github.com/arana-db/arana/pkg/config"
+	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/runtime"
 	"github.com/arana-db/arana/pkg/runtime/namespace"
@@ -165,6 +166,11 @@ L:
 					log.Errorf("[%s] handle event TABLE-CHG failed: %v", d.tenant, err)
 				}
 			}
+			if item.UpdateSchemas {
+				if err := d.onSchemaRoutesChange(ctx, item.Schemas); err != nil {
+					log.Errorf("[%s] handle event SCHEMA-ROUTE-CHG failed: %v", d.tenant, err)
+				}
+			}
 		case item := <-chShadowRules:
 			// TODO: need implementation
 			_ = item
@@ -370,6 +376,25 @@ func (d *watcher) onClusterAdd(ctx context.Context, cluster *config.DataSourceCl
 		}
 		ru.SetVTable(table, vt)
 	}
+
+	policies, err := d.discovery.ListPolicies(ctx, d.tenant, cluster.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ru.SetPolicies(policies)
+
+	views, err := d.discovery.ListViews(ctx, d.tenant, cluster.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ru.SetViews(views)
+
+	routes, err := d.discovery.ListSchemaRoutes(ctx, d.tenant, cluster.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ru.SetSchemaRoutes(routes)
+
 	cmds = append(cmds, namespace.UpdateRule(&ru))
 	ns, err := namespace.New(cluster.Name, cmds...)
 	if err != nil {
@@ -447,7 +472,7 @@ func (d *watcher) onNodeUpdate(ctx context.Context, node *config.Node) error {
 
 	paramsMap := make(map[string]config.ParametersMap)
 
-	updateNode := func(rt runtime.Runtime, cluster, group string) {
+	updateNode := func(rt runtime.Runtime, cluster, group string, db proto.DB) {
 		clonedNode := *node
 
 		newParams := make(config.ParametersMap)
@@ -467,6 +492,20 @@ func (d *watcher) onNodeUpdate(ctx context.Context, node *config.Node) error {
 		}
 		clonedNode.Parameters.Merge(params)
 
+		// update the weight only, no need to rebuild the connection pool, eg: drain a replica by weight r0w0
+		if runtime.IsWeightChangedOnly(db, &clonedNode) {
+			r, w, err := clonedNode.GetReadAndWriteWeight()
+			if err != nil {
+				log.Errorf("invalid weight '%s' of node '%s': %v", clonedNode.Weight, clonedNode.Name, err)
+				return
+			}
+			weight := proto.Weight{R: int32(r), W: int32(w)}
+			if err := rt.Namespace().EnqueueCommand(namespace.UpdateWeight(group, clonedNode.Name, weight)); err != nil {
+				log.Errorf("failed to enqueue update weight: %v", err)
+			}
+			return
+		}
+
 		if err := rt.Namespace().EnqueueCommand(namespace.UpsertDB(group, runtime.NewAtomDB(&clonedNode))); err != nil {
 			log.Errorf("failed to enqueue update node: %v", err)
 		}
@@ -485,7 +524,7 @@ func (d *watcher) onNodeUpdate(ctx context.Context, node *config.Node) error {
 				if db.ID() != node.Name {
 					continue
 				}
-				updateNode(rt, cluster, group)
+				updateNode(rt, cluster, group, db)
 			}
 		}
 	}
@@ -511,11 +550,15 @@ func (d *watcher) onTableDel(_ context.Context, name string) error {
 		return nil
 	}
 
-	if !ns.Rule().RemoveVTable(tbl) {
+	if !ns.Rule().Has(tbl) {
 		log.Warnf("[%s] ignore TABLE-DEL: no such table '%s.%s'", d.tenant, db, tbl)
 		return nil
 	}
 
+	if err := ns.EnqueueCommand(namespace.RemoveVTable(tbl)); err != nil {
+		return errors.WithStack(err)
+	}
+
 	log.Infof("[%s] TABLE-DEL: remove virtual table '%s.%s' successfully", d.tenant, db, tbl)
 
 	return nil
@@ -542,7 +585,9 @@ func (d *watcher) onTableAdd(_ context.Context, table *config.Table) error {
 		return errors.WithStack(err)
 	}
 
-	ns.Rule().SetVTable(tbl, vtab)
+	if err := ns.EnqueueCommand(namespace.UpsertVTable(tbl, vtab)); err != nil {
+		return errors.WithStack(err)
+	}
 	return nil
 }
 
@@ -566,7 +611,28 @@ func (d *watcher) onTableChange(_ context.Context, table *config.Table) error {
 		return errors.WithStack(err)
 	}
 
-	ns.Rule().SetVTable(tbl, vtab)
+	if err := ns.EnqueueCommand(namespace.UpsertVTable(tbl, vtab)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
 
+func (d *watcher) onSchemaRoutesChange(_ context.Context, schemas []*config.SchemaRoute) error {
+	for _, cluster := range security.DefaultTenantManager().GetClusters(d.tenant) {
+		ns := namespace.Load(d.tenant, cluster)
+		if ns == nil {
+			log.Warnf("[%s] SCHEMA-ROUTE-CHG: no such namespace '%s'", d.tenant, cluster)
+			continue
+		}
+
+		routes, err := config.MakeSchemaRoutes(cluster, schemas)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := ns.EnqueueCommand(namespace.UpdateSchemaRoutes(routes)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 	return nil
 }
@@ -31,9 +31,16 @@ import (
 
 import (
 	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/util/bloom"
 	"github.com/arana-db/arana/pkg/util/math"
 )
 
+// defaultKeyFilterCapacity is the default expected amount of keys of each physical table.
+const defaultKeyFilterCapacity = 1000000
+
+// defaultKeyLookupCapacity is the default max amount of cached mappings of lookup column.
+const defaultKeyLookupCapacity = 10000
+
 var (
 	_regexpTopology     *regexp.Regexp
 	_regexpTopologyOnce sync.Once
@@ -137,17 +144,86 @@ func MakeVTable(tableName string, table *Table) (*rule.VTable, error) {
 		var vs rule.VShard
 		if i < len(dbSm) {
 			vs.DB = dbSm[i]
+			vs.Name = table.DbRules[i].Name
 		}
 		if i < len(tbSm) {
 			vs.Table = tbSm[i]
+			if name := table.TblRules[i].Name; len(name) > 0 {
+				if len(vs.Name) > 0 && vs.Name != name {
+					return nil, errors.Errorf("conflict names of sharding strategy: %s, %s", vs.Name, name)
+				}
+				vs.Name = name
+			}
 		}
 		vt.AddVShards(&vs)
 	}
 
+	for _, next := range table.GeneratedColumns {
+		gc, err := toGeneratedColumn(next, defaultSteps)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse generated column '%s'", next.Name)
+		}
+		vt.AddGeneratedColumn(gc)
+	}
+
 	allowFullScan, err := strconv.ParseBool(table.Attributes["allow_full_scan"])
 	if err == nil && allowFullScan {
 		vt.SetAllowFullScan(true)
 	}
+	orderByPrimaryKey, err := strconv.ParseBool(table.Attributes["order_by_primary_key"])
+	if err == nil && orderByPrimaryKey {
+		vt.SetOrderByPrimaryKey(true)
+	}
+	// sorts the groups by the group items if ORDER BY is absent, like the legacy GROUP BY of MySQL 5.x
+	orderByGroupItems, err := strconv.ParseBool(table.Attributes["order_by_group_items"])
+	if err == nil && orderByGroupItems {
+		vt.SetOrderByGroupItems(true)
+	}
+	countByPrimaryKey, err := strconv.ParseBool(table.Attributes["count_by_primary_key"])
+	if err == nil && countByPrimaryKey {
+		vt.SetCountByPrimaryKey(true)
+	}
+	skipMissingTables, err := strconv.ParseBool(table.Attributes["skip_missing_tables"])
+	if err == nil && skipMissingTables {
+		vt.SetSkipMissingTables(true)
+	}
+	earlyLimit, err := strconv.ParseBool(table.Attributes["early_limit"])
+	if err == nil && earlyLimit {
+		vt.SetEarlyLimit(true)
+	}
+	// rejects all the writes of table, eg: INSERT, UPDATE, DELETE and DDL
+	readOnly, err := strconv.ParseBool(table.Attributes["read_only"])
+	if err == nil && readOnly {
+		vt.SetReadOnly(true)
+	}
+	// declares each physical table holds a single value of the sharding key, the shards of inequalities can be excluded
+	singleKeyShards, err := strconv.ParseBool(table.Attributes["single_key_shards"])
+	if err == nil && singleKeyShards {
+		vt.SetSingleKeyShards(true)
+	}
+	// the column names are case-insensitive by default like MySQL
+	caseSensitiveColumns, err := strconv.ParseBool(table.Attributes["case_sensitive_columns"])
+	if err == nil && caseSensitiveColumns {
+		vt.SetCaseSensitiveColumns(true)
+	}
+	if batchSize, err := strconv.Atoi(table.Attributes["insert_batch_size"]); err == nil {
+		vt.SetInsertBatchSize(batchSize)
+	}
+	// the tables of same database are coalesced into UNION ALL queries, eg: union_tables=16
+	if n, err := strconv.Atoi(table.Attributes["union_tables"]); err == nil && n > 0 {
+		vt.SetUnionTables(n)
+	}
+	if limit, err := strconv.ParseInt(table.Attributes["default_limit"], 10, 64); err == nil && limit > 0 {
+		vt.SetDefaultLimit(limit)
+	}
+	// a warning will be raised if a query fans out to at least so many shards, eg: wide_fan_out=16
+	if n, err := strconv.Atoi(table.Attributes["wide_fan_out"]); err == nil && n > 0 {
+		vt.SetWideFanOut(n)
+	}
+	// the tenant of connection will be injected as the predicate of tenant column, eg: tenant_column=tenant_id
+	if column := table.Attributes["tenant_column"]; len(column) > 0 {
+		vt.SetTenantColumn(column)
+	}
 	if table.Sequence != nil {
 		vt.SetAutoIncrement(&rule.AutoIncrement{
 			Type:   table.Sequence.Type,
@@ -155,6 +231,43 @@ func MakeVTable(tableName string, table *Table) (*rule.VTable, error) {
 		})
 	}
 
+	// bloom filter of existing keys, eg: key_filter_column=order_no, key_filter_capacity=1000000, key_filter_fp_rate=0.01
+	// The filter will prune shards, so it must be declared by key_filter_exclusive=true that this proxy is the only writer
+	// of the table, otherwise the keys written outside would be missing from the filter.
+	if column := table.Attributes["key_filter_column"]; len(column) > 0 {
+		if exclusive, _ := strconv.ParseBool(table.Attributes["key_filter_exclusive"]); !exclusive {
+			return nil, errors.Errorf("key filter of table '%s' requires key_filter_exclusive=true, the proxy must be the only writer of the table", table.Name)
+		}
+		capacity, err := strconv.ParseUint(table.Attributes["key_filter_capacity"], 10, 64)
+		if err != nil {
+			capacity = defaultKeyFilterCapacity
+		}
+		fpRate, err := strconv.ParseFloat(table.Attributes["key_filter_fp_rate"], 64)
+		if err != nil {
+			fpRate = bloom.DefaultFalsePositiveRate
+		}
+		vt.SetKeyFilter(rule.NewKeyFilter(column, capacity, fpRate))
+	}
+
+	// lookup of sharding key, eg: lookup_column=customer_code, lookup_key=customer_id, lookup_table=customer_db.customers
+	if column := table.Attributes["lookup_column"]; len(column) > 0 {
+		key, lookupTable := table.Attributes["lookup_key"], table.Attributes["lookup_table"]
+		if len(key) == 0 || len(lookupTable) == 0 {
+			return nil, errors.Errorf("both lookup_key and lookup_table are required by lookup column '%s'", column)
+		}
+		if vt.GetShardColumn(key) == nil {
+			return nil, errors.Errorf("lookup key '%s' is not a sharding key", key)
+		}
+		if !strings.Contains(lookupTable, ".") {
+			return nil, errors.Errorf("invalid lookup table '%s', expect format: db.table", lookupTable)
+		}
+		capacity, err := strconv.ParseInt(table.Attributes["lookup_cache_size"], 10, 64)
+		if err != nil || capacity <= 0 {
+			capacity = defaultKeyLookupCapacity
+		}
+		vt.SetKeyLookup(rule.NewKeyLookup(column, key, lookupTable, capacity))
+	}
+
 	// TODO: process attributes
 	_ = table.Attributes["sql_max_limit"]
 
@@ -164,6 +277,92 @@ func MakeVTable(tableName string, table *Table) (*rule.VTable, error) {
 	return &vt, nil
 }
 
+// MakeStatementPolicy converts the policy into the one of given cluster, nil will be returned
+// if none of the tables declared by the policy belongs to the cluster.
+func MakeStatementPolicy(cluster string, policy *StatementPolicy) (*rule.StatementPolicy, error) {
+	ret := &rule.StatementPolicy{
+		Name:          policy.Name,
+		WithoutWhere:  policy.WithoutWhere,
+		WithoutLimit:  policy.WithoutLimit,
+		CartesianJoin: policy.CartesianJoin,
+		Message:       policy.Message,
+	}
+
+	for _, typ := range policy.Types {
+		ret.Types = append(ret.Types, strings.ToUpper(strings.TrimSpace(typ)))
+	}
+
+	for _, it := range policy.Tables {
+		db, tb, err := ParseDatabaseAndTable(it)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid table of policy '%s'", policy.Name)
+		}
+		if db != cluster {
+			continue
+		}
+		ret.Tables = append(ret.Tables, tb)
+	}
+
+	if len(policy.Tables) > 0 && len(ret.Tables) == 0 {
+		return nil, nil
+	}
+
+	return ret, nil
+}
+
+// MakeView converts the view into the one of given cluster, nil will be returned if the view doesn't belong to the cluster.
+func MakeView(cluster string, view *View) (*rule.View, error) {
+	db, name, err := ParseDatabaseAndTable(view.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid name of view '%s'", view.Name)
+	}
+	if db != cluster {
+		return nil, nil
+	}
+	if len(strings.TrimSpace(view.Definition)) == 0 {
+		return nil, errors.Errorf("no definition of view '%s'", view.Name)
+	}
+	return &rule.View{
+		Name:       name,
+		Definition: view.Definition,
+	}, nil
+}
+
+// MakeSchemaRoutes converts the schema routes into the ones of given cluster.
+func MakeSchemaRoutes(cluster string, routes []*SchemaRoute) ([]*rule.SchemaRoute, error) {
+	var ret []*rule.SchemaRoute
+	for _, it := range routes {
+		route, err := MakeSchemaRoute(cluster, it)
+		if err != nil {
+			return nil, err
+		}
+		if route == nil {
+			continue
+		}
+		ret = append(ret, route)
+	}
+	return ret, nil
+}
+
+// MakeSchemaRoute converts the schema route into the one of given cluster, nil will be returned
+// if the target group doesn't belong to the cluster.
+func MakeSchemaRoute(cluster string, route *SchemaRoute) (*rule.SchemaRoute, error) {
+	if len(strings.TrimSpace(route.Prefix)) == 0 {
+		return nil, errors.Errorf("no prefix of schema route to '%s'", route.Group)
+	}
+	db, group, err := ParseDatabaseAndTable(route.Group)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid group of schema route '%s'", route.Prefix)
+	}
+	if db != cluster {
+		return nil, nil
+	}
+	return &rule.SchemaRoute{
+		Prefix: route.Prefix,
+		Group:  group,
+	}, nil
+}
+
 var (
 	_fullTableNameRegexp     *regexp.Regexp
 	_fullTableNameRegexpOnce sync.Once
@@ -204,39 +403,60 @@ func getRender(format string) func(int) string {
 	}
 }
 
-func toShardMetadata(rules []*Rule, defaultSteps int) ([]*rule.ShardMetadata, error) {
-	toShardColumn := func(ru *ColumnRule, dst *[]*rule.ShardColumn, defaultSteps int) {
-		unit := rule.Unum
-		switch strings.ToLower(ru.Type) {
-		case "string", "str":
-			unit = rule.Ustr
-		case "year":
-			unit = rule.Uyear
-		case "month":
-			unit = rule.Umonth
-		case "week":
-			unit = rule.Uweek
-		case "day":
-			unit = rule.Uday
-		case "hour":
-			unit = rule.Uhour
-		}
-		c := &rule.ShardColumn{
-			Name:  ru.Name,
-			Steps: ru.Step,
-			Stepper: rule.Stepper{
-				N: 1,
-				U: unit,
-			},
-		}
+func toGeneratedColumn(input *GeneratedColumn, defaultSteps int) (*rule.GeneratedColumn, error) {
+	c, err := toSharder(&Rule{
+		Columns: input.Columns,
+		Type:    input.Type,
+		Expr:    input.Expr,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
 
-		if c.Steps == 0 {
-			c.Steps = defaultSteps
-		}
+	var columns []*rule.ShardColumn
+	for _, next := range input.Columns {
+		toShardColumn(next, &columns, defaultSteps)
+	}
+	return &rule.GeneratedColumn{
+		Name:     input.Name,
+		Columns:  columns,
+		Computer: c,
+	}, nil
+}
+
+func toShardColumn(ru *ColumnRule, dst *[]*rule.ShardColumn, defaultSteps int) {
+	unit := rule.Unum
+	switch strings.ToLower(ru.Type) {
+	case "string", "str":
+		unit = rule.Ustr
+	case "year":
+		unit = rule.Uyear
+	case "month":
+		unit = rule.Umonth
+	case "week":
+		unit = rule.Uweek
+	case "day":
+		unit = rule.Uday
+	case "hour":
+		unit = rule.Uhour
+	}
+	c := &rule.ShardColumn{
+		Name:  ru.Name,
+		Steps: ru.Step,
+		Stepper: rule.Stepper{
+			N: 1,
+			U: unit,
+		},
+	}
 
-		*dst = append(*dst, c)
+	if c.Steps == 0 {
+		c.Steps = defaultSteps
 	}
 
+	*dst = append(*dst, c)
+}
+
+func toShardMetadata(rules []*Rule, defaultSteps int) ([]*rule.ShardMetadata, error) {
 	var ret []*rule.ShardMetadata
 	for i := range rules {
 		ru := rules[i]
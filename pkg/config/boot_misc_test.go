@@ -43,3 +43,44 @@ func TestParseDatabaseAndTable(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeView(t *testing.T) {
+	view := &View{
+		Name:       "employees.active_orders",
+		Definition: "SELECT * FROM orders WHERE status = 'active'",
+	}
+
+	v, err := MakeView("employees", view)
+	assert.NoError(t, err)
+	assert.Equal(t, "active_orders", v.Name)
+	assert.Equal(t, view.Definition, v.Definition)
+
+	v, err = MakeView("other", view)
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = MakeView("employees", &View{Name: "active_orders"})
+	assert.Error(t, err)
+}
+
+func TestMakeSchemaRoute(t *testing.T) {
+	route := &SchemaRoute{
+		Prefix: "tenant_",
+		Group:  "employees.employees_tenant",
+	}
+
+	r, err := MakeSchemaRoute("employees", route)
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant_", r.Prefix)
+	assert.Equal(t, "employees_tenant", r.Group)
+
+	r, err = MakeSchemaRoute("other", route)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	_, err = MakeSchemaRoute("employees", &SchemaRoute{Prefix: "tenant_", Group: "employees_tenant"})
+	assert.Error(t, err)
+
+	_, err = MakeSchemaRoute("employees", &SchemaRoute{Group: "employees.employees_tenant"})
+	assert.Error(t, err)
+}
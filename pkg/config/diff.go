@@ -258,9 +258,11 @@ func (s *ShardingRule) Diff(old *ShardingRule) *ShardingRuleEvent {
 	}
 
 	return &ShardingRuleEvent{
-		AddTables:    addTables,
-		UpdateTables: updateTables,
-		DeleteTables: deleteTables,
+		AddTables:     addTables,
+		UpdateTables:  updateTables,
+		DeleteTables:  deleteTables,
+		UpdateSchemas: !reflect.DeepEqual(s.Schemas, old.Schemas),
+		Schemas:       s.Schemas,
 	}
 }
 
@@ -498,6 +498,26 @@ func TestShardingRuleDiff(t *testing.T) {
 	}
 }
 
+func TestShardingRuleDiff_Schemas(t *testing.T) {
+	oldRule := &ShardingRule{
+		Schemas: []*SchemaRoute{{Prefix: "tenant_", Group: "employees.employees_tenant"}},
+	}
+
+	event := oldRule.Diff(oldRule)
+	assert.False(t, event.UpdateSchemas)
+
+	newRule := &ShardingRule{
+		Schemas: []*SchemaRoute{{Prefix: "tenant_", Group: "employees.employees_0000"}},
+	}
+	event = newRule.Diff(oldRule)
+	assert.True(t, event.UpdateSchemas)
+	assert.Equal(t, newRule.Schemas, event.Schemas)
+
+	event = (&ShardingRule{}).Diff(oldRule)
+	assert.True(t, event.UpdateSchemas)
+	assert.Empty(t, event.Schemas)
+}
+
 func TestShadowRuleDiff(t *testing.T) {
 	oldRule := &ShadowRule{
 		ShadowTables: []*ShadowTable{
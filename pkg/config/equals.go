@@ -131,6 +131,10 @@ func (t *Table) Equals(o *Table) bool {
 		return false
 	}
 
+	if !reflect.DeepEqual(t.GeneratedColumns, o.GeneratedColumns) {
+		return false
+	}
+
 	if !reflect.DeepEqual(t.Topology, o.Topology) || !reflect.DeepEqual(t.ShadowTopology, o.ShadowTopology) {
 		return false
 	}
@@ -84,6 +84,9 @@ type (
 		AddTables    []*Table
 		UpdateTables []*Table
 		DeleteTables []*Table
+		// UpdateSchemas is true if the schema routes are changed, Schemas holds the routes after change.
+		UpdateSchemas bool
+		Schemas       []*SchemaRoute
 	}
 
 	// ShadowRuleEvent shadow rule event
@@ -86,6 +86,7 @@ type (
 		Listeners  []*Listener `validate:"required,dive" yaml:"listeners" json:"listeners"`
 		Registry   *Registry   `yaml:"registry" json:"registry"`
 		Trace      *Trace      `yaml:"trace" json:"trace"`
+		Audit      *Audit      `yaml:"audit" json:"audit"`
 		Supervisor *User       `validate:"required,dive" yaml:"supervisor" json:"supervisor"`
 		Logging    *log.Config `validate:"required,dive" yaml:"logging" json:"logging"`
 	}
@@ -141,7 +142,36 @@ type (
 	}
 
 	ShardingRule struct {
-		Tables []*Table `yaml:"tables" json:"tables"`
+		Tables   []*Table           `yaml:"tables" json:"tables"`
+		Policies []*StatementPolicy `yaml:"policies,omitempty" json:"policies,omitempty"`
+		Views    []*View            `yaml:"views,omitempty" json:"views,omitempty"`
+		Schemas  []*SchemaRoute     `yaml:"schemas,omitempty" json:"schemas,omitempty"`
+	}
+
+	// SchemaRoute routes the queries of the physical schemas which have the prefix to a group directly,
+	// the sharding is bypassed, eg: the schema-per-tenant deployments.
+	SchemaRoute struct {
+		Prefix string `validate:"required" yaml:"prefix" json:"prefix"` // eg: tenant_, matches tenant_acme.users
+		Group  string `validate:"required" yaml:"group" json:"group"`   // eg: employees.employees_tenant
+	}
+
+	// View declares a logical view over a single table, it will be inlined into the statement before
+	// computing shards, so the predicates of view are able to route the statement of base table.
+	View struct {
+		Name       string `validate:"required" yaml:"name" json:"name"`             // eg: employees.active_orders
+		Definition string `validate:"required" yaml:"definition" json:"definition"` // eg: SELECT * FROM orders WHERE status = 'active'
+	}
+
+	// StatementPolicy declares a pattern of dangerous statements which should be rejected,
+	// a statement is rejected only if it matches all the given conditions.
+	StatementPolicy struct {
+		Name          string   `validate:"required" yaml:"name" json:"name"`
+		Types         []string `yaml:"types" json:"types,omitempty"`   // eg: SELECT, DELETE, empty means any type
+		Tables        []string `yaml:"tables" json:"tables,omitempty"` // eg: employees.student, empty means any table
+		WithoutWhere  bool     `yaml:"without_where" json:"without_where,omitempty"`
+		WithoutLimit  bool     `yaml:"without_limit" json:"without_limit,omitempty"`
+		CartesianJoin bool     `yaml:"cartesian_join" json:"cartesian_join,omitempty"`
+		Message       string   `yaml:"message" json:"message,omitempty"`
 	}
 
 	ShadowRule struct {
@@ -180,13 +210,14 @@ type (
 	}
 
 	Table struct {
-		Name           string            `validate:"required" yaml:"name" json:"name"`
-		Sequence       *Sequence         `yaml:"sequence" json:"sequence"`
-		DbRules        []*Rule           `yaml:"db_rules" json:"db_rules"`
-		TblRules       []*Rule           `yaml:"tbl_rules" json:"tbl_rules"`
-		Topology       *Topology         `validate:"required" yaml:"topology" json:"topology"`
-		ShadowTopology *Topology         `yaml:"shadow_topology" json:"shadow_topology"`
-		Attributes     map[string]string `yaml:"attributes" json:"attributes"`
+		Name             string             `validate:"required" yaml:"name" json:"name"`
+		Sequence         *Sequence          `yaml:"sequence" json:"sequence"`
+		DbRules          []*Rule            `yaml:"db_rules" json:"db_rules"`
+		TblRules         []*Rule            `yaml:"tbl_rules" json:"tbl_rules"`
+		GeneratedColumns []*GeneratedColumn `yaml:"generated_columns" json:"generated_columns,omitempty"`
+		Topology         *Topology          `validate:"required" yaml:"topology" json:"topology"`
+		ShadowTopology   *Topology          `yaml:"shadow_topology" json:"shadow_topology"`
+		Attributes       map[string]string  `yaml:"attributes" json:"attributes"`
 	}
 
 	Sequence struct {
@@ -194,12 +225,24 @@ type (
 		Option map[string]string `yaml:"option" json:"option,omitempty"`
 	}
 
+	// Rule declares a sharding rule, the db rule and table rule at the same position make up a sharding strategy,
+	// the named strategy can be chosen by 'SET arana_shard_strategy = <name>' at runtime.
 	Rule struct {
+		Name    string        `yaml:"name,omitempty" json:"name,omitempty"`
 		Columns []*ColumnRule `validate:"required" yaml:"columns" json:"columns"`
 		Type    string        `validate:"required" yaml:"type" json:"type"`
 		Expr    string        `validate:"required" yaml:"expr" json:"expr"`
 	}
 
+	// GeneratedColumn declares a generated column, eg: `month AS (MONTH(created_at))`,
+	// the expression computes the value of it from the base columns.
+	GeneratedColumn struct {
+		Name    string        `validate:"required" yaml:"name" json:"name"`
+		Columns []*ColumnRule `validate:"required" yaml:"columns" json:"columns"`
+		Type    string        `yaml:"type" json:"type"`
+		Expr    string        `validate:"required" yaml:"expr" json:"expr"`
+	}
+
 	ColumnRule struct {
 		Name string `validate:"required" yaml:"name" json:"name"`
 		Type string `yaml:"type" json:"type"`
@@ -217,6 +260,14 @@ type (
 		Type    string `default:"jaeger" yaml:"type" json:"type"`
 		Address string `default:"http://localhost:14268/api/traces" yaml:"address" json:"address"`
 	}
+
+	// Audit configures the auditor which receives the audit records of all routed statements,
+	// the records will be dropped if the buffer is full, so that the statements are never blocked.
+	Audit struct {
+		Type       string                 `yaml:"type" json:"type"`
+		BufferSize int                    `default:"4096" yaml:"buffer_size" json:"buffer_size"`
+		Options    map[string]interface{} `yaml:"options" json:"options"`
+	}
 )
 
 type ParametersMap map[string]string
@@ -385,18 +436,84 @@ func GetConnPropMaxCapacity(connProps map[string]interface{}, defaultValue int)
 
 // GetConnPropIdleTime parses the idle time of backend connection pool, return default value if failed.
 func GetConnPropIdleTime(connProps map[string]interface{}, defaultValue time.Duration) time.Duration {
+	return getConnPropDuration(connProps, defaultValue, "idle_time", "idleTime")
+}
+
+// GetConnPropMaxIdle parses the max number of idle connections of backend connection pool, the exceeded idle
+// connections will be closed once they are returned. Return default value if failed.
+func GetConnPropMaxIdle(connProps map[string]interface{}, defaultValue int) int {
+	var (
+		maxIdle interface{}
+		ok      bool
+	)
+
+	if maxIdle, ok = connProps["max_idle"]; !ok {
+		if maxIdle, ok = connProps["maxIdle"]; !ok {
+			return defaultValue
+		}
+	}
+	n, _ := strconv.Atoi(fmt.Sprint(maxIdle))
+	if n < 1 {
+		return defaultValue
+	}
+	return n
+}
+
+// GetConnPropMaxLifetime parses the max lifetime of backend connection, the expired connections won't be reused.
+// Return default value if failed.
+func GetConnPropMaxLifetime(connProps map[string]interface{}, defaultValue time.Duration) time.Duration {
+	return getConnPropDuration(connProps, defaultValue, "max_lifetime", "maxLifetime")
+}
+
+// GetConnPropWaitTimeout parses the max time of waiting for a backend connection when the pool is saturated.
+// Return default value if failed.
+func GetConnPropWaitTimeout(connProps map[string]interface{}, defaultValue time.Duration) time.Duration {
+	return getConnPropDuration(connProps, defaultValue, "wait_timeout", "waitTimeout")
+}
+
+// GetConnPropBreakerThreshold parses the amount of consecutive failures which opens the circuit breaker of backend,
+// zero means the circuit breaker is disabled. Return default value if failed.
+func GetConnPropBreakerThreshold(connProps map[string]interface{}, defaultValue int) int {
 	var (
-		idleTime interface{}
-		ok       bool
+		threshold interface{}
+		ok        bool
 	)
 
-	if idleTime, ok = connProps["idle_time"]; !ok {
-		if idleTime, ok = connProps["idleTime"]; !ok {
+	if threshold, ok = connProps["breaker_threshold"]; !ok {
+		if threshold, ok = connProps["breakerThreshold"]; !ok {
 			return defaultValue
 		}
 	}
+	n, _ := strconv.Atoi(fmt.Sprint(threshold))
+	if n < 1 {
+		return defaultValue
+	}
+	return n
+}
+
+// GetConnPropBreakerOpenTime parses the time of circuit breaker keeping open, a probe request will be allowed
+// after it elapsed. Return default value if failed.
+func GetConnPropBreakerOpenTime(connProps map[string]interface{}, defaultValue time.Duration) time.Duration {
+	return getConnPropDuration(connProps, defaultValue, "breaker_open_time", "breakerOpenTime")
+}
+
+// getConnPropDuration parses the duration by the first existing key, the number without unit means seconds.
+func getConnPropDuration(connProps map[string]interface{}, defaultValue time.Duration, keys ...string) time.Duration {
+	var (
+		value interface{}
+		ok    bool
+	)
+
+	for _, key := range keys {
+		if value, ok = connProps[key]; ok {
+			break
+		}
+	}
+	if !ok {
+		return defaultValue
+	}
 
-	s := fmt.Sprint(idleTime)
+	s := fmt.Sprint(value)
 	d, _ := time.ParseDuration(s)
 	if d > 0 {
 		return d
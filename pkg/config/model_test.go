@@ -402,6 +402,41 @@ func TestGetConnPropIdleTime(t *testing.T) {
 	}
 }
 
+func TestGetConnPropPoolLimits(t *testing.T) {
+	connProps := map[string]interface{}{
+		"max_idle":     4,
+		"maxLifetime":  "1h",
+		"wait_timeout": 3,
+	}
+	assert.Equal(t, 4, config.GetConnPropMaxIdle(connProps, 64))
+	assert.Equal(t, time.Hour, config.GetConnPropMaxLifetime(connProps, 0))
+	assert.Equal(t, 3*time.Second, config.GetConnPropWaitTimeout(connProps, 0))
+
+	// the defaults are used if absent or invalid
+	connProps = map[string]interface{}{
+		"max_idle":     -1,
+		"wait_timeout": "foo",
+	}
+	assert.Equal(t, 64, config.GetConnPropMaxIdle(connProps, 64))
+	assert.Equal(t, time.Duration(0), config.GetConnPropMaxLifetime(connProps, 0))
+	assert.Equal(t, time.Second, config.GetConnPropWaitTimeout(connProps, time.Second))
+}
+
+func TestGetConnPropBreaker(t *testing.T) {
+	connProps := map[string]interface{}{
+		"breaker_threshold": 5,
+		"breakerOpenTime":   "30s",
+	}
+	assert.Equal(t, 5, config.GetConnPropBreakerThreshold(connProps, 0))
+	assert.Equal(t, 30*time.Second, config.GetConnPropBreakerOpenTime(connProps, 0))
+
+	connProps = map[string]interface{}{
+		"breaker_threshold": "foo",
+	}
+	assert.Equal(t, 0, config.GetConnPropBreakerThreshold(connProps, 0))
+	assert.Equal(t, 10*time.Second, config.GetConnPropBreakerOpenTime(connProps, 10*time.Second))
+}
+
 func TestListener_String(t *testing.T) {
 	type fields struct {
 		ProtocolType  string
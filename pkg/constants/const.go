@@ -28,4 +28,12 @@ const (
 	VariableNameMaxAllowedPacket = "max_allowed_packet"
 
 	SlowThreshold = "slow_threshold"
+
+	SlowSampleRate = "slow_sample_rate"
+
+	ReadRetries = "read_retries"
+
+	ReadRetryBackoff = "read_retry_backoff"
+
+	QueryMemoryLimit = "query_memory_limit"
 )
@@ -429,6 +429,7 @@ const (
 	ERDuplicatedValueInType         = 1291
 	ERRowIsReferenced2              = 1451
 	ErNoReferencedRow2              = 1452
+	ERCantExecuteInReadOnlyTx       = 1792
 
 	// already exists
 	ERTableExists = 1050
@@ -573,6 +574,12 @@ const (
 	// SSBadFieldError is ER_BAD_FIELD_ERROR
 	SSBadFieldError = "42S22"
 
+	// SSWrongValueCountOnRow is ER_WRONG_VALUE_COUNT_ON_ROW
+	SSWrongValueCountOnRow = "21S01"
+
+	// SSWrongNumberOfColumnsInSelect is ER_WRONG_NUMBER_OF_COLUMNS_IN_SELECT
+	SSWrongNumberOfColumnsInSelect = "21000"
+
 	// SSDupKey is ER_DUP_KEY
 	SSDupKey = "23000"
 
@@ -580,6 +587,9 @@ const (
 	// ER_CANT_DO_THIS_DURING_AN_TRANSACTION
 	SSCantDoThisDuringAnTransaction = "25000"
 
+	// SSCantExecuteInReadOnlyTx is ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION
+	SSCantExecuteInReadOnlyTx = "25006"
+
 	// SSAccessDeniedError is ER_ACCESS_DENIED_ERROR
 	SSAccessDeniedError = "28000"
 
@@ -18,6 +18,7 @@
 package dataset
 
 import (
+	"github.com/arana-db/arana/pkg/merge"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/reduce"
 )
@@ -25,11 +26,35 @@ import (
 type pipeOption []func(proto.Dataset) proto.Dataset
 
 func Reduce(reducers map[int]reduce.Reducer) Option {
+	return ReduceWithIdentity(reducers, nil)
+}
+
+// ReduceWithIdentity reduces all rows into one row, the identity row will be returned if there are no rows at all.
+func ReduceWithIdentity(reducers map[int]reduce.Reducer, identity []proto.Value) Option {
+	return ReduceWithAggregators(reducers, nil, identity)
+}
+
+// ReduceWithAggregators is same as ReduceWithIdentity, but the fields of aggregators are merged by the aggregators.
+func ReduceWithAggregators(reducers map[int]reduce.Reducer, aggregators map[int]merge.Aggregator, identity []proto.Value) Option {
 	return func(option *pipeOption) {
 		*option = append(*option, func(dataset proto.Dataset) proto.Dataset {
 			return &ReduceDataset{
+				Dataset:     dataset,
+				Reducers:    reducers,
+				Aggregators: aggregators,
+				Identity:    identity,
+			}
+		})
+	}
+}
+
+// DefaultRow returns the row generated by the function if the dataset has no rows at all.
+func DefaultRow(generate func(fields []proto.Field) []proto.Value) Option {
+	return func(option *pipeOption) {
+		*option = append(*option, func(dataset proto.Dataset) proto.Dataset {
+			return &DefaultRowDataset{
 				Dataset:  dataset,
-				Reducers: reducers,
+				Generate: generate,
 			}
 		})
 	}
@@ -46,6 +71,15 @@ func Filter(predicate PredicateFunc) Option {
 	}
 }
 
+// Limit stops the dataset after n rows, the remaining rows won't be read.
+func Limit(n int64) Option {
+	return func(option *pipeOption) {
+		*option = append(*option, func(prev proto.Dataset) proto.Dataset {
+			return &LimitDataset{Dataset: prev, Limit: n}
+		})
+	}
+}
+
 func FilterPrefix(predicate PredicateFunc, prefix string) Option {
 	return func(option *pipeOption) {
 		*option = append(*option, func(prev proto.Dataset) proto.Dataset {
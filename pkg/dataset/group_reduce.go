@@ -82,7 +82,11 @@ func (gr *AggregateReducer) Reduce(next proto.Row) error {
 	}
 
 	for idx, aggregator := range gr.AggItems {
-		aggregator.Aggregate([]proto.Value{values[idx]})
+		aggregator.Aggregate(aggregateValues(aggregator, values, idx))
+		// the aggregators holding values may fail, eg: the memory limit of DISTINCT values is exceeded
+		if it, ok := aggregator.(interface{ Err() error }); ok && it.Err() != nil {
+			return it.Err()
+		}
 	}
 
 	for i := 0; i < len(values); i++ {
@@ -105,6 +109,20 @@ func (gr *AggregateReducer) Reduce(next proto.Row) error {
 	return nil
 }
 
+// aggregateValues returns the values of row to be aggregated at index idx, the multi-column aggregators read several columns.
+func aggregateValues(aggregator merge.Aggregator, values []proto.Value, idx int) []proto.Value {
+	mc, ok := aggregator.(merge.MultiColumnAggregator)
+	if !ok {
+		return []proto.Value{values[idx]}
+	}
+	columns := mc.Columns()
+	ret := make([]proto.Value, len(columns))
+	for i, column := range columns {
+		ret[i] = values[column]
+	}
+	return ret
+}
+
 func (gr *AggregateReducer) Row() proto.Row {
 	return gr.currentRow
 }
@@ -196,7 +214,13 @@ L:
 		return nil, err
 	}
 
-	return reducer.Row(), nil
+	// no rows left
+	row := reducer.Row()
+	if row == nil {
+		return nil, io.EOF
+	}
+
+	return row, nil
 }
 
 func (gd *GroupDataset) consumeUntilDifferent(indexes []int, rowsChan chan<- proto.Row, errChan chan<- error) {
@@ -311,13 +335,7 @@ func (gd *GroupDataset) getKeyIndexes() ([]int, error) {
 		}
 		gd.keyIndexes = make([]int, 0, len(gd.keys))
 		for _, key := range gd.keys {
-			idx := -1
-			for i := 0; i < len(fields); i++ {
-				if fields[i].Name() == key.Column {
-					idx = i
-					break
-				}
-			}
+			idx := proto.IndexOfField(fields, key.Column)
 			if idx == -1 {
 				gd.keyIndexesFailure = fmt.Errorf("cannot find group field '%+v'", key)
 				return
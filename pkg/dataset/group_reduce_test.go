@@ -111,7 +111,7 @@ func TestGroupReduce(t *testing.T) {
 	}
 
 	// Simulate: SELECT gender,COUNT(*) AS amount FROM xxx WHERE ... GROUP BY gender
-	groups := []OrderByItem{{"gender", true}}
+	groups := []OrderByItem{{Column: "gender", Desc: true}}
 	p := Pipe(&origin,
 		GroupReduce(
 			groups,
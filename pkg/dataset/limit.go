@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"io"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+var _ proto.Dataset = (*LimitDataset)(nil)
+
+// LimitDataset returns io.EOF once Limit rows have been read, the rest of the underlying dataset won't be touched.
+// It is useful for the lazy datasets, eg: the fused dataset won't execute the remaining generators.
+type LimitDataset struct {
+	proto.Dataset
+	Limit int64
+	count int64
+}
+
+func (l *LimitDataset) Next() (proto.Row, error) {
+	if l.count >= l.Limit {
+		return nil, io.EOF
+	}
+
+	row, err := l.Dataset.Next()
+	if err != nil {
+		return nil, err
+	}
+	l.count++
+
+	return row, nil
+}
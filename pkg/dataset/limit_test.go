@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"io"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+func TestLimit(t *testing.T) {
+	fields := []proto.Field{
+		mysql.NewField("id", consts.FieldTypeLong),
+	}
+
+	var generated int
+	gen := func() (proto.Dataset, error) {
+		generated++
+		ds := &VirtualDataset{Columns: fields}
+		for i := int64(0); i < 3; i++ {
+			ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(i)}))
+		}
+		return ds, nil
+	}
+
+	fused, err := Fuse(gen, gen, gen, gen)
+	assert.NoError(t, err)
+
+	limited := Pipe(fused, Limit(4))
+
+	var n int
+	for {
+		_, err = limited.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		n++
+	}
+	assert.Equal(t, 4, n)
+	// the last two datasets are never generated
+	assert.Equal(t, 2, generated)
+}
@@ -18,6 +18,7 @@
 package dataset
 
 import (
+	"io"
 	"testing"
 )
 
@@ -27,6 +28,14 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/testdata"
+)
+
 func TestOrderedDataset(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -76,3 +85,60 @@ func TestOrderedDataset(t *testing.T) {
 	t.Logf("next: %#v\n", pojo)
 	assert.Equal(t, int64(3), pojo.ID)
 }
+
+func TestOrderedDataset_Streaming(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const (
+		streams = 4
+		total   = 100000
+	)
+
+	fields := []proto.Field{
+		mysql.NewField("id", consts.FieldTypeLong),
+	}
+
+	// each stream generates the rows lazily: i, i+streams, i+2*streams...
+	produced := make([]int, streams)
+	var gens []GenerateFunc
+	for i := 0; i < streams; i++ {
+		i := i
+		ds := testdata.NewMockDataset(ctrl)
+		ds.EXPECT().Close().Return(nil).AnyTimes()
+		ds.EXPECT().Fields().Return(fields, nil).AnyTimes()
+		ds.EXPECT().Next().
+			DoAndReturn(func() (proto.Row, error) {
+				if produced[i] >= total {
+					return nil, io.EOF
+				}
+				id := int64(produced[i]*streams + i)
+				produced[i]++
+				return rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(id)}), nil
+			}).
+			AnyTimes()
+		gens = append(gens, func() (proto.Dataset, error) {
+			return ds, nil
+		})
+	}
+
+	pd, err := Parallel(gens[0], gens[1:]...)
+	assert.NoError(t, err)
+	od := NewOrderedDataset(pd, []OrderByItem{{Column: "id"}})
+
+	// the merged rows are produced incrementally, only one pending row of each stream is held
+	dest := make([]proto.Value, 1)
+	for i := 0; i < 10; i++ {
+		row, err := od.Next()
+		assert.NoError(t, err)
+		assert.NoError(t, row.Scan(dest))
+		id, _ := dest[0].Int64()
+		assert.Equal(t, int64(i), id)
+
+		var sum int
+		for _, n := range produced {
+			sum += n
+		}
+		assert.LessOrEqual(t, sum, i+1+streams)
+	}
+}
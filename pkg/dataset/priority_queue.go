@@ -19,6 +19,7 @@ package dataset
 
 import (
 	"container/heap"
+	"strings"
 )
 
 import (
@@ -35,8 +36,9 @@ type RowItem struct {
 }
 
 type OrderByItem struct {
-	Column string
-	Desc   bool
+	Column  string
+	Desc    bool
+	Collate string // the collation used to compare strings, empty means binary comparison
 }
 
 type PriorityQueue struct {
@@ -105,7 +107,7 @@ func (pq *PriorityQueue) update() {
 
 func compare(a *OrderByValue, b *OrderByValue, orderByItems []OrderByItem) int {
 	for _, item := range orderByItems {
-		c := compareTo(a.OrderValues[item.Column], b.OrderValues[item.Column], item.Desc)
+		c := compareTo(a.OrderValues[item.Column], b.OrderValues[item.Column], item.Desc, item.Collate)
 		if c == 0 {
 			continue
 		}
@@ -114,7 +116,7 @@ func compare(a *OrderByValue, b *OrderByValue, orderByItems []OrderByItem) int {
 	return 0
 }
 
-func compareTo(a, b proto.Value, desc bool) int {
+func compareTo(a, b proto.Value, desc bool, collate string) int {
 	if a == nil && b == nil {
 		return 0
 	}
@@ -125,9 +127,22 @@ func compareTo(a, b proto.Value, desc bool) int {
 		return 1
 	}
 
-	result := proto.CompareValue(a, b)
+	result := compareWithCollation(a, b, collate)
 	if desc {
 		return -result
 	}
 	return result
 }
+
+// compareWithCollation compares the values, the strings will be compared case-insensitively if the
+// collation is case-insensitive, eg: utf8mb4_general_ci.
+func compareWithCollation(a, b proto.Value, collate string) int {
+	if a.Family().IsNumberic() || b.Family().IsNumberic() || !isCaseInsensitive(collate) {
+		return proto.CompareValue(a, b)
+	}
+	return strings.Compare(strings.ToLower(a.String()), strings.ToLower(b.String()))
+}
+
+func isCaseInsensitive(collate string) bool {
+	return strings.HasSuffix(strings.ToLower(collate), "_ci")
+}
@@ -20,6 +20,7 @@ package dataset
 import (
 	"container/heap"
 	"database/sql"
+	"strings"
 	"testing"
 )
 
@@ -40,8 +41,8 @@ func TestPriorityQueue(t *testing.T) {
 		mysql.NewField("score", consts.FieldTypeLong),
 	}
 	items := []OrderByItem{
-		{"id", false},
-		{"score", true},
+		{Column: "id", Desc: false},
+		{Column: "score", Desc: true},
 	}
 
 	r1 := &RowItem{rows.NewTextVirtualRow(fields, []proto.Value{
@@ -85,6 +86,44 @@ func TestPriorityQueue(t *testing.T) {
 	}, heap.Pop(pq).(*RowItem).row)
 }
 
+func TestPriorityQueue_Collate(t *testing.T) {
+	fields := []proto.Field{
+		mysql.NewField("name", consts.FieldTypeVarString),
+	}
+
+	newRows := func() []*RowItem {
+		var ret []*RowItem
+		for _, name := range []string{"b", "A", "a", "B"} {
+			ret = append(ret, &RowItem{rows.NewTextVirtualRow(fields, []proto.Value{
+				proto.NewValueString(name),
+			}), 1})
+		}
+		return ret
+	}
+
+	popAll := func(pq *PriorityQueue) []string {
+		var ret []string
+		for pq.Len() > 0 {
+			name, _ := heap.Pop(pq).(*RowItem).row.Get("name")
+			ret = append(ret, name.String())
+		}
+		return ret
+	}
+
+	// binary comparison, upper case letters come first
+	pq := NewPriorityQueue(newRows(), []OrderByItem{{Column: "name", Collate: "utf8mb4_bin"}})
+	assert.Equal(t, []string{"A", "B", "a", "b"}, popAll(pq))
+
+	// case-insensitive comparison
+	pq = NewPriorityQueue(newRows(), []OrderByItem{{Column: "name", Collate: "utf8mb4_general_ci"}})
+	names := popAll(pq)
+	assert.Len(t, names, 4)
+	assert.Equal(t, "a", strings.ToLower(names[0]))
+	assert.Equal(t, "a", strings.ToLower(names[1]))
+	assert.Equal(t, "b", strings.ToLower(names[2]))
+	assert.Equal(t, "b", strings.ToLower(names[3]))
+}
+
 func assertScorePojoEquals(t *testing.T, expected fakeScorePojo, actual proto.Row) {
 	var pojo fakeScorePojo
 	err := scanScorePojo(actual, &pojo)
@@ -26,6 +26,7 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/merge"
 	"github.com/arana-db/arana/pkg/mysql/rows"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/reduce"
@@ -34,44 +35,86 @@ import (
 type ReduceDataset struct {
 	proto.Dataset
 	Reducers map[int]reduce.Reducer // field_index -> aggregator
+	// Aggregators merges the fields which cannot be reduced pairwise, eg: the variances are merged with the partial COUNT and SUM.
+	Aggregators map[int]merge.Aggregator
+	// Identity is the row returned if there are no rows at all, the reduced fields are always the identities of reducers.
+	Identity []proto.Value
 	prev     []proto.Value
 	binary   bool
 	eof      bool
 }
 
+// Next reduces all rows of upstream into one row, the rows are consumed one by one without buffering.
 func (ad *ReduceDataset) Next() (proto.Row, error) {
 	if ad.eof {
 		return nil, io.EOF
 	}
 
-	nextRow, err := ad.Dataset.Next()
-	if errors.Is(err, io.EOF) {
-		if ad.prev == nil {
-			return nil, io.EOF
+	for {
+		nextRow, err := ad.Dataset.Next()
+		if errors.Is(err, io.EOF) {
+			break
 		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err = ad.reduce(nextRow); err != nil {
+			return nil, err
+		}
+	}
+
+	ad.eof = true
+	fields, _ := ad.Dataset.Fields()
+
+	// the aggregation without GROUP BY always returns one row, even if there are no rows at all,
+	// eg: COUNT returns 0 and SUM returns NULL. The row is textual since the protocol of upstream is unknown.
+	if ad.prev == nil {
+		ad.prev = make([]proto.Value, len(fields))
+		copy(ad.prev, ad.Identity)
+		for i, red := range ad.Reducers {
+			if i >= len(fields) {
+				continue
+			}
+			if v, ok := reduce.Identity(red); ok {
+				ad.prev[i] = proto.NewValueDecimal(v)
+			} else {
+				ad.prev[i] = nil
+			}
+		}
+	}
 
-		ad.eof = true
-		fields, _ := ad.Dataset.Fields()
-		if ad.binary {
-			return rows.NewBinaryVirtualRow(fields, ad.prev), nil
+	for i, aggregator := range ad.Aggregators {
+		if i >= len(fields) {
+			continue
+		}
+		if v, ok := aggregator.GetResult(); ok {
+			ad.prev[i] = v
 		} else {
-			return rows.NewTextVirtualRow(fields, ad.prev), nil
+			ad.prev[i] = nil
 		}
 	}
 
-	if err != nil {
-		return nil, errors.WithStack(err)
+	if ad.binary {
+		return rows.NewBinaryVirtualRow(fields, ad.prev), nil
 	}
+	return rows.NewTextVirtualRow(fields, ad.prev), nil
+}
+
+func (ad *ReduceDataset) reduce(nextRow proto.Row) error {
 	fields, _ := ad.Fields()
 	values := make([]proto.Value, len(fields))
-	if err = nextRow.Scan(values); err != nil {
-		return nil, errors.WithStack(err)
+	if err := nextRow.Scan(values); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for i, aggregator := range ad.Aggregators {
+		aggregator.Aggregate(aggregateValues(aggregator, values, i))
 	}
 
 	if ad.prev == nil {
 		ad.prev = values
 		ad.binary = nextRow.IsBinary()
-		return ad.Next()
+		return nil
 	}
 
 	for i := range values {
@@ -94,19 +137,52 @@ func (ad *ReduceDataset) Next() (proto.Row, error) {
 
 		x, err := prev.Decimal()
 		if err != nil {
-			return nil, errors.WithStack(err)
+			return errors.WithStack(err)
 		}
 		y, err := next.Decimal()
 		if err != nil {
-			return nil, errors.WithStack(err)
+			return errors.WithStack(err)
 		}
 		z, err := red.Decimal(x, y)
 		if err != nil {
-			return nil, errors.WithStack(err)
+			return errors.WithStack(err)
 		}
 
 		ad.prev[i] = proto.NewValueDecimal(z)
 	}
 
-	return ad.Next()
+	return nil
+}
+
+var _ proto.Dataset = (*DefaultRowDataset)(nil)
+
+// DefaultRowDataset returns the default row if the upstream has no rows at all, eg: the aggregation without
+// GROUP BY always returns one row. The row is textual since the protocol of upstream is unknown.
+type DefaultRowDataset struct {
+	proto.Dataset
+	Generate func(fields []proto.Field) []proto.Value
+	nonEmpty bool
+	eof      bool
+}
+
+func (dd *DefaultRowDataset) Next() (proto.Row, error) {
+	if dd.eof {
+		return nil, io.EOF
+	}
+
+	next, err := dd.Dataset.Next()
+	if err == nil {
+		dd.nonEmpty = true
+		return next, nil
+	}
+	if !errors.Is(err, io.EOF) || dd.nonEmpty {
+		return nil, err
+	}
+
+	dd.eof = true
+	fields, err := dd.Dataset.Fields()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return rows.NewTextVirtualRow(fields, dd.Generate(fields)), nil
 }
@@ -19,6 +19,7 @@ package dataset
 
 import (
 	"fmt"
+	"io"
 	"testing"
 )
 
@@ -39,9 +40,6 @@ func TestReduce(t *testing.T) {
 		mysql.NewField("score", consts.FieldTypeLong),
 	}
 
-	var origin VirtualDataset
-	origin.Columns = fields
-
 	var rows [][]proto.Value
 	for i := 0; i < 10; i++ {
 		rows = append(rows, []proto.Value{
@@ -49,8 +47,13 @@ func TestReduce(t *testing.T) {
 		})
 	}
 
-	for _, it := range rows {
-		origin.Rows = append(origin.Rows, vrows.NewTextVirtualRow(fields, it))
+	// the dataset can be consumed only once
+	newOrigin := func() *VirtualDataset {
+		origin := &VirtualDataset{Columns: fields}
+		for _, it := range rows {
+			origin.Rows = append(origin.Rows, vrows.NewTextVirtualRow(fields, it))
+		}
+		return origin
 	}
 
 	totalFields := []proto.Field{
@@ -58,7 +61,7 @@ func TestReduce(t *testing.T) {
 	}
 
 	// Simulate: SELECT sum(score) AS total FROM xxx WHERE ...
-	pSum := Pipe(&origin,
+	pSum := Pipe(newOrigin(),
 		Reduce(
 			map[int]reduce.Reducer{
 				0: reduce.Sum(),
@@ -82,7 +85,7 @@ func TestReduce(t *testing.T) {
 	}
 
 	// Simulate: SELECT max(score) AS max FROM xxx WHERE ...
-	pMax := Pipe(&origin,
+	pMax := Pipe(newOrigin(),
 		Reduce(
 			map[int]reduce.Reducer{
 				0: reduce.Max(),
@@ -106,7 +109,7 @@ func TestReduce(t *testing.T) {
 	}
 
 	// Simulate: SELECT min(score) AS min FROM xxx WHERE ...
-	pMin := Pipe(&origin,
+	pMin := Pipe(newOrigin(),
 		Reduce(
 			map[int]reduce.Reducer{
 				0: reduce.Min(),
@@ -125,3 +128,87 @@ func TestReduce(t *testing.T) {
 		t.Logf("next: min=%v\n", v[0])
 	}
 }
+
+func TestReduce_Streaming(t *testing.T) {
+	const total = 1000000
+
+	fields := []proto.Field{
+		mysql.NewField("score", consts.FieldTypeLong),
+	}
+
+	// the rows are generated lazily, and never buffered by the reducer
+	var produced int
+	origin := &generatedDataset{
+		fields: fields,
+		next: func() (proto.Row, error) {
+			if produced >= total {
+				return nil, io.EOF
+			}
+			produced++
+			return vrows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(1)}), nil
+		},
+	}
+
+	ds := Pipe(origin, Reduce(map[int]reduce.Reducer{
+		0: reduce.Sum(),
+	}))
+
+	next, err := ds.Next()
+	assert.NoError(t, err)
+	v := make([]proto.Value, 1)
+	assert.NoError(t, next.Scan(v))
+	assert.Equal(t, fmt.Sprint(total), fmt.Sprint(v[0]))
+	assert.Equal(t, total, produced)
+
+	_, err = ds.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+type generatedDataset struct {
+	fields []proto.Field
+	next   func() (proto.Row, error)
+}
+
+func (g *generatedDataset) Close() error {
+	return nil
+}
+
+func (g *generatedDataset) Fields() ([]proto.Field, error) {
+	return g.fields, nil
+}
+
+func (g *generatedDataset) Next() (proto.Row, error) {
+	return g.next()
+}
+
+func TestDefaultRow(t *testing.T) {
+	fields := []proto.Field{
+		mysql.NewField("score", consts.FieldTypeLong),
+	}
+	generate := func(fields []proto.Field) []proto.Value {
+		return []proto.Value{proto.NewValueInt64(-1)}
+	}
+
+	drain := func(ds proto.Dataset) []string {
+		var ret []string
+		for {
+			next, err := ds.Next()
+			if err == io.EOF {
+				return ret
+			}
+			assert.NoError(t, err)
+			v := make([]proto.Value, len(fields))
+			_ = next.Scan(v)
+			ret = append(ret, fmt.Sprint(v[0]))
+		}
+	}
+
+	empty := &VirtualDataset{Columns: fields}
+	assert.Equal(t, []string{"-1"}, drain(Pipe(empty, DefaultRow(generate))))
+
+	origin := &VirtualDataset{Columns: fields}
+	for i := 0; i < 2; i++ {
+		origin.Rows = append(origin.Rows, vrows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(int64(i))}))
+	}
+	assert.Equal(t, []string{"0", "1"}, drain(Pipe(origin, DefaultRow(generate))))
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"io"
+	"sort"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+var _ proto.Dataset = (*sortedDataset)(nil)
+
+// MemoryTracker accounts the memory of buffered rows, an error should be returned if the limit is exceeded.
+type MemoryTracker interface {
+	Consume(n int64) error
+}
+
+// sortedDataset sorts all the rows of upstream dataset in memory, the rows with same order values keep their original order.
+type sortedDataset struct {
+	proto.Dataset
+	items   []OrderByItem
+	tracker MemoryTracker
+	rows    []proto.Row
+	sorted  bool
+}
+
+// NewSortedDataset creates a dataset which reads all the rows of upstream dataset and returns them in order,
+// unlike NewOrderedDataset, the upstream rows are needless to be ordered.
+// The buffered rows will be accounted by the tracker if it is not nil.
+func NewSortedDataset(dataset proto.Dataset, items []OrderByItem, tracker MemoryTracker) proto.Dataset {
+	return &sortedDataset{
+		Dataset: dataset,
+		items:   items,
+		tracker: tracker,
+	}
+}
+
+func (sd *sortedDataset) Next() (proto.Row, error) {
+	if !sd.sorted {
+		if err := sd.sort(); err != nil {
+			return nil, err
+		}
+		sd.sorted = true
+	}
+
+	if len(sd.rows) == 0 {
+		return nil, io.EOF
+	}
+	next := sd.rows[0]
+	sd.rows[0] = nil
+	sd.rows = sd.rows[1:]
+	return next, nil
+}
+
+func (sd *sortedDataset) sort() error {
+	var values []*OrderByValue
+	for {
+		next, err := sd.Dataset.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		keyed, ok := next.(proto.KeyedRow)
+		if !ok {
+			return errors.Errorf("cannot sort the row type %T", next)
+		}
+		value := &OrderByValue{
+			OrderValues: make(map[string]proto.Value, len(sd.items)),
+		}
+		for _, item := range sd.items {
+			if value.OrderValues[item.Column], err = keyed.Get(item.Column); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if sd.tracker != nil {
+			if err = sd.tracker.Consume(int64(next.Length())); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		sd.rows = append(sd.rows, next)
+		values = append(values, value)
+	}
+
+	sort.Stable(&sortedRows{rows: sd.rows, values: values, items: sd.items})
+	return nil
+}
+
+type sortedRows struct {
+	rows   []proto.Row
+	values []*OrderByValue
+	items  []OrderByItem
+}
+
+func (s *sortedRows) Len() int {
+	return len(s.rows)
+}
+
+func (s *sortedRows) Less(i, j int) bool {
+	return compare(s.values[i], s.values[j], s.items) < 0
+}
+
+func (s *sortedRows) Swap(i, j int) {
+	s.rows[i], s.rows[j] = s.rows[j], s.rows[i]
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+}
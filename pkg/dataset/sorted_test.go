@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dataset
+
+import (
+	"io"
+	"testing"
+)
+
+import (
+	"github.com/golang/mock/gomock"
+
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedDataset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pd := generateFakeParallelDataset(ctrl, 0, 3, 3, 3)
+	sd := NewSortedDataset(pd, []OrderByItem{
+		{
+			Column: "id",
+			Desc:   true,
+		},
+	}, nil)
+
+	var pojo fakePojo
+	for i := 5; i >= 0; i-- {
+		row, err := sd.Next()
+		assert.NoError(t, err)
+		assert.NoError(t, scanPojo(row, &pojo))
+		assert.Equal(t, int64(i), pojo.ID)
+	}
+
+	_, err := sd.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+type fakeTracker struct {
+	used, limit int64
+}
+
+func (ft *fakeTracker) Consume(n int64) error {
+	if ft.used += n; ft.used > ft.limit {
+		return errors.New("exceeded")
+	}
+	return nil
+}
+
+func TestSortedDataset_MemoryLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tracker := &fakeTracker{limit: 1 << 20}
+	sd := NewSortedDataset(generateFakeParallelDataset(ctrl, 0, 3, 3, 3), []OrderByItem{{Column: "id"}}, tracker)
+	for i := 0; i < 6; i++ {
+		_, err := sd.Next()
+		assert.NoError(t, err)
+	}
+	assert.Greater(t, tracker.used, int64(0))
+
+	// abort if the buffered rows exceed the limit
+	tracker = &fakeTracker{limit: 1}
+	sd = NewSortedDataset(generateFakeParallelDataset(ctrl, 0, 3, 3, 3), []OrderByItem{{Column: "id"}}, tracker)
+	_, err := sd.Next()
+	assert.EqualError(t, errors.Cause(err), "exceeded")
+}
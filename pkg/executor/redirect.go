@@ -19,8 +19,10 @@ package executor
 
 import (
 	"bytes"
+	"context"
 	stdErrors "errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -44,6 +46,7 @@ import (
 import (
 	mConstants "github.com/arana-db/arana/pkg/constants/mysql"
 	"github.com/arana-db/arana/pkg/metrics"
+	"github.com/arana-db/arana/pkg/mysql"
 	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/hint"
@@ -80,6 +83,31 @@ func getCharsetCollation(c uint8) (string, string) {
 	return charset, collation
 }
 
+// checkUnsupportedStatement returns an error if the statement which the parser doesn't recognize has no meaningful
+// sharded semantics, for example: HANDLER t OPEN, HANDLER t READ FIRST.
+func checkUnsupportedStatement(query string) error {
+	// the tokens are normalized by the lexer of parser, eg: '/* foo */ Handler t OPEN;' -> '`handler` `t` open ;'
+	words := strings.Fields(parser.Normalize(query))
+	for i := range words {
+		words[i] = strings.Trim(words[i], "`")
+	}
+	if len(words) < 2 || words[0] != "handler" {
+		return nil
+	}
+
+	// display the statement as 'HANDLER tbl_name ACTION'
+	words = words[1:]
+	if len(words) > 2 {
+		words = words[:2]
+	}
+	return mysqlErrors.NewSQLError(
+		mConstants.ERNotSupportedYet,
+		mConstants.SS42000,
+		"HANDLER statement is unsupported in sharded mode: HANDLER %s",
+		strings.Join(words, " "),
+	)
+}
+
 // IsErrMissingTx returns true if target error was caused by missing-tx.
 func IsErrMissingTx(err error) bool {
 	return errors.Is(err, errMissingTx)
@@ -140,18 +168,69 @@ func (executor *RedirectExecutor) ExecuteFieldList(ctx *proto.Context) ([]proto.
 		return nil, errors.WithStack(err)
 	}
 
-	if vt, ok := rt.Namespace().Rule().VTable(table); ok {
-		if _, atomTable, exist := vt.Topology().Render(0, 0); exist {
-			table = atomTable
+	vt, ok := rt.Namespace().Rule().VTable(table)
+	if !ok {
+		db := rt.Namespace().DB0(ctx.Context)
+		if db == nil {
+			return nil, errors.New("cannot get physical backend connection")
 		}
+		return db.CallFieldList(ctx.Context, table, wildcard)
 	}
 
-	db := rt.Namespace().DB0(ctx.Context)
+	// the logical table is answered by its smallest physical table, which lives in the group of the shard
+	group, atomTable, ok := vt.Topology().Smallest()
+	if !ok {
+		return nil, errors.Errorf("cannot list fields of '%s': the topology is empty", table)
+	}
+
+	db := rt.Namespace().DB(ctx.Context, group)
 	if db == nil {
-		return nil, errors.New("cannot get physical backend connection")
+		return nil, errors.Errorf("cannot get physical backend connection of group '%s'", group)
+	}
+
+	fields, err := db.CallFieldList(ctx.Context, atomTable, wildcard)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return toLogicalFields(ctx, table, atomTable, fields), nil
+}
+
+// toLogicalFields renames the fields of physical table to the logical table, the fields are ordered by the
+// cached columns of logical table, and the ones which are unknown to the metadata are dropped.
+func toLogicalFields(ctx *proto.Context, table, atomTable string, fields []proto.Field) []proto.Field {
+	for _, f := range fields {
+		if mf, ok := f.(*mysql.Field); ok {
+			mf.SetTableName(table)
+			mf.SetDatabaseName(ctx.C.Schema())
+		}
+	}
+
+	metadatas, err := proto.LoadSchemaLoader().Load(ctx.Context, ctx.C.Schema(), []string{atomTable})
+	if err != nil {
+		log.Warnf("failed to load metadata of table %s when listing fields: %v", atomTable, err)
+		return fields
+	}
+	metadata, ok := metadatas[atomTable]
+	if !ok || len(metadata.ColumnNames) < 1 {
+		return fields
+	}
+
+	ordinals := make(map[string]int, len(metadata.ColumnNames))
+	for i, name := range metadata.ColumnNames {
+		ordinals[strings.ToLower(name)] = i
 	}
 
-	return db.CallFieldList(ctx.Context, table, wildcard)
+	ret := make([]proto.Field, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := ordinals[strings.ToLower(f.Name())]; ok {
+			ret = append(ret, f)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ordinals[strings.ToLower(ret[i].Name())] < ordinals[strings.ToLower(ret[j].Name())]
+	})
+	return ret
 }
 
 func (executor *RedirectExecutor) doExecutorComQuery(ctx *proto.Context, act ast.StmtNode) (proto.Result, uint16, error) {
@@ -225,8 +304,13 @@ func (executor *RedirectExecutor) doExecutorComQuery(ctx *proto.Context, act ast
 			if err != nil {
 				return nil, 0, err
 			}
+			var beginCtx context.Context = ctx
+			if stmt.ReadOnly { // START TRANSACTION READ ONLY
+				beginCtx = rcontext.WithTxReadOnly(beginCtx)
+			}
 			var tx proto.Tx
-			if tx, err = rt.Begin(ctx, xaHook); err == nil {
+			if tx, err = rt.Begin(beginCtx, xaHook); err == nil {
+				rcontext.ConsumeNextTxCharacteristics(ctx)
 				executor.putTx(ctx, tx)
 				res = resultx.New()
 			}
@@ -317,8 +401,15 @@ func (executor *RedirectExecutor) doExecutorComQuery(ctx *proto.Context, act ast
 	return res, warn, err
 }
 
-func (executor *RedirectExecutor) ExecutorComQuery(ctx *proto.Context, h func(result proto.Result, warns uint16, failure error) error) error {
+func (executor *RedirectExecutor) ExecutorComQuery(ctx *proto.Context, h func(result proto.Result, warns uint16, failure error, more bool) error) error {
+	p := parser.New()
+	query := ctx.GetQuery()
+
 	wrapParseErr := func(err error) error {
+		// the statements which are not supported by parser, eg: HANDLER
+		if unsupported := checkUnsupportedStatement(query); unsupported != nil {
+			return unsupported
+		}
 		return mysqlErrors.NewSQLError(
 			mConstants.ERParseError,
 			mConstants.SS42000,
@@ -327,11 +418,8 @@ func (executor *RedirectExecutor) ExecutorComQuery(ctx *proto.Context, h func(re
 		)
 	}
 
-	p := parser.New()
-	query := ctx.GetQuery()
-
 	if len(query) < 1 {
-		return h(nil, 0, errEmptyQuery)
+		return h(nil, 0, errEmptyQuery, false)
 	}
 
 	log.DebugfWithLogType(log.LogicalSqlLog, "ComQuery: '%s'", query)
@@ -351,7 +439,7 @@ func (executor *RedirectExecutor) ExecutorComQuery(ctx *proto.Context, h func(re
 		} else {
 			failure = wrapParseErr(failure)
 		}
-		return h(result, warns, failure)
+		return h(result, warns, failure, false)
 	case len(query) - 1: // suffix is ';'
 		ctx.Data = ctx.Data[:len(ctx.Data)-1]
 		var (
@@ -365,16 +453,18 @@ func (executor *RedirectExecutor) ExecutorComQuery(ctx *proto.Context, h func(re
 		} else {
 			failure = wrapParseErr(failure)
 		}
-		return h(result, warns, failure)
+		return h(result, warns, failure, false)
 	}
 
-	// slow path
+	// slow path: multiple statements, eg: 'SELECT 1; SELECT 2'
 	p = parser.New()
 	stmts, _, err := p.Parse(query, charset, collation)
 	if err != nil {
-		return h(nil, 0, wrapParseErr(err))
+		return h(nil, 0, wrapParseErr(err), false)
 	}
 
+	// Each statement is optimized and routed independently, and it shares the session with others, so the
+	// transaction began by previous statement takes effect. The batch stops at the first failed statement.
 	for i := range stmts {
 		stmt := stmts[i]
 		q := strings.TrimFunc(stmt.OriginalText(), func(r rune) bool {
@@ -386,11 +476,13 @@ func (executor *RedirectExecutor) ExecutorComQuery(ctx *proto.Context, h func(re
 
 		result, warns, failure := executor.doExecutorComQuery(&ctx2, stmt)
 
-		if err := h(result, warns, failure); err != nil {
+		// the result must be consumed before executing the next statement, which may use the same backend connection
+		more := failure == nil && i < len(stmts)-1
+		if err := h(result, warns, failure, more); err != nil {
 			return err
 		}
 
-		if failure != nil {
+		if !more {
 			break
 		}
 	}
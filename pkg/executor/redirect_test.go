@@ -30,6 +30,8 @@ import (
 )
 
 import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/mysql"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/testdata"
 )
@@ -39,6 +41,28 @@ func TestIsErrMissingTx(t *testing.T) {
 	assert.True(t, IsErrMissingTx(err))
 }
 
+func TestCheckUnsupportedStatement(t *testing.T) {
+	for _, it := range []string{
+		"HANDLER student OPEN",
+		"  handler student READ FIRST;",
+		"HANDLER\tstudent CLOSE",
+		"/* comment */ HANDLER student READ NEXT",
+	} {
+		err := checkUnsupportedStatement(it)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported in sharded mode")
+		t.Log(err)
+	}
+
+	for _, it := range []string{
+		"select * from handler",
+		"handlers",
+		"update handler set x = 1",
+	} {
+		assert.NoError(t, checkUnsupportedStatement(it))
+	}
+}
+
 func TestProcessDistributedTransaction(t *testing.T) {
 	redirect := NewRedirectExecutor()
 	assert.False(t, redirect.ProcessDistributedTransaction())
@@ -67,6 +91,76 @@ func TestInLocalTransaction(t *testing.T) {
 	assert.False(t, result)
 }
 
+func TestExecutorComQuery_MultiStatements(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := testdata.NewMockFrontConn(ctrl)
+	c.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	c.EXPECT().CharacterSet().Return(uint8(33)).AnyTimes()
+	c.EXPECT().Schema().Return("").AnyTimes()
+	c.EXPECT().Tenant().Return("fake_tenant").AnyTimes()
+
+	type callback struct {
+		failure error
+		more    bool
+	}
+
+	var (
+		redirect  = NewRedirectExecutor()
+		callbacks []callback
+	)
+
+	ctx := createContext(c)
+	ctx.Data = []byte("select 1; select 2")
+	err := redirect.ExecutorComQuery(ctx, func(_ proto.Result, _ uint16, failure error, more bool) error {
+		callbacks = append(callbacks, callback{failure, more})
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// no database selected, the batch stops at the first failed statement
+	assert.Len(t, callbacks, 1)
+	assert.Error(t, callbacks[0].failure)
+	assert.False(t, callbacks[0].more)
+}
+
+func TestToLogicalFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := testdata.NewMockFrontConn(ctrl)
+	c.EXPECT().Schema().Return("employees").AnyTimes()
+	c.EXPECT().Tenant().Return("fake_tenant").AnyTimes()
+
+	loader := testdata.NewMockSchemaLoader(ctrl)
+	loader.EXPECT().Load(gomock.Any(), "employees", []string{"student_0000"}).
+		Return(map[string]*proto.TableMetadata{
+			"student_0000": {
+				Name:        "student_0000",
+				ColumnNames: []string{"id", "uid", "name"},
+			},
+		}, nil)
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	var fields []proto.Field
+	for _, name := range []string{"uid", "id", "phantom", "name"} {
+		fields = append(fields, mysql.NewField(name, consts.FieldTypeLongLong))
+	}
+
+	actual := toLogicalFields(createContext(c), "student", "student_0000", fields)
+	var names []string
+	for _, f := range actual {
+		names = append(names, f.Name())
+		assert.Equal(t, "student", f.(*mysql.Field).TableName())
+		assert.Equal(t, "employees", f.(*mysql.Field).DatabaseName())
+	}
+	assert.Equal(t, []string{"id", "uid", "name"}, names)
+}
+
 func createContext(c proto.FrontConn) *proto.Context {
 	result := &proto.Context{
 		C:    c,
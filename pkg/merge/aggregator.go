@@ -25,3 +25,16 @@ type Aggregator interface {
 	Aggregate(values []proto.Value)
 	GetResult() (proto.Value, bool)
 }
+
+// MultiColumnAggregator represents the aggregator which merges the values of several columns in one row,
+// eg: the variances of shards are merged with their partial COUNT and SUM.
+type MultiColumnAggregator interface {
+	Aggregator
+	// Columns returns the indexes of columns, the values will be aggregated in the same order.
+	Columns() []int
+}
+
+// MemoryTracker accounts the memory of values held by aggregators, an error should be returned if the limit is exceeded.
+type MemoryTracker interface {
+	Consume(n int64) error
+}
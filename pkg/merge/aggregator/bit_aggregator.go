@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+// BitAggregator merges the partial results of BIT_AND, BIT_OR or BIT_XOR, which can be combined with the same operation.
+type BitAggregator struct {
+	op    func(a, b uint64) uint64
+	value uint64
+	valid bool
+}
+
+func NewBitAndAggregator() *BitAggregator {
+	return &BitAggregator{op: func(a, b uint64) uint64 { return a & b }}
+}
+
+func NewBitOrAggregator() *BitAggregator {
+	return &BitAggregator{op: func(a, b uint64) uint64 { return a | b }}
+}
+
+func NewBitXorAggregator() *BitAggregator {
+	return &BitAggregator{op: func(a, b uint64) uint64 { return a ^ b }}
+}
+
+func (b *BitAggregator) Aggregate(values []proto.Value) {
+	if len(values) == 0 || values[0] == nil {
+		return
+	}
+
+	val, err := values[0].Uint64()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	if !b.valid {
+		b.valid = true
+		b.value = val
+		return
+	}
+
+	b.value = b.op(b.value, val)
+}
+
+func (b *BitAggregator) GetResult() (proto.Value, bool) {
+	if !b.valid {
+		return nil, false
+	}
+	return proto.NewValueUint64(b.value), true
+}
@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+func TestBitAggregator(t *testing.T) {
+	params := []struct {
+		agg    *BitAggregator
+		nums   [][]proto.Value
+		result uint64
+		valid  bool
+	}{
+		{
+			agg:    NewBitAndAggregator(),
+			nums:   [][]proto.Value{{proto.NewValueInt64(0b1110)}, {proto.NewValueUint64(0b0111)}, {nil}},
+			result: 0b0110,
+			valid:  true,
+		},
+		{
+			agg:    NewBitOrAggregator(),
+			nums:   [][]proto.Value{{proto.NewValueInt64(0b1000)}, {proto.NewValueInt64(0b0011)}},
+			result: 0b1011,
+			valid:  true,
+		},
+		{
+			agg:    NewBitXorAggregator(),
+			nums:   [][]proto.Value{{proto.NewValueInt64(0b1100)}, {proto.NewValueInt64(0b1010)}, {proto.NewValueInt64(0b0001)}},
+			result: 0b0111,
+			valid:  true,
+		},
+		{
+			agg:   NewBitOrAggregator(),
+			nums:  [][]proto.Value{{}, {nil}},
+			valid: false,
+		},
+	}
+
+	for _, param := range params {
+		for _, agg := range param.nums {
+			param.agg.Aggregate(agg)
+		}
+		resp, ok := param.agg.GetResult()
+		assert.Equal(t, param.valid, ok)
+		if !param.valid {
+			assert.Nil(t, resp)
+			continue
+		}
+		actual, err := resp.Uint64()
+		assert.NoError(t, err)
+		assert.Equal(t, param.result, actual)
+	}
+
+	assert.NotPanics(t, func() {
+		_ = GetAggFromName("BIT_XOR")()
+	})
+}
@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/merge"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+)
+
+// DistinctAggregator aggregates the values after deduplicated, eg: SUM(DISTINCT amount).
+// NULL values are ignored, it supports SUM, AVG and COUNT.
+type DistinctAggregator struct {
+	// Tracker accounts the memory of the distinct values, nil means no limit.
+	Tracker merge.MemoryTracker
+
+	name  string
+	seen  map[string]struct{}
+	count int64
+	sum   decimal.Decimal
+	err   error
+}
+
+func NewDistinctAggregator(name string) *DistinctAggregator {
+	return &DistinctAggregator{
+		name: name,
+		seen: make(map[string]struct{}),
+	}
+}
+
+func (d *DistinctAggregator) Aggregate(values []proto.Value) {
+	if len(values) == 0 || d.err != nil {
+		return
+	}
+
+	if values[0] == nil {
+		return
+	}
+
+	key := values[0].String()
+	switch values[0].Family() {
+	case proto.ValueFamilySign, proto.ValueFamilyUnsigned, proto.ValueFamilyFloat, proto.ValueFamilyDecimal:
+		// normalize numbers, eg: 5 and 5.00 are same
+		val, err := values[0].Decimal()
+		if err != nil {
+			panic(err.Error())
+		}
+		key = val.String()
+	}
+
+	if _, ok := d.seen[key]; ok {
+		return
+	}
+	if d.Tracker != nil {
+		if d.err = d.Tracker.Consume(int64(len(key))); d.err != nil {
+			return
+		}
+	}
+	d.seen[key] = struct{}{}
+	d.count++
+
+	if d.name == ast.AggrCount {
+		return
+	}
+
+	val, err := values[0].Decimal()
+	if err != nil {
+		panic(err.Error())
+	}
+	d.sum = d.sum.Add(val)
+}
+
+// Err returns the error which stops aggregating, eg: the memory limit is exceeded.
+func (d *DistinctAggregator) Err() error {
+	return d.err
+}
+
+func (d *DistinctAggregator) GetResult() (proto.Value, bool) {
+	switch d.name {
+	case ast.AggrCount:
+		return proto.NewValueInt64(d.count), true
+	case ast.AggrSum:
+		if d.count == 0 {
+			return nil, true
+		}
+		return proto.NewValueDecimal(d.sum), true
+	case ast.AggrAvg:
+		if d.count == 0 {
+			return nil, true
+		}
+		return proto.NewValueDecimal(d.sum.Div(decimal.NewFromInt(d.count))), true
+	default:
+		return nil, false
+	}
+}
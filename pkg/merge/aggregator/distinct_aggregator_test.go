@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+)
+
+func TestDistinctAggregator(t *testing.T) {
+	values := [][]proto.Value{
+		{proto.NewValueInt64(1)},
+		{proto.NewValueInt64(2)},
+		{proto.NewValueFloat64(2)},
+		{proto.NewValueInt64(3)},
+		{nil},
+		{proto.NewValueString("3")},
+		{proto.NewValueInt64(1)},
+		{proto.NewValueInt64(6)},
+		{},
+	}
+
+	params := []struct {
+		name   string
+		result float64
+	}{
+		{ast.AggrCount, 4},
+		{ast.AggrSum, 12},
+		{ast.AggrAvg, 3},
+	}
+
+	for _, param := range params {
+		t.Run(param.name, func(t *testing.T) {
+			aggr := NewDistinctAggregator(param.name)
+			for _, next := range values {
+				aggr.Aggregate(next)
+			}
+			resp, ok := aggr.GetResult()
+			assert.True(t, ok)
+			f, err := resp.Float64()
+			assert.NoError(t, err)
+			assert.EqualValues(t, param.result, f)
+		})
+	}
+}
+
+func TestDistinctAggregator_Empty(t *testing.T) {
+	resp, ok := NewDistinctAggregator(ast.AggrCount).GetResult()
+	assert.True(t, ok)
+	assert.Equal(t, proto.NewValueInt64(0), resp)
+
+	for _, name := range []string{ast.AggrSum, ast.AggrAvg} {
+		resp, ok = NewDistinctAggregator(name).GetResult()
+		assert.True(t, ok)
+		assert.Nil(t, resp)
+	}
+}
+
+type fakeTracker struct {
+	used, limit int64
+}
+
+func (f *fakeTracker) Consume(n int64) error {
+	if f.used += n; f.used > f.limit {
+		return errors.New("exceeded")
+	}
+	return nil
+}
+
+func TestDistinctAggregator_Tracker(t *testing.T) {
+	aggr := NewDistinctAggregator(ast.AggrCount)
+	aggr.Tracker = &fakeTracker{limit: 2}
+
+	aggr.Aggregate([]proto.Value{proto.NewValueInt64(1)})
+	aggr.Aggregate([]proto.Value{proto.NewValueInt64(1)})
+	assert.NoError(t, aggr.Err())
+	aggr.Aggregate([]proto.Value{proto.NewValueInt64(2)})
+	assert.NoError(t, aggr.Err())
+	aggr.Aggregate([]proto.Value{proto.NewValueInt64(3)})
+	assert.Error(t, aggr.Err())
+}
@@ -32,6 +32,9 @@ func init() {
 	aggregatorMap["MIN"] = func() merge.Aggregator { return &MinAggregator{} }
 	aggregatorMap["COUNT"] = func() merge.Aggregator { return &AddAggregator{} }
 	aggregatorMap["SUM"] = func() merge.Aggregator { return &AddAggregator{} }
+	aggregatorMap["BIT_AND"] = func() merge.Aggregator { return NewBitAndAggregator() }
+	aggregatorMap["BIT_OR"] = func() merge.Aggregator { return NewBitOrAggregator() }
+	aggregatorMap["BIT_XOR"] = func() merge.Aggregator { return NewBitXorAggregator() }
 }
 
 func GetAggFromName(name string) func() merge.Aggregator {
@@ -17,28 +17,78 @@
 
 package aggregator
 
+import (
+	"fmt"
+)
+
 import (
 	"github.com/arana-db/arana/pkg/merge"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/ast/ext"
 )
 
 func LoadAggs(fields []ast.SelectElement) map[int]func() merge.Aggregator {
 	aggMap := make(map[int]func() merge.Aggregator)
-	enter := func(i int, n *ast.AggrFunction) {
-		if n == nil {
-			return
-		}
-		aggMap[i] = GetAggFromName(n.Name())
-	}
-
 	for i, field := range fields {
-		if field == nil {
+		aggr := AggregateOf(field)
+		if aggr == nil {
 			continue
 		}
-		if f, ok := field.(*ast.SelectElementFunction); ok {
-			enter(i, f.Function().(*ast.AggrFunction))
+		if aggr.Name() == ast.AggrVarPop {
+			aggMap[i] = loadVariance(fields, i, aggr)
+			continue
 		}
+		aggMap[i] = GetAggFromName(aggr.Name())
 	}
 
 	return aggMap
 }
+
+// AggregateOf returns the aggregate function which merges the values of select element, nil will be returned if
+// the values should not be merged, eg: the non-aggregate columns, or the values computed from other fields.
+func AggregateOf(field ast.SelectElement) *ast.AggrFunction {
+	switch x := field.(type) {
+	case nil:
+		return nil
+	case *ext.MappingSelectElement:
+		if x.Placeholder == nil {
+			// the value will be computed from the other aggregated fields
+			return nil
+		}
+		// the placeholder is the partial aggregate, eg: VAR_POP(x) of VAR_SAMP(x)
+		field = x.Placeholder
+	case *ext.DistinctAggrSelectElement:
+		// the value will be computed from the distinct values
+		return nil
+	case *ext.WeakSelectElement:
+		// the partial aggregates which are appended implicitly, eg: SUM(x) and COUNT(x) of AVG(x)
+		return AggregateOf(x.SelectElement)
+	case ext.SelectElementProvider:
+		field = x.Prev()
+	}
+	if f, ok := field.(*ast.SelectElementFunction); ok {
+		// skip the non-aggregate functions, eg: YEAR(d)
+		if aggr, ok := f.Function().(*ast.AggrFunction); ok {
+			return aggr
+		}
+	}
+	return nil
+}
+
+// loadVariance creates the aggregator of VAR_POP at index i, which is merged with the partial COUNT and SUM.
+func loadVariance(fields []ast.SelectElement, i int, varPop *ast.AggrFunction) func() merge.Aggregator {
+	indexOf := func(name string) int {
+		expect := ast.MustRestoreToString(ast.RestoreDefault, ast.NewAggrFunction(name, "", varPop.Args()))
+		for j, field := range fields {
+			if aggr := AggregateOf(field); aggr != nil && ast.MustRestoreToString(ast.RestoreDefault, aggr) == expect {
+				return j
+			}
+		}
+		panic(fmt.Errorf("no partial aggregate %s found for %s", expect, ast.MustRestoreToString(ast.RestoreDefault, varPop)))
+	}
+
+	count, sum := indexOf(ast.AggrCount), indexOf(ast.AggrSum)
+	return func() merge.Aggregator {
+		return NewVarianceAggregator(i, count, sum)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"github.com/arana-db/arana/pkg/merge"
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+var _ merge.MultiColumnAggregator = (*VarianceAggregator)(nil)
+
+// VarianceAggregator merges the population variances of shards with the partial COUNT and SUM of each shard.
+// The parallel algorithm of Chan et al. is used, which avoids the cancellation of SUM(x*x) - SUM(x)*SUM(x)/COUNT(x):
+//
+//	n = na + nb, delta = mean_b - mean_a
+//	M2 = M2a + M2b + delta*delta*na*nb/n
+type VarianceAggregator struct {
+	columns []int
+	n       float64
+	mean    float64
+	m2      float64
+}
+
+// NewVarianceAggregator creates a VarianceAggregator with the indexes of VAR_POP, COUNT and SUM.
+func NewVarianceAggregator(varPop, count, sum int) *VarianceAggregator {
+	return &VarianceAggregator{
+		columns: []int{varPop, count, sum},
+	}
+}
+
+func (v *VarianceAggregator) Columns() []int {
+	return v.columns
+}
+
+// Aggregate merges the partial VAR_POP, COUNT and SUM of a shard.
+func (v *VarianceAggregator) Aggregate(values []proto.Value) {
+	if len(values) < 3 || values[0] == nil || values[1] == nil || values[2] == nil {
+		return
+	}
+
+	variance, err := values[0].Float64()
+	if err != nil {
+		panic(err.Error())
+	}
+	n, err := values[1].Float64()
+	if err != nil {
+		panic(err.Error())
+	}
+	sum, err := values[2].Float64()
+	if err != nil {
+		panic(err.Error())
+	}
+	if n == 0 {
+		return
+	}
+
+	mean := sum / n
+	if v.n == 0 {
+		v.n, v.mean, v.m2 = n, mean, variance*n
+		return
+	}
+
+	var (
+		total = v.n + n
+		delta = mean - v.mean
+	)
+	v.m2 += variance*n + delta*delta*v.n*n/total
+	v.mean += delta * n / total
+	v.n = total
+}
+
+// GetResult returns the population variance, NULL will be returned if there are no values.
+func (v *VarianceAggregator) GetResult() (proto.Value, bool) {
+	if v.n == 0 {
+		return nil, true
+	}
+	return proto.NewValueFloat64(v.m2 / v.n), true
+}
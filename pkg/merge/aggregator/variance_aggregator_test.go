@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregator
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+func TestVarianceAggregator(t *testing.T) {
+	partial := func(values ...float64) []proto.Value {
+		var sum, m2 float64
+		for _, it := range values {
+			sum += it
+		}
+		mean := sum / float64(len(values))
+		for _, it := range values {
+			m2 += (it - mean) * (it - mean)
+		}
+		return []proto.Value{
+			proto.NewValueFloat64(m2 / float64(len(values))),
+			proto.NewValueInt64(int64(len(values))),
+			proto.NewValueFloat64(sum),
+		}
+	}
+
+	t.Run("Merge", func(t *testing.T) {
+		aggr := NewVarianceAggregator(0, 1, 2)
+		assert.Equal(t, []int{0, 1, 2}, aggr.Columns())
+		aggr.Aggregate(partial(1, 5))
+		aggr.Aggregate([]proto.Value{nil, proto.NewValueInt64(0), nil})
+		aggr.Aggregate(partial(3))
+		ret, ok := aggr.GetResult()
+		assert.True(t, ok)
+		actual, err := ret.Float64()
+		assert.NoError(t, err)
+		// 1, 3, 5
+		assert.InDelta(t, 8.0/3, actual, 1e-9)
+	})
+
+	t.Run("Stable", func(t *testing.T) {
+		// SUM(x*x) - SUM(x)*SUM(x)/COUNT(x) loses all significant digits of such values
+		const base = 1e9
+		aggr := NewVarianceAggregator(0, 1, 2)
+		aggr.Aggregate(partial(base+4, base+7))
+		aggr.Aggregate(partial(base+13, base+16))
+		ret, _ := aggr.GetResult()
+		actual, err := ret.Float64()
+		assert.NoError(t, err)
+		assert.InDelta(t, 22.5, actual, 1e-6)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		aggr := NewVarianceAggregator(0, 1, 2)
+		aggr.Aggregate([]proto.Value{nil, proto.NewValueInt64(0), nil})
+		ret, ok := aggr.GetResult()
+		assert.True(t, ok)
+		assert.Nil(t, ret)
+	})
+}
@@ -45,10 +45,83 @@ var (
 		Help:      "histogram of processing time (s) in execute.",
 		Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 30), // 100us ~ 15h,
 	})
+
+	FailoverCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "arana",
+		Subsystem: "runtime",
+		Name:      "failover_total",
+		Help:      "counter of read requests failed over to another database node.",
+	}, []string{"group"})
+
+	BackendPoolInUse = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "arana",
+		Subsystem: "backend_pool",
+		Name:      "in_use",
+		Help:      "gauge of backend connections which are in use.",
+	}, []string{"node"})
+
+	BackendPoolIdle = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "arana",
+		Subsystem: "backend_pool",
+		Name:      "idle",
+		Help:      "gauge of backend connections which are open but idle.",
+	}, []string{"node"})
+
+	BackendPoolMaxOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "arana",
+		Subsystem: "backend_pool",
+		Name:      "max_open",
+		Help:      "gauge of the max number of backend connections which can be opened.",
+	}, []string{"node"})
+
+	BackendPoolWaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "arana",
+		Subsystem: "backend_pool",
+		Name:      "wait_duration_seconds",
+		Help:      "histogram of time (s) waiting for a backend connection.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 2, 25), // 10us ~ 5min
+	}, []string{"node"})
+
+	BackendPoolExhausted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "arana",
+		Subsystem: "backend_pool",
+		Name:      "exhausted_total",
+		Help:      "counter of requests failed since no backend connection is available in time.",
+	}, []string{"node"})
+
+	BackendCircuitState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "arana",
+		Subsystem: "backend_circuit",
+		Name:      "state",
+		Help:      "gauge of the circuit breaker state of backend, 0: closed, 1: open, 2: half-open.",
+	}, []string{"node"})
+
+	BackendCircuitRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "arana",
+		Subsystem: "backend_circuit",
+		Name:      "rejected_total",
+		Help:      "counter of requests failed fast since the circuit breaker of backend is open.",
+	}, []string{"node"})
+
+	AuditDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "arana",
+		Subsystem: "audit",
+		Name:      "dropped_total",
+		Help:      "counter of audit records dropped since the buffer is full.",
+	})
 )
 
 func RegisterMetrics() {
 	prometheus.MustRegister(ParserDuration)
 	prometheus.MustRegister(OptimizeDuration)
 	prometheus.MustRegister(ExecuteDuration)
+	prometheus.MustRegister(FailoverCount)
+	prometheus.MustRegister(BackendPoolInUse)
+	prometheus.MustRegister(BackendPoolIdle)
+	prometheus.MustRegister(BackendPoolMaxOpen)
+	prometheus.MustRegister(BackendPoolWaitDuration)
+	prometheus.MustRegister(BackendPoolExhausted)
+	prometheus.MustRegister(BackendCircuitState)
+	prometheus.MustRegister(BackendCircuitRejected)
+	prometheus.MustRegister(AuditDropped)
 }
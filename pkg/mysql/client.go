@@ -475,10 +475,12 @@ type BackendConnection struct {
 	characterSet uint8
 
 	remoteVariables map[string]proto.Value
+
+	createdAt time.Time
 }
 
 func (c *Connector) NewBackendConnection(ctx context.Context) (*BackendConnection, error) {
-	conn := &BackendConnection{conf: c.conf}
+	conn := &BackendConnection{conf: c.conf, createdAt: time.Now()}
 	if err := conn.Connect(ctx); err != nil {
 		defer conn.Close()
 		return nil, err
@@ -490,6 +492,11 @@ func (c *Connector) NewBackendConnection(ctx context.Context) (*BackendConnectio
 	return conn, nil
 }
 
+// CreatedAt returns the time when the connection is established.
+func (conn *BackendConnection) CreatedAt() time.Time {
+	return conn.createdAt
+}
+
 // SyncVariables sync local transient variables with upstream mysql.
 //
 // The sync logic:
@@ -464,3 +464,47 @@ func TestReadColumnDefinitionType(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, mysql.FieldTypeVarChar, field.fieldType)
 }
+
+func TestColumnDefinitionFlags(t *testing.T) {
+	// id BIGINT UNSIGNED NOT NULL PRIMARY KEY
+	flags := mysql.NotNullFlag | mysql.PriKeyFlag | mysql.UnsignedFlag
+
+	payload := make([]byte, 64)
+	pos := writeLenEncString(payload, 0, "def")
+	pos = writeLenEncString(payload, pos, "employees")
+	pos = writeLenEncString(payload, pos, "student")
+	pos = writeLenEncString(payload, pos, "student_0001")
+	pos = writeLenEncString(payload, pos, "id")
+	pos = writeLenEncString(payload, pos, "id")
+	pos = writeByte(payload, pos, 0x0c)
+	pos = writeUint16(payload, pos, 63) // binary
+	pos = writeUint32(payload, pos, 20)
+	pos = writeByte(payload, pos, 0x08) // BIGINT
+	pos = writeUint16(payload, pos, uint16(flags))
+	pos = writeByte(payload, pos, 0x00)
+	pos = writeUint16(payload, pos, 0x0000)
+
+	packet := append([]byte{byte(pos), 0x00, 0x00, 0x00}, payload[:pos]...)
+
+	read := func(data []byte) *Field {
+		conn := &BackendConnection{}
+		conn.c = newConn(&mockConn{data: data})
+		field := &Field{}
+		assert.NoError(t, conn.ReadColumnDefinition(field, 0))
+		return field
+	}
+
+	// read from backend
+	field := read(packet)
+	assert.Equal(t, mysql.FieldTypeLongLong, field.fieldType)
+	assert.True(t, mysql.HasUnsignedFlag(field.flags))
+	assert.True(t, mysql.HasPriKeyFlag(field.flags))
+	assert.True(t, mysql.HasNotNullFlag(field.flags))
+
+	// the flags returned to client should be same as the backend
+	mc := new(mockConn)
+	c := newConn(mc)
+	assert.NoError(t, c.writeColumnDefinition(field))
+	assert.Equal(t, packet[4:], mc.written[4:])
+	assert.Equal(t, flags, read(mc.written).flags)
+}
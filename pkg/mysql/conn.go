@@ -97,6 +97,9 @@ type Conn struct {
 	// tenant is the current tenant login.
 	tenant string
 
+	// username is the name of the user logged in.
+	username string
+
 	// connectionID is set:
 	// - at Connect() time for clients, with the value returned by
 	// the server.
@@ -107,6 +110,9 @@ type Conn struct {
 	// These variables will always keep sync with backend mysql conns.
 	transientVariables map[string]proto.Value
 
+	// warnings represents the warnings of the last statement generated by arana.
+	warnings []*proto.Warning
+
 	// closed is set to true when Close() is called on the connection.
 	closed *atomic.Bool
 
@@ -189,6 +195,14 @@ func (c *Conn) SetTenant(t string) {
 	c.tenant = t
 }
 
+func (c *Conn) Username() string {
+	return c.username
+}
+
+func (c *Conn) SetUsername(username string) {
+	c.username = username
+}
+
 func (c *Conn) TransientVariables() map[string]proto.Value {
 	return c.transientVariables
 }
@@ -197,6 +211,14 @@ func (c *Conn) SetTransientVariables(v map[string]proto.Value) {
 	c.transientVariables = v
 }
 
+func (c *Conn) Warnings() []*proto.Warning {
+	return c.warnings
+}
+
+func (c *Conn) SetWarnings(warnings []*proto.Warning) {
+	c.warnings = warnings
+}
+
 // startWriterBuffering starts using buffered writes. This should
 // be terminated by a call to endWriteBuffering.
 func (c *Conn) startWriterBuffering() {
@@ -143,37 +143,11 @@ func (l *Listener) handleQuery(c *Conn, ctx *proto.Context) error {
 		}
 		return nil
 	}
-	type compositeResult struct {
-		r proto.Result
-		w uint16
-		e error
-	}
 
-	var prev *compositeResult
-	err := l.executor.ExecutorComQuery(ctx, func(result proto.Result, warns uint16, failure error) error {
-		if prev != nil {
-			if err := handleOnce(prev.r, prev.e, prev.w, true); err != nil {
-				return err
-			}
-		}
-		prev = &compositeResult{
-			r: result,
-			w: warns,
-			e: failure,
-		}
-		return nil
+	// write the result of each statement immediately, the status flag tells the client if there are more results
+	return l.executor.ExecutorComQuery(ctx, func(result proto.Result, warns uint16, failure error, more bool) error {
+		return handleOnce(result, failure, warns, more)
 	})
-	if err != nil {
-		return err
-	}
-
-	if prev != nil {
-		if err := handleOnce(prev.r, prev.e, prev.w, false); err != nil {
-			return err
-		}
-	}
-
-	return nil
 }
 
 func (l *Listener) handleFieldList(c *Conn, ctx *proto.Context) error {
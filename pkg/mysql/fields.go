@@ -66,6 +66,17 @@ func (mf *Field) SetOrgName(name string) {
 	mf.orgName = name
 }
 
+// SetTableName sets both the table and original table of field, eg: the logical table of a physical shard.
+func (mf *Field) SetTableName(table string) {
+	mf.table = table
+	mf.orgTable = table
+}
+
+// SetDatabaseName sets the database of field.
+func (mf *Field) SetDatabaseName(database string) {
+	mf.database = database
+}
+
 func (mf *Field) SetName(name string) {
 	// TODO: should resize columnLength???
 	mf.name = name
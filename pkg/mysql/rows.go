@@ -48,13 +48,7 @@ type BinaryRow struct {
 }
 
 func (bi BinaryRow) Get(name string) (proto.Value, error) {
-	idx := -1
-	for i, it := range bi.fields {
-		if it.Name() == name {
-			idx = i
-			break
-		}
-	}
+	idx := proto.IndexOfField(bi.fields, name)
 	if idx == -1 {
 		return nil, errors.Errorf("no such field '%s' found", name)
 	}
@@ -265,13 +259,7 @@ type TextRow struct {
 }
 
 func (te TextRow) Get(name string) (proto.Value, error) {
-	idx := -1
-	for i, it := range te.fields {
-		if it.Name() == name {
-			idx = i
-			break
-		}
-	}
+	idx := proto.IndexOfField(te.fields, name)
 	if idx == -1 {
 		return nil, errors.Errorf("no such field '%s' found", name)
 	}
@@ -64,13 +64,7 @@ type baseVirtualRow struct {
 }
 
 func (b *baseVirtualRow) Get(name string) (proto.Value, error) {
-	idx := -1
-	for i, it := range b.fields {
-		if it.Name() == name {
-			idx = i
-			break
-		}
-	}
+	idx := proto.IndexOfField(b.fields, name)
 
 	if idx == -1 {
 		return nil, perrors.Errorf("no such field '%s' found", name)
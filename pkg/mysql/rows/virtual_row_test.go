@@ -115,3 +115,30 @@ func TestNew(t *testing.T) {
 		assert.Equal(t, now, createdAt.Time)
 	})
 }
+
+func TestVirtualRow_Get(t *testing.T) {
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+		mysql.NewField("Name", consts.FieldTypeString),
+		mysql.NewField("name", consts.FieldTypeString),
+	}
+	row := NewTextVirtualRow(fields, []proto.Value{
+		proto.NewValueInt64(1),
+		proto.NewValueString("foo"),
+		proto.NewValueString("bar"),
+	})
+
+	v, err := row.Get("uid")
+	assert.NoError(t, err)
+	assert.Equal(t, proto.NewValueInt64(1), v)
+
+	// the names are matched exactly, they have been resolved by optimizer
+	v, err = row.Get("name")
+	assert.NoError(t, err)
+	assert.Equal(t, proto.NewValueString("bar"), v)
+	_, err = row.Get("UID")
+	assert.Error(t, err)
+
+	_, err = row.Get("age")
+	assert.Error(t, err)
+}
@@ -263,6 +263,7 @@ func (l *Listener) handshake(c *Conn) error {
 
 	c.SetSchema(handshake.schema)
 	c.SetTenant(handshake.tenant)
+	c.SetUsername(handshake.username)
 
 	return nil
 }
@@ -42,6 +42,10 @@ var (
 		Col{Name: "weight", FieldType: consts.FieldTypeVarString},
 		Col{Name: "parameters", FieldType: consts.FieldTypeVarString},
 	}
+	Variable = Thead{
+		Col{Name: "Variable_name", FieldType: consts.FieldTypeVarString},
+		Col{Name: "Value", FieldType: consts.FieldTypeVarString},
+	}
 	Users = Thead{
 		Col{Name: "user_name", FieldType: consts.FieldTypeVarString},
 	}
@@ -78,6 +82,12 @@ var (
 		Col{Name: "expr", FieldType: consts.FieldTypeVarString},
 		Col{Name: "step", FieldType: consts.FieldTypeVarString},
 	}
+
+	Warnings = Thead{
+		Col{Name: "Level", FieldType: consts.FieldTypeVarString},
+		Col{Name: "Code", FieldType: consts.FieldTypeLong},
+		Col{Name: "Message", FieldType: consts.FieldTypeVarString},
+	}
 )
 
 type Col struct {
@@ -104,3 +104,15 @@ type (
 		RowsAffected() (uint64, error)
 	}
 )
+
+// IndexOfField returns the index of field with the name, returns -1 if not found.
+// The name is matched exactly, since the optimizer has resolved it to the name of select element in the way
+// of the table's 'case_sensitive_columns', eg: 'SELECT id ... ORDER BY ID' is merged by the field 'id'.
+func IndexOfField(fields []Field, name string) int {
+	for i := range fields {
+		if fields[i].Name() == name {
+			return i
+		}
+	}
+	return -1
+}
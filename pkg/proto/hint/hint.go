@@ -32,22 +32,36 @@ import (
 )
 
 const (
-	_            Type = iota
-	TypeMaster        // force route to master node
-	TypeSlave         // force route to slave node
-	TypeRoute         // custom route
-	TypeFullScan      // enable full-scan
-	TypeDirect        // direct route
-	TypeTrace         // distributed tracing
+	_               Type = iota
+	TypeMaster           // force route to master node
+	TypeSlave            // force route to slave node
+	TypeRoute            // custom route
+	TypeFullScan         // enable full-scan
+	TypeDirect           // direct route
+	TypeTrace            // distributed tracing
+	TypeHashJoin         // join with hash join
+	TypeNestedLoop       // join with nested loop
+	TypeOrderByPK        // append primary key to order-by items
+	TypeReplica          // force route to the named replica node
+	TypeBestEffort       // return partial results when some shards fail
+	TypeApproxCount      // estimate COUNT(*) by the table statistics
+	TypeMemoryLimit      // limit the memory of rows buffered by the query
 )
 
 var _hintTypes = [...]string{
-	TypeMaster:   "MASTER",
-	TypeSlave:    "SLAVE",
-	TypeRoute:    "ROUTE",
-	TypeFullScan: "FULLSCAN",
-	TypeDirect:   "DIRECT",
-	TypeTrace:    "TRACE",
+	TypeMaster:      "MASTER",
+	TypeSlave:       "SLAVE",
+	TypeRoute:       "ROUTE",
+	TypeFullScan:    "FULLSCAN",
+	TypeDirect:      "DIRECT",
+	TypeTrace:       "TRACE",
+	TypeHashJoin:    "HASHJOIN",
+	TypeNestedLoop:  "NESTEDLOOP",
+	TypeOrderByPK:   "ORDERBYPK",
+	TypeReplica:     "REPLICA",
+	TypeBestEffort:  "BESTEFFORT",
+	TypeApproxCount: "APPROXCOUNT",
+	TypeMemoryLimit: "MEMORYLIMIT",
 }
 
 // KeyValue represents a pair of key and value.
@@ -198,3 +212,36 @@ func Contains(hType Type, hints []*Hint) bool {
 	}
 	return false
 }
+
+// Merge appends the fallback hints which don't conflict with the given hints, the given hints take precedence.
+// The routing hints MASTER/SLAVE/REPLICA conflict with each other, so do the join hints HASHJOIN/NESTEDLOOP,
+// other hints conflict with the ones of same type.
+func Merge(hints []*Hint, fallbacks ...*Hint) []*Hint {
+	ret := hints
+	for _, fallback := range fallbacks {
+		if fallback == nil {
+			continue
+		}
+		var conflict bool
+		for _, h := range hints {
+			if kindOf(h.Type) == kindOf(fallback.Type) {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			ret = append(ret, fallback)
+		}
+	}
+	return ret
+}
+
+func kindOf(tp Type) Type {
+	switch tp {
+	case TypeSlave, TypeReplica:
+		return TypeMaster
+	case TypeNestedLoop:
+		return TypeHashJoin
+	}
+	return tp
+}
@@ -39,6 +39,13 @@ func TestParse(t *testing.T) {
 		{"not_exist_hint(1,2,3)", "", false},
 		{"route(,,,)", "ROUTE()", true},
 		{"fullscan()", "FULLSCAN()", true},
+		{"hashjoin()", "HASHJOIN()", true},
+		{"NestedLoop()", "NESTEDLOOP()", true},
+		{"OrderByPK()", "ORDERBYPK()", true},
+		{"Replica(name=replica_2)", "REPLICA(name=replica_2)", true},
+		{"BestEffort()", "BESTEFFORT()", true},
+		{"ApproxCount()", "APPROXCOUNT()", true},
+		{"MemoryLimit(64MB)", "MEMORYLIMIT(64MB)", true},
 		{"route(foo=111,bar=222,qux=333,)", "ROUTE(foo=111,bar=222,qux=333)", true},
 	} {
 		t.Run(next.input, func(t *testing.T) {
@@ -52,3 +59,27 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestMerge(t *testing.T) {
+	type tt struct {
+		inline   []*Hint
+		fallback *Hint
+		output   []Type
+	}
+
+	for _, next := range []tt{
+		{nil, nil, nil},
+		{nil, &Hint{Type: TypeMaster}, []Type{TypeMaster}},
+		{[]*Hint{{Type: TypeSlave}}, &Hint{Type: TypeMaster}, []Type{TypeSlave}},
+		{[]*Hint{{Type: TypeReplica}}, &Hint{Type: TypeMaster}, []Type{TypeReplica}},
+		{[]*Hint{{Type: TypeFullScan}}, &Hint{Type: TypeMaster}, []Type{TypeFullScan, TypeMaster}},
+		{[]*Hint{{Type: TypeNestedLoop}}, &Hint{Type: TypeHashJoin}, []Type{TypeNestedLoop}},
+		{[]*Hint{{Type: TypeRoute}}, &Hint{Type: TypeRoute}, []Type{TypeRoute}},
+	} {
+		var types []Type
+		for _, h := range Merge(next.inline, next.fallback) {
+			types = append(types, h.Type)
+		}
+		assert.Equal(t, next.output, types)
+	}
+}
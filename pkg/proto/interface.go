@@ -34,6 +34,7 @@ type (
 	ContextKeyTransientVariables     struct{}
 	ContextKeyServerVersion          struct{}
 	ContextKeyEnableLocalComputation struct{}
+	ContextKeyWarnings               struct{}
 )
 
 type (
@@ -55,6 +56,12 @@ type (
 		// SetTenant sets the tenant.
 		SetTenant(tenant string)
 
+		// Username returns the name of the user logged in.
+		Username() string
+
+		// SetUsername sets the name of the user logged in.
+		SetUsername(username string)
+
 		// TransientVariables returns the transient variables.
 		TransientVariables() map[string]Value
 
@@ -66,6 +73,19 @@ type (
 
 		// ServerVersion returns the server version.
 		ServerVersion() string
+
+		// Warnings returns the warnings of the last statement.
+		Warnings() []*Warning
+
+		// SetWarnings sets the warnings of the last statement.
+		SetWarnings(warnings []*Warning)
+	}
+
+	// Warning represents a warning generated by arana, which can be fetched by 'SHOW WARNINGS'.
+	Warning struct {
+		Level   string
+		Code    uint16
+		Message string
 	}
 
 	// Context is used to carry context objects
@@ -91,7 +111,9 @@ type (
 		InGlobalTransaction(ctx *Context) bool
 		ExecuteUseDB(ctx *Context, schema string) error
 		ExecuteFieldList(ctx *Context) ([]Field, error)
-		ExecutorComQuery(ctx *Context, callback func(Result, uint16, error) error) error
+		// ExecutorComQuery executes the query which may contain multiple statements, the callback will be called
+		// once for each statement in order, and more will be true if the following statements will be executed.
+		ExecutorComQuery(ctx *Context, callback func(result Result, warns uint16, failure error, more bool) error) error
 		ExecutorComStmtExecute(ctx *Context) (Result, uint16, error)
 		ConnectionClose(ctx *Context)
 	}
@@ -122,7 +144,13 @@ func (c Context) GetArgs() []Value {
 	for k := range c.Stmt.BindVars {
 		keys = append(keys, k)
 	}
-	sort.Strings(keys)
+	// the keys are named by position, eg: v1, v2, ..., v10, so the shorter one goes first
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
 	for _, k := range keys {
 		args = append(args, c.Stmt.BindVars[k])
 	}
@@ -141,6 +169,8 @@ func (c Context) Value(key interface{}) interface{} {
 		return c.GetQuery()
 	case ContextKeyServerVersion:
 		return c.C.ServerVersion()
+	case ContextKeyWarnings:
+		return c.C.Warnings()
 	case ContextKeyEnableLocalComputation:
 		return c.Context.Value(ContextKeyEnableLocalComputation{})
 	}
@@ -22,10 +22,10 @@ import (
 )
 
 type attributes struct {
-	inner map[byte][]byte
+	inner map[uint16][]byte
 }
 
-func (a *attributes) attribute(key byte) ([]byte, bool) {
+func (a *attributes) attribute(key uint16) ([]byte, bool) {
 	if a == nil {
 		return nil, false
 	}
@@ -33,14 +33,14 @@ func (a *attributes) attribute(key byte) ([]byte, bool) {
 	return b, ok
 }
 
-func (a *attributes) setAttribute(key byte, value []byte) {
+func (a *attributes) setAttribute(key uint16, value []byte) {
 	if a.inner == nil {
-		a.inner = make(map[byte][]byte)
+		a.inner = make(map[uint16][]byte)
 	}
 	a.inner[key] = value
 }
 
-func (a *attributes) attributeBool(key byte) (value bool, ok bool) {
+func (a *attributes) attributeBool(key uint16) (value bool, ok bool) {
 	var exist []byte
 
 	if exist, ok = a.attribute(key); !ok {
@@ -54,7 +54,7 @@ func (a *attributes) attributeBool(key byte) (value bool, ok bool) {
 	return
 }
 
-func (a *attributes) setAttributeBool(key byte, value bool) {
+func (a *attributes) setAttributeBool(key uint16, value bool) {
 	if value {
 		a.setAttribute(key, []byte{0x01})
 	} else {
@@ -62,13 +62,13 @@ func (a *attributes) setAttributeBool(key byte, value bool) {
 	}
 }
 
-func (a *attributes) setAttributeUint32(key byte, value uint32) {
+func (a *attributes) setAttributeUint32(key uint16, value uint32) {
 	b := make([]byte, 4)
 	binary.BigEndian.PutUint32(b, value)
 	a.setAttribute(key, b)
 }
 
-func (a *attributes) attributeUint32(key byte) (uint32, bool) {
+func (a *attributes) attributeUint32(key uint16) (uint32, bool) {
 	exist, ok := a.attribute(key)
 	if !ok {
 		return 0, false
@@ -76,13 +76,13 @@ func (a *attributes) attributeUint32(key byte) (uint32, bool) {
 	return binary.BigEndian.Uint32(exist), true
 }
 
-func (a *attributes) setAttributeUint64(key byte, value uint64) {
+func (a *attributes) setAttributeUint64(key uint16, value uint64) {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, value)
 	a.setAttribute(key, b)
 }
 
-func (a *attributes) attributeUint64(key byte) (uint64, bool) {
+func (a *attributes) attributeUint64(key uint16) (uint64, bool) {
 	exist, ok := a.attribute(key)
 	if !ok {
 		return 0, false
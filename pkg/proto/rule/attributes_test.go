@@ -26,7 +26,7 @@ import (
 )
 
 func TestAttributes(t *testing.T) {
-	const key byte = 0x01
+	const key uint16 = 0x01
 	var (
 		attrs attributes
 		ok    bool
@@ -66,7 +66,7 @@ func TestAttributes(t *testing.T) {
 }
 
 func TestAttributesNil(t *testing.T) {
-	const key byte = 0x02
+	const key uint16 = 0x02
 
 	var (
 		attrs = (*attributes)(nil)
@@ -261,6 +261,25 @@ func (dt DatabaseTables) And(other DatabaseTables) DatabaseTables {
 	return ret
 }
 
+// Each iterates the databases and tables in ascending order, it stops if f returns false.
+// The iteration order is deterministic, so the plans built from the shards are reproducible.
+func (dt DatabaseTables) Each(f func(db string, tables []string) bool) {
+	keys := make([]string, 0, len(dt))
+	for k := range dt {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, db := range keys {
+		tables := make([]string, len(dt[db]))
+		copy(tables, dt[db])
+		sort.Strings(tables)
+		if !f(db, tables) {
+			return
+		}
+	}
+}
+
 // IsFullScan returns true if the current DatabaseTables will cause full scan.
 func (dt DatabaseTables) IsFullScan() bool {
 	if dt == nil {
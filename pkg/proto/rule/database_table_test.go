@@ -152,3 +152,29 @@ func TestDatabaseTables_Smallest(t *testing.T) {
 		})
 	}
 }
+
+func TestDatabaseTables_Each(t *testing.T) {
+	dt := DatabaseTables{
+		"db2": {"tb5", "tb4"},
+		"db0": {"tb1", "tb0"},
+		"db1": {"tb3", "tb2"},
+	}
+
+	var actual []string
+	dt.Each(func(db string, tables []string) bool {
+		for _, tb := range tables {
+			actual = append(actual, db+"."+tb)
+		}
+		return true
+	})
+	assert.Equal(t, []string{"db0.tb0", "db0.tb1", "db1.tb2", "db1.tb3", "db2.tb4", "db2.tb5"}, actual)
+	// the original tables are untouched
+	assert.Equal(t, []string{"tb5", "tb4"}, dt["db2"])
+
+	var dbs []string
+	dt.Each(func(db string, _ []string) bool {
+		dbs = append(dbs, db)
+		return len(dbs) < 2
+	})
+	assert.Equal(t, []string{"db0", "db1"}, dbs)
+}
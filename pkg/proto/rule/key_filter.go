@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rule
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/util/bloom"
+)
+
+// KeyFilter maintains a bloom filter of existing keys for each physical table,
+// the shards which definitely contain none of the queried keys can be skipped.
+//
+// The filter of a physical table won't be consulted until it is ready, which means all existing
+// keys of the table have been loaded. Keys which are deleted cannot be removed from the filter,
+// they only increase the false positive rate.
+//
+// The filter is loaded only once and then learns the new keys from the INSERTs routed by this proxy,
+// so it is authoritative only if the proxy is the exclusive writer of the table. Any write outside,
+// eg: another arana instance or a direct write to the backend, leads to false negatives.
+type KeyFilter struct {
+	column   string
+	capacity uint64
+	fpRate   float64
+
+	mu       sync.RWMutex
+	filters  map[string]*bloom.Filter // db.table -> filter
+	ready    map[string]struct{}
+	disabled map[string]struct{}
+	once     sync.Once
+}
+
+// NewKeyFilter creates a KeyFilter for the column, each filter of physical table is expected to
+// hold capacity keys with false positive rate fpRate.
+func NewKeyFilter(column string, capacity uint64, fpRate float64) *KeyFilter {
+	return &KeyFilter{
+		column:   column,
+		capacity: capacity,
+		fpRate:   fpRate,
+		filters:  make(map[string]*bloom.Filter),
+		ready:    make(map[string]struct{}),
+		disabled: make(map[string]struct{}),
+	}
+}
+
+// Column returns the filtered column name.
+func (kf *KeyFilter) Column() string {
+	return kf.column
+}
+
+// Add adds a key into the filter of physical table.
+func (kf *KeyFilter) Add(db, table string, key proto.Value) {
+	if key == nil {
+		return
+	}
+
+	table = qualifyTable(db, table)
+
+	kf.mu.RLock()
+	f, ok := kf.filters[table]
+	kf.mu.RUnlock()
+
+	if !ok {
+		kf.mu.Lock()
+		if _, disabled := kf.disabled[table]; disabled {
+			kf.mu.Unlock()
+			return
+		}
+		if f, ok = kf.filters[table]; !ok {
+			f = bloom.New(kf.capacity, kf.fpRate)
+			kf.filters[table] = f
+		}
+		kf.mu.Unlock()
+	}
+
+	f.AddString(key.String())
+}
+
+// MayContain returns false if the physical table definitely doesn't contain the key.
+func (kf *KeyFilter) MayContain(db, table string, key proto.Value) bool {
+	if key == nil {
+		return true
+	}
+
+	table = qualifyTable(db, table)
+
+	kf.mu.RLock()
+	defer kf.mu.RUnlock()
+
+	if _, ok := kf.ready[table]; !ok {
+		return true
+	}
+	return kf.filters[table].TestString(key.String())
+}
+
+// SetReady marks the filter of physical table as ready after all existing keys are loaded.
+func (kf *KeyFilter) SetReady(db, table string) {
+	table = qualifyTable(db, table)
+	kf.mu.Lock()
+	defer kf.mu.Unlock()
+	if _, ok := kf.disabled[table]; ok {
+		return
+	}
+	if _, ok := kf.filters[table]; !ok {
+		kf.filters[table] = bloom.New(kf.capacity, kf.fpRate)
+	}
+	kf.ready[table] = struct{}{}
+}
+
+// Invalidate disables the filter of physical table, it won't be consulted any more.
+// It should be called when the keys of table cannot be tracked, eg: the key column is updated.
+func (kf *KeyFilter) Invalidate(db, table string) {
+	table = qualifyTable(db, table)
+	kf.mu.Lock()
+	defer kf.mu.Unlock()
+	delete(kf.ready, table)
+	delete(kf.filters, table)
+	kf.disabled[table] = struct{}{}
+}
+
+// LoadOnce executes the load function only once, it is used to load the existing keys when the rule is loaded.
+func (kf *KeyFilter) LoadOnce(load func()) {
+	kf.once.Do(load)
+}
+
+// qualifyTable returns the filter key of physical table, the same table name may exist in different databases.
+func qualifyTable(db, table string) string {
+	return db + "." + table
+}
@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rule
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+func TestKeyFilter(t *testing.T) {
+	kf := NewKeyFilter("order_no", 1000, 0.01)
+	assert.Equal(t, "order_no", kf.Column())
+
+	kf.Add("order_db_0000", "order_0000", proto.NewValueInt64(1))
+	kf.Add("order_db_0000", "order_0000", proto.NewValueInt64(2))
+
+	// not ready yet, always may contain
+	assert.True(t, kf.MayContain("order_db_0000", "order_0000", proto.NewValueInt64(3)))
+	assert.True(t, kf.MayContain("order_db_0000", "order_0001", proto.NewValueInt64(3)))
+
+	kf.SetReady("order_db_0000", "order_0000")
+	kf.SetReady("order_db_0000", "order_0001")
+	assert.True(t, kf.MayContain("order_db_0000", "order_0000", proto.NewValueInt64(1)))
+	assert.True(t, kf.MayContain("order_db_0000", "order_0000", proto.NewValueInt64(2)))
+	assert.False(t, kf.MayContain("order_db_0000", "order_0000", proto.NewValueInt64(3)))
+	assert.False(t, kf.MayContain("order_db_0000", "order_0001", proto.NewValueInt64(1)))
+
+	kf.Add("order_db_0000", "order_0001", proto.NewValueInt64(1))
+	assert.True(t, kf.MayContain("order_db_0000", "order_0001", proto.NewValueInt64(1)))
+
+	// filters of same table name in different databases are isolated
+	kf.Add("order_db_0001", "order_0001", proto.NewValueInt64(2))
+	kf.SetReady("order_db_0001", "order_0001")
+	assert.True(t, kf.MayContain("order_db_0001", "order_0001", proto.NewValueInt64(2)))
+	assert.False(t, kf.MayContain("order_db_0000", "order_0001", proto.NewValueInt64(2)))
+
+	// invalidated filter won't be consulted any more
+	kf.Invalidate("order_db_0000", "order_0001")
+	kf.SetReady("order_db_0000", "order_0001")
+	assert.True(t, kf.MayContain("order_db_0000", "order_0001", proto.NewValueInt64(3)))
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rule
+
+import (
+	"context"
+	"sync"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/third_party/cache"
+)
+
+// KeyResolver resolves the value of sharding key from the value of lookup column,
+// nil value means the mapping doesn't exist.
+type KeyResolver func(ctx context.Context, value proto.Value) (proto.Value, error)
+
+// KeyLookup translates the values of a lookup column into the values of sharding key, eg: customer_code -> customer_id,
+// so that the queries filtering on the lookup column can be pruned as the ones filtering on sharding key.
+//
+// The mapping is resolved from the lookup table on demand and cached, the lookup column is expected to map 1:1
+// to the sharding key and never be changed. A missing mapping won't be cached, which falls back to full scan.
+type KeyLookup struct {
+	column string
+	key    string
+	table  string
+
+	cache *cache.LRUCache
+
+	mu       sync.RWMutex
+	resolver KeyResolver
+}
+
+type lookupValue struct {
+	proto.Value
+}
+
+func (lookupValue) Size() int {
+	return 1
+}
+
+// NewKeyLookup creates a KeyLookup which maps the column to the sharding key, the mapping is stored
+// in the table which is formatted as 'db.table', at most capacity mappings will be cached.
+func NewKeyLookup(column, key, table string, capacity int64) *KeyLookup {
+	return &KeyLookup{
+		column: column,
+		key:    key,
+		table:  table,
+		cache:  cache.NewLRUCache(capacity),
+	}
+}
+
+// Column returns the lookup column, eg: customer_code.
+func (kl *KeyLookup) Column() string {
+	return kl.column
+}
+
+// Key returns the sharding key which the lookup column maps to, eg: customer_id.
+func (kl *KeyLookup) Key() string {
+	return kl.key
+}
+
+// Table returns the lookup table which stores the mapping, eg: customer_db.customers.
+func (kl *KeyLookup) Table() string {
+	return kl.table
+}
+
+// BindOnce sets the resolver if it is absent.
+func (kl *KeyLookup) BindOnce(resolver KeyResolver) {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	if kl.resolver == nil {
+		kl.resolver = resolver
+	}
+}
+
+// Put caches the mapping from value of lookup column to the value of sharding key.
+func (kl *KeyLookup) Put(value, key proto.Value) {
+	if value == nil || key == nil {
+		return
+	}
+	kl.cache.Set(value.String(), lookupValue{key})
+}
+
+// Lookup returns the value of sharding key which the value of lookup column maps to, the resolver will be
+// called on cache miss. False will be returned if the mapping cannot be resolved.
+func (kl *KeyLookup) Lookup(ctx context.Context, value proto.Value) (proto.Value, bool) {
+	if value == nil {
+		return nil, false
+	}
+
+	if v, ok := kl.cache.Get(value.String()); ok {
+		return v.(lookupValue).Value, true
+	}
+
+	kl.mu.RLock()
+	resolver := kl.resolver
+	kl.mu.RUnlock()
+
+	if resolver == nil {
+		return nil, false
+	}
+
+	key, err := resolver(ctx, value)
+	if err != nil || key == nil {
+		return nil, false
+	}
+	kl.Put(value, key)
+	return key, true
+}
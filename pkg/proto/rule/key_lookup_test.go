@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rule
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+func TestKeyLookup(t *testing.T) {
+	kl := NewKeyLookup("customer_code", "customer_id", "customer_db.customers", 2)
+	assert.Equal(t, "customer_code", kl.Column())
+	assert.Equal(t, "customer_id", kl.Key())
+	assert.Equal(t, "customer_db.customers", kl.Table())
+
+	// no resolver, always miss
+	_, ok := kl.Lookup(context.Background(), proto.NewValueString("ABC"))
+	assert.False(t, ok)
+
+	var calls int
+	kl.BindOnce(func(_ context.Context, value proto.Value) (proto.Value, error) {
+		calls++
+		switch value.String() {
+		case "ABC":
+			return proto.NewValueInt64(1), nil
+		case "DEF":
+			return proto.NewValueInt64(2), nil
+		case "ERR":
+			return nil, errors.New("bad connection")
+		}
+		return nil, nil
+	})
+	// the resolver won't be replaced
+	kl.BindOnce(func(_ context.Context, _ proto.Value) (proto.Value, error) {
+		return proto.NewValueInt64(-1), nil
+	})
+
+	for i := 0; i < 3; i++ {
+		key, ok := kl.Lookup(context.Background(), proto.NewValueString("ABC"))
+		assert.True(t, ok)
+		assert.Equal(t, "1", key.String())
+	}
+	// resolved once, the others hit the cache
+	assert.Equal(t, 1, calls)
+
+	_, ok = kl.Lookup(context.Background(), proto.NewValueString("XYZ"))
+	assert.False(t, ok)
+	_, ok = kl.Lookup(context.Background(), proto.NewValueString("ERR"))
+	assert.False(t, ok)
+	_, ok = kl.Lookup(context.Background(), nil)
+	assert.False(t, ok)
+
+	// the missing mapping is not cached
+	calls = 0
+	_, _ = kl.Lookup(context.Background(), proto.NewValueString("XYZ"))
+	assert.Equal(t, 1, calls)
+
+	kl.Put(proto.NewValueString("GHI"), proto.NewValueInt64(3))
+	key, ok := kl.Lookup(context.Background(), proto.NewValueString("GHI"))
+	assert.True(t, ok)
+	assert.Equal(t, "3", key.String())
+	assert.Equal(t, 1, calls)
+}
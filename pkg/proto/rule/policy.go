@@ -0,0 +1,35 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rule
+
+// StatementPolicy represents a pattern of statements which should be rejected.
+type StatementPolicy struct {
+	Name          string
+	Types         []string // statement types, empty means any type
+	Tables        []string // logical table names, empty means any table
+	WithoutWhere  bool     // matches the statements which have no WHERE clause
+	WithoutLimit  bool     // matches the statements which have no LIMIT clause
+	CartesianJoin bool     // matches the statements which join tables without any condition
+	Message       string   // the error message returned to client
+}
+
+// View represents a logical view over a single table, eg: SELECT * FROM orders WHERE status = 'active'.
+type View struct {
+	Name       string // the name of view
+	Definition string // the SELECT statement which defines the view
+}
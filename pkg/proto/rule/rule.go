@@ -21,11 +21,14 @@ package rule
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 )
 
 import (
 	"github.com/pkg/errors"
+
+	"github.com/shopspring/decimal"
 )
 
 import (
@@ -33,9 +36,22 @@ import (
 )
 
 const (
-	attrAllowFullScan byte = 0x01
+	attrAllowFullScan     uint16 = 0x0001
+	attrOrderByPrimaryKey uint16 = 0x0002
+	attrCountByPrimaryKey uint16 = 0x0004
+	attrSkipMissingTables uint16 = 0x0008
+	attrEarlyLimit        uint16 = 0x0010
+	attrSingleKeyShards   uint16 = 0x0020
+	attrCaseSensitiveCols uint16 = 0x0040
+	attrReadOnly          uint16 = 0x0080
+	attrOrderByGroupItems uint16 = 0x0100
 )
 
+// DefaultWideFanOut is the default amount of shards of a wide fan-out query. A query routed to so many shards
+// holds as many backend connections and merges as many result sets, which usually means the sharding key is missing
+// from the conditions, so it deserves a warning even if it is less than a full scan.
+const DefaultWideFanOut = 8
+
 type (
 	// ShardColumn represents the shard column.
 	ShardColumn struct {
@@ -58,8 +74,25 @@ type (
 		Compute(values ...proto.Value) (int, error)
 	}
 
+	// RangeShardComputer is a ShardComputer which computes the shards of a continuous range directly,
+	// it is required if the shards cannot be enumerated by stepping the values, eg: the range-mapping.
+	RangeShardComputer interface {
+		ShardComputer
+		// ComputeRange computes the shard indexes of values between begin and end, the nil bound means unbounded.
+		ComputeRange(begin, end proto.Value, beginInclude, endInclude bool) ([]int, error)
+	}
+
+	// GeneratedColumn represents a generated column, eg: `month AS (MONTH(created_at))`,
+	// the value of it can be derived from the base columns through the generation expression.
+	GeneratedColumn struct {
+		Name     string
+		Columns  []*ShardColumn // base columns
+		Computer ShardComputer  // compute the value of generated column
+	}
+
 	VShard struct {
 		sync.Once
+		Name      string // the name of sharding strategy, empty means anonymous
 		DB, Table *ShardMetadata
 		variables []string
 	}
@@ -92,6 +125,70 @@ func NewComputer(typ string, columns []string, expr string) (ShardComputer, erro
 	return f.Apply(columns, expr)
 }
 
+// Coerce converts the value to the declared type of shard column, so that both '42' and 42 are routed identically.
+func (sc *ShardColumn) Coerce(value proto.Value) (proto.Value, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	u := sc.Stepper.U
+	switch {
+	case u == Unum:
+		switch value.Family() {
+		case proto.ValueFamilyString, proto.ValueFamilyFloat, proto.ValueFamilyDecimal:
+			d, err := decimal.NewFromString(strings.TrimSpace(value.String()))
+			if err != nil || !d.Equal(d.Truncate(0)) {
+				return nil, errors.Errorf("cannot coerce value '%s' to %s of shard column '%s'", value, u, sc.Name)
+			}
+			return proto.NewValueInt64(d.IntPart()), nil
+		case proto.ValueFamilyBool, proto.ValueFamilyUnsigned:
+			// normalize the boolean and bit values, eg: TRUE -> 1, b'1' -> 1
+			n, err := value.Int64()
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot coerce value '%s' to %s of shard column '%s'", value, u, sc.Name)
+			}
+			return proto.NewValueInt64(n), nil
+		}
+	case u == Ustr:
+		if value.Family() != proto.ValueFamilyString {
+			return proto.NewValueString(value.String()), nil
+		}
+	case u.IsTime():
+		if value.Family() == proto.ValueFamilyString {
+			t, err := value.Time()
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot coerce value '%s' to %s of shard column '%s'", value, u, sc.Name)
+			}
+			return proto.NewValueTime(t), nil
+		}
+	}
+	return value, nil
+}
+
+// Generate computes the value of generated column from the values of base columns.
+func (gc *GeneratedColumn) Generate(values ...proto.Value) (proto.Value, error) {
+	if len(values) != len(gc.Columns) {
+		return nil, errors.Errorf("the length of base columns of generated column '%s' doesn't match: expect=%d, actual=%d", gc.Name, len(gc.Columns), len(values))
+	}
+	args := make([]proto.Value, 0, len(values))
+	for i := range values {
+		if values[i] == nil {
+			return nil, nil
+		}
+		arg, err := gc.Columns[i].Coerce(values[i])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		args = append(args, arg)
+	}
+	ret, err := gc.Computer.Compute(args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot generate value of column '%s'", gc.Name)
+	}
+	return proto.NewValueInt64(int64(ret)), nil
+}
+
+// GetShardColumn returns the shard column by name, returns nil if not exists.
 func (sm *ShardMetadata) GetShardColumn(name string) *ShardColumn {
 	for i := range sm.ShardColumns {
 		if sm.ShardColumns[i].Name == name {
@@ -133,8 +230,16 @@ type VTable struct {
 	attributes
 	name          string // TODO: set name
 	autoIncrement *AutoIncrement
+	keyFilter     *KeyFilter
+	keyLookup     *KeyLookup
+	batchSize     int
+	unionTables   int
+	defaultLimit  int64
+	wideFanOut    int
+	tenantColumn  string
 	topology      *Topology
 	shards        []*VShard
+	generated     []*GeneratedColumn
 	ext           map[string]interface{}
 }
 
@@ -159,6 +264,192 @@ func (vt *VTable) AllowFullScan() bool {
 	return ret
 }
 
+// InsertBatchSize returns the max amount of rows of each INSERT statement sent to a shard, the multi-row INSERT
+// will be split into batches which are executed in a transaction. It is disabled by default, zero means no limit.
+func (vt *VTable) InsertBatchSize() int {
+	return vt.batchSize
+}
+
+func (vt *VTable) SetInsertBatchSize(size int) {
+	vt.batchSize = size
+}
+
+// UnionTables returns the max amount of physical tables coalesced into each UNION ALL query sent to a database,
+// zero means all the tables of a database are queried in one round-trip.
+func (vt *VTable) UnionTables() int {
+	return vt.unionTables
+}
+
+func (vt *VTable) SetUnionTables(n int) {
+	vt.unionTables = n
+}
+
+// DefaultLimit returns the LIMIT which will be injected into the SELECT without LIMIT, zero means disabled.
+func (vt *VTable) DefaultLimit() int64 {
+	return vt.defaultLimit
+}
+
+func (vt *VTable) SetDefaultLimit(limit int64) {
+	vt.defaultLimit = limit
+}
+
+// WideFanOut returns the amount of shards, a warning will be raised if a query fans out to at least so many shards,
+// the DefaultWideFanOut will be used if it is not set.
+func (vt *VTable) WideFanOut() int {
+	if vt.wideFanOut > 0 {
+		return vt.wideFanOut
+	}
+	return DefaultWideFanOut
+}
+
+func (vt *VTable) SetWideFanOut(n int) {
+	vt.wideFanOut = n
+}
+
+// TenantColumn returns the column which isolates the rows of tenants, empty means the table is not tenant-scoped.
+func (vt *VTable) TenantColumn() string {
+	return vt.tenantColumn
+}
+
+func (vt *VTable) SetTenantColumn(column string) {
+	vt.tenantColumn = column
+}
+
+// KeyFilter returns the bloom filter of existing keys, returns nil if it is disabled.
+func (vt *VTable) KeyFilter() *KeyFilter {
+	return vt.keyFilter
+}
+
+func (vt *VTable) SetKeyFilter(kf *KeyFilter) {
+	vt.keyFilter = kf
+}
+
+// KeyLookup returns the lookup which translates a derived column into the sharding key, returns nil if it is disabled.
+func (vt *VTable) KeyLookup() *KeyLookup {
+	return vt.keyLookup
+}
+
+func (vt *VTable) SetKeyLookup(kl *KeyLookup) {
+	vt.keyLookup = kl
+}
+
+func (vt *VTable) SetOrderByPrimaryKey(enable bool) {
+	vt.setAttributeBool(attrOrderByPrimaryKey, enable)
+}
+
+// OrderByPrimaryKey returns true if the primary key should be appended as the last ORDER BY item,
+// which keeps the order of rows with equal sort keys deterministic across shards.
+func (vt *VTable) OrderByPrimaryKey() bool {
+	ret, _ := vt.attributeBool(attrOrderByPrimaryKey)
+	return ret
+}
+
+func (vt *VTable) SetOrderByGroupItems(enable bool) {
+	vt.setAttributeBool(attrOrderByGroupItems, enable)
+}
+
+// OrderByGroupItems returns true if the groups of a query without ORDER BY should be sorted by the group items,
+// which is compatible with the legacy implicit ordering of GROUP BY in MySQL 5.x.
+func (vt *VTable) OrderByGroupItems() bool {
+	ret, _ := vt.attributeBool(attrOrderByGroupItems)
+	return ret
+}
+
+func (vt *VTable) SetCountByPrimaryKey(enable bool) {
+	vt.setAttributeBool(attrCountByPrimaryKey, enable)
+}
+
+// CountByPrimaryKey returns true if COUNT(*) should be rewritten into COUNT(pk),
+// which may be executed as an index-only scan on each shard.
+func (vt *VTable) CountByPrimaryKey() bool {
+	ret, _ := vt.attributeBool(attrCountByPrimaryKey)
+	return ret
+}
+
+func (vt *VTable) SetSkipMissingTables(enable bool) {
+	vt.setAttributeBool(attrSkipMissingTables, enable)
+}
+
+// SkipMissingTables returns true if the physical tables which don't exist yet should be skipped
+// when querying across shards, eg: the new databases after an incremental shard split.
+func (vt *VTable) SkipMissingTables() bool {
+	ret, _ := vt.attributeBool(attrSkipMissingTables)
+	return ret
+}
+
+func (vt *VTable) SetEarlyLimit(enable bool) {
+	vt.setAttributeBool(attrEarlyLimit, enable)
+}
+
+// EarlyLimit returns true if the shards of an unordered LIMIT query should be scanned one by one,
+// and the remaining shards won't be queried once the LIMIT is satisfied.
+func (vt *VTable) EarlyLimit() bool {
+	ret, _ := vt.attributeBool(attrEarlyLimit)
+	return ret
+}
+
+func (vt *VTable) SetReadOnly(enable bool) {
+	vt.setAttributeBool(attrReadOnly, enable)
+}
+
+// ReadOnly returns true if all the writes to the table should be rejected, eg: the archived tables.
+func (vt *VTable) ReadOnly() bool {
+	ret, _ := vt.attributeBool(attrReadOnly)
+	return ret
+}
+
+func (vt *VTable) SetSingleKeyShards(enable bool) {
+	vt.setAttributeBool(attrSingleKeyShards, enable)
+}
+
+// SingleKeyShards returns true if each physical table is dedicated to one value of the sharding key, which means no two
+// values are routed to the same table, eg: one table per region. Only then the shards of 'key <> x' and 'key NOT IN (x,y)'
+// can be excluded, otherwise they require a full scan.
+func (vt *VTable) SingleKeyShards() bool {
+	ret, _ := vt.attributeBool(attrSingleKeyShards)
+	return ret
+}
+
+func (vt *VTable) SetCaseSensitiveColumns(enable bool) {
+	vt.setAttributeBool(attrCaseSensitiveCols, enable)
+}
+
+// CaseSensitiveColumns returns true if the column names should be matched case-sensitively,
+// by default they are case-insensitive like MySQL, eg: 'WHERE UID = 1' matches the sharding key 'uid'.
+func (vt *VTable) CaseSensitiveColumns() bool {
+	ret, _ := vt.attributeBool(attrCaseSensitiveCols)
+	return ret
+}
+
+// NormalizeColumn returns the declared name of the sharding key, generated column or lookup column which matches the name,
+// the name will be returned directly if nothing matched or the columns are case-sensitive.
+func (vt *VTable) NormalizeColumn(name string) string {
+	if vt.CaseSensitiveColumns() {
+		return name
+	}
+	for _, vs := range vt.shards {
+		for _, it := range vs.Variables() {
+			if strings.EqualFold(it, name) {
+				return it
+			}
+		}
+	}
+	for _, gc := range vt.generated {
+		if strings.EqualFold(gc.Name, name) {
+			return gc.Name
+		}
+		for _, it := range gc.Columns {
+			if strings.EqualFold(it.Name, name) {
+				return it.Name
+			}
+		}
+	}
+	if vt.keyLookup != nil && strings.EqualFold(vt.keyLookup.Column(), name) {
+		return vt.keyLookup.Column()
+	}
+	return name
+}
+
 func (vt *VTable) HasVShard(keys ...string) bool {
 	_, ok := vt.SearchVShard(keys...)
 	return ok
@@ -186,6 +477,44 @@ func (vt *VTable) GetVShards() []*VShard {
 	return vt.shards
 }
 
+// WithShardStrategy returns a view of VTable which only uses the VShards of the named sharding strategy.
+// The first named strategy is the primary one, which will be used if the name is empty or not declared.
+// The VTable itself will be returned if no named strategy is declared.
+func (vt *VTable) WithShardStrategy(name string) *VTable {
+	var primary string
+	for _, vs := range vt.shards {
+		if len(vs.Name) > 0 {
+			primary = vs.Name
+			break
+		}
+	}
+	if len(primary) == 0 {
+		return vt
+	}
+
+	filter := func(name string) []*VShard {
+		var shards []*VShard
+		for _, vs := range vt.shards {
+			if strings.EqualFold(vs.Name, name) {
+				shards = append(shards, vs)
+			}
+		}
+		return shards
+	}
+
+	var shards []*VShard
+	if len(name) > 0 {
+		shards = filter(name)
+	}
+	if len(shards) == 0 {
+		shards = filter(primary)
+	}
+
+	ret := *vt
+	ret.shards = shards
+	return &ret
+}
+
 func (vt *VTable) GetShardMetaDataJSON() (map[string]string, error) {
 	res := make(map[string]string)
 
@@ -233,8 +562,78 @@ func (vt *VTable) Topology() *Topology {
 	return vt.topology
 }
 
-// Shard returns the shard result.
+// GetShardColumn returns the shard column by name, returns nil if not exists.
+func (vt *VTable) GetShardColumn(name string) *ShardColumn {
+	for _, vs := range vt.shards {
+		for _, sm := range []*ShardMetadata{vs.DB, vs.Table} {
+			if sm == nil {
+				continue
+			}
+			if sc := sm.GetShardColumn(name); sc != nil {
+				return sc
+			}
+		}
+	}
+	return nil
+}
+
+// AddGeneratedColumn adds a generated column.
+func (vt *VTable) AddGeneratedColumn(gc *GeneratedColumn) {
+	vt.generated = append(vt.generated, gc)
+}
+
+// GetGeneratedColumns returns the generated columns which can be derived from the single base column.
+func (vt *VTable) GetGeneratedColumns(column string) []*GeneratedColumn {
+	var ret []*GeneratedColumn
+	for _, gc := range vt.generated {
+		if len(gc.Columns) == 1 && gc.Columns[0].Name == column {
+			ret = append(ret, gc)
+		}
+	}
+	return ret
+}
+
+// deriveGeneratedColumns fills the missing generated columns whose base columns are all given.
+func (vt *VTable) deriveGeneratedColumns(inputs map[string]proto.Value) (map[string]proto.Value, error) {
+	var derived map[string]proto.Value
+L:
+	for _, gc := range vt.generated {
+		if _, ok := inputs[gc.Name]; ok {
+			continue
+		}
+		values := make([]proto.Value, 0, len(gc.Columns))
+		for _, c := range gc.Columns {
+			v, ok := inputs[c.Name]
+			if !ok {
+				continue L
+			}
+			values = append(values, v)
+		}
+		v, err := gc.Generate(values...)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if derived == nil {
+			derived = make(map[string]proto.Value, len(inputs)+1)
+			for k := range inputs {
+				derived[k] = inputs[k]
+			}
+		}
+		derived[gc.Name] = v
+	}
+	if derived == nil {
+		return inputs, nil
+	}
+	return derived, nil
+}
+
+// Shard returns the shard result, the generated shard columns will be derived from the base columns if missing.
 func (vt *VTable) Shard(inputs map[string]proto.Value) (uint32 /* db */, uint32 /* table */, error) {
+	inputs, err := vt.deriveGeneratedColumns(inputs)
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
 	var bingo *VShard
 L:
 	for i := range vt.shards {
@@ -253,27 +652,32 @@ L:
 		return 0, 0, errors.Errorf("no available vshards")
 	}
 
-	compute := func(c ShardComputer) (int, error) {
+	compute := func(sm *ShardMetadata) (int, error) {
+		c := sm.Computer
 		args := make([]proto.Value, 0, len(c.Variables()))
 		for _, variable := range c.Variables() {
-			args = append(args, inputs[variable])
+			arg := inputs[variable]
+			if sc := sm.GetShardColumn(variable); sc != nil {
+				var err error
+				if arg, err = sc.Coerce(arg); err != nil {
+					return 0, errors.WithStack(err)
+				}
+			}
+			args = append(args, arg)
 		}
 		return c.Compute(args...)
 	}
 
-	var (
-		db, table int
-		err       error
-	)
+	var db, table int
 
 	if bingo.DB != nil {
-		if db, err = compute(bingo.DB.Computer); err != nil {
+		if db, err = compute(bingo.DB); err != nil {
 			return 0, 0, errors.Wrap(err, "cannot compute db shard")
 		}
 	}
 
 	if bingo.Table != nil {
-		if table, err = compute(bingo.Table.Computer); err != nil {
+		if table, err = compute(bingo.Table); err != nil {
 			return 0, 0, errors.Wrap(err, "cannot compute table shard")
 		}
 	}
@@ -295,9 +699,53 @@ func (vt *VTable) SetName(name string) {
 }
 
 // Rule represents sharding rule, a Rule contains multiple logical tables.
+//
+// The active Rule of namespace should be treated as an immutable snapshot, the reloading creates a new one by Clone,
+// so that the in-flight queries always see a consistent rule.
 type Rule struct {
-	mu    sync.RWMutex
-	vtabs map[string]*VTable // table name -> *VTable
+	mu       sync.RWMutex
+	version  uint64
+	vtabs    map[string]*VTable // table name -> *VTable
+	policies []*StatementPolicy
+	views    map[string]*View // view name -> *View
+	schemas  []*SchemaRoute
+}
+
+// Version returns the version of rule, it is increased by each reloading.
+func (ru *Rule) Version() uint64 {
+	if ru == nil {
+		return 0
+	}
+	ru.mu.RLock()
+	defer ru.mu.RUnlock()
+	return ru.version
+}
+
+// SetVersion sets the version of rule.
+func (ru *Rule) SetVersion(version uint64) {
+	ru.mu.Lock()
+	ru.version = version
+	ru.mu.Unlock()
+}
+
+// Clone returns a copy of the rule, the VTables, policies and views are shared.
+func (ru *Rule) Clone() *Rule {
+	ru.mu.RLock()
+	defer ru.mu.RUnlock()
+
+	ret := &Rule{
+		version:  ru.version,
+		policies: ru.policies,
+		views:    ru.views,
+		schemas:  ru.schemas,
+	}
+	if ru.vtabs != nil {
+		ret.vtabs = make(map[string]*VTable, len(ru.vtabs))
+		for k, v := range ru.vtabs {
+			ret.vtabs[k] = v
+		}
+	}
+	return ret
 }
 
 // Has return true if the table exists.
@@ -356,6 +804,90 @@ func (ru *Rule) MustVTable(name string) *VTable {
 	return v
 }
 
+// SetPolicies sets the statement policies.
+func (ru *Rule) SetPolicies(policies []*StatementPolicy) {
+	ru.mu.Lock()
+	ru.policies = policies
+	ru.mu.Unlock()
+}
+
+// Policies returns the statement policies.
+func (ru *Rule) Policies() []*StatementPolicy {
+	if ru == nil {
+		return nil
+	}
+	ru.mu.RLock()
+	defer ru.mu.RUnlock()
+	return ru.policies
+}
+
+// SetViews sets the views.
+func (ru *Rule) SetViews(views []*View) {
+	m := make(map[string]*View, len(views))
+	for _, it := range views {
+		m[it.Name] = it
+	}
+	ru.mu.Lock()
+	ru.views = m
+	ru.mu.Unlock()
+}
+
+// View returns the view of given name.
+func (ru *Rule) View(name string) (*View, bool) {
+	if ru == nil {
+		return nil, false
+	}
+	ru.mu.RLock()
+	defer ru.mu.RUnlock()
+	v, ok := ru.views[name]
+	return v, ok
+}
+
+// SetSchemaRoutes sets the routes of physical schemas.
+func (ru *Rule) SetSchemaRoutes(routes []*SchemaRoute) {
+	ru.mu.Lock()
+	ru.schemas = routes
+	ru.mu.Unlock()
+}
+
+// RouteSchema returns the group which the physical schema is routed to, the longest matched prefix wins.
+func (ru *Rule) RouteSchema(schema string) (string, bool) {
+	if ru == nil || len(schema) == 0 {
+		return "", false
+	}
+	ru.mu.RLock()
+	defer ru.mu.RUnlock()
+
+	var matched *SchemaRoute
+	for _, it := range ru.schemas {
+		if len(it.Prefix) > len(schema) || !strings.EqualFold(schema[:len(it.Prefix)], it.Prefix) {
+			continue
+		}
+		if matched == nil || len(it.Prefix) > len(matched.Prefix) {
+			matched = it
+		}
+	}
+	if matched == nil {
+		return "", false
+	}
+	return matched.Group, true
+}
+
+// HasShardStrategy returns true if the named sharding strategy is declared by any VTable.
+func (ru *Rule) HasShardStrategy(name string) bool {
+	var found bool
+	ru.Range(func(_ string, vt *VTable) bool {
+		for _, vs := range vt.shards {
+			if strings.EqualFold(vs.Name, name) {
+				found = true
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
 // Range ranges each VTable
 func (ru *Rule) Range(f func(table string, vt *VTable) bool) {
 	ru.mu.RLock()
@@ -183,3 +183,237 @@ func TestGetShardColumn_NotFound(t *testing.T) {
 	assert.Nil(t, result)
 	assert.True(t, reflect.DeepEqual(result, expected))
 }
+
+func TestShardColumn_Coerce(t *testing.T) {
+	num := &ShardColumn{Name: "uid", Stepper: Stepper{N: 1, U: Unum}}
+
+	v, err := num.Coerce(proto.NewValueString("42"))
+	assert.NoError(t, err)
+	assert.Equal(t, proto.NewValueInt64(42), v)
+
+	v, err = num.Coerce(proto.NewValueFloat64(42))
+	assert.NoError(t, err)
+	assert.Equal(t, proto.NewValueInt64(42), v)
+
+	v, err = num.Coerce(proto.NewValueInt64(42))
+	assert.NoError(t, err)
+	assert.Equal(t, proto.NewValueInt64(42), v)
+
+	v, err = num.Coerce(proto.NewValueBool(true))
+	assert.NoError(t, err)
+	assert.Equal(t, proto.NewValueInt64(1), v)
+
+	v, err = num.Coerce(proto.NewValueUint64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, proto.NewValueInt64(1), v)
+
+	_, err = num.Coerce(proto.NewValueString("4.5"))
+	assert.Error(t, err)
+
+	_, err = num.Coerce(proto.NewValueString("abc"))
+	assert.Error(t, err)
+
+	str := &ShardColumn{Name: "name", Stepper: Stepper{N: 1, U: Ustr}}
+	v, err = str.Coerce(proto.NewValueInt64(42))
+	assert.NoError(t, err)
+	assert.Equal(t, proto.NewValueString("42"), v)
+
+	day := &ShardColumn{Name: "created_at", Stepper: Stepper{N: 1, U: Uday}}
+	v, err = day.Coerce(proto.NewValueString("2022-01-02 15:04:05"))
+	assert.NoError(t, err)
+	assert.Equal(t, proto.ValueFamilyTime, v.Family())
+
+	_, err = day.Coerce(proto.NewValueString("foobar"))
+	assert.Error(t, err)
+}
+
+func TestShard_GeneratedColumn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	computer := testdata.NewMockShardComputer(ctrl)
+	computer.EXPECT().
+		Compute(gomock.Any()).
+		DoAndReturn(func(value proto.Value) (int, error) {
+			x, err := value.Int64()
+			return int(x) % 12, err
+		}).
+		MinTimes(1)
+	computer.EXPECT().Variables().Return([]string{"month"}).MinTimes(1)
+
+	generator := testdata.NewMockShardComputer(ctrl)
+	generator.EXPECT().
+		Compute(gomock.Any()).
+		DoAndReturn(func(value proto.Value) (int, error) {
+			t, err := value.Time()
+			return int(t.Month()), err
+		}).
+		MinTimes(1)
+
+	var vtab VTable
+	vtab.AddVShards(&VShard{
+		Table: &ShardMetadata{
+			ShardColumns: []*ShardColumn{{Name: "month", Steps: 12, Stepper: Stepper{N: 1, U: Unum}}},
+			Computer:     computer,
+		},
+	})
+	vtab.AddGeneratedColumn(&GeneratedColumn{
+		Name:     "month",
+		Columns:  []*ShardColumn{{Name: "created_at", Stepper: Stepper{N: 1, U: Uday}}},
+		Computer: generator,
+	})
+
+	assert.Len(t, vtab.GetGeneratedColumns("created_at"), 1)
+	assert.Empty(t, vtab.GetGeneratedColumns("month"))
+
+	_, tblIdx, err := vtab.Shard(map[string]proto.Value{
+		"month": proto.NewValueInt64(5),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(5), tblIdx)
+
+	inputs := map[string]proto.Value{
+		"created_at": proto.NewValueString("2023-11-10 12:00:00"),
+	}
+	_, tblIdx, err = vtab.Shard(inputs)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(11), tblIdx)
+	assert.Len(t, inputs, 1, "the inputs should not be modified")
+
+	_, _, err = vtab.Shard(map[string]proto.Value{
+		"created_at": proto.NewValueString("foobar"),
+	})
+	assert.Error(t, err)
+}
+
+func TestVTable_NormalizeColumn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	computer := testdata.NewMockShardComputer(ctrl)
+	computer.EXPECT().Variables().Return([]string{"uid"}).AnyTimes()
+
+	var vtab VTable
+	vtab.AddVShards(&VShard{
+		Table: &ShardMetadata{
+			ShardColumns: []*ShardColumn{{Name: "uid", Steps: 8, Stepper: Stepper{N: 1, U: Unum}}},
+			Computer:     computer,
+		},
+	})
+	vtab.AddGeneratedColumn(&GeneratedColumn{
+		Name:    "month",
+		Columns: []*ShardColumn{{Name: "created_at", Stepper: Stepper{N: 1, U: Uday}}},
+	})
+
+	assert.Equal(t, "uid", vtab.NormalizeColumn("UID"))
+	assert.Equal(t, "uid", vtab.NormalizeColumn("uid"))
+	assert.Equal(t, "month", vtab.NormalizeColumn("Month"))
+	assert.Equal(t, "created_at", vtab.NormalizeColumn("CREATED_AT"))
+	assert.Equal(t, "Name", vtab.NormalizeColumn("Name"))
+
+	vtab.SetCaseSensitiveColumns(true)
+	assert.Equal(t, "UID", vtab.NormalizeColumn("UID"))
+}
+
+func TestVTable_WithShardStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newComputer := func(column string, mod int) ShardComputer {
+		c := testdata.NewMockShardComputer(ctrl)
+		c.EXPECT().Variables().Return([]string{column}).AnyTimes()
+		c.EXPECT().
+			Compute(gomock.Any()).
+			DoAndReturn(func(value proto.Value) (int, error) {
+				x, err := value.Int64()
+				return int(x) % mod, err
+			}).
+			AnyTimes()
+		return c
+	}
+
+	var vtab VTable
+	vtab.AddVShards(&VShard{
+		Name:  "by_user",
+		Table: &ShardMetadata{Computer: newComputer("uid", 8)},
+	})
+	vtab.AddVShards(&VShard{
+		Name:  "by_time",
+		Table: &ShardMetadata{Computer: newComputer("month", 12)},
+	})
+
+	inputs := map[string]proto.Value{
+		"uid":   proto.NewValueInt64(13),
+		"month": proto.NewValueInt64(11),
+	}
+
+	// both of the strategies are matched
+	_, _, err := vtab.Shard(inputs)
+	assert.Error(t, err)
+
+	for _, it := range []struct {
+		name   string
+		expect string
+		table  uint32
+	}{
+		{"", "by_user", 5},
+		{"by_time", "by_time", 11},
+		{"BY_TIME", "by_time", 11},
+		{"not_exists", "by_user", 5},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			view := vtab.WithShardStrategy(it.name)
+			assert.Len(t, view.GetVShards(), 1)
+			assert.Equal(t, it.expect, view.GetVShards()[0].Name)
+
+			_, tblIdx, err := view.Shard(inputs)
+			assert.NoError(t, err)
+			assert.Equal(t, it.table, tblIdx)
+		})
+	}
+	assert.Len(t, vtab.GetVShards(), 2, "the origin VTable should not be modified")
+
+	var ru Rule
+	ru.SetVTable("student", &vtab)
+	assert.True(t, ru.HasShardStrategy("BY_TIME"))
+	assert.False(t, ru.HasShardStrategy("not_exists"))
+
+	var anonymous VTable
+	anonymous.AddVShards(&VShard{Table: &ShardMetadata{Computer: newComputer("uid", 8)}})
+	assert.Same(t, &anonymous, anonymous.WithShardStrategy("by_time"))
+}
+
+func TestRule_RouteSchema(t *testing.T) {
+	var ru Rule
+	_, ok := ru.RouteSchema("tenant_acme")
+	assert.False(t, ok)
+
+	ru.SetSchemaRoutes([]*SchemaRoute{
+		{Prefix: "tenant_", Group: "employees_tenant"},
+		{Prefix: "tenant_vip_", Group: "employees_vip"},
+	})
+
+	for _, it := range []struct {
+		schema string
+		group  string
+		ok     bool
+	}{
+		{"tenant_acme", "employees_tenant", true},
+		{"TENANT_Acme", "employees_tenant", true},
+		{"tenant_vip_foo", "employees_vip", true},
+		{"tenant", "", false},
+		{"employees", "", false},
+		{"", "", false},
+	} {
+		t.Run(it.schema, func(t *testing.T) {
+			group, ok := ru.RouteSchema(it.schema)
+			assert.Equal(t, it.ok, ok)
+			assert.Equal(t, it.group, group)
+		})
+	}
+
+	// the routes are kept by the cloned rule
+	group, ok := ru.Clone().RouteSchema("tenant_acme")
+	assert.True(t, ok)
+	assert.Equal(t, "employees_tenant", group)
+}
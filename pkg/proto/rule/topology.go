@@ -138,27 +138,32 @@ func (to *Topology) Each(onEach func(dbIdx, tbIdx int) (ok bool)) bool {
 	return done
 }
 
+// Smallest returns the smallest table of the smallest database which exists in current Topology.
+// Unlike Render(0, 0), the returned shard is always valid, and it is deterministic for the same Topology.
+// Returns false if the Topology is empty or no render is set.
 func (to *Topology) Smallest() (db, tb string, ok bool) {
 	to.mu.RLock()
 	dbRender, tbRender := to.dbRender, to.tbRender
 	to.mu.RUnlock()
 
+	if dbRender == nil || tbRender == nil {
+		return
+	}
+
 	smallest := [2]int{math.MaxInt64, math.MaxInt64}
 	to.idx.Range(func(key, value any) bool {
-		if d := key.(int); d < smallest[0] {
-			smallest[0] = d
-			if t := value.([]int); len(t) > 0 {
-				smallest[1] = t[0]
-			}
+		d, t := key.(int), value.([]int)
+		if d < smallest[0] && len(t) > 0 {
+			smallest[0], smallest[1] = d, t[0] // the tables are sorted already
 		}
 		return true
 	})
 
-	if smallest[0] != math.MaxInt64 || smallest[1] != math.MaxInt64 {
-		db, tb, ok = dbRender(smallest[0]), tbRender(smallest[1]), true
+	if smallest[0] == math.MaxInt64 {
+		return
 	}
 
-	return
+	return dbRender(smallest[0]), tbRender(smallest[1]), true
 }
 
 func (to *Topology) Largest() (db, tb string, ok bool) {
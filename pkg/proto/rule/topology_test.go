@@ -123,9 +123,25 @@ func TestTopology_Largest(t *testing.T) {
 
 func TestTopology_Smallest(t *testing.T) {
 	topology := createTopology()
+	// the table 0 doesn't exist
 	db, tb, ok := topology.Smallest()
-	assert.True(t, ok)
 	t.Logf("smallest: %s.%s\n", db, tb)
+	assert.True(t, ok)
+	assert.Equal(t, "dbRender:0", db)
+	assert.Equal(t, "tbRender:1", tb)
+
+	topology.SetTopology(0)
+	db, tb, ok = topology.Smallest()
+	assert.True(t, ok)
+	assert.Equal(t, "dbRender:1", db)
+	assert.Equal(t, "tbRender:4", tb)
+
+	topology.SetTopology(1)
+	_, _, ok = topology.Smallest()
+	assert.False(t, ok)
+
+	_, _, ok = (&Topology{}).Smallest()
+	assert.False(t, ok)
 }
 
 func createTopology() *Topology {
@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package reduce
+
+import (
+	"math/big"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/shopspring/decimal"
+)
+
+var _ Reducer = (*bitReducer)(nil)
+
+type bitReducer struct {
+	name     string
+	identity uint64 // the result of no rows
+	op       func(prev, next uint64) uint64
+}
+
+func (b bitReducer) Int64(prev, next int64) (int64, error) {
+	return int64(b.op(uint64(prev), uint64(next))), nil
+}
+
+func (b bitReducer) Float64(_, _ float64) (float64, error) {
+	return 0, errors.Errorf("float64 is not supported for %s", b.name)
+}
+
+func (b bitReducer) Decimal(prev, next decimal.Decimal) (decimal.Decimal, error) {
+	toUint64 := func(d decimal.Decimal) (uint64, error) {
+		if !d.IsInteger() || d.IsNegative() || !d.BigInt().IsUint64() {
+			return 0, errors.Errorf("invalid %s value %s", b.name, d)
+		}
+		return d.BigInt().Uint64(), nil
+	}
+	x, err := toUint64(prev)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	y, err := toUint64(next)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.NewFromBigInt(new(big.Int).SetUint64(b.op(x, y)), 0), nil
+}
+
+func (b bitReducer) Time(_, _ time.Time) (ret time.Time, err error) {
+	err = errors.Errorf("time.Time is not supported for %s", b.name)
+	return
+}
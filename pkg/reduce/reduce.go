@@ -18,6 +18,8 @@
 package reduce
 
 import (
+	"math"
+	"math/big"
 	"time"
 )
 
@@ -43,3 +45,32 @@ func Min() Reducer {
 func Sum() Reducer {
 	return sumReducer{}
 }
+
+// Count returns the reducer of COUNT, the partial counts are summed up.
+func Count() Reducer {
+	return countReducer{}
+}
+
+func BitAnd() Reducer {
+	return bitReducer{name: "BIT_AND", identity: math.MaxUint64, op: func(prev, next uint64) uint64 { return prev & next }}
+}
+
+func BitOr() Reducer {
+	return bitReducer{name: "BIT_OR", op: func(prev, next uint64) uint64 { return prev | next }}
+}
+
+func BitXor() Reducer {
+	return bitReducer{name: "BIT_XOR", op: func(prev, next uint64) uint64 { return prev ^ next }}
+}
+
+// Identity returns the result of reducing no rows, false means NULL.
+// For example, COUNT returns 0, BIT_AND returns all bits set, but SUM, MIN and MAX return NULL.
+func Identity(r Reducer) (decimal.Decimal, bool) {
+	switch x := r.(type) {
+	case countReducer:
+		return decimal.Zero, true
+	case bitReducer:
+		return decimal.NewFromBigInt(new(big.Int).SetUint64(x.identity), 0), true
+	}
+	return decimal.Decimal{}, false
+}
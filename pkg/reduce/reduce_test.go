@@ -78,3 +78,55 @@ func TestReduce(t *testing.T) {
 		}
 	}
 }
+
+func TestReduce_Bit(t *testing.T) {
+	for _, it := range []struct {
+		r Reducer
+		v int64
+	}{
+		{BitAnd(), 0b0100},
+		{BitOr(), 0b1101},
+		{BitXor(), 0b1001},
+	} {
+		r, v := it.r, it.v
+		i, err := r.Int64(0b1100, 0b0101)
+		assert.NoError(t, err)
+		assert.Equal(t, v, i)
+
+		d, err := r.Decimal(decimal.New(0b1100, 0), decimal.New(0b0101, 0))
+		assert.NoError(t, err)
+		assert.True(t, decimal.New(v, 0).Equal(d))
+
+		_, err = r.Decimal(decimal.New(-1, 0), decimal.New(1, 0))
+		assert.Error(t, err)
+
+		_, err = r.Float64(1, 2)
+		assert.Error(t, err)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	for _, it := range []struct {
+		r  Reducer
+		v  string
+		ok bool
+	}{
+		{Count(), "0", true},
+		{BitAnd(), "18446744073709551615", true},
+		{BitOr(), "0", true},
+		{BitXor(), "0", true},
+		{Sum(), "", false},
+		{Min(), "", false},
+		{Max(), "", false},
+	} {
+		v, ok := Identity(it.r)
+		assert.Equal(t, it.ok, ok)
+		if ok {
+			assert.Equal(t, it.v, v.String())
+		}
+	}
+
+	i, err := Count().Int64(3, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), i)
+}
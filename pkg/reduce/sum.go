@@ -27,10 +27,18 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-var _ Reducer = (*sumReducer)(nil)
+var (
+	_ Reducer = (*sumReducer)(nil)
+	_ Reducer = (*countReducer)(nil)
+)
 
 type sumReducer struct{}
 
+// countReducer sums up the partial counts, it differs from SUM only when there are no rows.
+type countReducer struct {
+	sumReducer
+}
+
 func (s sumReducer) Int64(prev, next int64) (int64, error) {
 	return prev + next, nil
 }
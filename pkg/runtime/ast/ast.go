@@ -90,11 +90,21 @@ func WithCollation(collation string) ParseOption {
 
 // FromStmtNode converts raw ast node to Statement.
 func FromStmtNode(node ast.StmtNode) (Statement, error) {
+	if err := checkUnsupported(node); err != nil {
+		return nil, err
+	}
+
 	var cc convCtx
 	switch stmt := node.(type) {
 	case *ast.SelectStmt:
+		if err := prepareCTEs(stmt); err != nil {
+			return nil, err
+		}
 		return cc.convSelectStmt(stmt), nil
 	case *ast.SetOprStmt:
+		if err := prepareCTEs(stmt); err != nil {
+			return nil, err
+		}
 		return cc.convUnionStmt(stmt), nil
 	case *ast.DeleteStmt:
 		if stmt.IsMultiTable {
@@ -117,9 +127,9 @@ func FromStmtNode(node ast.StmtNode) (Statement, error) {
 			return &DescribeStatement{Table: tgt.TableName, Column: tgt.Column}, nil
 		case *SelectStatement:
 			if len(tgt.From) != 0 {
-				return &ExplainStatement{Target: tgt, Table: tgt.From[0].Source.(TableName)}, nil
+				return &ExplainStatement{Target: tgt, Table: tgt.From[0].Source.(TableName), Analyze: stmt.Analyze}, nil
 			} else {
-				return &ExplainStatement{Target: tgt}, nil
+				return &ExplainStatement{Target: tgt, Analyze: stmt.Analyze}, nil
 			}
 		case *DeleteStatement:
 			return &ExplainStatement{Target: tgt, Table: tgt.Table}, nil
@@ -407,12 +417,34 @@ func (cc *convCtx) convDropTableStmt(stmt *ast.DropTableStmt) *DropTableStatemen
 	}
 }
 
+// isSetNextTx returns true if the statement is 'SET TRANSACTION ...' without scope, which only takes effect on the
+// next transaction. The parser marks the isolation level of next transaction as 'tx_isolation_one_shot', but the
+// access mode is parsed into the same variable as 'SET SESSION TRANSACTION', then it is told by the parsed tokens.
+func isSetNextTx(stmt *ast.SetStmt) bool {
+	for _, it := range stmt.Variables {
+		if it.Name == "tx_isolation_one_shot" {
+			return true
+		}
+	}
+	return strings.HasPrefix(parser.Normalize(stmt.Text()), "set transaction ")
+}
+
 func (cc *convCtx) convSetVariablesStmt(stmt *ast.SetStmt) *SetStatement {
+	oneShot := isSetNextTx(stmt)
+
 	vars := make([]*VariablePair, 0, len(stmt.Variables))
 	for i := range stmt.Variables {
 		next := stmt.Variables[i]
+		name := next.Name
+		if name == "tx_read_only" && next.IsSystem && !next.IsGlobal {
+			if oneShot {
+				name = "tx_read_only_one_shot" // see rcontext.VarTxReadOnlyOneShot
+			} else {
+				name = "transaction_read_only" // tx_read_only is removed since MySQL 8.0
+			}
+		}
 		vars = append(vars, &VariablePair{
-			Name:   next.Name,
+			Name:   name,
 			Value:  cc.convExpr(next.Value).(*AtomPredicateNode).A,
 			Global: next.IsGlobal,
 			System: next.IsSystem,
@@ -489,7 +521,53 @@ func (cc *convCtx) convColumn(col *ast.ColumnName) ColumnNameExpressionAtom {
 	return ret
 }
 
+// checkUnsupported returns error if the statement contains any node which cannot be converted yet, eg:
+// the nested set operations "SELECT 1 UNION (SELECT 2 UNION SELECT 3)",
+// or the set operation as subquery of IN predicate "uid IN (SELECT 1 UNION SELECT 2)".
+func checkUnsupported(node ast.Node) error {
+	var v unsupportedChecker
+	node.Accept(&v)
+	return v.err
+}
+
+type unsupportedChecker struct {
+	err error
+}
+
+func (sc *unsupportedChecker) Enter(n ast.Node) (ast.Node, bool) {
+	switch node := n.(type) {
+	case *ast.SetOprStmt:
+		if node.SelectList == nil {
+			return n, false
+		}
+		for _, it := range node.SelectList.Selects {
+			if _, ok := it.(*ast.SelectStmt); !ok {
+				sc.err = errors.Errorf("unsupported: nested set operations")
+				return n, true
+			}
+		}
+	case *ast.PatternInExpr:
+		sub, ok := node.Sel.(*ast.SubqueryExpr)
+		if !ok {
+			return n, false
+		}
+		if _, ok := sub.Query.(*ast.SelectStmt); !ok {
+			sc.err = errors.Errorf("unsupported: subquery %T of IN predicate", sub.Query)
+			return n, true
+		}
+	}
+	return n, false
+}
+
+func (sc *unsupportedChecker) Leave(n ast.Node) (ast.Node, bool) {
+	return n, sc.err == nil
+}
+
 func (cc *convCtx) convUnionStmt(stmt *ast.SetOprStmt) *UnionSelectStatement {
+	if stmt.With != nil {
+		defer cc.enterCTEs(stmt.With)()
+	}
+
 	var ret UnionSelectStatement
 
 	ret.First = cc.convSelectStmt(stmt.SelectList.Selects[0].(*ast.SelectStmt))
@@ -505,14 +583,34 @@ func (cc *convCtx) convUnionStmt(stmt *ast.SetOprStmt) *UnionSelectStatement {
 			item.Type = UnionTypeAll
 		case ast.Union:
 			item.Type = UnionTypeDistinct
+		case ast.IntersectAll:
+			item.Type = UnionTypeIntersectAll
+		case ast.Intersect:
+			item.Type = UnionTypeIntersectDistinct
+		case ast.ExceptAll:
+			item.Type = UnionTypeExceptAll
+		case ast.Except:
+			item.Type = UnionTypeExceptDistinct
 		}
 		ret.UnionStatementItems = append(ret.UnionStatementItems, &item)
 	}
 
+	if stmt.OrderBy != nil {
+		ret.OrderBy = cc.convOrderBy(stmt.OrderBy)
+	}
+	if stmt.Limit != nil {
+		ret.Limit = cc.convLimit(stmt.Limit)
+	}
+
 	return &ret
 }
 
 func (cc *convCtx) convSelectStmt(stmt *ast.SelectStmt) *SelectStatement {
+	if stmt.With != nil {
+		defer cc.enterCTEs(stmt.With)()
+	}
+	cc.mergeSubquery(stmt)
+
 	var ret SelectStatement
 
 	ret.Distinct = stmt.Distinct
@@ -905,6 +1003,10 @@ func MustParse(sql string) ([]*hint.Hint, Statement) {
 type convCtx struct {
 	flag   uint32
 	tables []string
+	// ctes are the visible common table expressions, which will be inlined into the referencing queries.
+	ctes []*ast.CommonTableExpression
+	// physicalTables are the tables moved from the merged CTEs, which shouldn't be resolved as CTEs again.
+	physicalTables map[*ast.TableName]struct{}
 }
 
 func (cc *convCtx) convTableSource(input *ast.TableSource) *TableSourceNode {
@@ -912,6 +1014,14 @@ func (cc *convCtx) convTableSource(input *ast.TableSource) *TableSourceNode {
 	target.Alias = input.AsName.O
 	switch source := input.Source.(type) {
 	case *ast.TableName:
+		if i, ok := cc.lookupCTE(source); ok {
+			target.Source = cc.convCTE(i)
+			if len(target.Alias) < 1 {
+				target.Alias = source.Name.O
+			}
+			cc.flag |= _ccHasSubQuery
+			break
+		}
 		cc.convTableName(source, &target)
 	case *ast.SelectStmt:
 		target.Source = cc.convSelectStmt(source)
@@ -1018,7 +1128,12 @@ func (cc *convCtx) convOrderBy(orderBy *ast.OrderByClause) (ret OrderByNode) {
 	for _, it := range orderBy.Items {
 		var next OrderByItem
 		next.Desc = it.Desc
-		switch val := cc.convExpr(it.Expr).(type) {
+		expr := it.Expr
+		if c, ok := expr.(*ast.SetCollationExpr); ok {
+			next.Collate = c.Collate
+			expr = c.Expr
+		}
+		switch val := cc.convExpr(expr).(type) {
 		case ExpressionAtom:
 			next.Expr = val
 		case *AtomPredicateNode:
@@ -1144,6 +1259,8 @@ func (cc *convCtx) convExpr(expr ast.ExprNode) interface{} {
 		return cc.convBetweenExpr(node)
 	case *ast.ParenthesesExpr:
 		return cc.convParenthesesExpr(node)
+	case *ast.RowExpr:
+		return cc.convRowExpr(node)
 	case *ast.PatternLikeExpr:
 		return cc.convPatternLikeExpr(node)
 	case ast.ValueExpr:
@@ -1166,18 +1283,25 @@ func (cc *convCtx) convExpr(expr ast.ExprNode) interface{} {
 		return cc.convRegexpExpr(node)
 	case *ast.TimeUnitExpr:
 		return cc.convTimeUnitExpr(node)
+	case *ast.SubqueryExpr:
+		return cc.convSubqueryExpr(node)
 	default:
 		panic(fmt.Sprintf("unimplement: expr node type %T!", node))
 	}
 }
 
 func (cc *convCtx) convVariableExpr(node *ast.VariableExpr) PredicateNode {
+	atom := &SystemVariableExpressionAtom{
+		Name:   node.Name,
+		System: node.IsSystem,
+		Global: node.IsGlobal,
+	}
+	// user variable assignment, eg: @rownum := @rownum + 1
+	if node.Value != nil {
+		atom.Value = toExpressionNode(cc.convExpr(node.Value))
+	}
 	return &AtomPredicateNode{
-		A: &SystemVariableExpressionAtom{
-			Name:   node.Name,
-			System: node.IsSystem,
-			Global: node.IsGlobal,
-		},
+		A: atom,
 	}
 }
 
@@ -1379,6 +1503,11 @@ func (cc *convCtx) toArg(arg ast.ExprNode) *FunctionArg {
 				Type:  FunctionArgExpression,
 				Value: &PredicateExpressionNode{P: next},
 			}
+		case *SystemVariableExpressionAtom:
+			return &FunctionArg{
+				Type:  FunctionArgExpression,
+				Value: &PredicateExpressionNode{P: next},
+			}
 		default:
 			panic(fmt.Sprintf("unimplement: function arg atom type %T!", atom))
 		}
@@ -1429,6 +1558,26 @@ func (cc *convCtx) convParenthesesExpr(expr *ast.ParenthesesExpr) PredicateNode
 	return &AtomPredicateNode{A: atom}
 }
 
+func (cc *convCtx) convRowExpr(expr *ast.RowExpr) PredicateNode {
+	values := make([]ExpressionNode, 0, len(expr.Values))
+	for _, it := range expr.Values {
+		switch node := cc.convExpr(it).(type) {
+		case ExpressionNode:
+			values = append(values, node)
+		case PredicateNode:
+			values = append(values, &PredicateExpressionNode{P: node})
+		default:
+			panic(fmt.Sprintf("unimplement: row value type %T!", node))
+		}
+	}
+
+	return &AtomPredicateNode{
+		A: &RowExpressionAtom{
+			Values: values,
+		},
+	}
+}
+
 func (cc *convCtx) convBetweenExpr(expr *ast.BetweenExpr) PredicateNode {
 	var (
 		key   = cc.convExpr(expr.Expr)
@@ -1443,8 +1592,30 @@ func (cc *convCtx) convBetweenExpr(expr *ast.BetweenExpr) PredicateNode {
 	}
 }
 
+// convSubqueryExpr converts the scalar subquery, eg: amount > (SELECT AVG(amount) FROM ...).
+func (cc *convCtx) convSubqueryExpr(expr *ast.SubqueryExpr) PredicateNode {
+	sel, ok := expr.Query.(*ast.SelectStmt)
+	if !ok {
+		panic(fmt.Sprintf("unimplement: subquery %T!", expr.Query))
+	}
+	return &AtomPredicateNode{
+		A: &SubqueryExpressionAtom{Sub: cc.convSelectStmt(sel)},
+	}
+}
+
 func (cc *convCtx) convPatternInExpr(expr *ast.PatternInExpr) PredicateNode {
 	key := cc.convExpr(expr.Expr)
+
+	// eg: uid IN (SELECT uid FROM ...), the other subqueries are rejected by checkUnsupported
+	if sub, ok := expr.Sel.(*ast.SubqueryExpr); ok {
+		cc.flag |= _ccHasInSelect
+		return &InPredicateNode{
+			Not: expr.Not,
+			P:   key.(PredicateNode),
+			Sub: cc.convSelectStmt(sub.Query.(*ast.SelectStmt)),
+		}
+	}
+
 	list := make([]ExpressionNode, 0, len(expr.List))
 	for _, it := range expr.List {
 		pn := cc.convExpr(it).(PredicateNode)
@@ -1494,6 +1665,8 @@ func (cc *convCtx) convValueExpr(expr ast.ValueExpr) PredicateNode {
 			// TODO: decimal or float?
 			f, _ := strconv.ParseFloat(val.String(), 64)
 			atom = &ConstantExpressionAtom{Inner: f}
+		case test_driver.BinaryLiteral:
+			atom = &ConstantExpressionAtom{Inner: BitLiteral(val)}
 		default:
 			if val == nil {
 				atom = &ConstantExpressionAtom{Inner: proto.Null{}}
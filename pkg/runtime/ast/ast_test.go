@@ -95,6 +95,11 @@ func TestParse_UnionStmt(t *testing.T) {
 		{"select 1 union select 2", "SELECT 1 UNION SELECT 2"},
 		{"select 1 union distinct select 2", "SELECT 1 UNION SELECT 2"},
 		{"select 1 union all select 2", "SELECT 1 UNION ALL SELECT 2"},
+		{"select 1 intersect select 2", "SELECT 1 INTERSECT SELECT 2"},
+		{"select 1 intersect all select 2", "SELECT 1 INTERSECT ALL SELECT 2"},
+		{"select 1 except select 2 union select 3", "SELECT 1 EXCEPT SELECT 2 UNION SELECT 3"},
+		{"select 1 except all select 2", "SELECT 1 EXCEPT ALL SELECT 2"},
+		{"select uid from student union select uid from tb_user order by uid desc limit 1,2", "SELECT `uid` FROM `student` UNION SELECT `uid` FROM `tb_user` ORDER BY `uid` DESC LIMIT 1,2"},
 		{"select id,uid,name,nickname from student where uid in (?,?,?) union all select id,uid,name,nickname from tb_user where uid in (?,?,?)", "SELECT `id`,`uid`,`name`,`nickname` FROM `student` WHERE `uid` IN (?,?,?) UNION ALL SELECT `id`,`uid`,`name`,`nickname` FROM `tb_user` WHERE `uid` IN (?,?,?)"},
 	} {
 		t.Run(next.input, func(t *testing.T) {
@@ -107,6 +112,118 @@ func TestParse_UnionStmt(t *testing.T) {
 			assert.Equal(t, next.expect, actual)
 		})
 	}
+
+	// nested set operations and set operations in IN predicate are not supported
+	for _, next := range []string{
+		"select 1 union (select 2 union select 3)",
+		"select * from (select 1 except (select 2 intersect select 3)) t",
+		"select * from student where uid in (select 1 union select 2)",
+	} {
+		t.Run(next, func(t *testing.T) {
+			_, _, err := Parse(next)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParse_CTE(t *testing.T) {
+	type tt struct {
+		input  string
+		expect string
+	}
+
+	for _, next := range []tt{
+		// merged into the referencing query
+		{
+			"with recent as (select uid, name from student where uid > 100) select name from recent where uid = 101",
+			"SELECT `name` FROM `student` WHERE `uid` > 100 AND `uid` = 101",
+		},
+		{
+			"with recent as (select * from student where uid > 100 or age < 18) select r.name from recent r where r.uid = 101 order by r.name",
+			"SELECT `name` FROM `student` WHERE (`uid` > 100 OR `age` < 18) AND `uid` = 101 ORDER BY `name`",
+		},
+		{
+			"with recent as (select uid, name as n from student s where s.gender = 1) select * from recent where n = 'foo'",
+			"SELECT `uid`,`name` AS `n` FROM `student` AS `s` WHERE `s`.`gender` = 1 AND `name` = 'foo'",
+		},
+		{
+			"with recent(id, n) as (select uid, name from student) select n from recent where id = 1 order by n",
+			"SELECT `name` AS `n` FROM `student` WHERE `uid` = 1 ORDER BY `n`",
+		},
+		{
+			"with a as (select uid, name from student where uid > 1), b as (select uid from a where uid < 10) select uid from b",
+			"SELECT `uid` FROM `student` WHERE `uid` > 1 AND `uid` < 10",
+		},
+		{
+			"with student as (select uid from student where uid = 1) select uid from student",
+			"SELECT `uid` FROM `student` WHERE `uid` = 1",
+		},
+		// inlined as derived table
+		{
+			"with c as (select uid, count(*) as cnt from student group by uid) select uid from c where cnt > 1",
+			"SELECT `uid` FROM (SELECT `uid`,COUNT(1) AS `cnt` FROM `student` GROUP BY `uid`) AS `c` WHERE `cnt` > 1",
+		},
+		{
+			"with c as (select uid from student limit 10) select * from c union all select uid from c",
+			"SELECT * FROM (SELECT `uid` FROM `student` LIMIT 10) AS `c` UNION ALL SELECT `uid` FROM (SELECT `uid` FROM `student` LIMIT 10) AS `c`",
+		},
+	} {
+		t.Run(next.input, func(t *testing.T) {
+			_, stmt, err := Parse(next.input)
+			assert.NoError(t, err, "should parse ok")
+
+			actual, err := RestoreToString(RestoreDefault, stmt.(Restorer))
+			assert.NoError(t, err, "should restore ok")
+			assert.Equal(t, next.expect, actual)
+		})
+	}
+
+	_, _, err := Parse("with recursive c(n) as (select 1 union all select n + 1 from c where n < 3) select n from c")
+	assert.ErrorIs(t, err, ErrRecursiveCTE)
+
+	_, _, err = Parse("with c(a, b) as (select uid from student) select a from c")
+	assert.Error(t, err)
+}
+
+func TestParse_DerivedTable(t *testing.T) {
+	type tt struct {
+		input  string
+		expect string
+	}
+
+	for _, next := range []tt{
+		// merged into the referencing query
+		{
+			"select dept, sum(x) from (select dept, x from student) sub group by dept",
+			"SELECT `dept`,SUM(`x`) FROM `student` GROUP BY `dept`",
+		},
+		{
+			"select sub.d, avg(sub.score) as s from (select dept as d, score from student where uid > 1) sub where sub.score > 60 group by sub.d order by s",
+			"SELECT `dept` AS `d`,AVG(`score`) AS `s` FROM `student` WHERE `uid` > 1 AND `score` > 60 GROUP BY `dept` ORDER BY `s`",
+		},
+		{
+			"select count(*) from (select uid from (select uid, name from student where uid < 10) a) b",
+			"SELECT COUNT(1) FROM `student` WHERE `uid` < 10",
+		},
+		// kept as derived table
+		{
+			"select dept, sum(cnt) from (select dept, count(*) as cnt from student group by dept) sub group by dept",
+			"SELECT `dept`,SUM(`cnt`) FROM (SELECT `dept`,COUNT(1) AS `cnt` FROM `student` GROUP BY `dept`) AS `sub` GROUP BY `dept`",
+		},
+		{
+			"select uid from (select uid from student limit 10) sub where uid > 1",
+			"SELECT `uid` FROM (SELECT `uid` FROM `student` LIMIT 10) AS `sub` WHERE `uid` > 1",
+		},
+	} {
+		t.Run(next.input, func(t *testing.T) {
+			_, stmt, err := Parse(next.input)
+			assert.NoError(t, err, "should parse ok")
+
+			actual, err := RestoreToString(RestoreDefault, stmt.(Restorer))
+			assert.NoError(t, err, "should restore ok")
+			assert.Equal(t, next.expect, actual)
+		})
+	}
 }
 
 func TestParse_SelectStmt(t *testing.T) {
@@ -119,6 +236,9 @@ func TestParse_SelectStmt(t *testing.T) {
 		{"select * from a left join b on a.k = b.k", "SELECT * FROM `a` LEFT JOIN `b` ON `a`.`k` = `b`.`k`"},
 		{"select * from foo as a left join bar as b on a.k = b.k", "SELECT * FROM `foo` AS `a` LEFT JOIN `bar` AS `b` ON `a`.`k` = `b`.`k`"},
 		{"select @@version", "SELECT @@`version`"},
+		{"select @rownum := @rownum + 1 as rn, name from student", "SELECT @`rownum` := @`rownum`+1 AS `rn`,`name` FROM `student`"},
+		{"select * from student where uid in (select uid from premium where tier = 'gold')", "SELECT * FROM `student` WHERE `uid` IN (SELECT `uid` FROM `premium` WHERE `tier` = 'gold')"},
+		{"select o.id from orders o where o.amount > (select avg(amount) from orders where user_id = o.user_id)", "SELECT `o`.`id` FROM `orders` AS `o` WHERE `o`.`amount` > (SELECT AVG(`amount`) FROM `orders` WHERE `user_id` = `o`.`user_id`)"},
 		{"select * from student for update", "SELECT * FROM `student` FOR UPDATE"},
 		{"select connection_id()", "SELECT CONNECTION_ID()"},
 		{`SELECT CONCAT("'", user, "'@'",host,"'") FROM mysql.user`, "SELECT CONCAT('\\'',`user`,'\\'@\\'',`host`,'\\'') FROM `mysql`.`user`"},
@@ -136,7 +256,9 @@ func TestParse_SelectStmt(t *testing.T) {
 		{"select cast(3.14 as char(6))", "SELECT CAST(3.14 AS CHAR(6))"},
 		//{"select cast('foo' as nchar(1))", "SELECT CAST('foo' AS NCHAR(1))"},
 		{"select * from student force index(uk_uid) where uid in (1,2,3)", "SELECT * FROM `student` FORCE INDEX(`uk_uid`) WHERE `uid` IN (1,2,3)"},
+		{"select * from student where (uid,name) in ((1,?),(2,'foo'))", "SELECT * FROM `student` WHERE (`uid`,`name`) IN ((1,?),(2,'foo'))"},
 		{"select * from student PARTITION (foo,bar) as foobar", "SELECT * FROM `student` PARTITION (`foo`,`bar`) AS `foobar`"},
+		{"select * from student order by name collate utf8mb4_bin desc, uid", "SELECT * FROM `student` ORDER BY `name` COLLATE utf8mb4_bin DESC, `uid`"},
 		{"select IF(sum(gender),1,0)+1 as xy from tb_user where uid in (7777, 10099) or uid between 10000 and 10004", "SELECT IF(SUM(`gender`),1,0)+1 AS `xy` FROM `tb_user` WHERE `uid` IN (7777,10099) OR `uid` BETWEEN 10000 AND 10004"},
 		{"select * from tb_user where uid is not null and uid = 10001", "SELECT * FROM `tb_user` WHERE `uid` IS NOT NULL AND `uid` = 10001"},
 		{"select * from student where uid = case when 2>1 then ? end", "SELECT * FROM `student` WHERE `uid` = CASE WHEN 2 > 1 THEN ? END"},
@@ -147,7 +269,7 @@ func TestParse_SelectStmt(t *testing.T) {
 		{"select * from student where uid = ABS(1-1+(case when IF(1=?,2,1)-1 then 1 else ? end))", "SELECT * FROM `student` WHERE `uid` = ABS(1-1+(CASE WHEN IF(1 = ?,2,1)-1 THEN 1 ELSE ? END))"},
 		{"select * from student where uid = case (4%5) when 1 then 1 when 4 then ? else 0 end", "SELECT * FROM `student` WHERE `uid` = CASE (4%5) WHEN 1 THEN 1 WHEN 4 THEN ? ELSE 0 END"},
 		//{"select birth_year,gender,count(*) as cnt from student where uid between 1 and 100 group by birth_year,gender having count(*)>5", "SELECT `birth_year`,`gender`,COUNT(*) AS `cnt` FROM `student` WHERE `uid` BETWEEN 1 AND 100 GROUP BY `birth_year`,`gender` HAVING COUNT(*) > 5"},
-		{`select * from (select id,uid from student where uid in(1,?,?)) as aaa`, "SELECT * FROM (SELECT `id`,`uid` FROM `student` WHERE `uid` IN (1,?,?)) AS `aaa`"},
+		{`select * from (select id,uid from student where uid in(1,?,?)) as aaa`, "SELECT `id`,`uid` FROM `student` WHERE `uid` IN (1,?,?)"},
 		//{"select count(*) from student where aaa.uid = 1", "SELECT COUNT(*) FROM `student` WHERE `aaa`.`uid` = 1"},
 		{`select * from (select id,uid from student where uid in(1,2,3) union all select id,uid from student where uid in (?,?)) as aaa where aaa.uid=?`, "SELECT * FROM (SELECT `id`,`uid` FROM `student` WHERE `uid` IN (1,2,3) UNION ALL SELECT `id`,`uid` FROM `student` WHERE `uid` IN (?,?)) AS `aaa` WHERE `aaa`.`uid` = ?"},
 		{"select * from student where not uid = 1", "SELECT * FROM `student` WHERE not `uid` = 1"},
@@ -155,9 +277,12 @@ func TestParse_SelectStmt(t *testing.T) {
 		{"select date_add(NOW(), interval 1 hour)", "SELECT DATE_ADD(NOW(),INTERVAL 1 HOUR)"},
 		{"select distinct gender from student where uid in (1,2,3,4)", "SELECT DISTINCT `gender` FROM `student` WHERE `uid` IN (1,2,3,4)"},
 		{"select distinct(gender) from student where uid in (1,2,3,4)", "SELECT DISTINCT (`gender`) FROM `student` WHERE `uid` IN (1,2,3,4)"},
+		{"select * from student where active = b'1' or flags = x'0A'", "SELECT * FROM `student` WHERE `active` = b'1' OR `flags` = b'1010'"},
 		{"select * from foo inner join bar on foo.x = bar.y", "SELECT * FROM `foo` INNER JOIN `bar` ON `foo`.`x` = `bar`.`y`"},
 		{"select * from foo left outer join bar on foo.x = bar.y", "SELECT * FROM `foo` LEFT JOIN `bar` ON `foo`.`x` = `bar`.`y`"},
 		{"select null as pkid", "SELECT NULL AS `pkid`"},
+		{"select now() from dual", "SELECT NOW()"},
+		{"select 1 from DUAL where 1 = 0", "SELECT 1 FROM DUAL WHERE 1 = 0"},
 	} {
 		t.Run(next.input, func(t *testing.T) {
 			_, stmt, err := Parse(next.input)
@@ -266,6 +391,12 @@ func TestParse_ExplainStmt(t *testing.T) {
 	assert.IsType(t, (*ExplainStatement)(nil), stmt)
 	s := MustRestoreToString(RestoreDefault, stmt)
 	assert.Equal(t, "EXPLAIN SELECT * FROM `student` WHERE `uid` = 1", s)
+
+	_, stmt, err = Parse("explain analyze select * from student where uid = 1")
+	assert.NoError(t, err)
+	assert.True(t, stmt.(*ExplainStatement).Analyze)
+	s = MustRestoreToString(RestoreDefault, stmt)
+	assert.Equal(t, "EXPLAIN ANALYZE SELECT * FROM `student` WHERE `uid` = 1", s)
 }
 
 func TestParseMore(t *testing.T) {
@@ -387,6 +518,35 @@ func TestParse_InsertStmt(t *testing.T) {
 	}
 }
 
+func TestParse_ReplaceStmt(t *testing.T) {
+	type tt struct {
+		input  string
+		expect string
+	}
+
+	for _, it := range []tt{
+		{"replace into student value (?,?)", "REPLACE INTO `student` VALUES (?, ?)"},
+		{
+			"replace into student set id=1,name='foo'",
+			"REPLACE INTO `student` SET `id` = 1, `name` = 'foo'",
+		},
+		{
+			"replace low_priority into student(id,name) values(?,?),(?,?)",
+			"REPLACE LOW_PRIORITY INTO `student`(`id`, `name`) VALUES (?, ?),(?, ?)",
+		},
+	} {
+		t.Run(it.input, func(t *testing.T) {
+			_, stmt, err := Parse(it.input)
+			assert.NoError(t, err)
+			assert.IsTypef(t, (*ReplaceStatement)(nil), stmt, "should be replace statement")
+
+			actual, err := RestoreToString(RestoreDefault, stmt.(Restorer))
+			assert.NoError(t, err, "should restore ok")
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
 func TestRestoreCount(t *testing.T) {
 	_, stmt := MustParse("select count(1)")
 	sel := stmt.(*SelectStatement)
@@ -473,6 +633,30 @@ func TestParse_SetVariable(t *testing.T) {
 	t.Logf("restore: %s\n", sb.String())
 }
 
+func TestParse_SetTransaction(t *testing.T) {
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{"set transaction read only", []string{"tx_read_only_one_shot"}},
+		{"/* comment */ SET TRANSACTION READ WRITE", []string{"tx_read_only_one_shot"}},
+		{"SET\n  Transaction READ ONLY", []string{"tx_read_only_one_shot"}},
+		{"set transaction isolation level read committed, read only", []string{"tx_isolation_one_shot", "tx_read_only_one_shot"}},
+		{"set session transaction read only", []string{"transaction_read_only"}},
+		{"set @@tx_read_only = 1", []string{"transaction_read_only"}},
+		{"set transaction_read_only = 1", []string{"transaction_read_only"}},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			_, stmt := MustParse(it.sql)
+			var names []string
+			for _, v := range stmt.(*SetStatement).Variables {
+				names = append(names, v.Name)
+			}
+			assert.Equal(t, it.expect, names)
+		})
+	}
+}
+
 func TestRestore(t *testing.T) {
 	type tt struct {
 		input  string
@@ -0,0 +1,429 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"github.com/arana-db/parser/ast"
+	"github.com/arana-db/parser/opcode"
+
+	"github.com/pkg/errors"
+)
+
+// ErrRecursiveCTE will be returned if the statement has a WITH RECURSIVE clause, only the non-recursive
+// CTEs are supported now, which will be inlined into the referencing queries.
+var ErrRecursiveCTE = errors.New("unsupported: recursive common table expression")
+
+// prepareCTEs validates the CTEs of statement, and applies the column lists of CTEs to the select fields.
+func prepareCTEs(node ast.Node) error {
+	var v ctePreparer
+	node.Accept(&v)
+	return v.err
+}
+
+type ctePreparer struct {
+	err error
+}
+
+func (cp *ctePreparer) Enter(n ast.Node) (ast.Node, bool) {
+	with, ok := n.(*ast.WithClause)
+	if !ok {
+		return n, false
+	}
+	if with.IsRecursive {
+		cp.err = ErrRecursiveCTE
+		return n, true
+	}
+	for _, cte := range with.CTEs {
+		if len(cte.ColNameList) == 0 {
+			continue
+		}
+		var sel *ast.SelectStmt
+		switch q := cte.Query.Query.(type) {
+		case *ast.SelectStmt:
+			sel = q
+		case *ast.SetOprStmt:
+			sel, _ = q.SelectList.Selects[0].(*ast.SelectStmt)
+		}
+		if sel == nil || sel.Fields == nil {
+			cp.err = errors.Errorf("unsupported: column list of common table expression '%s'", cte.Name.O)
+			return n, true
+		}
+		if len(sel.Fields.Fields) != len(cte.ColNameList) {
+			cp.err = errors.Errorf("In definition of view, derived table or common table expression, SELECT list and column names list have different column counts")
+			return n, true
+		}
+		for i, field := range sel.Fields.Fields {
+			if field.WildCard != nil {
+				cp.err = errors.Errorf("unsupported: column list of common table expression '%s' with wildcard", cte.Name.O)
+				return n, true
+			}
+			field.AsName = cte.ColNameList[i]
+		}
+	}
+	return n, false
+}
+
+func (cp *ctePreparer) Leave(n ast.Node) (ast.Node, bool) {
+	return n, cp.err == nil
+}
+
+// enterCTEs makes the CTEs visible, the returned function should be called when leaving the scope.
+func (cc *convCtx) enterCTEs(with *ast.WithClause) func() {
+	prev := cc.ctes
+	cc.ctes = append(cc.ctes[:len(cc.ctes):len(cc.ctes)], with.CTEs...)
+	return func() {
+		cc.ctes = prev
+	}
+}
+
+// lookupCTE returns the index of visible CTE which is referenced by the table name.
+func (cc *convCtx) lookupCTE(tn *ast.TableName) (int, bool) {
+	if len(tn.Schema.L) > 0 {
+		return 0, false
+	}
+	if _, ok := cc.physicalTables[tn]; ok {
+		return 0, false
+	}
+	for i := len(cc.ctes) - 1; i >= 0; i-- {
+		if cc.ctes[i].Name.L == tn.Name.L {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// inScopeOfCTE executes fn in the scope of the i-th CTE, only the preceding CTEs are visible.
+func (cc *convCtx) inScopeOfCTE(i int, fn func()) {
+	prev := cc.ctes
+	cc.ctes = cc.ctes[:i]
+	defer func() {
+		cc.ctes = prev
+	}()
+	fn()
+}
+
+// convCTE converts the definition of i-th CTE, which will be used as a derived table.
+func (cc *convCtx) convCTE(i int) (ret Node) {
+	cte := cc.ctes[i]
+	cc.inScopeOfCTE(i, func() {
+		switch q := cte.Query.Query.(type) {
+		case *ast.SelectStmt:
+			ret = cc.convSelectStmt(q)
+		case *ast.SetOprStmt:
+			ret = cc.convUnionStmt(q)
+		default:
+			panic(errors.Errorf("unimplement: common table expression type %T!", q))
+		}
+	})
+	return
+}
+
+// mergeSubquery merges the referenced CTE or derived table into the query if possible, so that the predicates
+// can be used to compute shards, and the aggregations can be pushed down to the shards.
+//
+// The CTE or derived table can be merged only if it is a simple query of single table, for example:
+//
+//	WITH recent AS (SELECT uid, name AS n FROM student WHERE uid > 100) SELECT n FROM recent WHERE uid = 101
+//	SELECT n FROM (SELECT uid, name AS n FROM student WHERE uid > 100) recent WHERE uid = 101
+//
+// will be merged into:
+//
+//	SELECT name AS n FROM student WHERE uid > 100 AND uid = 101
+//
+// The others will be kept as derived tables.
+func (cc *convCtx) mergeSubquery(stmt *ast.SelectStmt) {
+	if stmt.From == nil || stmt.From.TableRefs == nil || stmt.From.TableRefs.Right != nil {
+		return
+	}
+	ts, ok := stmt.From.TableRefs.Left.(*ast.TableSource)
+	if !ok {
+		return
+	}
+
+	var (
+		alias = ts.AsName.L
+		scope = len(cc.ctes)
+		body  *ast.SelectStmt
+	)
+	switch source := ts.Source.(type) {
+	case *ast.TableName:
+		i, ok := cc.lookupCTE(source)
+		if !ok {
+			return
+		}
+		if body, ok = cc.ctes[i].Query.Query.(*ast.SelectStmt); !ok {
+			return
+		}
+		scope = i
+		if len(alias) == 0 {
+			alias = source.Name.L
+		}
+	case *ast.SelectStmt:
+		body = source
+	default:
+		return
+	}
+
+	// the definition may reference the preceding CTEs or derived tables, which should be merged first
+	var inner *ast.TableName
+	cc.inScopeOfCTE(scope, func() {
+		if body.With != nil {
+			return
+		}
+		cc.mergeSubquery(body)
+		if !isMergeableCTE(body) {
+			return
+		}
+		tn := body.From.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+		if _, isCTE := cc.lookupCTE(tn); !isCTE {
+			inner = tn
+		}
+	})
+	if inner == nil {
+		return
+	}
+
+	r := cteRewriter{
+		alias:   alias,
+		columns: make(map[string]ast.ExprNode),
+	}
+	for _, field := range body.Fields.Fields {
+		switch {
+		case field.WildCard != nil:
+			r.wildcard = true
+		case len(field.AsName.L) > 0:
+			r.columns[field.AsName.L] = field.Expr
+		default:
+			if c, ok := field.Expr.(*ast.ColumnNameExpr); ok {
+				r.columns[c.Name.Name.L] = c
+			}
+		}
+	}
+
+	// check all the referenced columns can be resolved before rewriting
+	if !r.rewrite(stmt, true) {
+		return
+	}
+	r.rewrite(stmt, false)
+
+	var fields []*ast.SelectField
+	for _, field := range stmt.Fields.Fields {
+		if field.WildCard != nil {
+			fields = append(fields, body.Fields.Fields...)
+			continue
+		}
+		fields = append(fields, field)
+	}
+	stmt.Fields.Fields = fields
+	stmt.From = body.From
+	stmt.Where = mergeWhere(body.Where, stmt.Where)
+
+	if cc.physicalTables == nil {
+		cc.physicalTables = make(map[*ast.TableName]struct{})
+	}
+	cc.physicalTables[inner] = struct{}{}
+}
+
+func isMergeableCTE(body *ast.SelectStmt) bool {
+	if body.Kind != ast.SelectStmtKindSelect || body.With != nil || body.Distinct ||
+		body.GroupBy != nil || body.Having != nil || len(body.WindowSpecs) > 0 ||
+		body.OrderBy != nil || body.Limit != nil || body.LockInfo != nil || body.SelectIntoOpt != nil {
+		return false
+	}
+	if body.From == nil || body.From.TableRefs == nil || body.From.TableRefs.Right != nil {
+		return false
+	}
+	ts, ok := body.From.TableRefs.Left.(*ast.TableSource)
+	if !ok {
+		return false
+	}
+	if _, ok = ts.Source.(*ast.TableName); !ok {
+		return false
+	}
+
+	var v cteFieldChecker
+	for _, field := range body.Fields.Fields {
+		if field.WildCard != nil {
+			if len(field.WildCard.Schema.L) > 0 || len(field.WildCard.Table.L) > 0 {
+				return false
+			}
+			continue
+		}
+		field.Expr.Accept(&v)
+		if v.invalid {
+			return false
+		}
+	}
+	return true
+}
+
+// cteFieldChecker checks whether the select field can be evaluated row by row.
+type cteFieldChecker struct {
+	invalid bool
+}
+
+func (fc *cteFieldChecker) Enter(n ast.Node) (ast.Node, bool) {
+	switch n.(type) {
+	case *ast.AggregateFuncExpr, *ast.WindowFuncExpr, *ast.SubqueryExpr, *ast.VariableExpr:
+		fc.invalid = true
+		return n, true
+	}
+	return n, false
+}
+
+func (fc *cteFieldChecker) Leave(n ast.Node) (ast.Node, bool) {
+	return n, !fc.invalid
+}
+
+// cteRewriter replaces the columns of merged CTE with the select fields of definition.
+type cteRewriter struct {
+	alias    string
+	columns  map[string]ast.ExprNode
+	wildcard bool
+
+	dryRun   bool
+	aliases  map[string]struct{} // the select aliases of query, which won't be rewritten
+	resolved bool
+}
+
+// rewrite rewrites all the columns of query, returns false if any column cannot be resolved.
+func (r *cteRewriter) rewrite(stmt *ast.SelectStmt, dryRun bool) bool {
+	r.dryRun = dryRun
+	r.resolved = true
+	r.aliases = nil
+
+	for _, field := range stmt.Fields.Fields {
+		if field.WildCard != nil {
+			if len(field.WildCard.Schema.L) > 0 || (len(field.WildCard.Table.L) > 0 && field.WildCard.Table.L != r.alias) {
+				return false
+			}
+			continue
+		}
+		column, isColumn := field.Expr.(*ast.ColumnNameExpr)
+		field.Expr = r.accept(field.Expr)
+		// keep the name of result column
+		if isColumn && !dryRun && len(field.AsName.L) == 0 {
+			if c, ok := field.Expr.(*ast.ColumnNameExpr); !ok || c.Name.Name.L != column.Name.Name.L {
+				field.AsName = column.Name.Name
+			}
+		}
+	}
+	if stmt.Where != nil {
+		stmt.Where = r.accept(stmt.Where)
+	}
+
+	// the names of GROUP BY, HAVING and ORDER BY may reference the select aliases
+	r.aliases = make(map[string]struct{})
+	for _, field := range stmt.Fields.Fields {
+		if len(field.AsName.L) > 0 {
+			r.aliases[field.AsName.L] = struct{}{}
+		}
+	}
+	if stmt.GroupBy != nil {
+		for _, it := range stmt.GroupBy.Items {
+			it.Expr = r.accept(it.Expr)
+		}
+	}
+	if stmt.Having != nil {
+		stmt.Having.Expr = r.accept(stmt.Having.Expr)
+	}
+	if stmt.OrderBy != nil {
+		for _, it := range stmt.OrderBy.Items {
+			it.Expr = r.accept(it.Expr)
+		}
+	}
+
+	return r.resolved
+}
+
+func (r *cteRewriter) accept(expr ast.ExprNode) ast.ExprNode {
+	node, _ := expr.Accept(r)
+	return node.(ast.ExprNode)
+}
+
+func (r *cteRewriter) Enter(n ast.Node) (ast.Node, bool) {
+	// the correlated columns of subquery are not supported
+	if _, ok := n.(*ast.SubqueryExpr); ok {
+		r.resolved = false
+		return n, true
+	}
+	return n, false
+}
+
+func (r *cteRewriter) Leave(n ast.Node) (ast.Node, bool) {
+	c, ok := n.(*ast.ColumnNameExpr)
+	if !ok {
+		return n, r.resolved
+	}
+
+	name := c.Name
+	if len(name.Table.L) == 0 {
+		if _, ok := r.aliases[name.Name.L]; ok {
+			return n, true
+		}
+	}
+
+	expr, ok := r.resolve(name)
+	if !ok {
+		r.resolved = false
+		return n, false
+	}
+	if r.dryRun {
+		return n, true
+	}
+	return expr, true
+}
+
+func (r *cteRewriter) resolve(name *ast.ColumnName) (ast.ExprNode, bool) {
+	if len(name.Schema.L) > 0 || (len(name.Table.L) > 0 && name.Table.L != r.alias) {
+		return nil, false
+	}
+	if expr, ok := r.columns[name.Name.L]; ok {
+		switch expr.(type) {
+		case *ast.ColumnNameExpr, ast.ValueExpr:
+			return expr, true
+		default:
+			return &ast.ParenthesesExpr{Expr: expr}, true
+		}
+	}
+	if r.wildcard {
+		return &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: name.Name}}, true
+	}
+	return nil, false
+}
+
+func mergeWhere(inner, outer ast.ExprNode) ast.ExprNode {
+	if inner == nil {
+		return outer
+	}
+	if outer == nil {
+		return inner
+	}
+	return &ast.BinaryOperationExpr{
+		Op: opcode.LogicAnd,
+		L:  wrapLogicOr(inner),
+		R:  wrapLogicOr(outer),
+	}
+}
+
+func wrapLogicOr(expr ast.ExprNode) ast.ExprNode {
+	if b, ok := expr.(*ast.BinaryOperationExpr); ok && (b.Op == opcode.LogicOr || b.Op == opcode.LogicXor) {
+		return &ast.ParenthesesExpr{Expr: expr}
+	}
+	return expr
+}
@@ -56,12 +56,16 @@ func (d *DescribeStatement) Mode() SQLType {
 
 // ExplainStatement represents mysql explain statement. see https://dev.mysql.com/doc/refman/8.0/en/explain.html
 type ExplainStatement struct {
-	Target Statement
-	Table  TableName
+	Target  Statement
+	Table   TableName
+	Analyze bool // EXPLAIN ANALYZE, execute the target statement and collect the statistics
 }
 
 func (e *ExplainStatement) Restore(flag RestoreFlag, sb *strings.Builder, args *[]int) error {
 	sb.WriteString("EXPLAIN ")
+	if e.Analyze {
+		sb.WriteString("ANALYZE ")
+	}
 	if err := e.Target.Restore(flag, sb, args); err != nil {
 		return errors.WithStack(err)
 	}
@@ -46,6 +46,7 @@ var (
 	_ ExpressionAtom = (*UnaryExpressionAtom)(nil)
 	_ ExpressionAtom = (*SystemVariableExpressionAtom)(nil)
 	_ ExpressionAtom = (*IntervalExpressionAtom)(nil)
+	_ ExpressionAtom = (*SubqueryExpressionAtom)(nil)
 )
 
 var _compat80Dict = map[string]string{
@@ -116,6 +117,12 @@ type SystemVariableExpressionAtom struct {
 	Name   string
 	System bool
 	Global bool
+	Value  ExpressionNode // the assigned value of user variable, eg: @a := 1
+}
+
+// IsAssignment returns true if the user variable is assigned, eg: @a := 1
+func (sy *SystemVariableExpressionAtom) IsAssignment() bool {
+	return sy.Value != nil
 }
 
 func (sy *SystemVariableExpressionAtom) Accept(visitor Visitor) (interface{}, error) {
@@ -127,7 +134,7 @@ func (sy *SystemVariableExpressionAtom) IsCompat80() bool {
 	return ok
 }
 
-func (sy *SystemVariableExpressionAtom) Restore(rf RestoreFlag, sb *strings.Builder, _ *[]int) error {
+func (sy *SystemVariableExpressionAtom) Restore(rf RestoreFlag, sb *strings.Builder, args *[]int) error {
 	if rf.Has(RestoreCompat80) {
 		if compat80, ok := _compat80Dict[sy.Name]; ok {
 			sb.WriteString(compat80)
@@ -147,6 +154,13 @@ func (sy *SystemVariableExpressionAtom) Restore(rf RestoreFlag, sb *strings.Buil
 
 	WriteID(sb, sy.Name)
 
+	if sy.Value != nil {
+		sb.WriteString(" := ")
+		if err := sy.Value.Restore(rf, sb, args); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
 	return nil
 }
 
@@ -155,10 +169,44 @@ func (sy *SystemVariableExpressionAtom) phantom() expressionAtomPhantom {
 }
 
 func (sy *SystemVariableExpressionAtom) Clone() ExpressionAtom {
+	var value ExpressionNode
+	if sy.Value != nil {
+		value = sy.Value.Clone()
+	}
 	return &SystemVariableExpressionAtom{
 		Name:   sy.Name,
 		System: sy.System,
 		Global: sy.Global,
+		Value:  value,
+	}
+}
+
+// SubqueryExpressionAtom represents the scalar subquery, eg: amount > (SELECT AVG(amount) FROM ...).
+type SubqueryExpressionAtom struct {
+	Sub *SelectStatement
+}
+
+func (sq *SubqueryExpressionAtom) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAtomSubquery(sq)
+}
+
+func (sq *SubqueryExpressionAtom) Restore(rf RestoreFlag, sb *strings.Builder, args *[]int) error {
+	sb.WriteByte('(')
+	if err := sq.Sub.Restore(rf, sb, args); err != nil {
+		return errors.WithStack(err)
+	}
+	sb.WriteByte(')')
+	return nil
+}
+
+func (sq *SubqueryExpressionAtom) phantom() expressionAtomPhantom {
+	return expressionAtomPhantom{}
+}
+
+func (sq *SubqueryExpressionAtom) Clone() ExpressionAtom {
+	// the subquery is shared, same as the subquery of IN predicate
+	return &SubqueryExpressionAtom{
+		Sub: sq.Sub,
 	}
 }
 
@@ -172,7 +220,8 @@ func (u *UnaryExpressionAtom) Accept(visitor Visitor) (interface{}, error) {
 }
 
 func (u *UnaryExpressionAtom) IsOperatorNot() bool {
-	switch u.Operator {
+	// the operator may be formatted with trailing space, eg: 'not '
+	switch strings.ToUpper(strings.TrimSpace(u.Operator)) {
 	case "!", "NOT":
 		return true
 	}
@@ -269,6 +318,8 @@ func constant2string(value interface{}) string {
 		return strconv.FormatFloat(v, 'f', -1, 64)
 	case float32:
 		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case BitLiteral:
+		return v.String()
 	default:
 		panic(fmt.Sprintf("todo: render %T to string!", v))
 	}
@@ -287,6 +338,33 @@ func (c *ConstantExpressionAtom) Value() interface{} {
 	return c.Inner
 }
 
+// BitLiteral represents the bit-value or hexadecimal literal, eg: b'101', x'0A'.
+type BitLiteral []byte
+
+// Uint64 returns the value in numeric context, eg: b'101' -> 5.
+func (b BitLiteral) Uint64() (uint64, error) {
+	if len(b) > 8 {
+		return 0, errors.Errorf("bit literal %s is too long", b)
+	}
+	var ret uint64
+	for _, it := range b {
+		ret = ret<<8 | uint64(it)
+	}
+	return ret, nil
+}
+
+func (b BitLiteral) String() string {
+	var sb strings.Builder
+	for _, it := range b {
+		_, _ = fmt.Fprintf(&sb, "%08b", it)
+	}
+	bits := strings.TrimLeft(sb.String(), "0")
+	if len(bits) == 0 {
+		bits = "0"
+	}
+	return "b'" + bits + "'"
+}
+
 type ColumnNameExpressionAtom []string
 
 func NewSingleColumnNameExpressionAtom(name string) ColumnNameExpressionAtom {
@@ -430,6 +508,44 @@ func (n *NestedExpressionAtom) Clone() ExpressionAtom {
 	}
 }
 
+// RowExpressionAtom represents a row constructor, eg: (a,b) IN ((1,2),(3,4))
+type RowExpressionAtom struct {
+	Values []ExpressionNode
+}
+
+func (r *RowExpressionAtom) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAtomRow(r)
+}
+
+func (r *RowExpressionAtom) Restore(flag RestoreFlag, sb *strings.Builder, args *[]int) error {
+	sb.WriteByte('(')
+	for i, v := range r.Values {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		if err := v.Restore(flag, sb, args); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	sb.WriteByte(')')
+
+	return nil
+}
+
+func (r *RowExpressionAtom) phantom() expressionAtomPhantom {
+	return expressionAtomPhantom{}
+}
+
+func (r *RowExpressionAtom) Clone() ExpressionAtom {
+	values := make([]ExpressionNode, 0, len(r.Values))
+	for _, v := range r.Values {
+		values = append(values, v.Clone())
+	}
+	return &RowExpressionAtom{
+		Values: values,
+	}
+}
+
 type FunctionCallExpressionAtom struct {
 	F Node // *Function OR *AggrFunction OR *CaseWhenElseFunction OR *CastFunction
 }
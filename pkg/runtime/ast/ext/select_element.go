@@ -17,6 +17,10 @@
 
 package ext
 
+import (
+	"strings"
+)
+
 import (
 	"github.com/arana-db/arana/pkg/runtime/ast"
 )
@@ -30,6 +34,9 @@ var (
 
 	_ ast.SelectElement     = (*MappingSelectElement)(nil)
 	_ SelectElementProvider = (*MappingSelectElement)(nil)
+
+	_ ast.SelectElement     = (*DistinctAggrSelectElement)(nil)
+	_ SelectElementProvider = (*DistinctAggrSelectElement)(nil)
 )
 
 // WeakSelectElement represents a temporary SelectElement which will be cleaned finally.
@@ -52,6 +59,7 @@ type OrderedSelectElement struct {
 	ast.SelectElement
 	Ordinal int
 	Desc    bool
+	Collate string
 	GroupBy bool
 	OrderBy bool
 }
@@ -66,6 +74,9 @@ func (o OrderedSelectElement) Prev() ast.SelectElement {
 type MappingSelectElement struct {
 	ast.SelectElement
 	Mapping ast.SelectElement
+	// Placeholder is selected instead of the original element if present, since the value will be computed
+	// from the merged partial aggregates anyway, eg: VAR_SAMP(x) is computed from the merged VAR_POP(x).
+	Placeholder ast.SelectElement
 }
 
 func (vt MappingSelectElement) Prev() ast.SelectElement {
@@ -74,3 +85,28 @@ func (vt MappingSelectElement) Prev() ast.SelectElement {
 	}
 	return vt.SelectElement
 }
+
+func (vt MappingSelectElement) Restore(flag ast.RestoreFlag, sb *strings.Builder, args *[]int) error {
+	if vt.Placeholder != nil {
+		return vt.Placeholder.Restore(flag, sb, args)
+	}
+	return vt.SelectElement.Restore(flag, sb, args)
+}
+
+// DistinctAggrSelectElement represents an aggregate function with DISTINCT, eg: SUM(DISTINCT amount).
+// The raw argument will be selected from each shard, and the values will be deduplicated and aggregated globally.
+type DistinctAggrSelectElement struct {
+	ast.SelectElement
+	Arg ast.SelectElement
+}
+
+func (d DistinctAggrSelectElement) Prev() ast.SelectElement {
+	if p, ok := d.SelectElement.(SelectElementProvider); ok {
+		return p.Prev()
+	}
+	return d.SelectElement
+}
+
+func (d DistinctAggrSelectElement) Restore(flag ast.RestoreFlag, sb *strings.Builder, args *[]int) error {
+	return d.Arg.Restore(flag, sb, args)
+}
@@ -82,6 +82,15 @@ type Function struct {
 	args []*FunctionArg
 }
 
+// NewFunction creates a scalar function, eg: SQRT(x).
+func NewFunction(name string, args []*FunctionArg) *Function {
+	return &Function{
+		typ:  Fscalar,
+		name: name,
+		args: args,
+	}
+}
+
 func (f *Function) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitFunction(f)
 }
@@ -165,11 +174,18 @@ const (
 )
 
 const (
-	AggrAvg   = "AVG"
-	AggrMax   = "MAX"
-	AggrMin   = "MIN"
-	AggrSum   = "SUM"
-	AggrCount = "COUNT"
+	AggrAvg        = "AVG"
+	AggrMax        = "MAX"
+	AggrMin        = "MIN"
+	AggrSum        = "SUM"
+	AggrCount      = "COUNT"
+	AggrVarPop     = "VAR_POP"
+	AggrVarSamp    = "VAR_SAMP"
+	AggrStddevPop  = "STDDEV_POP"
+	AggrStddevSamp = "STDDEV_SAMP"
+	AggrBitAnd     = "BIT_AND"
+	AggrBitOr      = "BIT_OR"
+	AggrBitXor     = "BIT_XOR"
 )
 
 const (
@@ -55,6 +55,82 @@ func (b *baseInsertStatement) restoreHint(sb *strings.Builder) {
 	sb.WriteString(" ")
 }
 
+// restoreValues writes the columns and values, it is shared by INSERT and REPLACE.
+func (b *baseInsertStatement) restoreValues(flag RestoreFlag, sb *strings.Builder, args *[]int, values [][]ExpressionNode) error {
+	if b.IsSetSyntax() {
+		sb.WriteString(" SET ")
+		_ = b.Columns[0]
+		_ = values[0]
+
+		if len(b.Columns) != len(values[0]) {
+			return errors.Errorf("length of column and value doesn't match: %d<>%d", len(b.Columns), len(values[0]))
+		}
+
+		WriteID(sb, b.Columns[0])
+		sb.WriteString(" = ")
+		if err := values[0][0].Restore(flag, sb, args); err != nil {
+			return errors.WithStack(err)
+		}
+
+		for i := 1; i < len(b.Columns); i++ {
+			sb.WriteString(", ")
+			WriteID(sb, b.Columns[i])
+			sb.WriteString(" = ")
+			if err := values[0][i].Restore(flag, sb, args); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	} else if len(b.Columns) > 0 {
+		sb.WriteByte('(')
+		WriteID(sb, b.Columns[0])
+		for i := 1; i < len(b.Columns); i++ {
+			sb.WriteString(", ")
+			WriteID(sb, b.Columns[i])
+		}
+		sb.WriteString(") ")
+	} else {
+		sb.WriteByte(' ')
+	}
+
+	if !b.IsSetSyntax() {
+		sb.WriteString("VALUES ")
+
+		writeOne := func(flag RestoreFlag, sb *strings.Builder, args *[]int, values []ExpressionNode) error {
+			sb.WriteByte('(')
+
+			if len(values) > 0 {
+				if err := values[0].Restore(flag, sb, args); err != nil {
+					return errors.WithStack(err)
+				}
+				for i := 1; i < len(values); i++ {
+					sb.WriteString(", ")
+					if err := values[i].Restore(flag, sb, args); err != nil {
+						return errors.WithStack(err)
+					}
+				}
+
+			}
+
+			sb.WriteByte(')')
+
+			return nil
+		}
+
+		if err := writeOne(flag, sb, args, values[0]); err != nil {
+			return errors.WithStack(err)
+		}
+
+		for i := 1; i < len(values); i++ {
+			sb.WriteByte(',')
+			if err := writeOne(flag, sb, args, values[i]); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (b *baseInsertStatement) IsSetSyntax() bool {
 	return b.flag&_flagInsertSetSyntax != 0
 }
@@ -122,9 +198,38 @@ type ReplaceStatement struct {
 	Values [][]ExpressionNode
 }
 
+func NewReplaceStatement(table TableName, columns []string) *ReplaceStatement {
+	return &ReplaceStatement{
+		baseInsertStatement: &baseInsertStatement{
+			Table:   table,
+			Columns: columns,
+		},
+	}
+}
+
 func (r *ReplaceStatement) Restore(flag RestoreFlag, sb *strings.Builder, args *[]int) error {
-	// TODO implement me
-	panic("implement me")
+	sb.WriteString("REPLACE ")
+
+	r.restoreHint(sb)
+
+	// write priority, REPLACE only supports LOW_PRIORITY and DELAYED
+	if r.IsLowPriority() {
+		sb.WriteString("LOW_PRIORITY ")
+	} else if r.IsDelayed() {
+		sb.WriteString("DELAYED ")
+	}
+
+	sb.WriteString("INTO ")
+
+	if err := r.Table.Restore(flag, sb, args); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := r.restoreValues(flag, sb, args, r.Values); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
 }
 
 func (r *ReplaceStatement) Mode() SQLType {
@@ -171,75 +276,8 @@ func (is *InsertStatement) Restore(flag RestoreFlag, sb *strings.Builder, args *
 		return errors.WithStack(err)
 	}
 
-	if is.IsSetSyntax() {
-		sb.WriteString(" SET ")
-		_ = is.Columns[0]
-		_ = is.Values[0]
-
-		if len(is.Columns) != len(is.Values[0]) {
-			return errors.Errorf("length of column and value doesn't match: %d<>%d", len(is.Columns), len(is.Values[0]))
-		}
-
-		WriteID(sb, is.Columns[0])
-		sb.WriteString(" = ")
-		if err := is.Values[0][0].Restore(flag, sb, args); err != nil {
-			return errors.WithStack(err)
-		}
-
-		for i := 1; i < len(is.Columns); i++ {
-			sb.WriteString(", ")
-			WriteID(sb, is.Columns[i])
-			sb.WriteString(" = ")
-			if err := is.Values[0][i].Restore(flag, sb, args); err != nil {
-				return errors.WithStack(err)
-			}
-		}
-	} else if len(is.Columns) > 0 {
-		sb.WriteByte('(')
-		WriteID(sb, is.Columns[0])
-		for i := 1; i < len(is.Columns); i++ {
-			sb.WriteString(", ")
-			WriteID(sb, is.Columns[i])
-		}
-		sb.WriteString(") ")
-	} else {
-		sb.WriteByte(' ')
-	}
-
-	if !is.IsSetSyntax() {
-		sb.WriteString("VALUES ")
-
-		writeOne := func(flag RestoreFlag, sb *strings.Builder, args *[]int, values []ExpressionNode) error {
-			sb.WriteByte('(')
-
-			if len(values) > 0 {
-				if err := values[0].Restore(flag, sb, args); err != nil {
-					return errors.WithStack(err)
-				}
-				for i := 1; i < len(values); i++ {
-					sb.WriteString(", ")
-					if err := values[i].Restore(flag, sb, args); err != nil {
-						return errors.WithStack(err)
-					}
-				}
-
-			}
-
-			sb.WriteByte(')')
-
-			return nil
-		}
-
-		if err := writeOne(flag, sb, args, is.Values[0]); err != nil {
-			return errors.WithStack(err)
-		}
-
-		for i := 1; i < len(is.Values); i++ {
-			sb.WriteByte(',')
-			if err := writeOne(flag, sb, args, is.Values[i]); err != nil {
-				return errors.WithStack(err)
-			}
-		}
+	if err := is.restoreValues(flag, sb, args, is.Values); err != nil {
+		return errors.WithStack(err)
 	}
 
 	if len(is.DuplicatedUpdates) > 0 {
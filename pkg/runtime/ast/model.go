@@ -174,14 +174,19 @@ func (ih *IndexHint) Restore(flag RestoreFlag, sb *strings.Builder, _ *[]int) er
 }
 
 type OrderByItem struct {
-	Expr ExpressionAtom
-	Desc bool
+	Expr    ExpressionAtom
+	Desc    bool
+	Collate string // eg: ORDER BY name COLLATE utf8mb4_bin
 }
 
 func (o OrderByItem) Restore(flag RestoreFlag, sb *strings.Builder, args *[]int) error {
 	if err := o.Expr.Restore(flag, sb, args); err != nil {
 		return errors.WithStack(err)
 	}
+	if len(o.Collate) > 0 {
+		sb.WriteString(" COLLATE ")
+		sb.WriteString(o.Collate)
+	}
 	if o.Desc {
 		sb.WriteString(" DESC")
 	}
@@ -198,6 +203,13 @@ type GroupByItem struct {
 	flag uint8
 }
 
+// NewGroupByItem creates a GroupByItem without order.
+func NewGroupByItem(expr ExpressionNode) *GroupByItem {
+	return &GroupByItem{
+		expr: expr,
+	}
+}
+
 func (gb *GroupByItem) Restore(flag RestoreFlag, sb *strings.Builder, args *[]int) error {
 	if err := gb.expr.Restore(flag, sb, args); err != nil {
 		return errors.WithStack(err)
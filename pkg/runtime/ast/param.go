@@ -0,0 +1,45 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"github.com/arana-db/parser/ast"
+	"github.com/arana-db/parser/test_driver"
+)
+
+var _ ast.Visitor = (*paramCounter)(nil)
+
+type paramCounter int
+
+func (pc *paramCounter) Enter(n ast.Node) (ast.Node, bool) {
+	if _, ok := n.(*test_driver.ParamMarkerExpr); ok {
+		*pc++
+	}
+	return n, false
+}
+
+func (pc *paramCounter) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// CountParams returns the amount of placeholders in the statement.
+func CountParams(node ast.StmtNode) int {
+	var pc paramCounter
+	node.Accept(&pc)
+	return int(pc)
+}
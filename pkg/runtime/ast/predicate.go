@@ -266,7 +266,8 @@ type InPredicateNode struct {
 	Not bool
 	P   PredicateNode
 	E   []ExpressionNode
-	// TODO: select statement
+	// Sub is the subquery of 'IN (SELECT ...)', E will be empty if it is present.
+	Sub *SelectStatement
 }
 
 func (ip *InPredicateNode) Accept(visitor Visitor) (interface{}, error) {
@@ -286,6 +287,14 @@ func (ip *InPredicateNode) Restore(flag RestoreFlag, sb *strings.Builder, args *
 
 	sb.WriteByte('(')
 
+	if ip.Sub != nil {
+		if err := ip.Sub.Restore(flag, sb, args); err != nil {
+			return errors.WithStack(err)
+		}
+		sb.WriteByte(')')
+		return nil
+	}
+
 	if err := ip.E[0].Restore(flag, sb, args); err != nil {
 		return errors.WithStack(err)
 	}
@@ -315,5 +324,6 @@ func (ip *InPredicateNode) Clone() PredicateNode {
 		Not: ip.Not,
 		P:   ip.P.Clone(),
 		E:   e,
+		Sub: ip.Sub,
 	}
 }
@@ -101,6 +101,9 @@ func (ss *SelectStatement) Restore(flag RestoreFlag, sb *strings.Builder, args *
 				return errors.WithStack(err)
 			}
 		}
+	} else if ss.Where != nil {
+		// the WHERE clause requires a table before MySQL 8.0, eg: SELECT 1 FROM DUAL WHERE 1 = 1
+		sb.WriteString(" FROM DUAL")
 	}
 
 	if ss.Where != nil {
@@ -164,6 +167,43 @@ func (ss *SelectStatement) Restore(flag RestoreFlag, sb *strings.Builder, args *
 	return nil
 }
 
+// Clone returns a copy of the statement which can be rewritten by the optimizer without changing the origin,
+// the expressions are cloned, but the select elements and the sources of tables are shared.
+func (ss *SelectStatement) Clone() *SelectStatement {
+	ret := *ss
+	ret.Select = append(SelectNode(nil), ss.Select...)
+	ret.From = make(FromNode, 0, len(ss.From))
+	for _, it := range ss.From {
+		next := *it
+		next.Joins = append([]*JoinNode(nil), it.Joins...)
+		ret.From = append(ret.From, &next)
+	}
+	if ss.Where != nil {
+		ret.Where = ss.Where.Clone()
+	}
+	if ss.GroupBy != nil {
+		ret.GroupBy = &GroupByNode{
+			RollUp: ss.GroupBy.RollUp,
+			Items:  append([]*GroupByItem(nil), ss.GroupBy.Items...),
+		}
+	}
+	if ss.Having != nil {
+		ret.Having = ss.Having.Clone()
+	}
+	if ss.OrderBy != nil {
+		ret.OrderBy = make(OrderByNode, 0, len(ss.OrderBy))
+		for _, it := range ss.OrderBy {
+			next := *it
+			ret.OrderBy = append(ret.OrderBy, &next)
+		}
+	}
+	if ss.Limit != nil {
+		limit := *ss.Limit
+		ret.Limit = &limit
+	}
+	return &ret
+}
+
 func (ss *SelectStatement) HasJoin() bool {
 	switch len(ss.From) {
 	case 0:
@@ -29,6 +29,10 @@ const (
 	_ UnionType = iota
 	UnionTypeAll
 	UnionTypeDistinct
+	UnionTypeIntersectAll
+	UnionTypeIntersectDistinct
+	UnionTypeExceptAll
+	UnionTypeExceptDistinct
 )
 
 var (
@@ -37,8 +41,12 @@ var (
 )
 
 var _unionTypeNames = [...]string{
-	UnionTypeAll:      "ALL",
-	UnionTypeDistinct: "DISTINCT",
+	UnionTypeAll:               "ALL",
+	UnionTypeDistinct:          "DISTINCT",
+	UnionTypeIntersectAll:      "INTERSECT ALL",
+	UnionTypeIntersectDistinct: "INTERSECT",
+	UnionTypeExceptAll:         "EXCEPT ALL",
+	UnionTypeExceptDistinct:    "EXCEPT",
 }
 
 type UnionType uint8
@@ -47,10 +55,21 @@ func (u UnionType) String() string {
 	return _unionTypeNames[u]
 }
 
+// IsIntersect returns true if the type is INTERSECT or INTERSECT ALL.
+func (u UnionType) IsIntersect() bool {
+	return u == UnionTypeIntersectAll || u == UnionTypeIntersectDistinct
+}
+
+// IsExcept returns true if the type is EXCEPT or EXCEPT ALL.
+func (u UnionType) IsExcept() bool {
+	return u == UnionTypeExceptAll || u == UnionTypeExceptDistinct
+}
+
 type UnionSelectStatement struct {
 	First               *SelectStatement
 	UnionStatementItems []*UnionStatementItem
 	OrderBy             OrderByNode
+	Limit               *LimitNode
 }
 
 func (u *UnionSelectStatement) Accept(visitor Visitor) (interface{}, error) {
@@ -67,6 +86,10 @@ func (u *UnionSelectStatement) Restore(flag RestoreFlag, sb *strings.Builder, ar
 			sb.WriteString(" UNION ")
 		case UnionTypeAll:
 			sb.WriteString(" UNION ALL ")
+		case UnionTypeIntersectAll, UnionTypeIntersectDistinct, UnionTypeExceptAll, UnionTypeExceptDistinct:
+			sb.WriteByte(' ')
+			sb.WriteString(it.Type.String())
+			sb.WriteByte(' ')
 		default:
 			panic("unreachable")
 		}
@@ -82,6 +105,13 @@ func (u *UnionSelectStatement) Restore(flag RestoreFlag, sb *strings.Builder, ar
 		}
 	}
 
+	if u.Limit != nil {
+		sb.WriteString(" LIMIT ")
+		if err := u.Limit.Restore(flag, sb, args); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
 	return nil
 }
 
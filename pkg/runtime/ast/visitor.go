@@ -57,11 +57,13 @@ type Visitor interface {
 	VisitAtomConstant(node *ConstantExpressionAtom) (interface{}, error)
 	VisitAtomFunction(node *FunctionCallExpressionAtom) (interface{}, error)
 	VisitAtomNested(node *NestedExpressionAtom) (interface{}, error)
+	VisitAtomRow(node *RowExpressionAtom) (interface{}, error)
 	VisitAtomUnary(node *UnaryExpressionAtom) (interface{}, error)
 	VisitAtomMath(node *MathExpressionAtom) (interface{}, error)
 	VisitAtomSystemVariable(node *SystemVariableExpressionAtom) (interface{}, error)
 	VisitAtomVariable(node VariableExpressionAtom) (interface{}, error)
 	VisitAtomInterval(node *IntervalExpressionAtom) (interface{}, error)
+	VisitAtomSubquery(node *SubqueryExpressionAtom) (interface{}, error)
 	VisitFunction(node *Function) (interface{}, error)
 	VisitFunctionAggregate(node *AggrFunction) (interface{}, error)
 	VisitFunctionCast(node *CastFunction) (interface{}, error)
@@ -184,6 +186,10 @@ func (b BaseVisitor) VisitAtomNested(node *NestedExpressionAtom) (interface{}, e
 	panic("implement me")
 }
 
+func (b BaseVisitor) VisitAtomRow(node *RowExpressionAtom) (interface{}, error) {
+	panic("implement me")
+}
+
 func (b BaseVisitor) VisitAtomUnary(node *UnaryExpressionAtom) (interface{}, error) {
 	panic("implement me")
 }
@@ -204,6 +210,10 @@ func (b BaseVisitor) VisitAtomInterval(node *IntervalExpressionAtom) (interface{
 	panic("implement me")
 }
 
+func (b BaseVisitor) VisitAtomSubquery(node *SubqueryExpressionAtom) (interface{}, error) {
+	panic("implement me")
+}
+
 func (b BaseVisitor) VisitFunction(node *Function) (interface{}, error) {
 	panic("implement me")
 }
@@ -334,6 +344,10 @@ func (a AlwaysReturnSelfVisitor) VisitAtomNested(node *NestedExpressionAtom) (in
 	return node, nil
 }
 
+func (a AlwaysReturnSelfVisitor) VisitAtomRow(node *RowExpressionAtom) (interface{}, error) {
+	return node, nil
+}
+
 func (a AlwaysReturnSelfVisitor) VisitAtomUnary(node *UnaryExpressionAtom) (interface{}, error) {
 	return node, nil
 }
@@ -354,6 +368,10 @@ func (a AlwaysReturnSelfVisitor) VisitAtomInterval(node *IntervalExpressionAtom)
 	return node, nil
 }
 
+func (a AlwaysReturnSelfVisitor) VisitAtomSubquery(node *SubqueryExpressionAtom) (interface{}, error) {
+	return node, nil
+}
+
 func (a AlwaysReturnSelfVisitor) VisitFunction(node *Function) (interface{}, error) {
 	return node, nil
 }
@@ -373,3 +391,71 @@ func (a AlwaysReturnSelfVisitor) VisitFunctionCaseWhenElse(node *CaseWhenElseFun
 func (a AlwaysReturnSelfVisitor) VisitFunctionArg(node *FunctionArg) (interface{}, error) {
 	return node, nil
 }
+
+// Inspect traverses the expression in depth-first order, fn will be called for each node.
+func Inspect(node Node, fn func(Node)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+
+	switch it := node.(type) {
+	case *LogicalExpressionNode:
+		Inspect(it.Left, fn)
+		Inspect(it.Right, fn)
+	case *NotExpressionNode:
+		Inspect(it.E, fn)
+	case *PredicateExpressionNode:
+		Inspect(it.P, fn)
+	case *BinaryComparisonPredicateNode:
+		Inspect(it.Left, fn)
+		Inspect(it.Right, fn)
+	case *BetweenPredicateNode:
+		Inspect(it.Key, fn)
+		Inspect(it.Left, fn)
+		Inspect(it.Right, fn)
+	case *InPredicateNode:
+		Inspect(it.P, fn)
+		for _, e := range it.E {
+			Inspect(e, fn)
+		}
+	case *LikePredicateNode:
+		Inspect(it.Left, fn)
+		Inspect(it.Right, fn)
+	case *RegexpPredicationNode:
+		Inspect(it.Left, fn)
+		Inspect(it.Right, fn)
+	case *AtomPredicateNode:
+		Inspect(it.A, fn)
+	case *NestedExpressionAtom:
+		Inspect(it.First, fn)
+	case *MathExpressionAtom:
+		Inspect(it.Left, fn)
+		Inspect(it.Right, fn)
+	case *UnaryExpressionAtom:
+		Inspect(it.Inner, fn)
+	case *SystemVariableExpressionAtom:
+		if it.Value != nil {
+			Inspect(it.Value, fn)
+		}
+	case *FunctionCallExpressionAtom:
+		Inspect(it.F, fn)
+	case *Function:
+		for _, arg := range it.Args() {
+			Inspect(arg, fn)
+		}
+	case *CaseWhenElseFunction:
+		Inspect(it.CaseBlock, fn)
+		for _, b := range it.BranchBlocks {
+			Inspect(b.When, fn)
+			Inspect(b.Then, fn)
+		}
+		if it.ElseBlock != nil {
+			Inspect(it.ElseBlock, fn)
+		}
+	case *FunctionArg:
+		if n, ok := it.Value.(Node); ok {
+			Inspect(n, fn)
+		}
+	}
+}
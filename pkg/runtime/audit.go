@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"time"
+)
+
+import (
+	"github.com/arana-db/parser/ast"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/audit"
+	"github.com/arana-db/arana/pkg/proto"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+)
+
+// auditStatement submits the audit record of the statement, the physical statements are collected by the exec stats.
+func auditStatement(ctx *proto.Context, start time.Time, err error) {
+	timings := rcontext.ShardTimings(ctx)
+	shards := make([]audit.Shard, 0, len(timings))
+	for _, it := range timings {
+		shards = append(shards, audit.Shard{DB: it.DB, SQL: it.SQL})
+	}
+
+	r := &audit.Record{
+		Time:    start,
+		SQL:     ctx.GetQuery(),
+		Write:   isWriteStmt(ctx.Stmt),
+		TxID:    rcontext.TransactionID(ctx),
+		Shards:  shards,
+		Elapsed: time.Since(start),
+	}
+	if ctx.C != nil {
+		r.ConnID = ctx.C.ID()
+		r.Tenant = ctx.C.Tenant()
+		r.Username = ctx.C.Username()
+		r.Schema = ctx.C.Schema()
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	audit.Submit(r)
+}
+
+func isWriteStmt(stmt *proto.Stmt) bool {
+	if stmt == nil {
+		return false
+	}
+	switch it := stmt.StmtNode.(type) {
+	case *ast.SelectStmt:
+		// the locking reads are audited as writes
+		return it.LockInfo != nil && it.LockInfo.LockType != ast.SelectLockNone
+	case *ast.SetOprStmt, *ast.ShowStmt, *ast.ExplainStmt:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/metrics"
+	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
+	"github.com/arana-db/arana/third_party/pools"
+)
+
+// defaultBreakerOpenTime is the default time of circuit breaker keeping open before probing the backend.
+const defaultBreakerOpenTime = 10 * time.Second
+
+// ErrCircuitOpen is returned if the circuit breaker of db instance is open, the requests fail fast without touching the backend.
+var ErrCircuitOpen = errors.New("the circuit breaker is open")
+
+// IsErrCircuitOpen returns true if the error is caused by an open circuit breaker.
+func IsErrCircuitOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+type breakerState int32
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker protects a backend from being requested when it keeps failing.
+//
+// The breaker is opened after threshold consecutive backend failures, eg: broken connection, timeout or exhausted pool,
+// the requests fail fast with ErrCircuitOpen then. After the open time elapsed, a single probe request is allowed
+// in the half-open state, the breaker will be closed if it succeeds, otherwise it will be opened again.
+// The errors reported by MySQL, eg: syntax error or duplicate key, mean the backend is alive, which are not failures.
+type circuitBreaker struct {
+	id        string
+	threshold int
+	openTime  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// newCircuitBreaker creates a circuitBreaker of db instance, nil will be returned if the threshold is not positive.
+func newCircuitBreaker(id string, threshold int, openTime time.Duration) *circuitBreaker {
+	if threshold < 1 {
+		return nil
+	}
+	if openTime <= 0 {
+		openTime = defaultBreakerOpenTime
+	}
+	cb := &circuitBreaker{
+		id:        id,
+		threshold: threshold,
+		openTime:  openTime,
+		now:       time.Now,
+	}
+	cb.observe()
+	return cb
+}
+
+// State returns the current state.
+func (cb *circuitBreaker) State() breakerState {
+	if cb == nil {
+		return breakerClosed
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// allow returns ErrCircuitOpen if the request should fail fast.
+func (cb *circuitBreaker) allow() error {
+	if cb == nil {
+		return nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.openTime {
+			break
+		}
+		// the open time elapsed, let the current request probe the backend
+		cb.state = breakerHalfOpen
+		cb.probing = true
+		cb.observe()
+		return nil
+	case breakerHalfOpen:
+		if cb.probing {
+			break
+		}
+		cb.probing = true
+		return nil
+	default:
+		return nil
+	}
+
+	metrics.BackendCircuitRejected.WithLabelValues(cb.id).Inc()
+	return perrors.Wrapf(ErrCircuitOpen, "db instance '%s' is unavailable", cb.id)
+}
+
+// done records the result of an allowed request.
+func (cb *circuitBreaker) done(err error) {
+	if cb == nil {
+		return
+	}
+
+	failed := isBackendFailure(err)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerClosed:
+		if !failed {
+			cb.failures = 0
+			return
+		}
+		if cb.failures++; cb.failures >= cb.threshold {
+			cb.open()
+		}
+	case breakerHalfOpen:
+		cb.probing = false
+		if failed {
+			cb.open()
+			return
+		}
+		cb.state = breakerClosed
+		cb.failures = 0
+		cb.observe()
+	}
+}
+
+func (cb *circuitBreaker) open() {
+	cb.state = breakerOpen
+	cb.openedAt = cb.now()
+	cb.failures = 0
+	cb.observe()
+}
+
+func (cb *circuitBreaker) observe() {
+	metrics.BackendCircuitState.WithLabelValues(cb.id).Set(float64(cb.state))
+}
+
+// isBackendFailure returns true if the error means the backend is unavailable or too slow.
+func isBackendFailure(err error) bool {
+	if err == nil || errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if sqlErr, ok := perrors.Cause(err).(*mysqlErrors.SQLError); ok {
+		switch sqlErr.Number() {
+		case mysql.CRServerGone, mysql.CRServerLost, mysql.CRConnHostError:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, pools.ErrTimeout) ||
+		errors.Is(err, pools.ErrCtxTimeout) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
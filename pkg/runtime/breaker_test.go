@@ -0,0 +1,128 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/constants/mysql"
+	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
+	"github.com/arana-db/arana/third_party/pools"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	cb := newCircuitBreaker("fake_node", 3, time.Second)
+	cb.now = func() time.Time {
+		return now
+	}
+
+	failure := perrors.WithStack(syscall.ECONNREFUSED)
+
+	// the errors of mysql server and the successes reset the failures
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, cb.allow())
+		cb.done(failure)
+	}
+	assert.NoError(t, cb.allow())
+	cb.done(mysqlErrors.NewSQLError(mysql.ERDupEntry, mysql.SSDupKey, "Duplicate entry"))
+	assert.Equal(t, breakerClosed, cb.State())
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, cb.allow())
+		cb.done(failure)
+	}
+	assert.Equal(t, breakerOpen, cb.State())
+	assert.True(t, IsErrCircuitOpen(cb.allow()))
+
+	// only one probe is allowed after the open time
+	now = now.Add(time.Second)
+	assert.NoError(t, cb.allow())
+	assert.Equal(t, breakerHalfOpen, cb.State())
+	assert.True(t, IsErrCircuitOpen(cb.allow()))
+
+	// the probe fails, open again
+	cb.done(context.DeadlineExceeded)
+	assert.Equal(t, breakerOpen, cb.State())
+	assert.True(t, IsErrCircuitOpen(cb.allow()))
+
+	// the probe succeeds, closed
+	now = now.Add(time.Second)
+	assert.NoError(t, cb.allow())
+	cb.done(nil)
+	assert.Equal(t, breakerClosed, cb.State())
+	assert.NoError(t, cb.allow())
+	cb.done(nil)
+
+	// disabled
+	cb = newCircuitBreaker("fake_node", 0, time.Second)
+	assert.Nil(t, cb)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, cb.allow())
+		cb.done(failure)
+	}
+	assert.Equal(t, breakerClosed, cb.State())
+}
+
+func TestIsBackendFailure(t *testing.T) {
+	assert.False(t, isBackendFailure(nil))
+	assert.False(t, isBackendFailure(context.Canceled))
+	assert.False(t, isBackendFailure(perrors.WithStack(ErrCircuitOpen)))
+	assert.False(t, isBackendFailure(errors.New("bad sql")))
+	assert.False(t, isBackendFailure(mysqlErrors.NewSQLError(mysql.ERLockDeadlock, mysql.SSLockDeadlock, "Deadlock found")))
+	assert.True(t, isBackendFailure(mysqlErrors.NewSQLError(mysql.CRServerGone, mysql.SSUnknownSQLState, "MySQL server has gone away")))
+	assert.True(t, isBackendFailure(perrors.WithStack(pools.ErrTimeout)))
+	assert.True(t, isBackendFailure(perrors.WithStack(syscall.ECONNRESET)))
+	assert.True(t, isBackendFailure(context.DeadlineExceeded))
+}
+
+func TestAtomDB_CircuitBreaker(t *testing.T) {
+	var dials int
+	db := &AtomDB{
+		id:      "fake_node",
+		breaker: newCircuitBreaker("fake_node", 2, time.Hour),
+	}
+	db.pool = pools.NewResourcePool(func(ctx context.Context) (pools.Resource, error) {
+		dials++
+		return nil, syscall.ECONNREFUSED
+	}, 1, 1, 0, 0, nil)
+	defer db.pool.Close()
+
+	for i := 0; i < 2; i++ {
+		_, _, err := db.Call(context.Background(), "select 1")
+		assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+	}
+	assert.Equal(t, 2, dials)
+
+	// fail fast without touching the backend
+	_, _, err := db.Call(context.Background(), "select 1")
+	assert.True(t, IsErrCircuitOpen(err))
+	assert.Equal(t, 2, dials)
+}
@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package builtin
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+)
+
+var _ rule.RangeShardComputer = (*rangeShardComputer)(nil)
+
+func init() {
+	rule.RegisterShardComputer("range", rule.FuncShardComputerFactory(func(columns []string, expr string) (rule.ShardComputer, error) {
+		if len(columns) != 1 {
+			return nil, errors.Errorf("range shard computer requires exactly one column, actual=%d", len(columns))
+		}
+		return NewRangeShardComputer(expr, columns[0])
+	}))
+}
+
+// shardRange is an inclusive range of keys which belongs to the shard.
+type shardRange struct {
+	lower, upper int64
+	shard        int
+}
+
+// rangeShardComputer maps the explicit key ranges to shards, eg: '1..1000=0, 1001..2000=1, *=2',
+// the keys which are not contained by any range belong to the catch-all shard '*'.
+type rangeShardComputer struct {
+	expr     string
+	variable string
+	ranges   []shardRange // sorted by lower bound, never overlapped
+	fallback int          // the catch-all shard, -1 means none
+}
+
+// NewRangeShardComputer returns a shard computer which looks up the shard by range containment, the expression is
+// a comma-separated list of 'lower..upper=shard', both bounds are inclusive, and an omitted bound means unbounded,
+// eg: '..1000=0, 1001..2000=1, 2001..=2'. The catch-all shard can be declared as '*=shard'.
+func NewRangeShardComputer(expr string, column string) (rule.ShardComputer, error) {
+	ret := &rangeShardComputer{
+		expr:     expr,
+		variable: column,
+		fallback: -1,
+	}
+
+	for _, it := range strings.Split(expr, ",") {
+		it = strings.TrimSpace(it)
+		if len(it) == 0 {
+			continue
+		}
+
+		i := strings.LastIndexByte(it, '=')
+		if i == -1 {
+			return nil, errors.Errorf("invalid range '%s': missing shard", it)
+		}
+		shard, err := strconv.Atoi(strings.TrimSpace(it[i+1:]))
+		if err != nil || shard < 0 {
+			return nil, errors.Errorf("invalid range '%s': bad shard", it)
+		}
+
+		bounds := strings.TrimSpace(it[:i])
+		if bounds == "*" {
+			if ret.fallback != -1 {
+				return nil, errors.Errorf("invalid range '%s': duplicated catch-all shard", it)
+			}
+			ret.fallback = shard
+			continue
+		}
+
+		lower, upper, err := parseRangeBounds(bounds)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid range '%s'", it)
+		}
+		ret.ranges = append(ret.ranges, shardRange{lower: lower, upper: upper, shard: shard})
+	}
+
+	if len(ret.ranges) == 0 && ret.fallback == -1 {
+		return nil, errors.Errorf("no range is declared: %s", expr)
+	}
+
+	sort.Slice(ret.ranges, func(i, j int) bool {
+		return ret.ranges[i].lower < ret.ranges[j].lower
+	})
+	for i := 1; i < len(ret.ranges); i++ {
+		prev, next := ret.ranges[i-1], ret.ranges[i]
+		if next.lower <= prev.upper {
+			return nil, errors.Errorf("overlapping ranges: [%d,%d] and [%d,%d]", prev.lower, prev.upper, next.lower, next.upper)
+		}
+	}
+
+	return ret, nil
+}
+
+func parseRangeBounds(s string) (lower, upper int64, err error) {
+	i := strings.Index(s, "..")
+	if i == -1 {
+		// single key, eg: 42=1
+		if lower, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return 0, 0, errors.Errorf("bad key '%s'", s)
+		}
+		return lower, lower, nil
+	}
+
+	lower, upper = math.MinInt64, math.MaxInt64
+	if l := strings.TrimSpace(s[:i]); len(l) > 0 {
+		if lower, err = strconv.ParseInt(l, 10, 64); err != nil {
+			return 0, 0, errors.Errorf("bad lower bound '%s'", l)
+		}
+	}
+	if u := strings.TrimSpace(s[i+2:]); len(u) > 0 {
+		if upper, err = strconv.ParseInt(u, 10, 64); err != nil {
+			return 0, 0, errors.Errorf("bad upper bound '%s'", u)
+		}
+	}
+	if lower > upper {
+		return 0, 0, errors.Errorf("the lower bound %d is greater than the upper bound %d", lower, upper)
+	}
+	return lower, upper, nil
+}
+
+func (r *rangeShardComputer) String() string {
+	return r.expr
+}
+
+func (r *rangeShardComputer) Variables() []string {
+	return []string{r.variable}
+}
+
+func (r *rangeShardComputer) Compute(values ...proto.Value) (int, error) {
+	if len(values) != 1 {
+		return 0, errors.Errorf("the length of params doesn't match: expect=1, actual=%d", len(values))
+	}
+	if values[0] == nil {
+		return 0, errors.Errorf("cannot compute the shard of NULL key")
+	}
+	key, err := values[0].Int64()
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot compute the shard of key '%s'", values[0])
+	}
+
+	i := sort.Search(len(r.ranges), func(i int) bool {
+		return r.ranges[i].upper >= key
+	})
+	if i < len(r.ranges) && r.ranges[i].lower <= key {
+		return r.ranges[i].shard, nil
+	}
+	if r.fallback != -1 {
+		return r.fallback, nil
+	}
+	return 0, errors.Errorf("no range contains the key %d", key)
+}
+
+func (r *rangeShardComputer) ComputeRange(begin, end proto.Value, beginInclude, endInclude bool) ([]int, error) {
+	lower, upper := int64(math.MinInt64), int64(math.MaxInt64)
+	if begin != nil {
+		v, err := begin.Int64()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot compute the shards from key '%s'", begin)
+		}
+		if !beginInclude {
+			if v == math.MaxInt64 {
+				return nil, nil
+			}
+			v++
+		}
+		lower = v
+	}
+	if end != nil {
+		v, err := end.Int64()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot compute the shards to key '%s'", end)
+		}
+		if !endInclude {
+			if v == math.MinInt64 {
+				return nil, nil
+			}
+			v--
+		}
+		upper = v
+	}
+	if lower > upper {
+		return nil, nil
+	}
+
+	var (
+		ret     []int
+		visited = make(map[int]struct{})
+		gap     bool
+		cursor  = lower // the first key which is not covered yet
+		covered bool    // all keys until upper are covered
+	)
+	add := func(shard int) {
+		if _, ok := visited[shard]; !ok {
+			visited[shard] = struct{}{}
+			ret = append(ret, shard)
+		}
+	}
+
+	for _, it := range r.ranges {
+		if it.upper < lower {
+			continue
+		}
+		if it.lower > upper {
+			break
+		}
+		if it.lower > cursor {
+			gap = true
+		}
+		add(it.shard)
+		if it.upper >= upper {
+			covered = true
+			break
+		}
+		cursor = it.upper + 1
+	}
+
+	if (gap || !covered) && r.fallback != -1 {
+		add(r.fallback)
+	}
+
+	sort.Ints(ret)
+	return ret, nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package builtin
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+)
+
+func TestRangeShardComputer(t *testing.T) {
+	c, err := NewRangeShardComputer("1..1000=0, 1001..2000=1, 3001..=2, *=3", "uid")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"uid"}, c.Variables())
+
+	for _, it := range []struct {
+		key   int64
+		shard int
+	}{
+		{1, 0},
+		{1000, 0},
+		{1001, 1},
+		{2000, 1},
+		{2500, 3},
+		{3001, 2},
+		{1 << 40, 2},
+		{-1, 3},
+	} {
+		shard, err := c.Compute(proto.NewValueInt64(it.key))
+		assert.NoError(t, err)
+		assert.Equal(t, it.shard, shard, "key=%d", it.key)
+	}
+
+	// the string key is converted to integer
+	shard, err := c.Compute(proto.NewValueString("1500"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, shard)
+
+	rc := c.(rule.RangeShardComputer)
+	for _, it := range []struct {
+		begin, end               proto.Value
+		beginInclude, endInclude bool
+		shards                   []int
+	}{
+		{proto.NewValueInt64(1), proto.NewValueInt64(1000), true, true, []int{0}},
+		{proto.NewValueInt64(1), proto.NewValueInt64(1001), true, false, []int{0}},
+		{proto.NewValueInt64(1000), proto.NewValueInt64(1001), false, true, []int{1}},
+		{proto.NewValueInt64(500), proto.NewValueInt64(1500), true, true, []int{0, 1}},
+		{proto.NewValueInt64(1500), proto.NewValueInt64(2500), true, true, []int{1, 3}},
+		{proto.NewValueInt64(3500), nil, true, false, []int{2}},
+		{nil, proto.NewValueInt64(10), false, true, []int{0, 3}},
+		{proto.NewValueInt64(10), proto.NewValueInt64(1), true, true, nil},
+	} {
+		shards, err := rc.ComputeRange(it.begin, it.end, it.beginInclude, it.endInclude)
+		assert.NoError(t, err)
+		assert.Equal(t, it.shards, shards, "range=%v..%v", it.begin, it.end)
+	}
+}
+
+func TestRangeShardComputer_NoFallback(t *testing.T) {
+	c, err := NewRangeShardComputer("1..1000=0,1001..2000=1", "uid")
+	assert.NoError(t, err)
+
+	_, err = c.Compute(proto.NewValueInt64(2001))
+	assert.Error(t, err)
+
+	shards, err := c.(rule.RangeShardComputer).ComputeRange(proto.NewValueInt64(1500), nil, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, shards)
+}
+
+func TestRangeShardComputer_BadExpr(t *testing.T) {
+	for _, it := range []string{
+		"",
+		"1..1000",
+		"1..1000=x",
+		"1000..1=0",
+		"a..b=0",
+		"1..1000=0, 1000..2000=1",
+		"..10=0, 5..=1",
+		"*=0, *=1",
+	} {
+		_, err := NewRangeShardComputer(it, "uid")
+		assert.Error(t, err, it)
+	}
+
+	_, err := rule.NewComputer("range", []string{"uid", "sid"}, "1..10=0")
+	assert.Error(t, err)
+}
@@ -99,12 +99,16 @@ func (co *calculusOperator) AND(first *Calculus, others ...*Calculus) (*Calculus
 		}
 
 		switch comparison {
-		case cmp.Ceq:
-		case cmp.Cne:
+		case cmp.Ceq, cmp.Cne:
 			groups[key][comparison] = append(groups[key][comparison], c)
-		case cmp.Cgt, cmp.Cgte: // minimum is the first value.
-		case cmp.Clt, cmp.Clte: // maximum is the last value.
-			groups[key][comparison][0] = c
+		case cmp.Cgt, cmp.Cgte: // keep the maximum as lower bound, eg: a > 1 && a > 3 ---> a > 3
+			if compareComparativeValue(c.c, groups[key][comparison][0].c) > 0 {
+				groups[key][comparison][0] = c
+			}
+		case cmp.Clt, cmp.Clte: // keep the minimum as upper bound, eg: a < 3 && a < 5 ---> a < 3
+			if compareComparativeValue(c.c, groups[key][comparison][0].c) < 0 {
+				groups[key][comparison][0] = c
+			}
 		}
 	}
 	add(first)
@@ -114,44 +118,73 @@ func (co *calculusOperator) AND(first *Calculus, others ...*Calculus) (*Calculus
 
 	vShard := searchVShard((*rule.VTable)(co), groups)
 
+	// only a part of the composite sharding key is provided, eg: WHERE a = 5, the shards can still be narrowed
+	// if the DB or the table is computed by the provided columns only, see searchPrefixVShard
 	if vShard == nil {
-		return nil, ErrNoShardMatched
+		if vShard = searchPrefixVShard((*rule.VTable)(co), groups); vShard == nil {
+			return nil, ErrNoShardMatched
+		}
 	}
 
+	var (
+		dbFree  = vShard.DB != nil && !isCovered(vShard.DB, groups)
+		tblFree = vShard.Table != nil && !isCovered(vShard.Table, groups)
+	)
+
 	type valuePair struct {
-		db, tbl []interface{}
+		db, tbl    []interface{}
+		begin, end *cmp.Comparative // the bounds of range, which are used by RangeShardComputer
 	}
 
 	values := make(map[string]valuePair)
 	for i := range vShard.Variables() {
 		name := vShard.Variables()[i]
+		if _, ok := groups[name]; !ok {
+			// the missing column of composite sharding key, the shards computed by it are not pruned
+			continue
+		}
 		cm := calculusMap(groups[name])
 		begin, end := cm.getRange()
+		var (
+			inDB  = usesVariable(vShard.DB, name)
+			inTbl = usesVariable(vShard.Table, name)
+		)
 		switch {
 		case begin != nil && end != nil:
-			var vp valuePair
-			if vShard.DB != nil {
+			// a > 50 && a < 10 ---> NaN
+			if isEmptyRange(begin, end) {
+				return Zero, nil
+			}
+			vp := valuePair{begin: begin.c, end: end.c}
+			if inDB {
 				vp.db = computeRange(vShard.DB, begin.c, end.c)
 			}
-			if vShard.Table != nil {
+			if inTbl {
 				vp.tbl = computeRange(vShard.Table, begin.c, end.c)
 			}
 			values[name] = vp
 		case begin != nil && end == nil:
-			var vp valuePair
-			if vShard.DB != nil {
+			// a > 1 ---> the stepped values cannot cover an unbounded range, eg: uid % 8, full scan
+			if begin.c.Comparison() != cmp.Ceq && !rangeComputable(vShard, name) {
+				return nil, nil
+			}
+			vp := valuePair{begin: begin.c}
+			if inDB {
 				vp.db = computeLRange(vShard.DB, begin.c)
 			}
-			if vShard.Table != nil {
+			if inTbl {
 				vp.tbl = computeLRange(vShard.Table, begin.c)
 			}
 			values[name] = vp
 		case begin == nil && end != nil:
-			var vp valuePair
-			if vShard.DB != nil {
+			if end.c.Comparison() != cmp.Ceq && !rangeComputable(vShard, name) {
+				return nil, nil
+			}
+			vp := valuePair{end: end.c}
+			if inDB {
 				vp.db = computeRRange(vShard.DB, end.c)
 			}
-			if vShard.Table != nil {
+			if inTbl {
 				vp.tbl = computeRRange(vShard.Table, end.c)
 			}
 			values[name] = vp
@@ -159,6 +192,15 @@ func (co *calculusOperator) AND(first *Calculus, others ...*Calculus) (*Calculus
 			if cm.has(cmp.Ceq) {
 				return Zero, nil
 			}
+			// a <> 1 && a <> 2 ---> all shards except shard(1) and shard(2)
+			if neList, ok := co.excludable(vShard, cm); ok {
+				shards := rule.NewShards()
+				(*rule.VTable)(co).Topology().Each(func(x, y int) bool {
+					shards.Add(uint32(x), uint32(y))
+					return true
+				})
+				return co.excludeShards(vShard, neList, shards)
+			}
 			return nil, nil
 		}
 	}
@@ -183,7 +225,53 @@ func (co *calculusOperator) AND(first *Calculus, others ...*Calculus) (*Calculus
 		return nil
 	}
 
+	// the shards of range-mapping cannot be enumerated by stepping the values, they are computed by the bounds directly
+	computeByRange := func(computer rule.ShardComputer, dst *[]int) (bool, error) {
+		rc, ok := computer.(rule.RangeShardComputer)
+		if !ok || len(computer.Variables()) != 1 {
+			return false, nil
+		}
+		vp := values[computer.Variables()[0]]
+		if vp.begin == nil && vp.end == nil {
+			return false, nil
+		}
+
+		var (
+			begin, end               proto.Value
+			beginInclude, endInclude bool
+			err                      error
+		)
+		if c := vp.begin; c != nil {
+			if c.Comparison() == cmp.Ceq {
+				return false, nil
+			}
+			if begin, err = proto.NewValue(c.MustValue()); err != nil {
+				return true, err
+			}
+			beginInclude = c.Comparison() == cmp.Cgte
+		}
+		if c := vp.end; c != nil {
+			if c.Comparison() == cmp.Ceq {
+				return false, nil
+			}
+			if end, err = proto.NewValue(c.MustValue()); err != nil {
+				return true, err
+			}
+			endInclude = c.Comparison() == cmp.Clte
+		}
+
+		indexes, err := rc.ComputeRange(begin, end, beginInclude, endInclude)
+		if err != nil {
+			return true, err
+		}
+		*dst = append(*dst, indexes...)
+		return true, nil
+	}
+
 	computeDB := func(computer rule.ShardComputer, dst *[]int) error {
+		if ok, err := computeByRange(computer, dst); ok {
+			return err
+		}
 		var vals [][]interface{}
 		for _, name := range computer.Variables() {
 			vals = append(vals, values[name].db)
@@ -192,6 +280,9 @@ func (co *calculusOperator) AND(first *Calculus, others ...*Calculus) (*Calculus
 	}
 
 	computeTable := func(computer rule.ShardComputer, dst *[]int) error {
+		if ok, err := computeByRange(computer, dst); ok {
+			return err
+		}
 		var vals [][]interface{}
 		for _, name := range computer.Variables() {
 			vals = append(vals, values[name].tbl)
@@ -205,16 +296,22 @@ func (co *calculusOperator) AND(first *Calculus, others ...*Calculus) (*Calculus
 	)
 
 	g.Go(func() error {
-		if vShard.DB == nil {
+		switch {
+		case vShard.DB == nil:
 			dbIndexes = append(dbIndexes, 0)
 			return nil
+		case dbFree:
+			return nil
 		}
 		return computeDB(vShard.DB.Computer, &dbIndexes)
 	})
 	g.Go(func() error {
-		if vShard.Table == nil {
+		switch {
+		case vShard.Table == nil:
 			tblIndexes = append(tblIndexes, 0)
 			return nil
+		case tblFree:
+			return nil
 		}
 		return computeTable(vShard.Table.Computer, &tblIndexes)
 	})
@@ -224,16 +321,124 @@ func (co *calculusOperator) AND(first *Calculus, others ...*Calculus) (*Calculus
 	}
 
 	shards := rule.NewShards()
-	cp := misc.CartesianProduct[int]([][]int{dbIndexes, tblIndexes})
 	topology := (*rule.VTable)(co).Topology()
-	for i := range cp {
-		x := cp[i][0]
-		y := cp[i][1]
-		if topology.Exists(x, y) {
-			shards.Add(uint32(x), uint32(y))
+	if dbFree || tblFree {
+		// the uncomputable dimension matches any index, eg: all tables of the DB computed by 'a'
+		contains := func(free bool, indexes []int, i int) bool {
+			if free {
+				return true
+			}
+			for _, it := range indexes {
+				if it == i {
+					return true
+				}
+			}
+			return false
+		}
+		topology.Each(func(x, y int) bool {
+			if contains(dbFree, dbIndexes, x) && contains(tblFree, tblIndexes, y) {
+				shards.Add(uint32(x), uint32(y))
+			}
+			return true
+		})
+	} else {
+		cp := misc.CartesianProduct[int]([][]int{dbIndexes, tblIndexes})
+		for i := range cp {
+			x := cp[i][0]
+			y := cp[i][1]
+			if topology.Exists(x, y) {
+				shards.Add(uint32(x), uint32(y))
+			}
 		}
 	}
 
+	// a > 1 && a <> 3 ---> shards of a > 1 except shard(3)
+	if neList, ok := co.excludable(vShard, groups[vShard.Variables()[0]]); ok {
+		return co.excludeShards(vShard, neList, shards)
+	}
+
+	if shards.Len() < 1 {
+		return Zero, nil
+	}
+
+	return &Calculus{
+		s: shards,
+	}, nil
+}
+
+// rangeComputable returns true if the shards of an unbounded range of the variable can be computed, which means
+// each computer of the virtual shard using the variable is a rule.RangeShardComputer with single variable, eg: range-mapping.
+//
+// The other computers only map a single value to a shard, an unbounded range cannot be enumerated by stepping values,
+// and the topology tells nothing about which keys a shard holds, so the range cannot be clamped to it either.
+// The range will be scanned fully, which also applies to the one-sided relative time conditions,
+// eg: 'created_at >= NOW() - INTERVAL 7 DAY' must be bounded by 'created_at <= NOW()' to prune the shards.
+func rangeComputable(vShard *rule.VShard, name string) bool {
+	for _, m := range []*rule.ShardMetadata{vShard.DB, vShard.Table} {
+		if !usesVariable(m, name) {
+			continue
+		}
+		if _, ok := m.Computer.(rule.RangeShardComputer); !ok || len(m.Computer.Variables()) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// usesVariable returns true if the shards of metadata are computed by the variable.
+func usesVariable(m *rule.ShardMetadata, name string) bool {
+	if m == nil {
+		return false
+	}
+	for _, it := range m.Computer.Variables() {
+		if it == name {
+			return true
+		}
+	}
+	return false
+}
+
+// excludable returns the inequalities whose shards can be excluded.
+//
+// Excluding the shard of 'a <> 1' is sound only if no other value of the sharding key can be routed to that shard,
+// otherwise the rows of other values in the same shard would be missed, eg: 'uid <> 1' cannot exclude the shard
+// of 'uid % 8 = 1', because it also contains uid=9, uid=17, etc. Most sharding schemes cannot guarantee it since
+// the keys are unbounded, so the shards will be excluded only when:
+//  1. the VTable declares each physical table is dedicated to a single key value, see rule.VTable.SingleKeyShards
+//  2. the virtual shard is computed from exactly one sharding key
+func (co *calculusOperator) excludable(vShard *rule.VShard, cm calculusMap) ([]*Calculus, bool) {
+	if !(*rule.VTable)(co).SingleKeyShards() || len(vShard.Variables()) != 1 {
+		return nil, false
+	}
+	neList, ok := cm[cmp.Cne]
+	return neList, ok
+}
+
+// excludeShards removes the shards of inequalities from the given shards.
+func (co *calculusOperator) excludeShards(vShard *rule.VShard, neList []*Calculus, shards *rule.Shards) (*Calculus, error) {
+	computeIndex := func(sm *rule.ShardMetadata, v proto.Value) (int, error) {
+		if sm == nil {
+			return 0, nil
+		}
+		return sm.Computer.Compute(v)
+	}
+
+	for _, ne := range neList {
+		v, err := proto.NewValue(ne.c.MustValue())
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		db, err := computeIndex(vShard.DB, v)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		tb, err := computeIndex(vShard.Table, v)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		shards.Remove(uint32(db), uint32(tb))
+	}
+
 	if shards.Len() < 1 {
 		return Zero, nil
 	}
@@ -252,7 +457,7 @@ func (co calculusOperator) OR(first *Calculus, others ...*Calculus) (*Calculus,
 
 	if first.c != nil {
 		var err error
-		if first, err = co.AND(first); err != nil {
+		if first, err = co.AND(first); err != nil || first == nil {
 			return nil, err
 		}
 	}
@@ -270,7 +475,7 @@ func (co calculusOperator) OR(first *Calculus, others ...*Calculus) (*Calculus,
 		}
 		if next.c != nil {
 			var err error
-			if next, err = co.AND(next); err != nil {
+			if next, err = co.AND(next); err != nil || next == nil {
 				return nil, err
 			}
 		}
@@ -328,6 +533,13 @@ func (cm calculusMap) has(c cmp.Comparison) bool {
 
 func (cm calculusMap) getRange() (begin, end *Calculus) {
 	if eq, hasEq := cm[cmp.Ceq]; hasEq {
+		// a == 1 && a == 2 ---> NaN
+		for i := 1; i < len(eq); i++ {
+			if compareComparativeValue(eq[0].c, eq[i].c) != 0 {
+				return
+			}
+		}
+
 		if neList, hasNe := cm[cmp.Cne]; hasNe {
 			for _, ne := range neList {
 				if ne.c == nil {
@@ -420,6 +632,19 @@ func (cm calculusMap) getRange() (begin, end *Calculus) {
 	return
 }
 
+// isEmptyRange returns true if no value can be inside the range between begin and end.
+func isEmptyRange(begin, end *Calculus) bool {
+	switch compareComparativeValue(begin.c, end.c) {
+	case 1:
+		// a >= 3 && a <= 1
+		return true
+	case 0:
+		// a > 1 && a <= 1, a >= 1 && a < 1
+		return begin.c.Comparison() == cmp.Cgt || end.c.Comparison() == cmp.Clt
+	}
+	return false
+}
+
 func Eval(vtab *rule.VTable, l logic.Logic[*Calculus]) (*rule.Shards, error) {
 	sh, err := innerEval(vtab, l)
 	if err == nil {
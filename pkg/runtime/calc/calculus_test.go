@@ -92,6 +92,41 @@ func getMultipleKeysVTab() *rule.VTable {
 	return &vtab
 }
 
+func getPrefixKeysVTab() *rule.VTable {
+	var vtab rule.VTable
+	var topology rule.Topology
+	topology.SetTopology(0, 0, 1, 2, 3)
+	topology.SetTopology(1, 4, 5, 6, 7)
+	topology.SetTopology(2, 8, 9, 10, 11)
+	topology.SetTopology(3, 12, 13, 14, 15)
+	vtab.SetTopology(&topology)
+
+	newColumn := func(name string) *rule.ShardColumn {
+		return &rule.ShardColumn{
+			Name:  name,
+			Steps: 4,
+			Stepper: rule.Stepper{
+				N: 1,
+				U: rule.Unum,
+			},
+		}
+	}
+
+	// the DB is sharded by the leading column uid, and the table by both uid and sid
+	var vs rule.VShard
+	vs.DB = &rule.ShardMetadata{
+		ShardColumns: []*rule.ShardColumn{newColumn("uid")},
+		Computer:     rrule.MustNewJavascriptShardComputer("parseInt($0 % 4)", "uid"),
+	}
+	vs.Table = &rule.ShardMetadata{
+		ShardColumns: []*rule.ShardColumn{newColumn("uid"), newColumn("sid")},
+		Computer:     rrule.MustNewJavascriptShardComputer("parseInt(($0 % 4) * 4 + $1 % 4)", "uid", "sid"),
+	}
+	vtab.AddVShards(&vs)
+
+	return &vtab
+}
+
 func getSingleKeyVTab() *rule.VTable {
 	var vtab rule.VTable
 	var topology rule.Topology
@@ -201,6 +236,69 @@ func TestMultipleCalculus(t *testing.T) {
 	}
 }
 
+func TestPrefixCalculus(t *testing.T) {
+	type tt struct {
+		scene string
+		input logic.Logic[*Calculus]
+		want  [][2]uint32
+	}
+
+	for _, next := range []tt{
+		{
+			"uid = 5",
+			Wrap(cmp.NewInt64("uid", cmp.Ceq, 5)),
+			[][2]uint32{{1, 4}, {1, 5}, {1, 6}, {1, 7}},
+		},
+		{
+			"uid = 5 and sid = 2",
+			logic.AND(
+				Wrap(cmp.NewInt64("uid", cmp.Ceq, 5)),
+				Wrap(cmp.NewInt64("sid", cmp.Ceq, 2)),
+			),
+			[][2]uint32{{1, 6}},
+		},
+		{
+			"uid = 1 or uid = 6",
+			logic.OR(
+				Wrap(cmp.NewInt64("uid", cmp.Ceq, 1)),
+				Wrap(cmp.NewInt64("uid", cmp.Ceq, 6)),
+			),
+			[][2]uint32{{1, 4}, {1, 5}, {1, 6}, {1, 7}, {2, 8}, {2, 9}, {2, 10}, {2, 11}},
+		},
+		{
+			"uid >= 2 and uid <= 3",
+			logic.AND(
+				Wrap(cmp.NewInt64("uid", cmp.Cgte, 2)),
+				Wrap(cmp.NewInt64("uid", cmp.Clte, 3)),
+			),
+			[][2]uint32{{2, 8}, {2, 9}, {2, 10}, {2, 11}, {3, 12}, {3, 13}, {3, 14}, {3, 15}},
+		},
+	} {
+		t.Run(next.scene, func(t *testing.T) {
+			shards, err := Eval(getPrefixKeysVTab(), next.input)
+			assert.NoError(t, err)
+
+			want := rule.NewShards()
+			for _, it := range next.want {
+				want.Add(it[0], it[1])
+			}
+			assert.Equal(t, want.String(), shards.String())
+		})
+	}
+
+	t.Run("sid = 2", func(t *testing.T) {
+		// the DB cannot be computed without the leading column
+		_, err := Eval(getPrefixKeysVTab(), Wrap(cmp.NewInt64("sid", cmp.Ceq, 2)))
+		assert.True(t, errors.Is(err, ErrNoShardMatched))
+	})
+
+	t.Run("uid = 8 of single computer", func(t *testing.T) {
+		// both the DB and the table are computed by all columns
+		_, err := Eval(getMultipleKeysVTab(), Wrap(cmp.NewInt64("uid", cmp.Ceq, 8)))
+		assert.True(t, errors.Is(err, ErrNoShardMatched))
+	})
+}
+
 func TestSingleCalculus(t *testing.T) {
 	type tt struct {
 		scene string
@@ -262,6 +360,52 @@ func TestSingleCalculus(t *testing.T) {
 			),
 			"[]",
 		},
+		{
+			"uid >= 10 and uid >= 12 and uid <= 13",
+			logic.AND(
+				logic.AND(
+					Wrap(cmp.NewInt64("uid", cmp.Cgte, 10)),
+					Wrap(cmp.NewInt64("uid", cmp.Cgte, 12)),
+				),
+				Wrap(cmp.NewInt64("uid", cmp.Clte, 13)),
+			),
+			"[3:12,13]",
+		},
+		{
+			"uid >= 1 and uid <= 9 and uid < 4",
+			logic.AND(
+				logic.AND(
+					Wrap(cmp.NewInt64("uid", cmp.Cgte, 1)),
+					Wrap(cmp.NewInt64("uid", cmp.Clte, 9)),
+				),
+				Wrap(cmp.NewInt64("uid", cmp.Clt, 4)),
+			),
+			"[0:1,2,3]",
+		},
+		{
+			"uid > 50 and uid < 10",
+			logic.AND(
+				Wrap(cmp.NewInt64("uid", cmp.Cgt, 50)),
+				Wrap(cmp.NewInt64("uid", cmp.Clt, 10)),
+			),
+			"[]",
+		},
+		{
+			"uid >= 12 and uid < 12",
+			logic.AND(
+				Wrap(cmp.NewInt64("uid", cmp.Cgte, 12)),
+				Wrap(cmp.NewInt64("uid", cmp.Clt, 12)),
+			),
+			"[]",
+		},
+		{
+			"uid == 1 and uid == 2",
+			logic.AND(
+				Wrap(cmp.NewInt64("uid", cmp.Ceq, 1)),
+				Wrap(cmp.NewInt64("uid", cmp.Ceq, 2)),
+			),
+			"[]",
+		},
 		{
 			"uid == 1 and not (uid == 1)",
 			logic.AND(
@@ -282,3 +426,65 @@ func TestSingleCalculus(t *testing.T) {
 		})
 	}
 }
+
+func TestSingleKeyShardsCalculus(t *testing.T) {
+	type tt struct {
+		scene string
+		input logic.Logic[*Calculus]
+		want  string
+		// the shards without declaring single key shards
+		wantWithout string
+	}
+
+	for _, next := range []tt{
+		{
+			"uid <> 1",
+			Wrap(cmp.NewInt64("uid", cmp.Cne, 1)),
+			"[0:0,2,3;1:4,5,6,7;2:8,9,10,11;3:12,13,14,15]",
+			"*",
+		},
+		{
+			"uid not in (1,5,6)",
+			logic.AND(
+				logic.AND(
+					Wrap(cmp.NewInt64("uid", cmp.Cne, 1)),
+					Wrap(cmp.NewInt64("uid", cmp.Cne, 5)),
+				),
+				Wrap(cmp.NewInt64("uid", cmp.Cne, 6)),
+			),
+			"[0:0,2,3;1:4,7;2:8,9,10,11;3:12,13,14,15]",
+			"*",
+		},
+		{
+			"uid > 8 and uid <= 12 and uid <> 10",
+			logic.AND(
+				logic.AND(
+					Wrap(cmp.NewInt64("uid", cmp.Cgt, 8)),
+					Wrap(cmp.NewInt64("uid", cmp.Clte, 12)),
+				),
+				Wrap(cmp.NewInt64("uid", cmp.Cne, 10)),
+			),
+			"[2:9,11;3:12]",
+			"[2:9,10,11;3:12]",
+		},
+		{
+			"not uid = 1",
+			logic.NOT(Wrap(cmp.NewInt64("uid", cmp.Ceq, 1))),
+			"[0:0,2,3;1:4,5,6,7;2:8,9,10,11;3:12,13,14,15]",
+			"*",
+		},
+	} {
+		t.Run(next.scene, func(t *testing.T) {
+			// each physical table is dedicated to one uid, so the shards of inequalities can be excluded
+			vtab := getSingleKeyVTab()
+			vtab.SetSingleKeyShards(true)
+			shards, err := Eval(vtab, next.input)
+			assert.NoError(t, err)
+			assert.Equal(t, next.want, shards.String())
+
+			shards, err = Eval(getSingleKeyVTab(), next.input)
+			assert.NoError(t, err)
+			assert.Equal(t, next.wantWithout, shards.String())
+		})
+	}
+}
@@ -477,6 +477,11 @@ func atomAndIntersection[T Item](a atomLogic[T], b intersectionLogic[T]) Logic[T
 }
 
 func atomAndUnion[T Item](a atomLogic[T], b unionLogic[T]) Logic[T] {
+	// A ∩ TRUE => A
+	if len(b) < 1 {
+		return a
+	}
+
 	// A ∩ (B ∪ C) => (A ∩ B) ∪ (A ∩ C)
 	// A ∩ (A ∪ B) => A
 	for i := range b {
@@ -75,6 +75,20 @@ func TestLogic(t *testing.T) {
 			"a || !b",
 		},
 		// --- AND ---
+		{
+			"a && true",
+			func() Logic[String] {
+				return AND(a, True[String]())
+			},
+			"a",
+		},
+		{
+			"true && a",
+			func() Logic[String] {
+				return AND(True[String](), a)
+			},
+			"a",
+		},
 		{
 			"a && b",
 			func() Logic[String] {
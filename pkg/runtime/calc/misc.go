@@ -246,3 +246,29 @@ L:
 
 	return nil
 }
+
+// searchPrefixVShard searches the virtual shard whose sharding key is partially provided, the shards can be pruned
+// only if the DB or the table is computed by the provided columns only.
+//
+// The prefix pruning is supported when the DB and the table are computed by different computers, eg: the DB is
+// sharded by the leading column 'a' and the table by 'ab' or 'b', then 'WHERE a = 5' narrows the shards to all
+// tables of the DB where a=5 maps. A single computer over all columns, eg: a script of '($0 * 31 + $1) % 32', or
+// a stepped multi-column key cannot be pruned without all of its columns, it is degraded to full scan.
+func searchPrefixVShard[T any](table *rule.VTable, groups map[string]T) *rule.VShard {
+	for _, vShard := range table.GetVShards() {
+		if vShard.DB != nil && vShard.Table != nil && (isCovered(vShard.DB, groups) || isCovered(vShard.Table, groups)) {
+			return vShard
+		}
+	}
+	return nil
+}
+
+// isCovered returns true if all the variables of metadata are provided.
+func isCovered[T any](m *rule.ShardMetadata, groups map[string]T) bool {
+	for _, it := range m.Computer.Variables() {
+		if _, ok := groups[it]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 )
 
 import (
@@ -29,6 +30,7 @@ import (
 
 import (
 	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/third_party/pools"
 )
 
 func TestBackendResourcePool_Get(t *testing.T) {
@@ -54,3 +56,49 @@ func TestBackendResourcePool_Get(t *testing.T) {
 		})
 	}
 }
+
+func TestAtomDB_PoolLimits(t *testing.T) {
+	ctx := context.Background()
+
+	newDB := func(maxIdle int64, maxLifetime, waitTimeout time.Duration) *AtomDB {
+		db := &AtomDB{
+			id:          "fake_node",
+			maxIdle:     maxIdle,
+			maxLifetime: maxLifetime,
+			waitTimeout: waitTimeout,
+		}
+		db.pool = pools.NewResourcePool(func(ctx context.Context) (pools.Resource, error) {
+			return &mysql.BackendConnection{}, nil
+		}, 2, 2, 0, 0, nil)
+		return db
+	}
+
+	take := func(db *AtomDB) *mysql.BackendConnection {
+		res, err := db.pool.Get(ctx)
+		assert.NoError(t, err)
+		return res.(*mysql.BackendConnection)
+	}
+
+	// fail with a clear error once the pool is saturated
+	db := newDB(0, 0, 10*time.Millisecond)
+	a, b := take(db), take(db)
+	_, err := db.borrowConnection(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exhausted")
+	db.returnConnection(a)
+	db.returnConnection(b)
+	assert.Equal(t, int64(2), db.pool.Active())
+
+	// the exceeded idle connections are closed
+	db = newDB(1, 0, 0)
+	a, b = take(db), take(db)
+	db.returnConnection(a)
+	db.returnConnection(b)
+	assert.Equal(t, int64(1), db.pool.Active())
+	assert.Equal(t, int64(2), db.pool.Available())
+
+	// the expired connections are closed
+	db = newDB(0, time.Hour, 0)
+	db.returnConnection(take(db))
+	assert.Equal(t, int64(0), db.pool.Active())
+}
@@ -19,6 +19,9 @@ package context
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 )
 
 import (
@@ -30,6 +33,9 @@ const (
 	_flagDirect cFlag = 1 << iota
 	_flagRead
 	_flagWrite
+	_flagIdempotent
+	_flagPrimaryShardStrategy
+	_flagTxReadOnly
 )
 
 type (
@@ -38,10 +44,34 @@ type (
 	keyDefaultDBGroup struct{}
 	keyHints          struct{}
 	keyTransactionID  struct{}
+	keyWarnings       struct{}
+	keyExecStats      struct{}
+	keyStartTime      struct{}
 )
 
 type cFlag uint8
 
+// warnings collects the warnings of current statement.
+type warnings struct {
+	mu   sync.Mutex
+	list []*proto.Warning
+}
+
+// ShardTiming represents the elapsed time of a physical query sent to the shard, for the queries
+// which return rows, it is the elapsed time until the first response rather than all rows are read.
+type ShardTiming struct {
+	DB      string
+	SQL     string
+	Elapsed time.Duration
+}
+
+// execStats collects the execution stats of current statement.
+type execStats struct {
+	mu       sync.Mutex
+	fullScan bool
+	shards   []ShardTiming
+}
+
 // WithTransactionID sets transaction id
 func WithTransactionID(ctx context.Context, id string) context.Context {
 	return context.WithValue(ctx, keyTransactionID{}, id)
@@ -68,17 +98,136 @@ func WithRead(ctx context.Context) context.Context {
 	return context.WithValue(ctx, keyFlag{}, _flagRead|getFlag(ctx))
 }
 
+// WithIdempotent marked as idempotent operation, it can be retried safely.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyFlag{}, _flagIdempotent|getFlag(ctx))
+}
+
+// WithPrimaryShardStrategy pins the sharding to the primary strategy, the strategy chosen by session will be ignored.
+// It should be used by the writes, whose rows must always be located by the primary strategy.
+func WithPrimaryShardStrategy(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyFlag{}, _flagPrimaryShardStrategy|getFlag(ctx))
+}
+
+// WithTxReadOnly marks the transaction to begin as READ ONLY, eg: 'START TRANSACTION READ ONLY'.
+func WithTxReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyFlag{}, _flagTxReadOnly|getFlag(ctx))
+}
+
+// WithStartTime sets the start time of current statement, the time functions such as NOW() are computed by it.
+func WithStartTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, keyStartTime{}, t)
+}
+
 // WithHints binds the hints.
 func WithHints(ctx context.Context, hints []*hint.Hint) context.Context {
 	return context.WithValue(ctx, keyHints{}, hints)
 }
 
+// WithWarnings enables collecting the warnings of current statement.
+func WithWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyWarnings{}, &warnings{})
+}
+
+// AddWarning adds a warning of current statement, it will be ignored if collecting warnings is not enabled.
+func AddWarning(ctx context.Context, code uint16, format string, args ...interface{}) {
+	w, ok := ctx.Value(keyWarnings{}).(*warnings)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.list = append(w.list, &proto.Warning{
+		Level:   "Warning",
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Warnings returns the warnings collected of current statement.
+func Warnings(ctx context.Context) []*proto.Warning {
+	w, ok := ctx.Value(keyWarnings{}).(*warnings)
+	if !ok {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.list
+}
+
+// WithExecStats enables collecting the execution stats of current statement, eg: the time of each shard.
+func WithExecStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyExecStats{}, &execStats{})
+}
+
+// AddShardTiming records the elapsed time of a physical query, it will be ignored if collecting is not enabled.
+func AddShardTiming(ctx context.Context, db, sql string, elapsed time.Duration) {
+	s, ok := ctx.Value(keyExecStats{}).(*execStats)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shards = append(s.shards, ShardTiming{DB: db, SQL: sql, Elapsed: elapsed})
+}
+
+// ShardTimings returns the elapsed time of physical queries of current statement.
+func ShardTimings(ctx context.Context) []ShardTiming {
+	s, ok := ctx.Value(keyExecStats{}).(*execStats)
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shards
+}
+
+// MarkFullScan marks current statement as a full table scan.
+func MarkFullScan(ctx context.Context) {
+	s, ok := ctx.Value(keyExecStats{}).(*execStats)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fullScan = true
+}
+
+// IsFullScan returns true if current statement is a full table scan.
+func IsFullScan(ctx context.Context) bool {
+	s, ok := ctx.Value(keyExecStats{}).(*execStats)
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fullScan
+}
+
+// LastWarnings returns the warnings of the last statement in current session.
+func LastWarnings(ctx context.Context) []*proto.Warning {
+	if val, ok := ctx.Value(proto.ContextKeyWarnings{}).([]*proto.Warning); ok {
+		return val
+	}
+	return nil
+}
+
 // Tenant extracts the tenant.
 func Tenant(ctx context.Context) string {
 	return isString(ctx, proto.ContextKeyTenant{})
 }
 
 // IsRead returns true if this is a read operation
+// Now returns the start time of current statement in the time zone of session,
+// the current time will be used if the start time is not set.
+func Now(ctx context.Context) time.Time {
+	t, ok := ctx.Value(keyStartTime{}).(time.Time)
+	if !ok {
+		t = time.Now()
+	}
+	return t.In(TimeZone(ctx))
+}
+
 func IsRead(ctx context.Context) bool {
 	return hasFlag(ctx, _flagRead)
 }
@@ -88,6 +237,11 @@ func IsWrite(ctx context.Context) bool {
 	return hasFlag(ctx, _flagWrite)
 }
 
+// IsIdempotent returns true if this is an idempotent operation
+func IsIdempotent(ctx context.Context) bool {
+	return hasFlag(ctx, _flagIdempotent)
+}
+
 // IsDirect returns true if execute directly.
 func IsDirect(ctx context.Context) bool {
 	return hasFlag(ctx, _flagDirect)
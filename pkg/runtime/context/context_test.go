@@ -20,6 +20,7 @@ package context
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 import (
@@ -61,4 +62,192 @@ func TestContext(t *testing.T) {
 	assert.Empty(t, SQL(ctx))
 	assert.Empty(t, Schema(ctx))
 	assert.Empty(t, Version(ctx))
+
+	// warnings will be ignored if collecting is not enabled
+	AddWarning(ctx, 1105, "foo")
+	assert.Empty(t, Warnings(ctx))
+
+	warningsCtx := WithWarnings(ctx)
+	AddWarning(warningsCtx, 1105, "full table scan across %d shards", 8)
+	assert.Equal(t, []*proto.Warning{
+		{Level: "Warning", Code: 1105, Message: "full table scan across 8 shards"},
+	}, Warnings(warningsCtx))
+
+	assert.Empty(t, LastWarnings(ctx))
+	lastWarnings := Warnings(warningsCtx)
+	assert.Equal(t, lastWarnings, LastWarnings(context.WithValue(ctx, proto.ContextKeyWarnings{}, lastWarnings)))
+
+	// exec stats will be ignored if collecting is not enabled
+	MarkFullScan(ctx)
+	AddShardTiming(ctx, "employees_0000", "SELECT 1", time.Millisecond)
+	assert.False(t, IsFullScan(ctx))
+	assert.Empty(t, ShardTimings(ctx))
+
+	statsCtx := WithExecStats(ctx)
+	MarkFullScan(statsCtx)
+	AddShardTiming(statsCtx, "employees_0000", "SELECT 1", time.Millisecond)
+	assert.True(t, IsFullScan(statsCtx))
+	assert.Equal(t, []ShardTiming{
+		{DB: "employees_0000", SQL: "SELECT 1", Elapsed: time.Millisecond},
+	}, ShardTimings(statsCtx))
+}
+
+func TestSessionVariable(t *testing.T) {
+	ctx := context.Background()
+
+	assert.True(t, IsSessionVariable("ARANA_BEST_EFFORT"))
+	// the variables owned by backend are not answered by arana
+	assert.False(t, IsSessionVariable("sql_mode"))
+	assert.False(t, IsSessionVariable("time_zone"))
+	assert.Contains(t, SessionVariableNames(), VarHint)
+
+	v, ok := SessionVariable(ctx, VarBestEffort)
+	assert.True(t, ok)
+	assert.Equal(t, "0", v.String())
+
+	_, ok = SessionVariable(ctx, "max_allowed_packet")
+	assert.False(t, ok)
+
+	variables := map[string]proto.Value{
+		"@@" + VarBestEffort: proto.NewValueInt64(1),
+		"@@sql_mode":         proto.NewValueString("ANSI_QUOTES"),
+	}
+	variablesCtx := context.WithValue(ctx, proto.ContextKeyTransientVariables{}, variables)
+	v, ok = SessionVariable(variablesCtx, "ARANA_BEST_EFFORT")
+	assert.True(t, ok)
+	assert.Equal(t, "1", v.String())
+	_, ok = SessionVariable(variablesCtx, "sql_mode")
+	assert.False(t, ok)
+}
+
+func TestNow(t *testing.T) {
+	start := time.Date(2022, 3, 1, 20, 0, 0, 0, time.UTC)
+	withTimeZone := func(tz string) context.Context {
+		ctx := WithStartTime(context.Background(), start)
+		if len(tz) < 1 {
+			return ctx
+		}
+		return context.WithValue(ctx, proto.ContextKeyTransientVariables{}, map[string]proto.Value{
+			"@@time_zone": proto.NewValueString(tz),
+		})
+	}
+
+	for _, it := range []struct {
+		tz     string
+		expect string
+	}{
+		{"+08:00", "2022-03-02 04:00:00"},
+		{"-05:30", "2022-03-01 14:30:00"},
+		{"UTC", "2022-03-01 20:00:00"},
+	} {
+		t.Run(it.tz, func(t *testing.T) {
+			assert.Equal(t, it.expect, Now(withTimeZone(it.tz)).Format("2006-01-02 15:04:05"))
+		})
+	}
+
+	// the local time zone is used by default
+	for _, tz := range []string{"", "SYSTEM", "not_exist"} {
+		assert.Equal(t, time.Local, TimeZone(withTimeZone(tz)))
+		assert.True(t, start.Equal(Now(withTimeZone(tz))))
+	}
+}
+
+func TestShardStrategy(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, ShardStrategy(ctx))
+	assert.True(t, IsSessionVariable(VarShardStrategy))
+
+	variables := map[string]proto.Value{
+		"@@autocommit":           proto.NewValueInt64(0),
+		"@@arana_shard_strategy": proto.NewValueString("by_time"),
+	}
+	ctx = context.WithValue(ctx, proto.ContextKeyTransientVariables{}, variables)
+	assert.Equal(t, "by_time", ShardStrategy(ctx))
+	// the writes are pinned to the primary strategy
+	assert.Empty(t, ShardStrategy(WithPrimaryShardStrategy(ctx)))
+
+	// the local variables should not be synced to upstream
+	upstream := UpstreamVariables(ctx)
+	assert.Len(t, upstream, 1)
+	assert.Contains(t, upstream, "@@autocommit")
+	assert.Len(t, variables, 2)
+}
+
+func TestBestEffort(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, BestEffort(ctx))
+	assert.True(t, IsSessionVariable(VarBestEffort))
+
+	variables := map[string]proto.Value{
+		"@@arana_best_effort": proto.NewValueString("ON"),
+	}
+	ctx = context.WithValue(ctx, proto.ContextKeyTransientVariables{}, variables)
+	assert.True(t, BestEffort(ctx))
+	assert.Empty(t, UpstreamVariables(ctx))
+}
+
+func TestSessionHint(t *testing.T) {
+	ctx := context.Background()
+	h, err := SessionHint(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, h)
+	assert.True(t, IsSessionVariable(VarHint))
+
+	variables := map[string]proto.Value{
+		"@@arana_hint": proto.NewValueString("Master"),
+	}
+	ctx = context.WithValue(ctx, proto.ContextKeyTransientVariables{}, variables)
+	h, err = SessionHint(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, hint.TypeMaster, h.Type)
+	assert.Empty(t, UpstreamVariables(ctx))
+
+	// cleared by an empty string
+	variables["@@arana_hint"] = proto.NewValueString(" ")
+	h, err = SessionHint(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, h)
+
+	variables["@@arana_hint"] = proto.NewValueString("not_exist_hint")
+	_, err = SessionHint(ctx)
+	assert.Error(t, err)
+}
+
+func TestTxReadOnly(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, TxReadOnly(ctx))
+
+	variables := map[string]proto.Value{
+		"@@transaction_read_only":   proto.NewValueString("ON"),
+		"@@" + VarTxReadOnlyOneShot: proto.NewValueInt64(0),
+	}
+	ctx = context.WithValue(ctx, proto.ContextKeyTransientVariables{}, variables)
+	assert.NotContains(t, UpstreamVariables(ctx), "@@"+VarTxReadOnlyOneShot)
+
+	// the next transaction is READ WRITE, then the session characteristic takes effect
+	assert.False(t, TxReadOnly(ctx))
+	assert.False(t, TxReadOnly(ctx))
+	assert.True(t, TxReadOnly(WithTxReadOnly(ctx)))
+	ConsumeNextTxCharacteristics(ctx)
+	assert.True(t, TxReadOnly(ctx))
+
+	// START TRANSACTION READ ONLY works without variables
+	assert.True(t, TxReadOnly(WithTxReadOnly(context.Background())))
+	ConsumeNextTxCharacteristics(context.Background())
+}
+
+func TestMemoryQuota(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, GetMemoryQuota(ctx))
+	// unlimited
+	assert.NoError(t, GetMemoryQuota(ctx).Consume(1<<30))
+	assert.Nil(t, GetMemoryQuota(WithMemoryLimit(ctx, 0)))
+
+	ctx = WithMemoryLimit(ctx, 100)
+	mq := GetMemoryQuota(ctx)
+	assert.NoError(t, mq.Consume(60))
+	assert.ErrorIs(t, mq.Consume(60), ErrMemoryLimitExceeded)
+	mq.Release(60)
+	assert.Equal(t, int64(60), mq.Used())
+	assert.NoError(t, mq.Consume(40))
 }
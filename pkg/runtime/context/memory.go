@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package context
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ErrMemoryLimitExceeded is returned if the rows buffered by a query exceed the memory limit.
+var ErrMemoryLimitExceeded = errors.New("query exceeded memory limit")
+
+type keyMemoryQuota struct{}
+
+// MemoryQuota accounts the memory of rows buffered in proxy by a query, eg: the merged rows to be sorted,
+// it is shared by all the plans of the query. A nil MemoryQuota means no limit.
+type MemoryQuota struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// Consume accounts n bytes, ErrMemoryLimitExceeded will be returned if the limit is exceeded.
+func (mq *MemoryQuota) Consume(n int64) error {
+	if mq == nil {
+		return nil
+	}
+	if used := mq.used.Add(n); used > mq.limit {
+		return errors.Wrapf(ErrMemoryLimitExceeded, "used=%d, limit=%d", used, mq.limit)
+	}
+	return nil
+}
+
+// Release gives back n bytes which won't be held any more.
+func (mq *MemoryQuota) Release(n int64) {
+	if mq == nil {
+		return
+	}
+	mq.used.Add(-n)
+}
+
+// Used returns the bytes accounted currently.
+func (mq *MemoryQuota) Used() int64 {
+	if mq == nil {
+		return 0
+	}
+	return mq.used.Load()
+}
+
+// WithMemoryLimit limits the memory of rows buffered by current statement, zero or negative limit means no limit.
+func WithMemoryLimit(ctx context.Context, limit int64) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, keyMemoryQuota{}, &MemoryQuota{limit: limit})
+}
+
+// GetMemoryQuota returns the memory quota of current statement, nil will be returned if it is unlimited.
+func GetMemoryQuota(ctx context.Context) *MemoryQuota {
+	mq, _ := ctx.Value(keyMemoryQuota{}).(*MemoryQuota)
+	return mq
+}
@@ -0,0 +1,213 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package context
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/hint"
+)
+
+// _sessionVariables contains the default values of session variables which are managed by arana, the
+// other variables, eg: sql_mode or time_zone, are owned by the backend and should be queried from it.
+var _sessionVariables = map[string]proto.Value{
+	VarShardStrategy: proto.NewValueString(""),
+	VarBestEffort:    proto.NewValueInt64(0),
+	VarHint:          proto.NewValueString(""),
+}
+
+// VarShardStrategy is the name of sharding strategy which will be used by the current session,
+// the primary sharding strategy will be used if it is empty.
+const VarShardStrategy = "arana_shard_strategy"
+
+// VarBestEffort enables returning the partial results of healthy shards when some shards fail.
+const VarBestEffort = "arana_best_effort"
+
+// VarHint is the hint applied to the subsequent statements of current session, eg: SET arana_hint = 'master',
+// it is useful for the clients which strip the comments. The inline hints of statement take precedence.
+const VarHint = "arana_hint"
+
+// VarRuleVersion is the read-only variable which shows the version of active sharding rule,
+// it is increased by each reloading, eg: SHOW VARIABLES LIKE 'arana_rule_version'.
+const VarRuleVersion = "arana_rule_version"
+
+// VarTxReadOnlyOneShot is the characteristic set by 'SET TRANSACTION READ ONLY|READ WRITE' without scope,
+// it only takes effect on the next transaction.
+const VarTxReadOnlyOneShot = "tx_read_only_one_shot"
+
+// _localVariables contains the session variables which only take effect in arana, they won't be synced to upstream.
+var _localVariables = map[string]struct{}{
+	VarShardStrategy:     {},
+	VarBestEffort:        {},
+	VarHint:              {},
+	VarTxReadOnlyOneShot: {},
+}
+
+// IsSessionVariable returns true if the session variable is managed by arana.
+func IsSessionVariable(name string) bool {
+	_, ok := _sessionVariables[strings.ToLower(name)]
+	return ok
+}
+
+// SessionVariableNames returns the sorted names of session variables which are managed by arana.
+func SessionVariableNames() []string {
+	names := make([]string, 0, len(_sessionVariables))
+	for k := range _sessionVariables {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SessionVariable returns the value of session variable which is managed by arana,
+// the value changed by 'SET' statement will be returned first.
+func SessionVariable(ctx context.Context, name string) (proto.Value, bool) {
+	name = strings.ToLower(name)
+	value, ok := _sessionVariables[name]
+	if !ok {
+		return nil, false
+	}
+	if v, ok := TransientVariables(ctx)["@@"+name]; ok {
+		return v, true
+	}
+	return value, true
+}
+
+// TimeZone returns the location of session variable time_zone, eg: SET time_zone = '+08:00' or 'Asia/Shanghai'.
+// The local time zone will be returned if it is not set, or it is 'SYSTEM' or invalid.
+func TimeZone(ctx context.Context) *time.Location {
+	v, ok := TransientVariables(ctx)["@@time_zone"]
+	if !ok || v == nil {
+		return time.Local
+	}
+
+	s := strings.TrimSpace(v.String())
+	if len(s) == 6 && (s[0] == '+' || s[0] == '-') && s[3] == ':' {
+		h, err1 := strconv.Atoi(s[1:3])
+		m, err2 := strconv.Atoi(s[4:])
+		if err1 != nil || err2 != nil {
+			return time.Local
+		}
+		offset := h*3600 + m*60
+		if s[0] == '-' {
+			offset = -offset
+		}
+		return time.FixedZone(s, offset)
+	}
+
+	if strings.EqualFold(s, "SYSTEM") {
+		return time.Local
+	}
+	if loc, err := time.LoadLocation(s); err == nil {
+		return loc
+	}
+	return time.Local
+}
+
+// UpstreamVariables returns the transient variables which should be synced to upstream databases,
+// the variables which only take effect in arana are excluded.
+func UpstreamVariables(ctx context.Context) map[string]proto.Value {
+	vars := TransientVariables(ctx)
+	for k := range vars {
+		if _, ok := _localVariables[strings.TrimPrefix(k, "@@")]; !ok {
+			continue
+		}
+		ret := make(map[string]proto.Value, len(vars))
+		for k, v := range vars {
+			if _, ok := _localVariables[strings.TrimPrefix(k, "@@")]; !ok {
+				ret[k] = v
+			}
+		}
+		return ret
+	}
+	return vars
+}
+
+// ShardStrategy returns the name of sharding strategy chosen by 'SET arana_shard_strategy = <name>',
+// empty string will be returned if the primary strategy is pinned by WithPrimaryShardStrategy.
+func ShardStrategy(ctx context.Context) string {
+	if hasFlag(ctx, _flagPrimaryShardStrategy) {
+		return ""
+	}
+	if v, ok := SessionVariable(ctx, VarShardStrategy); ok && v != nil {
+		return v.String()
+	}
+	return ""
+}
+
+// TxReadOnly returns true if the transaction to begin is READ ONLY, whose reads can be routed to replicas.
+// The characteristic of statement wins, then the one of next transaction, at last the session one is used,
+// eg: 'START TRANSACTION READ ONLY', 'SET TRANSACTION READ ONLY' or 'SET SESSION TRANSACTION READ ONLY'.
+func TxReadOnly(ctx context.Context) bool {
+	if hasFlag(ctx, _flagTxReadOnly) {
+		return true
+	}
+	vars := TransientVariables(ctx)
+	if v, ok := vars["@@"+VarTxReadOnlyOneShot]; ok {
+		return isTruthy(v)
+	}
+	for _, name := range []string{"@@transaction_read_only", "@@tx_read_only"} {
+		if v, ok := vars[name]; ok {
+			return isTruthy(v)
+		}
+	}
+	return false
+}
+
+// ConsumeNextTxCharacteristics removes the characteristic set by 'SET TRANSACTION' without scope,
+// it should be called once the next transaction began.
+func ConsumeNextTxCharacteristics(ctx context.Context) {
+	delete(TransientVariables(ctx), "@@"+VarTxReadOnlyOneShot)
+}
+
+// BestEffort returns true if the partial results are enabled by 'SET arana_best_effort = 1'.
+func BestEffort(ctx context.Context) bool {
+	v, ok := SessionVariable(ctx, VarBestEffort)
+	return ok && isTruthy(v)
+}
+
+// SessionHint returns the hint set by 'SET arana_hint = <hint>', nil will be returned if it is empty.
+func SessionHint(ctx context.Context) (*hint.Hint, error) {
+	v, ok := SessionVariable(ctx, VarHint)
+	if !ok || v == nil {
+		return nil, nil
+	}
+	s := strings.TrimSpace(v.String())
+	if len(s) < 1 {
+		return nil, nil
+	}
+	return hint.Parse(s)
+}
+
+func isTruthy(v proto.Value) bool {
+	if v == nil {
+		return false
+	}
+	switch strings.ToUpper(v.String()) {
+	case "1", "ON", "TRUE":
+		return true
+	}
+	return false
+}
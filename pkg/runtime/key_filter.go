@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/util/log"
+)
+
+// loadKeyFilters loads the existing keys into the bloom filters of the rule in background,
+// each filter will be loaded only once.
+func (pi *defaultRuntime) loadKeyFilters(ru *rule.Rule) {
+	ru.Range(func(_ string, vt *rule.VTable) bool {
+		if kf := vt.KeyFilter(); kf != nil {
+			kf.LoadOnce(func() {
+				go pi.loadKeyFilter(vt, kf)
+			})
+		}
+		return true
+	})
+}
+
+func (pi *defaultRuntime) loadKeyFilter(vt *rule.VTable, kf *rule.KeyFilter) {
+	ctx := context.Background()
+	for db, tables := range vt.Topology().Enumerate() {
+		for _, table := range tables {
+			if err := pi.loadTableKeys(ctx, kf, db, table); err != nil {
+				log.Warnf("failed to load keys of bloom filter: table=%s.%s, err=%v", db, table, err)
+				continue
+			}
+			kf.SetReady(db, table)
+			log.Debugf("load keys of bloom filter successfully: table=%s.%s", db, table)
+		}
+	}
+}
+
+func (pi *defaultRuntime) loadTableKeys(ctx context.Context, kf *rule.KeyFilter, db, table string) error {
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	ast.WriteID(&sb, kf.Column())
+	sb.WriteString(" FROM ")
+	ast.WriteID(&sb, table)
+
+	res, err := pi.Query(ctx, db, sb.String())
+	if err != nil {
+		return perrors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return perrors.WithStack(err)
+	}
+	defer func() {
+		_ = ds.Close()
+	}()
+
+	dest := make([]proto.Value, 1)
+	for {
+		next, err := ds.Next()
+		if perrors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return perrors.WithStack(err)
+		}
+		if err = next.Scan(dest); err != nil {
+			return perrors.WithStack(err)
+		}
+		kf.Add(db, table, dest[0])
+	}
+}
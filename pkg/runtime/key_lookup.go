@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/util/log"
+)
+
+// bindKeyLookups binds the resolvers of key lookups in the rule, which query the mappings from the lookup tables.
+func (pi *defaultRuntime) bindKeyLookups(ru *rule.Rule) {
+	ru.Range(func(_ string, vt *rule.VTable) bool {
+		if kl := vt.KeyLookup(); kl != nil {
+			kl.BindOnce(func(ctx context.Context, value proto.Value) (proto.Value, error) {
+				key, err := pi.resolveKey(ctx, kl, value)
+				if err != nil {
+					log.Warnf("failed to resolve the lookup key: table=%s, %s=%s, err=%v", kl.Table(), kl.Column(), value, err)
+					return nil, err
+				}
+				return key, nil
+			})
+		}
+		return true
+	})
+}
+
+func (pi *defaultRuntime) resolveKey(ctx context.Context, kl *rule.KeyLookup, value proto.Value) (proto.Value, error) {
+	db, table, _ := strings.Cut(kl.Table(), ".")
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	ast.WriteID(&sb, kl.Key())
+	sb.WriteString(" FROM ")
+	ast.WriteID(&sb, table)
+	sb.WriteString(" WHERE ")
+	ast.WriteID(&sb, kl.Column())
+	sb.WriteString(" = ? LIMIT 1")
+
+	res, err := pi.Query(ctx, db, sb.String(), value)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	defer func() {
+		_ = ds.Close()
+	}()
+
+	next, err := ds.Next()
+	if perrors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+
+	dest := make([]proto.Value, 1)
+	if err = next.Scan(dest); err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	return dest[0], nil
+}
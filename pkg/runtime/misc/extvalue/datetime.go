@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extvalue
+
+import (
+	"context"
+	"time"
+)
+
+import (
+	pAst "github.com/arana-db/parser/ast"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+)
+
+const ctxTimeFunctionKey = favContextKey("time_function")
+
+// WithTimeFunctions enables the computation of relative time functions such as NOW(), CURDATE() and DATE_SUB().
+// It is designed for computing shards, the time functions selected by client should still be computed by backend.
+// Note that a one-sided range such as 'created_at >= NOW() - INTERVAL 7 DAY' prunes the shards only if the sharding
+// key is computed by range, eg: range-mapping, otherwise it should be bounded by 'created_at <= NOW()' to avoid full scan.
+func WithTimeFunctions(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxTimeFunctionKey, true)
+}
+
+// computeTimeFunction computes the relative time functions, eg: NOW() - INTERVAL 7 DAY,
+// ok will be false if the function is not a time function.
+func (vv *valueVisitor) computeTimeFunction(node *ast.Function) (ret interface{}, ok bool, err error) {
+	if vv.Context == nil {
+		return nil, false, nil
+	}
+	if enabled, _ := vv.Context.Value(ctxTimeFunctionKey).(bool); !enabled {
+		return nil, false, nil
+	}
+
+	// the time is computed in the time zone of session, and it is naive like the literals, eg: '2022-01-01 08:00:00'
+	now := rcontext.Now(vv.Context)
+	switch node.Name() {
+	case "NOW", "CURRENT_TIMESTAMP", "LOCALTIME", "LOCALTIMESTAMP", "SYSDATE":
+		h, mi, sec := now.Clock()
+		y, m, d := now.Date()
+		return proto.NewValueTime(time.Date(y, m, d, h, mi, sec, 0, time.UTC)), true, nil
+	case "CURDATE", "CURRENT_DATE":
+		y, m, d := now.Date()
+		return proto.NewValueTime(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)), true, nil
+	case "DATE_ADD", "ADDDATE":
+		ret, err = vv.computeDateArith(node, 1)
+		return ret, true, err
+	case "DATE_SUB", "SUBDATE":
+		ret, err = vv.computeDateArith(node, -1)
+		return ret, true, err
+	}
+	return nil, false, nil
+}
+
+func (vv *valueVisitor) computeDateArith(node *ast.Function, sign int) (interface{}, error) {
+	args := node.Args()
+	if len(args) != 2 {
+		return nil, errNotValue
+	}
+
+	base, err := args[0].Accept(vv)
+	if err != nil {
+		return nil, err
+	}
+	bv, ok := base.(proto.Value)
+	if !ok {
+		return nil, errNotValue
+	}
+	t, err := bv.Time()
+	if err != nil {
+		return nil, errNotValue
+	}
+
+	// ADDDATE(expr, days) is a synonym for ADDDATE(expr, INTERVAL days DAY)
+	var (
+		unit   = pAst.TimeUnitDay
+		amount ast.Node
+	)
+	if interval, ok := toInterval(args[1]); ok {
+		unit, amount = interval.Unit, interval.Value
+	} else {
+		amount = args[1]
+	}
+
+	av, err := amount.Accept(vv)
+	if err != nil {
+		return nil, err
+	}
+	nv, ok := av.(proto.Value)
+	if !ok {
+		return nil, errNotValue
+	}
+	n, err := nv.Int64()
+	if err != nil {
+		return nil, errNotValue
+	}
+
+	if t, ok = addInterval(t, unit, sign*int(n)); !ok {
+		return nil, errNotValue
+	}
+	return proto.NewValueTime(t), nil
+}
+
+func toInterval(arg *ast.FunctionArg) (*ast.IntervalExpressionAtom, bool) {
+	if arg.Type != ast.FunctionArgExpression {
+		return nil, false
+	}
+	pe, ok := arg.Value.(*ast.PredicateExpressionNode)
+	if !ok {
+		return nil, false
+	}
+	atom, ok := pe.P.(*ast.AtomPredicateNode)
+	if !ok {
+		return nil, false
+	}
+	interval, ok := atom.A.(*ast.IntervalExpressionAtom)
+	return interval, ok
+}
+
+func addInterval(t time.Time, unit pAst.TimeUnitType, n int) (time.Time, bool) {
+	switch unit {
+	case pAst.TimeUnitMicrosecond:
+		return t.Add(time.Duration(n) * time.Microsecond), true
+	case pAst.TimeUnitSecond:
+		return t.Add(time.Duration(n) * time.Second), true
+	case pAst.TimeUnitMinute:
+		return t.Add(time.Duration(n) * time.Minute), true
+	case pAst.TimeUnitHour:
+		return t.Add(time.Duration(n) * time.Hour), true
+	case pAst.TimeUnitDay:
+		return t.AddDate(0, 0, n), true
+	case pAst.TimeUnitWeek:
+		return t.AddDate(0, 0, 7*n), true
+	case pAst.TimeUnitMonth:
+		return addMonths(t, n), true
+	case pAst.TimeUnitQuarter:
+		return addMonths(t, 3*n), true
+	case pAst.TimeUnitYear:
+		return addMonths(t, 12*n), true
+	}
+	return t, false
+}
+
+// addMonths adds months like MySQL, the day will be clamped to the last day of month, eg: 2022-01-31 + 1 month = 2022-02-28.
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
@@ -42,3 +42,23 @@ func Compute(ctx context.Context, node ast.Node, args ...proto.Value) (proto.Val
 		return nil, nil
 	}
 }
+
+// ComputeRow is same as Compute, but the columns and aggregate functions are resolved from the values of row,
+// which are keyed by the field name, eg: 'cnt' for 'COUNT(*) AS cnt', 'COUNT(*)' for the unaliased one.
+func ComputeRow(ctx context.Context, node ast.Node, row map[string]proto.Value, args ...proto.Value) (proto.Value, error) {
+	var vv valueVisitor
+	vv.Context = ctx
+	vv.args = args
+	vv.row = row
+	ret, err := node.Accept(&vv)
+	if err != nil {
+		return nil, err
+	}
+
+	switch val := ret.(type) {
+	case proto.Value:
+		return val, nil
+	default:
+		return nil, nil
+	}
+}
@@ -20,6 +20,7 @@ package extvalue_test
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 import (
@@ -31,6 +32,7 @@ import (
 import (
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	_ "github.com/arana-db/arana/pkg/runtime/function"
 	"github.com/arana-db/arana/pkg/runtime/misc/extvalue"
 )
@@ -44,6 +46,10 @@ func TestCompute(t *testing.T) {
 	for _, next := range []tt{
 		{"1+2", "3"},
 		{"3 div 2", "1"},
+		{"-7 div 2", "-3"},
+		{"7 % 3", "1"},
+		{"-7 mod 3", "-1"},
+		{"7 % 0", "NULL"},
 		{"3/2", "1.5"},
 		{"case 1 when 1 then 'ok' end", "ok"},
 		{"case 1 when 2 then 'ok' end", "NULL"},
@@ -91,6 +97,105 @@ func TestCompute(t *testing.T) {
 	})
 }
 
+func TestCompute_TimeFunctions(t *testing.T) {
+	ctx := extvalue.WithTimeFunctions(context.TODO())
+
+	for _, next := range []struct {
+		input  string
+		expect string
+	}{
+		{"date_add('2022-01-31', interval 1 month)", "2022-02-28"},
+		{"date_sub('2022-03-31', interval 1 quarter)", "2021-12-31"},
+		{"date_sub('2022-03-01', interval 1 day)", "2022-02-28"},
+		{"adddate('2022-03-01', 2)", "2022-03-03"},
+		{"date_add('2020-02-29', interval 1 year)", "2021-02-28"},
+	} {
+		t.Run(next.input, func(t *testing.T) {
+			expr, err := getExpr(next.input)
+			assert.NoError(t, err)
+			v, err := extvalue.Compute(ctx, expr)
+			assert.NoError(t, err)
+			actual, err := v.Time()
+			assert.NoError(t, err)
+			assert.Equal(t, next.expect, actual.Format("2006-01-02"))
+		})
+	}
+
+	expr, err := getExpr("now()")
+	assert.NoError(t, err)
+
+	// disabled by default, which should be computed by backend
+	_, err = extvalue.Compute(context.TODO(), expr)
+	assert.Error(t, err)
+
+	// computed by the start time of statement in the time zone of session
+	ctx = rcontext.WithStartTime(context.WithValue(ctx, proto.ContextKeyTransientVariables{}, map[string]proto.Value{
+		"@@time_zone": proto.NewValueString("+08:00"),
+	}), time.Date(2022, 3, 1, 20, 0, 0, 0, time.UTC))
+	for _, next := range []struct {
+		input  string
+		expect string
+	}{
+		{"now()", "2022-03-02 04:00:00"},
+		{"curdate()", "2022-03-02 00:00:00"},
+		{"date_sub(now(), interval 5 hour)", "2022-03-01 23:00:00"},
+	} {
+		t.Run(next.input, func(t *testing.T) {
+			expr, err := getExpr(next.input)
+			assert.NoError(t, err)
+			v, err := extvalue.Compute(ctx, expr)
+			assert.NoError(t, err)
+			actual, err := v.Time()
+			assert.NoError(t, err)
+			assert.Equal(t, next.expect, actual.Format("2006-01-02 15:04:05"))
+		})
+	}
+}
+
+func TestComputeRow(t *testing.T) {
+	row := map[string]proto.Value{
+		"name":     proto.NewValueString("foo"),
+		"c":        proto.NewValueInt64(8),
+		"COUNT(1)": proto.NewValueInt64(8),
+		"score":    nil,
+	}
+
+	for _, next := range []struct {
+		input  string
+		expect string
+	}{
+		{"c > 5", "1"},
+		{"C > 5 and name = 'bar'", "0"},
+		{"count(*) >= 8 or name = 'bar'", "1"},
+		{"not (c > 5)", "0"},
+		{"score > 1 or c > 5", "1"},
+		{"score > 1 and c > 5", "NULL"},
+		{"score > 1 and c > 10", "0"},
+	} {
+		t.Run(next.input, func(t *testing.T) {
+			_, sel, err := ast.ParseSelect("select name from student group by name having " + next.input)
+			assert.NoError(t, err)
+			v, err := extvalue.ComputeRow(context.TODO(), sel.Having, row)
+			assert.NoError(t, err)
+
+			actual := "NULL"
+			if v != nil {
+				b, err := v.Bool()
+				assert.NoError(t, err)
+				actual = "0"
+				if b {
+					actual = "1"
+				}
+			}
+			assert.Equal(t, next.expect, actual)
+		})
+	}
+
+	_, sel, _ := ast.ParseSelect("select name from student group by name having age > 1")
+	_, err := extvalue.ComputeRow(context.TODO(), sel.Having, row)
+	assert.Error(t, err)
+}
+
 func getExpr(s string) (ast.ExpressionNode, error) {
 	_, sel, _ := ast.ParseSelect("select " + s)
 	switch f := sel.Select[0].(type) {
@@ -19,6 +19,7 @@ package extvalue
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"strings"
 )
@@ -49,12 +50,65 @@ type valueVisitor struct {
 	ast.BaseVisitor
 	context.Context
 	args []proto.Value
+	row  map[string]proto.Value // the values of row, nil means columns cannot be resolved
 }
 
 func (vv *valueVisitor) VisitPredicateExpression(node *ast.PredicateExpressionNode) (interface{}, error) {
 	return node.P.Accept(vv)
 }
 
+func (vv *valueVisitor) VisitLogicalExpression(node *ast.LogicalExpressionNode) (interface{}, error) {
+	l, err := vv.computeBool(node.Left)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	// short circuit: FALSE AND x, TRUE OR x
+	if l.Valid && l.Bool == node.Or {
+		return proto.NewValueBool(l.Bool), nil
+	}
+	r, err := vv.computeBool(node.Right)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	if r.Valid && r.Bool == node.Or {
+		return proto.NewValueBool(r.Bool), nil
+	}
+	if !l.Valid || !r.Valid {
+		return nil, nil
+	}
+	return proto.NewValueBool(!node.Or), nil
+}
+
+func (vv *valueVisitor) VisitNotExpression(node *ast.NotExpressionNode) (interface{}, error) {
+	b, err := vv.computeBool(node.E)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	if !b.Valid {
+		return nil, nil
+	}
+	return proto.NewValueBool(!b.Bool), nil
+}
+
+// computeBool computes the truth value of node, NULL is represented as invalid.
+func (vv *valueVisitor) computeBool(node ast.Node) (ret sql.NullBool, err error) {
+	var res interface{}
+	if res, err = node.Accept(vv); err != nil {
+		return
+	}
+	v, ok := res.(proto.Value)
+	if !ok || v == nil {
+		return
+	}
+	d, err := v.Decimal()
+	if err != nil {
+		d, err = decimal.Zero, nil
+	}
+	ret.Valid = true
+	ret.Bool = !d.IsZero()
+	return
+}
+
 func (vv *valueVisitor) VisitPredicateBinaryComparison(node *ast.BinaryComparisonPredicateNode) (interface{}, error) {
 	l, err := node.Left.Accept(vv)
 	if err != nil {
@@ -137,10 +191,34 @@ func (vv *valueVisitor) VisitPredicateAtom(node *ast.AtomPredicateNode) (interfa
 }
 
 func (vv *valueVisitor) VisitAtomColumn(node ast.ColumnNameExpressionAtom) (interface{}, error) {
-	return nil, nil
+	if vv.row == nil {
+		return nil, nil
+	}
+	return vv.lookup(node.Suffix())
+}
+
+// lookup returns the value of field from row, the field names are matched case-insensitively if not found.
+func (vv *valueVisitor) lookup(name string) (proto.Value, error) {
+	if v, ok := vv.row[name]; ok {
+		return v, nil
+	}
+	for k, v := range vv.row {
+		if strings.EqualFold(k, name) {
+			return v, nil
+		}
+	}
+	return nil, perrors.Errorf("no such column '%s' found", name)
 }
 
 func (vv *valueVisitor) VisitAtomConstant(node *ast.ConstantExpressionAtom) (interface{}, error) {
+	// the bit literal is evaluated as number, eg: b'1' -> 1
+	if b, ok := node.Value().(ast.BitLiteral); ok {
+		n, err := b.Uint64()
+		if err != nil {
+			return nil, perrors.WithStack(err)
+		}
+		return proto.NewValueUint64(n), nil
+	}
 	v, err := proto.NewValue(node.Value())
 	if err != nil {
 		return nil, perrors.WithStack(err)
@@ -155,11 +233,38 @@ func (vv *valueVisitor) VisitAtomFunction(node *ast.FunctionCallExpressionAtom)
 	return node.F.Accept(vv)
 }
 
+func (vv *valueVisitor) VisitFunctionAggregate(node *ast.AggrFunction) (interface{}, error) {
+	// the aggregate value can only be resolved from the computed row
+	if vv.row == nil {
+		return nil, errNotValue
+	}
+	var sb strings.Builder
+	if err := node.Restore(ast.RestoreDefault, &sb, nil); err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	return vv.lookup(sb.String())
+}
+
 func (vv *valueVisitor) VisitAtomNested(node *ast.NestedExpressionAtom) (interface{}, error) {
 	return node.First.Accept(vv)
 }
 
+func (vv *valueVisitor) VisitAtomRow(_ *ast.RowExpressionAtom) (interface{}, error) {
+	return nil, errNotValue
+}
+
 func (vv *valueVisitor) VisitAtomUnary(node *ast.UnaryExpressionAtom) (interface{}, error) {
+	if node.IsOperatorNot() {
+		b, err := vv.computeBool(node.Inner)
+		if err != nil {
+			return nil, perrors.WithStack(err)
+		}
+		if !b.Valid {
+			return nil, nil
+		}
+		return proto.NewValueBool(!b.Bool), nil
+	}
+
 	prev, err := node.Inner.Accept(vv)
 	if err != nil {
 		return nil, perrors.WithStack(err)
@@ -242,7 +347,14 @@ func (vv *valueVisitor) VisitAtomMath(node *ast.MathExpressionAtom) (interface{}
 		if y.Decimal.IsZero() {
 			return nil, nil
 		}
-		z = x.Decimal.Div(y.Decimal).Floor()
+		// the quotient is truncated toward zero like MySQL, eg: -7 DIV 2 = -3
+		z = x.Decimal.Div(y.Decimal).Truncate(0)
+	case opcode.Mod.Literal():
+		if y.Decimal.IsZero() {
+			return nil, nil
+		}
+		// the remainder has the sign of dividend like MySQL, eg: -7 % 3 = -1
+		z = x.Decimal.Mod(y.Decimal)
 	default:
 		// TODO: need implementation
 		return nil, perrors.Errorf("unsupported math opcode '%s'", node.Operator)
@@ -255,12 +367,20 @@ func (vv *valueVisitor) VisitAtomSystemVariable(node *ast.SystemVariableExpressi
 }
 
 func (vv *valueVisitor) VisitAtomVariable(node ast.VariableExpressionAtom) (interface{}, error) {
+	if node.N() >= len(vv.args) {
+		return nil, perrors.Errorf("no value bound to placeholder #%d, only %d args are given", node.N(), len(vv.args))
+	}
 	return vv.args[node.N()], nil
 }
 
 func (vv *valueVisitor) VisitAtomInterval(node *ast.IntervalExpressionAtom) (interface{}, error) {
-	// TODO implement me
-	panic("implement me")
+	// the interval will be computed by DATE_ADD/DATE_SUB, it is not a value itself
+	return nil, errNotValue
+}
+
+func (vv *valueVisitor) VisitAtomSubquery(node *ast.SubqueryExpressionAtom) (interface{}, error) {
+	// the subquery is executed by backend, it is not a value itself
+	return nil, errNotValue
 }
 
 func (vv *valueVisitor) VisitFunction(node *ast.Function) (interface{}, error) {
@@ -284,6 +404,10 @@ func (vv *valueVisitor) VisitFunction(node *ast.Function) (interface{}, error) {
 		)
 	}
 
+	if ret, ok, err := vv.computeTimeFunction(node); ok {
+		return ret, err
+	}
+
 	fn, ok := proto.GetFunc(node.Name())
 	if !ok {
 		return nil, newNoSuchFuncErr()
@@ -478,7 +602,7 @@ func (vv *valueVisitor) VisitFunctionArg(node *ast.FunctionArg) (interface{}, er
 	case ast.FunctionArgFunction:
 		return node.Value.(*ast.Function).Accept(vv)
 	case ast.FunctionArgAggrFunction:
-		return nil, errNotValue
+		return node.Value.(*ast.AggrFunction).Accept(vv)
 	case ast.FunctionArgCaseWhenElseFunction:
 		return node.Value.(*ast.CaseWhenElseFunction).Accept(vv)
 	case ast.FunctionArgCastFunction:
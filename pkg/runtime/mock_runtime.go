@@ -111,6 +111,26 @@ func (mr *MockRuntimeMockRecorder) Namespace() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Namespace", reflect.TypeOf((*MockRuntime)(nil).Namespace))
 }
 
+// PlanStats mocks base method.
+func (m *MockRuntime) PlanStats(arg0 context.Context, arg1 string, arg2 []proto.Value, arg3 ...PlanStatsOption) ([]*ShardStat, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PlanStats", varargs...)
+	ret0, _ := ret[0].([]*ShardStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlanStats indicates an expected call of PlanStats.
+func (mr *MockRuntimeMockRecorder) PlanStats(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlanStats", reflect.TypeOf((*MockRuntime)(nil).PlanStats), varargs...)
+}
+
 // Query mocks base method.
 func (m *MockRuntime) Query(arg0 context.Context, arg1, arg2 string, arg3 ...proto.Value) (proto.Result, error) {
 	m.ctrl.T.Helper()
@@ -130,3 +150,18 @@ func (mr *MockRuntimeMockRecorder) Query(arg0, arg1, arg2 interface{}, arg3 ...i
 	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockRuntime)(nil).Query), varargs...)
 }
+
+// ShardSkew mocks base method.
+func (m *MockRuntime) ShardSkew(arg0 context.Context, arg1 string) (*SkewReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardSkew", arg0, arg1)
+	ret0, _ := ret[0].(*SkewReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShardSkew indicates an expected call of ShardSkew.
+func (mr *MockRuntimeMockRecorder) ShardSkew(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardSkew", reflect.TypeOf((*MockRuntime)(nil).ShardSkew), arg0, arg1)
+}
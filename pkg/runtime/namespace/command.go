@@ -18,6 +18,7 @@
 package namespace
 
 import (
+	"strconv"
 	"time"
 )
 
@@ -26,6 +27,7 @@ import (
 	"github.com/arana-db/arana/pkg/constants"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/util/bytefmt"
 	"github.com/arana-db/arana/pkg/util/log"
 )
 
@@ -218,15 +220,54 @@ func UpsertDB(group string, ds proto.DB) Command {
 	}
 }
 
-// UpdateRule updates the rule.
+// UpdateRule updates the rule, the rule is swapped atomically and stamped with the next version,
+// so that the in-flight queries keep using the previous one.
 func UpdateRule(rule *rule.Rule) Command {
 	return func(ns *Namespace) error {
 		ns.Lock()
 		defer ns.Unlock()
-		ns.rule.Store(rule)
+		ns.storeRule(rule)
+		return nil
+	}
+}
+
+// UpsertVTable returns a command to add or replace the VTable, a new rule will be created with the VTable.
+func UpsertVTable(table string, vt *rule.VTable) Command {
+	return func(ns *Namespace) error {
+		ns.Lock()
+		defer ns.Unlock()
+
+		next := ns.Rule().Clone()
+		next.SetVTable(table, vt)
+		ns.storeRule(next)
+		return nil
+	}
+}
+
+// RemoveVTable returns a command to remove the VTable, a new rule will be created without the VTable.
+func RemoveVTable(table string) Command {
+	return func(ns *Namespace) error {
+		ns.Lock()
+		defer ns.Unlock()
+
+		next := ns.Rule().Clone()
+		if !next.RemoveVTable(table) {
+			return nil
+		}
+		ns.storeRule(next)
+		return nil
+	}
+}
 
-		log.Infof("[%s] update rule successfully", ns.name)
+// UpdateSchemaRoutes returns a command to replace the routes of physical schemas, a new rule will be created with the routes.
+func UpdateSchemaRoutes(routes []*rule.SchemaRoute) Command {
+	return func(ns *Namespace) error {
+		ns.Lock()
+		defer ns.Unlock()
 
+		next := ns.Rule().Clone()
+		next.SetSchemaRoutes(routes)
+		ns.storeRule(next)
 		return nil
 	}
 }
@@ -249,6 +290,47 @@ func UpdateSlowThreshold() Command {
 	}
 }
 
+// UpdateSlowSampleRate updates the ratio of slow queries which will be logged, eg: 0.1 means 10% of slow queries.
+func UpdateSlowSampleRate() Command {
+	return func(ns *Namespace) error {
+		if s, ok := ns.parameters[constants.SlowSampleRate]; ok {
+			if rate, err := strconv.ParseFloat(s, 64); err == nil && rate > 0 && rate <= 1 {
+				ns.slowSampleRate = rate
+			}
+		}
+		return nil
+	}
+}
+
+// UpdateReadRetries updates the max retry times and the backoff of failover for idempotent read requests.
+func UpdateReadRetries() Command {
+	return func(ns *Namespace) error {
+		if s, ok := ns.parameters[constants.ReadRetries]; ok {
+			if retries, err := strconv.Atoi(s); err == nil && retries >= 0 {
+				ns.readRetries = retries
+			}
+		}
+		if s, ok := ns.parameters[constants.ReadRetryBackoff]; ok {
+			if backoff, err := time.ParseDuration(s); err == nil && backoff >= 0 {
+				ns.retryBackoff = backoff
+			}
+		}
+		return nil
+	}
+}
+
+// UpdateQueryMemoryLimit updates the max bytes of rows buffered in proxy by each query, eg: 256MB.
+func UpdateQueryMemoryLimit() Command {
+	return func(ns *Namespace) error {
+		if s, ok := ns.parameters[constants.QueryMemoryLimit]; ok {
+			if limit, err := bytefmt.ToBytes(s); err == nil {
+				ns.memoryLimit = int64(limit)
+			}
+		}
+		return nil
+	}
+}
+
 func UpdateSlowLogger(path string, cfg *log.Config) Command {
 	return func(ns *Namespace) error {
 		ns.slowLog = log.NewSlowLogger(path, cfg)
@@ -41,8 +41,27 @@ import (
 	"github.com/arana-db/arana/pkg/util/log"
 )
 
+// DefaultReadRetries is the default max retry times of failover for idempotent read requests.
+const DefaultReadRetries = 1
+
+// DefaultReadRetryBackoff is the default backoff before retrying an idempotent read request on the same node.
+const DefaultReadRetryBackoff = 50 * time.Millisecond
+
+// DefaultSlowSampleRate is the default sample rate of slow logs, all the slow queries will be logged.
+const DefaultSlowSampleRate = 1.0
+
 var _namespaces sync.Map
 
+// RuleListener is notified after a rule is loaded or reloaded into the namespace.
+type RuleListener func(ns *Namespace, ru *rule.Rule)
+
+var _ruleListeners []RuleListener
+
+// AddRuleListener adds a listener of rule loading, it should be called during the package initialization.
+func AddRuleListener(l RuleListener) {
+	_ruleListeners = append(_ruleListeners, l)
+}
+
 // Load loads a namespace, return nil if no namespace found.
 func Load(tenant, namespace string) *Namespace {
 	exist, ok := _namespaces.Load(getLoadKey(tenant, namespace))
@@ -96,8 +115,12 @@ type (
 
 		sysDb proto.DB
 
-		parameters    config.ParametersMap
-		slowThreshold time.Duration
+		parameters     config.ParametersMap
+		slowThreshold  time.Duration
+		slowSampleRate float64
+		readRetries    int
+		retryBackoff   time.Duration
+		memoryLimit    int64
 
 		cmds chan Command  // command queue
 		done chan struct{} // done notify
@@ -112,9 +135,12 @@ type (
 // New creates a Namespace.
 func New(name string, commands ...Command) (*Namespace, error) {
 	ns := &Namespace{
-		name: name,
-		cmds: make(chan Command, 1),
-		done: make(chan struct{}),
+		name:           name,
+		readRetries:    DefaultReadRetries,
+		retryBackoff:   DefaultReadRetryBackoff,
+		slowSampleRate: DefaultSlowSampleRate,
+		cmds:           make(chan Command, 1),
+		done:           make(chan struct{}),
 	}
 	ns.dss.Store(make(map[string][]proto.DB)) // init empty map
 	ns.rule.Store(&rule.Rule{})               // init empty rule
@@ -193,6 +219,15 @@ func (ns *Namespace) DB(ctx context.Context, group string) proto.DB {
 	if len(wrList) != 0 {
 		target = selector.NewWeightRandomSelector(wrList).GetDataSourceNo()
 	}
+	// all datasources are drained, fallback to the master
+	if target == -1 {
+		for _, db := range exist {
+			if db.Weight().W > 0 {
+				return db
+			}
+		}
+		return nil
+	}
 	if len(exist) > 0 {
 		return exist[target]
 	}
@@ -250,6 +285,22 @@ func (ns *Namespace) DBSlave(_ context.Context, group string) proto.DB {
 	return nil
 }
 
+// DBReplica returns the slave DB with the given name, returns nil if it doesn't exist.
+func (ns *Namespace) DBReplica(_ context.Context, group, name string) proto.DB {
+	dss := ns.dss.Load().(map[string][]proto.DB)
+	exist, ok := dss[group]
+	if !ok {
+		return nil
+	}
+	// the drained slave can also be reached, it is useful to verify the data of an offline replica
+	for _, db := range exist {
+		if db.Weight().W == 0 && db.ID() == name {
+			return db
+		}
+	}
+	return nil
+}
+
 // SysDB returns SysDB
 func (ns *Namespace) SysDB() proto.DB {
 	return ns.sysDb
@@ -264,6 +315,18 @@ func (ns *Namespace) Rule() *rule.Rule {
 	return ru
 }
 
+// storeRule stores the rule as the next version, it must be called with the lock held.
+func (ns *Namespace) storeRule(ru *rule.Rule) {
+	ru.SetVersion(ns.Rule().Version() + 1)
+	ns.rule.Store(ru)
+
+	log.Infof("[%s] update rule successfully: version=%d", ns.name, ru.Version())
+
+	for _, l := range _ruleListeners {
+		l(ns, ru)
+	}
+}
+
 func (ns *Namespace) Parameters() config.ParametersMap {
 	return ns.parameters
 }
@@ -272,6 +335,26 @@ func (ns *Namespace) SlowThreshold() time.Duration {
 	return ns.slowThreshold
 }
 
+// SlowSampleRate returns the ratio of slow queries which will be logged, in range (0,1].
+func (ns *Namespace) SlowSampleRate() float64 {
+	return ns.slowSampleRate
+}
+
+// ReadRetries returns the max retry times of failover for an idempotent read request.
+func (ns *Namespace) ReadRetries() int {
+	return ns.readRetries
+}
+
+// ReadRetryBackoff returns the backoff before retrying on the same node, it will be doubled after each retry.
+func (ns *Namespace) ReadRetryBackoff() time.Duration {
+	return ns.retryBackoff
+}
+
+// QueryMemoryLimit returns the max bytes of rows buffered in proxy by each query, zero means no limit.
+func (ns *Namespace) QueryMemoryLimit() int64 {
+	return ns.memoryLimit
+}
+
 func (ns *Namespace) SlowLogger() log.Logger {
 	return ns.slowLog
 }
@@ -31,7 +31,10 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/config"
+	"github.com/arana-db/arana/pkg/constants"
 	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
 	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/testdata"
 )
@@ -163,3 +166,156 @@ func TestGetDBByWeight(t *testing.T) {
 	ctx = rcontext.WithWrite(context.Background())
 	assert.NotNil(t, ns.DB(ctx, getGroup(0)))
 }
+
+func TestGetDBDrained(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newDB := func(i int, weight *proto.Weight) proto.DB {
+		db := testdata.NewMockDB(ctrl)
+		db.EXPECT().ID().Return(fmt.Sprintf("the-mysql-instance-%d", i)).AnyTimes()
+		db.EXPECT().Weight().DoAndReturn(func() proto.Weight {
+			return *weight
+		}).AnyTimes()
+		db.EXPECT().Close().AnyTimes()
+		return db
+	}
+
+	var (
+		master   = &proto.Weight{R: 0, W: 10}
+		replica1 = &proto.Weight{R: 10, W: 0}
+		replica2 = &proto.Weight{R: 0, W: 0} // drained
+		db1      = newDB(1, master)
+		db2      = newDB(2, replica1)
+		db3      = newDB(3, replica2)
+	)
+
+	ns, err := New("account",
+		UpsertDB(getGroup(0), db1),
+		UpsertDB(getGroup(0), db2),
+		UpsertDB(getGroup(0), db3),
+	)
+	assert.NoError(t, err)
+	defer ns.Close()
+
+	ctx := rcontext.WithRead(context.Background())
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, db2, ns.DB(ctx, getGroup(0)))
+	}
+
+	// drain all replicas, fallback to the master
+	replica1.R = 0
+	assert.Equal(t, db1, ns.DB(ctx, getGroup(0)))
+
+	// undrain
+	replica2.R = 5
+	assert.Equal(t, db3, ns.DB(ctx, getGroup(0)))
+}
+
+func TestUpdateSlowSampleRate(t *testing.T) {
+	for _, it := range []struct {
+		value  string
+		expect float64
+	}{
+		{"0.1", 0.1},
+		{"1", 1},
+		{"0", DefaultSlowSampleRate},
+		{"1.5", DefaultSlowSampleRate},
+		{"bad", DefaultSlowSampleRate},
+	} {
+		t.Run(it.value, func(t *testing.T) {
+			ns, err := New("employees",
+				UpdateParameters(config.ParametersMap{constants.SlowSampleRate: it.value}),
+				UpdateSlowSampleRate(),
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, it.expect, ns.SlowSampleRate())
+		})
+	}
+}
+
+func TestUpdateQueryMemoryLimit(t *testing.T) {
+	for _, it := range []struct {
+		value  string
+		expect int64
+	}{
+		{"64MB", 64 << 20},
+		{"1G", 1 << 30},
+		{"bad", 0},
+	} {
+		t.Run(it.value, func(t *testing.T) {
+			ns, err := New("employees",
+				UpdateParameters(config.ParametersMap{constants.QueryMemoryLimit: it.value}),
+				UpdateQueryMemoryLimit(),
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, it.expect, ns.QueryMemoryLimit())
+		})
+	}
+}
+
+func TestUpdateRule(t *testing.T) {
+	var ru rule.Rule
+	ru.SetVTable("student", &rule.VTable{})
+
+	ns, err := New("employees", UpdateRule(&ru))
+	assert.NoError(t, err)
+	defer func() {
+		_ = ns.Close()
+	}()
+
+	// the snapshot of in-flight queries
+	prev := ns.Rule()
+	assert.Equal(t, uint64(1), prev.Version())
+
+	var vt rule.VTable
+	assert.NoError(t, UpsertVTable("teacher", &vt)(ns))
+	assert.Equal(t, uint64(2), ns.Rule().Version())
+	assert.True(t, ns.Rule().Has("teacher"))
+	assert.True(t, ns.Rule().Has("student"))
+	// the previous rule is not changed
+	assert.False(t, prev.Has("teacher"))
+	assert.Equal(t, uint64(1), prev.Version())
+
+	assert.NoError(t, RemoveVTable("student")(ns))
+	assert.Equal(t, uint64(3), ns.Rule().Version())
+	assert.False(t, ns.Rule().Has("student"))
+	assert.True(t, prev.Has("student"))
+
+	// nothing changed
+	assert.NoError(t, RemoveVTable("student")(ns))
+	assert.Equal(t, uint64(3), ns.Rule().Version())
+
+	assert.NoError(t, UpdateSchemaRoutes([]*rule.SchemaRoute{{Prefix: "tenant_", Group: "employees_tenant"}})(ns))
+	assert.Equal(t, uint64(4), ns.Rule().Version())
+	group, ok := ns.Rule().RouteSchema("tenant_acme")
+	assert.True(t, ok)
+	assert.Equal(t, "employees_tenant", group)
+	_, ok = prev.RouteSchema("tenant_acme")
+	assert.False(t, ok)
+
+	// the version keeps increasing even if the whole rule is replaced
+	assert.NoError(t, ns.EnqueueCommand(UpdateRule(&rule.Rule{})))
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, uint64(5), ns.Rule().Version())
+	assert.False(t, ns.Rule().Has("teacher"))
+}
+
+func TestAddRuleListener(t *testing.T) {
+	var versions []uint64
+	AddRuleListener(func(_ *Namespace, ru *rule.Rule) {
+		versions = append(versions, ru.Version())
+	})
+	defer func() {
+		_ruleListeners = _ruleListeners[:len(_ruleListeners)-1]
+	}()
+
+	ns, err := New("employees", UpdateRule(&rule.Rule{}))
+	assert.NoError(t, err)
+	defer func() {
+		_ = ns.Close()
+	}()
+
+	assert.NoError(t, UpsertVTable("student", &rule.VTable{})(ns))
+	assert.Equal(t, []uint64{1, 2}, versions)
+}
@@ -28,6 +28,8 @@ import (
 )
 
 import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/runtime/ast"
 	rcontext "github.com/arana-db/arana/pkg/runtime/context"
@@ -49,7 +51,12 @@ func optimizeKill(ctx context.Context, o *optimize.Optimizer) (proto.Plan, error
 	stmt := o.Stmt.(*ast.KillStmt)
 	ret := dal.NewKillPlan(stmt)
 
-	processId, groupId := math.DecodeProcessID(int64(stmt.ConnectionID), math.DefaultBase)
+	// the process id listed by SHOW PROCESSLIST is encoded with the index of db group, eg: <thread_id><group_id>
+	id := stmt.ConnectionID
+	processId, groupId := math.DecodeProcessID(int64(id), math.DefaultBase)
+	if processId <= 0 {
+		return nil, errUnknownThread(id)
+	}
 	stmt.ConnectionID = uint64(processId)
 
 	tenant := rcontext.Tenant(ctx)
@@ -66,12 +73,16 @@ func optimizeKill(ctx context.Context, o *optimize.Optimizer) (proto.Plan, error
 			continue
 		}
 
-		t, err := strconv.ParseInt(strs[1], 10, 64)
+		t, err := strconv.ParseInt(strs[len(strs)-1], 10, 64)
 		if err == nil && t == groupId {
 			ret.SetDatabase(group)
 			return ret, nil
 		}
 	}
 
-	return nil, perrors.New("can't find a proper db group")
+	return nil, errUnknownThread(id)
+}
+
+func errUnknownThread(id uint64) error {
+	return mysqlErrors.NewSQLError(consts.ERNoSuchThread, consts.SSUnknownSQLState, "Unknown thread id: %d", id)
 }
@@ -34,6 +34,7 @@ func init() {
 
 func optimizeShowVariables(_ context.Context, o *optimize.Optimizer) (proto.Plan, error) {
 	ret := dal.NewShowVariablesPlan(o.Stmt.(*ast.ShowVariables))
+	ret.RuleVersion = o.Rule.Version()
 	ret.BindArgs(o.Args)
 	return ret, nil
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/optimize"
 	"github.com/arana-db/arana/pkg/runtime/plan/dal"
 )
@@ -44,6 +45,7 @@ func optimizeShowWarnings(ctx context.Context, o *optimize.Optimizer) (proto.Pla
 
 	ret := dal.NewShowWarningsPlan(stmt, shards)
 	ret.BindArgs(o.Args)
+	ret.Warnings = rcontext.LastWarnings(ctx)
 
 	return ret, nil
 }
@@ -78,7 +78,7 @@ func optimizeCreateTable(ctx context.Context, o *optimize.Optimizer) (proto.Plan
 	shards = vt.Topology().Enumerate()
 
 	plans := make([]proto.Plan, 0, len(shards))
-	for k, v := range shards {
+	shards.Each(func(k string, v []string) bool {
 		next := &ddl.CreateTablePlan{
 			Database: k,
 			Tables:   v,
@@ -86,7 +86,8 @@ func optimizeCreateTable(ctx context.Context, o *optimize.Optimizer) (proto.Plan
 		}
 		next.BindArgs(o.Args)
 		plans = append(plans, next)
-	}
+		return true
+	})
 
 	tmpPlan := &dml.CompositePlan{
 		Plans: plans,
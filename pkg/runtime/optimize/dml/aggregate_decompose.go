@@ -0,0 +1,128 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"github.com/arana-db/parser/opcode"
+
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/runtime/ast"
+)
+
+// aggregateDecomposer decomposes an aggregate function into the partial aggregates which will be pushed down
+// to the shards and merged, and the final expression which is computed from the merged partial results.
+type aggregateDecomposer func(args []*ast.FunctionArg) (partials []*ast.AggrFunction, final ast.ExpressionAtom, err error)
+
+// _decomposableAggregates contains the aggregate functions which cannot be merged directly, the aggregate
+// functions which are not included will be pushed down as they are, eg: SUM, COUNT, MAX, MIN, BIT_OR.
+var _decomposableAggregates = map[string]aggregateDecomposer{
+	ast.AggrAvg: func(args []*ast.FunctionArg) ([]*ast.AggrFunction, ast.ExpressionAtom, error) {
+		var (
+			sum = ast.NewAggrFunction(ast.AggrSum, "", args)
+			cnt = ast.NewAggrFunction(ast.AggrCount, "", args)
+		)
+		// AVG(x) = SUM(x) / COUNT(x)
+		return []*ast.AggrFunction{sum, cnt}, divide(aggregateAtom(sum), aggregateAtom(cnt)), nil
+	},
+	ast.AggrVarPop: func(args []*ast.FunctionArg) ([]*ast.AggrFunction, ast.ExpressionAtom, error) {
+		return decomposeVariance(args, false)
+	},
+	ast.AggrVarSamp: func(args []*ast.FunctionArg) ([]*ast.AggrFunction, ast.ExpressionAtom, error) {
+		return decomposeVariance(args, true)
+	},
+	ast.AggrStddevPop: func(args []*ast.FunctionArg) ([]*ast.AggrFunction, ast.ExpressionAtom, error) {
+		return decomposeStddev(args, false)
+	},
+	ast.AggrStddevSamp: func(args []*ast.FunctionArg) ([]*ast.AggrFunction, ast.ExpressionAtom, error) {
+		return decomposeStddev(args, true)
+	},
+}
+
+// _placeholderAggregates contains the decomposed aggregate functions which are not selected from the shards,
+// the first partial aggregate is selected in place of them, eg: VAR_POP(x) is selected for STDDEV_SAMP(x).
+var _placeholderAggregates = map[string]struct{}{
+	ast.AggrVarPop:     {},
+	ast.AggrVarSamp:    {},
+	ast.AggrStddevPop:  {},
+	ast.AggrStddevSamp: {},
+}
+
+// decomposeVariance decomposes the variance into VAR_POP(x), COUNT(x) and SUM(x) of each shard, the population
+// variances are merged with the counts and sums, see aggregator.VarianceAggregator:
+//
+//	VAR_POP(x)  = VAR_POP(x)
+//	VAR_SAMP(x) = VAR_POP(x)*COUNT(x)/(COUNT(x)-1)
+func decomposeVariance(args []*ast.FunctionArg, sample bool) ([]*ast.AggrFunction, ast.ExpressionAtom, error) {
+	if len(args) != 1 {
+		return nil, nil, errors.Errorf("incorrect parameter count of variance: %d", len(args))
+	}
+
+	var (
+		varPop = ast.NewAggrFunction(ast.AggrVarPop, "", args)
+		cnt    = ast.NewAggrFunction(ast.AggrCount, "", args)
+		sum    = ast.NewAggrFunction(ast.AggrSum, "", args)
+	)
+
+	partials := []*ast.AggrFunction{varPop, cnt, sum}
+	if !sample {
+		return partials, aggregateAtom(varPop), nil
+	}
+
+	n := &ast.MathExpressionAtom{
+		Left:     aggregateAtom(cnt),
+		Operator: opcode.Minus.Literal(),
+		Right:    &ast.ConstantExpressionAtom{Inner: int64(1)},
+	}
+	return partials, divide(&ast.MathExpressionAtom{
+		Left:     aggregateAtom(varPop),
+		Operator: opcode.Mul.Literal(),
+		Right:    aggregateAtom(cnt),
+	}, n), nil
+}
+
+// decomposeStddev decomposes the standard deviation as the square root of variance.
+func decomposeStddev(args []*ast.FunctionArg, sample bool) ([]*ast.AggrFunction, ast.ExpressionAtom, error) {
+	partials, variance, err := decomposeVariance(args, sample)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	sqrt := ast.NewFunction("SQRT", []*ast.FunctionArg{
+		{
+			Type: ast.FunctionArgExpression,
+			Value: &ast.PredicateExpressionNode{
+				P: &ast.AtomPredicateNode{A: variance},
+			},
+		},
+	})
+	return partials, &ast.FunctionCallExpressionAtom{F: sqrt}, nil
+}
+
+func aggregateAtom(f *ast.AggrFunction) ast.ExpressionAtom {
+	return &ast.FunctionCallExpressionAtom{F: f}
+}
+
+func divide(left, right ast.ExpressionAtom) ast.ExpressionAtom {
+	return &ast.MathExpressionAtom{
+		Left:     left,
+		Operator: opcode.Div.Literal(),
+		Right:    right,
+	}
+}
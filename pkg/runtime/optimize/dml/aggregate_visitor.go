@@ -23,20 +23,19 @@ import (
 )
 
 import (
-	"github.com/arana-db/parser/opcode"
-
 	"github.com/pkg/errors"
 )
 
 import (
 	"github.com/arana-db/arana/pkg/runtime/ast"
-	"github.com/arana-db/arana/pkg/runtime/optimize/dml/ext"
+	"github.com/arana-db/arana/pkg/runtime/ast/ext"
 )
 
 type aggregateVisitor struct {
 	ast.AlwaysReturnSelfVisitor
 	hasMapping   bool
 	hasWeak      bool
+	hasDistinct  bool
 	aggregations []*ast.SelectElementFunction
 }
 
@@ -77,6 +76,11 @@ func (av *aggregateVisitor) VisitSelectStatement(node *ast.SelectStatement) (int
 		for _, rebuild := range rebuilds {
 			var next *ast.AggrFunction
 
+			if m, ok := rebuild.(*ext.MappingSelectElement); ok && m.Placeholder != nil {
+				// the placeholder is selected instead
+				rebuild = m.Placeholder
+			}
+
 			switch t := rebuild.(type) {
 			case *ast.SelectElementFunction:
 				switch f := t.Function().(type) {
@@ -128,6 +132,19 @@ func (av *aggregateVisitor) VisitSelectStatement(node *ast.SelectStatement) (int
 }
 
 func (av *aggregateVisitor) VisitSelectElementFunction(node *ast.SelectElementFunction) (interface{}, error) {
+	if f, ok := node.Function().(*ast.AggrFunction); ok && isDistinctAggregate(f) {
+		arg, err := toDistinctArg(f)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		av.aggregations = append(av.aggregations, node)
+		av.hasDistinct = true
+		return &ext.DistinctAggrSelectElement{
+			SelectElement: node,
+			Arg:           arg,
+		}, nil
+	}
+
 	before := len(av.aggregations)
 	v, err := node.Function().Accept(av)
 	if err != nil {
@@ -144,6 +161,11 @@ func (av *aggregateVisitor) VisitSelectElementFunction(node *ast.SelectElementFu
 		var vs ext.MappingSelectElement
 		vs.SelectElement = node
 		vs.Mapping = ast.NewSelectElementExpr(expr, "")
+		if f, ok := node.Function().(*ast.AggrFunction); ok {
+			if _, ok = _placeholderAggregates[f.Name()]; ok {
+				vs.Placeholder = av.aggregations[before]
+			}
+		}
 		av.hasMapping = true
 		return &vs, nil
 	default:
@@ -192,30 +214,53 @@ func (av *aggregateVisitor) VisitSelectElementExpr(node *ast.SelectElementExpr)
 }
 
 func (av *aggregateVisitor) VisitFunctionAggregate(node *ast.AggrFunction) (interface{}, error) {
-	switch node.Name() {
-	case ast.AggrAvg:
-		var (
-			sumFunc  = ast.NewAggrFunction(ast.AggrSum, "", node.Args())
-			cntFunc  = ast.NewAggrFunction(ast.AggrCount, "", node.Args())
-			sumField = ast.NewSelectElementAggrFunction(sumFunc, "")
-			cntField = ast.NewSelectElementAggrFunction(cntFunc, "")
-		)
-		av.aggregations = append(av.aggregations, sumField, cntField)
-		return &ast.MathExpressionAtom{
-			Left:     &ast.FunctionCallExpressionAtom{F: sumFunc},
-			Operator: opcode.Div.Literal(),
-			Right:    &ast.FunctionCallExpressionAtom{F: cntFunc},
-		}, nil
-	case ast.AggrCount:
-		if aggregator, ok := node.Aggregator(); ok {
-			// TODO: count distinct
-			return nil, errors.Errorf("todo: handle COUNT with aggregator '%s'", aggregator)
+	if isDistinctAggregate(node) {
+		// TODO: support distinct aggregate within expression, eg: SUM(DISTINCT x)+1
+		return nil, errors.Errorf("todo: handle %s(DISTINCT) within expression", node.Name())
+	}
+
+	if decompose, ok := _decomposableAggregates[node.Name()]; ok {
+		partials, final, err := decompose(node.Args())
+		if err != nil {
+			return nil, errors.WithStack(err)
 		}
-		fallthrough
+		for _, partial := range partials {
+			av.aggregations = append(av.aggregations, ast.NewSelectElementAggrFunction(partial, ""))
+		}
+		return final, nil
+	}
+
+	newborn := ast.NewSelectElementAggrFunction(node, "")
+	av.aggregations = append(av.aggregations, newborn)
+	return node, nil
+}
+
+// isDistinctAggregate returns true if the values should be deduplicated globally before aggregating.
+// MIN and MAX are not included, since the result is same with or without DISTINCT.
+func isDistinctAggregate(f *ast.AggrFunction) bool {
+	if aggregator, ok := f.Aggregator(); !ok || aggregator != ast.Distinct {
+		return false
+	}
+	switch f.Name() {
+	case ast.AggrSum, ast.AggrAvg, ast.AggrCount:
+		return true
+	}
+	return false
+}
+
+// toDistinctArg converts the argument of distinct aggregate to select element, eg: SUM(DISTINCT amount) -> amount
+func toDistinctArg(f *ast.AggrFunction) (ast.SelectElement, error) {
+	args := f.Args()
+	if len(args) != 1 {
+		return nil, errors.Errorf("todo: handle %s(DISTINCT) with %d arguments", f.Name(), len(args))
+	}
+	switch args[0].Type {
+	case ast.FunctionArgColumn:
+		return ast.NewSelectElementColumn(args[0].Value.(ast.ColumnNameExpressionAtom), ""), nil
+	case ast.FunctionArgExpression:
+		return ast.NewSelectElementExpr(args[0].Value.(ast.ExpressionNode), ""), nil
 	default:
-		newborn := ast.NewSelectElementAggrFunction(node, "")
-		av.aggregations = append(av.aggregations, newborn)
-		return node, nil
+		return nil, errors.Errorf("todo: handle %s(DISTINCT) with argument type %d", f.Name(), args[0].Type)
 	}
 }
 
@@ -0,0 +1,251 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/cmp"
+	"github.com/arana-db/arana/pkg/runtime/optimize"
+	"github.com/arana-db/arana/pkg/runtime/plan/dml"
+)
+
+// colocatedPair represents the physical tables of two joined tables in the same database.
+type colocatedPair struct {
+	db, left, right string
+}
+
+// optimizeColocatedInJoin pushes down the join on the sharding keys with an IN list of the key,
+// eg: 'SELECT * FROM a JOIN b ON a.key = b.key WHERE a.key IN (1,50,99)'.
+// Each value of IN routes both tables to one shard, the rows joined by the value can only live in the pair of
+// physical tables, so the join is executed by each pair separately and the joined rows are merged.
+//
+// It only applies to the plain queries without hints, the GROUP BY, ORDER BY, LIMIT, DISTINCT and aggregates
+// are left to the generic join, which merges the rows in arana. It returns false if the values are routed to
+// different databases, eg: the tables are not co-located.
+func optimizeColocatedInJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectStatement, where ast.ExpressionNode) (proto.Plan, bool, error) {
+	if len(o.Hints) > 0 || stmt.GroupBy != nil || stmt.Having != nil || stmt.OrderBy != nil || stmt.Limit != nil || stmt.Distinct {
+		return nil, false, nil
+	}
+	for _, sel := range stmt.Select {
+		if hasAggregate(sel) {
+			return nil, false, nil
+		}
+	}
+
+	from := stmt.From[0]
+	join := from.Joins[0]
+	tableLeft, ok := from.Source.(ast.TableName)
+	if !ok {
+		return nil, false, nil
+	}
+	tableRight, ok := join.Target.Source.(ast.TableName)
+	if !ok {
+		return nil, false, nil
+	}
+	vtLeft, ok := o.Rule.VTable(tableLeft.Suffix())
+	if !ok {
+		return nil, false, nil
+	}
+	vtRight, ok := o.Rule.VTable(tableRight.Suffix())
+	if !ok {
+		return nil, false, nil
+	}
+
+	aliasLeft, aliasRight := from.Alias, join.Target.Alias
+	if len(aliasLeft) == 0 {
+		aliasLeft = tableLeft.Suffix()
+	}
+	if len(aliasRight) == 0 {
+		aliasRight = tableRight.Suffix()
+	}
+
+	keyLeft, keyRight, ok := findJoinShardKeys(join.On, vtLeft, aliasLeft, vtRight, aliasRight)
+	if !ok {
+		return nil, false, nil
+	}
+	in := findKeyInList(where, aliasLeft, keyLeft, aliasRight, keyRight)
+	if in == nil {
+		return nil, false, nil
+	}
+
+	var (
+		sharder = optimize.NewXSharder(ctx, o.Rule, o.Args)
+		visited = make(map[colocatedPair]struct{})
+		pairs   []colocatedPair
+	)
+	route := func(table ast.TableName, key string, value ast.PredicateNode) (string, string, bool, error) {
+		shards, err := sharder.SimpleShard(table, &ast.PredicateExpressionNode{
+			P: &ast.BinaryComparisonPredicateNode{
+				Left:  &ast.AtomPredicateNode{A: ast.ColumnNameExpressionAtom{key}},
+				Op:    cmp.Ceq,
+				Right: value,
+			},
+		})
+		if err != nil {
+			return "", "", false, errors.WithStack(err)
+		}
+		if shards.Len() != 1 {
+			return "", "", false, nil
+		}
+		db, tbl := shards.Smallest()
+		return db, tbl, true, nil
+	}
+	for _, e := range in.E {
+		value, ok := e.(*ast.PredicateExpressionNode)
+		if !ok {
+			return nil, false, nil
+		}
+		db0, tbl0, ok, err := route(tableLeft, keyLeft, value.P)
+		if !ok || err != nil {
+			return nil, false, err
+		}
+		db1, tbl1, ok, err := route(tableRight, keyRight, value.P)
+		if !ok || err != nil {
+			return nil, false, err
+		}
+		if db0 != db1 {
+			return nil, false, nil
+		}
+		pair := colocatedPair{db: db0, left: tbl0, right: tbl1}
+		if _, ok := visited[pair]; ok {
+			continue
+		}
+		visited[pair] = struct{}{}
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].db != pairs[j].db {
+			return pairs[i].db < pairs[j].db
+		}
+		if pairs[i].left != pairs[j].left {
+			return pairs[i].left < pairs[j].left
+		}
+		return pairs[i].right < pairs[j].right
+	})
+
+	plans := make([]proto.Plan, 0, len(pairs))
+	for _, it := range pairs {
+		next := &dml.SimpleJoinPlan{
+			Database: it.db,
+			Left: &dml.JoinTable{
+				Tables: []string{it.left},
+				Alias:  aliasLeft,
+			},
+			Join: join,
+			Right: &dml.JoinTable{
+				Tables: []string{it.right},
+				Alias:  aliasRight,
+			},
+			Stmt: stmt,
+		}
+		next.BindArgs(o.Args)
+		plans = append(plans, next)
+	}
+	if len(plans) == 1 {
+		return plans[0], true, nil
+	}
+	return &dml.CompositePlan{Plans: plans}, true, nil
+}
+
+// findJoinShardKeys returns the sharding keys of a conjunctive 'left.key = right.key' condition in ON clause.
+func findJoinShardKeys(on ast.ExpressionNode, vtLeft *rule.VTable, aliasLeft string, vtRight *rule.VTable, aliasRight string) (string, string, bool) {
+	switch node := on.(type) {
+	case *ast.LogicalExpressionNode:
+		if node.Or {
+			return "", "", false
+		}
+		if l, r, ok := findJoinShardKeys(node.Left, vtLeft, aliasLeft, vtRight, aliasRight); ok {
+			return l, r, true
+		}
+		return findJoinShardKeys(node.Right, vtLeft, aliasLeft, vtRight, aliasRight)
+	case *ast.PredicateExpressionNode:
+		bc, ok := node.P.(*ast.BinaryComparisonPredicateNode)
+		if !ok || bc.Op != cmp.Ceq {
+			return "", "", false
+		}
+		column := func(p ast.PredicateNode) ast.ColumnNameExpressionAtom {
+			if atom, ok := p.(*ast.AtomPredicateNode); ok {
+				if c, ok := atom.Column(); ok {
+					return c
+				}
+			}
+			return nil
+		}
+		l, r := column(bc.Left), column(bc.Right)
+		if l == nil || r == nil {
+			return "", "", false
+		}
+		if strings.EqualFold(l.Prefix(), aliasRight) && strings.EqualFold(r.Prefix(), aliasLeft) {
+			l, r = r, l
+		}
+		if !strings.EqualFold(l.Prefix(), aliasLeft) || !strings.EqualFold(r.Prefix(), aliasRight) {
+			return "", "", false
+		}
+		keyLeft, keyRight := vtLeft.NormalizeColumn(l.Suffix()), vtRight.NormalizeColumn(r.Suffix())
+		if vtLeft.GetShardColumn(keyLeft) == nil || vtRight.GetShardColumn(keyRight) == nil {
+			return "", "", false
+		}
+		return keyLeft, keyRight, true
+	}
+	return "", "", false
+}
+
+// findKeyInList returns the conjunctive 'key IN (...)' predicate of either joined table in WHERE clause.
+func findKeyInList(where ast.ExpressionNode, aliasLeft, keyLeft, aliasRight, keyRight string) *ast.InPredicateNode {
+	switch node := where.(type) {
+	case *ast.LogicalExpressionNode:
+		if node.Or {
+			return nil
+		}
+		if in := findKeyInList(node.Left, aliasLeft, keyLeft, aliasRight, keyRight); in != nil {
+			return in
+		}
+		return findKeyInList(node.Right, aliasLeft, keyLeft, aliasRight, keyRight)
+	case *ast.PredicateExpressionNode:
+		in, ok := node.P.(*ast.InPredicateNode)
+		if !ok || in.Not || in.Sub != nil || len(in.E) == 0 {
+			return nil
+		}
+		atom, ok := in.P.(*ast.AtomPredicateNode)
+		if !ok {
+			return nil
+		}
+		c, ok := atom.Column()
+		if !ok {
+			return nil
+		}
+		if (strings.EqualFold(c.Prefix(), aliasLeft) && strings.EqualFold(c.Suffix(), keyLeft)) ||
+			(strings.EqualFold(c.Prefix(), aliasRight) && strings.EqualFold(c.Suffix(), keyRight)) {
+			return in
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/cmp"
+	"github.com/arana-db/arana/pkg/runtime/optimize"
+)
+
+// collectScalarSubqueries returns the scalar subqueries of select elements, WHERE and HAVING clause,
+// eg: 'SELECT ... FROM orders o WHERE o.amount > (SELECT AVG(amount) FROM orders WHERE user_id = o.user_id)'.
+func collectScalarSubqueries(stmt *ast.SelectStatement) []*ast.SubqueryExpressionAtom {
+	var ret []*ast.SubqueryExpressionAtom
+	collect := func(node ast.Node) {
+		if sq, ok := node.(*ast.SubqueryExpressionAtom); ok {
+			ret = append(ret, sq)
+		}
+	}
+	for _, sel := range stmt.Select {
+		switch it := sel.(type) {
+		case *ast.SelectElementFunction:
+			ast.Inspect(it.Function(), collect)
+		case *ast.SelectElementExpr:
+			ast.Inspect(it.Expression(), collect)
+		}
+	}
+	if stmt.Where != nil {
+		ast.Inspect(stmt.Where, collect)
+	}
+	if stmt.Having != nil {
+		ast.Inspect(stmt.Having, collect)
+	}
+	return ret
+}
+
+// resolveScalarSubqueries rewrites the tables of scalar subqueries to the physical tables which live in
+// the shard 'db.tbl' of outer query, so the whole statement can be pushed down to the single shard.
+//
+// A subquery of the same sharded table is resolved to 'tbl' only if it is correlated by the sharding key,
+// eg: 'WHERE user_id = o.user_id', which means all the rows it reads live in the same shard as the outer row.
+// A subquery of another sharded table is resolved only if its own conditions route it to one table of 'db'.
+// The non-sharded tables are kept as they are.
+func resolveScalarSubqueries(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectStatement, db, tbl string) error {
+	var (
+		outer     = stmt.From[0].Source.(ast.TableName)
+		outerVt   = o.Rule.MustVTable(outer.Suffix())
+		qualifier = stmt.From[0].Alias
+	)
+	if len(qualifier) == 0 {
+		qualifier = outer.Suffix()
+	}
+
+	for _, sq := range collectScalarSubqueries(stmt) {
+		sub := sq.Sub
+		if len(sub.From) != 1 || len(sub.From[0].Joins) > 0 {
+			return errors.New("scalar subquery with joins or derived tables is not supported")
+		}
+		inner, ok := sub.From[0].Source.(ast.TableName)
+		if !ok {
+			return errors.New("scalar subquery with joins or derived tables is not supported")
+		}
+		innerVt, ok := o.Rule.VTable(inner.Suffix())
+		if !ok {
+			continue
+		}
+
+		if innerVt == outerVt {
+			innerQualifier := sub.From[0].Alias
+			if len(innerQualifier) == 0 {
+				innerQualifier = inner.Suffix()
+			}
+			if !isCorrelatedBySharding(outerVt, qualifier, innerQualifier, sub.Where) {
+				return errors.Errorf("scalar subquery of table '%s' is not supported unless it is correlated by the sharding key", inner.Suffix())
+			}
+			sub.From[0].ResetTableName(tbl)
+			continue
+		}
+
+		shards, err := optimize.NewXSharder(ctx, o.Rule, o.Args).SimpleShard(inner, sub.Where)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if shards.Len() != 1 || len(shards[db]) != 1 {
+			return errors.Errorf("scalar subquery of table '%s' is not supported unless it is routed to the same database '%s'", inner.Suffix(), db)
+		}
+		sub.From[0].ResetTableName(shards[db][0])
+	}
+	return nil
+}
+
+// isCorrelatedBySharding returns true if the WHERE clause has a conjunctive 'inner.key = outer.key' condition,
+// the key is a sharding column of the table, the inner column may be unqualified.
+func isCorrelatedBySharding(vt *rule.VTable, outer, inner string, where ast.ExpressionNode) bool {
+	switch node := where.(type) {
+	case *ast.LogicalExpressionNode:
+		if node.Or {
+			return false
+		}
+		return isCorrelatedBySharding(vt, outer, inner, node.Left) || isCorrelatedBySharding(vt, outer, inner, node.Right)
+	case *ast.PredicateExpressionNode:
+		bc, ok := node.P.(*ast.BinaryComparisonPredicateNode)
+		if !ok || bc.Op != cmp.Ceq {
+			return false
+		}
+		column := func(p ast.PredicateNode) (ast.ColumnNameExpressionAtom, bool) {
+			atom, ok := p.(*ast.AtomPredicateNode)
+			if !ok {
+				return nil, false
+			}
+			c, ok := atom.Column()
+			if !ok || vt.GetShardColumn(vt.NormalizeColumn(c.Suffix())) == nil {
+				return nil, false
+			}
+			return c, true
+		}
+		left, ok := column(bc.Left)
+		if !ok {
+			return false
+		}
+		right, ok := column(bc.Right)
+		if !ok || vt.NormalizeColumn(left.Suffix()) != vt.NormalizeColumn(right.Suffix()) {
+			return false
+		}
+		isOuter := func(c ast.ColumnNameExpressionAtom) bool {
+			// the reference is ambiguous if both tables share the same qualifier
+			return strings.EqualFold(c.Prefix(), outer) && !strings.EqualFold(outer, inner)
+		}
+		isInner := func(c ast.ColumnNameExpressionAtom) bool {
+			return len(c.Prefix()) == 0 || strings.EqualFold(c.Prefix(), inner)
+		}
+		return (isOuter(left) && isInner(right)) || (isOuter(right) && isInner(left))
+	}
+	return false
+}
@@ -40,6 +40,21 @@ func init() {
 func optimizeDelete(ctx context.Context, o *optimize.Optimizer) (proto.Plan, error) {
 	stmt := o.Stmt.(*ast.DeleteStatement)
 
+	if ret, ok := optimizeSchemaRoute(o, stmt.Table); ok {
+		return ret, nil
+	}
+
+	vt, ok := o.Rule.VTable(stmt.Table.Suffix())
+	if !ok {
+		return plan.Transparent(stmt, o.Args), nil
+	}
+
+	// an unconditional DELETE will remove all rows of all shards, block it unless full-scan is allowed
+	if stmt.Where == nil && !vt.AllowFullScan() {
+		return nil, errors.Wrapf(optimize.ErrDenyFullScan, "unconditional DELETE on table '%s' is blocked, please add a WHERE clause", stmt.Table.Suffix())
+	}
+
+	// compute shards in the same way as SELECT
 	shards, err := o.ComputeShards(ctx, stmt.Table, stmt.Where, o.Args)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to optimize DELETE statement")
@@ -47,9 +62,8 @@ func optimizeDelete(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 
 	// TODO: delete from a child sharding-table directly
 
-	if shards == nil {
-		return plan.Transparent(stmt, o.Args), nil
-	}
+	// skip the shards which definitely contain none of the deleted keys
+	shards = optimize.FilterShardsByKey(ctx, vt, shards, stmt.Where, o.Args)
 
 	ret := dml.NewSimpleDeletePlan(stmt)
 	ret.BindArgs(o.Args)
@@ -0,0 +1,233 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"strings"
+)
+
+import (
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/ast/ext"
+)
+
+// havingResult represents the HAVING condition which will be applied to the merged groups.
+type havingResult struct {
+	expr       ast.ExpressionNode
+	aggregates map[string]string // restored aggregate function => field name
+}
+
+// anaHaving moves the HAVING condition which references the aggregate values out of the statement, since each shard
+// only has partial groups. The aggregates are resolved to the select elements, so that they are computed only once:
+//
+//	SELECT dept, COUNT(*) c FROM emp GROUP BY dept HAVING COUNT(*) > 5 ORDER BY c
+//	=> HAVING `c` > 5 on the merged groups
+//
+// The missing aggregates will be appended as weak select elements. The HAVING condition which only references the
+// group keys is kept, it can be filtered by each shard.
+//
+// The HAVING condition may also reference the columns which are functionally dependent on the group keys, the
+// missing ones will be carried through the aggregation as weak select elements:
+//
+//	SELECT id, COUNT(*) c FROM emp GROUP BY id HAVING c > 1 AND name <> 'foo'
+//	=> SELECT id, COUNT(*) c, name FROM emp GROUP BY id, and HAVING `c` > 1 AND `name` <> 'foo' on the merged groups
+//
+// The supported dependencies are the columns determined by the group keys, eg: grouping by the primary key or an unique
+// key. Like MySQL with ONLY_FULL_GROUP_BY disabled, the dependency is not verified, the value of such column is taken
+// from any row of the merged group.
+func (sc *selectScanner) anaHaving(dst *selectResult) error {
+	if sc.stmt.GroupBy == nil || sc.stmt.Having == nil {
+		return nil
+	}
+
+	var (
+		aggregates []*ast.AggrFunction
+		columns    []ast.ColumnNameExpressionAtom
+		aliased    bool
+	)
+	ast.Inspect(sc.stmt.Having, func(node ast.Node) {
+		switch it := node.(type) {
+		case *ast.AggrFunction:
+			aggregates = append(aggregates, it)
+		case ast.ColumnNameExpressionAtom:
+			columns = append(columns, it)
+			if sel, ok := sc.aliasOf(it.Suffix()); ok && hasAggregate(sel) {
+				aliased = true
+			}
+		}
+	})
+
+	if len(aggregates) == 0 && !aliased {
+		return nil
+	}
+
+	var (
+		sb     strings.Builder
+		xh     *xxhash.Digest
+		result = &havingResult{
+			expr:       sc.stmt.Having,
+			aggregates: make(map[string]string, len(aggregates)),
+		}
+	)
+	for _, aggr := range aggregates {
+		if err := aggr.Restore(ast.RestoreDefault, &sb, nil); err != nil {
+			return errors.WithStack(err)
+		}
+		search := sb.String()
+		sb.Reset()
+
+		if _, ok := result.aggregates[search]; ok {
+			continue
+		}
+
+		if alias, ok := sc.aliasOfAggregate(search); ok {
+			result.aggregates[search] = alias
+			continue
+		}
+
+		if xh == nil {
+			xh = xxhash.New()
+		} else {
+			xh.Reset()
+		}
+		writeAutoAlias(xh, &sb, search)
+		alias := sb.String()
+		sb.Reset()
+
+		if err := sc.appendSelectElement(&ext.WeakSelectElement{
+			SelectElement: ast.NewSelectElementAggrFunction(aggr, alias),
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		dst.hasWeak = true
+		result.aggregates[search] = alias
+	}
+
+	for _, column := range dependentColumns(columns, aggregates) {
+		if sc.hasField(column.Suffix()) {
+			continue
+		}
+		if err := sc.appendSelectElement(&ext.WeakSelectElement{
+			SelectElement: ast.NewSelectElementColumn(column, ""),
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		dst.hasWeak = true
+	}
+
+	sc.stmt.Having = nil
+	dst.having = result
+	return nil
+}
+
+// dependentColumns returns the distinct columns which are referenced out of the aggregates.
+func dependentColumns(columns []ast.ColumnNameExpressionAtom, aggregates []*ast.AggrFunction) []ast.ColumnNameExpressionAtom {
+	counts := make(map[string]int, len(columns))
+	for _, it := range columns {
+		counts[strings.ToLower(it.Suffix())]++
+	}
+	// the arguments of aggregates, eg: SUM(salary)
+	for _, aggr := range aggregates {
+		ast.Inspect(aggr, func(node ast.Node) {
+			if it, ok := node.(ast.ColumnNameExpressionAtom); ok {
+				counts[strings.ToLower(it.Suffix())]--
+			}
+		})
+	}
+
+	var ret []ast.ColumnNameExpressionAtom
+	for _, it := range columns {
+		key := strings.ToLower(it.Suffix())
+		if counts[key] > 0 {
+			ret = append(ret, it)
+			// each column is appended only once
+			counts[key] = 0
+		}
+	}
+	return ret
+}
+
+// hasField returns true if the merged rows contain a field of the name, which is either an alias or a column.
+func (sc *selectScanner) hasField(name string) bool {
+	if _, ok := sc.aliasOf(name); ok {
+		return true
+	}
+	for _, sel := range sc.stmt.Select {
+		column, ok := sel.(*ast.SelectElementColumn)
+		if ok && len(sel.Alias()) < 1 && strings.EqualFold(column.Suffix(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// aliasOf returns the select element of alias.
+func (sc *selectScanner) aliasOf(alias string) (ast.SelectElement, bool) {
+	for _, sel := range sc.stmt.Select {
+		if len(sel.Alias()) > 0 && strings.EqualFold(sel.Alias(), alias) {
+			return sel, true
+		}
+	}
+	return nil, false
+}
+
+// aliasOfAggregate returns the alias of the select element which is same as the restored aggregate function.
+func (sc *selectScanner) aliasOfAggregate(search string) (string, bool) {
+	var sb strings.Builder
+	for _, sel := range sc.stmt.Select {
+		f, ok := sel.(*ast.SelectElementFunction)
+		if !ok || len(sel.Alias()) < 1 {
+			continue
+		}
+		aggr, ok := f.Function().(*ast.AggrFunction)
+		if !ok {
+			continue
+		}
+		if err := aggr.Restore(ast.RestoreDefault, &sb, nil); err != nil {
+			return "", false
+		}
+		if sb.String() == search {
+			return sel.Alias(), true
+		}
+		sb.Reset()
+	}
+	return "", false
+}
+
+// hasAggregate returns true if the select element contains any aggregate function.
+func hasAggregate(sel ast.SelectElement) bool {
+	var found bool
+	check := func(node ast.Node) {
+		if _, ok := node.(*ast.AggrFunction); ok {
+			found = true
+		}
+	}
+	switch it := sel.(type) {
+	case *ast.SelectElementFunction:
+		ast.Inspect(it.Function(), check)
+	case *ast.SelectElementExpr:
+		ast.Inspect(it.Expression(), check)
+	}
+	return found
+}
@@ -0,0 +1,170 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"math"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/optimize"
+	"github.com/arana-db/arana/pkg/runtime/plan/dml"
+)
+
+// _maxPlaceholders is the max count of placeholders of a prepared statement.
+const _maxPlaceholders = math.MaxUint16
+
+// keyUnmaterialized marks the subqueries of IN predicates should not be materialized.
+type keyUnmaterialized struct{}
+
+// optimizeInSubquery materializes the subquery of 'key IN (SELECT ...)' if the key is the sharding key of table,
+// then the returned values are used to prune the shards, instead of scanning all shards with the subquery.
+// For example: 'SELECT * FROM student WHERE uid IN (SELECT uid FROM premium WHERE tier = 'gold')'
+// will be executed as 'SELECT * FROM student WHERE uid IN (?,?,...)' with the values of subquery.
+//
+// The subquery which only queries the non-sharded tables is materialized whatever the key is, since the
+// non-sharded tables live in the default datasource, which cannot be seen by the shards of outer table.
+// For example: 'SELECT * FROM student WHERE region IN (SELECT code FROM regions)'.
+//
+// The values are materialized at most _maxPlaceholders in total with the other args. If there are more values, the
+// statement is executed with the subquery as it is, or fails if the subquery only queries the non-sharded tables.
+func optimizeInSubquery(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectStatement) (proto.Plan, bool, error) {
+	if ctx.Value(keyUnmaterialized{}) != nil {
+		return nil, false, nil
+	}
+
+	tableName := stmt.From[0].Source.(ast.TableName)
+	vt, ok := o.Rule.VTable(tableName.Suffix())
+	if !ok {
+		return nil, false, nil
+	}
+
+	in := findInSubquery(o.Rule, vt, stmt.Where)
+	if in == nil {
+		return nil, false, nil
+	}
+
+	// the index of placeholder is global, so all args should be passed
+	sub, err := optimizeSelect(ctx, &optimize.Optimizer{
+		Rule:  o.Rule,
+		Hints: o.Hints,
+		Stmt:  in.Sub.Clone(),
+		Args:  o.Args,
+	})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to optimize subquery of IN predicate")
+	}
+
+	ret := &dml.InSubqueryPlan{
+		Subquery:  sub,
+		MaxValues: _maxPlaceholders - len(o.Args),
+		Build: func(values []proto.Value) (proto.Plan, error) {
+			args := make([]proto.Value, len(o.Args), len(o.Args)+len(values))
+			copy(args, o.Args)
+
+			// the plan may be executed more than once, so the origin statement should be kept
+			stmt := stmt.Clone()
+			in := findInSubquery(o.Rule, vt, stmt.Where)
+
+			in.Sub = nil
+			in.E = make([]ast.ExpressionNode, 0, len(values))
+			for _, it := range values {
+				in.E = append(in.E, &ast.PredicateExpressionNode{
+					P: &ast.AtomPredicateNode{A: ast.VariableExpressionAtom(len(args))},
+				})
+				args = append(args, it)
+			}
+			// nothing matches if the subquery returns no value
+			if len(in.E) == 0 {
+				in.E = append(in.E, &ast.PredicateExpressionNode{
+					P: &ast.AtomPredicateNode{A: &ast.ConstantExpressionAtom{Inner: proto.Null{}}},
+				})
+			}
+
+			return optimizeSelect(ctx, &optimize.Optimizer{
+				Rule:  o.Rule,
+				Hints: o.Hints,
+				Stmt:  stmt,
+				Args:  args,
+			})
+		},
+	}
+
+	// the non-sharded tables cannot be queried by the shards of outer table
+	if !isUnsharded(o.Rule, in.Sub) {
+		ret.Fallback = func() (proto.Plan, error) {
+			return optimizeSelect(context.WithValue(ctx, keyUnmaterialized{}, true), &optimize.Optimizer{
+				Rule:  o.Rule,
+				Hints: o.Hints,
+				Stmt:  stmt.Clone(),
+				Args:  o.Args,
+			})
+		}
+	}
+
+	return ret, true, nil
+}
+
+// findInSubquery returns the conjunctive 'key IN (SELECT ...)' predicate which can be materialized,
+// that is, the key is the sharding key of table, or the subquery only queries the non-sharded tables.
+func findInSubquery(ru *rule.Rule, vt *rule.VTable, where ast.ExpressionNode) *ast.InPredicateNode {
+	switch node := where.(type) {
+	case *ast.LogicalExpressionNode:
+		if node.Or {
+			return nil
+		}
+		if in := findInSubquery(ru, vt, node.Left); in != nil {
+			return in
+		}
+		return findInSubquery(ru, vt, node.Right)
+	case *ast.PredicateExpressionNode:
+		in, ok := node.P.(*ast.InPredicateNode)
+		if !ok || in.Not || in.Sub == nil {
+			return nil
+		}
+		atom, ok := in.P.(*ast.AtomPredicateNode)
+		if !ok {
+			return nil
+		}
+		cn, ok := atom.Column()
+		if !ok {
+			return nil
+		}
+		if vt.GetShardColumn(vt.NormalizeColumn(cn.Suffix())) != nil || isUnsharded(ru, in.Sub) {
+			return in
+		}
+	}
+	return nil
+}
+
+// isUnsharded returns true if the SELECT only queries a non-sharded table, the joins and derived tables are excluded.
+func isUnsharded(ru *rule.Rule, stmt *ast.SelectStatement) bool {
+	if len(stmt.From) != 1 || len(stmt.From[0].Joins) > 0 {
+		return false
+	}
+	tn, ok := stmt.From[0].Source.(ast.TableName)
+	return ok && len(tn) == 1 && !ru.Has(tn.Suffix())
+}
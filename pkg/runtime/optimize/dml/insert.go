@@ -19,6 +19,7 @@ package dml
 
 import (
 	"context"
+	"strings"
 )
 
 import (
@@ -28,11 +29,14 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/constants/mysql"
+	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/runtime/ast"
 	"github.com/arana-db/arana/pkg/runtime/cmp"
 	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+	"github.com/arana-db/arana/pkg/runtime/misc/extvalue"
 	"github.com/arana-db/arana/pkg/runtime/optimize"
 	"github.com/arana-db/arana/pkg/runtime/plan/dml"
 )
@@ -40,30 +44,156 @@ import (
 func init() {
 	optimize.Register(ast.SQLTypeInsert, optimizeInsert)
 	optimize.Register(ast.SQLTypeInsertSelect, optimizeInsertSelect)
+	optimize.Register(ast.SQLTypeReplace, optimizeReplace)
 }
 
 func optimizeInsert(ctx context.Context, o *optimize.Optimizer) (proto.Plan, error) {
+	return optimizeInsertRows(ctx, o, o.Stmt.(*ast.InsertStatement), false)
+}
+
+func optimizeReplace(ctx context.Context, o *optimize.Optimizer) (proto.Plan, error) {
+	stmt := o.Stmt.(*ast.ReplaceStatement)
+
+	// REPLACE is routed, rewritten and batched in the same way as INSERT
+	insert := ast.NewInsertStatement(stmt.Table, stmt.Columns)
+	insert.SetFlag(stmt.Flag())
+	insert.Hint = stmt.Hint
+	insert.Values = stmt.Values
+
+	return optimizeInsertRows(ctx, o, insert, true)
+}
+
+// optimizeInsertRows routes the rows of INSERT to shards, the statements of shards are restored as REPLACE if replace is true.
+func optimizeInsertRows(ctx context.Context, o *optimize.Optimizer, stmt *ast.InsertStatement, replace bool) (proto.Plan, error) {
+	// the rows are always located by primary sharding strategy, the strategy chosen by session only applies to reads
+	ctx = rcontext.WithPrimaryShardStrategy(ctx)
+
 	ret := dml.NewSimpleInsertPlan()
 	ret.BindArgs(o.Args)
 
 	var (
-		stmt      = o.Stmt.(*ast.InsertStatement)
 		vt        *rule.VTable
 		ok        bool
 		tableName = stmt.Table
+		action    = "insert"
 		err       error
 	)
 
+	put := func(db string, next *ast.InsertStatement) {
+		if !replace {
+			ret.Put(db, next)
+			return
+		}
+		r := ast.NewReplaceStatement(next.Table, next.Columns)
+		r.SetFlag(next.Flag())
+		r.Hint = next.Hint
+		r.Values = next.Values
+		ret.Put(db, r)
+	}
+
+	if replace {
+		action = "replace"
+	}
+
+	if routed, ok := optimizeSchemaRoute(o, tableName); ok {
+		return routed, nil
+	}
+
 	if vt, ok = o.Rule.VTable(stmt.Table.Suffix()); !ok { // insert into non-sharding table
-		ret.Put("", stmt)
+		put("", stmt)
 		return ret, nil
 	}
 
-	vshards := vt.GetVShards()
+	if len(stmt.Columns) == 0 {
+		if stmt.Columns, err = expandColumns(ctx, vt, stmt.Values); err != nil {
+			return nil, err
+		}
+	}
+	normalizeColumns(vt, stmt.Columns)
+
+	// REPLACE deletes the conflicting rows before inserting, the sharding keys must be provided explicitly
+	// so that the deleted rows and the inserted row always stay on the same shard.
+	var keys []string
+	if keys, err = findShardKeys(vt, stmt.Columns); err != nil {
+		return nil, errors.Wrapf(err, "failed to %s", action)
+	}
+
+	// check on duplicated key update
+	for _, upd := range stmt.DuplicatedUpdates {
+		if slices.Contains(keys, vt.NormalizeColumn(upd.Column.Suffix())) {
+			return nil, errors.New("do not support update sharding key")
+		}
+	}
+
+	var slots map[string]map[string][]int
+	if slots, err = routeValues(ctx, o, tableName, stmt.Columns, stmt.Values, keys); err != nil {
+		return nil, errors.Wrapf(err, "failed to %s", action)
+	}
+
+	for db, slot := range slots {
+		for table, indexes := range slot {
+			// clone insert stmt without values
+			newborn := ast.NewInsertStatement(ast.TableName{table}, stmt.Columns)
+			newborn.SetFlag(stmt.Flag())
+			newborn.DuplicatedUpdates = stmt.DuplicatedUpdates
+			newborn.Hint = stmt.Hint
+
+			// collect values with same table
+			values := make([][]ast.ExpressionNode, 0, len(indexes))
+			for _, i := range indexes {
+				values = append(values, stmt.Values[i])
+			}
+			newborn.Values = values
+
+			rewriteInsertStatement(ctx, o, vt, newborn)
+			if kf := vt.KeyFilter(); kf != nil {
+				addFilterKeys(ctx, kf, db, table, newborn.Columns, newborn.Values, newborn.DuplicatedUpdates, o.Args)
+			}
+			batches := splitInsertStatement(newborn, vt.InsertBatchSize())
+			if len(batches) > 1 {
+				ret.Batched = true
+			}
+			for _, batch := range batches {
+				put(db, batch)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// expandColumns returns the columns of logical schema for the INSERT without column list, the rewritten INSERTs
+// of shards will contain the explicit column names, which won't be affected by the drift of physical column order.
+func expandColumns(ctx context.Context, vt *rule.VTable, rows [][]ast.ExpressionNode) ([]string, error) {
+	metadata, err := getMetadata(ctx, vt)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for i, values := range rows {
+		if len(values) != len(metadata.ColumnNames) {
+			return nil, mysqlErrors.NewSQLError(mysql.ERWrongValueCountOnRow, mysql.SSWrongValueCountOnRow,
+				"Column count doesn't match value count at row %d", i+1)
+		}
+	}
+
+	return slices.Clone(metadata.ColumnNames), nil
+}
+
+// normalizeColumns replaces the columns with the declared names of sharding keys, eg: (UID, name) -> (uid, name).
+func normalizeColumns(vt *rule.VTable, columns []string) {
+	for i := range columns {
+		columns[i] = vt.NormalizeColumn(columns[i])
+	}
+}
+
+// findShardKeys returns the sharding keys of the first VShard of primary sharding strategy whose keys are all contained in columns.
+func findShardKeys(vt *rule.VTable, columns []string) ([]string, error) {
+	vshards := vt.WithShardStrategy("").GetVShards()
 	bingo := slices.IndexFunc(vshards, func(shard *rule.VShard) bool {
 		keys := shard.Variables()
 		for _, key := range keys {
-			if !slices.Contains(stmt.Columns, key) {
+			if !slices.Contains(columns, key) {
 				return false
 			}
 		}
@@ -71,25 +201,28 @@ func optimizeInsert(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 	})
 
 	if bingo == -1 {
-		return nil, errors.Wrap(optimize.ErrNoShardKeyFound, "failed to insert")
+		return nil, optimize.ErrNoShardKeyFound
 	}
 
-	vShard := vshards[bingo]
-	keys := vShard.Variables()
-
-	// check on duplicated key update
-	for _, upd := range stmt.DuplicatedUpdates {
-		if slices.Contains(keys, upd.Column.Suffix()) {
-			return nil, errors.New("do not support update sharding key")
-		}
-	}
+	return vshards[bingo].Variables(), nil
+}
 
+// routeValues computes the shard of each row, returns the indexes of rows grouped by db and table.
+func routeValues(
+	ctx context.Context,
+	o *optimize.Optimizer,
+	tableName ast.TableName,
+	columns []string,
+	rows [][]ast.ExpressionNode,
+	keys []string,
+) (map[string]map[string][]int, error) {
 	var (
+		err     error
 		sharder = optimize.NewXSharder(ctx, o.Rule, o.Args)
 		slots   = make(map[string]map[string][]int) // (db,table,valuesIndex)
 	)
 
-	for i, values := range stmt.Values {
+	for i, values := range rows {
 		var (
 			shards rule.DatabaseTables
 			filter ast.ExpressionNode
@@ -97,10 +230,10 @@ func optimizeInsert(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 
 		if len(keys) == 1 {
 			key := keys[0]
-			idx := slices.Index(stmt.Columns, key)
-			filter = buildFilter(stmt.Columns[idx], values[idx])
+			idx := slices.Index(columns, key)
+			filter = buildFilter(columns[idx], values[idx])
 		} else {
-			filter = buildLogicalFilter(stmt.Columns, values, keys)
+			filter = buildLogicalFilter(columns, values, keys)
 		}
 
 		if len(o.Hints) > 0 {
@@ -116,7 +249,7 @@ func optimizeInsert(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		}
 
 		if shards.Len() != 1 {
-			return nil, errors.Wrap(optimize.ErrNoShardKeyFound, "failed to insert")
+			return nil, optimize.ErrNoShardKeyFound
 		}
 
 		var (
@@ -130,38 +263,42 @@ func optimizeInsert(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 			break
 		}
 
-		if _, ok = slots[db]; !ok {
+		if _, ok := slots[db]; !ok {
 			slots[db] = make(map[string][]int)
 		}
 		slots[db][table] = append(slots[db][table], i)
 	}
 
-	for db, slot := range slots {
-		for table, indexes := range slot {
-			// clone insert stmt without values
-			newborn := ast.NewInsertStatement(ast.TableName{table}, stmt.Columns)
-			newborn.SetFlag(stmt.Flag())
-			newborn.DuplicatedUpdates = stmt.DuplicatedUpdates
-			newborn.Hint = stmt.Hint
+	return slots, nil
+}
 
-			// collect values with same table
-			values := make([][]ast.ExpressionNode, 0, len(indexes))
-			for _, i := range indexes {
-				values = append(values, stmt.Values[i])
-			}
-			newborn.Values = values
+// splitInsertStatement splits the multi-row INSERT into batches, each batch contains no more than size rows,
+// which avoids exceeding the max_allowed_packet of backend.
+func splitInsertStatement(stmt *ast.InsertStatement, size int) []*ast.InsertStatement {
+	if size <= 0 || len(stmt.Values) <= size {
+		return []*ast.InsertStatement{stmt}
+	}
 
-			rewriteInsertStatement(ctx, o, vt, newborn)
-			ret.Put(db, newborn)
+	ret := make([]*ast.InsertStatement, 0, (len(stmt.Values)+size-1)/size)
+	for i := 0; i < len(stmt.Values); i += size {
+		j := i + size
+		if j > len(stmt.Values) {
+			j = len(stmt.Values)
 		}
+		batch := *stmt // do copy
+		batch.Values = stmt.Values[i:j]
+		ret = append(ret, &batch)
 	}
-
-	return ret, nil
+	return ret
 }
 
 func optimizeInsertSelect(_ context.Context, o *optimize.Optimizer) (proto.Plan, error) {
 	stmt := o.Stmt.(*ast.InsertSelectStatement)
 
+	if ret, ok := optimizeSchemaRoute(o, stmt.Table); ok {
+		return ret, nil
+	}
+
 	ret := dml.NewInsertSelectPlan()
 
 	ret.BindArgs(o.Args)
@@ -176,6 +313,36 @@ func optimizeInsertSelect(_ context.Context, o *optimize.Optimizer) (proto.Plan,
 	return nil, errors.New("not support insert-select into sharding table")
 }
 
+// addFilterKeys adds the inserted keys into the bloom filter before execution, it is safe because
+// the filter only promises no false negatives.
+func addFilterKeys(ctx context.Context, kf *rule.KeyFilter, db, table string, columns []string, rows [][]ast.ExpressionNode, updates []*ast.UpdateElement, args []proto.Value) {
+	// the keys updated on duplicate cannot be tracked
+	for _, upd := range updates {
+		if strings.EqualFold(upd.Column.Suffix(), kf.Column()) {
+			kf.Invalidate(db, table)
+			return
+		}
+	}
+
+	idx := slices.IndexFunc(columns, func(column string) bool {
+		return strings.EqualFold(column, kf.Column())
+	})
+	// the keys are generated by backend, which cannot be tracked
+	if idx == -1 {
+		kf.Invalidate(db, table)
+		return
+	}
+
+	for _, values := range rows {
+		key, err := extvalue.Compute(ctx, values[idx], args...)
+		if err != nil || key == nil {
+			kf.Invalidate(db, table)
+			return
+		}
+		kf.Add(db, table, key)
+	}
+}
+
 func getMetadata(ctx context.Context, vtab *rule.VTable) (*proto.TableMetadata, error) {
 	_, tb0, _ := vtab.Topology().Smallest()
 	metadatas, err := proto.LoadSchemaLoader().Load(ctx, rcontext.Schema(ctx), []string{tb0})
@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"strings"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/cmp"
+	"github.com/arana-db/arana/pkg/runtime/misc/extvalue"
+)
+
+// keysetPagination represents a keyset(seek) pagination, which seeks from the last row of previous page
+// instead of skipping an offset. For example:
+//
+//	SELECT ... WHERE (score, id) > (?, ?) ORDER BY score, id LIMIT 20
+//
+// Each shard only needs to return the first rows after the seek position, and the ordered rows of shards
+// are merged by the same composite ordering, so no large offset will be pushed down.
+type keysetPagination struct {
+	columns []ast.ColumnNameExpressionAtom
+	values  []ast.PredicateNode
+	op      cmp.Comparison
+}
+
+// recognizeKeyset recognizes the keyset pagination from a SELECT statement, it requires:
+//  1. a conjunctive row comparison between columns and values, eg: (score, id) > (?, ?)
+//  2. the ORDER BY items are exactly the compared columns with same direction, ASC for '>' and DESC for '<'
+//  3. a LIMIT without offset
+func recognizeKeyset(ctx context.Context, stmt *ast.SelectStatement, args []proto.Value) (*keysetPagination, bool) {
+	if stmt.Limit == nil || stmt.Limit.HasOffset() || stmt.GroupBy != nil || len(stmt.OrderBy) < 1 {
+		return nil, false
+	}
+
+	var (
+		ret   *keysetPagination
+		visit func(expr ast.ExpressionNode)
+	)
+	visit = func(expr ast.ExpressionNode) {
+		switch node := expr.(type) {
+		case *ast.LogicalExpressionNode:
+			if !node.Or {
+				visit(node.Left)
+				visit(node.Right)
+			}
+		case *ast.PredicateExpressionNode:
+			if ret != nil {
+				return
+			}
+			if p, ok := node.P.(*ast.BinaryComparisonPredicateNode); ok {
+				ret, _ = newKeysetPagination(ctx, p, stmt.OrderBy, args)
+			}
+		}
+	}
+	visit(stmt.Where)
+
+	return ret, ret != nil
+}
+
+func newKeysetPagination(ctx context.Context, p *ast.BinaryComparisonPredicateNode, orders []*ast.OrderByItem, args []proto.Value) (*keysetPagination, bool) {
+	var desc bool
+	switch p.Op {
+	case cmp.Cgt, cmp.Cgte:
+	case cmp.Clt, cmp.Clte:
+		desc = true
+	default:
+		return nil, false
+	}
+
+	toRow := func(p ast.PredicateNode) (*ast.RowExpressionAtom, bool) {
+		atom, ok := p.(*ast.AtomPredicateNode)
+		if !ok {
+			return nil, false
+		}
+		row, ok := atom.A.(*ast.RowExpressionAtom)
+		return row, ok
+	}
+
+	left, ok := toRow(p.Left)
+	if !ok {
+		return nil, false
+	}
+	right, ok := toRow(p.Right)
+	if !ok || len(left.Values) != len(right.Values) || len(left.Values) != len(orders) {
+		return nil, false
+	}
+
+	ret := &keysetPagination{
+		columns: make([]ast.ColumnNameExpressionAtom, 0, len(left.Values)),
+		values:  make([]ast.PredicateNode, 0, len(right.Values)),
+		op:      p.Op,
+	}
+	for i := range left.Values {
+		column, ok := toColumn(left.Values[i])
+		if !ok {
+			return nil, false
+		}
+		order, ok := orders[i].Expr.(ast.ColumnNameExpressionAtom)
+		if !ok || orders[i].Desc != desc || !strings.EqualFold(order.Suffix(), column.Suffix()) {
+			return nil, false
+		}
+
+		value, ok := right.Values[i].(*ast.PredicateExpressionNode)
+		if !ok {
+			return nil, false
+		}
+		if v, err := extvalue.Compute(ctx, value.P, args...); err != nil || v == nil {
+			return nil, false
+		}
+
+		ret.columns = append(ret.columns, column)
+		ret.values = append(ret.values, value.P)
+	}
+
+	return ret, true
+}
+
+// leadingBound returns the bound of leading column which is implied by the seek condition,
+// eg: '(score, id) > (90, 100)' implies 'score >= 90', it can be used to compute the shards.
+func (kp *keysetPagination) leadingBound() ast.ExpressionNode {
+	op := kp.op
+	// only the single column comparison is strict
+	if len(kp.columns) > 1 {
+		switch op {
+		case cmp.Cgt:
+			op = cmp.Cgte
+		case cmp.Clt:
+			op = cmp.Clte
+		}
+	}
+	return &ast.PredicateExpressionNode{
+		P: &ast.BinaryComparisonPredicateNode{
+			Left:  &ast.AtomPredicateNode{A: kp.columns[0]},
+			Right: kp.values[0],
+			Op:    op,
+		},
+	}
+}
+
+func toColumn(expr ast.ExpressionNode) (ast.ColumnNameExpressionAtom, bool) {
+	pen, ok := expr.(*ast.PredicateExpressionNode)
+	if !ok {
+		return nil, false
+	}
+	atom, ok := pen.P.(*ast.AtomPredicateNode)
+	if !ok {
+		return nil, false
+	}
+	return atom.Column()
+}
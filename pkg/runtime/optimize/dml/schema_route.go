@@ -0,0 +1,40 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/optimize"
+	"github.com/arana-db/arana/pkg/runtime/plan"
+)
+
+// optimizeSchemaRoute returns a plan which executes the DML statement in the group which the schema of table
+// is routed to, the statement is sent as it is, eg: 'DELETE FROM tenant_acme.users WHERE ...'.
+func optimizeSchemaRoute(o *optimize.Optimizer, table ast.TableName) (proto.Plan, bool) {
+	if len(table) < 2 {
+		return nil, false
+	}
+	group, ok := o.Rule.RouteSchema(table.Prefix())
+	if !ok {
+		return nil, false
+	}
+	ret := plan.Transparent(o.Stmt, o.Args)
+	ret.SetDB(group)
+	return ret, true
+}
@@ -29,17 +29,18 @@ import (
 import (
 	mysql "github.com/arana-db/arana/pkg/constants/mysql"
 	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/merge"
 	"github.com/arana-db/arana/pkg/merge/aggregator"
 	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/hint"
 	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/ast/ext"
 	"github.com/arana-db/arana/pkg/runtime/cmp"
 	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/misc/extvalue"
 	"github.com/arana-db/arana/pkg/runtime/optimize"
-	"github.com/arana-db/arana/pkg/runtime/optimize/dml/ext"
 	"github.com/arana-db/arana/pkg/runtime/plan/dml"
 	"github.com/arana-db/arana/pkg/util/log"
 )
@@ -55,8 +56,21 @@ func init() {
 
 func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, error) {
 	stmt := o.Stmt.(*ast.SelectStatement)
+
+	// DUAL is a dummy table, eg: SELECT NOW() FROM DUAL, which is same as the SELECT without FROM
+	if isDual(o.Rule, stmt) {
+		stmt.From = nil
+	}
+
+	// answer the session variables managed by arana, eg: SELECT @@session.arana_best_effort
+	if ret, ok := optimizeSessionVariables(ctx, stmt); ok {
+		ret.BindArgs(o.Args)
+		return ret, nil
+	}
+
 	enableLocalMathComputation := ctx.Value(proto.ContextKeyEnableLocalComputation{}).(bool)
-	if enableLocalMathComputation && len(stmt.From) == 0 {
+	// the filtered ones will be answered by backend, eg: SELECT 1 FROM DUAL WHERE @@read_only = 0
+	if enableLocalMathComputation && len(stmt.From) == 0 && stmt.Where == nil && stmt.Having == nil && stmt.Limit == nil {
 		var (
 			isLocalFlag = true
 			isSequence  = false
@@ -137,9 +151,10 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		return optimizeJoin(ctx, o, stmt)
 	}
 
-	// overwrite stmt limit x offset y. eg `select * from student offset 100 limit 5` will be
-	// `select * from student offset 0 limit 100+5`
-	originOffset, newLimit := overwriteLimit(stmt, &o.Args)
+	injectDefaultLimit(ctx, o.Rule, stmt)
+
+	// LIMIT 0 only fetches the metadata of result set, eg: 'SELECT * FROM student LIMIT 0'
+	metadataOnly := normalizeLimitZero(stmt, o.Args)
 
 	flag := getSelectFlag(o.Rule, stmt)
 	if flag&_supported == 0 {
@@ -147,6 +162,16 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 	}
 
 	if flag&_bypass != 0 {
+		// the schema routed to a group, eg: 'tenant_acme.users', is queried as it is
+		if group, ok := routeSchema(o.Rule, stmt); ok {
+			ret := &dml.SimpleQueryPlan{
+				Database: group,
+				Stmt:     stmt,
+			}
+			ret.BindArgs(o.Args)
+			return ret, nil
+		}
+
 		if len(stmt.From) > 0 {
 			err := expandSelectStar(ctx, stmt, o)
 			if err != nil {
@@ -168,6 +193,10 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		}, nil
 	}
 
+	if ret, ok, err := optimizeInSubquery(ctx, o, stmt); ok || err != nil {
+		return ret, err
+	}
+
 	// --- SIMPLE QUERY BEGIN ---
 
 	var (
@@ -183,23 +212,65 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		}
 	}
 
+	condition := shardingCondition(ctx, stmt, o.Args)
+	// the seek condition of keyset pagination bounds the leading sort column, which may prune the shards
+	if kp, ok := recognizeKeyset(ctx, stmt, o.Args); ok {
+		condition = optimize.AndPredicates(condition, kp.leadingBound())
+	}
 	if shards == nil {
-		if shards, err = optimize.NewXSharder(ctx, o.Rule, o.Args).SimpleShard(tableName, stmt.Where); err != nil {
+		if shards, err = optimize.NewXSharder(ctx, o.Rule, o.Args).SimpleShard(tableName, condition); err != nil {
 			return nil, errors.WithStack(err)
 		}
 		fullScan = shards == nil
 	}
 
 	log.Debugf("compute shards: result=%s, isFullScan=%v", shards, fullScan)
+
+	// the approximate count is cheap, so it is allowed even if full-scan is disabled
+	if hint.Contains(hint.TypeApproxCount, o.Hints) {
+		if column, ok := isApproxCountable(stmt); ok {
+			if shards.IsFullScan() {
+				shards = vt.Topology().Enumerate()
+			}
+			return &dml.ApproxCountPlan{
+				Table:  vt.Name(),
+				Shards: shards,
+				Column: column,
+			}, nil
+		}
+		rcontext.AddWarning(ctx, mysql.ERUnknownError, "hint APPROXCOUNT is ignored, only 'SELECT COUNT(*) FROM <table>' can be estimated")
+	}
 	// return error if full-scan is disabled
-	if fullScan && (!vt.AllowFullScan() && !hint.Contains(hint.TypeFullScan, o.Hints)) {
+	if fullScan && !metadataOnly && (!vt.AllowFullScan() && !hint.Contains(hint.TypeFullScan, o.Hints)) {
 		return nil, errors.WithStack(optimize.ErrDenyFullScan)
 	}
 
+	// skip the shards which definitely contain none of the queried keys
+	shards = optimize.FilterShardsByKey(ctx, vt, shards, condition, o.Args)
+
+	if vt.CountByPrimaryKey() {
+		if err = rewriteCountByPrimaryKey(ctx, stmt, vt); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if vt.OrderByGroupItems() {
+		appendGroupByOrderBy(stmt)
+	}
+
+	// each shard only queries the values it owns, eg: WHERE (uid,sid) IN ((1,2),(3,4)) or WHERE uid IN (?,?,?)
+	tupleWheres, _ := optimize.SplitTupleIn(ctx, vt, stmt.Where, o.Args)
+
 	toSingle := func(db, tbl string) (proto.Plan, error) {
 		if err := expandSelectStar(ctx, stmt, o); err != nil {
 			return nil, err
 		}
+		if where, ok := tupleWheres[tbl]; ok {
+			stmt.Where = where
+		}
+		if err := resolveScalarSubqueries(ctx, o, stmt, db, tbl); err != nil {
+			return nil, err
+		}
 		ret := &dml.SimpleQueryPlan{
 			Stmt:     stmt,
 			Database: db,
@@ -218,22 +289,28 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		}, nil
 	}
 
-	// Go through first table if no shards matched.
+	// Go through first table if no shards matched, or only the metadata is required.
 	// For example:
 	//    SELECT ... FROM xxx WHERE a > 8 and a < 4
-	if shards.IsEmpty() {
+	//    SELECT ... FROM xxx LIMIT 0
+	if shards.IsEmpty() || metadataOnly {
 		var (
 			db0, tbl0 string
 			ok        bool
 		)
-		if db0, tbl0, ok = vt.Topology().Render(0, 0); !ok {
-			return nil, errors.Errorf("cannot compute minimal topology from '%s'", stmt.From[0].Source.(ast.TableName).Suffix())
+		if db0, tbl0, ok = vt.Topology().Smallest(); !ok {
+			return nil, errors.Errorf("cannot compute minimal topology from '%s': the topology is empty", stmt.From[0].Source.(ast.TableName).Suffix())
 		}
 
 		return toSingle(db0, tbl0)
 	}
 
-	// Handle single shard
+	// expand all shards if all shards matched
+	if shards.IsFullScan() {
+		shards = vt.Topology().Enumerate()
+	}
+
+	// Handle single shard, the backend applies ORDER BY, GROUP BY and LIMIT itself, so no merge is required.
 	if shards.Len() == 1 {
 		var db, tbl string
 		for k, v := range shards {
@@ -243,14 +320,35 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		return toSingle(db, tbl)
 	}
 
+	// the scalar subquery may read the rows of other shards, eg: 'WHERE amount > (SELECT AVG(amount) FROM ...)'
+	if len(collectScalarSubqueries(stmt)) > 0 {
+		return nil, errors.Errorf("scalar subquery across %d shards of table '%s' is not supported, please narrow it to a single shard by the sharding key", shards.Len(), tableName.Suffix())
+	}
+
+	// each shard evaluates the user variables separately, eg: '@rownum := @rownum + 1' restarts in every shard
+	if hasVariableAssignment(stmt) {
+		return nil, errors.Errorf("user variable assignment across %d shards of table '%s' is not supported, please narrow it to a single shard by the sharding key", shards.Len(), tableName.Suffix())
+	}
+
+	// overwrite stmt limit x offset y. eg `select * from student offset 100 limit 5` will be
+	// `select * from student offset 0 limit 100+5`
+	originOffset, newLimit := overwriteLimit(stmt, &o.Args)
+
 	if err = expandSelectStar(ctx, stmt, o); err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	if vt.OrderByPrimaryKey() || hint.Contains(hint.TypeOrderByPK, o.Hints) {
+		if err = appendPrimaryKeyOrderBy(ctx, stmt, vt); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
 	var (
 		analysis selectResult
 		scanner  = newSelectScanner(stmt, o.Args)
 	)
+	scanner.caseSensitive = vt.CaseSensitiveColumns()
 
 	if err = scanner.scan(&analysis); err != nil {
 		return nil, errors.WithStack(err)
@@ -258,52 +356,106 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 
 	// Handle multiple shards
 
-	if shards.IsFullScan() { // expand all shards if all shards matched
-		shards = vt.Topology().Enumerate()
+	if analysis.hasDistinct && (stmt.Having != nil || analysis.having != nil) {
+		return nil, errors.New("todo: handle HAVING with DISTINCT aggregate")
 	}
 
-	plans := make([]proto.Plan, 0, len(shards))
-	for k, v := range shards {
-		next := &dml.SimpleQueryPlan{
-			Database: k,
-			Tables:   v,
-			Stmt:     stmt,
+	// The LIMIT of GROUP BY applies to the merged groups, so it cannot be pushed down to shards.
+	// For example, 'SELECT dept, COUNT(*) FROM emp GROUP BY dept LIMIT 5' returns 5 groups instead of 5 rows of each shard.
+	// It is same for DISTINCT aggregate, which will be grouped by the argument in each shard.
+	limit := stmt.Limit
+	if stmt.GroupBy != nil || analysis.hasDistinct {
+		stmt.Limit = nil
+	}
+
+	if fullScan {
+		rcontext.MarkFullScan(ctx)
+		rcontext.AddWarning(ctx, mysql.ERUnknownError, "full table scan across %d shards of table '%s'", shards.Len(), vt.Name())
+		if optimize.HasUnprunableOr(ctx, o.Rule, tableName, stmt.Where, o.Args) {
+			rcontext.AddWarning(ctx, mysql.ERUnknownError, "OR condition on non-sharding column prevents shard pruning of table '%s'", vt.Name())
 		}
-		next.BindArgs(o.Args)
-		plans = append(plans, next)
+	} else if n := shards.Len(); n >= vt.WideFanOut() {
+		rcontext.AddWarning(ctx, mysql.ERUnknownError, "query fans out to %d shards of table '%s'", n, vt.Name())
 	}
 
-	var tmpPlan proto.Plan
-	tmpPlan = &dml.CompositePlan{
-		Plans: plans,
+	// the unordered LIMIT can be satisfied by any rows, so the shards will be scanned one by one,
+	// and the remaining ones won't be queried once enough rows have been read.
+	earlyLimit := limit != nil && vt.EarlyLimit() && len(analysis.orders) == 0 &&
+		stmt.GroupBy == nil && !analysis.hasAggregate && !analysis.hasDistinct
+
+	plans := make([]proto.Plan, 0, len(shards))
+	shards.Each(func(k string, v []string) bool {
+		// split into one plan per physical table, so that the missing tables can be skipped separately
+		if vt.SkipMissingTables() || earlyLimit {
+			for _, table := range v {
+				next := &dml.SimpleQueryPlan{
+					Database: k,
+					Tables:   []string{table},
+					Stmt:     stmt,
+					Wheres:   tupleWheres,
+				}
+				next.BindArgs(o.Args)
+				plans = append(plans, next)
+			}
+			return true
+		}
+		// the tables of same database are coalesced into UNION ALL queries to reduce the round-trips
+		for _, tables := range coalesceTables(v, vt.UnionTables()) {
+			next := &dml.SimpleQueryPlan{
+				Database: k,
+				Tables:   tables,
+				Stmt:     stmt,
+				Wheres:   tupleWheres,
+			}
+			next.BindArgs(o.Args)
+			plans = append(plans, next)
+		}
+		return true
+	})
+
+	composite := &dml.CompositePlan{
+		Plans:             plans,
+		SkipMissingTables: vt.SkipMissingTables(),
+	}
+	// the partial results are opt-in, and only allowed for the non-locking reads
+	if stmt.Lock == 0 && (hint.Contains(hint.TypeBestEffort, o.Hints) || rcontext.BestEffort(ctx)) {
+		composite.BestEffort = true
 	}
 
-	// check if order-by exists
-	if len(analysis.orders) > 0 {
-		var (
-			sb           strings.Builder
-			orderByItems = make([]dataset.OrderByItem, 0, len(analysis.orders))
-		)
+	var tmpPlan proto.Plan = composite
 
-		for _, it := range analysis.orders {
-			var next dataset.OrderByItem
-			next.Desc = it.Desc
-			if alias := it.Alias(); len(alias) > 0 {
-				next.Column = alias
-			} else {
-				switch prev := it.Prev().(type) {
-				case *ast.SelectElementColumn:
-					next.Column = prev.Suffix()
-				default:
-					if err = it.Restore(ast.RestoreWithoutAlias, &sb, nil); err != nil {
-						return nil, errors.WithStack(err)
-					}
-					next.Column = sb.String()
-					sb.Reset()
+	var (
+		sb           strings.Builder
+		orderByItems = make([]dataset.OrderByItem, 0, len(analysis.orders))
+	)
+	for _, it := range analysis.orders {
+		var next dataset.OrderByItem
+		next.Desc = it.Desc
+		next.Collate = it.Collate
+		if alias := it.Alias(); len(alias) > 0 {
+			next.Column = alias
+		} else {
+			switch prev := it.Prev().(type) {
+			case *ast.SelectElementColumn:
+				next.Column = prev.Suffix()
+			default:
+				if err = it.Restore(ast.RestoreWithoutAlias, &sb, nil); err != nil {
+					return nil, errors.WithStack(err)
 				}
+				next.Column = sb.String()
+				sb.Reset()
 			}
-			orderByItems = append(orderByItems, next)
 		}
+		orderByItems = append(orderByItems, next)
+	}
+
+	// The groups ordered by other than the group items, eg: 'SELECT dept, SUM(x) s FROM emp GROUP BY dept ORDER BY s DESC LIMIT 10',
+	// have to be sorted after they are merged, and the LIMIT applies to the sorted groups.
+	// So the shards are only ordered by the group items, which keeps the rows of same group adjacent.
+	sortGroups := stmt.GroupBy != nil && len(orderByItems) > 0 && !isOrderedByGroupItems(stmt)
+	if sortGroups {
+		stmt.OrderBy = nil
+	} else if len(orderByItems) > 0 {
 		tmpPlan = &dml.OrderPlan{
 			ParentPlan:   tmpPlan,
 			OrderByItems: orderByItems,
@@ -314,43 +466,61 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		if tmpPlan, err = handleGroupBy(tmpPlan, stmt); err != nil {
 			return nil, errors.WithStack(err)
 		}
-	} else if analysis.hasAggregate {
+	} else if analysis.hasAggregate && !analysis.hasDistinct {
 		tmpPlan = &dml.AggregatePlan{
 			Plan:   tmpPlan,
 			Fields: stmt.Select,
 		}
 	}
 
-	if stmt.Limit != nil {
+	if analysis.hasDistinct {
+		tmpPlan = handleDistinctAggregate(tmpPlan, stmt)
+	}
+
+	// the mapping values, eg: AVG, should be computed before the groups are filtered or sorted by them
+	earlyMapping := sortGroups || analysis.having != nil
+	if earlyMapping && analysis.hasMapping {
+		tmpPlan = &dml.MappingPlan{
+			Plan:   tmpPlan,
+			Fields: stmt.Select,
+		}
+	}
+
+	if having := analysis.having; having != nil {
+		tmpPlan = &dml.HavingPlan{
+			ParentPlan: tmpPlan,
+			Having:     having.expr,
+			Aggregates: having.aggregates,
+			Args:       o.Args,
+		}
+	}
+
+	if sortGroups {
+		tmpPlan = &dml.SortPlan{
+			ParentPlan:   tmpPlan,
+			OrderByItems: orderByItems,
+		}
+	}
+
+	if limit != nil {
 		tmpPlan = &dml.LimitPlan{
-			ParentPlan:     tmpPlan,
-			OriginOffset:   originOffset,
-			OverwriteLimit: newLimit,
+			ParentPlan:       tmpPlan,
+			OriginOffset:     originOffset,
+			OverwriteLimit:   newLimit,
+			EarlyTermination: earlyLimit,
 		}
 	}
 
-	if analysis.hasMapping {
+	if analysis.hasMapping && !earlyMapping {
 		tmpPlan = &dml.MappingPlan{
 			Plan:   tmpPlan,
 			Fields: stmt.Select,
 		}
 	}
 
-	// check & drop weak column
+	// drop the internal columns after merged
 	if analysis.hasWeak {
-		var weaks []*ext.WeakSelectElement
-		for i := range stmt.Select {
-			switch next := stmt.Select[i].(type) {
-			case *ext.WeakSelectElement:
-				weaks = append(weaks, next)
-			}
-		}
-		if len(weaks) > 0 {
-			tmpPlan = &dml.DropWeakPlan{
-				Plan:     tmpPlan,
-				WeakList: weaks,
-			}
-		}
+		tmpPlan = withProjection(tmpPlan, stmt.Select)
 	}
 
 	// FIXME: tuning, avoid rename everytime.
@@ -367,6 +537,48 @@ func optimizeSelect(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 	return tmpPlan, nil
 }
 
+// optimizeSessionVariables returns a local plan if all select elements are session variables managed by arana.
+func optimizeSessionVariables(ctx context.Context, stmt *ast.SelectStatement) (*dml.LocalSelectPlan, bool) {
+	if len(stmt.From) > 0 || len(stmt.Select) < 1 {
+		return nil, false
+	}
+
+	var (
+		columnList = make([]string, 0, len(stmt.Select))
+		valueList  = make([]proto.Value, 0, len(stmt.Select))
+	)
+	for i := range stmt.Select {
+		sel, ok := stmt.Select[i].(*ast.SelectElementExpr)
+		if !ok {
+			return nil, false
+		}
+		pen, ok := sel.Expression().(*ast.PredicateExpressionNode)
+		if !ok {
+			return nil, false
+		}
+		apn, ok := pen.P.(*ast.AtomPredicateNode)
+		if !ok {
+			return nil, false
+		}
+		sv, ok := apn.A.(*ast.SystemVariableExpressionAtom)
+		if !ok || !sv.System || sv.Global {
+			return nil, false
+		}
+		value, ok := rcontext.SessionVariable(ctx, sv.Name)
+		if !ok {
+			return nil, false
+		}
+		columnList = append(columnList, stmt.Select[i].DisplayName())
+		valueList = append(valueList, value)
+	}
+
+	return &dml.LocalSelectPlan{
+		Stmt:       stmt,
+		Result:     valueList,
+		ColumnList: columnList,
+	}, true
+}
+
 // handleGroupBy exp: `select max(score) group by id order by name` will be convert to
 // `select max(score), id group by id order by id, name`
 func handleGroupBy(parentPlan proto.Plan, stmt *ast.SelectStatement) (proto.Plan, error) {
@@ -394,10 +606,14 @@ func handleGroupBy(parentPlan proto.Plan, stmt *ast.SelectStatement) (proto.Plan
 			cn := sec.Name[len(sec.Name)-1]
 			selectItemsMap[cn] = si
 		}
+		// the group item may refer to the alias of select element, eg: SELECT YEAR(d) AS y ... GROUP BY y
+		if alias := si.Alias(); len(alias) > 0 {
+			selectItemsMap[alias] = si
+		}
 	}
 
 	for _, obi := range stmt.OrderBy {
-		if cn, ok := obi.Expr.(*ast.ColumnNameExpressionAtom); ok {
+		if cn, ok := obi.Expr.(ast.ColumnNameExpressionAtom); ok {
 			orderItemMap[cn.Suffix()] = obi
 		}
 	}
@@ -433,12 +649,116 @@ func handleGroupBy(parentPlan proto.Plan, stmt *ast.SelectStatement) (proto.Plan
 	stmt.OrderBy = newOrderByItems
 	groupPlan.GroupItems = groupItems
 
+	// rows of the same group must be adjacent, merge the shards ordered by the group items
+	if _, ok := parentPlan.(*dml.OrderPlan); !ok {
+		groupPlan.Plan = &dml.OrderPlan{
+			ParentPlan:   parentPlan,
+			OrderByItems: groupItems,
+		}
+	}
+
 	return groupPlan, nil
 }
 
+// isOrderedByGroupItems returns true if the order items are exactly the group items in any sequence,
+// eg: 'GROUP BY a, b ORDER BY b DESC, a', then the ordered rows of same group are always adjacent.
+func isOrderedByGroupItems(stmt *ast.SelectStatement) bool {
+	groups := make(map[string]struct{}, len(stmt.GroupBy.Items))
+	for _, item := range stmt.GroupBy.Items {
+		pen, ok := item.Expr().(*ast.PredicateExpressionNode)
+		if !ok {
+			return false
+		}
+		apn, ok := pen.P.(*ast.AtomPredicateNode)
+		if !ok {
+			return false
+		}
+		cn, ok := apn.Column()
+		if !ok {
+			return false
+		}
+		groups[cn.Suffix()] = struct{}{}
+	}
+
+	orders := make(map[string]struct{}, len(stmt.OrderBy))
+	for _, obi := range stmt.OrderBy {
+		cn, ok := obi.Expr.(ast.ColumnNameExpressionAtom)
+		if !ok {
+			return false
+		}
+		if _, ok = groups[cn.Suffix()]; !ok {
+			return false
+		}
+		orders[cn.Suffix()] = struct{}{}
+	}
+	return len(orders) == len(groups)
+}
+
+// handleDistinctAggregate exp: `select count(distinct uid) from student` will be convert to
+// `select uid from student group by uid`, then the distinct values of all shards will be deduplicated and aggregated.
+func handleDistinctAggregate(parentPlan proto.Plan, stmt *ast.SelectStatement) proto.Plan {
+	groupPlan, ok := parentPlan.(*dml.GroupPlan)
+	if !ok {
+		// no group-by items, all rows belong to the only group
+		groupPlan = &dml.GroupPlan{
+			Plan:              parentPlan,
+			AggItems:          aggregator.LoadAggs(stmt.Select),
+			Fields:            stmt.Select,
+			OriginColumnCount: len(stmt.Select),
+		}
+	}
+
+	if stmt.GroupBy == nil {
+		stmt.GroupBy = &ast.GroupByNode{}
+	}
+
+	visits := make(map[string]struct{})
+	for i, sel := range stmt.Select {
+		d, ok := sel.(*ext.DistinctAggrSelectElement)
+		if !ok {
+			continue
+		}
+
+		name := d.Prev().(*ast.SelectElementFunction).Function().(*ast.AggrFunction).Name()
+		groupPlan.AggItems[i] = func() merge.Aggregator {
+			return aggregator.NewDistinctAggregator(name)
+		}
+
+		var expr ast.ExpressionNode
+		switch arg := d.Arg.(type) {
+		case *ast.SelectElementColumn:
+			expr = &ast.PredicateExpressionNode{
+				P: &ast.AtomPredicateNode{
+					A: ast.ColumnNameExpressionAtom(arg.Name),
+				},
+			}
+		case *ast.SelectElementExpr:
+			expr = arg.Expression()
+		}
+
+		// group by each argument only once, eg: SUM(DISTINCT x), COUNT(DISTINCT x)
+		key := ast.MustRestoreToString(ast.RestoreDefault, expr)
+		if _, ok := visits[key]; ok {
+			continue
+		}
+		visits[key] = struct{}{}
+		stmt.GroupBy.Items = append(stmt.GroupBy.Items, ast.NewGroupByItem(expr))
+	}
+
+	return groupPlan
+}
+
 // optimizeJoin ony support  a join b in one db.
 // DEPRECATED: reimplement in the future
 func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectStatement) (proto.Plan, error) {
+	// propagate the constants to all joined tables, eg: ON a.uid = b.uid WHERE b.uid = 7 -> WHERE b.uid = 7 AND a.uid = 7
+	where := optimize.PropagateConstants(stmt.Where, stmt.From[0].Joins[0].On)
+
+	// the IN list of co-located sharding keys prunes both tables to the same shards, eg: ON a.uid = b.uid WHERE a.uid IN (1,2)
+	if ret, ok, err := optimizeColocatedInJoin(ctx, o, stmt, where); ok || err != nil {
+		return ret, err
+	}
+
 	compute := func(tableSource *ast.TableSourceItem) (database, alias string, table ast.TableName, shards rule.DatabaseTables, err error) {
 		table = tableSource.Source.(ast.TableName)
 		if table == nil {
@@ -452,7 +772,7 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 			alias = table.Suffix()
 		}
 
-		shards, err = o.ComputeShards(ctx, table, nil, o.Args)
+		shards, err = o.ComputeShards(ctx, table, optimize.TableBindings(where, table.Suffix(), tableSource.Alias), o.Args)
 		if err != nil {
 			return
 		}
@@ -471,9 +791,16 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 		return nil, err
 	}
 
+	strategy := getJoinStrategy(o.Hints)
+	if strategy == dml.JoinStrategyHash && !isEquiJoin(join.On) {
+		// hash join requires at least one equal condition, fallback to nested loop
+		strategy = dml.JoinStrategyNestedLoop
+	}
+
 	// one db
 	if dbLeft == dbRight && shardsLeft == nil && shardsRight == nil {
 		joinPan := &dml.SimpleJoinPlan{
+			Strategy: strategy,
 			Left: &dml.JoinTable{
 				Tables: tableLeft,
 				Alias:  aliasLeft,
@@ -489,11 +816,33 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 		return joinPan, nil
 	}
 
-	// multiple shards & do hash join
-	hashJoinPlan := &dml.HashJoinPlan{
-		Stmt: stmt,
+	// both tables are pruned to one shard of the same database, so the whole join can be pushed down,
+	// and the aggregates over the joined rows, eg: COUNT(*), will be computed by the backend.
+	// So are the ORDER BY and LIMIT, the LIMIT mustn't be rewritten or applied again by a LimitPlan.
+	if shardsLeft.Len() == 1 && shardsRight.Len() == 1 {
+		db0, tbLeft := shardsLeft.Smallest()
+		db1, tbRight := shardsRight.Smallest()
+		if db0 == db1 {
+			joinPan := &dml.SimpleJoinPlan{
+				Strategy: strategy,
+				Database: db0,
+				Left: &dml.JoinTable{
+					Tables: []string{tbLeft},
+					Alias:  aliasLeft,
+				},
+				Join: from.Joins[0],
+				Right: &dml.JoinTable{
+					Tables: []string{tbRight},
+					Alias:  aliasRight,
+				},
+				Stmt: o.Stmt.(*ast.SelectStatement),
+			}
+			joinPan.BindArgs(o.Args)
+			return joinPan, nil
+		}
 	}
 
+	// multiple shards & do hash join
 	onExpression, ok := from.Joins[0].On.(*ast.PredicateExpressionNode).P.(*ast.BinaryComparisonPredicateNode)
 	// todo support more 'ON' condition  ast.LogicalExpressionNode
 	if !ok {
@@ -557,8 +906,8 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 			selectStmt.Select = selectElements
 		}
 
-		if stmt.Where != nil {
-			selectStmt.Where = stmt.Where.Clone()
+		if where != nil {
+			selectStmt.Where = where.Clone()
 			err := filterWhereByTable(ctx, selectStmt.Where, tb0, aliasTb)
 			if err != nil {
 				return nil, err
@@ -568,6 +917,7 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 		optimizer := &optimize.Optimizer{
 			Rule: o.Rule,
 			Stmt: selectStmt,
+			Args: o.Args,
 		}
 		if _, ok = selectStmt.Select[0].(*ast.SelectElementAll); ok && len(selectStmt.Select) == 1 {
 			if err = expandSelectStar(ctx, selectStmt, optimizer); err != nil {
@@ -594,30 +944,59 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 		return nil, err
 	}
 
-	setPlan := func(plan *dml.HashJoinPlan, buildPlan, probePlan proto.Plan, buildKey, probeKey string) {
-		plan.BuildKey = buildKey
-		plan.ProbeKey = probeKey
-		plan.BuildPlan = buildPlan
-		plan.ProbePlan = probePlan
-	}
+	var (
+		buildPlan, probePlan = leftPlan, rightPlan
+		buildKey, probeKey   = leftKey, rightKey
+		comparison           = onExpression.Op
+		isFilterProbeRow     bool
+		isReversedColumn     bool
+	)
 
-	if join.Typ == ast.InnerJoin {
-		setPlan(hashJoinPlan, leftPlan, rightPlan, leftKey, rightKey)
-		hashJoinPlan.IsFilterProbeRow = true
-	} else {
-		hashJoinPlan.IsFilterProbeRow = false
-		if join.Typ == ast.LeftJoin {
-			hashJoinPlan.IsReversedColumn = true
-			setPlan(hashJoinPlan, rightPlan, leftPlan, rightKey, leftKey)
-		} else if join.Typ == ast.RightJoin {
-			setPlan(hashJoinPlan, leftPlan, rightPlan, leftKey, rightKey)
-		} else {
-			return nil, errors.New("not support Join Type")
+	switch join.Typ {
+	case ast.InnerJoin:
+		isFilterProbeRow = true
+		// the build side is kept in memory, so AUTO builds the smaller one, which is estimated by the
+		// amount of the tables to be scanned since the rows are unknown until execution.
+		if strategy == dml.JoinStrategyAuto && countJoinTables(shardsRight) < countJoinTables(shardsLeft) {
+			isReversedColumn = true
+			buildPlan, probePlan = rightPlan, leftPlan
+			buildKey, probeKey = rightKey, leftKey
+			comparison = reverseComparison(comparison)
 		}
+	case ast.LeftJoin:
+		isReversedColumn = true
+		buildPlan, probePlan = rightPlan, leftPlan
+		buildKey, probeKey = rightKey, leftKey
+		// keep the comparison as 'buildKey <op> probeKey'
+		comparison = reverseComparison(comparison)
+	case ast.RightJoin:
+	default:
+		return nil, errors.New("not support Join Type")
 	}
 
 	var tmpPlan proto.Plan
-	tmpPlan = hashJoinPlan
+	if comparison != cmp.Ceq || strategy == dml.JoinStrategyNestedLoop {
+		tmpPlan = &dml.NestedLoopJoinPlan{
+			BuildPlan:        buildPlan,
+			ProbePlan:        probePlan,
+			BuildKey:         buildKey,
+			ProbeKey:         probeKey,
+			Comparison:       comparison,
+			IsFilterProbeRow: isFilterProbeRow,
+			IsReversedColumn: isReversedColumn,
+			Stmt:             stmt,
+		}
+	} else {
+		tmpPlan = &dml.HashJoinPlan{
+			BuildPlan:        buildPlan,
+			ProbePlan:        probePlan,
+			BuildKey:         buildKey,
+			ProbeKey:         probeKey,
+			IsFilterProbeRow: isFilterProbeRow,
+			IsReversedColumn: isReversedColumn,
+			Stmt:             stmt,
+		}
+	}
 
 	var (
 		analysis selectResult
@@ -642,6 +1021,7 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 		for _, it := range analysis.orders {
 			var next dataset.OrderByItem
 			next.Desc = it.Desc
+			next.Collate = it.Collate
 			if alias := it.Alias(); len(alias) > 0 {
 				next.Column = alias
 			} else {
@@ -693,6 +1073,10 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 		}
 	}
 
+	if analysis.hasWeak {
+		tmpPlan = withProjection(tmpPlan, stmt.Select)
+	}
+
 	tmpPlan = &dml.RenamePlan{
 		Plan:       tmpPlan,
 		RenameList: analysis.normalizedFields,
@@ -700,13 +1084,124 @@ func optimizeJoin(ctx context.Context, o *optimize.Optimizer, stmt *ast.SelectSt
 	return tmpPlan, nil
 }
 
+// withProjection wraps the plan with a ProjectPlan which drops the internal columns, they are the weak select elements
+// injected during optimization, eg: the missing order-by columns, the SUM and COUNT of AVG.
+func withProjection(parentPlan proto.Plan, sels []ast.SelectElement) proto.Plan {
+	var (
+		internals []string
+		sb        strings.Builder
+	)
+	for _, sel := range sels {
+		weak, ok := sel.(*ext.WeakSelectElement)
+		if !ok {
+			continue
+		}
+		if alias := weak.Alias(); len(alias) > 0 {
+			internals = append(internals, alias)
+			continue
+		}
+		switch prev := weak.Prev().(type) {
+		case *ast.SelectElementColumn:
+			internals = append(internals, prev.Suffix())
+		default:
+			_ = prev.Restore(ast.RestoreWithoutAlias, &sb, nil)
+			internals = append(internals, sb.String())
+			sb.Reset()
+		}
+	}
+
+	if len(internals) < 1 {
+		return parentPlan
+	}
+
+	return &dml.ProjectPlan{
+		Plan:      parentPlan,
+		Internals: internals,
+	}
+}
+
+// countJoinTables returns the amount of the physical tables to be scanned for the joined table.
+func countJoinTables(shards rule.DatabaseTables) int {
+	// nil shards means a non-sharded table
+	if shards == nil {
+		return 1
+	}
+	return shards.Len()
+}
+
+// getJoinStrategy returns the join strategy specified by hints.
+func getJoinStrategy(hints []*hint.Hint) dml.JoinStrategy {
+	switch {
+	case hint.Contains(hint.TypeHashJoin, hints):
+		return dml.JoinStrategyHash
+	case hint.Contains(hint.TypeNestedLoop, hints):
+		return dml.JoinStrategyNestedLoop
+	default:
+		return dml.JoinStrategyAuto
+	}
+}
+
+// isEquiJoin returns true if the 'ON' condition contains at least one equal comparison between two columns,
+// eg: 'a.id = b.id AND a.x > 1'.
+func isEquiJoin(on ast.ExpressionNode) bool {
+	switch node := on.(type) {
+	case *ast.LogicalExpressionNode:
+		if node.Or {
+			return false
+		}
+		return isEquiJoin(node.Left) || isEquiJoin(node.Right)
+	case *ast.PredicateExpressionNode:
+		bc, ok := node.P.(*ast.BinaryComparisonPredicateNode)
+		if !ok || bc.Op != cmp.Ceq {
+			return false
+		}
+		isColumn := func(p ast.PredicateNode) bool {
+			if atom, ok := p.(*ast.AtomPredicateNode); ok {
+				_, ok = atom.Column()
+				return ok
+			}
+			return false
+		}
+		return isColumn(bc.Left) && isColumn(bc.Right)
+	}
+	return false
+}
+
+// reverseComparison returns the comparison after swapping two sides, eg: 'a < b' -> 'b > a'.
+func reverseComparison(c cmp.Comparison) cmp.Comparison {
+	switch c {
+	case cmp.Cgt:
+		return cmp.Clt
+	case cmp.Cgte:
+		return cmp.Clte
+	case cmp.Clt:
+		return cmp.Cgt
+	case cmp.Clte:
+		return cmp.Cgte
+	default:
+		return c
+	}
+}
+
+// isDual returns true if the only table is DUAL, the logical table of same name always takes precedence.
+func isDual(ru *rule.Rule, stmt *ast.SelectStatement) bool {
+	if len(stmt.From) != 1 || len(stmt.From[0].Joins) > 0 {
+		return false
+	}
+	tn, ok := stmt.From[0].Source.(ast.TableName)
+	if !ok || len(tn) != 1 || !strings.EqualFold(tn.Suffix(), "dual") {
+		return false
+	}
+	return !ru.Has(tn.Suffix())
+}
+
 func getSelectFlag(ru *rule.Rule, stmt *ast.SelectStatement) (flag uint32) {
 	switch len(stmt.From) {
 	case 1:
 		from := stmt.From[0]
-		tn := from.Source.(ast.TableName)
+		tn, ok := from.Source.(ast.TableName)
 
-		if tn == nil { // only FROM table supported now
+		if !ok || tn == nil { // only FROM table supported now, the derived table is not supported yet
 			return
 		}
 
@@ -718,6 +1213,10 @@ func getSelectFlag(ru *rule.Rule, stmt *ast.SelectStatement) (flag uint32) {
 				flag |= _bypass
 				return
 			}
+			if _, ok := ru.RouteSchema(tn.Prefix()); ok {
+				flag |= _bypass
+				return
+			}
 		}
 		if !ru.Has(tn.Suffix()) {
 			flag |= _bypass
@@ -729,6 +1228,140 @@ func getSelectFlag(ru *rule.Rule, stmt *ast.SelectStatement) (flag uint32) {
 	return
 }
 
+// routeSchema returns the group which the schema of queried table is routed to.
+func routeSchema(ru *rule.Rule, stmt *ast.SelectStatement) (string, bool) {
+	if len(stmt.From) != 1 {
+		return "", false
+	}
+	tn, ok := stmt.From[0].Source.(ast.TableName)
+	if !ok || len(tn) < 2 {
+		return "", false
+	}
+	return ru.RouteSchema(tn.Prefix())
+}
+
+// normalizeLimitZero returns true if the SELECT has LIMIT 0, and resets the LIMIT to 0 without offset,
+// since no rows should be returned whatever the offset is.
+func normalizeLimitZero(stmt *ast.SelectStatement, args []proto.Value) bool {
+	if stmt.Limit == nil {
+		return false
+	}
+	limit := stmt.Limit.Limit()
+	if stmt.Limit.IsLimitVar() {
+		if limit >= int64(len(args)) {
+			return false
+		}
+		var err error
+		if limit, err = args[limit].Int64(); err != nil {
+			return false
+		}
+	}
+	if limit != 0 {
+		return false
+	}
+	stmt.Limit = new(ast.LimitNode)
+	return true
+}
+
+// shardingCondition returns the condition which is used to compute shards, the conjunctive predicates of HAVING
+// on grouping columns are appended to WHERE, since they filter the rows before grouping too.
+// For example: 'SELECT uid, COUNT(*) FROM student GROUP BY uid HAVING uid = 5' is sharded by 'uid = 5'.
+func shardingCondition(ctx context.Context, stmt *ast.SelectStatement, args []proto.Value) ast.ExpressionNode {
+	if stmt.Having == nil || stmt.GroupBy == nil {
+		return stmt.Where
+	}
+
+	groups := make(map[string]struct{}, len(stmt.GroupBy.Items))
+	for _, item := range stmt.GroupBy.Items {
+		if pen, ok := item.Expr().(*ast.PredicateExpressionNode); ok {
+			if apn, ok := pen.P.(*ast.AtomPredicateNode); ok {
+				if cn, ok := apn.Column(); ok {
+					groups[cn.Suffix()] = struct{}{}
+				}
+			}
+		}
+	}
+	// the alias of select element shadows the column, eg: SELECT name AS uid ... GROUP BY uid HAVING uid = 5
+	for _, it := range stmt.Select {
+		if alias := it.Alias(); len(alias) > 0 {
+			if sc, ok := it.(*ast.SelectElementColumn); !ok || sc.Suffix() != alias {
+				delete(groups, alias)
+			}
+		}
+	}
+
+	isGroupColumn := func(p ast.PredicateNode) bool {
+		atom, ok := p.(*ast.AtomPredicateNode)
+		if !ok {
+			return false
+		}
+		cn, ok := atom.Column()
+		if !ok {
+			return false
+		}
+		_, ok = groups[cn.Suffix()]
+		return ok
+	}
+	isValue := func(p ast.Node) bool {
+		v, err := extvalue.Compute(ctx, p, args...)
+		return err == nil && v != nil
+	}
+
+	var (
+		where = stmt.Where
+		visit func(expr ast.ExpressionNode)
+	)
+	visit = func(expr ast.ExpressionNode) {
+		switch node := expr.(type) {
+		case *ast.LogicalExpressionNode:
+			if !node.Or {
+				visit(node.Left)
+				visit(node.Right)
+			}
+		case *ast.PredicateExpressionNode:
+			var ok bool
+			switch p := node.P.(type) {
+			case *ast.BinaryComparisonPredicateNode:
+				ok = p.Op == cmp.Ceq && (isGroupColumn(p.Left) && isValue(p.Right) || isGroupColumn(p.Right) && isValue(p.Left))
+			case *ast.InPredicateNode:
+				ok = !p.Not && isGroupColumn(p.P)
+				for i := 0; ok && i < len(p.E); i++ {
+					ok = isValue(p.E[i])
+				}
+			}
+			if !ok {
+				return
+			}
+			where = optimize.AndPredicates(where, node)
+		}
+	}
+	visit(stmt.Having)
+
+	return where
+}
+
+// injectDefaultLimit appends the default LIMIT of vtable to the SELECT without LIMIT, which protects the proxy
+// from loading too many rows. The queries inside transactions are unaffected.
+func injectDefaultLimit(ctx context.Context, ru *rule.Rule, stmt *ast.SelectStatement) {
+	if stmt.Limit != nil || len(stmt.From) != 1 || len(rcontext.TransactionID(ctx)) > 0 {
+		return
+	}
+	tableName, ok := stmt.From[0].Source.(ast.TableName)
+	if !ok {
+		return
+	}
+	vt, ok := ru.VTable(tableName.Suffix())
+	if !ok || vt.DefaultLimit() < 1 {
+		return
+	}
+
+	limit := new(ast.LimitNode)
+	limit.SetLimit(vt.DefaultLimit())
+	stmt.Limit = limit
+
+	rcontext.AddWarning(ctx, mysql.ERUnknownError, "default LIMIT %d is applied to the query of table '%s'", vt.DefaultLimit(), vt.Name())
+}
+
 func overwriteLimit(stmt *ast.SelectStatement, args *[]proto.Value) (originOffset, overwriteLimit int64) {
 	if stmt == nil || stmt.Limit == nil {
 		return 0, 0
@@ -741,6 +1374,11 @@ func overwriteLimit(stmt *ast.SelectStatement, args *[]proto.Value) (originOffse
 	var offsetIndex int64
 	var limitIndex int64
 
+	// the bound args may be shared by executions of a prepared statement, rewrite a copy of them
+	if stmt.Limit.IsLimitVar() || stmt.Limit.IsOffsetVar() {
+		*args = append(make([]proto.Value, 0, len(*args)+1), *args...)
+	}
+
 	if stmt.Limit.IsOffsetVar() {
 		offsetIndex = offset
 		offset, _ = (*args)[offsetIndex].Int64()
@@ -807,14 +1445,9 @@ func expandSelectStar(ctx context.Context, stmt *ast.SelectStatement, o *optimiz
 
 	selectExpandElements := make([]ast.SelectElement, 0)
 	for _, t := range tbs {
-		shards, err := o.ComputeShards(ctx, t, nil, o.Args)
-		if err != nil {
-			return errors.WithStack(err)
-		}
-
+		// all shards share the same metadata, no need to compute shards which may be denied as full-scan
 		tb0 := t.Suffix()
-		if shards != nil {
-			vt := o.Rule.MustVTable(tb0)
+		if vt, ok := o.Rule.VTable(tb0); ok {
 			_, tb0, _ = vt.Topology().Smallest()
 		}
 
@@ -831,6 +1464,164 @@ func expandSelectStar(ctx context.Context, stmt *ast.SelectStatement, o *optimiz
 	return nil
 }
 
+// appendPrimaryKeyOrderBy appends the missing primary key columns as the final order-by items, so that
+// rows with duplicate sort keys from different shards are always merged in the same order.
+// For example:
+//
+//	SELECT id,name FROM student ORDER BY score LIMIT 10,10
+//	  => SELECT id,name FROM student ORDER BY score,id LIMIT 10,10
+//
+// The missing primary key will be appended as a weak column, which will be dropped before return.
+func appendPrimaryKeyOrderBy(ctx context.Context, stmt *ast.SelectStatement, vt *rule.VTable) error {
+	// grouping and distinct rows cannot be sorted by the primary key
+	if len(stmt.OrderBy) < 1 || stmt.GroupBy != nil || stmt.Distinct {
+		return nil
+	}
+
+	_, tb0, ok := vt.Topology().Smallest()
+	if !ok {
+		return errors.Errorf("cannot compute minimal topology from '%s'", vt.Name())
+	}
+
+	metadata, err := loadMetadataByTable(ctx, tb0)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	exists := make(map[string]struct{}, len(stmt.OrderBy))
+	for _, it := range stmt.OrderBy {
+		if cn, ok := it.Expr.(ast.ColumnNameExpressionAtom); ok {
+			exists[strings.ToLower(cn.Suffix())] = struct{}{}
+		}
+	}
+
+	for _, pk := range metadata.PrimaryKeyColumns {
+		if _, ok := exists[strings.ToLower(pk)]; ok {
+			continue
+		}
+		stmt.OrderBy = append(stmt.OrderBy, &ast.OrderByItem{
+			Expr: ast.NewSingleColumnNameExpressionAtom(pk),
+		})
+	}
+
+	return nil
+}
+
+// appendGroupByOrderBy sorts the groups by the group items if ORDER BY is absent, the strict SQL doesn't guarantee
+// the order of groups, but the legacy MySQL returns them ordered by the group items implicitly.
+// For example:
+//
+//	SELECT dept, COUNT(*) FROM emp GROUP BY dept DESC, year
+//	  => SELECT dept, COUNT(*) FROM emp GROUP BY dept DESC, year ORDER BY dept DESC, year
+func appendGroupByOrderBy(stmt *ast.SelectStatement) {
+	if stmt.GroupBy == nil || len(stmt.OrderBy) > 0 {
+		return
+	}
+
+	orderBy := make(ast.OrderByNode, 0, len(stmt.GroupBy.Items))
+	for _, item := range stmt.GroupBy.Items {
+		pen, ok := item.Expr().(*ast.PredicateExpressionNode)
+		if !ok {
+			break
+		}
+		apn, ok := pen.P.(*ast.AtomPredicateNode)
+		if !ok {
+			break
+		}
+		orderBy = append(orderBy, &ast.OrderByItem{
+			Expr: apn.A,
+			Desc: item.IsOrderDesc(),
+		})
+	}
+
+	if len(orderBy) > 0 {
+		stmt.OrderBy = orderBy
+	}
+}
+
+// rewriteCountByPrimaryKey replaces COUNT(*) with COUNT(pk), which may be an index-only scan for some engines.
+// The primary key is never NULL, so the count is always equivalent.
+// For example:
+//
+//	SELECT COUNT(*) FROM student WHERE age > 18
+//	  => SELECT COUNT(`id`) FROM student WHERE age > 18
+//
+// The display name of COUNT(*) won't be changed.
+func rewriteCountByPrimaryKey(ctx context.Context, stmt *ast.SelectStatement, vt *rule.VTable) error {
+	var counts []*ast.SelectElementFunction
+	for _, sel := range stmt.Select {
+		f, ok := sel.(*ast.SelectElementFunction)
+		if !ok {
+			continue
+		}
+		if af, ok := f.Function().(*ast.AggrFunction); ok && isCountStar(af) {
+			counts = append(counts, f)
+		}
+	}
+	if len(counts) < 1 {
+		return nil
+	}
+
+	_, tb0, ok := vt.Topology().Smallest()
+	if !ok {
+		return errors.Errorf("cannot compute minimal topology from '%s'", vt.Name())
+	}
+
+	metadata, err := loadMetadataByTable(ctx, tb0)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(metadata.PrimaryKeyColumns) < 1 {
+		return nil
+	}
+
+	pk := metadata.PrimaryKeyColumns[0]
+	for _, f := range counts {
+		f.SetAggrFunction(ast.NewAggrFunction(ast.AggrCount, "", []*ast.FunctionArg{
+			{
+				Type:  ast.FunctionArgColumn,
+				Value: ast.NewSingleColumnNameExpressionAtom(pk),
+			},
+		}))
+	}
+	return nil
+}
+
+// isApproxCountable returns the column name if the statement is 'SELECT COUNT(*) FROM <table>',
+// which can be estimated by the table statistics.
+func isApproxCountable(stmt *ast.SelectStatement) (string, bool) {
+	if stmt.Where != nil || stmt.GroupBy != nil || stmt.Having != nil || stmt.Distinct || len(stmt.Select) != 1 {
+		return "", false
+	}
+	f, ok := stmt.Select[0].(*ast.SelectElementFunction)
+	if !ok {
+		return "", false
+	}
+	if af, ok := f.Function().(*ast.AggrFunction); !ok || !isCountStar(af) {
+		return "", false
+	}
+	return f.DisplayName(), true
+}
+
+// isCountStar returns true if the aggregate function is COUNT(*), which is parsed as COUNT(1).
+func isCountStar(af *ast.AggrFunction) bool {
+	if af.Name() != ast.AggrCount {
+		return false
+	}
+	if _, ok := af.Aggregator(); ok {
+		return false
+	}
+	if af.IsCountStar() {
+		return true
+	}
+	args := af.Args()
+	if len(args) != 1 || args[0].Type != ast.FunctionArgConstant {
+		return false
+	}
+	_, isNull := args[0].Value.(proto.Null)
+	return !isNull
+}
+
 func loadMetadataByTable(ctx context.Context, tb string) (*proto.TableMetadata, error) {
 	metadatas, err := proto.LoadSchemaLoader().Load(ctx, rcontext.Schema(ctx), []string{tb})
 	if err != nil {
@@ -992,3 +1783,36 @@ func filterNodeByTable(expNode ast.ExpressionNode, metadata *proto.TableMetadata
 	}
 	return nil
 }
+
+// hasVariableAssignment returns true if any select element assigns a user variable, eg: SELECT @rownum := @rownum + 1.
+func hasVariableAssignment(stmt *ast.SelectStatement) bool {
+	var found bool
+	check := func(node ast.Node) {
+		if v, ok := node.(*ast.SystemVariableExpressionAtom); ok && v.IsAssignment() {
+			found = true
+		}
+	}
+	for _, sel := range stmt.Select {
+		switch it := sel.(type) {
+		case *ast.SelectElementFunction:
+			ast.Inspect(it.Function(), check)
+		case *ast.SelectElementExpr:
+			ast.Inspect(it.Expression(), check)
+		}
+	}
+	return found
+}
+
+// coalesceTables splits the tables of a database into the groups of at most n tables, each group is queried
+// by one UNION ALL query. All the tables are in one group if n is not positive.
+func coalesceTables(tables []string, n int) [][]string {
+	if n <= 0 || len(tables) <= n {
+		return [][]string{tables}
+	}
+	ret := make([][]string, 0, (len(tables)+n-1)/n)
+	for len(tables) > n {
+		ret = append(ret, tables[:n:n])
+		tables = tables[n:]
+	}
+	return append(ret, tables)
+}
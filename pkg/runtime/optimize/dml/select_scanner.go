@@ -31,7 +31,7 @@ import (
 import (
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/runtime/ast"
-	"github.com/arana-db/arana/pkg/runtime/optimize/dml/ext"
+	"github.com/arana-db/arana/pkg/runtime/ast/ext"
 	"github.com/arana-db/arana/third_party/base58"
 )
 
@@ -39,10 +39,12 @@ const _autoPrefix = "__arana_"
 
 type selectResult struct {
 	hasAggregate     bool
+	hasDistinct      bool
 	hasMapping       bool
 	hasWeak          bool
 	orders           []*ext.OrderedSelectElement
 	groups           []*ext.OrderedSelectElement
+	having           *havingResult
 	normalizedFields []string
 }
 
@@ -53,6 +55,8 @@ type selectScanner struct {
 
 	selectIndex      map[string]ast.SelectElement // normal selectIndex: `foo` => foo as bar
 	selectAliasIndex map[string]ast.SelectElement // alias selectIndex: `bar` => foo as bar
+
+	caseSensitive bool // match the columns of order-by case-sensitively
 }
 
 func newSelectScanner(stmt *ast.SelectStatement, args []proto.Value) *selectScanner {
@@ -75,6 +79,10 @@ func (sc *selectScanner) scan(result *selectResult) error {
 		return errors.WithStack(err)
 	}
 
+	if err := sc.anaHaving(result); err != nil {
+		return errors.WithStack(err)
+	}
+
 	if err := sc.anaAggregate(result); err != nil {
 		return errors.WithStack(err)
 	}
@@ -145,6 +153,10 @@ func (sc *selectScanner) anaOrderBy(dst *selectResult) error {
 		}
 
 		sel, isAlias, ok := sc.indexOfSelect(search)
+		// the column names are case-insensitive, eg: SELECT id ... ORDER BY ID
+		if _, isColumn := orderBy.Expr.(ast.ColumnNameExpressionAtom); !ok && isColumn && !sc.caseSensitive {
+			sel, isAlias, ok = sc.indexOfSelectFold(search)
+		}
 
 		// 1. order-by exists in select elements
 		// 2. order-by is missing, will create and append a weak select element.
@@ -204,6 +216,7 @@ func (sc *selectScanner) anaOrderBy(dst *selectResult) error {
 			SelectElement: sel,
 			Ordinal:       i,
 			Desc:          orderBy.Desc,
+			Collate:       orderBy.Collate,
 			OrderBy:       true,
 		})
 	}
@@ -243,6 +256,21 @@ func (sc *selectScanner) indexOfSelect(search string) (ret ast.SelectElement, is
 	return
 }
 
+// indexOfSelectFold is same as indexOfSelect, but the search is matched case-insensitively.
+func (sc *selectScanner) indexOfSelectFold(search string) (ret ast.SelectElement, isAlias, ok bool) {
+	for k, v := range sc.selectAliasIndex {
+		if strings.EqualFold(k, search) {
+			return v, true, true
+		}
+	}
+	for k, v := range sc.selectIndex {
+		if strings.EqualFold(k, search) {
+			return v, false, true
+		}
+	}
+	return
+}
+
 func (sc *selectScanner) anaAggregate(result *selectResult) error {
 	var av aggregateVisitor
 	if _, err := sc.stmt.Accept(&av); err != nil {
@@ -250,6 +278,7 @@ func (sc *selectScanner) anaAggregate(result *selectResult) error {
 	}
 
 	result.hasAggregate = len(av.aggregations) > 0
+	result.hasDistinct = av.hasDistinct
 	result.hasMapping = av.hasMapping
 	result.hasWeak = result.hasWeak || av.hasWeak
 
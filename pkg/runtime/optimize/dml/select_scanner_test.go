@@ -109,3 +109,72 @@ func TestSelectScanner_Scan(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectScanner_ScanHaving(t *testing.T) {
+	type tt struct {
+		sql        string
+		selects    []string
+		having     bool
+		aggregates map[string]string
+	}
+
+	genAlias := func(name string) string {
+		var (
+			xh = xxhash.New()
+			sb strings.Builder
+		)
+		writeAutoAlias(xh, &sb, name)
+		return sb.String()
+	}
+
+	for _, it := range []tt{
+		{
+			"select name, count(*) from student group by name having name <> 'foo'",
+			[]string{"`name`", "COUNT(1)"},
+			false,
+			nil,
+		},
+		{
+			"select name, count(*) c from student group by name having c > 5",
+			[]string{"`name`", "COUNT(1) AS `c`"},
+			true,
+			map[string]string{},
+		},
+		{
+			"select name, count(*) c from student group by name having count(*) > 5 and c < 10",
+			[]string{"`name`", "COUNT(1) AS `c`"},
+			true,
+			map[string]string{"COUNT(1)": "c"},
+		},
+		{
+			"select name from student group by name having max(score) > 60",
+			[]string{"`name`", "MAX(`score`) AS `" + genAlias("MAX(`score`)") + "`"},
+			true,
+			map[string]string{"MAX(`score`)": genAlias("MAX(`score`)")},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			_, stmt, _ := ast.ParseSelect(it.sql)
+			var result selectResult
+			scanner := newSelectScanner(stmt, nil)
+			err := scanner.scan(&result)
+			assert.NoError(t, err)
+
+			var selects []string
+			for i := range stmt.Select {
+				selects = append(selects, ast.MustRestoreToString(ast.RestoreDefault, stmt.Select[i]))
+			}
+			assert.Equal(t, it.selects, selects)
+
+			if !it.having {
+				assert.Nil(t, result.having)
+				assert.NotNil(t, stmt.Having)
+				return
+			}
+			assert.Nil(t, stmt.Having)
+			if assert.NotNil(t, result.having) {
+				assert.Equal(t, it.aggregates, result.having.aggregates)
+			}
+		})
+	}
+}
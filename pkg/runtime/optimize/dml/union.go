@@ -0,0 +1,195 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/dataset"
+	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/optimize"
+	"github.com/arana-db/arana/pkg/runtime/plan/dml"
+)
+
+func init() {
+	optimize.Register(ast.SQLTypeUnion, optimizeUnion)
+}
+
+// optimizeUnion optimizes each SELECT of UNION, INTERSECT and EXCEPT independently, then the merged results
+// of them are combined in memory. INTERSECT binds tighter than UNION and EXCEPT, eg:
+// "a UNION b INTERSECT c" is computed as "a UNION (b INTERSECT c)".
+func optimizeUnion(ctx context.Context, o *optimize.Optimizer) (proto.Plan, error) {
+	stmt := o.Stmt.(*ast.UnionSelectStatement)
+
+	if err := checkUnionColumns(stmt); err != nil {
+		return nil, err
+	}
+
+	optimizeBranch := func(sel *ast.SelectStatement) (proto.Plan, error) {
+		// the index of placeholder is global, so all args should be passed
+		p, err := optimizeSelect(ctx, &optimize.Optimizer{
+			Rule:  o.Rule,
+			Hints: o.Hints,
+			Stmt:  sel,
+			Args:  o.Args,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to optimize set operation")
+		}
+		return p, nil
+	}
+
+	first, err := optimizeBranch(stmt.First)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		operands   = []proto.Plan{first}
+		operations []ast.UnionType
+	)
+	for _, it := range stmt.UnionStatementItems {
+		next, err := optimizeBranch(it.Stmt)
+		if err != nil {
+			return nil, err
+		}
+		if it.Type.IsIntersect() {
+			operands[len(operands)-1] = &dml.SetOperationPlan{
+				Left:      operands[len(operands)-1],
+				Right:     next,
+				Operation: it.Type,
+			}
+			continue
+		}
+		operands = append(operands, next)
+		operations = append(operations, it.Type)
+	}
+
+	ret := operands[0]
+	for i, operation := range operations {
+		ret = &dml.SetOperationPlan{
+			Left:      ret,
+			Right:     operands[i+1],
+			Operation: operation,
+		}
+	}
+
+	// the ORDER BY and LIMIT of set operation apply to the combined rows
+	if len(stmt.OrderBy) > 0 {
+		orderByItems, err := unionOrderByItems(stmt)
+		if err != nil {
+			return nil, err
+		}
+		ret = &dml.SortPlan{
+			ParentPlan:   ret,
+			OrderByItems: orderByItems,
+		}
+	}
+	if stmt.Limit != nil {
+		offset, limit, err := unionLimit(stmt.Limit, o.Args)
+		if err != nil {
+			return nil, err
+		}
+		ret = &dml.LimitPlan{
+			ParentPlan:     ret,
+			OriginOffset:   offset,
+			OverwriteLimit: offset + limit,
+		}
+	}
+
+	return ret, nil
+}
+
+// unionOrderByItems resolves the ORDER BY items of set operation, which can only refer to the columns
+// of first SELECT by name.
+func unionOrderByItems(stmt *ast.UnionSelectStatement) ([]dataset.OrderByItem, error) {
+	ret := make([]dataset.OrderByItem, 0, len(stmt.OrderBy))
+	for _, it := range stmt.OrderBy {
+		column, ok := it.Expr.(ast.ColumnNameExpressionAtom)
+		if !ok {
+			return nil, errors.New("unsupported: ORDER BY expression of set operation, only the column names are allowed")
+		}
+		ret = append(ret, dataset.OrderByItem{
+			Column:  column.Suffix(),
+			Desc:    it.Desc,
+			Collate: it.Collate,
+		})
+	}
+	return ret, nil
+}
+
+// unionLimit returns the offset and limit of set operation, the placeholders are resolved from args.
+func unionLimit(limit *ast.LimitNode, args []proto.Value) (int64, int64, error) {
+	resolve := func(n int64, isVar bool) (int64, error) {
+		if !isVar {
+			return n, nil
+		}
+		if n < 0 || n >= int64(len(args)) || args[n] == nil {
+			return 0, errors.Errorf("missing argument of LIMIT placeholder %d", n)
+		}
+		return args[n].Int64()
+	}
+
+	var (
+		offset, count int64
+		err           error
+	)
+	if limit.HasOffset() {
+		if offset, err = resolve(limit.Offset(), limit.IsOffsetVar()); err != nil {
+			return 0, 0, errors.WithStack(err)
+		}
+	}
+	if count, err = resolve(limit.Limit(), limit.IsLimitVar()); err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+	return offset, count, nil
+}
+
+// checkUnionColumns returns error if the SELECT statements have a different number of columns.
+func checkUnionColumns(stmt *ast.UnionSelectStatement) error {
+	countColumns := func(sel *ast.SelectStatement) int {
+		for _, it := range sel.Select {
+			if _, ok := it.(*ast.SelectElementAll); ok {
+				// cannot be determined before expanding the star
+				return -1
+			}
+		}
+		return len(sel.Select)
+	}
+
+	expect := countColumns(stmt.First)
+	for _, it := range stmt.UnionStatementItems {
+		actual := countColumns(it.Stmt)
+		if expect < 0 || actual < 0 {
+			continue
+		}
+		if actual != expect {
+			return mysqlErrors.NewSQLError(mysql.ERWrongNumberOfColumnsInSelect, mysql.SSWrongNumberOfColumnsInSelect, "The used SELECT statements have a different number of columns")
+		}
+	}
+	return nil
+}
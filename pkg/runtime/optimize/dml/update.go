@@ -19,6 +19,7 @@ package dml
 
 import (
 	"context"
+	"strings"
 )
 
 import (
@@ -48,6 +49,10 @@ func optimizeUpdate(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		ok    bool
 	)
 
+	if ret, ok := optimizeSchemaRoute(o, table); ok {
+		return ret, nil
+	}
+
 	// non-sharding update
 	if vt, ok = o.Rule.VTable(table.Suffix()); !ok {
 		ret := dml.NewUpdatePlan(stmt)
@@ -58,7 +63,7 @@ func optimizeUpdate(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 	// check update sharding key
 	vShards := vt.GetVShards()
 	for _, element := range stmt.Updated {
-		column := element.Column.Suffix()
+		column := vt.NormalizeColumn(element.Column.Suffix())
 		for _, vShard := range vShards {
 			if slices.Index(vShard.Variables(), column) != -1 {
 				return nil, errors.Errorf("your value to be updated is belong to a sharding key '%s'", column)
@@ -66,31 +71,15 @@ func optimizeUpdate(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		}
 	}
 
-	var (
-		shards   rule.DatabaseTables
-		fullScan = true
-		err      error
-	)
-
-	// compute shards
-	if where := stmt.Where; where != nil {
-		if len(o.Hints) > 0 {
-			if shards, err = optimize.Hints(table, o.Hints, o.Rule); err != nil {
-				return nil, errors.Wrap(err, "calculate hints failed")
-			}
-		}
-
-		if shards == nil {
-			if shards, err = optimize.NewXSharder(ctx, o.Rule, o.Args).SimpleShard(table, where); err != nil {
-				return nil, errors.Wrap(err, "failed to update")
-			}
-			fullScan = shards == nil
-		}
+	// exit if full-scan is disabled
+	if stmt.Where == nil && !vt.AllowFullScan() {
+		return nil, errors.Wrapf(optimize.ErrDenyFullScan, "unconditional UPDATE on table '%s' is blocked, please add a WHERE clause", table.Suffix())
 	}
 
-	// exit if full-scan is disabled
-	if fullScan && !vt.AllowFullScan() {
-		return nil, optimize.ErrDenyFullScan
+	// compute shards in the same way as SELECT
+	shards, err := o.ComputeShards(ctx, table, stmt.Where, o.Args)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update")
 	}
 
 	// must be empty shards (eg: update xxx set ... where 1 = 2 and uid = 1)
@@ -98,10 +87,30 @@ func optimizeUpdate(ctx context.Context, o *optimize.Optimizer) (proto.Plan, err
 		return plan.AlwaysEmptyExecPlan{}, nil
 	}
 
-	// compute all sharding tables
-	if shards.IsFullScan() {
-		// compute all tables
-		shards = vt.Topology().Enumerate()
+	// skip the shards which definitely contain none of the updated keys
+	if shards = optimize.FilterShardsByKey(ctx, vt, shards, stmt.Where, o.Args); shards.IsEmpty() {
+		return plan.AlwaysEmptyExecPlan{}, nil
+	}
+
+	// the LIMIT would be applied to each shard, which updates more rows than expected, and the ORDER BY
+	// cannot decide which rows of all shards are updated. It is only supported within a single shard.
+	if stmt.Limit != nil && shards.Len() > 1 {
+		return nil, errors.Errorf("UPDATE with LIMIT across %d shards of table '%s' is not supported, please narrow it to a single shard by the sharding key", shards.Len(), table.Suffix())
+	}
+
+	// the new keys cannot be tracked, disable the bloom filter of updated tables
+	if kf := vt.KeyFilter(); kf != nil {
+		for _, element := range stmt.Updated {
+			if !strings.EqualFold(element.Column.Suffix(), kf.Column()) {
+				continue
+			}
+			for db, tables := range shards {
+				for _, it := range tables {
+					kf.Invalidate(db, it)
+				}
+			}
+			break
+		}
 	}
 
 	ret := dml.NewUpdatePlan(stmt)
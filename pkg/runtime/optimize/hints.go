@@ -47,9 +47,9 @@ func RegisterHint(t hint.Type, h HintExecutor) {
 	hintHandlers[t] = h
 }
 
-// validate hints of the same type are allowed only once,such as (master||slave) || (router||fullScan||direct)
+// validate hints of the same type are allowed only once,such as (master||slave) || (router||fullScan||direct) || (hashJoin||nestedLoop)
 func validate(hints []*hint.Hint) error {
-	var shardingType, nodeType hint.Type
+	var shardingType, nodeType, joinType hint.Type
 
 	for _, v := range hints {
 		if v.Type == hint.TypeFullScan || v.Type == hint.TypeDirect || v.Type == hint.TypeRoute {
@@ -58,12 +58,22 @@ func validate(hints []*hint.Hint) error {
 			}
 			shardingType = v.Type
 		}
-		if v.Type == hint.TypeMaster || v.Type == hint.TypeSlave {
+		if v.Type == hint.TypeMaster || v.Type == hint.TypeSlave || v.Type == hint.TypeReplica {
 			if nodeType > 0 {
 				return errors.Errorf("hint type conflict:%s,%s", nodeType.String(), v.Type.String())
 			}
 			nodeType = v.Type
 		}
+		if v.Type == hint.TypeHashJoin || v.Type == hint.TypeNestedLoop {
+			if joinType > 0 {
+				return errors.Errorf("hint type conflict:%s,%s", joinType.String(), v.Type.String())
+			}
+			joinType = v.Type
+		}
+		// validate TypeReplica
+		if v.Type == hint.TypeReplica && len(v.Inputs) != 1 {
+			return errors.Errorf("replica hint format error")
+		}
 		// validate TypeRoute
 		if v.Type == hint.TypeRoute {
 			for _, i := range v.Inputs {
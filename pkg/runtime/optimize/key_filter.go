@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package optimize
+
+import (
+	"context"
+	"strings"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/cmp"
+	"github.com/arana-db/arana/pkg/runtime/misc/extvalue"
+)
+
+// FilterShardsByKey removes the shards which definitely contain none of the queried keys, it consults the
+// bloom filter of VTable when the where clause has a conjunctive 'key IN (...)' or 'key = ?' condition.
+// The input shards will be returned directly if the filter cannot be applied, nil shards means full scan.
+func FilterShardsByKey(ctx context.Context, vt *rule.VTable, shards rule.DatabaseTables, where ast.ExpressionNode, args []proto.Value) rule.DatabaseTables {
+	kf := vt.KeyFilter()
+	if kf == nil || where == nil {
+		return shards
+	}
+
+	keys, ok := extractFilterKeys(ctx, kf.Column(), where, args)
+	if !ok {
+		return shards
+	}
+
+	if shards == nil {
+		shards = vt.Topology().Enumerate()
+	}
+
+	ret := make(rule.DatabaseTables)
+	for db, tables := range shards {
+		for _, table := range tables {
+			for _, key := range keys {
+				if kf.MayContain(db, table, key) {
+					ret[db] = append(ret[db], table)
+					break
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// extractFilterKeys collects the keys from where clause, only conjunctive conditions are supported.
+func extractFilterKeys(ctx context.Context, column string, where ast.ExpressionNode, args []proto.Value) ([]proto.Value, bool) {
+	switch node := where.(type) {
+	case *ast.LogicalExpressionNode:
+		if node.Or {
+			return nil, false
+		}
+		if keys, ok := extractFilterKeys(ctx, column, node.Left, args); ok {
+			return keys, true
+		}
+		return extractFilterKeys(ctx, column, node.Right, args)
+	case *ast.PredicateExpressionNode:
+		return extractPredicateKeys(ctx, column, node.P, args)
+	}
+	return nil, false
+}
+
+func extractPredicateKeys(ctx context.Context, column string, p ast.PredicateNode, args []proto.Value) ([]proto.Value, bool) {
+	isKeyColumn := func(p ast.PredicateNode) bool {
+		atom, ok := p.(*ast.AtomPredicateNode)
+		if !ok {
+			return false
+		}
+		c, ok := atom.Column()
+		return ok && strings.EqualFold(c.Suffix(), column)
+	}
+
+	switch node := p.(type) {
+	case *ast.InPredicateNode:
+		if node.Not || !isKeyColumn(node.P) {
+			return nil, false
+		}
+		keys := make([]proto.Value, 0, len(node.E))
+		for _, e := range node.E {
+			key, err := extvalue.Compute(ctx, e, args...)
+			if err != nil || key == nil {
+				return nil, false
+			}
+			keys = append(keys, key)
+		}
+		return keys, true
+	case *ast.BinaryComparisonPredicateNode:
+		if node.Op != cmp.Ceq {
+			return nil, false
+		}
+		var value ast.PredicateNode
+		switch {
+		case isKeyColumn(node.Left):
+			value = node.Right
+		case isKeyColumn(node.Right):
+			value = node.Left
+		default:
+			return nil, false
+		}
+		key, err := extvalue.Compute(ctx, value, args...)
+		if err != nil || key == nil {
+			return nil, false
+		}
+		return []proto.Value{key}, true
+	}
+	return nil, false
+}
@@ -46,9 +46,11 @@ var Tracer = otel.Tracer("optimize")
 
 // errors group
 var (
-	ErrNoRuleFound     = errors.New("optimize: no rule found")
-	ErrDenyFullScan    = errors.New("optimize: the full-scan query is not allowed")
-	ErrNoShardKeyFound = errors.New("optimize: no shard key found")
+	ErrNoRuleFound      = errors.New("optimize: no rule found")
+	ErrDenyFullScan     = errors.New("optimize: the full-scan query is not allowed")
+	ErrNoShardKeyFound  = errors.New("optimize: no shard key found")
+	ErrStatementBlocked = errors.New("optimize: the statement is blocked by policy")
+	ErrReadOnlyTable    = errors.New("optimize: the table is read-only")
 )
 
 // IsNoShardKeyFoundErr returns true if target error is caused by NO-SHARD-KEY-FOUND
@@ -61,6 +63,16 @@ func IsNoRuleFoundErr(err error) bool {
 	return perrors.Is(err, ErrNoRuleFound)
 }
 
+// IsStatementBlockedErr returns true if target error is caused by STATEMENT-BLOCKED.
+func IsStatementBlockedErr(err error) bool {
+	return perrors.Is(err, ErrStatementBlocked)
+}
+
+// IsReadOnlyTableErr returns true if target error is caused by READ-ONLY-TABLE.
+func IsReadOnlyTableErr(err error) bool {
+	return perrors.Is(err, ErrReadOnlyTable)
+}
+
 // IsDenyFullScanErr returns true if target error is caused by DENY-FULL-SCAN.
 func IsDenyFullScanErr(err error) bool {
 	return perrors.Is(err, ErrDenyFullScan)
@@ -90,6 +102,11 @@ func NewOptimizer(rule *rule.Rule, hints []*hint.Hint, stmt ast.StmtNode, args [
 		return nil, perrors.Wrap(err, "optimize failed")
 	}
 
+	// each placeholder is bound to exactly one arg, the lists of 'IN (?,?,?)' are expanded by driver before preparing
+	if n := rast.CountParams(stmt); n != len(args) {
+		return nil, perrors.Errorf("optimize failed: the statement has %d placeholders, but %d args are given", n, len(args))
+	}
+
 	return &Optimizer{
 		Rule:  rule,
 		Hints: hints,
@@ -114,14 +131,102 @@ func (o *Optimizer) Optimize(ctx context.Context) (plan proto.Plan, err error) {
 		return nil, perrors.Errorf("optimize: no handler found for '%s'", o.Stmt.Mode())
 	}
 
+	if err = inlineViews(o.Rule, o.Stmt); err != nil {
+		return nil, err
+	}
+
+	if err = checkPolicies(o.Rule, o.Stmt); err != nil {
+		return nil, err
+	}
+
+	if err = checkReadOnly(o.Rule, o.Stmt); err != nil {
+		return nil, err
+	}
+
+	injectTenantPredicates(ctx, o.Rule, o.Stmt)
+
 	return h(ctx, o)
 }
 
 func (o *Optimizer) ComputeShards(ctx context.Context, table rast.TableName, where rast.ExpressionNode, args []proto.Value) (rule.DatabaseTables, error) {
+	shards, _, err := o.computeShards(ctx, table, where, args)
+	return shards, err
+}
+
+// TableShards represents the shards of a sharding table which the statement will be routed to.
+type TableShards struct {
+	Table    string              // the logical table name
+	Shards   rule.DatabaseTables // the physical tables
+	FullScan bool                // whether the shards cannot be pruned
+}
+
+// ComputeStatementShards computes the shards of each sharding table in the statement without building the plan,
+// the statement is preprocessed as Optimize does, eg: the views are inlined. Only SELECT, UPDATE and DELETE are supported.
+func (o *Optimizer) ComputeStatementShards(ctx context.Context) ([]TableShards, error) {
+	if err := inlineViews(o.Rule, o.Stmt); err != nil {
+		return nil, err
+	}
+	injectTenantPredicates(ctx, o.Rule, o.Stmt)
+
+	var (
+		tables []rast.TableName
+		wheres []rast.ExpressionNode
+	)
+	switch stmt := o.Stmt.(type) {
+	case *rast.SelectStatement:
+		for _, from := range stmt.From {
+			items := []*rast.TableSourceItem{&from.TableSourceItem}
+			for _, join := range from.Joins {
+				items = append(items, join.Target)
+			}
+			for _, it := range items {
+				table, ok := it.Source.(rast.TableName)
+				if !ok {
+					continue
+				}
+				where := stmt.Where
+				// the joined tables are pruned by their own bindings, eg: 'a.uid = 7 AND b.uid = 7'
+				if len(stmt.From) > 1 || len(from.Joins) > 0 {
+					where = TableBindings(stmt.Where, table.Suffix(), it.Alias)
+				}
+				tables = append(tables, table)
+				wheres = append(wheres, where)
+			}
+		}
+	case *rast.UpdateStatement:
+		tables = append(tables, stmt.Table)
+		wheres = append(wheres, stmt.Where)
+	case *rast.DeleteStatement:
+		tables = append(tables, stmt.Table)
+		wheres = append(wheres, stmt.Where)
+	default:
+		return nil, perrors.Errorf("cannot compute shards of '%s' statement", o.Stmt.Mode())
+	}
+
+	var ret []TableShards
+	for i, table := range tables {
+		shards, fullScan, err := o.computeShards(ctx, table, wheres[i], o.Args)
+		if err != nil {
+			return nil, err
+		}
+		// non-sharding table
+		if shards == nil {
+			continue
+		}
+		ret = append(ret, TableShards{
+			Table:    table.Suffix(),
+			Shards:   shards,
+			FullScan: fullScan,
+		})
+	}
+	return ret, nil
+}
+
+func (o *Optimizer) computeShards(ctx context.Context, table rast.TableName, where rast.ExpressionNode, args []proto.Value) (rule.DatabaseTables, bool, error) {
 	ru := o.Rule
 	vt, ok := ru.VTable(table.Suffix())
 	if !ok {
-		return nil, nil
+		return nil, false, nil
 	}
 	var (
 		shards   rule.DatabaseTables
@@ -131,33 +236,14 @@ func (o *Optimizer) ComputeShards(ctx context.Context, table rast.TableName, whe
 
 	if len(o.Hints) > 0 {
 		if shards, err = Hints(table, o.Hints, o.Rule); err != nil {
-			return nil, perrors.Wrap(err, "calculate hints failed")
+			return nil, false, perrors.Wrap(err, "calculate hints failed")
 		}
 	}
 
+	// share the sharder with SELECT, so that the writes are pruned in the same way
 	if shards == nil {
-		xsd := NewXSharder(ctx, ru, args)
-		if err := xsd.ForSingleSelect(table, "", where); err != nil {
-			return nil, perrors.Wrapf(err, "optimize: cannot calculate shards of table '%s'", table.Suffix())
-		}
-		for i := range xsd.results {
-			if xsd.results[i].L.Suffix() == table.Suffix() {
-				if r := xsd.results[i].R; r != nil {
-					shards = make(rule.DatabaseTables)
-					r.Each(func(db, tb uint32) bool {
-						dbs, tbs, ok := vt.Topology().Render(int(db), int(tb))
-						if !ok {
-							err = perrors.Errorf("cannot render table '%s'", vt.Name())
-							return false
-						}
-						shards[dbs] = append(shards[dbs], tbs)
-						return true
-					})
-					if err != nil {
-						return nil, err
-					}
-				}
-			}
+		if shards, err = NewXSharder(ctx, ru, args).SimpleShard(table, where); err != nil {
+			return nil, false, perrors.Wrap(err, "optimize")
 		}
 	}
 
@@ -165,11 +251,14 @@ func (o *Optimizer) ComputeShards(ctx context.Context, table rast.TableName, whe
 
 	// return error if full-scan is disabled
 	if fullScan && !vt.AllowFullScan() {
-		return nil, perrors.WithStack(ErrDenyFullScan)
+		return nil, false, perrors.WithStack(ErrDenyFullScan)
+	}
+	if fullScan {
+		rcontext.MarkFullScan(ctx)
 	}
 
 	if shards.IsEmpty() {
-		return shards, nil
+		return shards, fullScan, nil
 	}
 
 	if len(shards) == 0 {
@@ -177,5 +266,5 @@ func (o *Optimizer) ComputeShards(ctx context.Context, table rast.TableName, whe
 		shards = vt.Topology().Enumerate()
 	}
 
-	return shards, nil
+	return shards, fullScan, nil
 }
@@ -20,7 +20,11 @@ package optimize_test
 import (
 	"context"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -36,17 +40,153 @@ import (
 	consts "github.com/arana-db/arana/pkg/constants/mysql"
 	"github.com/arana-db/arana/pkg/dataset"
 	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
 	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/hint"
 	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/resultx"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+	"github.com/arana-db/arana/pkg/runtime/namespace"
 	. "github.com/arana-db/arana/pkg/runtime/optimize"
 	_ "github.com/arana-db/arana/pkg/runtime/optimize/dal"
 	_ "github.com/arana-db/arana/pkg/runtime/optimize/ddl"
 	_ "github.com/arana-db/arana/pkg/runtime/optimize/dml"
 	_ "github.com/arana-db/arana/pkg/runtime/optimize/utility"
+	rplan "github.com/arana-db/arana/pkg/runtime/plan"
+	"github.com/arana-db/arana/pkg/runtime/plan/dml"
 	"github.com/arana-db/arana/testdata"
 )
 
+// fakeQuery is a query received by the fake connection.
+type fakeQuery struct {
+	db   string
+	sql  string
+	args []interface{}
+}
+
+// queryRecorder records the queries received by the fake connection, the shards may be queried concurrently.
+type queryRecorder struct {
+	mu      sync.Mutex
+	queries []fakeQuery
+}
+
+func (qr *queryRecorder) record(db, sql string, args []interface{}) {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	qr.queries = append(qr.queries, fakeQuery{db: db, sql: sql, args: args})
+}
+
+func (qr *queryRecorder) reset() {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	qr.queries = nil
+}
+
+// sqls returns the sql of each query.
+func (qr *queryRecorder) sqls() []string {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	var ret []string
+	for _, it := range qr.queries {
+		ret = append(ret, it.sql)
+	}
+	return ret
+}
+
+// dbs returns the database of each query.
+func (qr *queryRecorder) dbs() []string {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	var ret []string
+	for _, it := range qr.queries {
+		ret = append(ret, it.db)
+	}
+	return ret
+}
+
+// args returns the printed args of each query, eg: '[1 foo]'.
+func (qr *queryRecorder) args() []string {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	var ret []string
+	for _, it := range qr.queries {
+		ret = append(ret, fmt.Sprint(it.args))
+	}
+	return ret
+}
+
+// tables returns the physical tables of student in all queries.
+func (qr *queryRecorder) tables() []string {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	var ret []string
+	for _, it := range qr.queries {
+		ret = append(ret, regexp.MustCompile("student_\\d+").FindAllString(it.sql, -1)...)
+	}
+	return ret
+}
+
+// recordQueries returns a fake connection which records all queries, each query returns an empty dataset of the fields.
+func recordQueries(t *testing.T, ctrl *gomock.Controller, fields ...proto.Field) (*testdata.MockVConn, *queryRecorder) {
+	var (
+		conn = testdata.NewMockVConn(ctrl)
+		qr   = new(queryRecorder)
+	)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			qr.record(db, sql, args)
+			return resultx.New(resultx.WithDataset(&dataset.VirtualDataset{Columns: fields})), nil
+		}).
+		AnyTimes()
+	return conn, qr
+}
+
+// optimizeQuery parses and optimizes the sql, nil will be returned if failed.
+func optimizeQuery(t *testing.T, ctx context.Context, ru *rule.Rule, sql string, args []proto.Value) proto.Plan {
+	t.Helper()
+
+	stmt, err := parser.New().ParseOneStmt(sql, "", "")
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	opt, err := NewOptimizer(ru, nil, stmt, args)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	plan, err := opt.Optimize(ctx)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	return plan
+}
+
+// execQuery optimizes the sql, executes the plan and drains the result, the fields of result are returned.
+func execQuery(t *testing.T, ctx context.Context, ru *rule.Rule, conn proto.VConn, sql string, args []proto.Value) []proto.Field {
+	t.Helper()
+
+	plan := optimizeQuery(t, ctx, ru, sql, args)
+	if plan == nil {
+		return nil
+	}
+	res, err := plan.ExecIn(ctx, conn)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	ds, err := res.Dataset()
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	fields, err := ds.Fields()
+	assert.NoError(t, err)
+	for {
+		if _, err = ds.Next(); err != nil {
+			assert.ErrorIs(t, err, io.EOF)
+			return fields
+		}
+	}
+}
+
 func TestOptimizer_OptimizeSelect(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -84,193 +224,3482 @@ func TestOptimizer_OptimizeSelect(t *testing.T) {
 	_, _ = plan.ExecIn(ctx, conn)
 }
 
-func TestOptimizer_OptimizeHashJoin(t *testing.T) {
+func TestOptimizer_OptimizeDual(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	studentFields := []proto.Field{
-		mysql.NewField("uid", consts.FieldTypeLongLong),
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("NOW()", consts.FieldTypeDateTime))
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	// same as the SELECT without FROM, which is routed to the default node instead of any shard
+	for _, sql := range []string{"select now()", "select now() from dual", "select now() from `DUAL`"} {
+		qr.reset()
+		fields := execQuery(t, ctx, ru, conn, sql, nil)
+		if assert.Len(t, fields, 1) {
+			assert.Equal(t, "now()", fields[0].Name())
+		}
+		assert.Equal(t, []string{"SELECT NOW()"}, qr.sqls(), sql)
 	}
 
-	salariesFields := []proto.Field{
-		mysql.NewField("uid", consts.FieldTypeLongLong),
+	// the constant is answered directly
+	assert.IsType(t, (*dml.LocalSelectPlan)(nil), optimizeQuery(t, ctx, ru, "select 1+1 from dual", nil))
+
+	// the filtered one is answered by the default node
+	qr.reset()
+	_, err := optimizeQuery(t, ctx, ru, "select 1 from dual where 1 = 0", nil).ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SELECT 1 FROM DUAL WHERE 1 = 0"}, qr.sqls())
+}
+
+func TestOptimizer_OptimizeSelectWarnings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
+	vt.SetAllowFullScan(true)
+
+	type tt struct {
+		sql        string
+		wideFanOut int
+		expect     []string
 	}
 
-	conn := testdata.NewMockVConn(ctrl)
-	buildPlan := true
-	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
-			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+	for _, it := range []tt{
+		{"select id, uid from student", 0, []string{"full table scan across 8 shards of table 'student'"}},
+		{"select id, uid from student where uid in (0,1,2,3,4,5,6,7)", 0, []string{"query fans out to 8 shards of table 'student'"}},
+		{"select id, uid from student where uid in (1,2)", 0, nil},
+		{"select id, uid from student where uid in (1,2)", 2, []string{"query fans out to 2 shards of table 'student'"}},
+		{"select id, uid from student where uid = 1", 0, nil},
+		{"select id, uid from student where uid = 1 or name = 'foo'", 0, []string{
+			"full table scan across 8 shards of table 'student'",
+			"OR condition on non-sharding column prevents shard pruning of table 'student'",
+		}},
+		{"select id, uid from student where (uid = 1 or name = 'foo') and uid = 1", 0, nil},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			vt.SetWideFanOut(it.wideFanOut)
+			defer vt.SetWideFanOut(0)
 
-			result := testdata.NewMockResult(ctrl)
-			fakeData := &dataset.VirtualDataset{}
-			if buildPlan {
-				fakeData.Columns = append(studentFields, mysql.NewField("uid", consts.FieldTypeLongLong))
-				result.EXPECT().Dataset().Return(fakeData, nil).AnyTimes()
-				buildPlan = false
+			ctx := rcontext.WithWarnings(context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true))
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			_, err = opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			var actual []string
+			for _, w := range rcontext.Warnings(ctx) {
+				assert.Equal(t, "Warning", w.Level)
+				assert.Equal(t, uint16(consts.ERUnknownError), w.Code)
+				actual = append(actual, w.Message)
+			}
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+
+	// the OR on non-sharding column degrades to full scan, which is denied by default
+	vt.SetAllowFullScan(false)
+	stmt, _ := parser.New().ParseOneStmt("select id, uid from student where uid = 1 or name = 'foo'", "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+	_, err = opt.Optimize(context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true))
+	assert.True(t, IsDenyFullScanErr(err))
+}
+
+func TestOptimizer_OptimizeSelectDefaultLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
+	vt.SetDefaultLimit(10)
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+
+	type tt struct {
+		sql     string
+		inTx    bool
+		limit   string
+		warning bool
+	}
+
+	for _, it := range []tt{
+		{"select uid from student where uid = 1", false, "LIMIT 10", true},
+		{"select uid from student where uid = 1 limit 3", false, "LIMIT 3", false},
+		{"select uid from student where uid = 1", true, "", false},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			qr.reset()
+
+			ctx := rcontext.WithWarnings(context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true))
+			if it.inTx {
+				ctx = rcontext.WithTransactionID(ctx, "fake_tx")
+			}
+			execQuery(t, ctx, ru, conn, it.sql, nil)
+
+			queries := qr.sqls()
+			assert.Len(t, queries, 1)
+			if len(it.limit) > 0 {
+				assert.Contains(t, queries[0], it.limit)
 			} else {
-				fakeData.Columns = append(salariesFields, mysql.NewField("uid", consts.FieldTypeLongLong))
-				result.EXPECT().Dataset().Return(fakeData, nil).AnyTimes()
+				assert.NotContains(t, queries[0], "LIMIT")
 			}
+			assert.Equal(t, it.warning, len(rcontext.Warnings(ctx)) > 0)
+		})
+	}
+}
 
-			return result, nil
-		}).MinTimes(2)
+func TestOptimizer_OptimizeSelectLimitZero(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-	fakeData := make(map[string]*proto.TableMetadata)
-	// fake data
-	fakeData["student_0000"] = &proto.TableMetadata{
-		Name:        "student_0000",
-		Columns:     map[string]*proto.ColumnMetadata{"uid": {}},
-		ColumnNames: []string{"uid"},
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+
+	type tt struct {
+		sql  string
+		args []proto.Value
 	}
 
-	fakeData["salaries_0000"] = &proto.TableMetadata{
-		Name:        "salaries_0000",
-		Columns:     map[string]*proto.ColumnMetadata{"uid": {}},
-		ColumnNames: []string{"uid"},
+	for _, it := range []tt{
+		{"select uid from student limit 0", nil},
+		{"select uid from student where uid in (1,2,3) limit 10, 0", nil},
+		{"select uid from student order by uid limit ?", []proto.Value{proto.NewValueInt64(0)}},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			qr.reset()
+
+			ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+			fields := execQuery(t, ctx, ru, conn, it.sql, it.args)
+
+			// no scatter-gather
+			queries := qr.sqls()
+			assert.Len(t, queries, 1)
+			assert.Contains(t, queries[0], "LIMIT 0")
+			assert.Len(t, fields, 1)
+		})
 	}
-	loader := testdata.NewMockSchemaLoader(ctrl)
-	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeData, nil).AnyTimes()
+}
 
-	oldLoader := proto.LoadSchemaLoader()
-	proto.RegisterSchemaLoader(loader)
-	defer proto.RegisterSchemaLoader(oldLoader)
+func TestOptimizer_OptimizeSelectSingleShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	single := makeFakeRule(ctrl, "student", 1, nil)
+	vt, _ := single.VTable("student")
+	vt.SetAllowFullScan(true)
+
+	for _, it := range []struct {
+		sql    string
+		ru     *rule.Rule
+		expect string
+	}{
+		{
+			"select uid, name from student where uid = 1 order by name limit 10, 5",
+			makeFakeRule(ctrl, "student", 8, nil),
+			"SELECT `uid`,`name` FROM `student_0001` WHERE `uid` = 1 ORDER BY `name` LIMIT 10,5",
+		},
+		{
+			"select uid, name from student where uid = 1 or uid = 9 order by name",
+			makeFakeRule(ctrl, "student", 8, nil),
+			"SELECT `uid`,`name` FROM `student_0001` WHERE `uid` = 1 OR `uid` = 9 ORDER BY `name`",
+		},
+		{
+			"select uid, count(*) from student group by uid having uid = 5",
+			makeFakeRule(ctrl, "student", 8, nil),
+			"SELECT `uid`,COUNT(1) FROM `student_0005` GROUP BY `uid` HAVING `uid` = 5",
+		},
+		{
+			"select uid, count(*) from student group by uid having count(*) > 1 and uid in (1, 9)",
+			makeFakeRule(ctrl, "student", 8, nil),
+			"SELECT `uid`,COUNT(1) FROM `student_0001` GROUP BY `uid` HAVING COUNT(1) > 1 AND `uid` IN (1,9)",
+		},
+		{
+			"with adult as (select uid, name from student where age > 18) select name from adult where uid = 3",
+			makeFakeRule(ctrl, "student", 8, nil),
+			"SELECT `name` FROM `student_0003` WHERE `age` > 18 AND `uid` = 3",
+		},
+		{
+			"select name, count(*) from student group by name order by name limit 1, 2",
+			single,
+			"SELECT `name`,COUNT(1) FROM `student_0000` GROUP BY `name` ORDER BY `name` LIMIT 1,2",
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			var sqls []string
+			conn := testdata.NewMockVConn(ctrl)
+			conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+					sqls = append(sqls, sql)
+					return resultx.New(), nil
+				}).
+				AnyTimes()
+
+			ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(it.ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			// no merge is required
+			assert.IsType(t, (*dml.RenamePlan)(nil), plan)
+
+			_, err = plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{it.expect}, sqls)
+		})
+	}
+
+	// the HAVING predicate on non-grouping column cannot be used to compute shards
+	stmt, _ := parser.New().ParseOneStmt("select name, count(*) from student group by name having uid = 5", "", "")
+	opt, err := NewOptimizer(makeFakeRule(ctrl, "student", 8, nil), nil, stmt, nil)
+	assert.NoError(t, err)
+	_, err = opt.Optimize(context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true))
+	assert.True(t, IsDenyFullScanErr(err))
+}
+
+func TestOptimizer_OptimizeGroupByAlias(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("y", consts.FieldTypeLongLong),
+		mysql.NewField("COUNT(*)", consts.FieldTypeLongLong),
+	}
+
+	fakeData := map[string][][2]int64{
+		"student_0001": {{2021, 1}, {2022, 2}, {2023, 1}},
+		"student_0002": {{2022, 3}, {2024, 5}},
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the alias should not be selected as a column
+			assert.NotContains(t, sql, "`y` AS `y`")
+
+			var values [][2]int64
+			for table := range fakeData {
+				if strings.Contains(sql, table) {
+					values = append(values, fakeData[table]...)
+				}
+			}
+			// ORDER BY `y` DESC
+			sort.SliceStable(values, func(i, j int) bool {
+				return values[i][0] > values[j][0]
+			})
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, it := range values {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueInt64(it[0]),
+					proto.NewValueInt64(it[1]),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
 
 	var (
-		sql = "select * from student join salaries on student.uid = salaries.uid"
+		sql = "select year(birth) as y, count(*) from student where uid in (1,2) group by y order by y desc"
 		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
 		ru  = makeFakeRule(ctrl, "student", 8, nil)
 	)
 
-	ru = makeFakeRule(ctrl, "salaries", 8, ru)
-
-	p := parser.New()
-	stmt, _ := p.ParseOneStmt(sql, "", "")
+	stmt, _ := parser.New().ParseOneStmt(sql, "", "")
 	opt, err := NewOptimizer(ru, nil, stmt, nil)
 	assert.NoError(t, err)
+	plan, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
 
-	vTable, _ := ru.VTable("student")
-	vTable.SetAllowFullScan(true)
+	res, err := plan.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
 
-	vTable2, _ := ru.VTable("salaries")
-	vTable2.SetAllowFullScan(true)
+	var actual []string
+	for {
+		next, err := ds.Next()
+		if err != nil {
+			break
+		}
+		dest := make([]proto.Value, len(fields))
+		assert.NoError(t, next.Scan(dest))
+		actual = append(actual, fmt.Sprintf("%s:%s", dest[0], dest[1]))
+	}
+	assert.Equal(t, []string{"2024:5", "2023:1", "2022:5", "2021:1"}, actual)
+}
 
-	plan, err := opt.Optimize(ctx)
-	assert.NoError(t, err)
+func TestOptimizer_OptimizeGroupByOrderByAggregateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-	_, _ = plan.ExecIn(ctx, conn)
+	fields := []proto.Field{
+		mysql.NewField("name", consts.FieldTypeVarString),
+		mysql.NewField("c", consts.FieldTypeLongLong),
+	}
+
+	type pair struct {
+		name  string
+		count int64
+	}
+
+	fakeData := map[string][]pair{
+		"student_0001": {{"alice", 1}, {"bob", 4}, {"carol", 2}},
+		"student_0002": {{"alice", 3}, {"carol", 1}, {"dave", 3}},
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the shards are ordered by the group items only, and the LIMIT applies to the merged groups
+			assert.NotContains(t, sql, "LIMIT")
+			assert.Contains(t, sql, "ORDER BY `name`")
+			assert.NotContains(t, sql, "DESC")
+
+			var values []pair
+			for table := range fakeData {
+				if strings.Contains(sql, table) {
+					values = append(values, fakeData[table]...)
+				}
+			}
+			// ORDER BY `name`
+			sort.SliceStable(values, func(i, j int) bool {
+				return values[i].name < values[j].name
+			})
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, it := range values {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueString(it.name),
+					proto.NewValueInt64(it.count),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{
+			"select name, count(*) as c from student where uid in (1,2) group by name order by c desc, name desc limit 3",
+			[]string{"bob:4", "alice:4", "dave:3"},
+		},
+		{
+			"select name, count(*) as c from student where uid in (1,2) group by name order by c desc, name limit 1, 2",
+			[]string{"bob:4", "carol:3"},
+		},
+		{
+			"select name, count(*) as c from student where uid in (1,2) group by name order by c limit 10",
+			[]string{"carol:3", "dave:3", "alice:4", "bob:4"},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+
+			var actual []string
+			for {
+				next, err := ds.Next()
+				if err != nil {
+					break
+				}
+				dest := make([]proto.Value, len(fields))
+				assert.NoError(t, next.Scan(dest))
+				actual = append(actual, fmt.Sprintf("%s:%s", dest[0], dest[1]))
+			}
+			assert.Equal(t, it.expect, actual)
+		})
+	}
 }
 
-func TestOptimizer_OptimizeInsert(t *testing.T) {
+func TestOptimizer_OptimizeGroupByHavingOrderByAlias(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	fields := []proto.Field{
+		mysql.NewField("name", consts.FieldTypeVarString),
+		mysql.NewField("c", consts.FieldTypeLongLong),
+	}
+
+	type pair struct {
+		name  string
+		count int64
+	}
+
+	fakeData := map[string][]pair{
+		"student_0001": {{"alice", 1}, {"bob", 4}, {"carol", 2}},
+		"student_0002": {{"alice", 3}, {"carol", 1}, {"dave", 3}},
+	}
+
 	conn := testdata.NewMockVConn(ctrl)
-	loader := testdata.NewMockSchemaLoader(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the HAVING applies to the merged groups, and the aggregate is computed only once
+			assert.NotContains(t, sql, "HAVING")
+			assert.Equal(t, strings.Count(sql, "SELECT "), strings.Count(sql, "COUNT("))
 
-	var fakeId uint64
+			var values []pair
+			for table := range fakeData {
+				if strings.Contains(sql, table) {
+					values = append(values, fakeData[table]...)
+				}
+			}
+			// ORDER BY `name`
+			sort.SliceStable(values, func(i, j int) bool {
+				return values[i].name < values[j].name
+			})
 
-	fakeStudentMetadata := make(map[string]*proto.TableMetadata)
-	fakeStuColumnMetadata := make(map[string]*proto.ColumnMetadata)
-	fakeStuColumnMetadata["name"] = &proto.ColumnMetadata{
-		Name:          "name",
-		DataType:      "varchar",
-		Ordinal:       "1",
-		PrimaryKey:    false,
-		Generated:     false,
-		CaseSensitive: false,
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, it := range values {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueString(it.name),
+					proto.NewValueInt64(it.count),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{
+			"select name, count(*) c from student where uid in (1,2) group by name having c > 3 order by c desc, name",
+			[]string{"alice:4", "bob:4"},
+		},
+		{
+			"select name, count(*) c from student where uid in (1,2) group by name having count(*) >= 3 order by c, name desc limit 3",
+			[]string{"dave:3", "carol:3", "bob:4"},
+		},
+		{
+			"select name, count(*) c from student where uid in (1,2) group by name having c > 3 and name <> 'alice' order by c desc",
+			[]string{"bob:4"},
+		},
+		{
+			"select name, count(*) c from student where uid in (1,2) group by name having c < 2 order by c desc",
+			nil,
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+
+			var actual []string
+			for {
+				next, err := ds.Next()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+				dest := make([]proto.Value, len(fields))
+				assert.NoError(t, next.Scan(dest))
+				actual = append(actual, fmt.Sprintf("%s:%s", dest[0], dest[1]))
+			}
+			assert.Equal(t, it.expect, actual)
+		})
 	}
-	fakeStuColumnMetadata["uid"] = &proto.ColumnMetadata{
-		Name:          "uid",
-		DataType:      "bigint",
-		Ordinal:       "2",
-		PrimaryKey:    false,
-		Generated:     false,
-		CaseSensitive: false,
+}
+
+func TestOptimizer_OptimizeGroupByHavingDependentColumn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("id", consts.FieldTypeLongLong),
+		mysql.NewField("c", consts.FieldTypeLongLong),
+		mysql.NewField("name", consts.FieldTypeVarString),
 	}
-	fakeStuColumnMetadata["age"] = &proto.ColumnMetadata{
-		Name:          "age",
-		DataType:      "tinyint",
-		Ordinal:       "3",
-		PrimaryKey:    false,
-		Generated:     false,
-		CaseSensitive: false,
+
+	type group struct {
+		id    int64
+		count int64
+		name  string
 	}
-	fakeStudentMetadata["student"] = &proto.TableMetadata{
-		Name:              "student",
-		Columns:           fakeStuColumnMetadata,
+
+	// the groups of same id are split into both shards
+	fakeData := map[string][]group{
+		"student_0001": {{1, 1, "alice"}, {2, 2, "bob"}, {3, 1, "carol"}},
+		"student_0002": {{1, 2, "alice"}, {2, 1, "bob"}, {4, 1, "dave"}},
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the dependent column is carried through the aggregation, and the HAVING applies to the merged groups
+			assert.NotContains(t, sql, "HAVING")
+			assert.Contains(t, sql, "COUNT(1) AS `c`,`name` FROM")
+
+			var values []group
+			for table := range fakeData {
+				if strings.Contains(sql, table) {
+					values = append(values, fakeData[table]...)
+				}
+			}
+			// ORDER BY `id`
+			sort.SliceStable(values, func(i, j int) bool {
+				return values[i].id < values[j].id
+			})
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, it := range values {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueInt64(it.id),
+					proto.NewValueInt64(it.count),
+					proto.NewValueString(it.name),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{
+			"select id, count(*) c from student where uid in (1,2) group by id having c > 2 and name <> 'bob'",
+			[]string{"1:3"},
+		},
+		{
+			"select id, count(*) c from student where uid in (1,2) group by id having count(*) < 3 or name = 'bob'",
+			[]string{"2:3", "3:1", "4:1"},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+
+			// the dependent column is dropped finally
+			actualFields, err := ds.Fields()
+			assert.NoError(t, err)
+			assert.Len(t, actualFields, 2)
+
+			var actual []string
+			for {
+				next, err := ds.Next()
+				if err != nil {
+					break
+				}
+				dest := make([]proto.Value, len(actualFields))
+				assert.NoError(t, next.Scan(dest))
+				actual = append(actual, fmt.Sprintf("%s:%s", dest[0], dest[1]))
+			}
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeGroupByMixedOrderBy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("dept", consts.FieldTypeVarString),
+		mysql.NewField("region", consts.FieldTypeVarString),
+		mysql.NewField("c", consts.FieldTypeLongLong),
+	}
+
+	type group struct {
+		dept, region string
+		count        int64
+	}
+
+	fakeData := map[string][]group{
+		"student_0001": {{"dev", "east", 1}, {"dev", "west", 4}, {"ops", "east", 2}, {"sales", "north", 1}},
+		"student_0002": {{"dev", "east", 1}, {"dev", "north", 2}, {"ops", "east", 3}, {"ops", "west", 4}},
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the groups are sorted by the aggregate after they are merged, so the shards are ordered by the group items
+			assert.Contains(t, sql, "ORDER BY `dept`, `region`")
+			assert.NotContains(t, sql, "DESC")
+
+			var values []group
+			for table := range fakeData {
+				if strings.Contains(sql, table) {
+					values = append(values, fakeData[table]...)
+				}
+			}
+			// ORDER BY `dept`,`region`
+			sort.SliceStable(values, func(i, j int) bool {
+				if values[i].dept != values[j].dept {
+					return values[i].dept < values[j].dept
+				}
+				return values[i].region < values[j].region
+			})
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, it := range values {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueString(it.dept),
+					proto.NewValueString(it.region),
+					proto.NewValueInt64(it.count),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{
+			"SELECT dept, region, COUNT(*) c FROM student WHERE uid IN (1,2) GROUP BY dept, region ORDER BY dept ASC, c DESC",
+			[]string{"dev:west:4", "dev:east:2", "dev:north:2", "ops:east:5", "ops:west:4", "sales:north:1"},
+		},
+		{
+			"SELECT dept, region, COUNT(*) c FROM student WHERE uid IN (1,2) GROUP BY dept, region ORDER BY c DESC, region, dept DESC LIMIT 4",
+			[]string{"ops:east:5", "ops:west:4", "dev:west:4", "dev:east:2"},
+		},
+		{
+			"SELECT dept, region, COUNT(*) c FROM student WHERE uid IN (1,2) GROUP BY dept, region ORDER BY dept DESC, c, region DESC",
+			[]string{"sales:north:1", "ops:west:4", "ops:east:5", "dev:north:2", "dev:east:2", "dev:west:4"},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+
+			var actual []string
+			for {
+				next, err := ds.Next()
+				if err != nil {
+					break
+				}
+				dest := make([]proto.Value, len(fields))
+				assert.NoError(t, next.Scan(dest))
+				actual = append(actual, fmt.Sprintf("%s:%s:%s", dest[0], dest[1], dest[2]))
+			}
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeApproxCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("TABLE_NAME", consts.FieldTypeVarString),
+		mysql.NewField("TABLE_ROWS", consts.FieldTypeLongLong),
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			assert.Contains(t, sql, "information_schema.TABLES")
+			// 10 rows of each table
+			ds := &dataset.VirtualDataset{Columns: fields}
+			for _, it := range args {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueString(it.(proto.Value).String()),
+					proto.NewValueInt64(10),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ctx = rcontext.WithWarnings(ctx)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+
+	approx, _ := hint.Parse("ApproxCount()")
+
+	stmt, _ := parser.New().ParseOneStmt("select count(*) from student", "", "")
+	opt, err := NewOptimizer(ru, []*hint.Hint{approx}, stmt, nil)
+	assert.NoError(t, err)
+	p, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
+	assert.IsType(t, (*dml.ApproxCountPlan)(nil), p)
+
+	res, err := p.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+	fs, err := ds.Fields()
+	assert.NoError(t, err)
+	assert.Equal(t, "count(*)", fs[0].Name())
+	row, err := ds.Next()
+	assert.NoError(t, err)
+	dest := make([]proto.Value, 1)
+	assert.NoError(t, row.Scan(dest))
+	assert.Equal(t, "80", dest[0].String())
+
+	warnings := rcontext.Warnings(ctx)
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0].Message, "approximate")
+	}
+
+	// the filtered count cannot be estimated
+	stmt, _ = parser.New().ParseOneStmt("select count(*) from student where uid between 1 and 3", "", "")
+	opt, err = NewOptimizer(ru, []*hint.Hint{approx}, stmt, nil)
+	assert.NoError(t, err)
+	p, err = opt.Optimize(ctx)
+	assert.NoError(t, err)
+	_, ok := p.(*dml.ApproxCountPlan)
+	assert.False(t, ok)
+}
+
+func TestOptimizer_OptimizeView(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl,
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+		mysql.NewField("name", consts.FieldTypeVarString),
+	)
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru.SetViews([]*rule.View{
+		{Name: "good_student", Definition: "SELECT uid, name FROM student s WHERE s.score >= 90"},
+		{Name: "bad_view", Definition: "SELECT MAX(score) FROM student"},
+	})
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{"select uid, name from good_student where uid = 3", []string{"`student_0003` AS `good_student`", "`good_student`.`score` >= 90 AND `uid` = 3"}},
+		{"select * from good_student g where g.uid = 3 or g.uid = 11", []string{"`g`.`uid`,`g`.`name`", "`student_0003` AS `g`", "`g`.`score` >= 90 AND (`g`.`uid` = 3 OR `g`.`uid` = 11)"}},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			qr.reset()
+			execQuery(t, ctx, ru, conn, it.sql, nil)
+
+			if queries := qr.sqls(); assert.Len(t, queries, 1) {
+				for _, expect := range it.expect {
+					assert.Contains(t, queries[0], expect)
+				}
+			}
+		})
+	}
+
+	stmt, _ := parser.New().ParseOneStmt("select * from bad_view", "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+	_, err = opt.Optimize(ctx)
+	assert.Error(t, err)
+}
+
+func TestOptimizer_OptimizeInSubquery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	premium := map[string][]proto.Value{
+		"gold":   {proto.NewValueInt64(1), proto.NewValueInt64(10), proto.NewValueInt64(1), nil},
+		"silver": nil,
+	}
+
+	var queries []string
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			queries = append(queries, sql)
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			if strings.Contains(sql, "`premium`") {
+				for _, it := range premium[args[0].(proto.Value).String()] {
+					ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{it}))
+				}
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	for _, it := range []struct {
+		tier   string
+		expect []string
+	}{
+		{
+			"gold",
+			[]string{
+				"SELECT `uid` FROM `premium` WHERE `tier` = ?",
+				"(SELECT `uid` FROM `student_0001` WHERE `uid` IN (?)) UNION ALL (SELECT `uid` FROM `student_0002` WHERE `uid` IN (?))",
+			},
+		},
+		{
+			"silver",
+			[]string{
+				"SELECT `uid` FROM `premium` WHERE `tier` = ?",
+				"SELECT `uid` FROM `student_0000` WHERE `uid` IN (NULL)",
+			},
+		},
+	} {
+		t.Run(it.tier, func(t *testing.T) {
+			queries = queries[:0]
+
+			stmt, _ := parser.New().ParseOneStmt("select uid from student where uid in (select uid from premium where tier = ?)", "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{proto.NewValueString(it.tier)})
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			// the plan can be executed more than once
+			for i := 0; i < 2; i++ {
+				queries = queries[:0]
+				res, err := plan.ExecIn(ctx, conn)
+				assert.NoError(t, err)
+				ds, err := res.Dataset()
+				assert.NoError(t, err)
+				_, err = ds.Next()
+				assert.ErrorIs(t, err, io.EOF)
+
+				assert.Equal(t, it.expect, queries)
+			}
+		})
+	}
+}
+
+func TestOptimizer_OptimizeInUnshardedSubquery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("code", consts.FieldTypeVarString),
+	}
+
+	var queries []string
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			queries = append(queries, sql)
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			if strings.Contains(sql, "`regions`") {
+				// the non-sharded table is queried in the default datasource
+				assert.Empty(t, db)
+				for _, it := range []string{"cn", "us"} {
+					ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueString(it)}))
+				}
+			} else {
+				assert.Equal(t, "fake_db", db)
+				assert.Equal(t, "cn", args[0].(proto.Value).String())
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	stmt, _ := parser.New().ParseOneStmt("select uid from student where region in (select code from regions where active = ?)", "", "")
+	opt, err := NewOptimizer(ru, []*hint.Hint{{Type: hint.TypeFullScan}}, stmt, []proto.Value{proto.NewValueInt64(1)})
+	assert.NoError(t, err)
+	plan, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
+
+	res, err := plan.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+	_, err = ds.Next()
+	assert.ErrorIs(t, err, io.EOF)
+
+	assert.NotEmpty(t, queries)
+	assert.Equal(t, "SELECT `code` FROM `regions` WHERE `active` = ?", queries[0])
+	// all shards are scanned with the values of subquery
+	var tables int
+	for _, it := range queries[1:] {
+		assert.NotContains(t, it, "`regions`")
+		assert.Contains(t, it, "`region` IN (?,?)")
+		tables += strings.Count(it, "`student_")
+	}
+	assert.Equal(t, 8, tables)
+}
+
+func TestOptimizer_OptimizeCorrelatedSubquery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("id", consts.FieldTypeLongLong))
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	t.Run("SingleShard", func(t *testing.T) {
+		execQuery(t, ctx, ru, conn, "select o.id from student o where o.uid = 5 and o.score > (select avg(score) from student where uid = o.uid)", nil)
+
+		// the whole statement is pushed down, both the outer and inner table are resolved to the same shard
+		assert.Equal(t, []string{
+			"SELECT `o`.`id` FROM `student_0005` AS `o` WHERE `o`.`uid` = 5 AND `o`.`score` > (SELECT AVG(`score`) FROM `student_0005` WHERE `uid` = `o`.`uid`)",
+		}, qr.sqls())
+	})
+
+	for _, it := range []struct {
+		name   string
+		sql    string
+		hints  []*hint.Hint
+		reason string
+	}{
+		{"Uncorrelated", "select o.id from student o where o.uid = 5 and o.score > (select avg(score) from student)", nil, ""},
+		{"MultipleShards", "select o.id from student o where o.score > (select avg(score) from student where uid = o.uid)", []*hint.Hint{{Type: hint.TypeFullScan}}, "not supported"},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, it.hints, stmt, nil)
+			assert.NoError(t, err)
+			_, err = opt.Optimize(ctx)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), it.reason)
+			}
+		})
+	}
+}
+
+func TestOptimizer_OptimizeSelectEarlyLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	var queries int // amount of queried physical tables
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the tables of same database may be queried in one UNION ALL statement
+			tables := strings.Count(sql, "`student_")
+			queries += tables
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for i := 0; i < 3*tables; i++ {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(int64(i))}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+
+	for _, it := range []struct {
+		sql        string
+		earlyLimit bool
+		rows       int
+		queries    int
+	}{
+		{"select uid from student limit 5", false, 5, 8},
+		{"select uid from student limit 5", true, 5, 2},
+		{"select uid from student limit 2, 5", true, 5, 3},
+		{"select uid from student limit 30", true, 24, 8},
+		// the ordered LIMIT requires the rows of all shards
+		{"select uid from student order by uid limit 5", true, 5, 8},
+	} {
+		t.Run(fmt.Sprintf("%s,early=%v", it.sql, it.earlyLimit), func(t *testing.T) {
+			ru := makeFakeRule(ctrl, "student", 8, nil)
+			vt, _ := ru.VTable("student")
+			vt.SetAllowFullScan(true)
+			vt.SetEarlyLimit(it.earlyLimit)
+
+			queries = 0
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+
+			var n int
+			for {
+				if _, err = ds.Next(); err != nil {
+					break
+				}
+				n++
+			}
+			assert.Equal(t, it.rows, n)
+			assert.Equal(t, it.queries, queries)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeDeterministicShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
+	vt.SetEarlyLimit(true)
+
+	// the shards are scanned one by one in the sorted order of each run
+	for i := 0; i < 10; i++ {
+		qr.reset()
+		execQuery(t, ctx, ru, conn, "select uid from student where uid in (7, 1, 5, 3) limit 10", nil)
+		assert.Equal(t, []string{"student_0001", "student_0003", "student_0005", "student_0007"}, qr.tables())
+	}
+}
+
+func TestOptimizer_OptimizeExpandedIn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	args := []proto.Value{proto.NewValueInt64(1), proto.NewValueInt64(9), proto.NewValueInt64(3)}
+
+	// the list is expanded by driver before preparing, each value is routed to its shard
+	execQuery(t, ctx, ru, conn, "select uid from student where uid in (?, ?, ?)", args)
+	for _, it := range qr.queries {
+		assert.Equal(t, len(it.args), strings.Count(it.sql, "?"))
+	}
+	tables := qr.tables()
+	sort.Strings(tables)
+	assert.Equal(t, []string{"student_0001", "student_0003"}, tables)
+
+	// the args should match the placeholders
+	stmt, _ := parser.New().ParseOneStmt("select uid from student where uid in (?)", "", "")
+	_, err := NewOptimizer(ru, nil, stmt, args)
+	assert.Error(t, err)
+}
+
+func TestOptimizer_OptimizeSelectKeyset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+		mysql.NewField("age", consts.FieldTypeLongLong),
+	}
+
+	var tables []string // queried physical tables
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			assert.NotContains(t, sql, "OFFSET")
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			// each shard returns the ordered rows, eg: (4,20),(4,21),(4,22)
+			for i := 0; i < 8; i++ {
+				table := fmt.Sprintf("student_%04d", i)
+				if !strings.Contains(sql, "`"+table+"`") {
+					continue
+				}
+				tables = append(tables, table)
+				for age := int64(20); age < 23; age++ {
+					ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+						proto.NewValueInt64(int64(i)),
+						proto.NewValueInt64(age),
+					}))
+				}
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+
+	for _, it := range []struct {
+		sql     string
+		args    []proto.Value
+		ordered bool
+		tables  []string
+	}{
+		{
+			"select uid, age from student where uid between 1 and 6 and (uid, age) > (4, 18) order by uid, age limit 5",
+			nil,
+			true,
+			[]string{"student_0004", "student_0005", "student_0006"},
+		},
+		{
+			"select uid, age from student where uid between 1 and 6 and (uid, age) > (?, ?) order by uid, age limit 5",
+			[]proto.Value{proto.NewValueInt64(4), proto.NewValueInt64(18)},
+			true,
+			[]string{"student_0004", "student_0005", "student_0006"},
+		},
+		// not a keyset pagination, the directions are mismatched
+		{
+			"select uid, age from student where uid between 1 and 6 and (uid, age) > (4, 18) order by uid desc, age desc limit 5",
+			nil,
+			false,
+			[]string{"student_0001", "student_0002", "student_0003", "student_0004", "student_0005", "student_0006"},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			tables = tables[:0]
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(makeFakeRule(ctrl, "student", 8, nil), nil, stmt, it.args)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+
+			var actual [][2]int64
+			for {
+				next, err := ds.Next()
+				if err != nil {
+					break
+				}
+				dest := make([]proto.Value, 2)
+				assert.NoError(t, next.Scan(dest))
+				uid, _ := dest[0].Int64()
+				age, _ := dest[1].Int64()
+				actual = append(actual, [2]int64{uid, age})
+			}
+			assert.Len(t, actual, 5)
+			if it.ordered {
+				// the merged rows respect the composite ordering
+				assert.Equal(t, [][2]int64{{4, 20}, {4, 21}, {4, 22}, {5, 20}, {5, 21}}, actual)
+			}
+
+			sort.Strings(tables)
+			assert.Equal(t, it.tables, tables)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeUnionPruning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("id", consts.FieldTypeLongLong),
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	var (
+		mu   sync.Mutex
+		sqls []string
+	)
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			mu.Lock()
+			sqls = append(sqls, sql)
+			mu.Unlock()
+			columns := fields[:1]
+			if strings.HasPrefix(sql, "SELECT `id`,`uid`") {
+				columns = fields
+			}
+			return resultx.New(resultx.WithDataset(&dataset.VirtualDataset{Columns: columns})), nil
+		}).
+		AnyTimes()
+
+	fakeData := map[string]*proto.TableMetadata{
+		"student_0000": {
+			Name:        "student_0000",
+			Columns:     map[string]*proto.ColumnMetadata{"id": {}, "uid": {}},
+			ColumnNames: []string{"id", "uid"},
+		},
+	}
+	loader := testdata.NewMockSchemaLoader(ctrl)
+	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeData, nil).AnyTimes()
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	// full scan is disallowed, so each branch must be pruned by its own condition
+	for _, it := range []struct {
+		sql      string
+		args     []proto.Value
+		expected []string
+	}{
+		{
+			"select * from student where uid = 1 union select * from student where uid = 100",
+			nil,
+			[]string{
+				"SELECT `id`,`uid` FROM `student_0001` WHERE `uid` = 1",
+				"SELECT `id`,`uid` FROM `student_0004` WHERE `uid` = 100",
+			},
+		},
+		{
+			"select id from student where uid = ? union all select id from student where uid = ?",
+			[]proto.Value{proto.NewValueInt64(3), proto.NewValueInt64(13)},
+			[]string{
+				"SELECT `id` FROM `student_0003` WHERE `uid` = ?",
+				"SELECT `id` FROM `student_0005` WHERE `uid` = ?",
+			},
+		},
+		{
+			"select id from student where uid = 2 union select id from student where uid = 10",
+			nil,
+			[]string{
+				"SELECT `id` FROM `student_0002` WHERE `uid` = 10",
+				"SELECT `id` FROM `student_0002` WHERE `uid` = 2",
+			},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			sqls = sqls[:0]
+
+			stmt, err := parser.New().ParseOneStmt(it.sql, "", "")
+			assert.NoError(t, err)
+			opt, err := NewOptimizer(ru, nil, stmt, it.args)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+			_, err = ds.Next()
+			assert.ErrorIs(t, err, io.EOF)
+
+			sort.Strings(sqls)
+			assert.Equal(t, it.expected, sqls)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeSetOperation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	fakeData := map[string][]int64{
+		"student_0001": {1, 1},
+		"student_0002": {2},
+		"student_0003": {3},
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, table := range []string{"student_0001", "student_0002", "student_0003"} {
+				if !strings.Contains(sql, table) {
+					continue
+				}
+				for _, it := range fakeData[table] {
+					ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(it)}))
+				}
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	for _, it := range []struct {
+		sql    string
+		expect []int64
+	}{
+		{"select uid from student where uid in (1,2) union all select uid from student where uid = 2", []int64{1, 1, 2, 2}},
+		{"select uid from student where uid in (1,2) union select uid from student where uid in (2,3)", []int64{1, 2, 3}},
+		{"select uid from student where uid in (1,2) intersect select uid from student where uid in (2,3)", []int64{2}},
+		{"select uid from student where uid in (1,2) intersect all select uid from student where uid = 1", []int64{1, 1}},
+		{"select uid from student where uid in (1,2) except select uid from student where uid in (2,3)", []int64{1}},
+		{"select uid from student where uid in (1,2) except all select uid from student where uid = 2", []int64{1, 1}},
+		// INTERSECT binds tighter than UNION
+		{"select uid from student where uid = 3 union select uid from student where uid in (1,2) intersect select uid from student where uid in (2,3)", []int64{3, 2}},
+		// ORDER BY and LIMIT apply to the combined rows
+		{"select uid from student where uid in (1,2) union select uid from student where uid in (2,3) order by uid desc limit 2", []int64{3, 2}},
+		{"select uid from student where uid in (1,2) union all select uid from student where uid = 3 order by uid limit 1,2", []int64{1, 2}},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+
+			var actual []int64
+			dest := make([]proto.Value, len(fields))
+			for {
+				next, err := ds.Next()
+				if err != nil {
+					break
+				}
+				assert.NoError(t, next.Scan(dest))
+				v, _ := dest[0].Int64()
+				actual = append(actual, v)
+			}
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+
+	t.Run("different number of columns", func(t *testing.T) {
+		stmt, _ := parser.New().ParseOneStmt("select uid from student where uid = 1 intersect select uid, name from student where uid = 2", "", "")
+		opt, err := NewOptimizer(ru, nil, stmt, nil)
+		assert.NoError(t, err)
+		_, err = opt.Optimize(ctx)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "different number of columns")
+	})
+}
+
+func TestOptimizer_OptimizeGroupByLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("name", consts.FieldTypeVarString),
+		mysql.NewField("COUNT(*)", consts.FieldTypeLongLong),
+	}
+
+	fakeData := map[string][][2]interface{}{
+		"student_0001": {{"a", 1}, {"b", 2}, {"c", 1}},
+		"student_0002": {{"b", 3}, {"c", 1}, {"d", 5}},
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the limit should not be pushed down to shards
+			assert.NotContains(t, sql, "LIMIT")
+
+			var values [][2]interface{}
+			for table := range fakeData {
+				if strings.Contains(sql, table) {
+					values = append(values, fakeData[table]...)
+				}
+			}
+			// ORDER BY `name`
+			sort.SliceStable(values, func(i, j int) bool {
+				return values[i][0].(string) < values[j][0].(string)
+			})
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, it := range values {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueString(it[0].(string)),
+					proto.NewValueInt64(int64(it[1].(int))),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	var (
+		sql = "select name, count(*) from student where uid in (1,2) group by name limit 2"
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	stmt, _ := parser.New().ParseOneStmt(sql, "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+	plan, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
+
+	res, err := plan.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+
+	var actual []string
+	for {
+		next, err := ds.Next()
+		if err != nil {
+			break
+		}
+		dest := make([]proto.Value, len(fields))
+		assert.NoError(t, next.Scan(dest))
+		actual = append(actual, fmt.Sprintf("%s:%s", dest[0], dest[1]))
+	}
+	assert.Equal(t, []string{"a:1", "b:5"}, actual)
+}
+
+func TestOptimizer_OptimizeDerivedTableAggregate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("dept", consts.FieldTypeVarString),
+		mysql.NewField("SUM(`x`)", consts.FieldTypeNewDecimal),
+		mysql.NewField("VAR_POP(`x`)", consts.FieldTypeDouble),
+		mysql.NewField("COUNT(`x`)", consts.FieldTypeLongLong),
+	}
+
+	// dept, SUM(x), VAR_POP(x), COUNT(x) of each shard
+	fakeData := map[string][][4]interface{}{
+		"student_0001": {{"a", 4, 1.0, 2}},
+		"student_0002": {{"a", 5, 0.0, 1}, {"b", 6, 1.0, 2}},
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the derived table should be merged, and the partial aggregates should be pushed down
+			assert.NotContains(t, sql, "FROM (")
+			assert.Contains(t, sql, "SELECT `dept`,SUM(`x`),VAR_POP(`x`),COUNT(`x`) FROM `student_")
+			assert.Contains(t, sql, "GROUP BY `dept`")
+
+			var values [][4]interface{}
+			for table := range fakeData {
+				if strings.Contains(sql, table) {
+					values = append(values, fakeData[table]...)
+				}
+			}
+			sort.SliceStable(values, func(i, j int) bool {
+				return values[i][0].(string) < values[j][0].(string)
+			})
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, it := range values {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueString(it[0].(string)),
+					proto.NewValueInt64(int64(it[1].(int))),
+					proto.NewValueFloat64(it[2].(float64)),
+					proto.NewValueInt64(int64(it[3].(int))),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	var (
+		sql = "select dept, sum(x), var_pop(x) from (select dept, x from student where uid in (1,2)) sub group by dept order by dept"
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	stmt, _ := parser.New().ParseOneStmt(sql, "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+	plan, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
+
+	res, err := plan.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+
+	fields, err = ds.Fields()
+	assert.NoError(t, err)
+	assert.Len(t, fields, 3)
+
+	var actual []string
+	for {
+		next, err := ds.Next()
+		if err != nil {
+			break
+		}
+		dest := make([]proto.Value, len(fields))
+		assert.NoError(t, next.Scan(dest))
+		variance, err := dest[2].Float64()
+		assert.NoError(t, err)
+		actual = append(actual, fmt.Sprintf("%s:%s:%.4f", dest[0], dest[1], variance))
+	}
+	// a: 1,3,5 b: 2,4
+	assert.Equal(t, []string{"a:9:2.6667", "b:6:1.0000"}, actual)
+}
+
+func TestOptimizer_OptimizeVarianceAggregate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("VAR_POP(`x`)", consts.FieldTypeDouble),
+		mysql.NewField("VAR_POP(`x`)", consts.FieldTypeDouble),
+		mysql.NewField("VAR_POP(`x`)", consts.FieldTypeDouble),
+		mysql.NewField("VAR_POP(`x`)", consts.FieldTypeDouble),
+		mysql.NewField("COUNT(`x`)", consts.FieldTypeLongLong),
+		mysql.NewField("SUM(`x`)", consts.FieldTypeNewDecimal),
+	}
+
+	// VAR_POP(x), COUNT(x), SUM(x) of each shard
+	fakeData := map[string][3]float64{
+		"student_0001": {1, 2, 4}, // 1, 3
+		"student_0002": {0, 1, 5}, // 5
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			// the variances are not selected, since they are computed from the merged VAR_POP(x)
+			assert.Contains(t, sql, "SELECT VAR_POP(`x`),VAR_POP(`x`),VAR_POP(`x`),VAR_POP(`x`),COUNT(`x`),SUM(`x`) FROM `student_")
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for table, it := range fakeData {
+				if !strings.Contains(sql, table) {
+					continue
+				}
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueFloat64(it[0]),
+					proto.NewValueFloat64(it[0]),
+					proto.NewValueFloat64(it[0]),
+					proto.NewValueFloat64(it[0]),
+					proto.NewValueInt64(int64(it[1])),
+					proto.NewValueInt64(int64(it[2])),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	var (
+		ctx  = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru   = makeFakeRule(ctrl, "student", 8, nil)
+		plan = optimizeQuery(t, ctx, ru, "select var_pop(x), var_samp(x), stddev_pop(x), stddev_samp(x) from student where uid in (1,2)", nil)
+	)
+
+	res, err := plan.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+	fields, err = ds.Fields()
+	assert.NoError(t, err)
+	assert.Len(t, fields, 4)
+
+	next, err := ds.Next()
+	assert.NoError(t, err)
+	dest := make([]proto.Value, len(fields))
+	assert.NoError(t, next.Scan(dest))
+	var actual []string
+	for _, it := range dest {
+		f, err := it.Float64()
+		assert.NoError(t, err)
+		actual = append(actual, fmt.Sprintf("%.4f", f))
+	}
+	assert.Equal(t, []string{"2.6667", "4.0000", "1.6330", "2.0000"}, actual)
+}
+
+func TestOptimizer_OptimizeDistinctAggregate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeData := map[string][][2]interface{}{
+		"student_0001": {{"a", 10}, {"a", 10}, {"b", 20}},
+		"student_0002": {{"a", 10}, {"a", 30}, {"b", 20}, {"c", 40}},
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			assert.NotContains(t, sql, "DISTINCT")
+			assert.NotContains(t, sql, "LIMIT")
+
+			grouped := strings.Contains(sql, "GROUP BY `name`,`score`")
+			if !grouped {
+				assert.Contains(t, sql, "GROUP BY `score`)")
+			}
+
+			// emulate the distinct values of each shard
+			var (
+				values [][2]interface{}
+				visits = make(map[string]struct{})
+			)
+			for table, tuples := range fakeData {
+				if !strings.Contains(sql, table) {
+					continue
+				}
+				for _, it := range tuples {
+					if !grouped {
+						it[0] = ""
+					}
+					key := fmt.Sprintf("%s.%s:%v", table, it[0], it[1])
+					if _, ok := visits[key]; ok {
+						continue
+					}
+					visits[key] = struct{}{}
+					values = append(values, it)
+				}
+			}
+			// ORDER BY `name`
+			sort.SliceStable(values, func(i, j int) bool {
+				return values[i][0].(string) < values[j][0].(string)
+			})
+
+			// SELECT `name`,`score`,`score` or SELECT `score`,`score`,`score`
+			fields := []proto.Field{
+				mysql.NewField("score", consts.FieldTypeLongLong),
+				mysql.NewField("score", consts.FieldTypeLongLong),
+				mysql.NewField("score", consts.FieldTypeLongLong),
+			}
+			if grouped {
+				fields[0] = mysql.NewField("name", consts.FieldTypeVarString)
+			}
+
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for _, it := range values {
+				var row []proto.Value
+				if grouped {
+					row = append(row, proto.NewValueString(it[0].(string)))
+				}
+				for len(row) < len(fields) {
+					row = append(row, proto.NewValueInt64(int64(it[1].(int))))
+				}
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, row))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+
+	query := func(sql string) []string {
+		ru := makeFakeRule(ctrl, "student", 8, nil)
+		stmt, _ := parser.New().ParseOneStmt(sql, "", "")
+		opt, err := NewOptimizer(ru, nil, stmt, nil)
+		assert.NoError(t, err)
+		plan, err := opt.Optimize(ctx)
+		assert.NoError(t, err)
+
+		res, err := plan.ExecIn(ctx, conn)
+		assert.NoError(t, err)
+		ds, err := res.Dataset()
+		assert.NoError(t, err)
+		fields, err := ds.Fields()
+		assert.NoError(t, err)
+
+		var actual []string
+		for {
+			next, err := ds.Next()
+			if err != nil {
+				break
+			}
+			dest := make([]proto.Value, len(fields))
+			assert.NoError(t, next.Scan(dest))
+			var sb strings.Builder
+			for i := range dest {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+				_, _ = fmt.Fprint(&sb, dest[i])
+			}
+			actual = append(actual, sb.String())
+		}
+		return actual
+	}
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{
+			"select count(distinct score), sum(distinct score), avg(distinct score) from student where uid in (1,2) limit 1",
+			[]string{"4,100,25"},
+		},
+		{
+			"select name, count(distinct score), sum(distinct score) from student where uid in (1,2) group by name",
+			[]string{"a,2,40", "b,1,20", "c,1,40"},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			assert.Equal(t, it.expect, query(it.sql))
+		})
+	}
+}
+
+func TestOptimizer_OptimizeOrderByPrimaryKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl,
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+		mysql.NewField("name", consts.FieldTypeVarString),
+		mysql.NewField("id", consts.FieldTypeLongLong),
+	)
+
+	fakeData := map[string]*proto.TableMetadata{
+		"student_0000": {
+			Name:              "student_0000",
+			Columns:           map[string]*proto.ColumnMetadata{"id": {}, "uid": {}, "name": {}},
+			ColumnNames:       []string{"id", "uid", "name"},
+			PrimaryKeyColumns: []string{"id"},
+		},
+	}
+	loader := testdata.NewMockSchemaLoader(ctrl)
+	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeData, nil).AnyTimes()
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	var (
+		sql = "select uid, name from student where uid in (?,?,?) order by name"
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	vTable, _ := ru.VTable("student")
+	vTable.SetOrderByPrimaryKey(true)
+
+	fields := execQuery(t, ctx, ru, conn, sql, []proto.Value{
+		proto.NewValueInt64(1),
+		proto.NewValueInt64(2),
+		proto.NewValueInt64(3),
+	})
+	// the weak primary key column should be dropped
+	assert.Len(t, fields, 2)
+
+	for _, next := range qr.sqls() {
+		assert.True(t, strings.HasSuffix(next, "ORDER BY `name`, `id`"), next)
+	}
+}
+
+func TestOptimizer_OptimizeCountByPrimaryKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	counts := map[string]int64{
+		"student_0001": 3,
+		"student_0002": 5,
+		"student_0003": 7,
+	}
+
+	var sqls []string
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			sqls = append(sqls, sql)
+
+			fields := []proto.Field{
+				mysql.NewField("COUNT(*)", consts.FieldTypeLongLong),
+			}
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for table, cnt := range counts {
+				if strings.Contains(sql, table) {
+					ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+						proto.NewValueInt64(cnt),
+					}))
+				}
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	fakeData := map[string]*proto.TableMetadata{
+		"student_0000": {
+			Name:              "student_0000",
+			Columns:           map[string]*proto.ColumnMetadata{"id": {}, "uid": {}, "name": {}},
+			ColumnNames:       []string{"id", "uid", "name"},
+			PrimaryKeyColumns: []string{"id"},
+		},
+	}
+	loader := testdata.NewMockSchemaLoader(ctrl)
+	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeData, nil).AnyTimes()
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+
+	count := func(sql string, enable bool) (string, int64) {
+		ru := makeFakeRule(ctrl, "student", 8, nil)
+		vTable, _ := ru.VTable("student")
+		vTable.SetCountByPrimaryKey(enable)
+
+		stmt, _ := parser.New().ParseOneStmt(sql, "", "")
+		opt, err := NewOptimizer(ru, nil, stmt, nil)
+		assert.NoError(t, err)
+		plan, err := opt.Optimize(ctx)
+		assert.NoError(t, err)
+
+		res, err := plan.ExecIn(ctx, conn)
+		assert.NoError(t, err)
+		ds, err := res.Dataset()
+		assert.NoError(t, err)
+		fields, err := ds.Fields()
+		assert.NoError(t, err)
+
+		next, err := ds.Next()
+		assert.NoError(t, err)
+		dest := make([]proto.Value, len(fields))
+		assert.NoError(t, next.Scan(dest))
+		ret, err := dest[0].Int64()
+		assert.NoError(t, err)
+		return fields[0].Name(), ret
+	}
+
+	for _, it := range []struct {
+		sql    string
+		expect int64
+	}{
+		{"select count(*) from student where uid in (1,2,3)", 15},
+		{"select count(*) from student where uid in (1,2,3) and name = 'foo'", 15},
+		{"select count(*) from student where uid = 2", 5},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			sqls = sqls[:0]
+			name, expect := count(it.sql, false)
+			assert.Equal(t, it.expect, expect)
+			for _, next := range sqls {
+				assert.NotContains(t, next, "COUNT(`id`)")
+			}
+
+			sqls = sqls[:0]
+			actualName, actual := count(it.sql, true)
+			assert.Equal(t, expect, actual)
+			assert.Equal(t, name, actualName)
+			for _, next := range sqls {
+				assert.Contains(t, next, "COUNT(`id`)")
+			}
+		})
+	}
+}
+
+func TestOptimizer_OptimizeShardWhere(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	for _, it := range []struct {
+		name   string
+		sql    string
+		args   []proto.Value
+		expect string
+		bound  string
+	}{
+		// the non-key conditions must be sent to the target shard along with the sharding key
+		{
+			"SingleShard",
+			"select uid from student where uid = ? and score > ? and name like ?",
+			[]proto.Value{proto.NewValueInt64(9), proto.NewValueInt64(60), proto.NewValueString("foo%")},
+			"SELECT `uid` FROM `student_0001` WHERE `uid` = ? AND `score` > ? AND `name` LIKE ?",
+			"[9 60 foo%]",
+		},
+		{
+			"SingleShardKeyLast",
+			"select uid from student where score > ? and uid = ?",
+			[]proto.Value{proto.NewValueInt64(60), proto.NewValueInt64(10)},
+			"SELECT `uid` FROM `student_0002` WHERE `score` > ? AND `uid` = ?",
+			"[60 10]",
+		},
+		{
+			"SingleShardOr",
+			"select uid from student where uid = 11 and (name = 'foo' or score < 60)",
+			nil,
+			"SELECT `uid` FROM `student_0003` WHERE `uid` = 11 AND (`name` = 'foo' OR `score` < 60)",
+			"[]",
+		},
+		{
+			"SingleShardLimit",
+			"select uid from student where uid in (4, 12) and score >= ? order by uid limit 3",
+			[]proto.Value{proto.NewValueInt64(90)},
+			"SELECT `uid` FROM `student_0004` WHERE `uid` IN (4,12) AND `score` >= ? ORDER BY `uid` LIMIT 3",
+			"[90]",
+		},
+		// each shard only queries the tuples it owns
+		{
+			"TupleIn",
+			"select uid from student where (uid,name) in ((1,'foo'),(?,'bar'),(9,?))",
+			[]proto.Value{proto.NewValueInt64(2), proto.NewValueString("qux")},
+			"(SELECT `uid` FROM `student_0001` WHERE (`uid`,`name`) IN ((1,'foo'),(9,?))) UNION ALL (SELECT `uid` FROM `student_0002` WHERE (`uid`,`name`) IN ((?,'bar')))",
+			"[qux 2]",
+		},
+		// each shard only queries the values it owns, 9%8=1, 2%8=2, 10%8=2, 17%8=1
+		{
+			"InPlaceholders",
+			"select uid from student where name = ? and uid in (?, 2, ?, ?)",
+			[]proto.Value{proto.NewValueString("foo"), proto.NewValueInt64(9), proto.NewValueInt64(10), proto.NewValueInt64(17)},
+			"(SELECT `uid` FROM `student_0001` WHERE `name` = ? AND `uid` IN (?,?)) UNION ALL (SELECT `uid` FROM `student_0002` WHERE `name` = ? AND `uid` IN (2,?))",
+			"[foo 9 17 foo 10]",
+		},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			qr.reset()
+			execQuery(t, ctx, ru, conn, it.sql, it.args)
+
+			assert.Equal(t, []string{"fake_db"}, qr.dbs())
+			assert.Equal(t, []string{it.expect}, qr.sqls())
+			assert.Equal(t, []string{it.bound}, qr.args())
+		})
+	}
+
+	// the placeholder without bound value cannot be computed
+	stmt, _ := parser.New().ParseOneStmt("select uid from student where name = ? and uid in (?, 2, ?, ?)", "", "")
+	_, err := NewOptimizer(ru, nil, stmt, []proto.Value{proto.NewValueString("foo")})
+	assert.Error(t, err)
+}
+
+func TestOptimizer_OptimizeExpressionProjection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl,
+		mysql.NewField("id", consts.FieldTypeLongLong),
+		mysql.NewField("total", consts.FieldTypeLongLong),
+	)
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	// the arithmetic projections are sent to shards unchanged, and labeled by their aliases
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{
+			"select id, price * quantity as total from student where uid = 5",
+			[]string{"SELECT `id`,`price`*`quantity` AS `total` FROM `student_0005` WHERE `uid` = 5"},
+		},
+		{
+			"select id, (price - discount) * quantity total from student where uid in (1, 2)",
+			[]string{"(SELECT `id`,(`price`-`discount`)*`quantity` AS `total` FROM `student_0001` WHERE `uid` IN (1)) UNION ALL (SELECT `id`,(`price`-`discount`)*`quantity` AS `total` FROM `student_0002` WHERE `uid` IN (2))"},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			qr.reset()
+			fields := execQuery(t, ctx, ru, conn, it.sql, nil)
+
+			var names []string
+			for _, f := range fields {
+				names = append(names, f.Name())
+			}
+			assert.Equal(t, []string{"id", "total"}, names)
+			assert.Equal(t, it.expect, qr.sqls())
+		})
+	}
+}
+
+func TestOptimizer_OptimizePreparedLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+
+	var (
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	// the prepared statement is parsed once, and executed with different args
+	stmt, _ := parser.New().ParseOneStmt("select uid from student where uid in (?, ?) order by uid limit ?, ?", "", "")
+
+	for _, it := range []struct {
+		offset, limit int64
+		expect        string
+	}{
+		{0, 10, "[1 0 10 2 0 10]"},
+		{5, 10, "[1 0 15 2 0 15]"},
+		{3, 2, "[1 0 5 2 0 5]"},
+		{0, 10, "[1 0 10 2 0 10]"},
+	} {
+		t.Run(fmt.Sprintf("limit %d, %d", it.offset, it.limit), func(t *testing.T) {
+			bound := []proto.Value{
+				proto.NewValueInt64(1),
+				proto.NewValueInt64(2),
+				proto.NewValueInt64(it.offset),
+				proto.NewValueInt64(it.limit),
+			}
+			opt, err := NewOptimizer(ru, nil, stmt, bound)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			// the plan may be executed more than once, eg: retried
+			for i := 0; i < 2; i++ {
+				qr.reset()
+				res, err := plan.ExecIn(ctx, conn)
+				assert.NoError(t, err)
+				ds, err := res.Dataset()
+				assert.NoError(t, err)
+				_, err = ds.Next()
+				assert.ErrorIs(t, err, io.EOF)
+
+				assert.Equal(t, []string{
+					"(SELECT `uid` FROM `student_0001` WHERE `uid` IN (?) ORDER BY `uid` LIMIT ?,?) UNION ALL (SELECT `uid` FROM `student_0002` WHERE `uid` IN (?) ORDER BY `uid` LIMIT ?,?) ORDER BY `uid`",
+				}, qr.sqls())
+				assert.Equal(t, []string{it.expect}, qr.args())
+			}
+			// the bound args of execution are never changed by the limit rewriting
+			assert.Equal(t, fmt.Sprintf("[1 2 %d %d]", it.offset, it.limit), fmt.Sprint(bound))
+		})
+	}
+}
+
+func TestOptimizer_OptimizeExplainAnalyze(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("id", consts.FieldTypeLongLong),
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+
+			fakeData := &dataset.VirtualDataset{Columns: fields}
+			for i := 0; i < 3; i++ {
+				fakeData.Rows = append(fakeData.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueInt64(int64(i)),
+					proto.NewValueInt64(int64(i)),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(fakeData)), nil
+		}).
+		AnyTimes()
+
+	var (
+		sql = "explain analyze select id, uid from student where uid in (?,?,?)"
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	p := parser.New()
+	stmt, _ := p.ParseOneStmt(sql, "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{
+		proto.NewValueInt64(1),
+		proto.NewValueInt64(2),
+		proto.NewValueInt64(3),
+	})
+	assert.NoError(t, err)
+	plan, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
+
+	res, err := plan.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+	f, err := ds.Fields()
+	assert.NoError(t, err)
+	assert.Equal(t, "database", f[1].Name())
+	assert.Equal(t, "rows", f[3].Name())
+
+	var cnt int
+	for {
+		next, err := ds.Next()
+		if err != nil {
+			break
+		}
+		values := make([]proto.Value, len(f))
+		_ = next.Scan(values)
+		t.Logf("explain analyze: %v", values)
+		assert.Equal(t, "fake_db", values[1].String())
+		n, _ := values[3].Int64()
+		assert.Equal(t, int64(3), n)
+		cnt++
+	}
+	assert.Equal(t, 1, cnt)
+}
+
+func TestOptimizer_OptimizeExplainWarnings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
+	vt.SetAllowFullScan(true)
+
+	type tt struct {
+		sql    string
+		expect []string
+	}
+
+	for _, it := range []tt{
+		{"explain select id, uid from student", []string{"no sharding-key predicate; will full-scan 8 shards of table 'student'"}},
+		{"explain select id, uid from student where name = 'foo'", []string{"no sharding-key predicate; will full-scan 8 shards of table 'student'"}},
+		{"explain delete from student where name = 'foo'", []string{"no sharding-key predicate; will full-scan 8 shards of table 'student'"}},
+		{"explain select id, uid from student where uid = 1 or name = 'foo'", []string{
+			"OR condition on non-sharding column prevents shard pruning; will full-scan 8 shards of table 'student'",
+		}},
+		{"explain select id, uid from student where uid in (1,2)", nil},
+		{"explain update student set name = 'foo' where uid = 1", nil},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			ctx := rcontext.WithWarnings(context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true))
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			_, err = opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			var actual []string
+			for _, w := range rcontext.Warnings(ctx) {
+				actual = append(actual, w.Message)
+			}
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeSessionVariables(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// session variables should be answered without backend
+	conn := testdata.NewMockVConn(ctrl)
+
+	var (
+		sql = "select @@session.arana_best_effort, @@arana_hint"
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	p := parser.New()
+	stmt, _ := p.ParseOneStmt(sql, "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+	plan, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
+
+	res, err := plan.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+	fields, err := ds.Fields()
+	assert.NoError(t, err)
+	assert.Equal(t, "@@session.arana_best_effort", fields[0].Name())
+	assert.Equal(t, "@@arana_hint", fields[1].Name())
+
+	next, err := ds.Next()
+	assert.NoError(t, err)
+	values := make([]proto.Value, len(fields))
+	_ = next.Scan(values)
+	assert.Equal(t, "0", values[0].String())
+	assert.Equal(t, "", values[1].String())
+
+	// the variables owned by backend are forwarded
+	stmt, _ = p.ParseOneStmt("select @@sql_mode, @@time_zone", "", "")
+	opt, err = NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+	plan, err = opt.Optimize(ctx)
+	assert.NoError(t, err)
+	_, ok := plan.(*dml.LocalSelectPlan)
+	assert.False(t, ok)
+}
+
+func TestOptimizer_OptimizeSetShardStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru.MustVTable("student").GetVShards()[0].Name = "by_user"
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(resultx.New(resultx.WithDataset(&dataset.VirtualDataset{})), nil).
+		AnyTimes()
+
+	for _, it := range []struct {
+		sql string
+		ok  bool
+	}{
+		{"set arana_shard_strategy = 'BY_USER'", true},
+		{"set arana_shard_strategy = ''", true},
+		{"set arana_shard_strategy = 'not_exists'", false},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			variables := make(map[string]proto.Value)
+			ctx := context.WithValue(context.Background(), proto.ContextKeyTransientVariables{}, variables)
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			_, err = plan.ExecIn(ctx, conn)
+			if it.ok {
+				assert.NoError(t, err)
+				assert.Contains(t, variables, "@@arana_shard_strategy")
+			} else {
+				assert.Error(t, err)
+				assert.Empty(t, variables)
+			}
+		})
+	}
+}
+
+func TestOptimizer_OptimizeHashJoin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	studentFields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	salariesFields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	buildPlan := true
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+
+			result := testdata.NewMockResult(ctrl)
+			fakeData := &dataset.VirtualDataset{}
+			if buildPlan {
+				fakeData.Columns = append(studentFields, mysql.NewField("uid", consts.FieldTypeLongLong))
+				result.EXPECT().Dataset().Return(fakeData, nil).AnyTimes()
+				buildPlan = false
+			} else {
+				fakeData.Columns = append(salariesFields, mysql.NewField("uid", consts.FieldTypeLongLong))
+				result.EXPECT().Dataset().Return(fakeData, nil).AnyTimes()
+			}
+
+			return result, nil
+		}).MinTimes(2)
+
+	fakeData := make(map[string]*proto.TableMetadata)
+	// fake data
+	fakeData["student_0000"] = &proto.TableMetadata{
+		Name:        "student_0000",
+		Columns:     map[string]*proto.ColumnMetadata{"uid": {}},
+		ColumnNames: []string{"uid"},
+	}
+
+	fakeData["salaries_0000"] = &proto.TableMetadata{
+		Name:        "salaries_0000",
+		Columns:     map[string]*proto.ColumnMetadata{"uid": {}},
+		ColumnNames: []string{"uid"},
+	}
+	loader := testdata.NewMockSchemaLoader(ctrl)
+	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeData, nil).AnyTimes()
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	var (
+		sql = "select * from student join salaries on student.uid = salaries.uid"
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	ru = makeFakeRule(ctrl, "salaries", 8, ru)
+
+	p := parser.New()
+	stmt, _ := p.ParseOneStmt(sql, "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+
+	vTable, _ := ru.VTable("student")
+	vTable.SetAllowFullScan(true)
+
+	vTable2, _ := ru.VTable("salaries")
+	vTable2.SetAllowFullScan(true)
+
+	plan, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
+
+	_, _ = plan.ExecIn(ctx, conn)
+}
+
+func TestOptimizer_OptimizeJoinStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeData := map[string]*proto.TableMetadata{
+		"student_0000": {
+			Name:        "student_0000",
+			Columns:     map[string]*proto.ColumnMetadata{"uid": {}},
+			ColumnNames: []string{"uid"},
+		},
+		"salaries_0000": {
+			Name:        "salaries_0000",
+			Columns:     map[string]*proto.ColumnMetadata{"uid": {}},
+			ColumnNames: []string{"uid"},
+		},
+	}
+	loader := testdata.NewMockSchemaLoader(ctrl)
+	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeData, nil).AnyTimes()
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	// student has 8 tables, salaries has 4 tables
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru = makeFakeRule(ctrl, "salaries", 4, ru)
+	ru.MustVTable("student").SetAllowFullScan(true)
+	ru.MustVTable("salaries").SetAllowFullScan(true)
+
+	type tt struct {
+		name     string
+		hint     string
+		sql      string
+		hash     bool
+		reversed bool
+	}
+
+	for _, it := range []tt{
+		{"AutoBuildRight", "", "select a.uid, b.uid from student a join salaries b on a.uid = b.uid", true, true},
+		{"AutoBuildLeft", "", "select a.uid, b.uid from salaries b join student a on b.uid = a.uid", true, false},
+		{"AutoNonEquiJoin", "", "select a.uid, b.uid from student a join salaries b on a.uid > b.uid", false, true},
+		{"Hash", "HashJoin()", "select a.uid, b.uid from student a join salaries b on a.uid = b.uid", true, false},
+		{"NestedLoop", "NestedLoop()", "select a.uid, b.uid from student a join salaries b on a.uid = b.uid", false, false},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			var hints []*hint.Hint
+			if len(it.hint) > 0 {
+				h, err := hint.Parse(it.hint)
+				assert.NoError(t, err)
+				hints = append(hints, h)
+			}
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, hints, stmt, nil)
+			assert.NoError(t, err)
+
+			plan, err := opt.Optimize(context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true))
+			assert.NoError(t, err)
+
+			var (
+				hash, nestedLoop bool
+				reversed         bool
+			)
+			_ = dml.Walk(plan, func(p proto.Plan, _ int) (bool, error) {
+				switch join := p.(type) {
+				case *dml.HashJoinPlan:
+					hash, reversed = true, join.IsReversedColumn
+				case *dml.NestedLoopJoinPlan:
+					nestedLoop, reversed = true, join.IsReversedColumn
+				}
+				return true, nil
+			})
+			assert.Equal(t, it.hash, hash)
+			assert.Equal(t, !it.hash, nestedLoop)
+			assert.Equal(t, it.reversed, reversed)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeJoinDropInternalColumns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		sql = "select a.uid from student a join salaries b on a.uid = b.uid order by b.score desc"
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	ru = makeFakeRule(ctrl, "salaries", 8, ru)
+	ru.MustVTable("student").SetAllowFullScan(true)
+	ru.MustVTable("salaries").SetAllowFullScan(true)
+
+	stmt, _ := parser.New().ParseOneStmt(sql, "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+
+	plan, err := opt.Optimize(ctx)
+	assert.NoError(t, err)
+
+	// the order-by column is injected into the join, which should be dropped after merged
+	var internals []string
+	_ = dml.Walk(plan, func(p proto.Plan, _ int) (bool, error) {
+		if it, ok := p.(*dml.ProjectPlan); ok {
+			internals = append(internals, it.Internals...)
+		}
+		return true, nil
+	})
+	assert.Equal(t, []string{"score"}, internals)
+}
+
+func TestOptimizer_OptimizeJoinPropagateConstants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl,
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	)
+
+	fakeData := map[string]*proto.TableMetadata{
+		"student_0000": {
+			Name:        "student_0000",
+			Columns:     map[string]*proto.ColumnMetadata{"uid": {}},
+			ColumnNames: []string{"uid"},
+		},
+		"salaries_0000": {
+			Name:        "salaries_0000",
+			Columns:     map[string]*proto.ColumnMetadata{"uid": {}},
+			ColumnNames: []string{"uid"},
+		},
+	}
+	loader := testdata.NewMockSchemaLoader(ctrl)
+	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeData, nil).AnyTimes()
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	var (
+		sql = "select * from student a join salaries b on a.uid = b.uid where a.uid = b.uid and b.uid = ?"
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+	ru = makeFakeRule(ctrl, "salaries", 8, ru)
+
+	// full scan is disallowed, both tables should be pruned by the single constant
+	execQuery(t, ctx, ru, conn, sql, []proto.Value{proto.NewValueInt64(7)})
+
+	// both tables are pruned to the same shard, so the join is pushed down
+	assert.Equal(t, []string{
+		"SELECT * FROM `student_0007` AS `a` INNER JOIN `salaries_0007` AS `b` ON `a`.`uid` = `b`.`uid` WHERE `a`.`uid` = `b`.`uid` AND `b`.`uid` = ?",
+	}, qr.sqls())
+}
+
+func TestOptimizer_OptimizeQuoteReservedWords(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl,
+		mysql.NewField("order", consts.FieldTypeLongLong),
+		mysql.NewField("select", consts.FieldTypeVarString),
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	)
+
+	columns := func(names ...string) map[string]*proto.ColumnMetadata {
+		ret := make(map[string]*proto.ColumnMetadata)
+		for _, it := range names {
+			ret[it] = &proto.ColumnMetadata{Name: it}
+		}
+		return ret
+	}
+	fakeData := map[string]*proto.TableMetadata{
+		"student_0000": {
+			Name:        "student_0000",
+			Columns:     columns("order", "select", "uid"),
+			ColumnNames: []string{"order", "select", "uid"},
+		},
+	}
+	loader := testdata.NewMockSchemaLoader(ctrl)
+	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeData, nil).AnyTimes()
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru = makeFakeRule(ctrl, "salaries", 8, ru)
+
+	for _, it := range []struct {
+		sql      string
+		expected string
+	}{
+		{
+			"select * from student where uid = 1",
+			"SELECT `order`,`select`,`uid` FROM `student_0001` WHERE `uid` = 1",
+		},
+		{
+			"select `order`, `select` from student where uid = 1 order by `order`",
+			"SELECT `order`,`select` FROM `student_0001` WHERE `uid` = 1 ORDER BY `order`",
+		},
+		{
+			"select `order`.`select` from student `order` join salaries `group` on `order`.uid = `group`.uid where `order`.uid = 5",
+			"SELECT `order`.`select` FROM `student_0005` AS `order` INNER JOIN `salaries_0005` AS `group` ON `order`.`uid` = `group`.`uid` WHERE `order`.`uid` = 5",
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			qr.reset()
+			_, err := optimizeQuery(t, ctx, ru, it.sql, nil).ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{it.expected}, qr.sqls())
+		})
+	}
+}
+
+func TestOptimizer_OptimizeSingleShardJoinAggregate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn := testdata.NewMockVConn(ctrl)
+
+	var sqls []string
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			assert.Equal(t, "fake_db", db)
+			sqls = append(sqls, sql)
+
+			fields := []proto.Field{
+				mysql.NewField("COUNT(*)", consts.FieldTypeLongLong),
+			}
+			fakeData := &dataset.VirtualDataset{
+				Columns: fields,
+				Rows: []proto.Row{
+					rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(3)}),
+				},
+			}
+			return resultx.New(resultx.WithDataset(fakeData)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru = makeFakeRule(ctrl, "salaries", 8, ru)
+
+	for _, it := range []struct {
+		sql      string
+		args     []proto.Value
+		expected string
+	}{
+		{
+			"select count(*) from student a join salaries b on a.uid = b.uid where a.uid = 5",
+			nil,
+			"SELECT COUNT(1) FROM `student_0005` AS `a` INNER JOIN `salaries_0005` AS `b` ON `a`.`uid` = `b`.`uid` WHERE `a`.`uid` = 5",
+		},
+		{
+			"select count(*) from student join salaries on student.uid = salaries.uid where salaries.uid = ?",
+			[]proto.Value{proto.NewValueInt64(13)},
+			"SELECT COUNT(1) FROM `student_0005` AS `student` INNER JOIN `salaries_0005` AS `salaries` ON `student`.`uid` = `salaries`.`uid` WHERE `salaries`.`uid` = ?",
+		},
+		{
+			"select count(*) from student a join salaries b on a.uid = b.uid where a.uid = 5 group by b.month having count(*) > 1 order by b.month limit 3",
+			nil,
+			"SELECT COUNT(1) FROM `student_0005` AS `a` INNER JOIN `salaries_0005` AS `b` ON `a`.`uid` = `b`.`uid` WHERE `a`.`uid` = 5 " +
+				"GROUP BY `b`.`month` HAVING COUNT(1) > 1 ORDER BY `b`.`month` LIMIT 3",
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			sqls = sqls[:0]
+
+			stmt, err := parser.New().ParseOneStmt(it.sql, "", "")
+			assert.NoError(t, err)
+			opt, err := NewOptimizer(ru, nil, stmt, it.args)
+			assert.NoError(t, err)
+
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{it.expected}, sqls)
+
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+			next, err := ds.Next()
+			assert.NoError(t, err)
+			dest := make([]proto.Value, 1)
+			assert.NoError(t, next.Scan(dest))
+			assert.Equal(t, "3", dest[0].String())
+		})
+	}
+}
+
+func TestOptimizer_OptimizeSingleShardJoinLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn := testdata.NewMockVConn(ctrl)
+
+	var (
+		sqls []string
+		args [][]interface{}
+	)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, a ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, a)
+			sqls = append(sqls, sql)
+			args = append(args, a)
+
+			// the backend has applied the ORDER BY and LIMIT already
+			fields := []proto.Field{
+				mysql.NewField("uid", consts.FieldTypeLongLong),
+			}
+			fakeData := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for i := 0; i < 3; i++ {
+				fakeData.Rows = append(fakeData.Rows, rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(5)}))
+			}
+			return resultx.New(resultx.WithDataset(fakeData)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru = makeFakeRule(ctrl, "salaries", 8, ru)
+
+	for _, it := range []struct {
+		sql      string
+		args     []proto.Value
+		expected string
+	}{
+		{
+			"select a.uid from student a join salaries b on a.uid = b.uid where a.uid = 5 limit 10",
+			nil,
+			"SELECT `a`.`uid` FROM `student_0005` AS `a` INNER JOIN `salaries_0005` AS `b` ON `a`.`uid` = `b`.`uid` WHERE `a`.`uid` = 5 LIMIT 10",
+		},
+		{
+			"select a.uid from student a join salaries b on a.uid = b.uid where a.uid = 5 order by b.month desc limit 10 offset 20",
+			nil,
+			"SELECT `a`.`uid` FROM `student_0005` AS `a` INNER JOIN `salaries_0005` AS `b` ON `a`.`uid` = `b`.`uid` WHERE `a`.`uid` = 5 " +
+				"ORDER BY `b`.`month` DESC LIMIT 20,10",
+		},
+		{
+			"select a.uid from student a join salaries b on a.uid = b.uid where b.uid = ? order by b.month limit ?, ?",
+			[]proto.Value{proto.NewValueInt64(13), proto.NewValueInt64(20), proto.NewValueInt64(10)},
+			"SELECT `a`.`uid` FROM `student_0005` AS `a` INNER JOIN `salaries_0005` AS `b` ON `a`.`uid` = `b`.`uid` WHERE `b`.`uid` = ? " +
+				"ORDER BY `b`.`month` LIMIT ?,?",
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			sqls, args = sqls[:0], args[:0]
+
+			stmt, err := parser.New().ParseOneStmt(it.sql, "", "")
+			assert.NoError(t, err)
+			opt, err := NewOptimizer(ru, nil, stmt, it.args)
+			assert.NoError(t, err)
+
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{it.expected}, sqls)
+			// the LIMIT arguments are passed to the backend without rewriting
+			assert.Len(t, args[0], len(it.args))
+
+			// the returned rows are not limited or offset again
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+			var n int
+			for {
+				if _, err = ds.Next(); err != nil {
+					break
+				}
+				n++
+			}
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, 3, n)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeColocatedInJoin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn := testdata.NewMockVConn(ctrl)
+
+	var sqls []string
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake query: db=%s, sql=%s, args=%v\n", db, sql, args)
+			assert.Equal(t, "fake_db", db)
+			sqls = append(sqls, sql)
+
+			fields := []proto.Field{
+				mysql.NewField("uid", consts.FieldTypeLongLong),
+			}
+			fakeData := &dataset.VirtualDataset{
+				Columns: fields,
+				Rows: []proto.Row{
+					rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(1)}),
+				},
+			}
+			return resultx.New(resultx.WithDataset(fakeData)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru = makeFakeRule(ctrl, "salaries", 8, ru)
+
+	for _, it := range []struct {
+		sql      string
+		args     []proto.Value
+		expected []string
+	}{
+		{
+			"select a.uid from student a join salaries b on a.uid = b.uid where a.uid in (1, 9, 3, ?)",
+			[]proto.Value{proto.NewValueInt64(11)},
+			[]string{
+				"SELECT `a`.`uid` FROM `student_0001` AS `a` INNER JOIN `salaries_0001` AS `b` ON `a`.`uid` = `b`.`uid` WHERE `a`.`uid` IN (1,9,3,?)",
+				"SELECT `a`.`uid` FROM `student_0003` AS `a` INNER JOIN `salaries_0003` AS `b` ON `a`.`uid` = `b`.`uid` WHERE `a`.`uid` IN (1,9,3,?)",
+			},
+		},
+		{
+			"select a.uid from student a left join salaries b on b.uid = a.uid where b.uid in (2, 7) and b.month = 3",
+			nil,
+			[]string{
+				"SELECT `a`.`uid` FROM `student_0002` AS `a` LEFT JOIN `salaries_0002` AS `b` ON `b`.`uid` = `a`.`uid` WHERE `b`.`uid` IN (2,7) AND `b`.`month` = 3",
+				"SELECT `a`.`uid` FROM `student_0007` AS `a` LEFT JOIN `salaries_0007` AS `b` ON `b`.`uid` = `a`.`uid` WHERE `b`.`uid` IN (2,7) AND `b`.`month` = 3",
+			},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			sqls = sqls[:0]
+
+			stmt, err := parser.New().ParseOneStmt(it.sql, "", "")
+			assert.NoError(t, err)
+			opt, err := NewOptimizer(ru, nil, stmt, it.args)
+			assert.NoError(t, err)
+
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+
+			res, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+
+			// the rows joined in each pair of shards are merged
+			ds, err := res.Dataset()
+			assert.NoError(t, err)
+			var n int
+			for {
+				if _, err = ds.Next(); err != nil {
+					break
+				}
+				n++
+			}
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, len(it.expected), n)
+			assert.Equal(t, it.expected, sqls)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeInsert(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn := testdata.NewMockVConn(ctrl)
+	loader := testdata.NewMockSchemaLoader(ctrl)
+
+	var fakeId uint64
+
+	fakeStudentMetadata := make(map[string]*proto.TableMetadata)
+	fakeStuColumnMetadata := make(map[string]*proto.ColumnMetadata)
+	fakeStuColumnMetadata["name"] = &proto.ColumnMetadata{
+		Name:          "name",
+		DataType:      "varchar",
+		Ordinal:       "1",
+		PrimaryKey:    false,
+		Generated:     false,
+		CaseSensitive: false,
+	}
+	fakeStuColumnMetadata["uid"] = &proto.ColumnMetadata{
+		Name:          "uid",
+		DataType:      "bigint",
+		Ordinal:       "2",
+		PrimaryKey:    false,
+		Generated:     false,
+		CaseSensitive: false,
+	}
+	fakeStuColumnMetadata["age"] = &proto.ColumnMetadata{
+		Name:          "age",
+		DataType:      "tinyint",
+		Ordinal:       "3",
+		PrimaryKey:    false,
+		Generated:     false,
+		CaseSensitive: false,
+	}
+	fakeStudentMetadata["student"] = &proto.TableMetadata{
+		Name:              "student",
+		Columns:           fakeStuColumnMetadata,
 		Indexes:           nil,
 		ColumnNames:       []string{"name", "uid", "age"},
 		PrimaryKeyColumns: nil,
 	}
+	fakeStudentMetadata["student_0000"] = fakeStudentMetadata["student"]
+
+	conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake exec: db='%s', sql=\"%s\", args=%v\n", db, sql, args)
+			fakeId++
+
+			return resultx.New(
+				resultx.WithRowsAffected(uint64(strings.Count(sql, "?"))),
+				resultx.WithLastInsertID(fakeId),
+			), nil
+		}).
+		AnyTimes()
+	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeStudentMetadata, nil).Times(6)
+
+	oldLoader := proto.LoadSchemaLoader()
+	proto.RegisterSchemaLoader(loader)
+	defer proto.RegisterSchemaLoader(oldLoader)
+
+	var (
+		ctx = context.Background()
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	t.Run("sharding", func(t *testing.T) {
+		sql := "insert into student(name,uid,age) values('foo',?,18),('bar',?,19),('qux',?,17)"
+
+		p := parser.New()
+		stmt, _ := p.ParseOneStmt(sql, "", "")
+
+		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{
+			proto.NewValueInt64(8),
+			proto.NewValueInt64(9),
+			proto.NewValueInt64(16),
+		})
+		assert.NoError(t, err)
+
+		plan, err := opt.Optimize(ctx) // 8,16 -> fake_db_0000, 9 -> fake_db_0001
+		assert.NoError(t, err)
+		// the batching is disabled by default
+		assert.False(t, plan.(rplan.Transactional).Transactional())
+
+		res, err := plan.ExecIn(ctx, conn)
+		assert.NoError(t, err)
+
+		affected, _ := res.RowsAffected()
+		assert.Equal(t, uint64(3), affected)
+		lastInsertId, _ := res.LastInsertId()
+		assert.Equal(t, fakeId, lastInsertId)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		vt, _ := ru.VTable("student")
+		vt.SetInsertBatchSize(1)
+		defer vt.SetInsertBatchSize(0)
+
+		sql := "insert into student(name,uid,age) values('foo',?,18),('bar',?,19)"
+
+		p := parser.New()
+		stmt, _ := p.ParseOneStmt(sql, "", "")
+
+		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{
+			proto.NewValueInt64(8),
+			proto.NewValueInt64(16),
+		})
+		assert.NoError(t, err)
+
+		plan, err := opt.Optimize(ctx) // 8,16 -> fake_db_0000, split into 2 statements
+		assert.NoError(t, err)
+		assert.True(t, plan.(rplan.Transactional).Transactional())
+
+		before := fakeId
+		res, err := plan.ExecIn(ctx, conn)
+		assert.NoError(t, err)
+		assert.Equal(t, before+2, fakeId)
+
+		affected, _ := res.RowsAffected()
+		assert.Equal(t, uint64(2), affected)
+	})
+
+	t.Run("implicit columns", func(t *testing.T) {
+		conn := testdata.NewMockVConn(ctrl)
+		conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+				assert.Equal(t, "INSERT INTO `student_0000`(`name`, `uid`, `age`) VALUES ('foo', ?, 18)", sql)
+				return resultx.New(resultx.WithRowsAffected(1)), nil
+			}).
+			Times(1)
+
+		p := parser.New()
+		stmt, _ := p.ParseOneStmt("insert into student values('foo',?,18)", "", "")
+
+		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{proto.NewValueInt64(8)})
+		assert.NoError(t, err)
+
+		plan, err := opt.Optimize(ctx)
+		assert.NoError(t, err)
+
+		_, err = plan.ExecIn(ctx, conn)
+		assert.NoError(t, err)
+
+		// the amount of values doesn't match the logical schema
+		stmt, _ = p.ParseOneStmt("insert into student values('foo',?)", "", "")
+		opt, err = NewOptimizer(ru, nil, stmt, []proto.Value{proto.NewValueInt64(8)})
+		assert.NoError(t, err)
+		_, err = opt.Optimize(ctx)
+		assert.Error(t, err)
+	})
+
+	t.Run("non-sharding", func(t *testing.T) {
+		sql := "insert into abc set name='foo',uid=?,age=18"
+
+		p := parser.New()
+		stmt, _ := p.ParseOneStmt(sql, "", "")
+
+		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{proto.NewValueInt64(1)})
+		assert.NoError(t, err)
+
+		plan, err := opt.Optimize(ctx)
+		assert.NoError(t, err)
+
+		res, err := plan.ExecIn(ctx, conn)
+		assert.NoError(t, err)
+
+		affected, _ := res.RowsAffected()
+		assert.Equal(t, uint64(1), affected)
+		lastInsertId, _ := res.LastInsertId()
+		assert.Equal(t, fakeId, lastInsertId)
+	})
+}
+
+func TestOptimizer_OptimizeUnconditionalWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+
+	for _, it := range []struct {
+		sql    string
+		denied bool
+	}{
+		{"delete from student", true},
+		{"update student set name = 'foo'", true},
+		{"delete from student where uid = 1", false},
+		{"update student set name = 'foo' where uid = 1", false},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			_, err = opt.Optimize(context.Background())
+			if !it.denied {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, IsDenyFullScanErr(err))
+			assert.Contains(t, err.Error(), "unconditional")
+		})
+	}
+
+	// allowed explicitly
+	vt, _ := ru.VTable("student")
+	vt.SetAllowFullScan(true)
+	stmt, _ := parser.New().ParseOneStmt("delete from student", "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+	_, err = opt.Optimize(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestOptimizer_OptimizeUpdateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var sqls []string
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			sqls = append(sqls, sql)
+			return resultx.New(), nil
+		}).
+		AnyTimes()
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
+	vt.SetAllowFullScan(true)
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+		err    string
+	}{
+		{
+			"update student set name = 'foo' where uid = 1 order by id limit 10",
+			[]string{"UPDATE `student_0001` SET `name` = 'foo' WHERE `uid` = 1 ORDER BY `id` LIMIT 10"},
+			"",
+		},
+		{
+			"update student set name = 'foo' where uid in (1,9) order by id desc limit 1",
+			[]string{"UPDATE `student_0001` SET `name` = 'foo' WHERE `uid` IN (1,9) ORDER BY `id` DESC LIMIT 1"},
+			"",
+		},
+		{
+			"update student set name = 'foo' where uid in (1,2) order by id limit 10",
+			nil,
+			"UPDATE with LIMIT across 2 shards of table 'student' is not supported",
+		},
+		{
+			"update student set name = 'foo' where age > 18 limit 10",
+			nil,
+			"UPDATE with LIMIT across 8 shards of table 'student' is not supported",
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			sqls = sqls[:0]
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(context.Background())
+			if len(it.err) > 0 {
+				assert.ErrorContains(t, err, it.err)
+				return
+			}
+			assert.NoError(t, err)
+			_, err = plan.ExecIn(context.Background(), conn)
+			assert.NoError(t, err)
+			assert.Equal(t, it.expect, sqls)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeWriteShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var tables []string
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake exec: db=%s, sql=%s, args=%v\n", db, sql, args)
+			tables = append(tables, regexp.MustCompile("student_\\d+").FindString(sql))
+			return resultx.New(), nil
+		}).
+		AnyTimes()
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+
+	all := []string{
+		"student_0000", "student_0001", "student_0002", "student_0003",
+		"student_0004", "student_0005", "student_0006", "student_0007",
+	}
+
+	// the writes are pruned in the same way as SELECT
+	for _, it := range []struct {
+		where  string
+		args   []proto.Value
+		expect []string
+	}{
+		{"uid = 1", nil, []string{"student_0001"}},
+		{"uid = ? and name = 'foo'", []proto.Value{proto.NewValueInt64(10)}, []string{"student_0002"}},
+		{"uid in (1, 9, 3)", nil, []string{"student_0001", "student_0003"}},
+		{"uid in (?, ?)", []proto.Value{proto.NewValueInt64(4), proto.NewValueInt64(5)}, []string{"student_0004", "student_0005"}},
+		{"uid between 2 and 4", nil, []string{"student_0002", "student_0003", "student_0004"}},
+		{"uid > 10 and uid < 13", nil, []string{"student_0003", "student_0004"}},
+		{"uid = 1 or uid = 6", nil, []string{"student_0001", "student_0006"}},
+		{"(uid = 1 or uid = 2) and uid <> 1", nil, []string{"student_0002"}},
+		{"uid = 1 and uid = 2", nil, nil},
+		// cannot be pruned
+		{"uid = 1 or name = 'foo'", nil, all},
+		{"name = 'foo'", nil, all},
+		{"uid > 1000", nil, all},
+	} {
+		for _, sql := range []string{
+			"update student set score = 100 where " + it.where,
+			"delete from student where " + it.where,
+		} {
+			t.Run(sql, func(t *testing.T) {
+				tables = nil
+
+				vt, _ := ru.VTable("student")
+				vt.SetAllowFullScan(len(it.expect) == len(all))
+
+				stmt, _ := parser.New().ParseOneStmt(sql, "", "")
+				opt, err := NewOptimizer(ru, nil, stmt, it.args)
+				assert.NoError(t, err)
+				plan, err := opt.Optimize(context.Background())
+				assert.NoError(t, err)
+				_, err = plan.ExecIn(context.Background(), conn)
+				assert.NoError(t, err)
+
+				sort.Strings(tables)
+				assert.Equal(t, it.expect, tables)
+			})
+		}
+	}
+}
 
-	conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
-			t.Logf("fake exec: db='%s', sql=\"%s\", args=%v\n", db, sql, args)
-			fakeId++
+func TestOptimizer_OptimizeUnboundedRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-			return resultx.New(
-				resultx.WithRowsAffected(uint64(strings.Count(sql, "?"))),
-				resultx.WithLastInsertID(fakeId),
-			), nil
-		}).
-		AnyTimes()
-	loader.EXPECT().Load(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeStudentMetadata, nil).Times(2)
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong), mysql.NewField("name", consts.FieldTypeVarString))
 
-	oldLoader := proto.LoadSchemaLoader()
-	proto.RegisterSchemaLoader(loader)
-	defer proto.RegisterSchemaLoader(oldLoader)
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
 
-	var (
-		ctx = context.Background()
-		ru  = makeFakeRule(ctrl, "student", 8, nil)
-	)
+	const sql = "select uid, name from student where uid > 1000"
 
-	t.Run("sharding", func(t *testing.T) {
-		sql := "insert into student(name,uid,age) values('foo',?,18),('bar',?,19),('qux',?,17)"
+	// the range of modulo sharding key cannot be pruned, it is a full scan
+	vt.SetAllowFullScan(false)
+	stmt, _ := parser.New().ParseOneStmt(sql, "", "")
+	opt, err := NewOptimizer(ru, nil, stmt, nil)
+	assert.NoError(t, err)
+	_, err = opt.Optimize(ctx)
+	assert.True(t, IsDenyFullScanErr(err))
 
-		p := parser.New()
-		stmt, _ := p.ParseOneStmt(sql, "", "")
+	vt.SetAllowFullScan(true)
+	execQuery(t, ctx, ru, conn, sql, nil)
 
-		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{
-			proto.NewValueInt64(8),
-			proto.NewValueInt64(9),
-			proto.NewValueInt64(16),
+	tables := qr.tables()
+	sort.Strings(tables)
+	assert.Equal(t, []string{
+		"student_0000", "student_0001", "student_0002", "student_0003",
+		"student_0004", "student_0005", "student_0006", "student_0007",
+	}, tables)
+}
+func TestOptimizer_OptimizeCaseInsensitiveColumns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var sqls []string
+	record := func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+		sqls = append(sqls, sql)
+		return resultx.New(), nil
+	}
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(record).AnyTimes()
+	conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(record).AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{
+			"select uid, name from student where UID = 3",
+			[]string{"SELECT `uid`,`name` FROM `student_0003` WHERE `UID` = 3"},
+		},
+		{
+			"insert into student(UID, name) values (3, 'foo')",
+			[]string{"INSERT INTO `student_0003`(`uid`, `name`) VALUES (3, 'foo')"},
+		},
+		{
+			// the ORDER BY matches the select element, no weak column is appended
+			"select id, uid from student where uid in (1,9) order by ID",
+			[]string{"SELECT `id`,`uid` FROM `student_0001` WHERE `uid` IN (1,9) ORDER BY `ID`"},
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			sqls = sqls[:0]
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(makeFakeRule(ctrl, "student", 8, nil), nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+			_, err = plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			assert.Equal(t, it.expect, sqls)
 		})
+	}
+
+	t.Run("UpdateShardingKey", func(t *testing.T) {
+		stmt, _ := parser.New().ParseOneStmt("update student set UID = 2 where uid = 1", "", "")
+		opt, err := NewOptimizer(makeFakeRule(ctrl, "student", 8, nil), nil, stmt, nil)
 		assert.NoError(t, err)
+		_, err = opt.Optimize(ctx)
+		assert.ErrorContains(t, err, "sharding key 'uid'")
+	})
 
-		plan, err := opt.Optimize(ctx) // 8,16 -> fake_db_0000, 9 -> fake_db_0001
+	t.Run("CaseSensitive", func(t *testing.T) {
+		ru := makeFakeRule(ctrl, "student", 8, nil)
+		vt, _ := ru.VTable("student")
+		vt.SetCaseSensitiveColumns(true)
+
+		stmt, _ := parser.New().ParseOneStmt("select uid, name from student where UID = 3", "", "")
+		opt, err := NewOptimizer(ru, nil, stmt, nil)
 		assert.NoError(t, err)
+		_, err = opt.Optimize(ctx)
+		assert.True(t, IsDenyFullScanErr(err))
 
-		res, err := plan.ExecIn(ctx, conn)
+		// the ORDER BY doesn't match the select element, it is selected as a weak column
+		stmt, _ = parser.New().ParseOneStmt("select id, uid from student where uid in (1,2) order by ID", "", "")
+		opt, err = NewOptimizer(ru, nil, stmt, nil)
+		assert.NoError(t, err)
+		plan, err := opt.Optimize(ctx)
 		assert.NoError(t, err)
+		var orderBy []string
+		_ = dml.Walk(plan, func(p proto.Plan, _ int) (bool, error) {
+			if op, ok := p.(*dml.OrderPlan); ok {
+				for _, it := range op.OrderByItems {
+					orderBy = append(orderBy, it.Column)
+				}
+			}
+			return true, nil
+		})
+		assert.Equal(t, []string{"ID"}, orderBy)
+	})
+}
 
-		affected, _ := res.RowsAffected()
-		assert.Equal(t, uint64(3), affected)
-		lastInsertId, _ := res.LastInsertId()
-		assert.Equal(t, fakeId, lastInsertId)
+func TestOptimizer_OptimizeStatementPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru.SetPolicies([]*rule.StatementPolicy{
+		{
+			Name:         "no-limit-select",
+			Types:        []string{"SELECT"},
+			Tables:       []string{"student"},
+			WithoutLimit: true,
+			Message:      "SELECT on student requires a LIMIT clause",
+		},
+		{
+			Name:          "cartesian-join",
+			CartesianJoin: true,
+		},
 	})
 
-	t.Run("non-sharding", func(t *testing.T) {
-		sql := "insert into abc set name='foo',uid=?,age=18"
+	for _, it := range []struct {
+		sql     string
+		blocked string
+	}{
+		{"select id, uid from student where uid = 1", "requires a LIMIT clause"},
+		{"select id, uid from student where uid = 1 limit 10", ""},
+		{"select a.id from student a join student b limit 10", "cartesian-join"},
+		{"select a.id from student a join student b on a.id = b.id where a.uid = 1 limit 10", ""},
+		{"select a.id from student a join student b on 1 = 1 limit 10", "cartesian-join"},
+		{"select a.id from student a join student b on a.age > b.age limit 10", "cartesian-join"},
+		{"select a.id from student a, student b where a.id = b.id limit 10", ""},
+		{"select a.id from student a join student b on a.id = b.id join student c on a.age = 18 limit 10", "cartesian-join"},
+		{"select a.id from student a join student b on a.id = b.id join student c on c.uid = b.uid limit 10", ""},
+		{"delete from student where uid = 1", ""},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			_, err = opt.Optimize(context.Background())
+			if len(it.blocked) == 0 {
+				assert.False(t, IsStatementBlockedErr(err))
+				return
+			}
+			assert.True(t, IsStatementBlockedErr(err))
+			assert.Contains(t, err.Error(), it.blocked)
+		})
+	}
+}
 
-		p := parser.New()
-		stmt, _ := p.ParseOneStmt(sql, "", "")
+func TestOptimizer_OptimizeReadOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{proto.NewValueInt64(1)})
-		assert.NoError(t, err)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru = makeFakeRule(ctrl, "archive", 8, ru)
+	vt, _ := ru.VTable("archive")
+	vt.SetReadOnly(true)
 
-		plan, err := opt.Optimize(ctx)
-		assert.NoError(t, err)
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
 
-		res, err := plan.ExecIn(ctx, conn)
-		assert.NoError(t, err)
+	for _, it := range []struct {
+		sql      string
+		readOnly bool
+	}{
+		{"select id, uid from archive where uid = 1", false},
+		{"select a.id from archive a join student b on a.uid = b.uid where a.uid = 1", false},
+		{"insert into archive(id, uid) values(1, 1)", true},
+		{"replace into archive(id, uid) values(1, 1)", true},
+		{"insert into student(id, uid) select id, uid from archive where uid = 1", false},
+		{"insert into archive(id, uid) select id, uid from student where uid = 1", true},
+		{"update archive set name = 'foo' where uid = 1", true},
+		{"delete from archive where uid = 1", true},
+		{"truncate table archive", true},
+		{"alter table archive add column age int", true},
+		{"create index idx_name on archive(name)", true},
+		{"drop table student, archive", true},
+		{"delete from student where uid = 1", false},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, err := parser.New().ParseOneStmt(it.sql, "", "")
+			assert.NoError(t, err)
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			_, err = opt.Optimize(ctx)
+			if !it.readOnly {
+				assert.False(t, IsReadOnlyTableErr(err))
+				return
+			}
+			assert.True(t, IsReadOnlyTableErr(err))
+			assert.Contains(t, err.Error(), "table 'archive' is read-only")
+		})
+	}
+}
 
-		affected, _ := res.RowsAffected()
-		assert.Equal(t, uint64(1), affected)
-		lastInsertId, _ := res.LastInsertId()
-		assert.Equal(t, fakeId, lastInsertId)
-	})
+func TestOptimizer_OptimizeTenantPredicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeResult := func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+		return resultx.New(), nil
+	}
+
+	var (
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+		ctx = context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	)
+
+	// the table is sharded by tenant
+	vt, _ := ru.VTable("student")
+	vt.SetTenantColumn("uid")
+	ctx = context.WithValue(ctx, proto.ContextKeyTenant{}, "3")
+
+	for _, it := range []struct {
+		sql    string
+		expect string
+	}{
+		{
+			"select uid, name from student where name = 'foo' or age > 18",
+			"SELECT `uid`,`name` FROM `student_0003` WHERE (`name` = 'foo' OR `age` > 18) AND `uid` = '3'",
+		},
+		{
+			"select uid, name from student",
+			"SELECT `uid`,`name` FROM `student_0003` WHERE `uid` = '3'",
+		},
+		{
+			"update student set name = 'foo' where age > 18",
+			"UPDATE `student_0003` SET `name` = 'foo' WHERE `age` > 18 AND `uid` = '3'",
+		},
+		{
+			"delete from student where age > 18",
+			"DELETE FROM `student_0003` WHERE `age` > 18 AND `uid` = '3'",
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			var sqls []string
+			record := func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+				sqls = append(sqls, sql)
+				return fakeResult(ctx, db, sql, args...)
+			}
+
+			conn := testdata.NewMockVConn(ctrl)
+			conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(record).AnyTimes()
+			conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(record).AnyTimes()
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			assert.NoError(t, err)
+			_, err = plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+
+			assert.Equal(t, []string{it.expect}, sqls)
+		})
+	}
 }
 
 func TestOptimizer_OptimizeAlterTable(t *testing.T) {
@@ -371,7 +3800,7 @@ func TestOptimizer_OptimizeInsertSelect(t *testing.T) {
 		p := parser.New()
 		stmt, _ := p.ParseOneStmt(sql, "", "")
 
-		opt, err := NewOptimizer(&ru, nil, stmt, []proto.Value{proto.NewValueInt64(1)})
+		opt, err := NewOptimizer(&ru, nil, stmt, nil)
 		assert.NoError(t, err)
 
 		plan, err := opt.Optimize(ctx)
@@ -386,3 +3815,394 @@ func TestOptimizer_OptimizeInsertSelect(t *testing.T) {
 		assert.Equal(t, fakeId, lastInsertId)
 	})
 }
+
+func TestOptimizer_OptimizeReplace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn := testdata.NewMockVConn(ctrl)
+
+	var sqls []string
+	conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake exec: db='%s', sql=\"%s\", args=%v\n", db, sql, args)
+			assert.True(t, strings.HasPrefix(sql, "REPLACE INTO"))
+			sqls = append(sqls, sql)
+			// each replaced row counts twice: one for delete, one for insert
+			return resultx.New(resultx.WithRowsAffected(uint64(2 * strings.Count(sql, "?")))), nil
+		}).
+		AnyTimes()
+
+	var (
+		ctx = context.Background()
+		ru  = makeFakeRule(ctrl, "student", 8, nil)
+	)
+
+	t.Run("sharding", func(t *testing.T) {
+		sqls = nil
+
+		sql := "replace into student(name,uid,age) values('foo',?,18),('bar',?,19),('qux',?,17)"
+
+		p := parser.New()
+		stmt, _ := p.ParseOneStmt(sql, "", "")
+
+		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{
+			proto.NewValueInt64(8),
+			proto.NewValueInt64(9),
+			proto.NewValueInt64(16),
+		})
+		assert.NoError(t, err)
+
+		plan, err := opt.Optimize(ctx) // 8,16 -> student_0000, 9 -> student_0001
+		assert.NoError(t, err)
+		assert.True(t, plan.(rplan.Transactional).Transactional())
+
+		res, err := plan.ExecIn(ctx, conn)
+		assert.NoError(t, err)
+		assert.Len(t, sqls, 2)
+
+		affected, _ := res.RowsAffected()
+		assert.Equal(t, uint64(6), affected)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		sqls = nil
+
+		vt, _ := ru.VTable("student")
+		vt.SetInsertBatchSize(1)
+		defer vt.SetInsertBatchSize(0)
+
+		sql := "replace into student(name,uid,age) values('foo',?,18),('bar',?,19)"
+
+		p := parser.New()
+		stmt, _ := p.ParseOneStmt(sql, "", "")
+
+		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{
+			proto.NewValueInt64(8),
+			proto.NewValueInt64(16),
+		})
+		assert.NoError(t, err)
+
+		plan, err := opt.Optimize(ctx) // 8,16 -> student_0000, split into 2 statements
+		assert.NoError(t, err)
+		assert.True(t, plan.(rplan.Transactional).Transactional())
+
+		_, err = plan.ExecIn(ctx, conn)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"REPLACE INTO `student_0000`(`name`, `uid`, `age`) VALUES ('foo', ?, 18)",
+			"REPLACE INTO `student_0000`(`name`, `uid`, `age`) VALUES ('bar', ?, 19)",
+		}, sqls)
+	})
+
+	t.Run("single shard", func(t *testing.T) {
+		sql := "replace into student set name='foo',uid=?,age=18"
+
+		p := parser.New()
+		stmt, _ := p.ParseOneStmt(sql, "", "")
+
+		opt, err := NewOptimizer(ru, nil, stmt, []proto.Value{proto.NewValueInt64(8)})
+		assert.NoError(t, err)
+
+		plan, err := opt.Optimize(ctx)
+		assert.NoError(t, err)
+		assert.False(t, plan.(rplan.Transactional).Transactional())
+	})
+
+	t.Run("without sharding key", func(t *testing.T) {
+		sql := "replace into student(name,age) values('foo',18)"
+
+		p := parser.New()
+		stmt, _ := p.ParseOneStmt(sql, "", "")
+
+		opt, err := NewOptimizer(ru, nil, stmt, nil)
+		assert.NoError(t, err)
+
+		_, err = opt.Optimize(ctx)
+		assert.True(t, IsNoShardKeyFoundErr(err))
+	})
+}
+
+func TestOptimizer_OptimizeOrderByGroupItems(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl,
+		mysql.NewField("name", consts.FieldTypeVarString),
+		mysql.NewField("COUNT(1)", consts.FieldTypeLongLong),
+	)
+
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
+	vt.SetAllowFullScan(true)
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+
+	for _, it := range []struct {
+		sql      string
+		enable   bool
+		expected string
+	}{
+		{"select name, count(1) from student where uid = 1 group by name", false, "GROUP BY `name`"},
+		{"select name, count(1) from student where uid = 1 group by name", true, "GROUP BY `name` ORDER BY `name`"},
+		{"select name, count(1) from student where uid = 1 group by name desc, age", true, "GROUP BY `name` DESC,`age` ORDER BY `name` DESC, `age`"},
+		{"select name, count(1) from student where uid = 1 group by name order by count(1) desc", true, "ORDER BY COUNT(1) DESC"},
+		{"select name, count(1) from student group by name", true, "GROUP BY `name` ORDER BY `name`)"},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			vt.SetOrderByGroupItems(it.enable)
+			qr.reset()
+
+			plan := optimizeQuery(t, ctx, ru, it.sql, nil)
+			_, err := plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+
+			sqls := qr.sqls()
+			assert.NotEmpty(t, sqls)
+			for _, sql := range sqls {
+				assert.Contains(t, sql, it.expected)
+				if !it.enable {
+					assert.NotContains(t, sql, "ORDER BY")
+				}
+			}
+		})
+	}
+}
+func TestOptimizer_OptimizeSchemaRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+	conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			t.Logf("fake exec: db=%s, sql=%s, args=%v\n", db, sql, args)
+			qr.record(db, sql, args)
+			return resultx.New(resultx.WithRowsAffected(1)), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	ru.SetSchemaRoutes([]*rule.SchemaRoute{
+		{Prefix: "tenant_", Group: "fake_tenant_db"},
+	})
+
+	for _, it := range []struct {
+		sql       string
+		args      []proto.Value
+		expectDB  string
+		expectSQL string
+	}{
+		// the logical table of same name is not sharded
+		{"select * from tenant_acme.student where name = ?", []proto.Value{proto.NewValueString("foo")}, "fake_tenant_db", "SELECT * FROM `tenant_acme`.`student` WHERE `name` = ?"},
+		{"select uid from Tenant_Foo.users", nil, "fake_tenant_db", "SELECT `uid` FROM `Tenant_Foo`.`users`"},
+		{"select uid from student where uid = 1", nil, "fake_db", "SELECT `uid` FROM `student_0001` WHERE `uid` = 1"},
+		{"insert into tenant_acme.student(uid,name) values(1,?)", []proto.Value{proto.NewValueString("foo")}, "fake_tenant_db", "INSERT INTO `tenant_acme`.`student`(`uid`, `name`) VALUES (1, ?)"},
+		{"replace into tenant_acme.student(uid,name) values(1,'foo')", nil, "fake_tenant_db", "REPLACE INTO `tenant_acme`.`student`(`uid`, `name`) VALUES (1, 'foo')"},
+		{"update tenant_acme.student set name = ? where uid = 1", []proto.Value{proto.NewValueString("foo")}, "fake_tenant_db", "UPDATE `tenant_acme`.`student` SET `name` = ? WHERE `uid` = 1"},
+		{"delete from tenant_acme.student where uid = 1", nil, "fake_tenant_db", "DELETE FROM `tenant_acme`.`student` WHERE `uid` = 1"},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			qr.reset()
+
+			p := optimizeQuery(t, ctx, ru, it.sql, it.args)
+			_, err := p.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{it.expectDB}, qr.dbs())
+			assert.Equal(t, []string{it.expectSQL}, qr.sqls())
+		})
+	}
+}
+func TestOptimizer_OptimizeVariableAssignment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl,
+		mysql.NewField("rn", consts.FieldTypeLongLong),
+		mysql.NewField("name", consts.FieldTypeVarString),
+	)
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
+	vt.SetAllowFullScan(true)
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+		err    string
+	}{
+		{
+			"select @rownum := @rownum + 1 as rn, name from student where uid = 1",
+			[]string{"SELECT @`rownum` := @`rownum`+1 AS `rn`,`name` FROM `student_0001` WHERE `uid` = 1"},
+			"",
+		},
+		{
+			"select @rownum := @rownum + 1 as rn, name from student where uid in (1,2)",
+			nil,
+			"user variable assignment across 2 shards of table 'student' is not supported",
+		},
+		{
+			"select concat(@prev := name, '!') as rn, name from student",
+			nil,
+			"user variable assignment across 8 shards of table 'student' is not supported",
+		},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			qr.reset()
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(ru, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			if len(it.err) > 0 {
+				assert.ErrorContains(t, err, it.err)
+				return
+			}
+			assert.NoError(t, err)
+			_, err = plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			assert.Equal(t, it.expect, qr.sqls())
+		})
+	}
+}
+func TestOptimizer_OptimizeUnionTables(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+	vt, _ := ru.VTable("student")
+
+	for _, it := range []struct {
+		unionTables int
+		expect      []string
+	}{
+		// all tables of same database are queried in one round-trip by default
+		{0, []string{
+			"(SELECT `uid` FROM `student_0001` WHERE `uid` IN (1)) UNION ALL (SELECT `uid` FROM `student_0002` WHERE `uid` IN (2)) UNION ALL (SELECT `uid` FROM `student_0003` WHERE `uid` IN (3))",
+		}},
+		{2, []string{
+			"(SELECT `uid` FROM `student_0001` WHERE `uid` IN (1)) UNION ALL (SELECT `uid` FROM `student_0002` WHERE `uid` IN (2))",
+			"SELECT `uid` FROM `student_0003` WHERE `uid` IN (3)",
+		}},
+	} {
+		t.Run(fmt.Sprint(it.unionTables), func(t *testing.T) {
+			qr.reset()
+			vt.SetUnionTables(it.unionTables)
+
+			// the sub-queries are issued lazily while reading
+			execQuery(t, ctx, ru, conn, "select uid from student where uid in (1,2,3)", nil)
+			sqls := qr.sqls()
+			sort.Strings(sqls)
+			sort.Strings(it.expect)
+			assert.Equal(t, it.expect, sqls)
+		})
+	}
+}
+func TestOptimizer_OptimizeKill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const (
+		tenant = "kill_tenant"
+		schema = "kill_schema"
+	)
+
+	newDB := func(id string) proto.DB {
+		db := testdata.NewMockDB(ctrl)
+		db.EXPECT().ID().Return(id).AnyTimes()
+		db.EXPECT().Weight().Return(proto.Weight{R: 10, W: 10}).AnyTimes()
+		db.EXPECT().Close().AnyTimes()
+		return db
+	}
+	ns, err := namespace.New(schema,
+		namespace.UpsertDB("employees_0000", newDB("employees_0000")),
+		namespace.UpsertDB("employees_0001", newDB("employees_0001")),
+	)
+	assert.NoError(t, err)
+	_ = namespace.Register(tenant, ns)
+	defer func() {
+		_ = namespace.Unregister(tenant, schema)
+	}()
+
+	type exec struct {
+		db, sql string
+	}
+	var execs []exec
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			execs = append(execs, exec{db, sql})
+			return resultx.New(), nil
+		}).
+		AnyTimes()
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyTenant{}, tenant)
+	ctx = context.WithValue(ctx, proto.ContextKeySchema{}, schema)
+
+	for _, it := range []struct {
+		sql    string
+		expect []exec
+		err    string
+	}{
+		// 42<<16 + 1: the thread 42 of group employees_0001
+		{"kill 2752513", []exec{{"employees_0001", "KILL 42"}}, ""},
+		{"kill query 2752512", []exec{{"employees_0000", "KILL QUERY 42"}}, ""},
+		{"kill 5", nil, "Unknown thread id: 5"},
+		{"kill 2752519", nil, "Unknown thread id: 2752519"},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			execs = execs[:0]
+
+			stmt, _ := parser.New().ParseOneStmt(it.sql, "", "")
+			opt, err := NewOptimizer(nil, nil, stmt, nil)
+			assert.NoError(t, err)
+			plan, err := opt.Optimize(ctx)
+			if len(it.err) > 0 {
+				assert.ErrorContains(t, err, it.err)
+				return
+			}
+			assert.NoError(t, err)
+			_, err = plan.ExecIn(ctx, conn)
+			assert.NoError(t, err)
+			assert.Equal(t, it.expect, execs)
+		})
+	}
+}
+
+func TestOptimizer_OptimizeBoundParamArithmetic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, qr := recordQueries(t, ctrl, mysql.NewField("uid", consts.FieldTypeLongLong))
+
+	ctx := context.WithValue(context.Background(), proto.ContextKeyEnableLocalComputation{}, true)
+	ru := makeFakeRule(ctrl, "student", 8, nil)
+
+	for _, it := range []struct {
+		sql    string
+		expect []string
+	}{
+		{"select uid from student where uid = ? + 1", []string{"student_0004"}},
+		{"select uid from student where uid = 2 * ?", []string{"student_0006"}},
+		{"select uid from student where uid = (? + 1) * 2", []string{"student_0000"}},
+		{"select uid from student where uid = ? + 10 % 8", []string{"student_0005"}},
+		{"select uid from student where uid = ? div 2", []string{"student_0001"}},
+		{"select uid from student where uid in (? - 1, ? * 3)", []string{"student_0001", "student_0002"}},
+		{"select uid from student where uid = ? % 2", []string{"student_0001"}},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			qr.reset()
+
+			// each placeholder is bound to 3
+			args := []proto.Value{proto.NewValueInt64(3), proto.NewValueInt64(3)}[:strings.Count(it.sql, "?")]
+			execQuery(t, ctx, ru, conn, it.sql, args)
+			tables := qr.tables()
+			sort.Strings(tables)
+			assert.Equal(t, it.expect, tables)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package optimize
+
+import (
+	"fmt"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto/rule"
+	rast "github.com/arana-db/arana/pkg/runtime/ast"
+)
+
+// checkPolicies returns ErrStatementBlocked if the statement matches any of the statement policies.
+func checkPolicies(ru *rule.Rule, stmt rast.Statement) error {
+	for _, p := range ru.Policies() {
+		if !matchPolicy(p, stmt) {
+			continue
+		}
+		if len(p.Message) > 0 {
+			return perrors.Wrap(ErrStatementBlocked, p.Message)
+		}
+		return perrors.Wrapf(ErrStatementBlocked, "rejected by policy '%s'", p.Name)
+	}
+	return nil
+}
+
+func matchPolicy(p *rule.StatementPolicy, stmt rast.Statement) bool {
+	if len(p.Types) > 0 && !containsFold(p.Types, stmt.Mode().String()) {
+		return false
+	}
+
+	if len(p.Tables) > 0 {
+		var matched bool
+		for _, table := range collectTables(stmt) {
+			if containsFold(p.Tables, table) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	where, limit, ok := whereAndLimit(stmt)
+	if p.WithoutWhere && (!ok || where != nil) {
+		return false
+	}
+	if p.WithoutLimit && (!ok || limit != nil) {
+		return false
+	}
+
+	if p.CartesianJoin {
+		sel, ok := stmt.(*rast.SelectStatement)
+		if !ok || !isCartesianJoin(sel) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// whereAndLimit returns the WHERE and LIMIT clause of statement, ok will be false if the statement has neither.
+func whereAndLimit(stmt rast.Statement) (where rast.ExpressionNode, limit *rast.LimitNode, ok bool) {
+	switch it := stmt.(type) {
+	case *rast.SelectStatement:
+		return it.Where, it.Limit, true
+	case *rast.DeleteStatement:
+		return it.Where, it.Limit, true
+	case *rast.UpdateStatement:
+		return it.Where, it.Limit, true
+	}
+	return
+}
+
+// isCartesianJoin returns true if any of the joined tables is not connected to others by an equality of their columns, eg:
+//   - SELECT * FROM a, b
+//   - SELECT * FROM a JOIN b ON 1 = 1
+//   - SELECT * FROM a JOIN b ON a.x > b.y
+func isCartesianJoin(sel *rast.SelectStatement) bool {
+	var (
+		tables     []string
+		conditions []rast.ExpressionNode
+	)
+	for i, from := range sel.From {
+		tables = append(tables, tableQualifier(&from.TableSourceItem, i))
+		for j, join := range from.Joins {
+			tables = append(tables, tableQualifier(join.Target, i, j))
+			collectConjunctions(join.On, &conditions)
+		}
+	}
+	if len(tables) < 2 {
+		return false
+	}
+	collectConjunctions(sel.Where, &conditions)
+
+	parents := make(map[string]string, len(tables))
+	for _, it := range tables {
+		parents[it] = it
+	}
+	var find func(string) string
+	find = func(table string) string {
+		if parents[table] == table {
+			return table
+		}
+		parents[table] = find(parents[table])
+		return parents[table]
+	}
+
+	groups := len(tables)
+	for _, it := range conditions {
+		l, r, ok := getColumnEquality(it)
+		if !ok {
+			continue
+		}
+		// the table of unqualified column is unknown, treat it as a join condition
+		if len(l.Prefix()) == 0 || len(r.Prefix()) == 0 {
+			if !strings.EqualFold(l.Suffix(), r.Suffix()) {
+				return false
+			}
+			continue
+		}
+		lt, lok := parents[strings.ToLower(l.Prefix())]
+		rt, rok := parents[strings.ToLower(r.Prefix())]
+		if !lok || !rok {
+			continue
+		}
+		if lt, rt = find(lt), find(rt); lt != rt {
+			parents[lt] = rt
+			groups--
+		}
+	}
+	return groups > 1
+}
+
+// tableQualifier returns the lower-cased name which qualifies the columns of table source, the derived table
+// without alias is named by its position.
+func tableQualifier(item *rast.TableSourceItem, pos ...int) string {
+	if len(item.Alias) > 0 {
+		return strings.ToLower(item.Alias)
+	}
+	if tn, ok := item.Source.(rast.TableName); ok {
+		return strings.ToLower(tn.Suffix())
+	}
+	return fmt.Sprint(pos)
+}
+
+// collectTables returns the names of tables which are accessed by the statement.
+func collectTables(stmt rast.Statement) []string {
+	var tables []string
+
+	var visitSelect func(sel *rast.SelectStatement)
+	visitSource := func(item *rast.TableSourceItem) {
+		switch source := item.Source.(type) {
+		case rast.TableName:
+			tables = append(tables, source.Suffix())
+		case *rast.SelectStatement:
+			visitSelect(source)
+		case *rast.UnionSelectStatement:
+			visitSelect(source.First)
+			for _, it := range source.UnionStatementItems {
+				visitSelect(it.Stmt)
+			}
+		}
+	}
+	visitSelect = func(sel *rast.SelectStatement) {
+		if sel == nil {
+			return
+		}
+		for _, from := range sel.From {
+			visitSource(&from.TableSourceItem)
+			for _, join := range from.Joins {
+				visitSource(join.Target)
+			}
+		}
+	}
+
+	switch it := stmt.(type) {
+	case *rast.SelectStatement:
+		visitSelect(it)
+	case *rast.UnionSelectStatement:
+		visitSelect(it.First)
+		for _, next := range it.UnionStatementItems {
+			visitSelect(next.Stmt)
+		}
+	case *rast.DeleteStatement:
+		tables = append(tables, it.Table.Suffix())
+	case *rast.UpdateStatement:
+		tables = append(tables, it.Table.Suffix())
+	case *rast.InsertStatement:
+		tables = append(tables, it.Table.Suffix())
+	case *rast.InsertSelectStatement:
+		tables = append(tables, it.Table.Suffix())
+		visitSelect(it.Select())
+	case *rast.ReplaceStatement:
+		tables = append(tables, it.Table.Suffix())
+	}
+
+	return tables
+}
+
+func containsFold(values []string, s string) bool {
+	for _, it := range values {
+		if strings.EqualFold(it, s) {
+			return true
+		}
+	}
+	return false
+}
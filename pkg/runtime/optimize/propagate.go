@@ -0,0 +1,265 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package optimize
+
+import (
+	"strings"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/cmp"
+)
+
+// PropagateConstants propagates the constants of where clause through the chained column equalities, eg:
+//
+//	FROM a JOIN b ON a.uid = b.uid JOIN c ON b.uid = c.uid WHERE c.uid = 7
+//	-> WHERE c.uid = 7 AND a.uid = 7 AND b.uid = 7
+//
+// Then each joined table has its own constant binding, which can be used to compute shards.
+// Only the conjunctive equalities are considered, and the constants are only bound from where clause,
+// the column equalities of where clause which are implied by the constant bindings will be removed.
+func PropagateConstants(where ast.ExpressionNode, on ...ast.ExpressionNode) ast.ExpressionNode {
+	if where == nil {
+		return nil
+	}
+
+	var (
+		conditions []ast.ExpressionNode
+		joins      []ast.ExpressionNode
+	)
+	collectConjunctions(where, &conditions)
+	for i := range on {
+		collectConjunctions(on[i], &joins)
+	}
+
+	var (
+		ec       = &equivalenceClasses{parents: make(map[string]string)}
+		bindings = make(map[string]ast.PredicateNode) // root column -> constant
+		bound    = make(map[string]struct{})          // columns bound in where clause
+	)
+
+	for _, it := range append(joins, conditions...) {
+		if l, r, ok := getColumnEquality(it); ok {
+			ec.union(l, r)
+		}
+	}
+
+	for _, it := range conditions {
+		if c, v, ok := getConstantEquality(it); ok {
+			key := columnKey(c)
+			bound[key] = struct{}{}
+			if _, exist := bindings[ec.find(key)]; !exist {
+				bindings[ec.find(key)] = v
+			}
+		}
+	}
+
+	if len(bindings) < 1 {
+		return where
+	}
+
+	var ret ast.ExpressionNode
+	appendCondition := func(next ast.ExpressionNode) {
+		ret = AndPredicates(ret, next)
+	}
+
+	for _, it := range conditions {
+		if l, _, ok := getColumnEquality(it); ok {
+			// remove: a.uid = b.uid, it is implied by a.uid = 7 AND b.uid = 7
+			if _, exist := bindings[ec.find(columnKey(l))]; exist {
+				continue
+			}
+		}
+		appendCondition(it)
+	}
+
+	for _, column := range ec.columns {
+		if _, exist := bound[columnKey(column)]; exist {
+			continue
+		}
+		v, exist := bindings[ec.find(columnKey(column))]
+		if !exist {
+			continue
+		}
+		appendCondition(&ast.PredicateExpressionNode{
+			P: &ast.BinaryComparisonPredicateNode{
+				Left:  &ast.AtomPredicateNode{A: column},
+				Right: v,
+				Op:    cmp.Ceq,
+			},
+		})
+	}
+
+	return ret
+}
+
+// TableBindings returns the conjunctive constant bindings of the table, which can be used to compute its shards.
+// The names are the table name and its alias, eg: 'a.uid = 7 AND b.uid = 7' -> 'a.uid = 7' for table 'student a'.
+func TableBindings(where ast.ExpressionNode, names ...string) ast.ExpressionNode {
+	var conditions []ast.ExpressionNode
+	collectConjunctions(where, &conditions)
+
+	var ret ast.ExpressionNode
+	for _, it := range conditions {
+		c, _, ok := getConstantEquality(it)
+		if !ok {
+			continue
+		}
+		for _, name := range names {
+			if len(name) > 0 && strings.EqualFold(c.Prefix(), name) {
+				ret = AndPredicates(ret, it)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// AndPredicates combines the predicates with AND, the nil one is ignored. The OR expression is nested to
+// keep the precedence, eg: a = 1 OR b = 2 -> (a = 1 OR b = 2) AND tenant_id = 'foo'.
+func AndPredicates(left, right ast.ExpressionNode) ast.ExpressionNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	return &ast.LogicalExpressionNode{
+		Left:  nestDisjunction(left),
+		Right: nestDisjunction(right),
+	}
+}
+
+func nestDisjunction(expr ast.ExpressionNode) ast.ExpressionNode {
+	if logical, ok := expr.(*ast.LogicalExpressionNode); ok && logical.Or {
+		return &ast.PredicateExpressionNode{
+			P: &ast.AtomPredicateNode{A: &ast.NestedExpressionAtom{First: expr}},
+		}
+	}
+	return expr
+}
+
+func collectConjunctions(expr ast.ExpressionNode, dest *[]ast.ExpressionNode) {
+	if expr == nil {
+		return
+	}
+	if node, ok := expr.(*ast.LogicalExpressionNode); ok && !node.Or {
+		collectConjunctions(node.Left, dest)
+		collectConjunctions(node.Right, dest)
+		return
+	}
+	*dest = append(*dest, expr)
+}
+
+func getEquality(expr ast.ExpressionNode) (*ast.BinaryComparisonPredicateNode, bool) {
+	pe, ok := expr.(*ast.PredicateExpressionNode)
+	if !ok {
+		return nil, false
+	}
+	bc, ok := pe.P.(*ast.BinaryComparisonPredicateNode)
+	if !ok || bc.Op != cmp.Ceq {
+		return nil, false
+	}
+	return bc, true
+}
+
+// getColumnEquality extracts the columns of equality, eg: a.uid = b.uid
+func getColumnEquality(expr ast.ExpressionNode) (ast.ColumnNameExpressionAtom, ast.ColumnNameExpressionAtom, bool) {
+	bc, ok := getEquality(expr)
+	if !ok {
+		return nil, nil, false
+	}
+	l, lok := getColumn(bc.Left)
+	r, rok := getColumn(bc.Right)
+	if !lok || !rok {
+		return nil, nil, false
+	}
+	return l, r, true
+}
+
+// getConstantEquality extracts the column and constant of equality, eg: a.uid = 7, a.uid = ?
+func getConstantEquality(expr ast.ExpressionNode) (ast.ColumnNameExpressionAtom, ast.PredicateNode, bool) {
+	bc, ok := getEquality(expr)
+	if !ok {
+		return nil, nil, false
+	}
+	if c, ok := getColumn(bc.Left); ok && isConstant(bc.Right) {
+		return c, bc.Right, true
+	}
+	if c, ok := getColumn(bc.Right); ok && isConstant(bc.Left) {
+		return c, bc.Left, true
+	}
+	return nil, nil, false
+}
+
+func getColumn(p ast.PredicateNode) (ast.ColumnNameExpressionAtom, bool) {
+	atom, ok := p.(*ast.AtomPredicateNode)
+	if !ok {
+		return nil, false
+	}
+	return atom.Column()
+}
+
+func isConstant(p ast.PredicateNode) bool {
+	atom, ok := p.(*ast.AtomPredicateNode)
+	if !ok {
+		return false
+	}
+	switch atom.A.(type) {
+	case *ast.ConstantExpressionAtom, ast.VariableExpressionAtom:
+		return true
+	}
+	return false
+}
+
+func columnKey(c ast.ColumnNameExpressionAtom) string {
+	return strings.ToLower(strings.Join(c, "."))
+}
+
+// equivalenceClasses is a disjoint set of columns, the columns in a same set are equal.
+type equivalenceClasses struct {
+	parents map[string]string
+	columns []ast.ColumnNameExpressionAtom // in order of appearance
+}
+
+func (ec *equivalenceClasses) add(c ast.ColumnNameExpressionAtom) string {
+	key := columnKey(c)
+	if _, ok := ec.parents[key]; !ok {
+		ec.parents[key] = key
+		ec.columns = append(ec.columns, c)
+	}
+	return key
+}
+
+func (ec *equivalenceClasses) find(key string) string {
+	parent, ok := ec.parents[key]
+	if !ok || parent == key {
+		return key
+	}
+	root := ec.find(parent)
+	ec.parents[key] = root
+	return root
+}
+
+func (ec *equivalenceClasses) union(a, b ast.ColumnNameExpressionAtom) {
+	ra, rb := ec.find(ec.add(a)), ec.find(ec.add(b))
+	if ra != rb {
+		ec.parents[rb] = ra
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package optimize
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto/rule"
+	rast "github.com/arana-db/arana/pkg/runtime/ast"
+)
+
+// checkReadOnly returns ErrReadOnlyTable if the statement writes any read-only table, the reads are always allowed.
+func checkReadOnly(ru *rule.Rule, stmt rast.Statement) error {
+	for _, table := range writtenTables(stmt) {
+		if vt, ok := ru.VTable(table.Suffix()); ok && vt.ReadOnly() {
+			return perrors.Wrapf(ErrReadOnlyTable, "table '%s' is read-only", table.Suffix())
+		}
+	}
+	return nil
+}
+
+// writtenTables returns the tables which will be modified by the statement, eg: the target of INSERT ... SELECT.
+func writtenTables(stmt rast.Statement) []rast.TableName {
+	switch it := stmt.(type) {
+	case *rast.InsertStatement:
+		return []rast.TableName{it.Table}
+	case *rast.InsertSelectStatement:
+		return []rast.TableName{it.Table}
+	case *rast.ReplaceStatement:
+		return []rast.TableName{it.Table}
+	case *rast.UpdateStatement:
+		return []rast.TableName{it.Table}
+	case *rast.DeleteStatement:
+		return []rast.TableName{it.Table}
+	case *rast.TruncateStatement:
+		return []rast.TableName{it.Table}
+	case *rast.AlterTableStatement:
+		return []rast.TableName{it.Table}
+	case *rast.CreateIndexStatement:
+		return []rast.TableName{it.Table}
+	case *rast.DropIndexStatement:
+		return []rast.TableName{it.Table}
+	case *rast.DropTriggerStatement:
+		return []rast.TableName{it.Table}
+	case *rast.DropTableStatement:
+		return derefTables(it.Tables)
+	case *rast.OptimizeTableStatement:
+		return derefTables(it.Tables)
+	case *rast.RepairTableStmt:
+		return derefTables(it.Tables)
+	case *rast.CreateTableStmt:
+		return derefTables([]*rast.TableName{it.Table})
+	case *rast.RenameTableStatement:
+		var tables []rast.TableName
+		for _, it := range it.TableToTables {
+			tables = append(tables, *it.OldTable)
+		}
+		return tables
+	}
+	return nil
+}
+
+func derefTables(tables []*rast.TableName) []rast.TableName {
+	ret := make([]rast.TableName, 0, len(tables))
+	for _, it := range tables {
+		if it != nil {
+			ret = append(ret, *it)
+		}
+	}
+	return ret
+}
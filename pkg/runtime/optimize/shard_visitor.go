@@ -35,6 +35,7 @@ import (
 	"github.com/arana-db/arana/pkg/runtime/calc"
 	"github.com/arana-db/arana/pkg/runtime/calc/logic"
 	"github.com/arana-db/arana/pkg/runtime/cmp"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/misc"
 	"github.com/arana-db/arana/pkg/runtime/misc/extvalue"
 )
@@ -47,13 +48,14 @@ type ShardVisitor struct {
 	ctx context.Context
 	ast.BaseVisitor
 	ru      *rule.Rule
+	vtab    *rule.VTable
 	args    []proto.Value
 	results []misc.Pair[ast.TableName, *rule.Shards]
 }
 
 func NewXSharder(ctx context.Context, ru *rule.Rule, args []proto.Value) *ShardVisitor {
 	return &ShardVisitor{
-		ctx:  ctx,
+		ctx:  extvalue.WithTimeFunctions(ctx),
 		ru:   ru,
 		args: args,
 	}
@@ -114,19 +116,20 @@ func (sd *ShardVisitor) ForSingleSelect(table ast.TableName, alias string, where
 		return nil
 	}
 
+	// the sharding strategy can be chosen by session, eg: SET arana_shard_strategy = 'by_time'
+	vtab = vtab.WithShardStrategy(rcontext.ShardStrategy(sd.ctx))
+
+	sd.vtab = vtab
 	l, err := where.Accept(sd)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	// 2. eval shards
+	// 2. eval shards, the conditions without sharding keys cannot be pruned, eg: "uid = 1 OR name = 'foo'",
+	// which should be degraded to full scan instead of only the shards of key branch.
 	shards, err := calc.Eval(vtab, l.(logic.Logic[*calc.Calculus]))
-	if err != nil {
-		return errors.Wrap(err, "compute shard evaluator failed")
-	}
-	// 3. eval
 	if err != nil && !errors.Is(err, calc.ErrNoShardMatched) {
-		return errors.Wrap(err, "eval shards failed")
+		return errors.Wrap(err, "compute shard evaluator failed")
 	}
 
 	sd.results = append(sd.results, misc.Pair[ast.TableName, *rule.Shards]{
@@ -194,7 +197,10 @@ func (sd *ShardVisitor) VisitPredicateAtom(node *ast.AtomPredicateNode) (interfa
 }
 
 func (sd *ShardVisitor) VisitPredicateBetween(node *ast.BetweenPredicateNode) (interface{}, error) {
-	key := node.Key.(*ast.AtomPredicateNode).A.(ast.ColumnNameExpressionAtom)
+	key, ok := columnOf(node.Key)
+	if !ok {
+		return alwaysTrue(), nil
+	}
 
 	l, err := extvalue.Compute(sd.ctx, node.Left, sd.args...)
 	if err != nil {
@@ -208,33 +214,113 @@ func (sd *ShardVisitor) VisitPredicateBetween(node *ast.BetweenPredicateNode) (i
 
 	if node.Not {
 		// convert: f NOT BETWEEN a AND b -> f < a OR f > b
-		k1, err := newCmp(key.Suffix(), cmp.Clt, l)
+		k1, err := sd.newCmp(key.Suffix(), cmp.Clt, l)
 		if err != nil {
 			return nil, err
 		}
-		k2, err := newCmp(key.Suffix(), cmp.Cgt, r)
+		k2, err := sd.newCmp(key.Suffix(), cmp.Cgt, r)
 		if err != nil {
 			return nil, err
 		}
-		return logic.OR(
-			calc.Wrap(k1),
-			calc.Wrap(k2),
-		), nil
+		return logic.OR(k1, k2), nil
 	}
 
 	// convert: f BETWEEN a AND b -> f >= a AND f <= b
-	k1, err := newCmp(key.Suffix(), cmp.Cgte, l)
+	k1, err := sd.newCmp(key.Suffix(), cmp.Cgte, l)
 	if err != nil {
 		return nil, err
 	}
-	k2, err := newCmp(key.Suffix(), cmp.Clte, r)
+	k2, err := sd.newCmp(key.Suffix(), cmp.Clte, r)
 	if err != nil {
 		return nil, err
 	}
-	return logic.AND(
-		calc.Wrap(k1),
-		calc.Wrap(k2),
-	), nil
+	return logic.AND(k1, k2), nil
+}
+
+// newCmp creates the calculus of comparative, the value will be coerced to the declared type if the key is a shard column.
+// The fractional value compared with a numeric shard column is rounded to the nearest integer inside the range,
+// eg: uid < 10.5 -> uid < 11, uid >= 10.5 -> uid >= 11, and the equality never matches, eg: uid = 1.5.
+func (sd *ShardVisitor) newCmp(key string, comparison cmp.Comparison, v proto.Value) (Calculus, error) {
+	if sd.vtab != nil {
+		key = sd.vtab.NormalizeColumn(key)
+		if sc := sd.vtab.GetShardColumn(key); sc != nil {
+			if d, ok := fractionOf(v); ok && sc.Stepper.U == rule.Unum {
+				switch comparison {
+				case cmp.Clt, cmp.Cgte:
+					v = proto.NewValueInt64(d.Ceil().IntPart())
+				case cmp.Clte, cmp.Cgt:
+					v = proto.NewValueInt64(d.Floor().IntPart())
+				case cmp.Cne:
+					return alwaysTrue(), nil
+				default:
+					return alwaysFalse(), nil
+				}
+			}
+			var err error
+			if v, err = sc.Coerce(v); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+	c, err := newCmp(key, comparison, v)
+	if err != nil {
+		return nil, err
+	}
+	return calc.Wrap(c), nil
+}
+
+// fractionOf returns the decimal if the value is a number with fractional part, eg: 1.5 or '1.5'.
+func fractionOf(v proto.Value) (decimal.Decimal, bool) {
+	var (
+		d   decimal.Decimal
+		err error
+	)
+	switch v.Family() {
+	case proto.ValueFamilyFloat, proto.ValueFamilyDecimal:
+		d, err = v.Decimal()
+	case proto.ValueFamilyString:
+		d, err = decimal.NewFromString(strings.TrimSpace(v.String()))
+	default:
+		return d, false
+	}
+	return d, err == nil && !d.Equal(d.Truncate(0))
+}
+
+// compare creates the calculus of comparison, the equality of base column will be applied to the generated columns
+// derived from it too, eg: created_at = '2023-05-10' -> created_at = '2023-05-10' AND month = 5
+func (sd *ShardVisitor) compare(key string, comparison cmp.Comparison, v proto.Value) (Calculus, error) {
+	if sd.vtab != nil {
+		key = sd.vtab.NormalizeColumn(key)
+		// translate the lookup column into sharding key, eg: customer_code = 'ABC' -> customer_id = 42,
+		// the unresolved mapping falls back to full scan since the lookup column is not a sharding key.
+		if kl := sd.vtab.KeyLookup(); kl != nil && comparison == cmp.Ceq && key == kl.Column() {
+			if kv, ok := kl.Lookup(sd.ctx, v); ok {
+				return sd.compare(kl.Key(), cmp.Ceq, kv)
+			}
+		}
+	}
+	ret, err := sd.newCmp(key, comparison, v)
+	if err != nil {
+		return nil, err
+	}
+	if comparison != cmp.Ceq || sd.vtab == nil {
+		return ret, nil
+	}
+	for _, gc := range sd.vtab.GetGeneratedColumns(key) {
+		gv, err := gc.Generate(v)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if gv == nil {
+			continue
+		}
+		next, err := sd.newCmp(gc.Name, cmp.Ceq, gv)
+		if err != nil {
+			return nil, err
+		}
+		ret = logic.AND(ret, next)
+	}
+	return ret, nil
 }
 
 func newCmp(key string, comparison cmp.Comparison, v proto.Value) (*cmp.Comparative, error) {
@@ -270,8 +356,7 @@ func newCmp(key string, comparison cmp.Comparison, v proto.Value) (*cmp.Comparat
 }
 
 func (sd *ShardVisitor) VisitPredicateBinaryComparison(node *ast.BinaryComparisonPredicateNode) (interface{}, error) {
-	switch k := node.Left.(*ast.AtomPredicateNode).A.(type) {
-	case ast.ColumnNameExpressionAtom:
+	if k, ok := columnOf(node.Left); ok {
 		v, err := extvalue.Compute(sd.ctx, node.Right, sd.args...)
 		if err != nil {
 			if extvalue.IsErrNotSupportedValue(err) {
@@ -279,15 +364,10 @@ func (sd *ShardVisitor) VisitPredicateBinaryComparison(node *ast.BinaryCompariso
 			}
 			return nil, errors.WithStack(err)
 		}
-		c, err := newCmp(k.Suffix(), node.Op, v)
-		if err != nil {
-			return nil, err
-		}
-		return calc.Wrap(c), nil
+		return sd.compare(k.Suffix(), node.Op, v)
 	}
 
-	switch k := node.Right.(*ast.AtomPredicateNode).A.(type) {
-	case ast.ColumnNameExpressionAtom:
+	if k, ok := columnOf(node.Right); ok {
 		v, err := extvalue.Compute(sd.ctx, node.Left, sd.args...)
 		if err != nil {
 			if extvalue.IsErrNotSupportedValue(err) {
@@ -295,11 +375,7 @@ func (sd *ShardVisitor) VisitPredicateBinaryComparison(node *ast.BinaryCompariso
 			}
 			return nil, errors.WithStack(err)
 		}
-		c, err := newCmp(k.Suffix(), node.Op, v)
-		if err != nil {
-			return nil, err
-		}
-		return calc.Wrap(c), nil
+		return sd.compare(k.Suffix(), node.Op, v)
 	}
 
 	l, _ := extvalue.Compute(sd.ctx, node.Left, sd.args...)
@@ -362,7 +438,21 @@ func (sd *ShardVisitor) VisitPredicateBinaryComparison(node *ast.BinaryCompariso
 }
 
 func (sd *ShardVisitor) VisitPredicateIn(node *ast.InPredicateNode) (interface{}, error) {
-	key := node.P.(*ast.AtomPredicateNode).A.(ast.ColumnNameExpressionAtom)
+	if atom, ok := node.P.(*ast.AtomPredicateNode); ok {
+		if row, ok := atom.A.(*ast.RowExpressionAtom); ok {
+			return sd.visitTupleIn(node, row)
+		}
+	}
+
+	// the values of subquery cannot be computed
+	if node.Sub != nil {
+		return alwaysTrue(), nil
+	}
+
+	key, ok := columnOf(node.P)
+	if !ok {
+		return alwaysTrue(), nil
+	}
 
 	var ret Calculus
 	for i := range node.E {
@@ -374,34 +464,135 @@ func (sd *ShardVisitor) VisitPredicateIn(node *ast.InPredicateNode) (interface{}
 			return nil, errors.WithStack(err)
 		}
 
+		// NULL never matches any value, eg: 'f IN (NULL)' and 'f NOT IN (a,NULL)' are both false
+		if actualValue == nil {
+			if node.Not {
+				return alwaysFalse(), nil
+			}
+			continue
+		}
+
 		if node.Not {
-			ke, err := newCmp(key.Suffix(), cmp.Cne, actualValue)
+			ke, err := sd.newCmp(key.Suffix(), cmp.Cne, actualValue)
 			if err != nil {
 				return nil, err
 			}
 			// convert: f NOT IN (a,b,c) -> f <> a AND f <> b AND f <> c
 			if ret == nil {
-				ret = calc.Wrap(ke)
+				ret = ke
 			} else {
-				ret = logic.AND(ret, calc.Wrap(ke))
+				ret = logic.AND(ret, ke)
 			}
 		} else {
 			// convert: f IN (a,b,c) -> f = a OR f = b OR f = c
-			ke, err := newCmp(key.Suffix(), cmp.Ceq, actualValue)
+			ke, err := sd.compare(key.Suffix(), cmp.Ceq, actualValue)
 			if err != nil {
 				return nil, err
 			}
 			if ret == nil {
-				ret = calc.Wrap(ke)
+				ret = ke
 			} else {
-				ret = logic.OR(ret, calc.Wrap(ke))
+				ret = logic.OR(ret, ke)
 			}
 		}
 	}
 
+	if ret == nil {
+		return alwaysFalse(), nil
+	}
+
 	return ret, nil
 }
 
+func (sd *ShardVisitor) visitTupleIn(node *ast.InPredicateNode, row *ast.RowExpressionAtom) (interface{}, error) {
+	var ret Calculus
+	for i := range node.E {
+		next, err := sd.computeTuple(row, node.E[i])
+		if err != nil {
+			if extvalue.IsErrNotSupportedValue(err) {
+				return alwaysTrue(), nil
+			}
+			return nil, errors.WithStack(err)
+		}
+		// convert: (a,b) IN ((1,2),(3,4)) -> (a = 1 AND b = 2) OR (a = 3 AND b = 4)
+		if ret == nil {
+			ret = next
+		} else {
+			ret = logic.OR(ret, next)
+		}
+	}
+
+	if node.Not {
+		// convert: (a,b) NOT IN ((1,2),(3,4)) -> NOT ((a = 1 AND b = 2) OR (a = 3 AND b = 4))
+		return logic.NOT(ret), nil
+	}
+	return ret, nil
+}
+
+func (sd *ShardVisitor) computeTuple(row *ast.RowExpressionAtom, tuple ast.ExpressionNode) (Calculus, error) {
+	values, err := computeTupleValues(sd.ctx, tuple, sd.args)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(values) != len(row.Values) {
+		return nil, errors.Errorf("operand should contain %d column(s)", len(row.Values))
+	}
+
+	var ret Calculus
+	for i := range row.Values {
+		var next Calculus
+		if key, ok := getRowColumn(row.Values[i]); ok && values[i] != nil {
+			if next, err = sd.compare(key.Suffix(), cmp.Ceq, values[i]); err != nil {
+				return nil, err
+			}
+		} else {
+			next = alwaysTrue()
+		}
+
+		if ret == nil {
+			ret = next
+		} else {
+			ret = logic.AND(ret, next)
+		}
+	}
+	return ret, nil
+}
+
+// computeTupleValues computes the values of a tuple, eg: (1,'foo',?)
+func computeTupleValues(ctx context.Context, tuple ast.ExpressionNode, args []proto.Value) ([]proto.Value, error) {
+	var row *ast.RowExpressionAtom
+	if pe, ok := tuple.(*ast.PredicateExpressionNode); ok {
+		if atom, ok := pe.P.(*ast.AtomPredicateNode); ok {
+			row, _ = atom.A.(*ast.RowExpressionAtom)
+		}
+	}
+	if row == nil {
+		return nil, errors.New("operand should be a tuple")
+	}
+
+	values := make([]proto.Value, 0, len(row.Values))
+	for i := range row.Values {
+		v, err := extvalue.Compute(ctx, row.Values[i], args...)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func getRowColumn(value ast.ExpressionNode) (ast.ColumnNameExpressionAtom, bool) {
+	pe, ok := value.(*ast.PredicateExpressionNode)
+	if !ok {
+		return nil, false
+	}
+	atom, ok := pe.P.(*ast.AtomPredicateNode)
+	if !ok {
+		return nil, false
+	}
+	return atom.Column()
+}
+
 func (sd *ShardVisitor) VisitPredicateLike(node *ast.LikePredicateNode) (interface{}, error) {
 	key := node.Left.(*ast.AtomPredicateNode).A.(ast.ColumnNameExpressionAtom)
 
@@ -418,12 +609,7 @@ func (sd *ShardVisitor) VisitPredicateLike(node *ast.LikePredicateNode) (interfa
 	}
 
 	if !strings.ContainsAny(like.String(), "%_") {
-		c, err := newCmp(key.Suffix(), cmp.Ceq, like)
-		if err != nil {
-			return nil, err
-		}
-
-		return calc.Wrap(c), nil
+		return sd.compare(key.Suffix(), cmp.Ceq, like)
 	}
 
 	return alwaysTrue(), nil
@@ -464,7 +650,19 @@ func (sd *ShardVisitor) VisitAtomNested(node *ast.NestedExpressionAtom) (interfa
 	return node.First.Accept(sd)
 }
 
+func (sd *ShardVisitor) VisitAtomRow(_ *ast.RowExpressionAtom) (interface{}, error) {
+	return alwaysTrue(), nil
+}
+
 func (sd *ShardVisitor) VisitAtomUnary(node *ast.UnaryExpressionAtom) (interface{}, error) {
+	// eg: NOT (uid = 1 OR uid = 2)
+	if nested, ok := node.Inner.(*ast.NestedExpressionAtom); ok && node.IsOperatorNot() {
+		ret, err := nested.Accept(sd)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return logic.NOT(ret.(Calculus)), nil
+	}
 	return sd.fromValueNode(node)
 }
 
@@ -477,6 +675,9 @@ func (sd *ShardVisitor) VisitAtomSystemVariable(_ *ast.SystemVariableExpressionA
 }
 
 func (sd *ShardVisitor) VisitAtomVariable(node ast.VariableExpressionAtom) (interface{}, error) {
+	if node.N() >= len(sd.args) {
+		return nil, errors.Errorf("no value bound to placeholder #%d, only %d args are given", node.N(), len(sd.args))
+	}
 	return sd.fromConstant(sd.args[node.N()])
 }
 
@@ -484,6 +685,10 @@ func (sd *ShardVisitor) VisitAtomInterval(_ *ast.IntervalExpressionAtom) (interf
 	return alwaysTrue(), nil
 }
 
+func (sd *ShardVisitor) VisitAtomSubquery(_ *ast.SubqueryExpressionAtom) (interface{}, error) {
+	return alwaysTrue(), nil
+}
+
 func (sd *ShardVisitor) fromConstant(val proto.Value) (Calculus, error) {
 	if val == nil {
 		return alwaysFalse(), nil
@@ -506,6 +711,23 @@ func (sd *ShardVisitor) fromValueNode(node ast.Node) (interface{}, error) {
 	return sd.fromConstant(val)
 }
 
+// columnOf returns the column of predicate, the enclosing parentheses are ignored, eg: '(uid)' or '((uid))'.
+func columnOf(node ast.PredicateNode) (ast.ColumnNameExpressionAtom, bool) {
+	atom, ok := node.(*ast.AtomPredicateNode)
+	if !ok {
+		return nil, false
+	}
+	switch it := atom.A.(type) {
+	case ast.ColumnNameExpressionAtom:
+		return it, true
+	case *ast.NestedExpressionAtom:
+		if pen, ok := it.First.(*ast.PredicateExpressionNode); ok {
+			return columnOf(pen.P)
+		}
+	}
+	return nil, false
+}
+
 func alwaysTrue() Calculus {
 	return logic.True[*calc.Calculus]()
 }
@@ -513,3 +735,30 @@ func alwaysTrue() Calculus {
 func alwaysFalse() Calculus {
 	return logic.False[*calc.Calculus]()
 }
+
+// HasUnprunableOr returns true if the where clause contains an OR condition, one of whose branches can be pruned
+// by the sharding keys but the other one cannot, eg: "uid = 1 OR name = 'foo'". The rows matched by the non-key
+// branch may be located in any shard, so the whole query has to be full-scanned.
+func HasUnprunableOr(ctx context.Context, ru *rule.Rule, table ast.TableName, where ast.ExpressionNode, args []proto.Value) bool {
+	switch node := where.(type) {
+	case *ast.LogicalExpressionNode:
+		if node.Or && isPrunable(ctx, ru, table, node.Left, args) != isPrunable(ctx, ru, table, node.Right, args) {
+			return true
+		}
+		return HasUnprunableOr(ctx, ru, table, node.Left, args) || HasUnprunableOr(ctx, ru, table, node.Right, args)
+	case *ast.NotExpressionNode:
+		return HasUnprunableOr(ctx, ru, table, node.E, args)
+	case *ast.PredicateExpressionNode:
+		if atom, ok := node.P.(*ast.AtomPredicateNode); ok {
+			if nested, ok := atom.A.(*ast.NestedExpressionAtom); ok {
+				return HasUnprunableOr(ctx, ru, table, nested.First, args)
+			}
+		}
+	}
+	return false
+}
+
+func isPrunable(ctx context.Context, ru *rule.Rule, table ast.TableName, where ast.ExpressionNode, args []proto.Value) bool {
+	shards, err := NewXSharder(ctx, ru, args).SimpleShard(table, where)
+	return err == nil && shards != nil
+}
@@ -22,7 +22,9 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 import (
@@ -35,6 +37,8 @@ import (
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	rrule "github.com/arana-db/arana/pkg/runtime/builtin"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	_ "github.com/arana-db/arana/pkg/runtime/function"
 	. "github.com/arana-db/arana/pkg/runtime/optimize"
 	"github.com/arana-db/arana/testdata"
@@ -58,7 +62,53 @@ func TestShardNG(t *testing.T) {
 		{"select * from student where uid = PI() div 3", nil, []int{1}},
 		{"select * from student where uid = PI() div ?", []interface{}{3}, []int{1}},
 		{"select * from student where 1+2", nil, nil},
+		{"select * from student where 1 = 1 and uid = 7", nil, []int{7}},
 		{"select * from student where uid between 1 and 3", nil, []int{1, 2, 3}},
+		{"select * from student where uid = '42'", nil, []int{2}},
+		{"select * from student where uid = ?", []interface{}{"42"}, []int{2}},
+		{"select * from student where uid between '1' and '3'", nil, []int{1, 2, 3}},
+		{"select * from student where uid in ('7', 12)", nil, []int{4, 7}},
+		{"select * from student where (uid,name) in ((1,'foo'),(?,'bar'))", []interface{}{10}, []int{1, 2}},
+		{"select * from student where (uid,name) in ((3,'foo')) and uid > 1", nil, []int{3}},
+		{"select * from student where uid = true", nil, []int{1}},
+		{"select * from student where uid in (false, b'11', x'0A')", nil, []int{0, 2, 3}},
+		{"select * from student where uid = ?", []interface{}{true}, []int{1}},
+		{"select * from student where uid = ? or name = ?", []interface{}{7, "foo"}, nil},
+		{"select * from student where name = ?", []interface{}{"foo"}, nil},
+		{"select * from student where uid = 1 and name = ?", []interface{}{"foo"}, []int{1}},
+		{"select * from student where (uid = 7 or name = 'foo') and uid = 12", nil, []int{4}},
+		// nested parentheses
+		{"select * from student where (uid = 1 or uid = 2) and status = 'active'", nil, []int{1, 2}},
+		{"select * from student where (((uid = 3)))", nil, []int{3}},
+		{"select * from student where ((uid = 1 or (uid = 2)) and (status = 'a' or status = 'b'))", nil, []int{1, 2}},
+		{"select * from student where ((uid in (1,2)) and ((uid = 2) or (uid = 10)))", nil, []int{2}},
+		{"select * from student where (uid = 1 and (name = 'x' or (uid = 9 and age > 1))) or uid = 4", nil, []int{1, 4}},
+		{"select * from student where uid between 1 and 3 and (uid = 2 or (uid = 3 and (name = 'a')))", nil, []int{2, 3}},
+		{"select * from student where ((uid = ? or (uid = ? and (age > 1 or (name = 'x')))) and (status = 'a'))", []interface{}{5, 6}, []int{5, 6}},
+		{"select * from student where (uid = 1 or name = 'foo') and (uid = 2 or name = 'bar')", nil, nil},
+		{"select * from student where not (uid = 1 or ((uid = 2)))", nil, nil},
+		{"select * from student where not (name = 'foo')", nil, nil},
+		{"select * from student where not (uid <> 3)", nil, []int{3}},
+		{"select * from student where not (uid <> 3 or name = 'foo')", nil, []int{3}},
+		{"select * from student where not (uid <> 3 and name = 'foo')", nil, nil},
+		{"select * from student where uid = (1+2)", nil, []int{3}},
+		{"select * from student where (uid) = 5", nil, []int{5}},
+		{"select * from student where ((uid)) in (1, 9) or 3 = (uid)", nil, []int{1, 3}},
+		{"select * from student where (uid) between 2 and 3", nil, []int{2, 3}},
+		{"select * from student where uid + 1 in (2, 3)", nil, nil},
+		{"select * from student where 3 > uid and uid >= 1", nil, []int{1, 2}},
+		{"select * from student where ((uid between 1 and 2) or (uid in (5, 6))) and (uid > 1)", nil, []int{2, 5, 6}},
+		// unbounded range of modulo cannot be pruned
+		{"select * from student where uid > 1000", nil, nil},
+		{"select * from student where uid >= ?", []interface{}{1000}, nil},
+		{"select * from student where uid < 1000 or uid = 3", nil, nil},
+		{"select * from student where uid > 1000 and uid < 1003", nil, []int{1, 2}},
+		// fractional bounds are rounded inside the range
+		{"select * from student where uid > 1000.5 and uid < 1002.5", nil, []int{1, 2}},
+		{"select * from student where uid >= 1000.5 and uid <= ?", []interface{}{1002.5}, []int{1, 2}},
+		{"select * from student where uid between 1.5 and '3.5'", nil, []int{2, 3}},
+		{"select * from student where uid in (1.5, 2)", nil, []int{2}},
+		{"select * from student where uid <> 1.5", nil, nil},
 	} {
 		t.Run(it.sql, func(t *testing.T) {
 			_, rawStmt := ast.MustParse(it.sql)
@@ -90,6 +140,385 @@ func TestShardNG(t *testing.T) {
 	}
 }
 
+func TestShardNG_CoerceFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeRule := makeFakeRule(ctrl, "student", 8, nil)
+
+	for _, sql := range []string{
+		"select * from student where uid = 'abc'",
+	} {
+		t.Run(sql, func(t *testing.T) {
+			_, rawStmt := ast.MustParse(sql)
+			stmt := rawStmt.(*ast.SelectStatement)
+			_, err := stmt.Accept(NewXSharder(context.TODO(), fakeRule, nil))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestShardNG_NonIntegralEquality(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeRule := makeFakeRule(ctrl, "student", 8, nil)
+
+	// the numeric sharding key never equals to a fractional value, no shard is matched
+	for _, sql := range []string{
+		"select * from student where uid = 4.5",
+		"select * from student where uid = '4.5'",
+		"select * from student where uid in (1.5, 2.5)",
+	} {
+		t.Run(sql, func(t *testing.T) {
+			_, rawStmt := ast.MustParse(sql)
+			stmt := rawStmt.(*ast.SelectStatement)
+			shd := NewXSharder(context.TODO(), fakeRule, nil)
+			_, err := stmt.Accept(shd)
+			assert.NoError(t, err)
+
+			res := shd.Result()[0]
+			assert.NotNil(t, res.R, "should not be full scan")
+			assert.Equal(t, 0, res.R.Len())
+		})
+	}
+}
+
+func TestShardNG_GeneratedColumn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// test rule: orders, `month AS (MONTH(created_at))` % 12
+	var (
+		tab  rule.VTable
+		topo rule.Topology
+	)
+	topo.SetRender(func(_ int) string {
+		return "fake_db"
+	}, func(i int) string {
+		return fmt.Sprintf("orders_%04d", i)
+	})
+	topo.SetTopology(0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11)
+	tab.SetTopology(&topo)
+	tab.SetName("orders")
+
+	computer := testdata.NewMockShardComputer(ctrl)
+	computer.EXPECT().
+		Compute(gomock.Any()).
+		DoAndReturn(func(value proto.Value) (int, error) {
+			x, err := value.Int64()
+			return int(x) % 12, err
+		}).
+		AnyTimes()
+	computer.EXPECT().Variables().Return([]string{"month"}).AnyTimes()
+	tab.AddVShards(&rule.VShard{
+		Table: &rule.ShardMetadata{
+			ShardColumns: []*rule.ShardColumn{
+				{Name: "month", Steps: 12, Stepper: rule.Stepper{N: 1, U: rule.Unum}},
+			},
+			Computer: computer,
+		},
+	})
+
+	generator := testdata.NewMockShardComputer(ctrl)
+	generator.EXPECT().
+		Compute(gomock.Any()).
+		DoAndReturn(func(value proto.Value) (int, error) {
+			t, err := value.Time()
+			return int(t.Month()), err
+		}).
+		AnyTimes()
+	generator.EXPECT().Variables().Return([]string{"created_at"}).AnyTimes()
+	tab.AddGeneratedColumn(&rule.GeneratedColumn{
+		Name: "month",
+		Columns: []*rule.ShardColumn{
+			{Name: "created_at", Steps: 12, Stepper: rule.Stepper{N: 1, U: rule.Uday}},
+		},
+		Computer: generator,
+	})
+
+	var ru rule.Rule
+	ru.SetVTable("orders", &tab)
+
+	for _, it := range []struct {
+		sql    string
+		expect []int
+	}{
+		{"select * from orders where month = 5", []int{5}},
+		{"select * from orders where created_at = '2023-05-10'", []int{5}},
+		{"select * from orders where created_at in ('2023-05-10', '2023-11-01')", []int{5, 11}},
+		{"select * from orders where created_at = '2023-05-10' and month = 6", []int{}},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			_, rawStmt := ast.MustParse(it.sql)
+			stmt := rawStmt.(*ast.SelectStatement)
+
+			shd := NewXSharder(context.TODO(), &ru, nil)
+			_, err := stmt.Accept(shd)
+			assert.NoError(t, err)
+
+			actual := make([]int, 0)
+			shd.Result()[0].R.Each(func(_, tb uint32) bool {
+				actual = append(actual, int(tb))
+				return true
+			})
+			sort.Ints(actual)
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
+func TestShardNG_KeyLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// test rule: orders, customer_id % 4, customer_code -> customer_id
+	var (
+		tab  rule.VTable
+		topo rule.Topology
+	)
+	topo.SetRender(func(_ int) string {
+		return "fake_db"
+	}, func(i int) string {
+		return fmt.Sprintf("orders_%04d", i)
+	})
+	topo.SetTopology(0, 0, 1, 2, 3)
+	tab.SetTopology(&topo)
+	tab.SetName("orders")
+
+	computer := testdata.NewMockShardComputer(ctrl)
+	computer.EXPECT().
+		Compute(gomock.Any()).
+		DoAndReturn(func(value proto.Value) (int, error) {
+			x, err := value.Int64()
+			return int(x) % 4, err
+		}).
+		AnyTimes()
+	computer.EXPECT().Variables().Return([]string{"customer_id"}).AnyTimes()
+	tab.AddVShards(&rule.VShard{
+		Table: &rule.ShardMetadata{
+			ShardColumns: []*rule.ShardColumn{
+				{Name: "customer_id", Steps: 4, Stepper: rule.Stepper{N: 1, U: rule.Unum}},
+			},
+			Computer: computer,
+		},
+	})
+
+	kl := rule.NewKeyLookup("customer_code", "customer_id", "customer_db.customers", 100)
+	kl.BindOnce(func(_ context.Context, value proto.Value) (proto.Value, error) {
+		switch value.String() {
+		case "ABC":
+			return proto.NewValueInt64(5), nil
+		case "DEF":
+			return proto.NewValueInt64(6), nil
+		}
+		return nil, nil
+	})
+	tab.SetKeyLookup(kl)
+
+	var ru rule.Rule
+	ru.SetVTable("orders", &tab)
+
+	for _, it := range []struct {
+		sql    string
+		expect []int
+	}{
+		{"select * from orders where customer_code = 'ABC'", []int{1}},
+		{"select * from orders where CUSTOMER_CODE = 'ABC'", []int{1}},
+		{"select * from orders where customer_code in ('ABC', 'DEF')", []int{1, 2}},
+		{"select * from orders where customer_code = 'ABC' and customer_id = 6", []int{}},
+		{"select * from orders where customer_code = 'ABC' or customer_id = 7", []int{1, 3}},
+		// miss, full scan
+		{"select * from orders where customer_code = 'XYZ'", nil},
+		{"select * from orders where customer_code in ('ABC', 'XYZ')", nil},
+		{"select * from orders where customer_code > 'ABC'", nil},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			_, rawStmt := ast.MustParse(it.sql)
+			stmt := rawStmt.(*ast.SelectStatement)
+
+			shd := NewXSharder(context.TODO(), &ru, nil)
+			_, err := stmt.Accept(shd)
+			assert.NoError(t, err)
+
+			// nil shards means full scan
+			var actual []int
+			if res := shd.Result()[0].R; res != nil {
+				actual = make([]int, 0)
+				res.Each(func(_, tb uint32) bool {
+					actual = append(actual, int(tb))
+					return true
+				})
+				sort.Ints(actual)
+			}
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
+func TestShardNG_RangeMapping(t *testing.T) {
+	// test rule: 1..1000 -> 0, 1001..2000 -> 1, others -> 2
+	var (
+		tab  rule.VTable
+		topo rule.Topology
+	)
+	topo.SetRender(func(_ int) string {
+		return "fake_db"
+	}, func(i int) string {
+		return fmt.Sprintf("student_%04d", i)
+	})
+	topo.SetTopology(0, 0, 1, 2)
+	tab.SetTopology(&topo)
+	tab.SetName("student")
+
+	computer, err := rrule.NewRangeShardComputer("1..1000=0, 1001..2000=1, *=2", "uid")
+	assert.NoError(t, err)
+	tab.AddVShards(&rule.VShard{
+		Table: &rule.ShardMetadata{
+			ShardColumns: []*rule.ShardColumn{
+				{Name: "uid", Steps: 3, Stepper: rule.Stepper{N: 1, U: rule.Unum}},
+			},
+			Computer: computer,
+		},
+	})
+
+	var ru rule.Rule
+	ru.SetVTable("student", &tab)
+
+	for _, it := range []struct {
+		sql    string
+		expect []int
+	}{
+		{"select * from student where uid = 1500", []int{1}},
+		{"select * from student where uid in (1, 1001, 5000)", []int{0, 1, 2}},
+		{"select * from student where uid between 100 and 900", []int{0}},
+		{"select * from student where uid >= 500 and uid <= 1500", []int{0, 1}},
+		{"select * from student where uid > 1000 and uid < 2001", []int{1}},
+		{"select * from student where uid > 1500", []int{1, 2}},
+		{"select * from student where uid > 1000", []int{1, 2}},
+		{"select * from student where uid <= 1000", []int{0, 2}},
+		{"select * from student where uid < 1", []int{2}},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			_, rawStmt := ast.MustParse(it.sql)
+			stmt := rawStmt.(*ast.SelectStatement)
+
+			shd := NewXSharder(context.TODO(), &ru, nil)
+			_, err := stmt.Accept(shd)
+			assert.NoError(t, err)
+
+			actual := make([]int, 0)
+			shd.Result()[0].R.Each(func(_, tb uint32) bool {
+				actual = append(actual, int(tb))
+				return true
+			})
+			sort.Ints(actual)
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
+func TestShardNG_RelativeTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// test rule: logs, WEEKDAY(created_at) % 7
+	var (
+		tab  rule.VTable
+		topo rule.Topology
+	)
+	topo.SetRender(func(_ int) string {
+		return "fake_db"
+	}, func(i int) string {
+		return fmt.Sprintf("logs_%04d", i)
+	})
+	topo.SetTopology(0, 0, 1, 2, 3, 4, 5, 6)
+	tab.SetTopology(&topo)
+	tab.SetName("logs")
+
+	computer := testdata.NewMockShardComputer(ctrl)
+	computer.EXPECT().
+		Compute(gomock.Any()).
+		DoAndReturn(func(value proto.Value) (int, error) {
+			t, err := value.Time()
+			return int(t.Weekday()), err
+		}).
+		AnyTimes()
+	computer.EXPECT().Variables().Return([]string{"created_at"}).AnyTimes()
+	tab.AddVShards(&rule.VShard{
+		Table: &rule.ShardMetadata{
+			ShardColumns: []*rule.ShardColumn{
+				{Name: "created_at", Steps: 7, Stepper: rule.Stepper{N: 1, U: rule.Uday}},
+			},
+			Computer: computer,
+		},
+	})
+
+	var ru rule.Rule
+	ru.SetVTable("logs", &tab)
+
+	// 2022-03-01 20:00 UTC is Wednesday 2022-03-02 in the time zone of session
+	ctx := rcontext.WithStartTime(context.WithValue(context.TODO(), proto.ContextKeyTransientVariables{}, map[string]proto.Value{
+		"@@time_zone": proto.NewValueString("+08:00"),
+	}), time.Date(2022, 3, 1, 20, 0, 0, 0, time.UTC))
+	today := time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	weekdays := func(days ...int) []int {
+		ret := make([]int, 0, len(days))
+		for _, d := range days {
+			ret = append(ret, int(today.AddDate(0, 0, d).Weekday()))
+		}
+		sort.Ints(ret)
+		return ret
+	}
+
+	for _, it := range []struct {
+		sql    string
+		expect []int
+	}{
+		{"select * from logs where created_at = curdate()", weekdays(0)},
+		{"select * from logs where created_at = curdate() - interval 1 day", weekdays(-1)},
+		{"select * from logs where created_at = date_add(curdate(), interval 1 week)", weekdays(0)},
+		{"select * from logs where created_at between curdate() - interval 2 day and curdate()", weekdays(-2, -1, 0)},
+		{"select * from logs where created_at >= date_sub(curdate(), interval 1 day) and created_at <= now()", weekdays(-1, 0)},
+		// the one-sided range is pruned once it is bounded
+		{"select * from logs where created_at >= now() - interval 2 day and created_at <= now()", weekdays(-2, -1, 0)},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			_, rawStmt := ast.MustParse(it.sql)
+			stmt := rawStmt.(*ast.SelectStatement)
+
+			shd := NewXSharder(ctx, &ru, nil)
+			_, err := stmt.Accept(shd)
+			assert.NoError(t, err)
+
+			actual := make([]int, 0)
+			shd.Result()[0].R.Each(func(_, tb uint32) bool {
+				actual = append(actual, int(tb))
+				return true
+			})
+			sort.Ints(actual)
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+
+	for _, sql := range []string{
+		// unresolvable expression, fallback to full scan
+		"select * from logs where created_at >= now() - interval '1:1' minute_second",
+		// the one-sided range cannot be enumerated by the stepper, fallback to full scan, see calc.rangeComputable
+		"select * from logs where created_at >= now() - interval 7 day",
+		"select * from logs where created_at < curdate()",
+	} {
+		t.Run(sql, func(t *testing.T) {
+			_, rawStmt := ast.MustParse(sql)
+			shd := NewXSharder(ctx, &ru, nil)
+			_, err := rawStmt.(*ast.SelectStatement).Accept(shd)
+			assert.NoError(t, err)
+			assert.Nil(t, shd.Result()[0].R)
+		})
+	}
+}
+
 func makeFakeRule(c *gomock.Controller, table string, mod int, ru *rule.Rule) *rule.Rule {
 	var (
 		tab  rule.VTable
@@ -151,3 +580,151 @@ func makeFakeRule(c *gomock.Controller, table string, mod int, ru *rule.Rule) *r
 	ru.SetVTable(table, &tab)
 	return ru
 }
+
+func TestFilterShardsByKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeRule := makeFakeRule(ctrl, "student", 8, nil)
+	vt := fakeRule.MustVTable("student")
+
+	kf := rule.NewKeyFilter("name", 100, 0.01)
+	for i := 0; i < 8; i++ {
+		kf.SetReady("fake_db", fmt.Sprintf("student_%04d", i))
+	}
+	kf.Add("fake_db", "student_0001", proto.NewValueString("foo"))
+	kf.Add("fake_db", "student_0005", proto.NewValueString("bar"))
+	vt.SetKeyFilter(kf)
+
+	type tt struct {
+		sql    string
+		expect []string
+	}
+
+	for _, it := range []tt{
+		{"select * from student where name in ('foo','bar')", []string{"student_0001", "student_0005"}},
+		{"select * from student where age > 1 and name = 'bar'", []string{"student_0005"}},
+		{"select * from student where name = 'qux'", nil},
+		// disjunctive condition cannot be filtered, keep full scan
+		{"select * from student where name = 'foo' or age > 1", nil},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			_, rawStmt := ast.MustParse(it.sql)
+			stmt := rawStmt.(*ast.SelectStatement)
+
+			shards := FilterShardsByKey(context.TODO(), vt, nil, stmt.Where, nil)
+			actual := shards["fake_db"]
+			sort.Strings(actual)
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
+func TestSplitTupleIn(t *testing.T) {
+	// composite sharding keys: (uid*31+sid) % 4
+	var (
+		vt   rule.VTable
+		topo rule.Topology
+	)
+	topo.SetRender(func(_ int) string {
+		return "fake_db"
+	}, func(i int) string {
+		return fmt.Sprintf("student_%04d", i)
+	})
+	topo.SetTopology(0, 0, 1, 2, 3)
+	vt.SetTopology(&topo)
+	vt.SetName("student")
+	vt.AddVShards(&rule.VShard{
+		Table: &rule.ShardMetadata{
+			ShardColumns: []*rule.ShardColumn{
+				{Name: "uid", Steps: 4, Stepper: rule.Stepper{N: 1, U: rule.Unum}},
+				{Name: "sid", Steps: 4, Stepper: rule.Stepper{N: 1, U: rule.Unum}},
+			},
+			Computer: rrule.MustNewJavascriptShardComputer("($0*31+$1) % 4", "uid", "sid"),
+		},
+	})
+
+	restore := func(where ast.ExpressionNode) string {
+		var sb strings.Builder
+		assert.NoError(t, where.Restore(ast.RestoreDefault, &sb, nil))
+		return sb.String()
+	}
+
+	_, rawStmt := ast.MustParse("select * from student where (uid,sid) in ((1,1),(1,2),(2,?)) and age > 18")
+	stmt := rawStmt.(*ast.SelectStatement)
+
+	wheres, ok := SplitTupleIn(context.TODO(), &vt, stmt.Where, []proto.Value{proto.NewValueInt64(3)})
+	assert.True(t, ok)
+	assert.Len(t, wheres, 2)
+	// (1*31+1)%4=0, (1*31+2)%4=1, (2*31+3)%4=1
+	assert.Equal(t, "(`uid`,`sid`) IN ((1,1)) AND `age` > 18", restore(wheres["student_0000"]))
+	assert.Equal(t, "(`uid`,`sid`) IN ((1,2),(2,?)) AND `age` > 18", restore(wheres["student_0001"]))
+
+	for _, it := range []string{
+		"select * from student where (uid,sid) in ((1,1)) or age > 18",
+		"select * from student where (uid,name) in ((1,'foo'))",
+		"select * from student where uid in (1,2)",
+	} {
+		_, rawStmt = ast.MustParse(it)
+		_, ok = SplitTupleIn(context.TODO(), &vt, rawStmt.(*ast.SelectStatement).Where, nil)
+		assert.False(t, ok, it)
+	}
+}
+
+func TestPropagateConstants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// test rule: uid % 8
+	fakeRule := makeFakeRule(ctrl, "student", 8, nil)
+	fakeRule = makeFakeRule(ctrl, "salaries", 8, fakeRule)
+	fakeRule = makeFakeRule(ctrl, "score", 8, fakeRule)
+
+	restore := func(where ast.ExpressionNode) string {
+		var sb strings.Builder
+		assert.NoError(t, where.Restore(ast.RestoreDefault, &sb, nil))
+		return sb.String()
+	}
+
+	_, rawStmt := ast.MustParse("select * from student a join salaries b on a.uid = b.uid join score c on b.uid = c.uid " +
+		"where a.uid = c.uid and c.uid = 7 and a.age > 18")
+	stmt := rawStmt.(*ast.SelectStatement)
+
+	ons := make([]ast.ExpressionNode, 0, len(stmt.From[0].Joins))
+	for _, join := range stmt.From[0].Joins {
+		ons = append(ons, join.On)
+	}
+
+	where := PropagateConstants(stmt.Where, ons...)
+	assert.Equal(t, "`c`.`uid` = 7 AND `a`.`age` > 18 AND `a`.`uid` = 7 AND `b`.`uid` = 7", restore(where))
+
+	// a single constant prunes all joined tables
+	for _, table := range []string{"student", "salaries", "score"} {
+		shards, err := NewXSharder(context.TODO(), fakeRule, nil).SimpleShard(ast.TableName{table}, where)
+		assert.NoError(t, err)
+		assert.Equal(t, rule.DatabaseTables{"fake_db": {fmt.Sprintf("%s_0007", table)}}, shards)
+	}
+
+	// nothing changed without constant binding
+	_, rawStmt = ast.MustParse("select * from student a join salaries b on a.uid = b.uid where a.uid > 7 or b.uid = 1")
+	stmt = rawStmt.(*ast.SelectStatement)
+	assert.Same(t, stmt.Where, PropagateConstants(stmt.Where, stmt.From[0].Joins[0].On))
+}
+
+func TestAndPredicates(t *testing.T) {
+	restore := func(where ast.ExpressionNode) string {
+		var sb strings.Builder
+		assert.NoError(t, where.Restore(ast.RestoreDefault, &sb, nil))
+		return sb.String()
+	}
+
+	_, rawStmt := ast.MustParse("select * from student where uid = 1 or age > 18")
+	or := rawStmt.(*ast.SelectStatement).Where
+	_, rawStmt = ast.MustParse("select * from student where tenant_id = 'foo'")
+	p := rawStmt.(*ast.SelectStatement).Where
+
+	assert.Same(t, p, AndPredicates(nil, p))
+	assert.Same(t, p, AndPredicates(p, nil))
+	assert.Equal(t, "(`uid` = 1 OR `age` > 18) AND `tenant_id` = 'foo'", restore(AndPredicates(or, p)))
+	assert.Equal(t, "`tenant_id` = 'foo' AND (`uid` = 1 OR `age` > 18)", restore(AndPredicates(p, or)))
+}
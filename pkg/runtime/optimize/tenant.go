@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package optimize
+
+import (
+	"context"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto/rule"
+	rast "github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/cmp"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+)
+
+// injectTenantPredicates appends the predicate 'tenant_column = <current_tenant>' to the statement for each
+// tenant-scoped table, so the rows of other tenants cannot be accessed even if the tenant filter is missing.
+// It should be called before computing shards, then the injected predicate can be used to prune the shards.
+func injectTenantPredicates(ctx context.Context, ru *rule.Rule, stmt rast.Statement) {
+	tenant := rcontext.Tenant(ctx)
+	if ru == nil || len(tenant) == 0 {
+		return
+	}
+
+	predicate := func(table rast.TableName, qualifier string) rast.ExpressionNode {
+		vt, ok := ru.VTable(table.Suffix())
+		if !ok || len(vt.TenantColumn()) == 0 {
+			return nil
+		}
+		column := rast.ColumnNameExpressionAtom{vt.TenantColumn()}
+		if len(qualifier) > 0 {
+			column = rast.ColumnNameExpressionAtom{qualifier, vt.TenantColumn()}
+		}
+		return &rast.PredicateExpressionNode{
+			P: &rast.BinaryComparisonPredicateNode{
+				Left:  &rast.AtomPredicateNode{A: column},
+				Right: &rast.AtomPredicateNode{A: &rast.ConstantExpressionAtom{Inner: tenant}},
+				Op:    cmp.Ceq,
+			},
+		}
+	}
+
+	var visitSelect func(sel *rast.SelectStatement)
+	visitUnion := func(union *rast.UnionSelectStatement) {
+		visitSelect(union.First)
+		for _, it := range union.UnionStatementItems {
+			visitSelect(it.Stmt)
+		}
+	}
+	// visitSource returns the tenant predicate of table source, the derived tables will be rewritten recursively.
+	visitSource := func(item *rast.TableSourceItem, qualified bool) rast.ExpressionNode {
+		switch source := item.Source.(type) {
+		case rast.TableName:
+			var qualifier string
+			if qualified {
+				if qualifier = item.Alias; len(qualifier) == 0 {
+					qualifier = source.Suffix()
+				}
+			}
+			return predicate(source, qualifier)
+		case *rast.SelectStatement:
+			visitSelect(source)
+		case *rast.UnionSelectStatement:
+			visitUnion(source)
+		}
+		return nil
+	}
+	visitSelect = func(sel *rast.SelectStatement) {
+		if sel == nil {
+			return
+		}
+		// qualify the tenant column if multiple tables are accessed, or it may be ambiguous
+		qualified := len(sel.From) > 1
+		for _, from := range sel.From {
+			if len(from.Joins) > 0 {
+				qualified = true
+			}
+		}
+		for _, from := range sel.From {
+			base := visitSource(&from.TableSourceItem, qualified)
+			for _, join := range from.Joins {
+				p := visitSource(join.Target, qualified)
+				// put the predicate of nullable table into ON clause, or the outer join will be converted to inner join
+				switch {
+				case join.On == nil:
+				case join.Typ == rast.LeftJoin:
+					if p != nil {
+						join.On, p = AndPredicates(join.On, p), nil
+					}
+				case join.Typ == rast.RightJoin:
+					if base != nil {
+						join.On, base = AndPredicates(join.On, base), nil
+					}
+				}
+				if p != nil {
+					sel.Where = AndPredicates(sel.Where, p)
+				}
+			}
+			if base != nil {
+				sel.Where = AndPredicates(sel.Where, base)
+			}
+		}
+	}
+
+	switch it := stmt.(type) {
+	case *rast.SelectStatement:
+		visitSelect(it)
+	case *rast.UnionSelectStatement:
+		visitUnion(it)
+	case *rast.InsertSelectStatement:
+		visitSelect(it.Select())
+	case *rast.UpdateStatement:
+		if p := predicate(it.Table, ""); p != nil {
+			it.Where = AndPredicates(it.Where, p)
+		}
+	case *rast.DeleteStatement:
+		if p := predicate(it.Table, ""); p != nil {
+			it.Where = AndPredicates(it.Where, p)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package optimize
+
+import (
+	"context"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/calc"
+)
+
+// SplitTupleIn splits the values of a conjunctive 'row IN (...)' or 'key IN (...)' condition by the physical tables
+// which own them, it returns a where clause for each physical table which only contains the owned values, eg:
+//
+//	WHERE (uid,sid) IN ((1,2),(3,4)) -> student_0001: WHERE (uid,sid) IN ((1,2)), student_0003: WHERE (uid,sid) IN ((3,4))
+//	WHERE uid IN (?,?,?) -> student_0001: WHERE uid IN (?,?), student_0003: WHERE uid IN (?)
+//
+// The placeholders are kept as they are, each of them is computed by the bound args and will be rebound by its index.
+// False will be returned if there is no such condition, or the shards of any tuple cannot be determined.
+func SplitTupleIn(ctx context.Context, vt *rule.VTable, where ast.ExpressionNode, args []proto.Value) (map[string]ast.ExpressionNode, bool) {
+	if where == nil {
+		return nil, false
+	}
+
+	in := findTupleIn(vt, where)
+	if in == nil {
+		return nil, false
+	}
+
+	sd := &ShardVisitor{
+		ctx:  ctx,
+		vtab: vt,
+		args: args,
+	}
+
+	owned := make(map[string][]ast.ExpressionNode)
+	for _, tuple := range in.E {
+		l, err := sd.VisitPredicateIn(&ast.InPredicateNode{
+			P: in.P,
+			E: []ast.ExpressionNode{tuple},
+		})
+		if err != nil {
+			return nil, false
+		}
+		shards, err := calc.Eval(vt, l.(Calculus))
+		if err != nil || shards == nil {
+			return nil, false
+		}
+
+		var ok = true
+		shards.Each(func(db, tb uint32) bool {
+			var table string
+			if _, table, ok = vt.Topology().Render(int(db), int(tb)); !ok {
+				return false
+			}
+			owned[table] = append(owned[table], tuple)
+			return true
+		})
+		if !ok {
+			return nil, false
+		}
+	}
+
+	ret := make(map[string]ast.ExpressionNode, len(owned))
+	for table, tuples := range owned {
+		ret[table] = replacePredicate(where, in, &ast.InPredicateNode{
+			P: in.P,
+			E: tuples,
+		})
+	}
+	return ret, true
+}
+
+// findTupleIn finds the first conjunctive 'row IN (...)' or 'key IN (...)' condition, the key must be a sharding key.
+func findTupleIn(vt *rule.VTable, where ast.ExpressionNode) *ast.InPredicateNode {
+	switch node := where.(type) {
+	case *ast.LogicalExpressionNode:
+		if node.Or {
+			return nil
+		}
+		if in := findTupleIn(vt, node.Left); in != nil {
+			return in
+		}
+		return findTupleIn(vt, node.Right)
+	case *ast.PredicateExpressionNode:
+		in, ok := node.P.(*ast.InPredicateNode)
+		if !ok || in.Not || in.Sub != nil || len(in.E) < 2 {
+			return nil
+		}
+		if atom, ok := in.P.(*ast.AtomPredicateNode); ok {
+			if _, ok = atom.A.(*ast.RowExpressionAtom); ok {
+				return in
+			}
+		}
+		if key, ok := columnOf(in.P); ok {
+			column := vt.NormalizeColumn(key.Suffix())
+			if vt.GetShardColumn(column) != nil || len(vt.GetGeneratedColumns(column)) > 0 {
+				return in
+			}
+		}
+	}
+	return nil
+}
+
+// replacePredicate replaces the target predicate, the nodes on the path will be copied, others will be shared.
+func replacePredicate(where ast.ExpressionNode, target, replacement ast.PredicateNode) ast.ExpressionNode {
+	switch node := where.(type) {
+	case *ast.LogicalExpressionNode:
+		return &ast.LogicalExpressionNode{
+			Or:    node.Or,
+			Left:  replacePredicate(node.Left, target, replacement),
+			Right: replacePredicate(node.Right, target, replacement),
+		}
+	case *ast.PredicateExpressionNode:
+		if node.P == target {
+			return &ast.PredicateExpressionNode{
+				P: replacement,
+			}
+		}
+	}
+	return where
+}
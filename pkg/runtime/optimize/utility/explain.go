@@ -22,9 +22,15 @@ import (
 )
 
 import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/constants/mysql"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/optimize"
 	"github.com/arana-db/arana/pkg/runtime/plan/utility"
 )
@@ -36,6 +42,10 @@ func init() {
 func optimzeExplainStatement(ctx context.Context, o *optimize.Optimizer) (proto.Plan, error) {
 	stmt := o.Stmt.(*ast.ExplainStatement)
 
+	if stmt.Analyze {
+		return optimizeExplainAnalyze(ctx, o, stmt)
+	}
+
 	ret := utility.NewExplainPlan(stmt)
 
 	var (
@@ -43,7 +53,10 @@ func optimzeExplainStatement(ctx context.Context, o *optimize.Optimizer) (proto.
 		err    error
 	)
 
-	shards, err = o.ComputeShards(ctx, stmt.Table, nil, o.Args)
+	where := explainWhere(stmt.Target)
+	lintFullScan(ctx, o, stmt, where)
+
+	shards, err = o.ComputeShards(ctx, stmt.Table, where, o.Args)
 	if err != nil {
 		return nil, err
 	}
@@ -52,3 +65,65 @@ func optimzeExplainStatement(ctx context.Context, o *optimize.Optimizer) (proto.
 
 	return ret, nil
 }
+
+// explainWhere returns the where clause of explained statement, which will be used to compute the shards.
+func explainWhere(target ast.Statement) ast.ExpressionNode {
+	switch t := target.(type) {
+	case *ast.SelectStatement:
+		return t.Where
+	case *ast.UpdateStatement:
+		return t.Where
+	case *ast.DeleteStatement:
+		return t.Where
+	}
+	return nil
+}
+
+// lintFullScan warns if the explained statement will full-scan the sharded table, the accidental expensive
+// queries which miss the predicates of sharding keys can be caught before deployment.
+func lintFullScan(ctx context.Context, o *optimize.Optimizer, stmt *ast.ExplainStatement, where ast.ExpressionNode) {
+	switch stmt.Target.Mode() {
+	case ast.SQLTypeSelect, ast.SQLTypeUpdate, ast.SQLTypeDelete:
+	default:
+		return
+	}
+
+	vt, ok := o.Rule.VTable(stmt.Table.Suffix())
+	if !ok || len(o.Hints) > 0 {
+		return
+	}
+
+	if where != nil {
+		shards, err := optimize.NewXSharder(ctx, o.Rule, o.Args).SimpleShard(stmt.Table, where)
+		if err != nil || shards != nil {
+			return
+		}
+	}
+
+	_, n := vt.Topology().Len()
+	if optimize.HasUnprunableOr(ctx, o.Rule, stmt.Table, where, o.Args) {
+		rcontext.AddWarning(ctx, mysql.ERUnknownError, "OR condition on non-sharding column prevents shard pruning; will full-scan %d shards of table '%s'", n, vt.Name())
+		return
+	}
+	rcontext.AddWarning(ctx, mysql.ERUnknownError, "no sharding-key predicate; will full-scan %d shards of table '%s'", n, vt.Name())
+}
+
+// optimizeExplainAnalyze optimizes the target statement, then executes it with instrumented shard plans.
+func optimizeExplainAnalyze(ctx context.Context, o *optimize.Optimizer, stmt *ast.ExplainStatement) (proto.Plan, error) {
+	if _, ok := stmt.Target.(*ast.SelectStatement); !ok {
+		return nil, errors.Errorf("optimize: EXPLAIN ANALYZE only supports SELECT statement")
+	}
+
+	target := &optimize.Optimizer{
+		Rule:  o.Rule,
+		Hints: o.Hints,
+		Stmt:  stmt.Target,
+		Args:  o.Args,
+	}
+	p, err := target.Optimize(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return utility.NewExplainAnalyzePlan(p), nil
+}
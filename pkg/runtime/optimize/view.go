@@ -0,0 +1,225 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package optimize
+
+import (
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto/rule"
+	rast "github.com/arana-db/arana/pkg/runtime/ast"
+)
+
+// inlineViews replaces the views in FROM clause with their base tables, and the predicates of view definitions
+// are merged into the statement, eg: 'SELECT * FROM active_orders WHERE uid = 1' will be rewritten as
+// 'SELECT * FROM orders AS active_orders WHERE status = 'active' AND uid = 1', so the shards can be computed.
+func inlineViews(ru *rule.Rule, stmt rast.Statement) error {
+	switch it := stmt.(type) {
+	case *rast.SelectStatement:
+		return inlineSelectViews(ru, it)
+	case *rast.UnionSelectStatement:
+		return inlineUnionViews(ru, it)
+	}
+	return nil
+}
+
+func inlineUnionViews(ru *rule.Rule, union *rast.UnionSelectStatement) error {
+	if err := inlineSelectViews(ru, union.First); err != nil {
+		return err
+	}
+	for _, it := range union.UnionStatementItems {
+		if err := inlineSelectViews(ru, it.Stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func inlineSelectViews(ru *rule.Rule, sel *rast.SelectStatement) error {
+	if sel == nil {
+		return nil
+	}
+
+	single := len(sel.From) == 1 && len(sel.From[0].Joins) == 0
+	for _, from := range sel.From {
+		base, err := inlineView(ru, sel, &from.TableSourceItem, single)
+		if err != nil {
+			return err
+		}
+		for _, join := range from.Joins {
+			p, err := inlineView(ru, sel, join.Target, false)
+			if err != nil {
+				return err
+			}
+			// put the predicate of nullable table into ON clause, or the outer join will be converted to inner join
+			switch {
+			case join.On == nil:
+			case join.Typ == rast.LeftJoin:
+				if p != nil {
+					join.On, p = AndPredicates(join.On, p), nil
+				}
+			case join.Typ == rast.RightJoin:
+				if base != nil {
+					join.On, base = AndPredicates(join.On, base), nil
+				}
+			}
+			if p != nil {
+				sel.Where = AndPredicates(p, sel.Where)
+			}
+		}
+		if base != nil {
+			sel.Where = AndPredicates(base, sel.Where)
+		}
+	}
+	return nil
+}
+
+// inlineView replaces the view of table source with its base table, and returns the predicate of view definition.
+func inlineView(ru *rule.Rule, sel *rast.SelectStatement, item *rast.TableSourceItem, single bool) (rast.ExpressionNode, error) {
+	var name rast.TableName
+	switch source := item.Source.(type) {
+	case rast.TableName:
+		name = source
+	case *rast.SelectStatement:
+		return nil, inlineSelectViews(ru, source)
+	case *rast.UnionSelectStatement:
+		return nil, inlineUnionViews(ru, source)
+	default:
+		return nil, nil
+	}
+
+	// the logical table always takes precedence over the view of same name
+	if ru.Has(name.Suffix()) {
+		return nil, nil
+	}
+	view, ok := ru.View(name.Suffix())
+	if !ok {
+		return nil, nil
+	}
+
+	def, err := parseView(view)
+	if err != nil {
+		return nil, err
+	}
+
+	qualifier := item.Alias
+	if len(qualifier) == 0 {
+		qualifier = name.Suffix()
+	}
+
+	var (
+		from          = def.From[0]
+		baseQualifier = from.Alias
+	)
+	if len(baseQualifier) == 0 {
+		baseQualifier = from.Source.(rast.TableName).Suffix()
+	}
+
+	// keep the name of view as alias, so the columns referenced by view name are still available
+	item.Source = from.Source
+	item.Alias = qualifier
+
+	rast.Inspect(def.Where, func(node rast.Node) {
+		if c, ok := node.(rast.ColumnNameExpressionAtom); ok && len(c) == 2 && strings.EqualFold(c[0], baseQualifier) {
+			c[0] = qualifier
+		}
+	})
+
+	expandViewColumns(sel, def, qualifier, single)
+
+	return def.Where, nil
+}
+
+// parseView parses the definition of view, only the filter and projection of single table are supported.
+func parseView(view *rule.View) (*rast.SelectStatement, error) {
+	_, def, err := rast.ParseSelect(view.Definition)
+	if err != nil {
+		return nil, perrors.Wrapf(err, "invalid definition of view '%s'", view.Name)
+	}
+
+	unsupported := func(reason string) error {
+		return perrors.Errorf("unsupported definition of view '%s': %s", view.Name, reason)
+	}
+
+	if len(def.From) != 1 || len(def.From[0].Joins) > 0 {
+		return nil, unsupported("only single table is supported")
+	}
+	if _, ok := def.From[0].Source.(rast.TableName); !ok {
+		return nil, unsupported("only single table is supported")
+	}
+	if def.GroupBy != nil || def.Having != nil || def.Distinct {
+		return nil, unsupported("aggregation is not supported")
+	}
+	if len(def.OrderBy) > 0 || def.Limit != nil || def.Lock != 0 {
+		return nil, unsupported("ORDER BY, LIMIT and locking read are not supported")
+	}
+
+	for _, it := range def.Select {
+		switch elem := it.(type) {
+		case *rast.SelectElementAll:
+		case *rast.SelectElementColumn:
+			if len(elem.Alias()) > 0 && !strings.EqualFold(elem.Alias(), elem.Suffix()) {
+				return nil, unsupported("column alias is not supported")
+			}
+		default:
+			return nil, unsupported("only columns can be selected")
+		}
+	}
+
+	var placeholder bool
+	rast.Inspect(def.Where, func(node rast.Node) {
+		if _, ok := node.(rast.VariableExpressionAtom); ok {
+			placeholder = true
+		}
+	})
+	if placeholder {
+		return nil, unsupported("placeholder is not supported")
+	}
+
+	return def, nil
+}
+
+// expandViewColumns replaces the wildcard of view with the columns declared by view definition.
+func expandViewColumns(sel *rast.SelectStatement, def *rast.SelectStatement, qualifier string, single bool) {
+	var columns []string
+	for _, it := range def.Select {
+		c, ok := it.(*rast.SelectElementColumn)
+		if !ok {
+			return
+		}
+		columns = append(columns, c.Suffix())
+	}
+
+	selects := make(rast.SelectNode, 0, len(sel.Select))
+	for _, it := range sel.Select {
+		all, ok := it.(*rast.SelectElementAll)
+		if !ok || !(strings.EqualFold(all.Prefix(), qualifier) || (single && len(all.Prefix()) == 0)) {
+			selects = append(selects, it)
+			continue
+		}
+		for _, column := range columns {
+			selects = append(selects, rast.NewSelectElementColumn([]string{qualifier, column}, ""))
+		}
+	}
+	sel.Select = selects
+}
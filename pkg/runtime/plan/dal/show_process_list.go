@@ -32,8 +32,10 @@ import (
 	"github.com/arana-db/arana/pkg/dataset"
 	"github.com/arana-db/arana/pkg/mysql/rows"
 	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/hint"
 	"github.com/arana-db/arana/pkg/resultx"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/plan"
 	"github.com/arana-db/arana/pkg/util/math"
 )
@@ -71,6 +73,10 @@ func (s *ShowProcessListPlan) ExecIn(ctx context.Context, conn proto.VConn) (pro
 		return nil, errors.WithStack(err)
 	}
 
+	// KILL is always sent to the master, so the listed process ids should come from the master too
+	hints := append([]*hint.Hint{{Type: hint.TypeMaster}}, rcontext.Hints(ctx)...)
+	ctx = rcontext.WithHints(ctx, hints)
+
 	res, err := conn.Query(ctx, s.db, sb.String(), s.ToArgs(indexes)...)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -90,7 +96,7 @@ func (s *ShowProcessListPlan) ExecIn(ctx context.Context, conn proto.VConn) (pro
 	if len(strs) < 2 {
 		return nil, fmt.Errorf("can get the id of sub database")
 	}
-	groupId, err := strconv.ParseInt(strs[1], 10, 64)
+	groupId, err := strconv.ParseInt(strs[len(strs)-1], 10, 64)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -27,8 +27,13 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/mysql/thead"
 	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/plan"
 )
 
@@ -36,7 +41,8 @@ var _ proto.Plan = (*ShowVariablesPlan)(nil)
 
 type ShowVariablesPlan struct {
 	plan.BasePlan
-	stmt *ast.ShowVariables
+	stmt        *ast.ShowVariables
+	RuleVersion uint64 // the version of active sharding rule
 }
 
 func NewShowVariablesPlan(stmt *ast.ShowVariables) *ShowVariablesPlan {
@@ -55,8 +61,64 @@ func (s *ShowVariablesPlan) ExecIn(ctx context.Context, vConn proto.VConn) (prot
 	ctx, span := plan.Tracer.Start(ctx, "ShowVariablesPlan.ExecIn")
 	defer span.End()
 
+	// answer locally if the target is a variable managed by arana, eg: SHOW VARIABLES LIKE 'arana_hint'
+	if like, ok := s.stmt.Like(); ok && (rcontext.IsSessionVariable(like) || strings.EqualFold(like, rcontext.VarRuleVersion)) {
+		value, _ := rcontext.SessionVariable(ctx, like)
+		if strings.EqualFold(like, rcontext.VarRuleVersion) {
+			value = proto.NewValueUint64(s.RuleVersion)
+		}
+		fields := thead.Variable.ToFields()
+		ds := &dataset.VirtualDataset{
+			Columns: fields,
+			Rows: []proto.Row{
+				rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueString(strings.ToLower(like)),
+					proto.NewValueString(value.String()),
+				}),
+			},
+		}
+		return resultx.New(resultx.WithDataset(ds)), nil
+	}
+
 	if err := s.stmt.Restore(ast.RestoreDefault, &sb, &args); err != nil {
 		return nil, errors.Wrap(err, "failed to execute show variables statement")
 	}
-	return vConn.Query(ctx, "", sb.String(), s.ToArgs(args)...)
+
+	res, err := vConn.Query(ctx, "", sb.String(), s.ToArgs(args)...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	fields, err := ds.Fields()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// overwrite the values of session variables managed by arana
+	ds = dataset.Pipe(ds, dataset.Map(nil, func(next proto.Row) (proto.Row, error) {
+		dest := make([]proto.Value, len(fields))
+		if err := next.Scan(dest); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if dest[0] == nil {
+			return next, nil
+		}
+		value, ok := rcontext.SessionVariable(ctx, dest[0].String())
+		if !ok {
+			return next, nil
+		}
+
+		dest[1] = proto.NewValueString(value.String())
+		if next.IsBinary() {
+			return rows.NewBinaryVirtualRow(fields, dest), nil
+		}
+		return rows.NewTextVirtualRow(fields, dest), nil
+	}))
+
+	return resultx.New(resultx.WithDataset(ds)), nil
 }
@@ -27,8 +27,12 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/mysql/thead"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/resultx"
 	"github.com/arana-db/arana/pkg/runtime/ast"
 	"github.com/arana-db/arana/pkg/runtime/plan"
 )
@@ -39,6 +43,8 @@ type ShowWarningsPlan struct {
 	plan.BasePlan
 	Stmt   *ast.ShowWarnings
 	Shards rule.DatabaseTables
+	// Warnings is the warnings of the last statement generated by arana, they will be returned without querying backend.
+	Warnings []*proto.Warning
 }
 
 func NewShowWarningsPlan(stmt *ast.ShowWarnings, shards rule.DatabaseTables) *ShowWarningsPlan {
@@ -62,6 +68,10 @@ func (s *ShowWarningsPlan) ExecIn(ctx context.Context, vConn proto.VConn) (proto
 	ctx, span := plan.Tracer.Start(ctx, "ShowWarningsPlan.ExecIn")
 	defer span.End()
 
+	if len(s.Warnings) > 0 {
+		return s.localWarnings(), nil
+	}
+
 	if err := s.Stmt.Restore(ast.RestoreDefault, &sb, &args); err != nil {
 		return nil, errors.Wrap(err, "failed to execute SHOW WARNINGS statement")
 	}
@@ -79,3 +89,18 @@ func (s *ShowWarningsPlan) ExecIn(ctx context.Context, vConn proto.VConn) (proto
 
 	return ret, nil
 }
+
+func (s *ShowWarningsPlan) localWarnings() proto.Result {
+	fields := thead.Warnings.ToFields()
+	ds := &dataset.VirtualDataset{
+		Columns: fields,
+	}
+	for _, it := range s.Warnings {
+		ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+			proto.NewValueString(it.Level),
+			proto.NewValueInt64(int64(it.Code)),
+			proto.NewValueString(it.Message),
+		}))
+	}
+	return resultx.New(resultx.WithDataset(ds))
+}
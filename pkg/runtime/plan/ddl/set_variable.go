@@ -27,8 +27,11 @@ import (
 )
 
 import (
+	mConstants "github.com/arana-db/arana/pkg/constants/mysql"
 	errors2 "github.com/arana-db/arana/pkg/mysql/errors"
 	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/hint"
+	"github.com/arana-db/arana/pkg/proto/rule"
 	"github.com/arana-db/arana/pkg/resultx"
 	"github.com/arana-db/arana/pkg/runtime/ast"
 	rcontext "github.com/arana-db/arana/pkg/runtime/context"
@@ -42,6 +45,7 @@ var _ proto.Plan = (*SetVariablePlan)(nil)
 type SetVariablePlan struct {
 	plan.BasePlan
 	Stmt *ast.SetStatement
+	Rule *rule.Rule // used to validate the sharding strategy
 }
 
 func (d *SetVariablePlan) Type() proto.PlanType {
@@ -130,6 +134,22 @@ func (d *SetVariablePlan) nextVars(ctx context.Context) (map[string]proto.Value,
 			return nil, errors.New("setting of global variable is not unsupported yet")
 		}
 
+		// reject the invalid hint early, or all the subsequent statements will fail
+		if s := strings.TrimSpace(v.String()); next.System && strings.EqualFold(next.Name, rcontext.VarHint) && len(s) > 0 {
+			if _, err = hint.Parse(s); err != nil {
+				return nil, errors2.NewSQLError(mConstants.ERWrongValueForVar, mConstants.SS42000,
+					"Variable '%s' can't be set to the value of '%s'", next.Name, v.String())
+			}
+		}
+
+		// the unknown sharding strategy would be ignored silently, reject it
+		if s := v.String(); next.System && strings.EqualFold(next.Name, rcontext.VarShardStrategy) && len(s) > 0 {
+			if d.Rule == nil || !d.Rule.HasShardStrategy(s) {
+				return nil, errors2.NewSQLError(mConstants.ERWrongValueForVar, mConstants.SS42000,
+					"Variable '%s' can't be set to the value of '%s'", next.Name, s)
+			}
+		}
+
 		key.WriteByte('@')
 		if next.System {
 			key.WriteByte('@')
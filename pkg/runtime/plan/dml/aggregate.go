@@ -27,11 +27,14 @@ import (
 
 import (
 	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/merge"
+	"github.com/arana-db/arana/pkg/merge/aggregator"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/reduce"
 	"github.com/arana-db/arana/pkg/resultx"
 	"github.com/arana-db/arana/pkg/runtime/ast"
-	"github.com/arana-db/arana/pkg/runtime/optimize/dml/ext"
+	"github.com/arana-db/arana/pkg/runtime/ast/ext"
+	"github.com/arana-db/arana/pkg/runtime/misc/extvalue"
 	"github.com/arana-db/arana/pkg/runtime/plan"
 )
 
@@ -44,7 +47,7 @@ func (ap *AggregatePlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Re
 	ctx, span := plan.Tracer.Start(ctx, "AggregatePlan.ExecIn")
 	defer span.End()
 
-	reds, err := ap.probe()
+	reds, aggrs, err := ap.probe()
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -59,41 +62,101 @@ func (ap *AggregatePlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Re
 		return nil, errors.WithStack(err)
 	}
 
-	return resultx.New(resultx.WithDataset(dataset.Pipe(ds, dataset.Reduce(reds)))), nil
+	fields, err := ds.Fields()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	identity := identityRow(ctx, ap.Fields, len(fields))
+	return resultx.New(resultx.WithDataset(dataset.Pipe(ds, dataset.ReduceWithAggregators(reds, aggrs, identity)))), nil
 }
 
-func (ap *AggregatePlan) probe() (map[int]reduce.Reducer, error) {
-	aggrTable := make(map[int]reduce.Reducer)
+// probe returns the reducers of aggregated fields, and the aggregators of fields which cannot be reduced pairwise, eg: VAR_POP.
+func (ap *AggregatePlan) probe() (map[int]reduce.Reducer, map[int]merge.Aggregator, error) {
+	var (
+		aggrTable = make(map[int]reduce.Reducer)
+		mergers   map[int]merge.Aggregator
+	)
 	for i, field := range ap.Fields {
-		var fun ast.Node
-		switch x := field.(type) {
-		case *ext.MappingSelectElement:
+		aggr := aggregator.AggregateOf(field)
+		if aggr == nil {
 			continue
-		case ext.SelectElementProvider:
-			switch y := x.Prev().(type) {
-			case *ast.SelectElementFunction:
-				fun = y.Function()
+		}
+
+		if aggr.Name() == ast.AggrVarPop {
+			if mergers == nil {
+				mergers = make(map[int]merge.Aggregator)
 			}
-		case *ast.SelectElementFunction:
-			fun = x.Function()
+			mergers[i] = aggregator.LoadAggs(ap.Fields)[i]()
+			continue
+		}
+
+		red, err := reducerOf(aggr.Name())
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
 		}
+		aggrTable[i] = red
+	}
 
-		aggr, ok := fun.(*ast.AggrFunction)
-		if !ok {
+	return aggrTable, mergers, nil
+}
+
+func reducerOf(name string) (reduce.Reducer, error) {
+	switch name {
+	case ast.AggrMin:
+		return reduce.Min(), nil
+	case ast.AggrMax:
+		return reduce.Max(), nil
+	case ast.AggrSum:
+		return reduce.Sum(), nil
+	case ast.AggrCount:
+		return reduce.Count(), nil
+	case ast.AggrBitAnd:
+		return reduce.BitAnd(), nil
+	case ast.AggrBitOr:
+		return reduce.BitOr(), nil
+	case ast.AggrBitXor:
+		return reduce.BitXor(), nil
+	default:
+		return nil, errors.Errorf("invalid aggregate %s", name)
+	}
+}
+
+// identityRow returns the row of an aggregation without GROUP BY if there are no rows at all,
+// eg: `SELECT COUNT(DISTINCT uid), 'x' FROM student` returns (0, 'x').
+// The aggregates are their identities, the other columns are computed as constants, or NULL if they are not.
+func identityRow(ctx context.Context, fields []ast.SelectElement, width int) []proto.Value {
+	ret := make([]proto.Value, width)
+	for i := 0; i < len(fields) && i < width; i++ {
+		field := fields[i]
+		if _, ok := field.(*ext.MappingSelectElement); ok {
 			continue
 		}
+		if p, ok := field.(ext.SelectElementProvider); ok {
+			field = p.Prev()
+		}
 
-		switch aggr.Name() {
-		case ast.AggrMin:
-			aggrTable[i] = reduce.Min()
-		case ast.AggrMax:
-			aggrTable[i] = reduce.Max()
-		case ast.AggrSum, ast.AggrCount:
-			aggrTable[i] = reduce.Sum()
+		var node ast.Node
+		switch x := field.(type) {
+		case *ast.SelectElementFunction:
+			if aggr, ok := x.Function().(*ast.AggrFunction); ok {
+				if red, err := reducerOf(aggr.Name()); err == nil {
+					if v, ok := reduce.Identity(red); ok {
+						ret[i] = proto.NewValueDecimal(v)
+					}
+				}
+				continue
+			}
+			node = x.Function()
+		case *ast.SelectElementExpr:
+			node = x.Expression()
 		default:
-			return nil, errors.Errorf("invalid aggregate %s", aggr.Name())
+			continue
 		}
-	}
 
-	return aggrTable, nil
+		if v, err := extvalue.Compute(ctx, node); err == nil {
+			ret[i] = v
+		}
+	}
+	return ret
 }
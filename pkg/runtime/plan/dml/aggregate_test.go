@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/merge"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+)
+
+// fakeShardsPlan returns one row of partial aggregates for each shard.
+type fakeShardsPlan struct {
+	fields []proto.Field
+	shards [][]proto.Value
+}
+
+func (f *fakeShardsPlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (f *fakeShardsPlan) ExecIn(_ context.Context, _ proto.VConn) (proto.Result, error) {
+	ds := &dataset.VirtualDataset{Columns: f.fields}
+	for _, it := range f.shards {
+		ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(f.fields, it))
+	}
+	return resultx.New(resultx.WithDataset(ds)), nil
+}
+
+func TestAggregatePlan(t *testing.T) {
+	_, stmt := ast.MustParse("SELECT COUNT(*), SUM(score), MAX(score), BIT_OR(flag), name, 'x' FROM student")
+	fields := []proto.Field{
+		mysql.NewField("COUNT(*)", consts.FieldTypeLongLong),
+		mysql.NewField("SUM(score)", consts.FieldTypeNewDecimal),
+		mysql.NewField("MAX(score)", consts.FieldTypeLong),
+		mysql.NewField("BIT_OR(flag)", consts.FieldTypeLongLong),
+		mysql.NewField("name", consts.FieldTypeVarString),
+		mysql.NewField("x", consts.FieldTypeVarString),
+	}
+
+	shard := func(count, sum, max, flag int64, name string) []proto.Value {
+		return []proto.Value{
+			proto.NewValueInt64(count),
+			proto.NewValueInt64(sum),
+			proto.NewValueInt64(max),
+			proto.NewValueInt64(flag),
+			proto.NewValueString(name),
+			proto.NewValueString("x"),
+		}
+	}
+
+	for _, it := range []struct {
+		name   string
+		shards [][]proto.Value
+		expect []string
+	}{
+		{"zero shards", nil, []string{"0", "NULL", "NULL", "0", "NULL", "x"}},
+		{"one shard", [][]proto.Value{shard(2, 30, 20, 0b01, "foo")}, []string{"2", "30", "20", "1", "foo", "x"}},
+		{"many shards", [][]proto.Value{
+			shard(2, 30, 20, 0b01, "foo"),
+			shard(3, 90, 40, 0b10, "bar"),
+			shard(1, 5, 5, 0b01, "qux"),
+		}, []string{"6", "125", "40", "3", "foo", "x"}},
+		{"shards without rows", [][]proto.Value{
+			{proto.NewValueInt64(0), nil, nil, proto.NewValueInt64(0), nil, proto.NewValueString("x")},
+			{proto.NewValueInt64(0), nil, nil, proto.NewValueInt64(0), nil, proto.NewValueString("x")},
+		}, []string{"0", "NULL", "NULL", "0", "NULL", "x"}},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			p := &AggregatePlan{
+				Plan:   &fakeShardsPlan{fields: fields, shards: it.shards},
+				Fields: stmt.(*ast.SelectStatement).Select,
+			}
+			res, err := p.ExecIn(context.Background(), nil)
+			assert.NoError(t, err)
+
+			values := drainJoinResult(t, res)
+			assert.Len(t, values, 1)
+
+			actual := make([]string, 0, len(values[0]))
+			for _, v := range values[0] {
+				if v == nil {
+					actual = append(actual, "NULL")
+				} else {
+					actual = append(actual, v.String())
+				}
+			}
+			assert.Equal(t, it.expect, actual)
+		})
+	}
+}
+
+func TestGroupPlan_EmptyWithoutGroupBy(t *testing.T) {
+	_, stmt := ast.MustParse("SELECT COUNT(DISTINCT uid), SUM(DISTINCT score), 'x' FROM student")
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+		mysql.NewField("score", consts.FieldTypeLong),
+		mysql.NewField("x", consts.FieldTypeVarString),
+	}
+
+	p := &GroupPlan{
+		Plan:              &fakeShardsPlan{fields: fields},
+		AggItems:          map[int]func() merge.Aggregator{},
+		Fields:            stmt.(*ast.SelectStatement).Select,
+		OriginColumnCount: 3,
+	}
+	res, err := p.ExecIn(context.Background(), nil)
+	assert.NoError(t, err)
+
+	values := drainJoinResult(t, res)
+	assert.Len(t, values, 1)
+	assert.Equal(t, "0", values[0][0].String())
+	assert.Nil(t, values[0][1])
+	assert.Equal(t, "x", values[0][2].String())
+}
@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/resultx"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+	"github.com/arana-db/arana/pkg/runtime/plan"
+)
+
+var _ proto.Plan = (*ApproxCountPlan)(nil)
+
+// ApproxCountPlan estimates the count of rows by the statistics of 'information_schema.TABLES' instead of scanning
+// the shards, which is fast but approximate, eg: the estimate of InnoDB may vary from the actual count by 40% to 50%.
+type ApproxCountPlan struct {
+	Table  string // the logical table name
+	Shards rule.DatabaseTables
+	Column string // the column name of result
+}
+
+func (ap *ApproxCountPlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (ap *ApproxCountPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	ctx, span := plan.Tracer.Start(ctx, "ApproxCountPlan.ExecIn")
+	defer span.End()
+
+	dbs := make([]string, 0, len(ap.Shards))
+	for db := range ap.Shards {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+
+	var total int64
+	for _, db := range dbs {
+		counts, err := EstimateTableRows(ctx, conn, db, ap.Shards[db])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to estimate the count of table '%s' in %s", ap.Table, db)
+		}
+		for _, n := range counts {
+			total += n
+		}
+	}
+
+	rcontext.AddWarning(ctx, consts.ERUnknownError, "the count of table '%s' is approximate, which is estimated by information_schema.TABLES", ap.Table)
+
+	fields := []proto.Field{
+		mysql.NewField(ap.Column, consts.FieldTypeLongLong),
+	}
+	ds := &dataset.VirtualDataset{
+		Columns: fields,
+		Rows: []proto.Row{
+			rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(total)}),
+		},
+	}
+	return resultx.New(resultx.WithDataset(ds)), nil
+}
+
+// EstimateTableRows estimates the rows of the physical tables in the database by the statistics of
+// 'information_schema.TABLES' with one query, the tables without statistics are absent from the result, eg: views.
+func EstimateTableRows(ctx context.Context, conn proto.VConn, db string, tables []string) (map[string]int64, error) {
+	var (
+		sb   strings.Builder
+		args = make([]proto.Value, 0, len(tables))
+	)
+	sb.WriteString("SELECT TABLE_NAME, TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME IN (")
+	for i, table := range tables {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('?')
+		args = append(args, proto.NewValueString(table))
+	}
+	sb.WriteByte(')')
+
+	res, err := conn.Query(ctx, db, sb.String(), args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = ds.Close()
+	}()
+
+	ret := make(map[string]int64, len(tables))
+	dest := make([]proto.Value, 2)
+	for {
+		next, err := ds.Next()
+		if errors.Is(err, io.EOF) {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err = next.Scan(dest); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// the TABLE_ROWS is NULL for views
+		if dest[0] == nil || dest[1] == nil {
+			continue
+		}
+		n, err := dest[1].Int64()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		ret[dest[0].String()] = n
+	}
+}
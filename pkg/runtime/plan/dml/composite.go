@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 )
 
 import (
@@ -28,9 +29,12 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/constants/mysql"
 	"github.com/arana-db/arana/pkg/dataset"
+	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/resultx"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/plan"
 	"github.com/arana-db/arana/pkg/util/log"
 )
@@ -38,6 +42,10 @@ import (
 // CompositePlan merges multiple query plan.
 type CompositePlan struct {
 	Plans []proto.Plan
+	// SkipMissingTables skips the query plans whose physical tables don't exist, instead of failing the whole query.
+	SkipMissingTables bool
+	// BestEffort omits the failed query plans with warnings, the rows of the healthy shards will be returned.
+	BestEffort bool
 }
 
 func (u CompositePlan) Type() proto.PlanType {
@@ -114,14 +122,22 @@ func (u CompositePlan) query(ctx context.Context, conn proto.VConn) (proto.Resul
 	for _, it := range u.Plans {
 		it := it
 		generators = append(generators, func() (proto.Dataset, error) {
-			res, err := it.ExecIn(ctx, conn)
-			if err != nil {
-				return nil, errors.WithStack(err)
-			}
-			return res.Dataset()
+			return traceShard(ctx, it, conn)
 		})
 	}
 
+	if u.BestEffort {
+		var err error
+		if generators, err = u.bestEffort(ctx, generators); err != nil {
+			return nil, err
+		}
+	} else if u.SkipMissingTables {
+		var err error
+		if generators, err = u.skipMissingTables(ctx, generators); err != nil {
+			return nil, err
+		}
+	}
+
 	ds, err := dataset.Fuse(generators[0], generators[1:]...)
 	if err != nil {
 		log.Errorf("CompositePlan Fuse error:%v", err)
@@ -130,6 +146,126 @@ func (u CompositePlan) query(ctx context.Context, conn proto.VConn) (proto.Resul
 	return resultx.New(resultx.WithDataset(ds)), nil
 }
 
+// skipMissingTables wraps the generators, the datasets of missing tables will be replaced with empty ones.
+func (u CompositePlan) skipMissingTables(ctx context.Context, generators []dataset.GenerateFunc) ([]dataset.GenerateFunc, error) {
+	plans := u.Plans
+
+	skip := func(p proto.Plan, err error) {
+		log.Warnf("skip missing physical table: %s, err=%v", describePlanShards(p), err)
+		rcontext.AddWarning(ctx, mysql.ERUnknownError, "missing physical table %s is skipped", describePlanShards(p))
+	}
+
+	// the fields are determined by the first dataset, so the leading missing tables should be skipped eagerly,
+	// the last one will be kept to report the error if all tables are missing.
+	for len(generators) > 1 {
+		ds, err := generators[0]()
+		if err == nil {
+			generators[0] = func() (proto.Dataset, error) {
+				return ds, nil
+			}
+			break
+		}
+		if !isNoSuchTableErr(err) {
+			return nil, err
+		}
+		skip(plans[0], err)
+		generators, plans = generators[1:], plans[1:]
+	}
+
+	for i := 1; i < len(generators); i++ {
+		gen, p := generators[i], plans[i]
+		generators[i] = func() (proto.Dataset, error) {
+			ds, err := gen()
+			if err != nil && isNoSuchTableErr(err) {
+				skip(p, err)
+				return &dataset.VirtualDataset{}, nil
+			}
+			return ds, err
+		}
+	}
+
+	return generators, nil
+}
+
+// bestEffort wraps the generators, the failed shards will be omitted with warnings instead of failing the whole query.
+// The cancellation of client is never ignored, and the error will be reported if all shards fail.
+func (u CompositePlan) bestEffort(ctx context.Context, generators []dataset.GenerateFunc) ([]dataset.GenerateFunc, error) {
+	plans := u.Plans
+
+	omit := func(p proto.Plan, err error) bool {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return false
+		}
+		log.Warnf("omit failed shard from partial results: %s, err=%v", describePlanShards(p), err)
+		rcontext.AddWarning(ctx, mysql.ERUnknownError, "shard %s is omitted from partial results: %v", describePlanShards(p), errors.Cause(err))
+		return true
+	}
+
+	wrap := func(p proto.Plan, ds proto.Dataset) proto.Dataset {
+		return &bestEffortDataset{Dataset: ds, omit: func(err error) bool {
+			return omit(p, err)
+		}}
+	}
+
+	// same as skipping missing tables, the leading failed shards should be omitted eagerly.
+	for len(generators) > 1 {
+		ds, err := generators[0]()
+		if err == nil {
+			ds = wrap(plans[0], ds)
+			generators[0] = func() (proto.Dataset, error) {
+				return ds, nil
+			}
+			break
+		}
+		if !omit(plans[0], err) {
+			return nil, err
+		}
+		generators, plans = generators[1:], plans[1:]
+	}
+
+	for i := 1; i < len(generators); i++ {
+		gen, p := generators[i], plans[i]
+		generators[i] = func() (proto.Dataset, error) {
+			ds, err := gen()
+			if err != nil {
+				if omit(p, err) {
+					return &dataset.VirtualDataset{}, nil
+				}
+				return nil, err
+			}
+			return wrap(p, ds), nil
+		}
+	}
+
+	return generators, nil
+}
+
+// bestEffortDataset stops reading the shard silently once it fails, the rows which have been read are kept.
+type bestEffortDataset struct {
+	proto.Dataset
+	omit func(error) bool
+}
+
+func (b *bestEffortDataset) Next() (proto.Row, error) {
+	row, err := b.Dataset.Next()
+	if err != nil && !errors.Is(err, io.EOF) && b.omit(err) {
+		return nil, io.EOF
+	}
+	return row, err
+}
+
+func isNoSuchTableErr(err error) bool {
+	sqlErr, ok := errors.Cause(err).(*mysqlErrors.SQLError)
+	return ok && sqlErr.Number() == mysql.ERNoSuchTable
+}
+
+func describePlanShards(p proto.Plan) string {
+	if sp, ok := p.(*SimpleQueryPlan); ok {
+		return fmt.Sprintf("%s.%s", sp.Database, strings.Join(sp.Tables, ","))
+	}
+	return fmt.Sprintf("%T", p)
+}
+
 func (u CompositePlan) exec(ctx context.Context, conn proto.VConn) (proto.Result, error) {
 	var id, affects uint64
 	for _, it := range u.Plans {
@@ -0,0 +1,294 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+import (
+	"github.com/golang/mock/gomock"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/mysql"
+	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+	"github.com/arana-db/arana/testdata"
+)
+
+func TestCompositePlan_SkipMissingTables(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			if db != "employees_0001" {
+				return nil, mysqlErrors.NewSQLError(consts.ERNoSuchTable, consts.SSNoTableSelected, "Table '%s.student' doesn't exist", db)
+			}
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+				Rows: []proto.Row{
+					rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(1)}),
+				},
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	newPlan := func(skip bool, databases ...string) CompositePlan {
+		ret := CompositePlan{
+			SkipMissingTables: skip,
+		}
+		for _, db := range databases {
+			_, stmt, _ := ast.ParseSelect("select uid from student")
+			ret.Plans = append(ret.Plans, &SimpleQueryPlan{
+				Database: db,
+				Tables:   []string{"student"},
+				Stmt:     stmt,
+			})
+		}
+		return ret
+	}
+
+	count := func(res proto.Result) int {
+		ds, err := res.Dataset()
+		assert.NoError(t, err)
+		var n int
+		for {
+			_, err := ds.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			n++
+		}
+		return n
+	}
+
+	// fail the whole query by default
+	_, err := newPlan(false, "employees_0000", "employees_0001").ExecIn(context.Background(), conn)
+	assert.Error(t, err)
+
+	ctx := rcontext.WithWarnings(context.Background())
+	res, err := newPlan(true, "employees_0000", "employees_0001", "employees_0002").ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count(res))
+
+	// the skipped tables are reported to the client
+	warnings := rcontext.Warnings(ctx)
+	if assert.Len(t, warnings, 2) {
+		assert.Contains(t, warnings[0].Message, "employees_0000.student")
+		assert.Contains(t, warnings[1].Message, "employees_0002.student")
+	}
+
+	// all tables are missing
+	_, err = newPlan(true, "employees_0000", "employees_0002").ExecIn(context.Background(), conn)
+	assert.Error(t, err)
+}
+
+func TestCompositePlan_BestEffort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			if db == "employees_0000" || db == "employees_0002" {
+				return nil, context.DeadlineExceeded
+			}
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+				Rows: []proto.Row{
+					rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(1)}),
+				},
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	newPlan := func(bestEffort bool, databases ...string) CompositePlan {
+		ret := CompositePlan{
+			BestEffort: bestEffort,
+		}
+		for _, db := range databases {
+			_, stmt, _ := ast.ParseSelect("select uid from student")
+			ret.Plans = append(ret.Plans, &SimpleQueryPlan{
+				Database: db,
+				Tables:   []string{"student"},
+				Stmt:     stmt,
+			})
+		}
+		return ret
+	}
+
+	// all-or-nothing by default
+	_, err := newPlan(false, "employees_0000", "employees_0001").ExecIn(context.Background(), conn)
+	assert.Error(t, err)
+
+	ctx := rcontext.WithWarnings(context.Background())
+	res, err := newPlan(true, "employees_0000", "employees_0001", "employees_0002", "employees_0003").ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+	var n int
+	for {
+		_, err := ds.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		n++
+	}
+	assert.Equal(t, 2, n)
+
+	warnings := rcontext.Warnings(ctx)
+	if assert.Len(t, warnings, 2) {
+		assert.Contains(t, warnings[0].Message, "employees_0000.student")
+		assert.Contains(t, warnings[1].Message, "employees_0002.student")
+	}
+
+	// all shards fail
+	_, err = newPlan(true, "employees_0000", "employees_0002").ExecIn(context.Background(), conn)
+	assert.Error(t, err)
+
+	// the cancellation of client should not be ignored
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = newPlan(true, "employees_0000", "employees_0001").ExecIn(cancelled, conn)
+	assert.Error(t, err)
+}
+
+func TestCompositePlan_TraceShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fields := []proto.Field{
+		mysql.NewField("uid", consts.FieldTypeLongLong),
+	}
+
+	amounts := map[string]int{
+		"employees_0000": 2,
+		"employees_0001": 1,
+	}
+	conn := testdata.NewMockVConn(ctrl)
+	conn.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, db string, sql string, args ...interface{}) (proto.Result, error) {
+			ds := &dataset.VirtualDataset{
+				Columns: fields,
+			}
+			for i := 0; i < amounts[db]; i++ {
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{proto.NewValueInt64(int64(i))}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), nil
+		}).
+		AnyTimes()
+
+	var p CompositePlan
+	for _, db := range []string{"employees_0000", "employees_0001"} {
+		_, stmt, _ := ast.ParseSelect("select uid from student")
+		p.Plans = append(p.Plans, &SimpleQueryPlan{
+			Database: db,
+			Tables:   []string{"student_0000", "student_0001"},
+			Stmt:     stmt,
+		})
+	}
+
+	// the spans are no-op until a TracerProvider is registered
+	res, err := p.ExecIn(context.Background(), conn)
+	assert.NoError(t, err)
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+	_, err = ds.Fields()
+	assert.NoError(t, err)
+	_, err = ds.Next()
+	assert.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer func() {
+		_ = tp.Shutdown(context.Background())
+	}()
+	otel.SetTracerProvider(tp)
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "query")
+	res, err = p.ExecIn(ctx, conn)
+	assert.NoError(t, err)
+	ds, err = res.Dataset()
+	assert.NoError(t, err)
+	for {
+		if _, err = ds.Next(); err != nil {
+			break
+		}
+	}
+	assert.Equal(t, io.EOF, err)
+	root.End()
+
+	var (
+		composite trace.SpanContext
+		shards    []sdktrace.ReadOnlySpan
+	)
+	for _, it := range recorder.Ended() {
+		switch it.Name() {
+		case "CompositePlan.ExecIn":
+			assert.Equal(t, root.SpanContext().SpanID(), it.Parent().SpanID())
+			composite = it.SpanContext()
+		case "CompositePlan.shard":
+			shards = append(shards, it)
+		}
+	}
+	assert.Len(t, shards, 2)
+
+	actual := make(map[string]int64)
+	for _, it := range shards {
+		assert.Equal(t, composite.SpanID(), it.Parent().SpanID())
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range it.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		assert.Equal(t, []string{"student_0000", "student_0001"}, attrs["shard.tables"].AsStringSlice())
+		assert.Contains(t, attrs, attribute.Key("shard.duration_ms"))
+		actual[attrs["shard.database"].AsString()] = attrs["shard.rows"].AsInt64()
+	}
+	assert.Equal(t, map[string]int64{"employees_0000": 2, "employees_0001": 1}, actual)
+}
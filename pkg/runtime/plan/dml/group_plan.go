@@ -28,8 +28,11 @@ import (
 import (
 	"github.com/arana-db/arana/pkg/dataset"
 	"github.com/arana-db/arana/pkg/merge"
+	"github.com/arana-db/arana/pkg/merge/aggregator"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 )
 
 // GroupPlan TODO now only support stmt which group by items equal with order by items, such as
@@ -38,6 +41,8 @@ type GroupPlan struct {
 	Plan       proto.Plan
 	AggItems   map[int]func() merge.Aggregator
 	GroupItems []dataset.OrderByItem
+	// Fields is the select list, it is used to generate the only row of the aggregation without GROUP BY if there are no rows at all.
+	Fields []ast.SelectElement
 
 	OriginColumnCount int
 }
@@ -61,13 +66,34 @@ func (g *GroupPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result,
 		return nil, errors.WithStack(err)
 	}
 
-	return resultx.New(resultx.WithDataset(dataset.Pipe(ds, dataset.GroupReduce(
+	// the distinct values are held in memory until the group is reduced
+	quota := rcontext.GetMemoryQuota(ctx)
+	aggItems := make(map[int]func() merge.Aggregator, len(g.AggItems))
+	for i, it := range g.AggItems {
+		newAggregator := it
+		aggItems[i] = func() merge.Aggregator {
+			agg := newAggregator()
+			if d, ok := agg.(*aggregator.DistinctAggregator); ok && quota != nil {
+				d.Tracker = quota
+			}
+			return agg
+		}
+	}
+
+	options := []dataset.Option{dataset.GroupReduce(
 		g.GroupItems,
 		func(fields []proto.Field) []proto.Field {
 			return fields[0:g.OriginColumnCount]
 		},
 		func() dataset.Reducer {
-			return dataset.NewGroupReducer(g.AggItems, fields, g.OriginColumnCount)
+			return dataset.NewGroupReducer(aggItems, fields, g.OriginColumnCount)
 		},
-	)))), nil
+	)}
+	if len(g.GroupItems) == 0 && g.Fields != nil {
+		options = append(options, dataset.DefaultRow(func(fields []proto.Field) []proto.Value {
+			return identityRow(ctx, g.Fields, len(fields))
+		}))
+	}
+
+	return resultx.New(resultx.WithDataset(dataset.Pipe(ds, options...))), nil
 }
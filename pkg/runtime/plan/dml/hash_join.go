@@ -36,6 +36,7 @@ import (
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/resultx"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/plan"
 	"github.com/arana-db/arana/third_party/base58"
 )
@@ -96,6 +97,7 @@ func (h *HashJoinPlan) build(ctx context.Context, conn proto.VConn) (proto.Datas
 	}
 	cn := h.BuildKey
 	xh := xxhash.New()
+	quota := rcontext.GetMemoryQuota(ctx)
 	h.hashArea = make(map[string]proto.Row)
 	// build map
 	for {
@@ -112,6 +114,10 @@ func (h *HashJoinPlan) build(ctx context.Context, conn proto.VConn) (proto.Datas
 		}
 
 		if value != nil {
+			// the rows of build side are held in memory until the probe is done
+			if err = quota.Consume(int64(next.Length())); err != nil {
+				return nil, errors.WithStack(err)
+			}
 			_, _ = xh.WriteString(value.String())
 			h.hashArea[base58.Encode(xh.Sum(nil))] = next
 		}
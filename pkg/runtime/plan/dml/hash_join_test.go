@@ -101,14 +101,14 @@ func TestHashJoinPlan(t *testing.T) {
 	// sql: select * from student join salaries on uid = emp_no;
 	plan := &HashJoinPlan{
 		BuildPlan: CompositePlan{
-			[]proto.Plan{
+			Plans: []proto.Plan{
 				&SimpleQueryPlan{
 					Stmt: stmt1,
 				},
 			},
 		},
 		ProbePlan: CompositePlan{
-			[]proto.Plan{
+			Plans: []proto.Plan{
 				&SimpleQueryPlan{
 					Stmt: stmt2,
 				},
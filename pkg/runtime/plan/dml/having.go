@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/misc/extvalue"
+)
+
+var _ proto.Plan = (*HavingPlan)(nil)
+
+// HavingPlan filters the merged groups by the HAVING condition, which cannot be pushed down to shards
+// since the aggregate values of each shard are partial.
+type HavingPlan struct {
+	ParentPlan proto.Plan
+	Having     ast.ExpressionNode
+	// Aggregates maps the aggregate functions of HAVING to the fields which hold the computed values,
+	// eg: 'COUNT(*)' => 'c' for 'SELECT COUNT(*) c ... HAVING COUNT(*) > 5'.
+	Aggregates map[string]string
+	Args       []proto.Value
+}
+
+func (hp *HavingPlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (hp *HavingPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	if hp.ParentPlan == nil {
+		return nil, errors.New("having plan: ParentPlan is nil")
+	}
+
+	res, err := hp.ParentPlan.ExecIn(ctx, conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	fields, err := ds.Fields()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return resultx.New(resultx.WithDataset(&havingDataset{
+		Dataset: ds,
+		ctx:     ctx,
+		fields:  fields,
+		plan:    hp,
+	})), nil
+}
+
+type havingDataset struct {
+	proto.Dataset
+	ctx    context.Context
+	fields []proto.Field
+	plan   *HavingPlan
+}
+
+func (hd *havingDataset) Next() (proto.Row, error) {
+	values := make([]proto.Value, len(hd.fields))
+	for {
+		row, err := hd.Dataset.Next()
+		if err != nil {
+			return nil, err
+		}
+		if err = row.Scan(values); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		m := make(map[string]proto.Value, len(hd.fields)+len(hd.plan.Aggregates))
+		for i := range hd.fields {
+			m[hd.fields[i].Name()] = values[i]
+		}
+		for aggr, field := range hd.plan.Aggregates {
+			m[aggr] = m[field]
+		}
+
+		ok, err := extvalue.ComputeRow(hd.ctx, hd.plan.Having, m, hd.plan.Args...)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// the NULL condition is treated as false
+		if ok == nil {
+			continue
+		}
+		if b, err := ok.Bool(); err == nil && b {
+			return row, nil
+		}
+	}
+}
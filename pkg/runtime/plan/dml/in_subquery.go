@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"io"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/proto"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+)
+
+var _ proto.Plan = (*InSubqueryPlan)(nil)
+
+// InSubqueryPlan materializes the values of subquery first, then executes the plan built with the values.
+// For example: 'SELECT * FROM student WHERE uid IN (SELECT uid FROM premium WHERE tier = 'gold')', the shards of
+// student can be pruned by the values of uid returned by the subquery.
+type InSubqueryPlan struct {
+	Subquery proto.Plan
+	// Build builds the plan with the distinct non-null values of the first column of subquery.
+	Build func(values []proto.Value) (proto.Plan, error)
+	// MaxValues is the max count of values which can be materialized, zero means unlimited.
+	MaxValues int
+	// Fallback builds the plan which executes the subquery as it is, it is used if the subquery returns
+	// more than MaxValues values. The execution fails if it is nil.
+	Fallback func() (proto.Plan, error)
+}
+
+func (ip *InSubqueryPlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (ip *InSubqueryPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	values, ok, err := ip.materialize(ctx, conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var p proto.Plan
+	switch {
+	case ok:
+		p, err = ip.Build(values)
+	case ip.Fallback != nil:
+		rcontext.AddWarning(ctx, mysql.ERUnknownError, "the subquery of IN predicate returns more than %d values, which are not materialized", ip.MaxValues)
+		p, err = ip.Fallback()
+	default:
+		return nil, errors.Errorf("the subquery of IN predicate returns more than %d values", ip.MaxValues)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return p.ExecIn(ctx, conn)
+}
+
+// materialize returns the distinct values of subquery, false will be returned if there are more than MaxValues values.
+func (ip *InSubqueryPlan) materialize(ctx context.Context, conn proto.VConn) ([]proto.Value, bool, error) {
+	res, err := ip.Subquery.ExecIn(ctx, conn)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	defer func() {
+		_ = ds.Close()
+	}()
+
+	fields, err := ds.Fields()
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	if len(fields) != 1 {
+		return nil, false, errors.Errorf("the subquery of IN predicate should contain 1 column, actual=%d", len(fields))
+	}
+
+	var (
+		values []proto.Value
+		visits = make(map[string]struct{})
+		dest   = make([]proto.Value, 1)
+		quota  = rcontext.GetMemoryQuota(ctx)
+	)
+	for {
+		next, err := ds.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		if err = next.Scan(dest); err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		// NULL never matches any value
+		if dest[0] == nil {
+			continue
+		}
+		key := dest[0].String()
+		if _, ok := visits[key]; ok {
+			continue
+		}
+		if ip.MaxValues > 0 && len(values) >= ip.MaxValues {
+			return nil, false, nil
+		}
+		// the values are held in memory until the statement is rewritten
+		if err = quota.Consume(int64(next.Length())); err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		visits[key] = struct{}{}
+		values = append(values, dest[0])
+	}
+	return values, true, nil
+}
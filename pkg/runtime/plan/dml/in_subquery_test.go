@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/proto"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+)
+
+func TestInSubqueryPlan_MaxValues(t *testing.T) {
+	var (
+		fields = []proto.Field{mysql.NewField("uid", consts.FieldTypeLongLong)}
+		sub    = &fakeShardsPlan{fields: fields, shards: [][]proto.Value{
+			{proto.NewValueInt64(1)},
+			{proto.NewValueInt64(2)},
+			{proto.NewValueInt64(1)},
+			{proto.NewValueInt64(3)},
+		}}
+		built, fallback int
+	)
+
+	newPlan := func(maxValues int, withFallback bool) *InSubqueryPlan {
+		ret := &InSubqueryPlan{
+			Subquery:  sub,
+			MaxValues: maxValues,
+			Build: func(values []proto.Value) (proto.Plan, error) {
+				built = len(values)
+				return &fakeShardsPlan{fields: fields}, nil
+			},
+		}
+		if withFallback {
+			ret.Fallback = func() (proto.Plan, error) {
+				fallback++
+				return &fakeShardsPlan{fields: fields}, nil
+			}
+		}
+		return ret
+	}
+
+	// the duplicated values are counted only once
+	_, err := newPlan(3, false).ExecIn(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, built)
+	assert.Equal(t, 0, fallback)
+
+	ctx := rcontext.WithWarnings(context.Background())
+	_, err = newPlan(2, true).ExecIn(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fallback)
+	assert.Len(t, rcontext.Warnings(ctx), 1)
+
+	_, err = newPlan(2, false).ExecIn(context.Background(), nil)
+	assert.Error(t, err)
+}
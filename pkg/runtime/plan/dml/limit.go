@@ -37,6 +37,9 @@ type LimitPlan struct {
 	ParentPlan     proto.Plan
 	OriginOffset   int64
 	OverwriteLimit int64
+	// EarlyTermination stops reading the parent once the limit is satisfied, it should be enabled only if
+	// the parent is lazy and unordered, so that the remaining shards won't be queried at all.
+	EarlyTermination bool
 }
 
 func (limitPlan *LimitPlan) Type() proto.PlanType {
@@ -58,8 +61,13 @@ func (limitPlan *LimitPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto
 		return nil, errors.WithStack(err)
 	}
 
+	var options []dataset.Option
+	if limitPlan.EarlyTermination {
+		options = append(options, dataset.Limit(limitPlan.OverwriteLimit))
+	}
+
 	var count int64
-	ds = dataset.Pipe(ds, dataset.Filter(func(next proto.Row) bool {
+	options = append(options, dataset.Filter(func(next proto.Row) bool {
 		count++
 		if count < limitPlan.OriginOffset {
 			return false
@@ -69,5 +77,6 @@ func (limitPlan *LimitPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto
 		}
 		return false
 	}))
+	ds = dataset.Pipe(ds, options...)
 	return resultx.New(resultx.WithDataset(ds)), nil
 }
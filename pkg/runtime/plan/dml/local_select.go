@@ -53,7 +53,9 @@ func (s *LocalSelectPlan) ExecIn(ctx context.Context, _ proto.VConn) (proto.Resu
 
 	for i, item := range s.ColumnList {
 		sRes := s.Result[i].String()
-		if strings.ContainsRune(sRes, '.') {
+		if s.Result[i].Family() == proto.ValueFamilyString {
+			theadLocalSelect = append(theadLocalSelect, thead.Col{Name: item, FieldType: consts.FieldTypeVarString})
+		} else if strings.ContainsRune(sRes, '.') {
 			theadLocalSelect = append(theadLocalSelect, thead.Col{Name: item, FieldType: consts.FieldTypeFloat})
 		} else {
 			theadLocalSelect = append(theadLocalSelect, thead.Col{Name: item, FieldType: consts.FieldTypeLong})
@@ -0,0 +1,290 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/cmp"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+	"github.com/arana-db/arana/pkg/runtime/plan"
+)
+
+const (
+	JoinStrategyAuto       JoinStrategy = iota // decided by optimizer or backend
+	JoinStrategyHash                           // build a hash table from one side, then probe it
+	JoinStrategyNestedLoop                     // compare each probe row with every build row
+)
+
+var _joinStrategyNames = [...]string{
+	JoinStrategyAuto:       "AUTO",
+	JoinStrategyHash:       "HASH",
+	JoinStrategyNestedLoop: "NESTED_LOOP",
+}
+
+// JoinStrategy represents the algorithm used to join two tables.
+type JoinStrategy uint8
+
+func (js JoinStrategy) String() string {
+	return _joinStrategyNames[js]
+}
+
+var _ proto.Plan = (*NestedLoopJoinPlan)(nil)
+
+// NestedLoopJoinPlan joins two plans by comparing each row of ProbePlan with all rows of BuildPlan.
+// It is the fallback of HashJoinPlan, which supports non-equal join conditions, eg: ON a.x < b.y.
+type NestedLoopJoinPlan struct {
+	BuildPlan proto.Plan
+	ProbePlan proto.Plan
+
+	BuildKey string
+	ProbeKey string
+	// Comparison is the operator between BuildKey and ProbeKey, eg: 'BuildKey < ProbeKey'.
+	Comparison       cmp.Comparison
+	IsFilterProbeRow bool
+	IsReversedColumn bool
+
+	Stmt *ast.SelectStatement
+}
+
+func (n *NestedLoopJoinPlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (n *NestedLoopJoinPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	ctx, span := plan.Tracer.Start(ctx, "NestedLoopJoinPlan.ExecIn")
+	defer span.End()
+
+	buildFields, buildRows, err := n.build(ctx, conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res, err := n.ProbePlan.ExecIn(ctx, conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	probeFields, err := ds.Fields()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var fields []proto.Field
+	if n.IsReversedColumn {
+		fields = append(append(fields, probeFields[:len(probeFields)-1]...), buildFields[:len(buildFields)-1]...)
+	} else {
+		fields = append(append(fields, buildFields[:len(buildFields)-1]...), probeFields[:len(probeFields)-1]...)
+	}
+
+	return resultx.New(resultx.WithDataset(&nestedLoopDataset{
+		plan:        n,
+		probe:       ds,
+		probeFields: probeFields,
+		buildFields: buildFields,
+		buildRows:   buildRows,
+		fields:      fields,
+	})), nil
+}
+
+// build loads all rows of BuildPlan into memory.
+func (n *NestedLoopJoinPlan) build(ctx context.Context, conn proto.VConn) ([]proto.Field, []proto.Row, error) {
+	res, err := n.BuildPlan.ExecIn(ctx, conn)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = ds.Close()
+	}()
+
+	fields, err := ds.Fields()
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	var (
+		buildRows []proto.Row
+		quota     = rcontext.GetMemoryQuota(ctx)
+	)
+	for {
+		next, err := ds.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		// the rows of build side are held in memory until the probe is done
+		if err = quota.Consume(int64(next.Length())); err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		buildRows = append(buildRows, next)
+	}
+
+	return fields, buildRows, nil
+}
+
+// match returns true if the join condition between build value and probe value is satisfied.
+func (n *NestedLoopJoinPlan) match(buildValue, probeValue proto.Value) bool {
+	// NULL never matches anything
+	if buildValue == nil || probeValue == nil {
+		return false
+	}
+
+	c := proto.CompareValue(buildValue, probeValue)
+	switch n.Comparison {
+	case cmp.Ceq:
+		return c == 0
+	case cmp.Cne:
+		return c != 0
+	case cmp.Cgt:
+		return c > 0
+	case cmp.Cgte:
+		return c >= 0
+	case cmp.Clt:
+		return c < 0
+	case cmp.Clte:
+		return c <= 0
+	}
+	return false
+}
+
+var _ proto.Dataset = (*nestedLoopDataset)(nil)
+
+type nestedLoopDataset struct {
+	plan *NestedLoopJoinPlan
+
+	probe       proto.Dataset
+	probeFields []proto.Field
+	buildFields []proto.Field
+	buildRows   []proto.Row
+	fields      []proto.Field
+
+	current      proto.Row
+	currentDest  []proto.Value
+	currentValue proto.Value
+	offset       int
+	matched      bool
+}
+
+func (nd *nestedLoopDataset) Close() error {
+	return nd.probe.Close()
+}
+
+func (nd *nestedLoopDataset) Fields() ([]proto.Field, error) {
+	return nd.fields, nil
+}
+
+func (nd *nestedLoopDataset) Next() (proto.Row, error) {
+	for {
+		if nd.current == nil {
+			next, err := nd.probe.Next()
+			if err != nil {
+				return nil, err
+			}
+			nd.current = next
+			nd.currentDest = make([]proto.Value, len(nd.probeFields))
+			if err = next.Scan(nd.currentDest); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if nd.currentValue, err = next.(proto.KeyedRow).Get(nd.plan.ProbeKey); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			nd.offset = 0
+			nd.matched = false
+		}
+
+		for nd.offset < len(nd.buildRows) {
+			buildRow := nd.buildRows[nd.offset]
+			nd.offset++
+
+			buildValue, err := buildRow.(proto.KeyedRow).Get(nd.plan.BuildKey)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if !nd.plan.match(buildValue, nd.currentValue) {
+				continue
+			}
+
+			nd.matched = true
+			buildDest := make([]proto.Value, len(nd.buildFields))
+			if err = buildRow.Scan(buildDest); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return nd.join(buildDest)
+		}
+
+		// outer join: fill the build side with NULL if no rows matched
+		if !nd.matched && !nd.plan.IsFilterProbeRow {
+			nd.matched = true
+			return nd.join(make([]proto.Value, len(nd.buildFields)))
+		}
+
+		nd.current = nil
+	}
+}
+
+func (nd *nestedLoopDataset) join(buildDest []proto.Value) (proto.Row, error) {
+	var (
+		probeDest = nd.currentDest
+		dest      = make([]proto.Value, 0, len(nd.fields))
+	)
+
+	// remove 'ON' column
+	if nd.plan.IsReversedColumn {
+		dest = append(append(dest, probeDest[:len(probeDest)-1]...), buildDest[:len(buildDest)-1]...)
+	} else {
+		dest = append(append(dest, buildDest[:len(buildDest)-1]...), probeDest[:len(probeDest)-1]...)
+	}
+
+	var b bytes.Buffer
+	if nd.current.IsBinary() {
+		if _, err := rows.NewBinaryVirtualRow(nd.fields, dest).WriteTo(&b); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return mysql.NewBinaryRow(nd.fields, b.Bytes()), nil
+	}
+
+	if _, err := rows.NewTextVirtualRow(nd.fields, dest).WriteTo(&b); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return mysql.NewTextRow(nd.fields, b.Bytes()), nil
+}
@@ -0,0 +1,206 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/cmp"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+)
+
+// fakeJoinSidePlan returns rows of (key, name, key), the last column is the 'ON' column.
+type fakeJoinSidePlan struct {
+	key  string
+	keys []int64
+}
+
+func (f *fakeJoinSidePlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (f *fakeJoinSidePlan) ExecIn(_ context.Context, _ proto.VConn) (proto.Result, error) {
+	fields := []proto.Field{
+		mysql.NewField(f.key, consts.FieldTypeLongLong),
+		mysql.NewField("name", consts.FieldTypeString),
+		mysql.NewField(f.key, consts.FieldTypeLongLong),
+	}
+	ds := &dataset.VirtualDataset{Columns: fields}
+	for _, k := range f.keys {
+		ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+			proto.NewValueInt64(k),
+			proto.NewValueString(fmt.Sprintf("fake-%s-%d", f.key, k)),
+			proto.NewValueInt64(k),
+		}))
+	}
+	return resultx.New(resultx.WithDataset(ds)), nil
+}
+
+func newFakeJoinSidePlan(key string, begin, end int64) *fakeJoinSidePlan {
+	ret := &fakeJoinSidePlan{key: key}
+	for i := begin; i < end; i++ {
+		ret.keys = append(ret.keys, i)
+	}
+	return ret
+}
+
+func drainJoinResult(t testing.TB, res proto.Result) [][]proto.Value {
+	ds, err := res.Dataset()
+	assert.NoError(t, err)
+	fields, err := ds.Fields()
+	assert.NoError(t, err)
+
+	var ret [][]proto.Value
+	for {
+		next, err := ds.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		dest := make([]proto.Value, len(fields))
+		_ = next.Scan(dest)
+		ret = append(ret, dest)
+	}
+	return ret
+}
+
+func TestNestedLoopJoinPlan(t *testing.T) {
+	t.Run("inner join on uid < emp_no", func(t *testing.T) {
+		p := &NestedLoopJoinPlan{
+			BuildPlan:        newFakeJoinSidePlan("uid", 0, 3),
+			ProbePlan:        newFakeJoinSidePlan("emp_no", 0, 3),
+			BuildKey:         "uid",
+			ProbeKey:         "emp_no",
+			Comparison:       cmp.Clt,
+			IsFilterProbeRow: true,
+		}
+		res, err := p.ExecIn(context.Background(), nil)
+		assert.NoError(t, err)
+
+		ds, _ := res.Dataset()
+		f, _ := ds.Fields()
+		assert.Equal(t, "uid", f[0].Name())
+		assert.Equal(t, "name", f[1].Name())
+		assert.Equal(t, "emp_no", f[2].Name())
+		assert.Equal(t, "name", f[3].Name())
+
+		values := drainJoinResult(t, res)
+		// (0,1),(0,2),(1,2)
+		assert.Len(t, values, 3)
+		for _, next := range values {
+			assert.Equal(t, -1, proto.CompareValue(next[0], next[2]))
+		}
+	})
+
+	t.Run("left join on uid = emp_no", func(t *testing.T) {
+		// left: 0..5, right: 3..8, build with right side
+		p := &NestedLoopJoinPlan{
+			BuildPlan:        newFakeJoinSidePlan("emp_no", 3, 8),
+			ProbePlan:        newFakeJoinSidePlan("uid", 0, 5),
+			BuildKey:         "emp_no",
+			ProbeKey:         "uid",
+			Comparison:       cmp.Ceq,
+			IsReversedColumn: true,
+		}
+		res, err := p.ExecIn(context.Background(), nil)
+		assert.NoError(t, err)
+
+		values := drainJoinResult(t, res)
+		assert.Len(t, values, 5)
+		for _, next := range values {
+			uid, _ := next[0].Int64()
+			if uid < 3 {
+				assert.Nil(t, next[2])
+			} else {
+				assert.Equal(t, 0, proto.CompareValue(next[0], next[2]))
+			}
+		}
+	})
+}
+
+func TestNestedLoopJoinPlan_MemoryLimit(t *testing.T) {
+	p := &NestedLoopJoinPlan{
+		BuildPlan:        newFakeJoinSidePlan("uid", 0, 100),
+		ProbePlan:        newFakeJoinSidePlan("emp_no", 0, 3),
+		BuildKey:         "uid",
+		ProbeKey:         "emp_no",
+		Comparison:       cmp.Ceq,
+		IsFilterProbeRow: true,
+	}
+	_, err := p.ExecIn(rcontext.WithMemoryLimit(context.Background(), 64), nil)
+	assert.ErrorIs(t, err, rcontext.ErrMemoryLimitExceeded)
+}
+
+func benchmarkJoin(b *testing.B, newPlan func(build, probe proto.Plan) proto.Plan) {
+	var (
+		build = newFakeJoinSidePlan("uid", 0, 100)
+		probe = newFakeJoinSidePlan("emp_no", 0, 5000)
+		p     = newPlan(build, probe)
+	)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res, err := p.ExecIn(context.Background(), nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n := len(drainJoinResult(b, res)); n != 100 {
+			b.Fatalf("incorrect join result: expect=100, actual=%d", n)
+		}
+	}
+}
+
+func BenchmarkHashJoinPlan(b *testing.B) {
+	benchmarkJoin(b, func(build, probe proto.Plan) proto.Plan {
+		return &HashJoinPlan{
+			BuildPlan:        build,
+			ProbePlan:        probe,
+			BuildKey:         "uid",
+			ProbeKey:         "emp_no",
+			IsFilterProbeRow: true,
+		}
+	})
+}
+
+func BenchmarkNestedLoopJoinPlan(b *testing.B) {
+	benchmarkJoin(b, func(build, probe proto.Plan) proto.Plan {
+		return &NestedLoopJoinPlan{
+			BuildPlan:        build,
+			ProbePlan:        probe,
+			BuildKey:         "uid",
+			ProbeKey:         "emp_no",
+			Comparison:       cmp.Ceq,
+			IsFilterProbeRow: true,
+		}
+	})
+}
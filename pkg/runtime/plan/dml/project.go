@@ -19,7 +19,6 @@ package dml
 
 import (
 	"context"
-	"strings"
 )
 
 import (
@@ -31,15 +30,14 @@ import (
 	"github.com/arana-db/arana/pkg/mysql/rows"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/resultx"
-	"github.com/arana-db/arana/pkg/runtime/ast"
-	"github.com/arana-db/arana/pkg/runtime/optimize/dml/ext"
 )
 
-var _ proto.Plan = (*DropWeakPlan)(nil)
+var _ proto.Plan = (*ProjectPlan)(nil)
 
-// DropWeakPlan drops weak fields from upstream dataset.
+// ProjectPlan drops the internal fields from upstream dataset after the shards are merged, so that the client
+// always receives exactly the requested fields.
 //
-// For example:
+// The internal fields are injected into the queries of shards during optimization, for example:
 //
 //	SELECT id,uid,name FROM student WHERE ... ORDER BY age DESC
 //
@@ -47,14 +45,15 @@ var _ proto.Plan = (*DropWeakPlan)(nil)
 //
 //	SELECT id,uid,name,age FROM student WHERE ... ORDER BY age DESC
 //
-// the `age` field is weak, will be dropped finally.
-type DropWeakPlan struct {
+// the `age` field is internal, will be dropped finally. Others are the arguments of AVG, eg: SUM and COUNT,
+// and the aggregates referred by HAVING only.
+type ProjectPlan struct {
 	proto.Plan
-	WeakList []*ext.WeakSelectElement
+	Internals []string // the names of internal fields
 }
 
-func (dr DropWeakPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
-	res, err := dr.Plan.ExecIn(ctx, conn)
+func (pp ProjectPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	res, err := pp.Plan.ExecIn(ctx, conn)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -69,18 +68,14 @@ func (dr DropWeakPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Resu
 		return nil, errors.WithStack(err)
 	}
 
-	var (
-		weakMap = dr.generateWeakMap()
-		weakIdx = make(map[int]struct{}, len(weakMap))
-	)
-	for i := range fields {
-		if _, ok := weakMap[fields[i].Name()]; ok {
-			weakIdx[i] = struct{}{}
-		}
+	dropped := pp.indexOfInternals(fields)
+	if len(dropped) < 1 {
+		return res, nil
 	}
-	actualFields := make([]proto.Field, 0, len(fields))
+
+	actualFields := make([]proto.Field, 0, len(fields)-len(dropped))
 	for i := range fields {
-		if _, ok := weakIdx[i]; ok {
+		if _, ok := dropped[i]; ok {
 			continue
 		}
 		actualFields = append(actualFields, fields[i])
@@ -94,9 +89,9 @@ func (dr DropWeakPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Resu
 			return nil, errors.WithStack(err)
 		}
 
-		var actualCells []proto.Value
+		actualCells := make([]proto.Value, 0, len(actualFields))
 		for i := range cells {
-			if _, ok := weakIdx[i]; ok {
+			if _, ok := dropped[i]; ok {
 				continue
 			}
 			actualCells = append(actualCells, cells[i])
@@ -114,27 +109,20 @@ func (dr DropWeakPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Resu
 	return resultx.New(resultx.WithDataset(newDs)), nil
 }
 
-func (dr DropWeakPlan) generateWeakMap() map[string]struct{} {
-	var (
-		weaks = make(map[string]struct{}, len(dr.WeakList))
-		sb    strings.Builder
-	)
-	for _, it := range dr.WeakList {
-		if alias := it.Alias(); len(alias) > 0 {
-			weaks[alias] = struct{}{}
-			continue
-		}
-
-		root := it.Prev()
-		switch val := root.(type) {
-		case *ast.SelectElementColumn:
-			weaks[val.Suffix()] = struct{}{}
-		default:
-			_ = val.Restore(ast.RestoreWithoutAlias, &sb, nil)
-			weaks[sb.String()] = struct{}{}
-			sb.Reset()
+// indexOfInternals returns the indexes of internal fields. The internal fields are always appended after the
+// requested ones, so they are searched from the end, which keeps the requested field with the same name.
+func (pp ProjectPlan) indexOfInternals(fields []proto.Field) map[int]struct{} {
+	dropped := make(map[int]struct{}, len(pp.Internals))
+	for _, name := range pp.Internals {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if _, ok := dropped[i]; ok {
+				continue
+			}
+			if fields[i].Name() == name {
+				dropped[i] = struct{}{}
+				break
+			}
 		}
 	}
-
-	return weaks
+	return dropped
 }
@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+func TestProjectPlan(t *testing.T) {
+	fieldNames := func(plan proto.Plan) []string {
+		res, err := plan.ExecIn(context.Background(), nil)
+		assert.NoError(t, err)
+		ds, err := res.Dataset()
+		assert.NoError(t, err)
+		fields, err := ds.Fields()
+		assert.NoError(t, err)
+		var names []string
+		for _, it := range fields {
+			names = append(names, it.Name())
+		}
+		return names
+	}
+
+	// the fields are (uid, name, uid), the internal uid is appended after the requested one
+	plan := ProjectPlan{
+		Plan:      newFakeJoinSidePlan("uid", 1, 3),
+		Internals: []string{"uid"},
+	}
+	assert.Equal(t, []string{"uid", "name"}, fieldNames(plan))
+
+	res, err := plan.ExecIn(context.Background(), nil)
+	assert.NoError(t, err)
+	values := drainJoinResult(t, res)
+	assert.Len(t, values, 2)
+	for i, it := range values {
+		assert.Len(t, it, 2)
+		assert.Equal(t, proto.NewValueInt64(int64(i+1)).String(), it[0].String())
+		assert.Equal(t, "fake-uid-"+it[0].String(), it[1].String())
+	}
+
+	plan.Internals = []string{"uid", "name", "uid"}
+	assert.Empty(t, fieldNames(plan))
+
+	// the fields which are not found are ignored
+	plan.Internals = []string{"not_exist"}
+	assert.Equal(t, []string{"uid", "name", "uid"}, fieldNames(plan))
+}
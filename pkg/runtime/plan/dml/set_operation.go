@@ -0,0 +1,274 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+	"github.com/arana-db/arana/pkg/runtime/plan"
+)
+
+var _ proto.Plan = (*SetOperationPlan)(nil)
+
+// SetOperationPlan combines the results of two query plans with UNION, INTERSECT or EXCEPT, the rows are
+// compared by the values of all columns.
+//
+// The rows of left plan are streamed, but all rows of right plan will be loaded into memory before computing
+// INTERSECT and EXCEPT, and the distinct variants also hold every distinct row which has been returned.
+// Both plans should be merged completely, eg: the CompositePlan of sharding tables.
+type SetOperationPlan struct {
+	Left      proto.Plan
+	Right     proto.Plan
+	Operation ast.UnionType
+}
+
+func (sp *SetOperationPlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (sp *SetOperationPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	ctx, span := plan.Tracer.Start(ctx, "SetOperationPlan.ExecIn")
+	defer span.End()
+
+	left := func() (proto.Dataset, error) {
+		return execDataset(ctx, conn, sp.Left)
+	}
+	right := func() (proto.Dataset, error) {
+		return execDataset(ctx, conn, sp.Right)
+	}
+
+	if sp.Operation == ast.UnionTypeAll {
+		ds, err := dataset.Fuse(left, right)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return resultx.New(resultx.WithDataset(ds)), nil
+	}
+
+	var (
+		ds     proto.Dataset
+		counts map[setRowKey]int
+		quota  = rcontext.GetMemoryQuota(ctx)
+		err    error
+	)
+
+	switch {
+	case sp.Operation == ast.UnionTypeDistinct:
+		if ds, err = dataset.Fuse(left, right); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		ds = newSetDataset(ds, true, quota, nil)
+	case sp.Operation.IsIntersect(), sp.Operation.IsExcept():
+		if counts, err = loadRowCounts(right, quota); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if ds, err = left(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		intersect := sp.Operation.IsIntersect()
+		all := sp.Operation == ast.UnionTypeIntersectAll || sp.Operation == ast.UnionTypeExceptAll
+		ds = newSetDataset(ds, !all, quota, func(key setRowKey) bool {
+			n, ok := counts[key]
+			if ok && all {
+				// each row of right side can only match once, eg: [1,1,1] INTERSECT ALL [1,1] -> [1,1]
+				if counts[key] = n - 1; n == 1 {
+					delete(counts, key)
+				}
+			}
+			return ok == intersect
+		})
+	default:
+		return nil, errors.Errorf("unsupported set operation '%s'", sp.Operation)
+	}
+
+	return resultx.New(resultx.WithDataset(ds)), nil
+}
+
+func execDataset(ctx context.Context, conn proto.VConn, p proto.Plan) (proto.Dataset, error) {
+	res, err := p.ExecIn(ctx, conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res.Dataset()
+}
+
+// loadRowCounts loads all rows of dataset, and returns the amount of each distinct row.
+func loadRowCounts(gen dataset.GenerateFunc, quota *rcontext.MemoryQuota) (map[setRowKey]int, error) {
+	ds, err := gen()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = ds.Close()
+	}()
+
+	fields, err := ds.Fields()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	counts := make(map[setRowKey]int)
+	dest := make([]proto.Value, len(fields))
+	for {
+		next, err := ds.Next()
+		if errors.Is(err, io.EOF) {
+			return counts, nil
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		key, err := rowKey(next, dest)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, ok := counts[key]; !ok {
+			if err = quota.Consume(int64(len(key))); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		counts[key]++
+	}
+}
+
+// setRowKey identifies the rows with same values, each value is encoded with its type tag and length,
+// so that NULL, strings and numbers never collide, and the numbers of different types are compared by
+// their numeric values, eg: 1 and 1.0 are same.
+type setRowKey string
+
+const (
+	setKeyNull byte = iota
+	setKeyString
+	setKeyNumber
+	setKeyTime
+)
+
+// rowKey returns the key of row which is composed of all values, the rows with same values have same key.
+func rowKey(row proto.Row, dest []proto.Value) (setRowKey, error) {
+	if err := row.Scan(dest); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var b []byte
+	writeValue := func(tag byte, value string) {
+		b = append(b, tag)
+		b = binary.AppendUvarint(b, uint64(len(value)))
+		b = append(b, value...)
+	}
+	for _, it := range dest {
+		if it == nil {
+			b = append(b, setKeyNull)
+			continue
+		}
+		switch it.Family() {
+		case proto.ValueFamilySign, proto.ValueFamilyUnsigned, proto.ValueFamilyFloat, proto.ValueFamilyDecimal, proto.ValueFamilyBool:
+			d, err := it.Decimal()
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			writeValue(setKeyNumber, d.String())
+		case proto.ValueFamilyTime:
+			t, err := it.Time()
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			writeValue(setKeyTime, t.Format(time.RFC3339Nano))
+		default:
+			writeValue(setKeyString, it.String())
+		}
+	}
+	return setRowKey(b), nil
+}
+
+var _ proto.Dataset = (*setDataset)(nil)
+
+// setDataset filters the rows of dataset by the keys.
+type setDataset struct {
+	proto.Dataset
+	dest      []proto.Value
+	distinct  bool
+	seen      map[setRowKey]struct{}
+	quota     *rcontext.MemoryQuota
+	predicate func(key setRowKey) bool
+}
+
+func newSetDataset(ds proto.Dataset, distinct bool, quota *rcontext.MemoryQuota, predicate func(key setRowKey) bool) *setDataset {
+	ret := &setDataset{
+		Dataset:   ds,
+		distinct:  distinct,
+		quota:     quota,
+		predicate: predicate,
+	}
+	if distinct {
+		ret.seen = make(map[setRowKey]struct{})
+	}
+	return ret
+}
+
+func (sd *setDataset) Next() (proto.Row, error) {
+	for {
+		next, err := sd.Dataset.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if sd.dest == nil {
+			fields, err := sd.Dataset.Fields()
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			sd.dest = make([]proto.Value, len(fields))
+		}
+
+		key, err := rowKey(next, sd.dest)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if sd.distinct {
+			if _, ok := sd.seen[key]; ok {
+				continue
+			}
+		}
+		if sd.predicate != nil && !sd.predicate(key) {
+			continue
+		}
+		if sd.distinct {
+			// the distinct keys are held in memory until the dataset is closed
+			if err = sd.quota.Consume(int64(len(key))); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			sd.seen[key] = struct{}{}
+		}
+		return next, nil
+	}
+}
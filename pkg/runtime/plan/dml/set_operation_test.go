@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+)
+
+func TestRowKey(t *testing.T) {
+	fields := []proto.Field{
+		mysql.NewField("a", consts.FieldTypeVarString),
+		mysql.NewField("b", consts.FieldTypeVarString),
+	}
+	key := func(values ...proto.Value) setRowKey {
+		k, err := rowKey(rows.NewBinaryVirtualRow(fields, values), make([]proto.Value, len(values)))
+		assert.NoError(t, err)
+		return k
+	}
+
+	// the values containing separators cannot collide with each other
+	assert.NotEqual(t,
+		key(proto.NewValueString("a\x00\x01b"), proto.NewValueString("")),
+		key(proto.NewValueString("a"), proto.NewValueString("b")),
+	)
+	// NULL is different from the string 'NULL' and the empty string
+	assert.NotEqual(t, key(nil, proto.NewValueString("x")), key(proto.NewValueString("NULL"), proto.NewValueString("x")))
+	assert.NotEqual(t, key(nil, proto.NewValueString("x")), key(proto.NewValueString(""), proto.NewValueString("x")))
+	// the string '1' is different from the number 1
+	assert.NotEqual(t, key(proto.NewValueString("1"), nil), key(proto.NewValueInt64(1), nil))
+	// numbers are compared by the numeric values
+	assert.Equal(t,
+		key(proto.NewValueInt64(1), proto.NewValueUint64(2)),
+		key(proto.NewValueDecimal(decimal.RequireFromString("1.0")), proto.NewValueFloat64(2)),
+	)
+}
+
+func TestSetOperationPlan_MemoryLimit(t *testing.T) {
+	for _, op := range []ast.UnionType{ast.UnionTypeDistinct, ast.UnionTypeIntersectDistinct, ast.UnionTypeExceptAll} {
+		t.Run(op.String(), func(t *testing.T) {
+			p := &SetOperationPlan{
+				Left:      newFakeJoinSidePlan("uid", 0, 100),
+				Right:     newFakeJoinSidePlan("uid", 50, 150),
+				Operation: op,
+			}
+			ctx := rcontext.WithMemoryLimit(context.Background(), 64)
+			res, err := p.ExecIn(ctx, nil)
+			if err == nil {
+				var ds proto.Dataset
+				ds, err = res.Dataset()
+				assert.NoError(t, err)
+				for err == nil {
+					_, err = ds.Next()
+				}
+			}
+			assert.NotErrorIs(t, err, io.EOF)
+			assert.ErrorIs(t, err, rcontext.ErrMemoryLimitExceeded)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/plan"
+)
+
+// the attributes of shard query span
+const (
+	attrShardDatabase = attribute.Key("shard.database")
+	attrShardTables   = attribute.Key("shard.tables")
+	attrShardRows     = attribute.Key("shard.rows")
+	attrShardDuration = attribute.Key("shard.duration_ms")
+)
+
+// startShardSpan starts a child span for the query of one shard, the span is a no-op unless a TracerProvider
+// is registered, eg: the trace of config is enabled.
+func startShardSpan(ctx context.Context, p proto.Plan) (context.Context, trace.Span) {
+	ctx, span := plan.Tracer.Start(ctx, "CompositePlan.shard")
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	if sp, ok := p.(*SimpleQueryPlan); ok {
+		span.SetAttributes(attrShardDatabase.String(sp.Database), attrShardTables.StringSlice(sp.Tables))
+	}
+	return ctx, span
+}
+
+// traceShard executes the query plan of one shard in a child span, the span won't end until all rows of the shard
+// are read, so the amount of rows and the whole duration can be recorded.
+func traceShard(ctx context.Context, p proto.Plan, conn proto.VConn) (proto.Dataset, error) {
+	ctx, span := startShardSpan(ctx, p)
+	if !span.IsRecording() {
+		span.End()
+		res, err := p.ExecIn(ctx, conn)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return res.Dataset()
+	}
+
+	ts := &tracedDataset{
+		span:  span,
+		start: time.Now(),
+	}
+
+	res, err := p.ExecIn(ctx, conn)
+	if err != nil {
+		ts.end(err)
+		return nil, errors.WithStack(err)
+	}
+
+	if ts.Dataset, err = res.Dataset(); err != nil {
+		ts.end(err)
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+// tracedDataset counts the rows of shard, and ends the span once the dataset is exhausted, failed or closed.
+type tracedDataset struct {
+	proto.Dataset
+	span  trace.Span
+	start time.Time
+	rows  int64
+	once  sync.Once
+}
+
+func (t *tracedDataset) Next() (proto.Row, error) {
+	row, err := t.Dataset.Next()
+	if err != nil {
+		t.end(err)
+		return row, err
+	}
+	t.rows++
+	return row, nil
+}
+
+func (t *tracedDataset) Close() error {
+	t.end(nil)
+	return t.Dataset.Close()
+}
+
+func (t *tracedDataset) end(err error) {
+	t.once.Do(func() {
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.span.RecordError(err)
+			t.span.SetStatus(codes.Error, err.Error())
+		}
+		t.span.SetAttributes(
+			attrShardRows.Int64(t.rows),
+			attrShardDuration.Int64(time.Since(t.start).Milliseconds()),
+		)
+		t.span.End()
+	})
+}
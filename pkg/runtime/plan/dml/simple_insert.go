@@ -33,16 +33,21 @@ import (
 	"github.com/arana-db/arana/pkg/runtime/plan"
 )
 
-var _ proto.Plan = (*SimpleInsertPlan)(nil)
+var (
+	_ proto.Plan         = (*SimpleInsertPlan)(nil)
+	_ plan.Transactional = (*SimpleInsertPlan)(nil)
+)
 
 type SimpleInsertPlan struct {
 	plan.BasePlan
-	batch map[string][]*ast.InsertStatement // key=db
+	// Batched is true if the rows of a shard are split into multiple statements, eg: 'insert_batch_size' of table.
+	Batched bool
+	batch   map[string][]ast.BaseInsertStatement // key=db
 }
 
 func NewSimpleInsertPlan() *SimpleInsertPlan {
 	return &SimpleInsertPlan{
-		batch: make(map[string][]*ast.InsertStatement),
+		batch: make(map[string][]ast.BaseInsertStatement),
 	}
 }
 
@@ -50,10 +55,32 @@ func (sp *SimpleInsertPlan) Type() proto.PlanType {
 	return proto.PlanTypeExec
 }
 
-func (sp *SimpleInsertPlan) Put(db string, stmt *ast.InsertStatement) {
+// Put adds an INSERT or REPLACE statement which will be executed in the database.
+func (sp *SimpleInsertPlan) Put(db string, stmt ast.BaseInsertStatement) {
 	sp.batch[db] = append(sp.batch[db], stmt)
 }
 
+// Transactional returns true if the rows of a shard are split into multiple statements,
+// or the rows of REPLACE are split across shards, since each REPLACE may delete existing rows.
+func (sp *SimpleInsertPlan) Transactional() bool {
+	if sp.Batched {
+		return true
+	}
+	var total int
+	for _, inserts := range sp.batch {
+		total += len(inserts)
+	}
+	if total < 2 {
+		return false
+	}
+	for _, inserts := range sp.batch {
+		if _, ok := inserts[0].(*ast.ReplaceStatement); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (sp *SimpleInsertPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
 	var (
 		affects      uint64
@@ -79,7 +106,7 @@ func (sp *SimpleInsertPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto
 	return resultx.New(resultx.WithLastInsertID(lastInsertId), resultx.WithRowsAffected(affects)), nil
 }
 
-func (sp *SimpleInsertPlan) doInsert(ctx context.Context, conn proto.VConn, db string, stmt *ast.InsertStatement) (uint64, uint64, error) {
+func (sp *SimpleInsertPlan) doInsert(ctx context.Context, conn proto.VConn, db string, stmt ast.BaseInsertStatement) (uint64, uint64, error) {
 	var (
 		sb   strings.Builder
 		args []int
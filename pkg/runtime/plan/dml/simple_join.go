@@ -29,6 +29,7 @@ import (
 import (
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/runtime/ast"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/plan"
 )
 
@@ -37,6 +38,18 @@ type JoinTable struct {
 	Alias  string
 }
 
+// hintName returns the name of joined table referred by the optimizer hints, which is the alias or the physical table,
+// returns empty string if the tables are unioned without an alias.
+func (jt *JoinTable) hintName() string {
+	if len(jt.Alias) > 0 {
+		return jt.Alias
+	}
+	if len(jt.Tables) == 1 {
+		return jt.Tables[0]
+	}
+	return ""
+}
+
 type SimpleJoinPlan struct {
 	plan.BasePlan
 	Database string
@@ -44,6 +57,8 @@ type SimpleJoinPlan struct {
 	Join     *ast.JoinNode
 	Right    *JoinTable
 	Stmt     *ast.SelectStatement
+	// Strategy is the join algorithm suggested to the backend, it will be rendered as the optimizer hint of MySQL.
+	Strategy JoinStrategy
 }
 
 func (s *SimpleJoinPlan) Type() proto.PlanType {
@@ -61,6 +76,10 @@ func (s *SimpleJoinPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Re
 	ctx, span := plan.Tracer.Start(ctx, "SimpleJoinPlan.ExecIn")
 	defer span.End()
 
+	if s.Stmt.Lock == 0 {
+		ctx = rcontext.WithIdempotent(ctx)
+	}
+
 	if err := s.generateSelect(&sb, &indexes); err != nil {
 		return nil, err
 	}
@@ -92,6 +111,10 @@ func (s *SimpleJoinPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Re
 		}
 	}
 
+	if err := s.generateTail(&sb, &indexes); err != nil {
+		return nil, err
+	}
+
 	var (
 		query = sb.String()
 		args  = s.ToArgs(indexes)
@@ -107,6 +130,8 @@ func (s *SimpleJoinPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Re
 func (s *SimpleJoinPlan) generateSelect(sb *strings.Builder, args *[]int) error {
 	sb.WriteString("SELECT ")
 
+	s.generateStrategy(sb)
+
 	if s.Stmt.Distinct {
 		sb.WriteString(ast.Distinct)
 		sb.WriteString(" ")
@@ -124,40 +149,111 @@ func (s *SimpleJoinPlan) generateSelect(sb *strings.Builder, args *[]int) error
 	return nil
 }
 
-func (s *SimpleJoinPlan) generateTable(tables []string, alias string, sb *strings.Builder) error {
-	if len(tables) == 1 {
-		sb.WriteString(tables[0] + " ")
+// generateTail writes the GROUP BY, HAVING, ORDER BY, LIMIT and locking clauses, which are applied to the joined rows.
+// The whole join is executed by one backend, so the LIMIT is pushed down as it is, no extra LimitPlan is required.
+func (s *SimpleJoinPlan) generateTail(sb *strings.Builder, args *[]int) error {
+	if s.Stmt.GroupBy != nil {
+		sb.WriteString(" GROUP BY ")
+		for i, it := range s.Stmt.GroupBy.Items {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if err := it.Restore(ast.RestoreDefault, sb, args); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if s.Stmt.GroupBy.RollUp {
+			sb.WriteString(" WITH ROLLUP")
+		}
+	}
 
-		if alias != "" {
-			sb.WriteString(" AS ")
-			sb.WriteString(alias + " ")
+	if s.Stmt.Having != nil {
+		sb.WriteString(" HAVING ")
+		if err := s.Stmt.Having.Restore(ast.RestoreDefault, sb, args); err != nil {
+			return errors.WithStack(err)
 		}
-		return nil
 	}
 
-	sb.WriteString("( ")
-	sb.WriteString("select * from " + tables[0] + " ")
+	for i, it := range s.Stmt.OrderBy {
+		if i == 0 {
+			sb.WriteString(" ORDER BY ")
+		} else {
+			sb.WriteString(", ")
+		}
+		if err := it.Restore(ast.RestoreDefault, sb, args); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 
-	if len(tables) == 1 {
-		sb.WriteString(" )")
-		return nil
+	if s.Stmt.Limit != nil {
+		sb.WriteString(" LIMIT ")
+		if err := s.Stmt.Limit.Restore(ast.RestoreDefault, sb, args); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if s.Stmt.Lock != 0 {
+		sb.WriteByte(' ')
+		sb.WriteString(s.Stmt.Lock.String())
+	}
+
+	return nil
+}
+
+// generateStrategy writes the join algorithm hints, works with MySQL 8.0.20+ only.
+// The hint is skipped if either side cannot be named, since a hint without tables applies to the whole query block.
+// See also: https://dev.mysql.com/doc/refman/8.0/en/optimizer-hints.html#optimizer-hints-table-level
+func (s *SimpleJoinPlan) generateStrategy(sb *strings.Builder) {
+	var name string
+	switch s.Strategy {
+	case JoinStrategyHash:
+		name = "BNL"
+	case JoinStrategyNestedLoop:
+		name = "NO_BNL"
+	default:
+		return
 	}
 
-	for i := 1; i < len(tables); i++ {
-		sb.WriteString(" UNION ALL ")
-		sb.WriteString("select * from " + tables[i] + " ")
+	left, right := s.Left.hintName(), s.Right.hintName()
+	if len(left) < 1 || len(right) < 1 {
+		return
+	}
+
+	sb.WriteString("/*+ ")
+	sb.WriteString(name)
+	sb.WriteByte('(')
+	ast.WriteID(sb, left)
+	sb.WriteString(", ")
+	ast.WriteID(sb, right)
+	sb.WriteString(") */ ")
+}
+
+func (s *SimpleJoinPlan) generateTable(tables []string, alias string, sb *strings.Builder) error {
+	// the identifiers are quoted, since the table or alias may be a reserved word, eg: `order`
+	if len(tables) == 1 {
+		ast.WriteID(sb, tables[0])
+	} else {
+		sb.WriteByte('(')
+		for i, table := range tables {
+			if i > 0 {
+				sb.WriteString(" UNION ALL ")
+			}
+			sb.WriteString("SELECT * FROM ")
+			ast.WriteID(sb, table)
+		}
+		sb.WriteByte(')')
 	}
-	sb.WriteString(" )")
 
 	if alias != "" {
 		sb.WriteString(" AS ")
-		sb.WriteString(alias + " ")
+		ast.WriteID(sb, alias)
 	}
 	return nil
 }
 
 func (s *SimpleJoinPlan) generateJoinType(sb *strings.Builder) {
 	// add join type
+	sb.WriteByte(' ')
 	switch s.Join.Typ {
 	case ast.LeftJoin:
 		sb.WriteString("LEFT")
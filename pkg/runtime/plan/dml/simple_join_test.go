@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleJoinPlan_generateStrategy(t *testing.T) {
+	type tt struct {
+		name     string
+		left     *JoinTable
+		right    *JoinTable
+		strategy JoinStrategy
+		expect   string
+	}
+
+	for _, it := range []tt{
+		{
+			"Alias",
+			&JoinTable{Tables: []string{"student_0000"}, Alias: "a"},
+			&JoinTable{Tables: []string{"score_0000"}, Alias: "b"},
+			JoinStrategyHash,
+			"/*+ BNL(`a`, `b`) */ ",
+		},
+		{
+			"ReservedAlias",
+			&JoinTable{Tables: []string{"student_0000"}, Alias: "order"},
+			&JoinTable{Tables: []string{"score_0000"}, Alias: "b"},
+			JoinStrategyNestedLoop,
+			"/*+ NO_BNL(`order`, `b`) */ ",
+		},
+		{
+			"NoAlias",
+			&JoinTable{Tables: []string{"student_0000"}},
+			&JoinTable{Tables: []string{"score_0000"}},
+			JoinStrategyHash,
+			"/*+ BNL(`student_0000`, `score_0000`) */ ",
+		},
+		{
+			"UnionWithoutAlias",
+			&JoinTable{Tables: []string{"student_0000", "student_0001"}},
+			&JoinTable{Tables: []string{"score_0000"}, Alias: "b"},
+			JoinStrategyHash,
+			"",
+		},
+		{
+			"Auto",
+			&JoinTable{Tables: []string{"student_0000"}, Alias: "a"},
+			&JoinTable{Tables: []string{"score_0000"}, Alias: "b"},
+			JoinStrategyAuto,
+			"",
+		},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			s := &SimpleJoinPlan{
+				Left:     it.left,
+				Right:    it.right,
+				Strategy: it.strategy,
+			}
+			var sb strings.Builder
+			s.generateStrategy(&sb)
+			assert.Equal(t, it.expect, sb.String())
+		})
+	}
+}
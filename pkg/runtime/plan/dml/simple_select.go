@@ -43,6 +43,7 @@ type SimpleQueryPlan struct {
 	Database string
 	Tables   []string
 	Stmt     *ast.SelectStatement
+	Wheres   map[string]ast.ExpressionNode // physical table -> where, which overrides the where of Stmt
 }
 
 func (s *SimpleQueryPlan) Type() proto.PlanType {
@@ -84,6 +85,11 @@ func (s *SimpleQueryPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.R
 
 	discard := s.filter()
 
+	// a select without locking read can be retried on another node
+	if s.Stmt.Lock == 0 {
+		ctx = rcontext.WithIdempotent(ctx)
+	}
+
 	if s.isCompat80Enabled(ctx, conn) {
 		rf |= ast.RestoreCompat80
 	}
@@ -150,6 +156,12 @@ func (s *SimpleQueryPlan) resetTable(tgt *ast.SelectStatement, table string) err
 	return nil
 }
 
+func (s *SimpleQueryPlan) resetWhere(tgt *ast.SelectStatement, table string) {
+	if where, ok := s.Wheres[table]; ok {
+		tgt.Where = where
+	}
+}
+
 func (s *SimpleQueryPlan) generate(rf ast.RestoreFlag, sb *strings.Builder, args *[]int) error {
 	switch len(s.Tables) {
 	case 0:
@@ -166,6 +178,7 @@ func (s *SimpleQueryPlan) generate(rf ast.RestoreFlag, sb *strings.Builder, args
 		if err = s.resetTable(&stmt, s.Tables[0]); err != nil {
 			return errors.WithStack(err)
 		}
+		s.resetWhere(&stmt, s.Tables[0])
 		if err = stmt.Restore(ast.RestoreDefault, sb, args); err != nil {
 			return errors.WithStack(err)
 		}
@@ -190,11 +203,13 @@ func (s *SimpleQueryPlan) generate(rf ast.RestoreFlag, sb *strings.Builder, args
 			if err := s.resetTable(stmt, table); err != nil {
 				return err
 			}
+			s.resetWhere(stmt, table)
 			if err := stmt.Restore(ast.RestoreDefault, sb, args); err != nil {
 				return err
 			}
 			sb.WriteByte(')')
 			stmt.From = s.Stmt.From
+			stmt.Where = s.Stmt.Where
 			return nil
 		}
 
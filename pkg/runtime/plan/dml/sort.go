@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"context"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+)
+
+var _ proto.Plan = (*SortPlan)(nil)
+
+// SortPlan sorts all the rows of parent plan in memory, unlike OrderPlan, the rows of parent plan are needless
+// to be ordered, eg: the merged groups which are ordered by the aggregate values.
+type SortPlan struct {
+	ParentPlan   proto.Plan
+	OrderByItems []dataset.OrderByItem
+}
+
+func (sp *SortPlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (sp *SortPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	if sp.ParentPlan == nil {
+		return nil, errors.New("sort plan: ParentPlan is nil")
+	}
+
+	res, err := sp.ParentPlan.ExecIn(ctx, conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return resultx.New(resultx.WithDataset(dataset.NewSortedDataset(ds, sp.OrderByItems, rcontext.GetMemoryQuota(ctx)))), nil
+}
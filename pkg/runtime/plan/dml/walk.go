@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+// WalkFunc is called for each plan visited by Walk, the depth of the root plan is 0.
+// The sub plans of current plan will be skipped if false is returned.
+type WalkFunc func(p proto.Plan, depth int) (bool, error)
+
+// Walk traverses the plan tree in depth-first order, the plan is visited before its sub plans.
+// The traversal will be stopped if fn returns an error, which is returned by Walk.
+func Walk(p proto.Plan, fn WalkFunc) error {
+	return walk(p, 0, fn)
+}
+
+func walk(p proto.Plan, depth int, fn WalkFunc) error {
+	if p == nil {
+		return nil
+	}
+	next, err := fn(p, depth)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !next {
+		return nil
+	}
+	for _, child := range Children(p) {
+		if err = walk(child, depth+1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Children returns the sub plans of the plan, returns nil if the plan is a leaf, eg: SimpleQueryPlan.
+func Children(p proto.Plan) []proto.Plan {
+	switch it := p.(type) {
+	case CompositePlan:
+		return it.Plans
+	case *CompositePlan:
+		return it.Plans
+	case RenamePlan:
+		return []proto.Plan{it.Plan}
+	case *RenamePlan:
+		return []proto.Plan{it.Plan}
+	case ProjectPlan:
+		return []proto.Plan{it.Plan}
+	case *ProjectPlan:
+		return []proto.Plan{it.Plan}
+	case *MappingPlan:
+		return []proto.Plan{it.Plan}
+	case *AggregatePlan:
+		return []proto.Plan{it.Plan}
+	case *GroupPlan:
+		return []proto.Plan{it.Plan}
+	case *LimitPlan:
+		return []proto.Plan{it.ParentPlan}
+	case *OrderPlan:
+		return []proto.Plan{it.ParentPlan}
+	case *SortPlan:
+		return []proto.Plan{it.ParentPlan}
+	case *HavingPlan:
+		return []proto.Plan{it.ParentPlan}
+	case *InSubqueryPlan:
+		return []proto.Plan{it.Subquery}
+	case *HashJoinPlan:
+		return []proto.Plan{it.BuildPlan, it.ProbePlan}
+	case *NestedLoopJoinPlan:
+		return []proto.Plan{it.BuildPlan, it.ProbePlan}
+	case *SetOperationPlan:
+		return []proto.Plan{it.Left, it.Right}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dml
+
+import (
+	"fmt"
+	"testing"
+)
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+)
+
+func TestWalk(t *testing.T) {
+	p := &LimitPlan{
+		ParentPlan: &OrderPlan{
+			ParentPlan: &CompositePlan{
+				Plans: []proto.Plan{
+					&SimpleQueryPlan{Database: "fake_db", Tables: []string{"student_0000"}},
+					&SimpleQueryPlan{Database: "fake_db", Tables: []string{"student_0001"}},
+				},
+			},
+		},
+	}
+
+	var visited []string
+	err := Walk(p, func(p proto.Plan, depth int) (bool, error) {
+		switch it := p.(type) {
+		case *SimpleQueryPlan:
+			visited = append(visited, fmt.Sprintf("%d:%s", depth, it.Tables[0]))
+		default:
+			visited = append(visited, fmt.Sprintf("%d:%T", depth, p))
+		}
+		return true, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"0:*dml.LimitPlan",
+		"1:*dml.OrderPlan",
+		"2:*dml.CompositePlan",
+		"3:student_0000",
+		"3:student_0001",
+	}, visited)
+
+	// skip the sub plans
+	var cnt int
+	err = Walk(p, func(p proto.Plan, depth int) (bool, error) {
+		cnt++
+		_, ok := p.(*OrderPlan)
+		return !ok, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cnt)
+
+	// stop walking
+	mockErr := errors.New("mock error")
+	cnt = 0
+	err = Walk(p, func(p proto.Plan, depth int) (bool, error) {
+		if cnt++; depth == 3 {
+			return false, mockErr
+		}
+		return true, nil
+	})
+	assert.ErrorIs(t, err, mockErr)
+	assert.Equal(t, 4, cnt)
+}
+
+func ExampleWalk() {
+	p := &OrderPlan{
+		ParentPlan: &CompositePlan{
+			Plans: []proto.Plan{
+				&SimpleQueryPlan{Database: "fake_db", Tables: []string{"student_0000"}},
+				&SimpleQueryPlan{Database: "fake_db", Tables: []string{"student_0001"}},
+			},
+		},
+	}
+
+	// print the physical tables, which are the leaves of plan tree
+	_ = Walk(p, func(p proto.Plan, depth int) (bool, error) {
+		if it, ok := p.(*SimpleQueryPlan); ok {
+			fmt.Println(depth, it.Database, it.Tables)
+		}
+		return true, nil
+	})
+	// Output:
+	// 2 fake_db [student_0000]
+	// 2 fake_db [student_0001]
+}
@@ -27,6 +27,13 @@ import (
 
 var Tracer = otel.Tracer("ExecPlan")
 
+// Transactional represents a plan which consists of multiple statements, they should be executed within
+// a transaction even if the session is in autocommit mode.
+type Transactional interface {
+	// Transactional returns true if the plan should be executed within a transaction.
+	Transactional() bool
+}
+
 type BasePlan struct {
 	Args []proto.Value
 }
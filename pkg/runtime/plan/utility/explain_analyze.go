@@ -0,0 +1,246 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utility
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/pkg/errors"
+)
+
+import (
+	constant "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/plan"
+	"github.com/arana-db/arana/pkg/runtime/plan/dml"
+)
+
+var _ proto.Plan = (*ExplainAnalyzePlan)(nil)
+
+// ExplainAnalyzePlan executes the target plan, and returns the rows and time spent of each shard.
+//
+// Output example:
+//
+//	+----+-----------+---------------------------+------+----------+
+//	| id | database  | tables                    | rows | elapsed  |
+//	+----+-----------+---------------------------+------+----------+
+//	|  1 | employees | student_0001,student_0005 |   12 | 1.802ms  |
+//	|  2 | employees | student_0002              |    3 | 312.57µs |
+//	+----+-----------+---------------------------+------+----------+
+type ExplainAnalyzePlan struct {
+	Plan  proto.Plan
+	stats []*shardStat
+}
+
+// NewExplainAnalyzePlan creates an ExplainAnalyzePlan from the target plan.
+func NewExplainAnalyzePlan(target proto.Plan) *ExplainAnalyzePlan {
+	ep := &ExplainAnalyzePlan{}
+	ep.Plan = ep.instrument(target)
+	return ep
+}
+
+func (ep *ExplainAnalyzePlan) Type() proto.PlanType {
+	return proto.PlanTypeQuery
+}
+
+func (ep *ExplainAnalyzePlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	ctx, span := plan.Tracer.Start(ctx, "ExplainAnalyzePlan.ExecIn")
+	defer span.End()
+
+	res, err := ep.Plan.ExecIn(ctx, conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// exhaust all rows, the statistics will be collected by the instrumented shard plans
+	for {
+		if _, err = ds.Next(); err != nil {
+			break
+		}
+	}
+	_ = ds.Close()
+	if !errors.Is(err, io.EOF) {
+		return nil, errors.WithStack(err)
+	}
+
+	fields := []proto.Field{
+		mysql.NewField("id", constant.FieldTypeLongLong),
+		mysql.NewField("database", constant.FieldTypeVarString),
+		mysql.NewField("tables", constant.FieldTypeVarString),
+		mysql.NewField("rows", constant.FieldTypeLongLong),
+		mysql.NewField("elapsed", constant.FieldTypeVarString),
+	}
+
+	ret := &dataset.VirtualDataset{Columns: fields}
+	for i, it := range ep.stats {
+		ret.Rows = append(ret.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+			proto.NewValueInt64(int64(i + 1)),
+			proto.NewValueString(it.database),
+			proto.NewValueString(strings.Join(it.tables, ",")),
+			proto.NewValueInt64(it.rows),
+			proto.NewValueString(it.elapsed.String()),
+		}))
+	}
+
+	return resultx.New(resultx.WithDataset(ret)), nil
+}
+
+// instrument replaces the shard plans of the plan tree with the instrumented ones.
+func (ep *ExplainAnalyzePlan) instrument(p proto.Plan) proto.Plan {
+	switch it := p.(type) {
+	case *dml.SimpleQueryPlan:
+		return ep.newShardPlan(p, it.Database, it.Tables)
+	case *dml.SimpleJoinPlan:
+		var tables []string
+		if it.Left != nil {
+			tables = append(tables, it.Left.Tables...)
+		}
+		if it.Right != nil {
+			tables = append(tables, it.Right.Tables...)
+		}
+		return ep.newShardPlan(p, it.Database, tables)
+	case *dml.CompositePlan:
+		for i := range it.Plans {
+			it.Plans[i] = ep.instrument(it.Plans[i])
+		}
+	case *dml.RenamePlan:
+		it.Plan = ep.instrument(it.Plan)
+	case *dml.ProjectPlan:
+		it.Plan = ep.instrument(it.Plan)
+	case *dml.MappingPlan:
+		it.Plan = ep.instrument(it.Plan)
+	case *dml.AggregatePlan:
+		it.Plan = ep.instrument(it.Plan)
+	case *dml.GroupPlan:
+		it.Plan = ep.instrument(it.Plan)
+	case *dml.LimitPlan:
+		it.ParentPlan = ep.instrument(it.ParentPlan)
+	case *dml.OrderPlan:
+		it.ParentPlan = ep.instrument(it.ParentPlan)
+	case *dml.SortPlan:
+		it.ParentPlan = ep.instrument(it.ParentPlan)
+	case *dml.HavingPlan:
+		it.ParentPlan = ep.instrument(it.ParentPlan)
+	case *dml.InSubqueryPlan:
+		it.Subquery = ep.instrument(it.Subquery)
+		build := it.Build
+		it.Build = func(values []proto.Value) (proto.Plan, error) {
+			p, err := build(values)
+			if err != nil {
+				return nil, err
+			}
+			return ep.instrument(p), nil
+		}
+	case *dml.HashJoinPlan:
+		it.BuildPlan = ep.instrument(it.BuildPlan)
+		it.ProbePlan = ep.instrument(it.ProbePlan)
+	case *dml.NestedLoopJoinPlan:
+		it.BuildPlan = ep.instrument(it.BuildPlan)
+		it.ProbePlan = ep.instrument(it.ProbePlan)
+	case *dml.SetOperationPlan:
+		it.Left = ep.instrument(it.Left)
+		it.Right = ep.instrument(it.Right)
+	}
+	return p
+}
+
+func (ep *ExplainAnalyzePlan) newShardPlan(p proto.Plan, db string, tables []string) proto.Plan {
+	stat := &shardStat{
+		database: db,
+		tables:   tables,
+	}
+	ep.stats = append(ep.stats, stat)
+	return &shardPlan{
+		Plan: p,
+		stat: stat,
+	}
+}
+
+type shardStat struct {
+	database string
+	tables   []string
+	rows     int64
+	elapsed  time.Duration
+}
+
+// shardPlan records the rows and time spent of a single shard.
+type shardPlan struct {
+	proto.Plan
+	stat *shardStat
+}
+
+func (sp *shardPlan) ExecIn(ctx context.Context, conn proto.VConn) (proto.Result, error) {
+	begin := time.Now()
+	res, err := sp.Plan.ExecIn(ctx, conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ds, err := res.Dataset()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return resultx.New(resultx.WithDataset(&shardDataset{
+		Dataset: ds,
+		stat:    sp.stat,
+		begin:   begin,
+	})), nil
+}
+
+type shardDataset struct {
+	proto.Dataset
+	stat  *shardStat
+	begin time.Time
+	done  bool
+}
+
+func (sd *shardDataset) Next() (proto.Row, error) {
+	next, err := sd.Dataset.Next()
+	if err != nil {
+		sd.finish()
+		return nil, err
+	}
+	sd.stat.rows++
+	return next, nil
+}
+
+func (sd *shardDataset) Close() error {
+	sd.finish()
+	return sd.Dataset.Close()
+}
+
+func (sd *shardDataset) finish() {
+	if sd.done {
+		return
+	}
+	sd.done = true
+	sd.stat.elapsed = time.Since(sd.begin)
+}
@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/runtime/ast"
+	"github.com/arana-db/arana/pkg/runtime/optimize"
+	"github.com/arana-db/arana/pkg/runtime/plan/dml"
+	"github.com/arana-db/arana/pkg/util/log"
+)
+
+// UnknownRows means the estimated rows of physical table is unavailable.
+const UnknownRows int64 = -1
+
+// ShardStat represents a physical table which the query will be routed to.
+type ShardStat struct {
+	Table         string // the logical table name
+	Database      string // the physical database name
+	PhysicalTable string // the physical table name
+	FullScan      bool   // whether the shards of logical table cannot be pruned
+	EstimatedRows int64  // the rows estimated by information_schema.TABLES, UnknownRows if not estimated
+}
+
+type planStatsOption struct {
+	estimate bool
+}
+
+// PlanStatsOption represents the option of PlanStats.
+type PlanStatsOption func(*planStatsOption)
+
+// WithEstimatedRows estimates the rows of each physical table by the statistics of 'information_schema.TABLES',
+// which is fast but approximate.
+func WithEstimatedRows() PlanStatsOption {
+	return func(o *planStatsOption) {
+		o.estimate = true
+	}
+}
+
+// PlanStats computes the shards of the query without executing it, the shards of each logical table are sorted
+// by physical database and physical table. Only SELECT, UPDATE and DELETE statements are supported.
+// The shards are computed by the optimizer, so the hints, views and the switch of full-scan take effect.
+func (pi *defaultRuntime) PlanStats(ctx context.Context, sql string, args []proto.Value, options ...PlanStatsOption) ([]*ShardStat, error) {
+	var o planStatsOption
+	for _, it := range options {
+		it(&o)
+	}
+
+	hints, stmt, err := ast.Parse(sql)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+
+	ru := pi.Namespace().Rule()
+	if ru == nil {
+		return nil, nil
+	}
+
+	opt := &optimize.Optimizer{
+		Rule:  ru,
+		Hints: hints,
+		Stmt:  stmt,
+		Args:  args,
+	}
+	results, err := opt.ComputeStatementShards(ctx)
+	if err != nil {
+		return nil, perrors.Wrap(err, "failed to compute shards")
+	}
+
+	var stats []*ShardStat
+	for _, next := range results {
+		next.Shards.Each(func(db string, tables []string) bool {
+			for _, table := range tables {
+				stats = append(stats, &ShardStat{
+					Table:         next.Table,
+					Database:      db,
+					PhysicalTable: table,
+					FullScan:      next.FullScan,
+					EstimatedRows: UnknownRows,
+				})
+			}
+			return true
+		})
+	}
+
+	if o.estimate {
+		pi.estimateRows(ctx, stats)
+	}
+
+	return stats, nil
+}
+
+// estimateRows fills the estimated rows of physical tables, the tables of same database are estimated by one query.
+// The rows remain unknown if the statistics cannot be fetched.
+func (pi *defaultRuntime) estimateRows(ctx context.Context, stats []*ShardStat) {
+	var (
+		dbs    []string
+		groups = make(map[string][]*ShardStat)
+	)
+	for _, it := range stats {
+		if _, ok := groups[it.Database]; !ok {
+			dbs = append(dbs, it.Database)
+		}
+		groups[it.Database] = append(groups[it.Database], it)
+	}
+
+	for _, db := range dbs {
+		tables := make([]string, 0, len(groups[db]))
+		for _, it := range groups[db] {
+			tables = append(tables, it.PhysicalTable)
+		}
+		rows, err := dml.EstimateTableRows(ctx, pi, db, tables)
+		if err != nil {
+			log.Warnf("failed to estimate rows of tables in %s: %v", db, err)
+			continue
+		}
+		for _, it := range groups[db] {
+			if n, ok := rows[it.PhysicalTable]; ok {
+				it.EstimatedRows = n
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/golang/mock/gomock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/namespace"
+	"github.com/arana-db/arana/pkg/runtime/optimize"
+	"github.com/arana-db/arana/testdata"
+)
+
+func TestPlanStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const group = "employees_0000"
+
+	var topo rule.Topology
+	topo.SetRender(func(_ int) string {
+		return group
+	}, func(i int) string {
+		return fmt.Sprintf("student_%04d", i)
+	})
+	topo.SetTopology(0, 0, 1, 2, 3)
+
+	computer := testdata.NewMockShardComputer(ctrl)
+	computer.EXPECT().
+		Compute(gomock.Any()).
+		DoAndReturn(func(value proto.Value) (int, error) {
+			n, err := strconv.Atoi(value.String())
+			if err != nil {
+				return 0, err
+			}
+			return n % 4, nil
+		}).
+		AnyTimes()
+	computer.EXPECT().Variables().Return([]string{"uid"}).AnyTimes()
+
+	var vt rule.VTable
+	vt.SetName("student")
+	vt.SetTopology(&topo)
+	vt.AddVShards(&rule.VShard{
+		Table: &rule.ShardMetadata{
+			ShardColumns: []*rule.ShardColumn{
+				{Name: "uid", Steps: 4, Stepper: rule.Stepper{N: 1, U: rule.Unum}},
+			},
+			Computer: computer,
+		},
+	})
+
+	vt.SetAllowFullScan(true)
+
+	var ru rule.Rule
+	ru.SetVTable("student", &vt)
+
+	fields := []proto.Field{
+		mysql.NewField("TABLE_NAME", consts.FieldTypeVarString),
+		mysql.NewField("TABLE_ROWS", consts.FieldTypeLongLong),
+	}
+
+	var queries int
+	db := testdata.NewMockDB(ctrl)
+	db.EXPECT().ID().Return("primary").AnyTimes()
+	db.EXPECT().Weight().Return(proto.Weight{R: 10, W: 10}).AnyTimes()
+	db.EXPECT().Close().AnyTimes()
+	db.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sql string, args ...proto.Value) (proto.Result, uint16, error) {
+			queries++
+			ds := &dataset.VirtualDataset{Columns: fields}
+			for _, it := range args {
+				table := it.String()
+				// the statistics of student_0002 is missing
+				if table == "student_0002" {
+					continue
+				}
+				n, _ := strconv.Atoi(table[len(table)-1:])
+				ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+					proto.NewValueString(table),
+					proto.NewValueInt64(int64(n * 100)),
+				}))
+			}
+			return resultx.New(resultx.WithDataset(ds)), 0, nil
+		}).
+		AnyTimes()
+
+	ns, err := namespace.New("employees", namespace.UpsertDB(group, db), namespace.UpdateRule(&ru))
+	assert.NoError(t, err)
+	rt := (*defaultRuntime)(ns)
+
+	type tt struct {
+		sql      string
+		args     []proto.Value
+		tables   []string
+		fullScan bool
+	}
+
+	for _, it := range []tt{
+		{"select id from student where uid = 1", nil, []string{"student_0001"}, false},
+		{"select id from student where uid in (?,?)", []proto.Value{proto.NewValueInt64(7), proto.NewValueInt64(2)}, []string{"student_0002", "student_0003"}, false},
+		{"update student set name = 'foo' where uid = 4", nil, []string{"student_0000"}, false},
+		{"delete from student where name = 'foo'", nil, []string{"student_0000", "student_0001", "student_0002", "student_0003"}, true},
+		{"select id from student", nil, []string{"student_0000", "student_0001", "student_0002", "student_0003"}, true},
+		{"/*A! route(employees_0000.student_0002) */ select id from student", nil, []string{"student_0002"}, false},
+		{"select a.id from student a join student b on a.uid = b.uid where a.uid = 1 and b.uid = 2", nil, []string{"student_0001", "student_0002"}, false},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			queries = 0
+			stats, err := rt.PlanStats(context.Background(), it.sql, it.args)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, queries)
+
+			var tables []string
+			for _, next := range stats {
+				assert.Equal(t, "student", next.Table)
+				assert.Equal(t, group, next.Database)
+				assert.Equal(t, it.fullScan, next.FullScan)
+				assert.Equal(t, UnknownRows, next.EstimatedRows)
+				tables = append(tables, next.PhysicalTable)
+			}
+			assert.Equal(t, it.tables, tables)
+		})
+	}
+
+	t.Run("EstimatedRows", func(t *testing.T) {
+		queries = 0
+		stats, err := rt.PlanStats(context.Background(), "select id from student where uid between 1 and 3", nil, WithEstimatedRows())
+		assert.NoError(t, err)
+		// the tables of same database are estimated by one query
+		assert.Equal(t, 1, queries)
+		assert.Len(t, stats, 3)
+
+		assert.Equal(t, "student_0001", stats[0].PhysicalTable)
+		assert.Equal(t, int64(100), stats[0].EstimatedRows)
+		assert.Equal(t, "student_0002", stats[1].PhysicalTable)
+		assert.Equal(t, UnknownRows, stats[1].EstimatedRows)
+		assert.Equal(t, "student_0003", stats[2].PhysicalTable)
+		assert.Equal(t, int64(300), stats[2].EstimatedRows)
+	})
+
+	t.Run("DenyFullScan", func(t *testing.T) {
+		vt.SetAllowFullScan(false)
+		defer vt.SetAllowFullScan(true)
+		_, err := rt.PlanStats(context.Background(), "select id from student", nil)
+		assert.ErrorIs(t, err, optimize.ErrDenyFullScan)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := rt.PlanStats(context.Background(), "insert into student(uid) values(1)", nil)
+		assert.Error(t, err)
+	})
+}
@@ -20,13 +20,20 @@ package runtime
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
 import (
+	"github.com/arana-db/parser/ast"
+
 	"github.com/bwmarrin/snowflake"
 
 	perrors "github.com/pkg/errors"
@@ -39,12 +46,15 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/audit"
 	"github.com/arana-db/arana/pkg/config"
+	mConstants "github.com/arana-db/arana/pkg/constants/mysql"
 	"github.com/arana-db/arana/pkg/metrics"
 	"github.com/arana-db/arana/pkg/mysql"
 	errors2 "github.com/arana-db/arana/pkg/mysql/errors"
 	"github.com/arana-db/arana/pkg/proto"
 	"github.com/arana-db/arana/pkg/proto/hint"
+	"github.com/arana-db/arana/pkg/proto/rule"
 	_ "github.com/arana-db/arana/pkg/runtime/builtin"
 	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	_ "github.com/arana-db/arana/pkg/runtime/function"
@@ -54,6 +64,8 @@ import (
 	_ "github.com/arana-db/arana/pkg/runtime/optimize/ddl"
 	_ "github.com/arana-db/arana/pkg/runtime/optimize/dml"
 	_ "github.com/arana-db/arana/pkg/runtime/optimize/utility"
+	rplan "github.com/arana-db/arana/pkg/runtime/plan"
+	"github.com/arana-db/arana/pkg/util/bytefmt"
 	"github.com/arana-db/arana/pkg/util/log"
 	"github.com/arana-db/arana/pkg/util/rand2"
 	"github.com/arana-db/arana/third_party/pools"
@@ -68,7 +80,22 @@ var (
 
 var Tracer = otel.Tracer("Runtime")
 
-var errTxClosed = errors.New("transaction is closed")
+func init() {
+	namespace.AddRuleListener(onRuleLoaded)
+}
+
+// onRuleLoaded prepares the resources of the rule which depend on the backend databases.
+func onRuleLoaded(ns *namespace.Namespace, ru *rule.Rule) {
+	pi := (*defaultRuntime)(ns)
+	pi.loadKeyFilters(ru)
+	pi.bindKeyLookups(ru)
+}
+
+var (
+	errTxClosed   = errors.New("transaction is closed")
+	errReadOnlyTx = errors2.NewSQLError(mConstants.ERCantExecuteInReadOnlyTx, mConstants.SSCantExecuteInReadOnlyTx,
+		"Cannot execute statement in a READ ONLY transaction.")
+)
 
 // Runtime executes a sql statement.
 type Runtime interface {
@@ -78,6 +105,10 @@ type Runtime interface {
 	Namespace() *namespace.Namespace
 	// Begin begins a new transaction.
 	Begin(ctx context.Context, hooks ...TxHook) (proto.Tx, error)
+	// PlanStats returns the shards which the query will be routed to, without executing it.
+	PlanStats(ctx context.Context, sql string, args []proto.Value, options ...PlanStatsOption) ([]*ShardStat, error)
+	// ShardSkew reports the distribution of rows across the physical tables of a logical table.
+	ShardSkew(ctx context.Context, table string) (*SkewReport, error)
 }
 
 // Register registers a Runtime.
@@ -117,7 +148,7 @@ type AtomDB struct {
 
 	id string
 
-	weight     proto.Weight
+	weight     atomic.Value // proto.Weight, it can be updated at runtime
 	connection proto.NodeConn
 	pool       *pools.ResourcePool
 
@@ -125,6 +156,14 @@ type AtomDB struct {
 
 	pendingRequests atomic.Int64
 
+	// the limits of connection pool, zero means unlimited
+	maxIdle     int64
+	maxLifetime time.Duration
+	waitTimeout time.Duration
+
+	// fail fast if the backend keeps failing, nil means disabled
+	breaker *circuitBreaker
+
 	node *config.Node
 }
 
@@ -147,10 +186,10 @@ func NewAtomDB(node *config.Node) *AtomDB {
 	}
 	db := &AtomDB{
 		id:         node.Name,
-		weight:     proto.Weight{R: int32(r), W: int32(w)},
 		node:       node,
 		connection: connection,
 	}
+	db.weight.Store(proto.Weight{R: int32(r), W: int32(w)})
 
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", node.Username, node.Password, node.Host, node.Port, node.Database, node.Parameters.String())
 	connector, err := mysql.NewConnector(dsn)
@@ -164,13 +203,42 @@ func NewAtomDB(node *config.Node) *AtomDB {
 		idleTime    = config.GetConnPropIdleTime(node.ConnProps, 30*time.Minute)
 	)
 
+	if capacity > maxCapacity {
+		capacity = maxCapacity
+	}
+
+	db.maxIdle = int64(config.GetConnPropMaxIdle(node.ConnProps, 0))
+	db.maxLifetime = config.GetConnPropMaxLifetime(node.ConnProps, 0)
+	db.waitTimeout = config.GetConnPropWaitTimeout(node.ConnProps, 0)
+	db.breaker = newCircuitBreaker(db.id,
+		config.GetConnPropBreakerThreshold(node.ConnProps, 0),
+		config.GetConnPropBreakerOpenTime(node.ConnProps, defaultBreakerOpenTime),
+	)
+
 	db.pool = pools.NewResourcePool(func(ctx context.Context) (pools.Resource, error) {
 		return connector.NewBackendConnection(ctx)
 	}, capacity, maxCapacity, idleTime, 1, nil)
 
+	db.observePool()
+
 	return db
 }
 
+// IsWeightChangedOnly returns true if the node differs from the current node of db in weight only,
+// which means the weight can be updated without rebuilding the connection pool.
+func IsWeightChangedOnly(db proto.DB, node *config.Node) bool {
+	atom, ok := db.(*AtomDB)
+	if !ok || atom.node == nil || node == nil {
+		return false
+	}
+	if atom.node.Weight == node.Weight {
+		return false
+	}
+	prev := *atom.node
+	prev.Weight = node.Weight
+	return reflect.DeepEqual(&prev, node)
+}
+
 func (db *AtomDB) Variable(ctx context.Context, name string) (interface{}, error) {
 	if db.closed.Load() {
 		return nil, perrors.Errorf("the db instance '%s' is closed already", db.id)
@@ -202,15 +270,19 @@ func (db *AtomDB) Variable(ctx context.Context, name string) (interface{}, error
 	return nil, nil
 }
 
-func (db *AtomDB) begin(ctx context.Context, f dbFunc) (*branchTx, error) {
+func (db *AtomDB) begin(ctx context.Context, f dbFunc) (tx *branchTx, err error) {
 	if db.closed.Load() {
 		return nil, perrors.Errorf("the db instance '%s' is closed already", db.id)
 	}
 
-	var (
-		bc  *mysql.BackendConnection
-		err error
-	)
+	if err = db.breaker.allow(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		db.breaker.done(err)
+	}()
+
+	var bc *mysql.BackendConnection
 
 	if bc, err = db.borrowConnection(ctx); err != nil {
 		return nil, perrors.WithStack(err)
@@ -272,6 +344,13 @@ func (db *AtomDB) Call(ctx context.Context, sql string, args ...proto.Value) (re
 		return
 	}
 
+	if err = db.breaker.allow(); err != nil {
+		return
+	}
+	defer func() {
+		db.breaker.done(err)
+	}()
+
 	var bc *mysql.BackendConnection
 
 	if bc, err = db.borrowConnection(ctx); err != nil {
@@ -281,7 +360,7 @@ func (db *AtomDB) Call(ctx context.Context, sql string, args ...proto.Value) (re
 
 	undoPending := db.pending()
 
-	if err = bc.SyncVariables(rcontext.TransientVariables(ctx)); err != nil {
+	if err = bc.SyncVariables(rcontext.UpstreamVariables(ctx)); err != nil {
 		undoPending()
 		db.returnConnection(bc)
 		return
@@ -344,7 +423,8 @@ func (db *AtomDB) Capacity() int {
 }
 
 func (db *AtomDB) Weight() proto.Weight {
-	return db.weight
+	weight, _ := db.weight.Load().(proto.Weight)
+	return weight
 }
 
 func (db *AtomDB) NodeConn() proto.NodeConn {
@@ -366,26 +446,62 @@ func (db *AtomDB) SetIdleTimeout(idleTimeout time.Duration) error {
 }
 
 func (db *AtomDB) SetWeight(weight proto.Weight) error {
-	db.weight = weight
+	db.weight.Store(weight)
 	return nil
 }
 
+// borrowConnection borrows a connection from pool, it waits until a connection is returned if the pool is saturated,
+// and fails if no connection is available in the wait timeout.
 func (db *AtomDB) borrowConnection(ctx context.Context) (*mysql.BackendConnection, error) {
+	if db.waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, db.waitTimeout)
+		defer cancel()
+	}
+
 	bcp := (*BackendResourcePool)(db.pool)
-	//var (
-	//	active0, available0 = db.pool.Active(), db.pool.Available()
-	//)
-	res, err := bcp.Get(ctx)
-	// log.Infof("^^^^^ borrow conn: %d/%d => %d/%d", available0, active0, db.pool.Active(), db.pool.Available())
-	if err != nil {
-		return nil, perrors.WithStack(err)
+	start := time.Now()
+	for {
+		res, err := bcp.Get(ctx)
+		if err != nil {
+			metrics.BackendPoolWaitDuration.WithLabelValues(db.id).Observe(time.Since(start).Seconds())
+			if errors.Is(err, pools.ErrTimeout) || errors.Is(err, pools.ErrCtxTimeout) {
+				metrics.BackendPoolExhausted.WithLabelValues(db.id).Inc()
+				return nil, perrors.Wrapf(err, "the connection pool of db instance '%s' is exhausted: in_use=%d, max_open=%d",
+					db.id, db.pool.InUse(), db.pool.MaxCap())
+			}
+			return nil, perrors.WithStack(err)
+		}
+		// the expired connection is closed, and a new one will be created by the next round
+		if db.isExpired(res) {
+			db.pool.Discard(res)
+			continue
+		}
+		metrics.BackendPoolWaitDuration.WithLabelValues(db.id).Observe(time.Since(start).Seconds())
+		db.observePool()
+		return res, nil
 	}
-	return res, nil
 }
 
 func (db *AtomDB) returnConnection(bc *mysql.BackendConnection) {
-	db.pool.Put(bc)
-	// log.Infof("^^^^^ return conn: active=%d, available=%d", db.pool.Active(), db.pool.Available())
+	// the idle connections which exceed the limit are closed, instead of being kept in the pool
+	if bc != nil && (db.isExpired(bc) || db.maxIdle > 0 && db.pool.Active()-db.pool.InUse() >= db.maxIdle) {
+		db.pool.Discard(bc)
+	} else {
+		db.pool.Put(bc)
+	}
+	db.observePool()
+}
+
+func (db *AtomDB) isExpired(bc *mysql.BackendConnection) bool {
+	return db.maxLifetime > 0 && time.Since(bc.CreatedAt()) > db.maxLifetime
+}
+
+func (db *AtomDB) observePool() {
+	inUse := db.pool.InUse()
+	metrics.BackendPoolInUse.WithLabelValues(db.id).Set(float64(inUse))
+	metrics.BackendPoolIdle.WithLabelValues(db.id).Set(float64(db.pool.Active() - inUse))
+	metrics.BackendPoolMaxOpen.WithLabelValues(db.id).Set(float64(db.pool.MaxCap()))
 }
 
 type defaultRuntime namespace.Namespace
@@ -435,16 +551,30 @@ func (pi *defaultRuntime) Execute(ctx *proto.Context) (res proto.Result, warn ui
 	ctx.Context, span = Tracer.Start(ctx.Context, "defaultRuntime.Execute")
 	span.SetAttributes(attribute.Key("sql").String(ctx.GetQuery()))
 	execStart := time.Now()
+	ns := pi.Namespace()
+	auditing := audit.Enabled()
+	if ns.SlowThreshold() != 0 || auditing {
+		ctx.Context = rcontext.WithExecStats(ctx.Context)
+	}
 	defer func() {
 		span.End()
 		since := time.Since(execStart)
 		metrics.ExecuteDuration.Observe(since.Seconds())
-		if pi.Namespace().SlowThreshold() != 0 && since > pi.Namespace().SlowThreshold() {
-			pi.Namespace().SlowLogger().Warnf("slow logs elapsed %v sql %s", since, ctx.GetQuery())
+		if ns.SlowThreshold() != 0 && since > ns.SlowThreshold() && rand2.Float64() < ns.SlowSampleRate() {
+			logSlowQuery(ctx, ns.SlowLogger(), since)
+		}
+		if auditing {
+			auditStatement(ctx, execStart, err)
 		}
 	}()
 	args := ctx.GetArgs()
 
+	ctx.Context = rcontext.WithWarnings(ctx.Context)
+	defer func() {
+		warn += saveWarnings(ctx)
+	}()
+	ctx.Context = rcontext.WithStartTime(ctx.Context, execStart)
+
 	if rcontext.IsDirect(ctx.Context) {
 		return pi.callDirect(ctx, args)
 	}
@@ -454,12 +584,19 @@ func (pi *defaultRuntime) Execute(ctx *proto.Context) (res proto.Result, warn ui
 		plan proto.Plan
 	)
 
-	ctx.Context = rcontext.WithHints(ctx.Context, ctx.Stmt.Hints)
+	var hints []*hint.Hint
+	if hints, err = withSessionHint(ctx, ctx.Stmt.Hints); err != nil {
+		return
+	}
+	hints = withoutReplicaHint(ctx, hints, false)
+
+	ctx.Context = rcontext.WithHints(ctx.Context, hints)
+	ctx.Context = rcontext.WithMemoryLimit(ctx.Context, queryMemoryLimit(ctx, ns.QueryMemoryLimit()))
 
 	start := time.Now()
 
 	var opt proto.Optimizer
-	if opt, err = optimize.NewOptimizer(ru, ctx.Stmt.Hints, ctx.Stmt.StmtNode, args); err != nil {
+	if opt, err = optimize.NewOptimizer(ru, hints, ctx.Stmt.StmtNode, args); err != nil {
 		err = perrors.WithStack(err)
 		return
 	}
@@ -470,7 +607,7 @@ func (pi *defaultRuntime) Execute(ctx *proto.Context) (res proto.Result, warn ui
 	}
 	metrics.OptimizeDuration.Observe(time.Since(start).Seconds())
 
-	if res, err = plan.ExecIn(ctx, pi); err != nil {
+	if res, err = pi.execPlan(ctx, plan); err != nil {
 		// TODO: how to warp error packet
 		if sqlErr, ok := perrors.Cause(err).(*errors2.SQLError); ok {
 			err = sqlErr
@@ -483,6 +620,45 @@ func (pi *defaultRuntime) Execute(ctx *proto.Context) (res proto.Result, warn ui
 	return
 }
 
+// execPlan executes the plan, a temporary transaction will be used if the plan is transactional.
+func (pi *defaultRuntime) execPlan(ctx context.Context, plan proto.Plan) (proto.Result, error) {
+	if tp, ok := plan.(rplan.Transactional); !ok || !tp.Transactional() {
+		return plan.ExecIn(ctx, pi)
+	}
+
+	tx, err := pi.Begin(ctx)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+
+	res, err := plan.ExecIn(ctx, tx)
+	if err != nil {
+		if _, _, rerr := tx.Rollback(ctx); rerr != nil {
+			log.Errorf("failed to rollback transaction %s: %v", tx.ID(), rerr)
+		}
+		return nil, err
+	}
+
+	if _, _, err = tx.Commit(ctx); err != nil {
+		return nil, perrors.WithStack(err)
+	}
+
+	return res, nil
+}
+
+// saveWarnings saves the warnings of current statement into session, they can be fetched by 'SHOW WARNINGS'.
+func saveWarnings(ctx *proto.Context) uint16 {
+	if ctx.C == nil {
+		return 0
+	}
+	if stmt, ok := ctx.Stmt.StmtNode.(*ast.ShowStmt); ok && stmt.Tp == ast.ShowWarnings {
+		return 0
+	}
+	warnings := rcontext.Warnings(ctx)
+	ctx.C.SetWarnings(warnings)
+	return uint16(len(warnings))
+}
+
 func (pi *defaultRuntime) callDirect(ctx *proto.Context, args []proto.Value) (res proto.Result, warn uint16, err error) {
 	res, warn, err = pi.Namespace().DB0(ctx.Context).Call(rcontext.WithWrite(ctx.Context), ctx.GetQuery(), args...)
 	if err != nil {
@@ -495,15 +671,142 @@ func (pi *defaultRuntime) callDirect(ctx *proto.Context, args []proto.Value) (re
 func (pi *defaultRuntime) call(ctx context.Context, group, query string, args ...proto.Value) (proto.Result, error) {
 	db := selectDB(ctx, group, pi.Namespace())
 	if db == nil {
+		if name, ok := getReplicaName(ctx); ok && rcontext.IsRead(ctx) {
+			return nil, perrors.Errorf("cannot get replica %s of upstream database %s", name, group)
+		}
 		return nil, perrors.Errorf("cannot get upstream database %s", group)
 	}
 	log.Debugf("call upstream: db=%s, id=%s, sql=\"%s\", args=%v", group, db.ID(), query, args)
+
+	start := time.Now()
+	defer func() {
+		rcontext.AddShardTiming(ctx, group, query, time.Since(start))
+	}()
+
 	// TODO: how to pass warn???
 	res, _, err := db.Call(ctx, query, args...)
+	if err == nil || !rcontext.IsRead(ctx) || !rcontext.IsIdempotent(ctx) {
+		return res, err
+	}
+
+	// failover: retry the idempotent read request on another healthy node, or on the same node after
+	// a backoff if the error is transient, eg: deadlock
+	var (
+		tried   = []proto.DB{db}
+		backoff = pi.Namespace().ReadRetryBackoff()
+	)
+	for i := 0; i < pi.Namespace().ReadRetries() && isFailoverable(ctx, err); i++ {
+		prev := tried[len(tried)-1]
+		next := selectFailoverDB(ctx, group, pi.Namespace(), tried)
+		if next == nil {
+			if !isTransientErr(err) || !waitBackoff(ctx, backoff) {
+				break
+			}
+			log.Warnf("retry upstream: db=%s, id=%s, backoff=%s, err=%v", group, prev.ID(), backoff, err)
+			next = prev
+			backoff *= 2
+		} else {
+			log.Warnf("failover upstream: db=%s, from=%s, to=%s, err=%v", group, prev.ID(), next.ID(), err)
+			metrics.FailoverCount.WithLabelValues(group).Inc()
+			tried = append(tried, next)
+		}
+
+		if res, _, err = next.Call(ctx, query, args...); err == nil {
+			return res, nil
+		}
+	}
 
 	return res, err
 }
 
+// isFailoverable returns true if the error is caused by the upstream node rather than the sql itself.
+// The request won't be retried if it is canceled or timeout.
+func isFailoverable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if perrors.Is(err, context.Canceled) || perrors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// the error returned by mysql server, nothing will be changed if retry it, except the transient ones
+	if _, ok := perrors.Cause(err).(*errors2.SQLError); ok {
+		return isTransientErr(err)
+	}
+	return true
+}
+
+// isTransientErr returns true if the error may disappear by retrying, eg: deadlock, lock wait timeout or broken connection.
+func isTransientErr(err error) bool {
+	if sqlErr, ok := perrors.Cause(err).(*errors2.SQLError); ok {
+		switch sqlErr.Number() {
+		case mConstants.ERLockDeadlock, mConstants.ERLockWaitTimeout, mConstants.CRServerGone, mConstants.CRServerLost, mConstants.CRConnHostError:
+			return true
+		}
+		return false
+	}
+	return perrors.Is(err, driver.ErrBadConn) ||
+		perrors.Is(err, io.ErrUnexpectedEOF) ||
+		perrors.Is(err, syscall.ECONNRESET)
+}
+
+// waitBackoff waits for the backoff, false will be returned if there is no time left before the deadline of context.
+func waitBackoff(ctx context.Context, backoff time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+		return false
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// selectFailoverDB selects an untried node, the replicas are preferred, then the primary.
+func selectFailoverDB(ctx context.Context, group string, ns *namespace.Namespace, tried []proto.DB) proto.DB {
+	var hintType hint.Type
+	for _, v := range rcontext.Hints(ctx) {
+		if v.Type == hint.TypeMaster || v.Type == hint.TypeSlave || v.Type == hint.TypeReplica {
+			hintType = v.Type
+			break
+		}
+	}
+	// the primary or the named replica is required, no other choice
+	if hintType == hint.TypeMaster || hintType == hint.TypeReplica {
+		return nil
+	}
+
+	isTried := func(db proto.DB) bool {
+		for _, it := range tried {
+			if it.ID() == db.ID() {
+				return true
+			}
+		}
+		return false
+	}
+
+	var primary proto.DB
+	for _, db := range ns.DBs(group) {
+		if isTried(db) {
+			continue
+		}
+		w := db.Weight()
+		if w.W == 0 && w.R > 0 {
+			return db
+		}
+		if w.W > 0 && primary == nil {
+			primary = db
+		}
+	}
+
+	if hintType == hint.TypeSlave {
+		return nil
+	}
+	return primary
+}
+
 // select db by group
 func selectDB(ctx context.Context, group string, ns *namespace.Namespace) proto.DB {
 	if len(group) < 1 { // empty db, select first
@@ -523,7 +826,7 @@ func selectDB(ctx context.Context, group string, ns *namespace.Namespace) proto.
 	// extracts hints
 	hints := rcontext.Hints(ctx)
 	for _, v := range hints {
-		if v.Type == hint.TypeMaster || v.Type == hint.TypeSlave {
+		if v.Type == hint.TypeMaster || v.Type == hint.TypeSlave || v.Type == hint.TypeReplica {
 			hintType = v.Type
 			break
 		}
@@ -533,12 +836,91 @@ func selectDB(ctx context.Context, group string, ns *namespace.Namespace) proto.
 		db = ns.DBMaster(ctx, group)
 	case hint.TypeSlave:
 		db = ns.DBSlave(ctx, group)
+	case hint.TypeReplica:
+		name, _ := getReplicaName(ctx)
+		db = ns.DBReplica(ctx, group, name)
 	default:
 		db = ns.DB(ctx, group)
 	}
 	return db
 }
 
+// withSessionHint merges the hint set by 'SET arana_hint = <hint>' into the inline hints of statement,
+// the inline hints take precedence.
+func withSessionHint(ctx context.Context, hints []*hint.Hint) ([]*hint.Hint, error) {
+	h, err := rcontext.SessionHint(ctx)
+	if err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	if h == nil {
+		return hints, nil
+	}
+	return hint.Merge(append([]*hint.Hint(nil), hints...), h), nil
+}
+
+// withoutReplicaHint drops the REPLICA hint with a warning unless the statement is a non-transactional read,
+// the writes and the reads in a transaction are always routed to the transactional nodes.
+func withoutReplicaHint(ctx *proto.Context, hints []*hint.Hint, inTx bool) []*hint.Hint {
+	var replica *hint.Hint
+	for _, v := range hints {
+		if v.Type == hint.TypeReplica {
+			replica = v
+			break
+		}
+	}
+	if replica == nil {
+		return hints
+	}
+
+	switch ctx.Stmt.StmtNode.(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt:
+		if !inTx {
+			return hints
+		}
+		rcontext.AddWarning(ctx, mConstants.ERUnknownError, "hint %s is ignored in a transaction", replica)
+	default:
+		rcontext.AddWarning(ctx, mConstants.ERUnknownError, "hint %s is ignored, only the reads can be routed to a replica", replica)
+	}
+
+	ret := make([]*hint.Hint, 0, len(hints)-1)
+	for _, v := range hints {
+		if v != replica {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// queryMemoryLimit returns the memory limit of MEMORYLIMIT hint, eg: MEMORYLIMIT(64MB), or the default limit.
+func queryMemoryLimit(ctx context.Context, limit int64) int64 {
+	for _, v := range rcontext.Hints(ctx) {
+		if v.Type != hint.TypeMemoryLimit || len(v.Inputs) < 1 {
+			continue
+		}
+		if n, err := bytefmt.ToBytes(v.Inputs[0].V); err == nil {
+			return int64(n)
+		}
+		log.Warnf("invalid memory limit of hint %s", v)
+	}
+	return limit
+}
+
+// getReplicaName returns the replica name of REPLICA hint, eg: REPLICA(name=replica_2) or REPLICA(replica_2).
+func getReplicaName(ctx context.Context) (string, bool) {
+	for _, v := range rcontext.Hints(ctx) {
+		if v.Type != hint.TypeReplica {
+			continue
+		}
+		for _, in := range v.Inputs {
+			if len(in.K) < 1 || strings.EqualFold(in.K, "name") {
+				return in.V, true
+			}
+		}
+		return "", true
+	}
+	return "", false
+}
+
 var (
 	_txIds     *snowflake.Node
 	_txIdsOnce sync.Once
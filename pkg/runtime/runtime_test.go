@@ -18,18 +18,30 @@
 package runtime
 
 import (
+	"context"
+	"io"
 	"sync"
 	"testing"
+	"time"
 )
 
 import (
+	"github.com/arana-db/parser"
+
 	"github.com/golang/mock/gomock"
 
 	"github.com/stretchr/testify/assert"
 )
 
 import (
+	"github.com/arana-db/arana/pkg/config"
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	mysqlErrors "github.com/arana-db/arana/pkg/mysql/errors"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/hint"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/namespace"
+	"github.com/arana-db/arana/testdata"
 )
 
 func TestLoad(t *testing.T) {
@@ -73,3 +85,254 @@ func TestNextTxID(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestFailover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const group = "employees_0000"
+
+	newDB := func(id string, weight proto.Weight, err error) *testdata.MockDB {
+		db := testdata.NewMockDB(ctrl)
+		db.EXPECT().ID().Return(id).AnyTimes()
+		db.EXPECT().Weight().Return(weight).AnyTimes()
+		db.EXPECT().Close().AnyTimes()
+		if err != nil {
+			db.EXPECT().Call(gomock.Any(), gomock.Any()).Return(nil, uint16(0), err).AnyTimes()
+		} else {
+			db.EXPECT().Call(gomock.Any(), gomock.Any()).Return(testdata.NewMockResult(ctrl), uint16(0), nil).AnyTimes()
+		}
+		return db
+	}
+
+	newRuntime := func(dbs ...proto.DB) *defaultRuntime {
+		cmds := make([]namespace.Command, 0, len(dbs))
+		for _, db := range dbs {
+			cmds = append(cmds, namespace.UpsertDB(group, db))
+		}
+		ns, err := namespace.New("employees", cmds...)
+		assert.NoError(t, err)
+		return (*defaultRuntime)(ns)
+	}
+
+	// the replica with zero read weight is always selected first with SLAVE hint
+	ctx := rcontext.WithHints(context.Background(), []*hint.Hint{{Type: hint.TypeSlave}})
+	broken := func() proto.DB {
+		return newDB("replica-broken", proto.Weight{R: 0, W: 0}, io.EOF)
+	}
+
+	t.Run("FailoverToReplica", func(t *testing.T) {
+		rt := newRuntime(broken(), newDB("replica-ok", proto.Weight{R: 10, W: 0}, nil))
+		res, err := rt.Query(rcontext.WithIdempotent(ctx), group, "select 1")
+		assert.NoError(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("NoReplicaLeft", func(t *testing.T) {
+		// the primary won't be chosen with SLAVE hint
+		rt := newRuntime(broken(), newDB("primary", proto.Weight{R: 10, W: 10}, nil))
+		_, err := rt.Query(rcontext.WithIdempotent(ctx), group, "select 1")
+		assert.ErrorIs(t, err, io.EOF)
+
+		res, err := rt.Query(rcontext.WithIdempotent(context.Background()), group, "select 1")
+		assert.NoError(t, err)
+		assert.NotNil(t, res)
+	})
+
+	t.Run("NotIdempotent", func(t *testing.T) {
+		rt := newRuntime(broken(), newDB("replica-ok", proto.Weight{R: 10, W: 0}, nil))
+		_, err := rt.Query(ctx, group, "select 1")
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("SQLError", func(t *testing.T) {
+		sqlErr := mysqlErrors.NewSQLError(consts.ERNoSuchTable, consts.SSNoTableSelected, "Table 'foo' doesn't exist")
+		rt := newRuntime(
+			newDB("replica-broken", proto.Weight{R: 0, W: 0}, sqlErr),
+			newDB("replica-ok", proto.Weight{R: 10, W: 0}, nil),
+		)
+		_, err := rt.Query(rcontext.WithIdempotent(ctx), group, "select * from foo")
+		assert.ErrorIs(t, err, sqlErr)
+	})
+
+	t.Run("TransientSQLError", func(t *testing.T) {
+		// no other node, the transient error is retried on the same node
+		newDeadlockDB := func() proto.DB {
+			deadlock := mysqlErrors.NewSQLError(consts.ERLockDeadlock, consts.SSLockDeadlock, "Deadlock found when trying to get lock")
+			db := testdata.NewMockDB(ctrl)
+			db.EXPECT().ID().Return("replica-deadlock").AnyTimes()
+			db.EXPECT().Weight().Return(proto.Weight{R: 0, W: 0}).AnyTimes()
+			db.EXPECT().Close().AnyTimes()
+			gomock.InOrder(
+				db.EXPECT().Call(gomock.Any(), gomock.Any()).Return(nil, uint16(0), deadlock),
+				db.EXPECT().Call(gomock.Any(), gomock.Any()).Return(testdata.NewMockResult(ctrl), uint16(0), nil).AnyTimes(),
+			)
+			return db
+		}
+
+		rt := newRuntime(newDeadlockDB())
+		res, err := rt.Query(rcontext.WithIdempotent(ctx), group, "select 1")
+		assert.NoError(t, err)
+		assert.NotNil(t, res)
+
+		// no time left for the backoff
+		rt = newRuntime(newDeadlockDB())
+		timeout, cancel := context.WithTimeout(rcontext.WithIdempotent(ctx), time.Millisecond)
+		defer cancel()
+		_, err = rt.Query(timeout, group, "select 1")
+		assert.Error(t, err)
+	})
+}
+
+func TestReplicaHint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const group = "employees_0000"
+
+	newDB := func(id string, weight proto.Weight, err error, times int) *testdata.MockDB {
+		db := testdata.NewMockDB(ctrl)
+		db.EXPECT().ID().Return(id).AnyTimes()
+		db.EXPECT().Weight().Return(weight).AnyTimes()
+		db.EXPECT().Close().AnyTimes()
+		if err != nil {
+			db.EXPECT().Call(gomock.Any(), gomock.Any()).Return(nil, uint16(0), err).Times(times)
+		} else {
+			db.EXPECT().Call(gomock.Any(), gomock.Any()).Return(testdata.NewMockResult(ctrl), uint16(0), nil).Times(times)
+		}
+		return db
+	}
+
+	ns, err := namespace.New(
+		"employees",
+		namespace.UpsertDB(group, newDB("primary", proto.Weight{R: 10, W: 10}, nil, 0)),
+		namespace.UpsertDB(group, newDB("replica_1", proto.Weight{R: 10, W: 0}, nil, 0)),
+		namespace.UpsertDB(group, newDB("replica_2", proto.Weight{R: 0, W: 0}, nil, 5)),
+		namespace.UpsertDB(group, newDB("replica_3", proto.Weight{R: 10, W: 0}, io.EOF, 1)),
+	)
+	assert.NoError(t, err)
+	rt := (*defaultRuntime)(ns)
+
+	withReplica := func(name string) context.Context {
+		h, err := hint.Parse("replica(name=" + name + ")")
+		assert.NoError(t, err)
+		return rcontext.WithIdempotent(rcontext.WithHints(context.Background(), []*hint.Hint{h}))
+	}
+
+	// the drained replica can be reached explicitly
+	for i := 0; i < 5; i++ {
+		res, err := rt.Query(withReplica("replica_2"), group, "select 1")
+		assert.NoError(t, err)
+		assert.NotNil(t, res)
+	}
+
+	// the primary is not a replica
+	_, err = rt.Query(withReplica("primary"), group, "select 1")
+	assert.Error(t, err)
+
+	_, err = rt.Query(withReplica("not_exist"), group, "select 1")
+	assert.Error(t, err)
+
+	// the request won't be failover to other nodes if the named replica is down
+	_, err = rt.Query(withReplica("replica_3"), group, "select 1")
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestFailover_DrainedReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const group = "employees_0000"
+
+	newDB := func(id string, weight proto.Weight) *testdata.MockDB {
+		db := testdata.NewMockDB(ctrl)
+		db.EXPECT().ID().Return(id).AnyTimes()
+		db.EXPECT().Weight().Return(weight).AnyTimes()
+		db.EXPECT().Close().AnyTimes()
+		return db
+	}
+
+	broken := newDB("replica-broken", proto.Weight{R: 10, W: 0})
+	broken.EXPECT().Call(gomock.Any(), gomock.Any()).Return(nil, uint16(0), io.EOF).AnyTimes()
+	// the drained replica is neither routed to nor failed over to
+	drained := newDB("replica-drained", proto.Weight{R: 0, W: 0})
+	drained.EXPECT().Call(gomock.Any(), gomock.Any()).Times(0)
+	primary := newDB("primary", proto.Weight{R: 0, W: 10})
+	primary.EXPECT().Call(gomock.Any(), gomock.Any()).Return(testdata.NewMockResult(ctrl), uint16(0), nil).AnyTimes()
+
+	ns, err := namespace.New("employees",
+		namespace.UpsertDB(group, broken),
+		namespace.UpsertDB(group, drained),
+		namespace.UpsertDB(group, primary),
+	)
+	assert.NoError(t, err)
+	rt := (*defaultRuntime)(ns)
+
+	for i := 0; i < 20; i++ {
+		res, err := rt.Query(rcontext.WithIdempotent(context.Background()), group, "select 1")
+		assert.NoError(t, err)
+		assert.NotNil(t, res)
+	}
+}
+
+func TestWithoutReplicaHint(t *testing.T) {
+	h, err := hint.Parse("replica(name=replica_2)")
+	assert.NoError(t, err)
+
+	for _, it := range []struct {
+		sql  string
+		inTx bool
+		keep bool
+	}{
+		{"select * from employees", false, true},
+		{"select * from a union select * from b", false, true},
+		{"select * from employees", true, false},
+		{"insert into employees values (1)", false, false},
+		{"update employees set name = 'foo'", false, false},
+		{"delete from employees", true, false},
+	} {
+		t.Run(it.sql, func(t *testing.T) {
+			stmt, err := parser.New().ParseOneStmt(it.sql, "", "")
+			assert.NoError(t, err)
+
+			ctx := &proto.Context{
+				Context: rcontext.WithWarnings(context.Background()),
+				Stmt:    &proto.Stmt{StmtNode: stmt},
+			}
+			hints := withoutReplicaHint(ctx, []*hint.Hint{h}, it.inTx)
+			if it.keep {
+				assert.Len(t, hints, 1)
+				assert.Empty(t, rcontext.Warnings(ctx))
+			} else {
+				assert.Empty(t, hints)
+				assert.Len(t, rcontext.Warnings(ctx), 1)
+			}
+		})
+	}
+}
+
+func TestIsWeightChangedOnly(t *testing.T) {
+	node := &config.Node{
+		Name:     "node0",
+		Host:     "127.0.0.1",
+		Port:     3306,
+		Username: "root",
+		Password: "123456",
+		Database: "employees_0000",
+		Weight:   "r10w0",
+	}
+	db := NewAtomDB(node)
+	assert.Equal(t, proto.Weight{R: 10, W: 0}, db.Weight())
+
+	next := *node
+	assert.False(t, IsWeightChangedOnly(db, &next))
+
+	next.Weight = "r0w0"
+	assert.True(t, IsWeightChangedOnly(db, &next))
+
+	next.Port = 3307
+	assert.False(t, IsWeightChangedOnly(db, &next))
+
+	assert.NoError(t, db.SetWeight(proto.Weight{R: 0, W: 0}))
+	assert.Equal(t, proto.Weight{R: 0, W: 0}, db.Weight())
+}
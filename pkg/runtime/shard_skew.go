@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"math"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// SkewReport represents the distribution of rows across the physical tables of a logical table,
+// the physical tables whose rows are unknown are excluded from the statistics.
+type SkewReport struct {
+	Table  string       // the logical table name
+	Shards []*ShardStat // sorted by physical database and physical table
+	Min    int64        // the minimum rows per physical table
+	Max    int64        // the maximum rows per physical table
+	Mean   float64      // the average rows per physical table
+	Stddev float64      // the population standard deviation of rows per physical table
+}
+
+// Ratio returns the ratio of maximum rows to average rows, 1 means the rows are distributed evenly.
+// It returns 0 if no rows are estimated.
+func (sr *SkewReport) Ratio() float64 {
+	if sr.Mean == 0 {
+		return 0
+	}
+	return float64(sr.Max) / sr.Mean
+}
+
+// ShardSkew samples the rows of all physical tables of the logical table by the statistics of
+// 'information_schema.TABLES', and reports how evenly the rows are distributed.
+// It helps to find the hot shards which need rebalancing.
+func (pi *defaultRuntime) ShardSkew(ctx context.Context, table string) (*SkewReport, error) {
+	ru := pi.Namespace().Rule()
+	if ru == nil {
+		return nil, perrors.Errorf("no such sharding table '%s'", table)
+	}
+	vt, ok := ru.VTable(table)
+	if !ok {
+		return nil, perrors.Errorf("no such sharding table '%s'", table)
+	}
+
+	ret := &SkewReport{
+		Table: vt.Name(),
+	}
+	vt.Topology().Enumerate().Each(func(db string, tables []string) bool {
+		for _, it := range tables {
+			ret.Shards = append(ret.Shards, &ShardStat{
+				Table:         vt.Name(),
+				Database:      db,
+				PhysicalTable: it,
+				FullScan:      true,
+				EstimatedRows: UnknownRows,
+			})
+		}
+		return true
+	})
+
+	pi.estimateRows(ctx, ret.Shards)
+
+	var (
+		n   int
+		sum float64
+	)
+	for _, it := range ret.Shards {
+		if it.EstimatedRows == UnknownRows {
+			continue
+		}
+		if n == 0 || it.EstimatedRows < ret.Min {
+			ret.Min = it.EstimatedRows
+		}
+		if n == 0 || it.EstimatedRows > ret.Max {
+			ret.Max = it.EstimatedRows
+		}
+		sum += float64(it.EstimatedRows)
+		n++
+	}
+	if n == 0 {
+		return ret, nil
+	}
+
+	ret.Mean = sum / float64(n)
+	var variance float64
+	for _, it := range ret.Shards {
+		if it.EstimatedRows == UnknownRows {
+			continue
+		}
+		d := float64(it.EstimatedRows) - ret.Mean
+		variance += d * d
+	}
+	ret.Stddev = math.Sqrt(variance / float64(n))
+
+	return ret, nil
+}
@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/golang/mock/gomock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	consts "github.com/arana-db/arana/pkg/constants/mysql"
+	"github.com/arana-db/arana/pkg/dataset"
+	"github.com/arana-db/arana/pkg/mysql"
+	"github.com/arana-db/arana/pkg/mysql/rows"
+	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/rule"
+	"github.com/arana-db/arana/pkg/resultx"
+	"github.com/arana-db/arana/pkg/runtime/namespace"
+	"github.com/arana-db/arana/testdata"
+)
+
+func TestShardSkew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var topo rule.Topology
+	topo.SetRender(func(i int) string {
+		return fmt.Sprintf("employees_%04d", i)
+	}, func(i int) string {
+		return fmt.Sprintf("student_%04d", i)
+	})
+	topo.SetTopology(0, 0, 1)
+	topo.SetTopology(1, 2, 3)
+
+	var vt rule.VTable
+	vt.SetName("student")
+	vt.SetTopology(&topo)
+
+	var ru rule.Rule
+	ru.SetVTable("student", &vt)
+
+	fields := []proto.Field{
+		mysql.NewField("TABLE_NAME", consts.FieldTypeVarString),
+		mysql.NewField("TABLE_ROWS", consts.FieldTypeLongLong),
+	}
+
+	newDB := func(id string) proto.DB {
+		db := testdata.NewMockDB(ctrl)
+		db.EXPECT().ID().Return(id).AnyTimes()
+		db.EXPECT().Weight().Return(proto.Weight{R: 10, W: 10}).AnyTimes()
+		db.EXPECT().Close().AnyTimes()
+		db.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, sql string, args ...proto.Value) (proto.Result, uint16, error) {
+				ds := &dataset.VirtualDataset{Columns: fields}
+				for _, it := range args {
+					table := it.String()
+					// the statistics of student_0002 is missing
+					if table == "student_0002" {
+						continue
+					}
+					n, _ := strconv.Atoi(table[len(table)-1:])
+					ds.Rows = append(ds.Rows, rows.NewTextVirtualRow(fields, []proto.Value{
+						proto.NewValueString(table),
+						proto.NewValueInt64(int64(n*200 + 100)),
+					}))
+				}
+				return resultx.New(resultx.WithDataset(ds)), 0, nil
+			}).
+			AnyTimes()
+		return db
+	}
+
+	ns, err := namespace.New("employees",
+		namespace.UpsertDB("employees_0000", newDB("employees_0000")),
+		namespace.UpsertDB("employees_0001", newDB("employees_0001")),
+		namespace.UpdateRule(&ru),
+	)
+	assert.NoError(t, err)
+	rt := (*defaultRuntime)(ns)
+
+	report, err := rt.ShardSkew(context.Background(), "student")
+	assert.NoError(t, err)
+	assert.Equal(t, "student", report.Table)
+	assert.Len(t, report.Shards, 4)
+
+	var tables []string
+	for _, it := range report.Shards {
+		tables = append(tables, it.Database+"."+it.PhysicalTable)
+	}
+	assert.Equal(t, []string{
+		"employees_0000.student_0000",
+		"employees_0000.student_0001",
+		"employees_0001.student_0002",
+		"employees_0001.student_0003",
+	}, tables)
+	assert.Equal(t, UnknownRows, report.Shards[2].EstimatedRows)
+
+	// rows: 100, 300, 700
+	assert.Equal(t, int64(100), report.Min)
+	assert.Equal(t, int64(700), report.Max)
+	assert.InDelta(t, 366.67, report.Mean, 0.01)
+	assert.InDelta(t, math.Sqrt((266.67*266.67+66.67*66.67+333.33*333.33)/3), report.Stddev, 0.01)
+	assert.InDelta(t, 1.91, report.Ratio(), 0.01)
+
+	_, err = rt.ShardSkew(context.Background(), "not_exists")
+	assert.Error(t, err)
+}
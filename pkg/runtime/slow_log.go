@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/arana-db/arana/pkg/proto"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
+	"github.com/arana-db/arana/pkg/util/log"
+)
+
+// logSlowQuery writes the slow query with the execution stats, eg: the elapsed time of each shard,
+// which is useful for diagnosing the queries fanned out to lots of shards.
+func logSlowQuery(ctx *proto.Context, logger log.Logger, elapsed time.Duration) {
+	if logger == nil {
+		return
+	}
+
+	var (
+		sb      strings.Builder
+		timings = rcontext.ShardTimings(ctx)
+	)
+	for i, it := range timings {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&sb, "{db=%s elapsed=%v sql=%s}", it.DB, it.Elapsed, it.SQL)
+	}
+
+	logger.Warnf("slow logs elapsed %v sql %s shards %d full_scan %v shard_timings [%s]",
+		elapsed, ctx.GetQuery(), len(timings), rcontext.IsFullScan(ctx), sb.String())
+}
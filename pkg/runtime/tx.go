@@ -35,9 +35,11 @@ import (
 )
 
 import (
+	"github.com/arana-db/arana/pkg/audit"
 	"github.com/arana-db/arana/pkg/metrics"
 	"github.com/arana-db/arana/pkg/mysql"
 	"github.com/arana-db/arana/pkg/proto"
+	"github.com/arana-db/arana/pkg/proto/hint"
 	"github.com/arana-db/arana/pkg/resultx"
 	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	_ "github.com/arana-db/arana/pkg/runtime/function"
@@ -132,14 +134,19 @@ type (
 
 func newCompositeTx(ctx context.Context, pi *defaultRuntime, hooks ...TxHook) *compositeTx {
 	tx := &compositeTx{
-		tenant: rcontext.Tenant(ctx),
-		id:     gtid.NewID(),
-		rt:     pi,
-		txs:    make(map[string]*branchTx),
-		hooks:  hooks,
-		beginFunc: func(ctx context.Context, bc *mysql.BackendConnection) (proto.Result, error) {
-			return bc.ExecuteWithWarningCount("begin", true)
-		},
+		tenant:   rcontext.Tenant(ctx),
+		id:       gtid.NewID(),
+		rt:       pi,
+		txs:      make(map[string]*branchTx),
+		hooks:    hooks,
+		readOnly: rcontext.TxReadOnly(ctx),
+	}
+
+	tx.beginFunc = func(ctx context.Context, bc *mysql.BackendConnection) (proto.Result, error) {
+		if tx.readOnly {
+			return bc.ExecuteWithWarningCount("start transaction read only", true)
+		}
+		return bc.ExecuteWithWarningCount("begin", true)
 	}
 
 	tx.setTxState(ctx, TrxActive)
@@ -157,6 +164,7 @@ type compositeTx struct {
 
 	isoLevel sql.IsolationLevel
 	txState  TxState
+	readOnly bool
 
 	beginFunc dbFunc
 
@@ -187,6 +195,9 @@ func (tx *compositeTx) Query(ctx context.Context, db string, query string, args
 }
 
 func (tx *compositeTx) Exec(ctx context.Context, db string, query string, args ...proto.Value) (proto.Result, error) {
+	if tx.readOnly {
+		return nil, errReadOnlyTx
+	}
 	return tx.call(ctx, db, query, args...)
 }
 
@@ -202,7 +213,9 @@ func (tx *compositeTx) call(ctx context.Context, db string, query string, args .
 
 	log.Debugf("call upstream: db=%s, sql=\"%s\", args=%v", db, query, args)
 
+	start := time.Now()
 	res, _, err := atx.Call(ctx, query, args...)
+	rcontext.AddShardTiming(ctx, db, query, time.Since(start))
 	if err != nil {
 		return nil, perrors.WithStack(err)
 	}
@@ -214,8 +227,12 @@ func (tx *compositeTx) begin(ctx context.Context, group string) (*branchTx, erro
 		return exist, nil
 	}
 
-	// force use writeable node
-	ctx = rcontext.WithWrite(ctx)
+	// force use writeable node, the reads of READ ONLY transaction can be routed to replicas
+	if tx.readOnly {
+		ctx = rcontext.WithRead(ctx)
+	} else {
+		ctx = rcontext.WithWrite(ctx)
+	}
 	db := selectDB(ctx, group, tx.rt.Namespace())
 	if db == nil {
 		return nil, perrors.Errorf("cannot get upstream database %s", group)
@@ -238,9 +255,16 @@ func (tx *compositeTx) Execute(ctx *proto.Context) (res proto.Result, warn uint1
 	var span trace.Span
 	ctx.Context, span = Tracer.Start(ctx.Context, "compositeTx.Execute")
 	execStart := time.Now()
+	auditing := audit.Enabled()
+	if auditing {
+		ctx.Context = rcontext.WithExecStats(ctx.Context)
+	}
 	defer func() {
 		span.End()
 		metrics.ExecuteDuration.Observe(time.Since(execStart).Seconds())
+		if auditing {
+			auditStatement(ctx, execStart, err)
+		}
 	}()
 	if tx.closed.Load() {
 		err = errTxClosed
@@ -248,6 +272,13 @@ func (tx *compositeTx) Execute(ctx *proto.Context) (res proto.Result, warn uint1
 	}
 
 	args := ctx.GetArgs()
+
+	ctx.Context = rcontext.WithWarnings(ctx.Context)
+	defer func() {
+		warn += saveWarnings(ctx)
+	}()
+	ctx.Context = rcontext.WithStartTime(ctx.Context, execStart)
+
 	if direct := rcontext.IsDirect(ctx.Context); direct {
 		var (
 			group = tx.rt.Namespace().DBGroups()[0]
@@ -269,10 +300,17 @@ func (tx *compositeTx) Execute(ctx *proto.Context) (res proto.Result, warn uint1
 		plan proto.Plan
 	)
 
-	ctx.Context = rcontext.WithHints(ctx.Context, ctx.Stmt.Hints)
+	var hints []*hint.Hint
+	if hints, err = withSessionHint(ctx, ctx.Stmt.Hints); err != nil {
+		return
+	}
+	hints = withoutReplicaHint(ctx, hints, true)
+
+	ctx.Context = rcontext.WithHints(ctx.Context, hints)
+	ctx.Context = rcontext.WithMemoryLimit(ctx.Context, queryMemoryLimit(ctx, tx.rt.Namespace().QueryMemoryLimit()))
 
 	var opt proto.Optimizer
-	if opt, err = optimize.NewOptimizer(ru, ctx.Stmt.Hints, ctx.Stmt.StmtNode, args); err != nil {
+	if opt, err = optimize.NewOptimizer(ru, hints, ctx.Stmt.StmtNode, args); err != nil {
 		err = perrors.WithStack(err)
 		return
 	}
@@ -34,6 +34,7 @@ import (
 import (
 	"github.com/arana-db/arana/pkg/mysql"
 	"github.com/arana-db/arana/pkg/proto"
+	rcontext "github.com/arana-db/arana/pkg/runtime/context"
 	"github.com/arana-db/arana/pkg/runtime/gtid"
 )
 
@@ -132,3 +133,21 @@ func Test_compositeTx_Rollback(t *testing.T) {
 		})
 	}
 }
+
+func Test_compositeTx_ReadOnly(t *testing.T) {
+	variables := map[string]proto.Value{
+		"@@" + rcontext.VarTxReadOnlyOneShot: proto.NewValueInt64(1),
+	}
+	ctx := context.WithValue(context.Background(), proto.ContextKeyTransientVariables{}, variables)
+
+	tx := newCompositeTx(ctx, &defaultRuntime{})
+	assert.True(t, tx.readOnly)
+	_, err := tx.Exec(ctx, "employees_0000", "update student set score = 100")
+	assert.ErrorContains(t, err, "READ ONLY transaction")
+
+	// the characteristic of next transaction is kept until it is consumed
+	assert.True(t, newCompositeTx(ctx, &defaultRuntime{}).readOnly)
+	rcontext.ConsumeNextTxCharacteristics(ctx)
+	assert.False(t, newCompositeTx(ctx, &defaultRuntime{}).readOnly)
+	assert.True(t, newCompositeTx(rcontext.WithTxReadOnly(ctx), &defaultRuntime{}).readOnly)
+}
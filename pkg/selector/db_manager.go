@@ -18,5 +18,6 @@
 package selector
 
 type Selector interface {
+	// GetDataSourceNo returns the index of selected datasource, returns -1 if nothing can be selected.
 	GetDataSourceNo() int
 }
@@ -40,6 +40,10 @@ func init() {
 
 func (w weightRandom) GetDataSourceNo() int {
 	areaSize := len(w.weightAreaEnds)
+	// all datasources are drained, eg: the weights are all zero
+	if areaSize == 0 || w.weightAreaEnds[areaSize-1] <= 0 {
+		return -1
+	}
 	randSeed := rand.Intn(w.weightAreaEnds[areaSize-1])
 	for i := 0; i < areaSize; i++ {
 		if randSeed < w.weightAreaEnds[i] {
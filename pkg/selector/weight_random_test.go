@@ -31,3 +31,17 @@ func TestGetDatasourceNo(t *testing.T) {
 		t.Errorf("No.%d invalid", no)
 	}
 }
+
+func TestGetDatasourceNo_Drained(t *testing.T) {
+	// the datasource with zero weight should never be selected
+	selector := NewWeightRandomSelector([]int{0, 5, 0})
+	for i := 0; i < 100; i++ {
+		if no := selector.GetDataSourceNo(); no != 1 {
+			t.Errorf("No.%d invalid", no)
+		}
+	}
+
+	if no := NewWeightRandomSelector([]int{0, 0}).GetDataSourceNo(); no != -1 {
+		t.Errorf("No.%d invalid", no)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package bloom provides a concurrent-safe bloom filter.
+//
+// A bloom filter answers "definitely not exists" or "may exist", it never reports false negatives.
+// The accuracy/memory tradeoff for n keys with false positive rate p:
+//
+//	bits   m = -n*ln(p)/(ln2)^2
+//	hashes k = m/n*ln2
+//
+// For example, 1,000,000 keys need about 1.14MiB with p=0.01 (k=7), or 1.71MiB with p=0.001 (k=10).
+// The false positive rate grows quickly once more than n keys are added, so n should be estimated generously.
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+import (
+	"github.com/cespare/xxhash/v2"
+)
+
+// DefaultFalsePositiveRate is the default false positive rate.
+const DefaultFalsePositiveRate = 0.01
+
+// Filter represents a bloom filter.
+type Filter struct {
+	mu   sync.RWMutex
+	bits []uint64
+	m    uint64 // amount of bits
+	k    uint64 // amount of hash functions
+}
+
+// New creates a bloom filter which is expected to hold n keys with the false positive rate p.
+func New(n uint64, p float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = DefaultFalsePositiveRate
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &Filter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// Add adds a key into the filter.
+func (f *Filter) Add(key []byte) {
+	h1, h2 := hash(key)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		f.bits[pos>>6] |= 1 << (pos & 63)
+	}
+}
+
+// AddString adds a string key into the filter.
+func (f *Filter) AddString(key string) {
+	f.Add([]byte(key))
+}
+
+// Test returns false if the key definitely doesn't exist, true if it may exist.
+func (f *Filter) Test(key []byte) bool {
+	h1, h2 := hash(key)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos>>6]&(1<<(pos&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestString tests a string key.
+func (f *Filter) TestString(key string) bool {
+	return f.Test([]byte(key))
+}
+
+// Bits returns the amount of bits.
+func (f *Filter) Bits() uint64 {
+	return f.m
+}
+
+// Hashes returns the amount of hash functions.
+func (f *Filter) Hashes() uint64 {
+	return f.k
+}
+
+// hash computes two hashes for double hashing, see "Less Hashing, Same Performance: Building a Better Bloom Filter".
+func hash(key []byte) (uint64, uint64) {
+	h1 := xxhash.Sum64(key)
+
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], h1)
+	h2 := xxhash.Sum64(b[:]) | 1 // keep odd, avoid the same position for all hash functions
+	return h1, h2
+}
@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bloom
+
+import (
+	"strconv"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	const n = 10000
+
+	f := New(n, 0.01)
+	assert.Equal(t, uint64(7), f.Hashes())
+	// about 9.6 bits per key
+	assert.InDelta(t, 95851, f.Bits(), 1)
+
+	for i := 0; i < n; i++ {
+		f.AddString(strconv.Itoa(i))
+	}
+
+	// no false negatives
+	for i := 0; i < n; i++ {
+		assert.True(t, f.TestString(strconv.Itoa(i)))
+	}
+
+	var fp int
+	for i := n; i < 2*n; i++ {
+		if f.TestString(strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	t.Logf("false positive rate: %.4f", float64(fp)/n)
+	assert.Less(t, fp, n/50)
+}
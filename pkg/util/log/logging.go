@@ -50,6 +50,7 @@ const (
 	LogicalSqlLog  = LogType("logical sql")
 	PhysicalSqlLog = LogType("physical sql")
 	TxLog          = LogType("tx")
+	AuditLog       = LogType("audit")
 )
 
 const (
@@ -57,6 +58,7 @@ const (
 	_sqlLogName     = "sql.log"
 	_txLogName      = "tx.log"
 	_phySqlLogName  = "physql.log"
+	_auditLogName   = "audit.log"
 )
 
 type Config struct {
@@ -245,6 +247,19 @@ func NewSlowLogger(logPath string, cfg *Config) *zap.SugaredLogger {
 	return zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2)).Sugar()
 }
 
+// NewAuditLogger creates a logger which writes the audit records as JSON lines into the file 'audit.log' of logPath.
+func NewAuditLogger(logPath string, cfg *Config) *zap.Logger {
+	syncer := zapcore.AddSync(buildLumberJack(logPath, AuditLog, cfg))
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.LevelKey = zapcore.OmitKey
+	encoderConfig.CallerKey = zapcore.OmitKey
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), syncer, zap.NewAtomicLevelAt(zap.InfoLevel))
+	return zap.New(core)
+}
+
 //nolint:staticcheck
 func buildLumberJack(logPath string, logType LogType, cfg *Config) *lumberjack.Logger {
 	var logName string
@@ -270,6 +285,8 @@ func buildLumberJack(logPath string, logType LogType, cfg *Config) *lumberjack.L
 		logName = _phySqlLogName
 	case TxLog:
 		logName = _txLogName
+	case AuditLog:
+		logName = _auditLogName
 	}
 
 	filename := filepath.Clean(filepath.Join(logPath, logName))
@@ -148,6 +148,30 @@ func (mr *MockFrontConnMockRecorder) SetTransientVariables(arg0 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTransientVariables", reflect.TypeOf((*MockFrontConn)(nil).SetTransientVariables), arg0)
 }
 
+// SetUsername mocks base method.
+func (m *MockFrontConn) SetUsername(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetUsername", arg0)
+}
+
+// SetUsername indicates an expected call of SetUsername.
+func (mr *MockFrontConnMockRecorder) SetUsername(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUsername", reflect.TypeOf((*MockFrontConn)(nil).SetUsername), arg0)
+}
+
+// SetWarnings mocks base method.
+func (m *MockFrontConn) SetWarnings(arg0 []*proto.Warning) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWarnings", arg0)
+}
+
+// SetWarnings indicates an expected call of SetWarnings.
+func (mr *MockFrontConnMockRecorder) SetWarnings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWarnings", reflect.TypeOf((*MockFrontConn)(nil).SetWarnings), arg0)
+}
+
 // Tenant mocks base method.
 func (m *MockFrontConn) Tenant() string {
 	m.ctrl.T.Helper()
@@ -175,3 +199,31 @@ func (mr *MockFrontConnMockRecorder) TransientVariables() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransientVariables", reflect.TypeOf((*MockFrontConn)(nil).TransientVariables))
 }
+
+// Username mocks base method.
+func (m *MockFrontConn) Username() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Username")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Username indicates an expected call of Username.
+func (mr *MockFrontConnMockRecorder) Username() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Username", reflect.TypeOf((*MockFrontConn)(nil).Username))
+}
+
+// Warnings mocks base method.
+func (m *MockFrontConn) Warnings() []*proto.Warning {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Warnings")
+	ret0, _ := ret[0].([]*proto.Warning)
+	return ret0
+}
+
+// Warnings indicates an expected call of Warnings.
+func (mr *MockFrontConnMockRecorder) Warnings() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnings", reflect.TypeOf((*MockFrontConn)(nil).Warnings))
+}
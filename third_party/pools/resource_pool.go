@@ -287,6 +287,22 @@ func (rp *ResourcePool) Put(resource Resource) {
 	rp.available.Add(1)
 }
 
+// Discard closes the resource and returns an empty slot to the pool. Unlike Put(nil), no new resource
+// will be created in its place until the next Get, which is useful to shrink the idle resources.
+func (rp *ResourcePool) Discard(resource Resource) {
+	if resource != nil {
+		resource.Close()
+		rp.active.Add(-1)
+	}
+	select {
+	case rp.resources <- resourceWrapper{}:
+	default:
+		panic(errors.New("attempt to Discard into a full ResourcePool"))
+	}
+	rp.inUse.Add(-1)
+	rp.available.Add(1)
+}
+
 func (rp *ResourcePool) reopenResource(wrapper *resourceWrapper) {
 	if r, err := rp.factory(context.TODO()); err == nil {
 		wrapper.resource = r
@@ -689,3 +689,40 @@ func TestExpired(t *testing.T) {
 		t.Errorf("got %v, want %s", err, want)
 	}
 }
+
+func TestDiscard(t *testing.T) {
+	ctx := context.Background()
+	lastID.Store(0)
+	count.Store(0)
+	p := NewResourcePool(PoolFactory, 2, 2, time.Second, 0, nil)
+	defer p.Close()
+
+	r, err := p.Get(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if p.Active() != 1 || p.InUse() != 1 {
+		t.Errorf("expecting 1/1, received %d/%d", p.Active(), p.InUse())
+	}
+
+	p.Discard(r)
+	if !r.(*TestResource).closed {
+		t.Errorf("expecting the resource is closed")
+	}
+	if p.Active() != 0 || p.InUse() != 0 || p.Available() != 2 {
+		t.Errorf("expecting 0/0/2, received %d/%d/%d", p.Active(), p.InUse(), p.Available())
+	}
+	if count.Load() != 0 {
+		t.Errorf("Expecting 0, received %d", count.Load())
+	}
+
+	// a new resource will be created lazily
+	r, err = p.Get(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if r.(*TestResource).num != 2 {
+		t.Errorf("Expecting 2, received %d", r.(*TestResource).num)
+	}
+	p.Put(r)
+}